@@ -77,83 +77,88 @@ func (abi ABI) Pack(name string, args ...interface{}) ([]byte, error) {
 	return append(method.Id(), arguments...), nil
 }
 
-// toGoSliceType prses the input and casts it to the proper slice defined by the ABI
-// argument in T.
-func toGoSlice(i int, t Argument, output []byte) (interface{}, error) {
-	index := i * 32
-	// The slice must, at very least be large enough for the index+32 which is exactly the size required
-	// for the [offset in output, size of offset].
-	if index+32 > len(output) {
-		return nil, fmt.Errorf("abi: cannot marshal in to go slice: insufficient size output %d require %d", len(output), index+32)
-	}
-	elem := t.Type.Elem
-
-	// first we need to create a slice of the type
-	var refSlice reflect.Value
-	switch elem.T {
-	case IntTy, UintTy, BoolTy: // int, uint, bool can all be of type big int.
-		refSlice = reflect.ValueOf([]*big.Int(nil))
-	case AddressTy: // address must be of slice Address
-		refSlice = reflect.ValueOf([]common.Address(nil))
-	case HashTy: // hash must be of slice hash
-		refSlice = reflect.ValueOf([]common.Hash(nil))
-	case FixedBytesTy:
-		refSlice = reflect.ValueOf([]byte(nil))
-	default: // no other types are supported
-		return nil, fmt.Errorf("abi: unsupported slice type %v", elem.T)
+// lengthPrefixPointsTo interprets the word at the given index as the offset of
+// a length prefixed value, returning where its content starts and its length.
+func lengthPrefixPointsTo(index int, output []byte) (start int, length int, err error) {
+	offset, err := offsetPointsTo(index, output)
+	if err != nil {
+		return 0, 0, err
 	}
-	// get the offset which determines the start of this array ...
-	offset := int(new(big.Int).SetBytes(output[index : index+32]).Uint64())
 	if offset+32 > len(output) {
-		return nil, fmt.Errorf("abi: cannot marshal in to go slice: offset %d would go over slice boundary (len=%d)", len(output), offset+32)
+		return 0, 0, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), offset+32)
 	}
+	size := new(big.Int).SetBytes(output[offset : offset+32])
+	if size.BitLen() > 31 || offset+32+int(size.Int64()) > len(output) {
+		return 0, 0, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %v", len(output), new(big.Int).Add(size, big.NewInt(int64(offset+32))))
+	}
+	return offset + 32, int(size.Int64()), nil
+}
 
-	slice := output[offset:]
-	// ... starting with the size of the array in elements ...
-	size := int(new(big.Int).SetBytes(slice[:32]).Uint64())
-	slice = slice[32:]
-	// ... and make sure that we've at the very least the amount of bytes
-	// available in the buffer.
-	if size*32 > len(slice) {
-		return nil, fmt.Errorf("abi: cannot marshal in to go slice: insufficient size output %d require %d", len(output), offset+32+size*32)
+// offsetPointsTo interprets the word at the given index as the offset of a value
+// encoded out of place, checking that it lies within the output.
+func offsetPointsTo(index int, output []byte) (int, error) {
+	offset := new(big.Int).SetBytes(output[index : index+32])
+	if offset.BitLen() > 31 || int(offset.Int64()) > len(output) {
+		return 0, fmt.Errorf("abi: cannot marshal in to go type: offset %v would go over slice boundary (len=%d)", offset, len(output))
 	}
+	return int(offset.Int64()), nil
+}
 
-	// reslice to match the required size
-	slice = slice[:(size * 32)]
+// forEachUnpack unpacks size consecutive elements of the array or slice type t
+// from the output, returning them as a Go array or slice.
+func forEachUnpack(t Type, output []byte, size int) (interface{}, error) {
+	elemSize := t.Elem.headSize()
+	if size*elemSize > len(output) {
+		return nil, fmt.Errorf("abi: cannot marshal in to go array: length insufficient %d require %d", len(output), size*elemSize)
+	}
+	var refSlice reflect.Value
+	if t.T == SliceTy {
+		refSlice = reflect.MakeSlice(t.reflectType(), size, size)
+	} else {
+		refSlice = reflect.New(t.reflectType()).Elem()
+	}
 	for i := 0; i < size; i++ {
-		var (
-			inter        interface{}             // interface type
-			returnOutput = slice[i*32 : i*32+32] // the return output
-		)
-
-		// set inter to the correct type (cast)
-		switch elem.T {
-		case IntTy, UintTy:
-			inter = new(big.Int).SetBytes(returnOutput)
-		case BoolTy:
-			inter = new(big.Int).SetBytes(returnOutput).Uint64() > 0
-		case AddressTy:
-			inter = common.BytesToAddress(returnOutput)
-		case HashTy:
-			inter = common.BytesToHash(returnOutput)
+		inter, err := toGoElem(i*elemSize, *t.Elem, output)
+		if err != nil {
+			return nil, err
 		}
-		// append the item to our reflect slice
-		refSlice = reflect.Append(refSlice, reflect.ValueOf(inter))
+		refSlice.Index(i).Set(reflect.ValueOf(inter))
 	}
-
-	// return the interface
 	return refSlice.Interface(), nil
 }
 
-// toGoType parses the input and casts it to the proper type defined by the ABI
-// argument in T.
-func toGoType(i int, t Argument, output []byte) (interface{}, error) {
-	// we need to treat slices differently
-	if (t.Type.IsSlice || t.Type.IsArray) && t.Type.T != BytesTy && t.Type.T != StringTy && t.Type.T != FixedBytesTy {
-		return toGoSlice(i, t, output)
+// forTupleUnpack unpacks the components of the tuple type t from the output,
+// returning them as a Go struct.
+func forTupleUnpack(t Type, output []byte) (interface{}, error) {
+	retval := reflect.New(t.reflectType()).Elem()
+
+	index := 0
+	for i, elem := range t.TupleElems {
+		inter, err := toGoElem(index, *elem, output)
+		if err != nil {
+			return nil, err
+		}
+		retval.Field(i).Set(reflect.ValueOf(inter))
+		index += elem.headSize()
+	}
+	return retval.Interface(), nil
+}
+
+// toGoElem parses a value nested within an array or a tuple. Contrary to values
+// at the top level, fixed bytes are returned as a byte array of their exact size.
+func toGoElem(index int, t Type, output []byte) (interface{}, error) {
+	inter, err := toGoType(index, t, output)
+	if err != nil || t.T != FixedBytesTy {
+		return inter, err
 	}
+	array := reflect.New(t.reflectType()).Elem()
+	reflect.Copy(array, reflect.ValueOf(inter))
+	return array.Interface(), nil
+}
 
-	index := i * 32
+// toGoType parses the output at the given byte index and casts it to the proper
+// type defined by the ABI type in t.
+func toGoType(index int, t Type, output []byte) (interface{}, error) {
 	if index+32 > len(output) {
 		return nil, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), index+32)
 	}
@@ -161,33 +166,46 @@ func toGoType(i int, t Argument, output []byte) (interface{}, error) {
 	// Parse the given index output and check whether we need to read
 	// a different offset and length based on the type (i.e. string, bytes)
 	var returnOutput []byte
-	switch t.Type.T {
-	case StringTy, BytesTy: // variable arrays are written at the end of the return bytes
-		// parse offset from which we should start reading
-		offset := int(new(big.Int).SetBytes(output[index : index+32]).Uint64())
-		if offset+32 > len(output) {
-			return nil, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), offset+32)
+	switch t.T {
+	case SliceTy:
+		begin, size, err := lengthPrefixPointsTo(index, output)
+		if err != nil {
+			return nil, err
 		}
-		// parse the size up until we should be reading
-		size := int(new(big.Int).SetBytes(output[offset : offset+32]).Uint64())
-		if offset+32+size > len(output) {
-			return nil, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), offset+32+size)
+		return forEachUnpack(t, output[begin:], size)
+	case ArrayTy, TupleTy:
+		// static arrays and tuples are encoded in place, dynamic ones at an offset
+		begin := index
+		if t.isDynamic() {
+			offset, err := offsetPointsTo(index, output)
+			if err != nil {
+				return nil, err
+			}
+			begin = offset
+		}
+		if t.T == ArrayTy {
+			return forEachUnpack(t, output[begin:], t.SliceSize)
+		}
+		return forTupleUnpack(t, output[begin:])
+	case StringTy, BytesTy: // variable arrays are written at the end of the return bytes
+		begin, size, err := lengthPrefixPointsTo(index, output)
+		if err != nil {
+			return nil, err
 		}
-
 		// get the bytes for this return value
-		returnOutput = output[offset+32 : offset+32+size]
+		returnOutput = output[begin : begin+size]
 	default:
 		returnOutput = output[index : index+32]
 	}
 
 	// convert the bytes to whatever is specified by the ABI.
-	switch t.Type.T {
+	switch t.T {
 	case IntTy, UintTy:
 		bigNum := new(big.Int).SetBytes(returnOutput)
 
 		// If the type is a integer convert to the integer type
 		// specified by the ABI.
-		switch t.Type.Kind {
+		switch t.Kind {
 		case reflect.Uint8:
 			return uint8(bigNum.Uint64()), nil
 		case reflect.Uint16:
@@ -218,7 +236,35 @@ func toGoType(i int, t Argument, output []byte) (interface{}, error) {
 	case StringTy:
 		return string(returnOutput), nil
 	}
-	return nil, fmt.Errorf("abi: unknown type %v", t.Type.T)
+	return nil, fmt.Errorf("abi: unknown type %v", t.T)
+}
+
+// unpackArguments parses the output of a sequence of arguments, returning the
+// values each of them is cast to.
+func unpackArguments(args []Argument, output []byte) ([]interface{}, error) {
+	values := make([]interface{}, len(args))
+
+	index := 0
+	for i, arg := range args {
+		value, err := toGoType(index, arg.Type, output)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+		index += arg.Type.headSize()
+	}
+	return values, nil
+}
+
+// toGoTopic parses an indexed event argument from its log topic and casts it to
+// the proper type defined by the ABI argument in T. Dynamic types, arrays and
+// tuples are logged as the hash of their encoding, which is returned as is.
+func toGoTopic(t Argument, topic common.Hash) (interface{}, error) {
+	switch t.Type.T {
+	case StringTy, BytesTy, SliceTy, ArrayTy, TupleTy:
+		return topic, nil
+	}
+	return toGoType(0, t.Type, topic[:])
 }
 
 // these variable are used to determine certain types during type assertion for
@@ -247,17 +293,18 @@ func (abi ABI) Unpack(v interface{}, name string, output []byte) error {
 		typ   = value.Type()
 	)
 
+	marshalledValues, err := unpackArguments(method.Outputs, output)
+	if err != nil {
+		return err
+	}
+
 	if len(method.Outputs) > 1 {
 		switch value.Kind() {
 		// struct will match named return values to the struct's field
 		// names
 		case reflect.Struct:
 			for i := 0; i < len(method.Outputs); i++ {
-				marshalledValue, err := toGoType(i, method.Outputs[i], output)
-				if err != nil {
-					return err
-				}
-				reflectValue := reflect.ValueOf(marshalledValue)
+				reflectValue := reflect.ValueOf(marshalledValues[i])
 
 				for j := 0; j < typ.NumField(); j++ {
 					field := typ.Field(j)
@@ -281,11 +328,7 @@ func (abi ABI) Unpack(v interface{}, name string, output []byte) error {
 				}
 
 				for i := 0; i < len(method.Outputs); i++ {
-					marshalledValue, err := toGoType(i, method.Outputs[i], output)
-					if err != nil {
-						return err
-					}
-					reflectValue := reflect.ValueOf(marshalledValue)
+					reflectValue := reflect.ValueOf(marshalledValues[i])
 					if err := set(value.Index(i).Elem(), reflectValue, method.Outputs[i]); err != nil {
 						return err
 					}
@@ -297,11 +340,7 @@ func (abi ABI) Unpack(v interface{}, name string, output []byte) error {
 			// values to the new interface slice.
 			z := reflect.MakeSlice(typ, 0, len(method.Outputs))
 			for i := 0; i < len(method.Outputs); i++ {
-				marshalledValue, err := toGoType(i, method.Outputs[i], output)
-				if err != nil {
-					return err
-				}
-				z = reflect.Append(z, reflect.ValueOf(marshalledValue))
+				z = reflect.Append(z, reflect.ValueOf(marshalledValues[i]))
 			}
 			value.Set(z)
 		default:
@@ -309,11 +348,7 @@ func (abi ABI) Unpack(v interface{}, name string, output []byte) error {
 		}
 
 	} else {
-		marshalledValue, err := toGoType(0, method.Outputs[0], output)
-		if err != nil {
-			return err
-		}
-		if err := set(value, reflect.ValueOf(marshalledValue), method.Outputs[0]); err != nil {
+		if err := set(value, reflect.ValueOf(marshalledValues[0]), method.Outputs[0]); err != nil {
 			return err
 		}
 	}
@@ -321,14 +356,79 @@ func (abi ABI) Unpack(v interface{}, name string, output []byte) error {
 	return nil
 }
 
+// UnpackLog unpacks a log emitted by the named event in to v, which must be a
+// pointer to a struct. Non-indexed arguments are decoded from the log data and
+// indexed ones from its topics, each matched to the struct field carrying the
+// capitalised argument name (Arg0, Arg1, ... for anonymous arguments).
+//
+// Indexed arguments of dynamic types (strings, bytes and slices) are logged as
+// the Keccak256 hash of their content only, and are unpacked as a common.Hash.
+func (abi ABI) UnpackLog(v interface{}, name string, topics []common.Hash, data []byte) error {
+	event, ok := abi.Events[name]
+	if !ok {
+		return fmt.Errorf("abi: event '%s' not found", name)
+	}
+	// make sure the passed value is a pointer to a struct
+	valueOf := reflect.ValueOf(v)
+	if reflect.Ptr != valueOf.Kind() || reflect.Struct != valueOf.Elem().Kind() {
+		return fmt.Errorf("abi: UnpackLog(non-struct-pointer %T)", v)
+	}
+	value := valueOf.Elem()
+
+	// Non-anonymous events carry their signature as the first topic, check it
+	if !event.Anonymous {
+		if len(topics) == 0 || topics[0] != event.Id() {
+			return fmt.Errorf("abi: log is not a '%s' event", name)
+		}
+		topics = topics[1:]
+	}
+	// Decode all the non-indexed arguments from the log data at once
+	var plainArgs []Argument
+	for _, input := range event.Inputs {
+		if !input.Indexed {
+			plainArgs = append(plainArgs, input)
+		}
+	}
+	plainValues, err := unpackArguments(plainArgs, data)
+	if err != nil {
+		return err
+	}
+	var indexed, plain int
+	for i, input := range event.Inputs {
+		var marshalledValue interface{}
+		if input.Indexed {
+			if indexed >= len(topics) {
+				return fmt.Errorf("abi: cannot unpack event '%s': insufficient topics %d require %d", name, len(topics), indexed+1)
+			}
+			if marshalledValue, err = toGoTopic(input, topics[indexed]); err != nil {
+				return err
+			}
+			indexed++
+		} else {
+			marshalledValue = plainValues[plain]
+			plain++
+		}
+		fieldName := fmt.Sprintf("Arg%d", i)
+		if input.Name != "" {
+			fieldName = strings.ToUpper(input.Name[:1]) + input.Name[1:]
+		}
+		if field := value.FieldByName(fieldName); field.IsValid() && field.CanSet() {
+			if err := set(field, reflect.ValueOf(marshalledValue), input); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (abi *ABI) UnmarshalJSON(data []byte) error {
 	var fields []struct {
-		Type     string
-		Name     string
-		Constant bool
-		Indexed  bool
-		Inputs   []Argument
-		Outputs  []Argument
+		Type      string
+		Name      string
+		Constant  bool
+		Anonymous bool
+		Inputs    []Argument
+		Outputs   []Argument
 	}
 
 	if err := json.Unmarshal(data, &fields); err != nil {
@@ -353,8 +453,9 @@ func (abi *ABI) UnmarshalJSON(data []byte) error {
 			}
 		case "event":
 			abi.Events[field.Name] = Event{
-				Name:   field.Name,
-				Inputs: field.Inputs,
+				Name:      field.Name,
+				Anonymous: field.Anonymous,
+				Inputs:    field.Inputs,
 			}
 		}
 	}
@@ -103,6 +103,23 @@ func TestTypeCheck(t *testing.T) {
 	}
 }
 
+func TestNewTypeErrors(t *testing.T) {
+	for i, test := range []struct {
+		typ string
+		err string
+	}{
+		{"uint8[-1]", "abi: invalid array size -1"},
+		{"uint8[0]", "abi: invalid array size 0"},
+		{"uint8[2][0]", "abi: invalid array size 0"},
+		{"uint8[0][2]", "abi: invalid array size 0"},
+		{"uint8[x]", `abi: error parsing array size: strconv.Atoi: parsing "x": invalid syntax`},
+	} {
+		if _, err := NewType(test.typ); err == nil || err.Error() != test.err {
+			t.Errorf("%d failed. Expected err: '%v' got err: '%v'", i, test.err, err)
+		}
+	}
+}
+
 func TestSimpleMethodUnpack(t *testing.T) {
 	for i, test := range []struct {
 		def              string      // definition of the **output** ABI params
@@ -357,8 +374,6 @@ func TestMethodPack(t *testing.T) {
 	}
 
 	sig := abi.Methods["slice"].Id()
-	sig = append(sig, common.LeftPadBytes([]byte{32}, 32)...)
-	sig = append(sig, common.LeftPadBytes([]byte{2}, 32)...)
 	sig = append(sig, common.LeftPadBytes([]byte{1}, 32)...)
 	sig = append(sig, common.LeftPadBytes([]byte{2}, 32)...)
 
@@ -406,8 +421,6 @@ func TestMethodPack(t *testing.T) {
 	}
 
 	sig = abi.Methods["slice256"].Id()
-	sig = append(sig, common.LeftPadBytes([]byte{32}, 32)...)
-	sig = append(sig, common.LeftPadBytes([]byte{2}, 32)...)
 	sig = append(sig, common.LeftPadBytes([]byte{1}, 32)...)
 	sig = append(sig, common.LeftPadBytes([]byte{2}, 32)...)
 
@@ -1150,3 +1163,152 @@ func TestUnmarshal(t *testing.T) {
 		t.Fatal("expected error:", err)
 	}
 }
+
+func TestNestedArrays(t *testing.T) {
+	const definition = `[
+	{ "name" : "g", "constant" : false, "inputs": [ { "name": "a", "type": "uint256[][]" }, { "name": "b", "type": "string[]" } ], "outputs": [ { "name": "a", "type": "uint256[][]" }, { "name": "b", "type": "string[]" } ] },
+	{ "name" : "pairs", "constant" : false, "inputs": [ { "name": "a", "type": "uint8[2][]" } ], "outputs": [ { "name": "a", "type": "uint8[2][]" } ] }]`
+
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Encoding taken from the example in the Solidity ABI specification
+	exp := common.Hex2Bytes("2289b18c" +
+		"0000000000000000000000000000000000000000000000000000000000000040" +
+		"0000000000000000000000000000000000000000000000000000000000000140" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000040" +
+		"00000000000000000000000000000000000000000000000000000000000000a0" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"0000000000000000000000000000000000000000000000000000000000000060" +
+		"00000000000000000000000000000000000000000000000000000000000000a0" +
+		"00000000000000000000000000000000000000000000000000000000000000e0" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"6f6e650000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"74776f0000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000005" +
+		"7468726565000000000000000000000000000000000000000000000000000000")
+
+	a := [][]*big.Int{{big.NewInt(1), big.NewInt(2)}, {big.NewInt(3)}}
+	b := []string{"one", "two", "three"}
+
+	packed, err := abi.Pack("g", a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(packed, exp) {
+		t.Fatalf("expected %x got %x", exp, packed)
+	}
+	var out struct {
+		A [][]*big.Int
+		B []string
+	}
+	if err := abi.Unpack(&out, "g", packed[4:]); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out.A, a) {
+		t.Errorf("expected %v got %v", a, out.A)
+	}
+	if !reflect.DeepEqual(out.B, b) {
+		t.Errorf("expected %v got %v", b, out.B)
+	}
+
+	// Static arrays nested in a dynamic one are encoded in place
+	pairs := [][2]uint8{{1, 2}, {3, 4}}
+	packed, err = abi.Pack("pairs", pairs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packed) != 4+6*32 {
+		t.Fatalf("expected %d bytes got %d", 4+6*32, len(packed))
+	}
+	var pairsOut [][2]uint8
+	if err := abi.Unpack(&pairsOut, "pairs", packed[4:]); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pairsOut, pairs) {
+		t.Errorf("expected %v got %v", pairs, pairsOut)
+	}
+}
+
+func TestTuples(t *testing.T) {
+	const definition = `[
+	{ "name" : "static", "constant" : false, "inputs": [ { "name": "s", "type": "tuple", "components": [ { "name": "a", "type": "uint256" }, { "name": "b", "type": "bytes32[2]" } ] } ], "outputs": [ { "name": "s", "type": "tuple", "components": [ { "name": "a", "type": "uint256" }, { "name": "b", "type": "bytes32[2]" } ] } ] },
+	{ "name" : "dynamic", "constant" : false, "inputs": [ { "name": "x", "type": "uint8" }, { "name": "s", "type": "tuple[]", "components": [ { "name": "owner_name", "type": "string" }, { "name": "values", "type": "int64[]" } ] } ], "outputs": [ { "name": "x", "type": "uint8" }, { "name": "s", "type": "tuple[]", "components": [ { "name": "owner_name", "type": "string" }, { "name": "values", "type": "int64[]" } ] } ] }]`
+
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig := abi.Methods["static"].Sig(); sig != "static((uint256,bytes32[2]))" {
+		t.Errorf("signature mismatch: %s", sig)
+	}
+	if sig := abi.Methods["dynamic"].Sig(); sig != "dynamic(uint8,(string,int64[])[])" {
+		t.Errorf("signature mismatch: %s", sig)
+	}
+
+	// Static tuples are encoded in place
+	type staticTuple struct {
+		A *big.Int
+		B [2][32]byte
+	}
+	in := staticTuple{A: big.NewInt(7), B: [2][32]byte{{1}, {2}}}
+
+	packed, err := abi.Pack("static", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := append(abi.Methods["static"].Id(), common.LeftPadBytes([]byte{7}, 32)...)
+	exp = append(exp, common.RightPadBytes([]byte{1}, 32)...)
+	exp = append(exp, common.RightPadBytes([]byte{2}, 32)...)
+	if !bytes.Equal(packed, exp) {
+		t.Fatalf("expected %x got %x", exp, packed)
+	}
+	var out staticTuple
+	if err := abi.Unpack(&out, "static", packed[4:]); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("expected %v got %v", in, out)
+	}
+
+	// Dynamic tuples are encoded at an offset and round trip through structs
+	type dynamicTuple struct {
+		OwnerName string
+		Values    []int64
+	}
+	tuples := []dynamicTuple{{"alice", []int64{1, -2}}, {"bob", nil}}
+
+	packed, err = abi.Pack("dynamic", uint8(3), tuples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dynOut struct {
+		X uint8
+		S []dynamicTuple
+	}
+	if err := abi.Unpack(&dynOut, "dynamic", packed[4:]); err != nil {
+		t.Fatal(err)
+	}
+	if dynOut.X != 3 {
+		t.Errorf("expected 3 got %d", dynOut.X)
+	}
+	if len(dynOut.S) != 2 || dynOut.S[0].OwnerName != "alice" || dynOut.S[1].OwnerName != "bob" {
+		t.Fatalf("tuple mismatch: %v", dynOut.S)
+	}
+	if !reflect.DeepEqual(dynOut.S[0].Values, []int64{1, -2}) || len(dynOut.S[1].Values) != 0 {
+		t.Errorf("tuple values mismatch: %v", dynOut.S)
+	}
+
+	// Tuples can't be packed from structs missing any of their components
+	if _, err := abi.Pack("static", struct{ A *big.Int }{big.NewInt(1)}); err == nil {
+		t.Error("expected error for incomplete struct")
+	}
+}
@@ -29,21 +29,29 @@ type Argument struct {
 	Indexed bool // indexed is only used by events
 }
 
+// argumentMarshaling is the JSON representation of an argument, with tuple
+// arguments listing their components recursively.
+type argumentMarshaling struct {
+	Name         string
+	Type         string
+	InternalType string
+	Components   []argumentMarshaling
+	Indexed      bool
+}
+
 func (a *Argument) UnmarshalJSON(data []byte) error {
-	var extarg struct {
-		Name string
-		Type string
-	}
+	var extarg argumentMarshaling
 	err := json.Unmarshal(data, &extarg)
 	if err != nil {
 		return fmt.Errorf("argument json err: %v", err)
 	}
 
-	a.Type, err = NewType(extarg.Type)
+	a.Type, err = newType(extarg.Type, extarg.InternalType, extarg.Components)
 	if err != nil {
 		return err
 	}
 	a.Name = extarg.Name
+	a.Indexed = extarg.Indexed
 
 	return nil
 }
@@ -22,6 +22,7 @@ import (
 
 	"github.com/ethereumproject/go-ethereum/common"
 	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/core/vm"
 )
 
 // ErrNoCode is returned by call and transact operations for which the requested
@@ -72,11 +73,46 @@ type ContractTransactor interface {
 	SendTransaction(tx *types.Transaction) error
 }
 
+// FilterQuery contains the options for contract log filtering.
+type FilterQuery struct {
+	FromBlock *big.Int         // Beginning of the queried range (nil = latest block)
+	ToBlock   *big.Int         // End of the range (nil = latest block)
+	Addresses []common.Address // Contracts the logs need to originate from (empty = any)
+
+	// Topics restricts the logs by their topics in order, each position listing
+	// alternative matches. The zero hash matches any topic in that position.
+	Topics [][]common.Hash
+}
+
+// Subscription represents a stream of logs delivered by a ContractFilterer.
+type Subscription interface {
+	// Err returns a channel which receives the error terminating the stream, if
+	// any. The channel is closed by Unsubscribe.
+	Err() <-chan error
+
+	// Unsubscribe stops the delivery of logs and closes the error channel. It can
+	// be called any number of times.
+	Unsubscribe()
+}
+
+// ContractFilterer defines the methods needed to access log events of contracts
+// using one-off queries or continuous event subscriptions.
+type ContractFilterer interface {
+	// FilterLogs executes a log filter operation, blocking during execution and
+	// returning all the results in one batch.
+	FilterLogs(query FilterQuery) (vm.Logs, error)
+
+	// SubscribeFilterLogs creates a background log filtering operation, returning
+	// a subscription immediately, which can be used to stream the found events.
+	// Logs reverted by a reorg are streamed again with their Removed flag set.
+	SubscribeFilterLogs(query FilterQuery, sink chan<- *vm.Log) (Subscription, error)
+}
+
 // ContractBackend defines the methods needed to allow operating with contract
 // on a read-write basis.
 //
-// This interface is essentially the union of ContractCaller and ContractTransactor
-// but due to a bug in the Go compiler (https://github.com/golang/go/issues/6977),
+// This interface is essentially the union of ContractCaller, ContractTransactor
+// and ContractFilterer but due to a bug in the Go compiler (https://github.com/golang/go/issues/6977),
 // we cannot simply list it as the two interfaces. The other solution is to add a
 // third interface containing the common methods, but that convolutes the user API
 // as it introduces yet another parameter to require for initialization.
@@ -108,4 +144,26 @@ type ContractBackend interface {
 
 	// SendTransaction injects the transaction into the pending pool for execution.
 	SendTransaction(tx *types.Transaction) error
+
+	// FilterLogs executes a log filter operation, blocking during execution and
+	// returning all the results in one batch.
+	FilterLogs(query FilterQuery) (vm.Logs, error)
+
+	// SubscribeFilterLogs creates a background log filtering operation, returning
+	// a subscription immediately, which can be used to stream the found events.
+	// Logs reverted by a reorg are streamed again with their Removed flag set.
+	SubscribeFilterLogs(query FilterQuery, sink chan<- *vm.Log) (Subscription, error)
+}
+
+// DeployBackend defines the methods needed to track the inclusion of transactions
+// into the chain, most notably to wait for contracts to be deployed.
+type DeployBackend interface {
+	// TransactionReceipt returns the receipt of a mined transaction. Note that the
+	// receipt is nil, without any error, if the transaction is not yet included
+	// in the chain.
+	TransactionReceipt(txHash common.Hash) (*types.Receipt, error)
+
+	// HasCode checks if the contract at the given address has any code associated
+	// with it or not.
+	HasCode(contract common.Address, pending bool) (bool, error)
 }
@@ -22,6 +22,7 @@ import (
 	"github.com/ethereumproject/go-ethereum/accounts/abi/bind"
 	"github.com/ethereumproject/go-ethereum/common"
 	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/core/vm"
 )
 
 // This nil assignment ensures compile time that nilBackend implements bind.ContractBackend.
@@ -42,6 +43,10 @@ func (*nilBackend) HasCode(common.Address, bool) (bool, error)         { panic("
 func (*nilBackend) SuggestGasPrice() (*big.Int, error)                 { panic("not implemented") }
 func (*nilBackend) PendingAccountNonce(common.Address) (uint64, error) { panic("not implemented") }
 func (*nilBackend) SendTransaction(*types.Transaction) error           { panic("not implemented") }
+func (*nilBackend) FilterLogs(bind.FilterQuery) (vm.Logs, error)       { panic("not implemented") }
+func (*nilBackend) SubscribeFilterLogs(bind.FilterQuery, chan<- *vm.Log) (bind.Subscription, error) {
+	panic("not implemented")
+}
 
 // NewNilBackend creates a new binding backend that can be used for instantiation
 // but will panic on any invocation. Its sole purpose is to help testing.
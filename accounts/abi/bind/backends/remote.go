@@ -22,16 +22,22 @@ import (
 	"math/big"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/ethereumproject/go-ethereum/accounts/abi/bind"
 	"github.com/ethereumproject/go-ethereum/common"
 	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/core/vm"
 	"github.com/ethereumproject/go-ethereum/rlp"
 	"github.com/ethereumproject/go-ethereum/rpc"
 )
 
-// This nil assignment ensures compile time that rpcBackend implements bind.ContractBackend.
-var _ bind.ContractBackend = (*rpcBackend)(nil)
+// These nil assignments ensure compile time that rpcBackend implements
+// bind.ContractBackend and bind.DeployBackend.
+var (
+	_ bind.ContractBackend = (*rpcBackend)(nil)
+	_ bind.DeployBackend   = (*rpcBackend)(nil)
+)
 
 // rpcBackend implements bind.ContractBackend, and acts as the data provider to
 // Ethereum contracts bound to Go structs. It uses an RPC connection to delegate
@@ -243,3 +249,125 @@ func (b *rpcBackend) SendTransaction(tx *types.Transaction) error {
 	}
 	return nil
 }
+
+// rpcReceipt is the subset of the remote transaction receipt fields needed to
+// reconstruct a consensus receipt.
+type rpcReceipt struct {
+	Root              string          `json:"root"`
+	TxHash            common.Hash     `json:"transactionHash"`
+	GasUsed           string          `json:"gasUsed"`
+	CumulativeGasUsed string          `json:"cumulativeGasUsed"`
+	ContractAddress   *common.Address `json:"contractAddress"`
+	Logs              vm.Logs         `json:"logs"`
+}
+
+// TransactionReceipt implements DeployBackend.TransactionReceipt, delegating the
+// receipt retrieval to the remote node.
+func (b *rpcBackend) TransactionReceipt(txHash common.Hash) (*types.Receipt, error) {
+	res, err := b.request("eth_getTransactionReceipt", []interface{}{txHash.Hex()})
+	if err != nil {
+		return nil, err
+	}
+	var fields *rpcReceipt
+	if err := json.Unmarshal(res, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, nil
+	}
+	gasUsed, ok := new(big.Int).SetString(fields.GasUsed, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid gas used hex: %s", fields.GasUsed)
+	}
+	cumulative, ok := new(big.Int).SetString(fields.CumulativeGasUsed, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid cumulative gas used hex: %s", fields.CumulativeGasUsed)
+	}
+	receipt := types.NewReceipt(common.FromHex(fields.Root), cumulative)
+	receipt.TxHash = fields.TxHash
+	receipt.GasUsed = gasUsed
+	receipt.Logs = fields.Logs
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	if fields.ContractAddress != nil {
+		receipt.ContractAddress = *fields.ContractAddress
+	}
+	return receipt, nil
+}
+
+// FilterLogs implements ContractFilterer.FilterLogs, delegating the execution of
+// the log filter query to the remote node.
+func (b *rpcBackend) FilterLogs(query bind.FilterQuery) (vm.Logs, error) {
+	res, err := b.request("eth_getLogs", []interface{}{toFilterArg(query)})
+	if err != nil {
+		return nil, err
+	}
+	var logs vm.Logs
+	if err := json.Unmarshal(res, &logs); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// SubscribeFilterLogs implements ContractFilterer.SubscribeFilterLogs, installing
+// a log filter on the remote node and polling it for changes until unsubscribed.
+func (b *rpcBackend) SubscribeFilterLogs(query bind.FilterQuery, sink chan<- *vm.Log) (bind.Subscription, error) {
+	res, err := b.request("eth_newFilter", []interface{}{toFilterArg(query)})
+	if err != nil {
+		return nil, err
+	}
+	var id string
+	if err := json.Unmarshal(res, &id); err != nil {
+		return nil, err
+	}
+	return bind.NewSubscription(func(quit <-chan struct{}) error {
+		defer b.request("eth_uninstallFilter", []interface{}{id})
+
+		ticker := time.NewTicker(logPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				res, err := b.request("eth_getFilterChanges", []interface{}{id})
+				if err != nil {
+					return err
+				}
+				var logs vm.Logs
+				if err := json.Unmarshal(res, &logs); err != nil {
+					return err
+				}
+				for _, log := range logs {
+					select {
+					case sink <- log:
+					case <-quit:
+						return nil
+					}
+				}
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// logPollInterval is the time interval between checking a remote log filter
+// for any changes.
+const logPollInterval = time.Second
+
+// toFilterArg converts a log filter query into the format expected by the remote
+// filter APIs.
+func toFilterArg(query bind.FilterQuery) interface{} {
+	arg := map[string]interface{}{
+		"fromBlock": "latest",
+		"toBlock":   "latest",
+		"address":   query.Addresses,
+		"topics":    query.Topics,
+	}
+	if query.FromBlock != nil {
+		arg["fromBlock"] = rpc.NewHexNumber(query.FromBlock)
+	}
+	if query.ToBlock != nil {
+		arg["toBlock"] = rpc.NewHexNumber(query.ToBlock)
+	}
+	return arg
+}
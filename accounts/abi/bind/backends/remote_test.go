@@ -0,0 +1,93 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ethereumproject/go-ethereum/accounts/abi/bind"
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/vm"
+)
+
+// testRPCClient is an rpc.Client answering requests with canned results per
+// method, consumed in order (the last one is repeated).
+type testRPCClient struct {
+	results map[string][]interface{}
+	last    *request
+}
+
+func (c *testRPCClient) SupportedModules() (map[string]string, error) { return nil, nil }
+func (c *testRPCClient) Close()                                       {}
+
+func (c *testRPCClient) Send(req interface{}) error {
+	c.last = req.(*request)
+	return nil
+}
+
+func (c *testRPCClient) Recv(msg interface{}) error {
+	results := c.results[c.last.Method]
+	result := results[0]
+	if len(results) > 1 {
+		c.results[c.last.Method] = results[1:]
+	}
+	blob, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	*msg.(*response) = response{JSONRPC: "2.0", ID: c.last.ID, Result: blob}
+	return nil
+}
+
+// Tests that logs reverted by a reorg are delivered to log subscriptions flagged
+// as removed, in the order received.
+func TestRPCSubscribeFilterLogsRemoved(t *testing.T) {
+	removed := &vm.Log{Address: common.Address{0x01}, BlockNumber: 1, Index: 0, Removed: true}
+	added := &vm.Log{Address: common.Address{0x01}, BlockNumber: 1, Index: 1}
+
+	client := &testRPCClient{results: map[string][]interface{}{
+		"eth_newFilter":        {"0x1"},
+		"eth_getFilterChanges": {vm.Logs{removed, added}, vm.Logs{}},
+		"eth_uninstallFilter":  {true},
+	}}
+	backend := NewRPCBackend(client)
+
+	sink := make(chan *vm.Log, 2)
+	sub, err := backend.SubscribeFilterLogs(bind.FilterQuery{Addresses: []common.Address{{0x01}}}, sink)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for _, want := range []*vm.Log{removed, added} {
+		select {
+		case log := <-sink:
+			if log.Removed != want.Removed || log.Index != want.Index {
+				t.Fatalf("delivered log mismatch: have %v (removed %v), want %v (removed %v)", log, log.Removed, want, want.Removed)
+			}
+		case <-time.After(3 * logPollInterval):
+			t.Fatalf("log not delivered")
+		}
+	}
+	select {
+	case log := <-sink:
+		t.Fatalf("unexpected log delivered: %v", log)
+	case <-time.After(logPollInterval + 100*time.Millisecond):
+	}
+}
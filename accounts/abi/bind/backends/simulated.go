@@ -17,28 +17,40 @@
 package backends
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/ethereumproject/go-ethereum/accounts/abi/bind"
 	"github.com/ethereumproject/go-ethereum/common"
 	"github.com/ethereumproject/go-ethereum/core"
 	"github.com/ethereumproject/go-ethereum/core/state"
 	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/core/vm"
+	"github.com/ethereumproject/go-ethereum/eth/filters"
 	"github.com/ethereumproject/go-ethereum/ethdb"
 	"github.com/ethereumproject/go-ethereum/event"
 )
 
-// This nil assignment ensures compile time that SimulatedBackend implements bind.ContractBackend.
-var _ bind.ContractBackend = (*SimulatedBackend)(nil)
+// These nil assignments ensure compile time that SimulatedBackend implements
+// bind.ContractBackend and bind.DeployBackend.
+var (
+	_ bind.ContractBackend = (*SimulatedBackend)(nil)
+	_ bind.DeployBackend   = (*SimulatedBackend)(nil)
+)
 
 // SimulatedBackend implements bind.ContractBackend, simulating a blockchain in
 // the background. Its main purpose is to allow easily testing contract bindings.
 type SimulatedBackend struct {
 	database   ethdb.Database   // In memory database to store our testing data
 	blockchain *core.BlockChain // Ethereum blockchain to handle the consensus
+	mux        *event.TypeMux   // Event multiplexer the blockchain posts its events to
 
 	pendingBlock *types.Block   // Currently pending block that will be imported on request
 	pendingState *state.StateDB // Currently pending state that will be the active on on request
+	pendingShift int64          // Seconds the pending block's timestamp is pushed into the future
 }
 
 // NewSimulatedBackend creates a new binding backend using a simulated blockchain
@@ -46,11 +58,13 @@ type SimulatedBackend struct {
 func NewSimulatedBackend(accounts ...core.GenesisAccount) *SimulatedBackend {
 	database, _ := ethdb.NewMemDatabase()
 	core.WriteGenesisBlockForTesting(database, accounts...)
-	blockchain, _ := core.NewBlockChain(database, core.TestConfig, new(core.FakePow), new(event.TypeMux))
+	mux := new(event.TypeMux)
+	blockchain, _ := core.NewBlockChain(database, core.TestConfig, new(core.FakePow), mux)
 
 	backend := &SimulatedBackend{
 		database:   database,
 		blockchain: blockchain,
+		mux:        mux,
 	}
 	backend.Rollback()
 
@@ -68,8 +82,33 @@ func (b *SimulatedBackend) Commit() {
 
 // Rollback aborts all pending transactions, reverting to the last committed state.
 func (b *SimulatedBackend) Rollback() {
-	blocks, _ := core.GenerateChain(core.TestConfig, b.blockchain.CurrentBlock(), b.database, 1, func(int, *core.BlockGen) {})
+	b.pendingShift = 0
+	b.rebuildPending(nil)
+}
 
+// AdjustTime pushes the timestamp of the pending block into the future by the
+// given duration (truncated to whole seconds), allowing contracts depending on
+// the block time to be tested. The shift lasts until the next commit or rollback.
+func (b *SimulatedBackend) AdjustTime(adjustment time.Duration) error {
+	if adjustment < 0 {
+		return errors.New("cannot move the pending block back in time")
+	}
+	b.pendingShift += int64(adjustment / time.Second)
+	b.rebuildPending(b.pendingBlock.Transactions())
+	return nil
+}
+
+// rebuildPending regenerates the pending block on top of the current head of the
+// chain from the given transactions, along with the state resulting from them.
+func (b *SimulatedBackend) rebuildPending(txs types.Transactions) {
+	blocks, _ := core.GenerateChain(core.TestConfig, b.blockchain.CurrentBlock(), b.database, 1, func(number int, block *core.BlockGen) {
+		if b.pendingShift > 0 {
+			block.OffsetTime(b.pendingShift)
+		}
+		for _, tx := range txs {
+			block.AddTx(tx)
+		}
+	})
 	b.pendingBlock = blocks[0]
 	b.pendingState, _ = state.New(b.pendingBlock.Root(), b.database)
 }
@@ -174,19 +213,82 @@ func (b *SimulatedBackend) EstimateGasLimit(sender common.Address, contract *com
 	return gas, err
 }
 
-// SendTransaction implements ContractTransactor.SendTransaction, delegating the raw
-// transaction injection to the remote node.
+// SendTransaction implements ContractTransactor.SendTransaction, adding the
+// transaction to the pending block after the same sender checks a live node's
+// transaction pool would do.
 func (b *SimulatedBackend) SendTransaction(tx *types.Transaction) error {
-	blocks, _ := core.GenerateChain(core.TestConfig, b.blockchain.CurrentBlock(), b.database, 1, func(number int, block *core.BlockGen) {
-		for _, tx := range b.pendingBlock.Transactions() {
-			block.AddTx(tx)
+	from, err := tx.From()
+	if err != nil {
+		return fmt.Errorf("invalid transaction: %v", err)
+	}
+	if nonce := b.pendingState.GetNonce(from); tx.Nonce() != nonce {
+		return fmt.Errorf("invalid transaction nonce: have %d, want %d", tx.Nonce(), nonce)
+	}
+	if balance := b.pendingState.GetBalance(from); balance.Cmp(tx.Cost()) < 0 {
+		return fmt.Errorf("insufficient funds: have %v, want %v", balance, tx.Cost())
+	}
+	if gas := new(big.Int).Add(b.pendingBlock.GasUsed(), tx.Gas()); gas.Cmp(b.pendingBlock.GasLimit()) > 0 {
+		return fmt.Errorf("exceeds block gas limit: have %v, want %v", b.pendingBlock.GasLimit(), gas)
+	}
+	b.rebuildPending(append(b.pendingBlock.Transactions(), tx))
+	return nil
+}
+
+// TransactionReceipt implements DeployBackend.TransactionReceipt, retrieving the
+// receipt of a transaction included in one of the committed blocks.
+func (b *SimulatedBackend) TransactionReceipt(txHash common.Hash) (*types.Receipt, error) {
+	return core.GetReceipt(b.database, txHash), nil
+}
+
+// FilterLogs implements ContractFilterer.FilterLogs, executing the log filter
+// query against the committed blocks of the simulated chain.
+func (b *SimulatedBackend) FilterLogs(query bind.FilterQuery) (vm.Logs, error) {
+	filter := filters.New(b.database)
+	filter.SetBeginBlock(-1)
+	if query.FromBlock != nil {
+		filter.SetBeginBlock(query.FromBlock.Int64())
+	}
+	filter.SetEndBlock(-1)
+	if query.ToBlock != nil {
+		filter.SetEndBlock(query.ToBlock.Int64())
+	}
+	filter.SetAddresses(query.Addresses)
+	filter.SetTopics(query.Topics)
+
+	return filter.Find(context.Background())
+}
+
+// SubscribeFilterLogs implements ContractFilterer.SubscribeFilterLogs, streaming
+// the matching logs of the blocks committed after the subscription was created.
+func (b *SimulatedBackend) SubscribeFilterLogs(query bind.FilterQuery, sink chan<- *vm.Log) (bind.Subscription, error) {
+	filter := filters.New(b.database)
+	filter.SetAddresses(query.Addresses)
+	filter.SetTopics(query.Topics)
+
+	// Logs reverted by a reorg are delivered flagged, on a copy as they are shared
+	done := make(chan struct{})
+	filter.LogCallback = func(log *vm.Log, removed bool) {
+		if removed {
+			cpy := *log
+			cpy.Removed = true
+			log = &cpy
 		}
-		block.AddTx(tx)
-	})
-	b.pendingBlock = blocks[0]
-	b.pendingState, _ = state.New(b.pendingBlock.Root(), b.database)
+		select {
+		case sink <- log:
+		case <-done:
+		}
+	}
+	system := filters.NewFilterSystem(b.mux)
+	system.Lock()
+	system.Add(filter, filters.LogFilter)
+	system.Unlock()
 
-	return nil
+	return bind.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		close(done)
+		system.Stop()
+		return nil
+	}), nil
 }
 
 // callmsg implements core.Message to allow passing it as a transaction simulator.
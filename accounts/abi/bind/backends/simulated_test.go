@@ -0,0 +1,141 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/crypto"
+)
+
+// transfer creates a plain value transfer signed by the given key.
+func transfer(t *testing.T, key *ecdsa.PrivateKey, nonce uint64, to common.Address, amount int64) *types.Transaction {
+	tx, err := types.NewTransaction(nonce, to, big.NewInt(amount), big.NewInt(21000), big.NewInt(1), nil).SignECDSA(key)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	return tx
+}
+
+// Tests that multiple genesis accounts can transact within the same block and
+// that the receipts of their transactions become available once committed.
+func TestSimulatedReceipts(t *testing.T) {
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	addr1, addr2 := crypto.PubkeyToAddress(key1.PublicKey), crypto.PubkeyToAddress(key2.PublicKey)
+
+	sim := NewSimulatedBackend(
+		core.GenesisAccount{Address: addr1, Balance: big.NewInt(10000000000)},
+		core.GenesisAccount{Address: addr2, Balance: big.NewInt(10000000000)},
+	)
+	tx1 := transfer(t, key1, 0, addr2, 1)
+	tx2 := transfer(t, key2, 0, addr1, 1)
+	for i, tx := range []*types.Transaction{tx1, tx2} {
+		if err := sim.SendTransaction(tx); err != nil {
+			t.Fatalf("tx %d: failed to send: %v", i, err)
+		}
+		if receipt, err := sim.TransactionReceipt(tx.Hash()); err != nil || receipt != nil {
+			t.Fatalf("tx %d: pending receipt mismatch: have %v/%v, want nil/nil", i, receipt, err)
+		}
+	}
+	sim.Commit()
+
+	for i, tx := range []*types.Transaction{tx1, tx2} {
+		receipt, err := sim.TransactionReceipt(tx.Hash())
+		if err != nil {
+			t.Fatalf("tx %d: failed to retrieve receipt: %v", i, err)
+		}
+		if receipt == nil {
+			t.Fatalf("tx %d: receipt missing after commit", i)
+		}
+		if receipt.TxHash != tx.Hash() {
+			t.Errorf("tx %d: receipt hash mismatch: have %x, want %x", i, receipt.TxHash, tx.Hash())
+		}
+		if receipt.GasUsed.Cmp(big.NewInt(21000)) != 0 {
+			t.Errorf("tx %d: gas used mismatch: have %v, want %v", i, receipt.GasUsed, 21000)
+		}
+	}
+}
+
+// Tests that transactions a live node would reject are refused by the simulated
+// backend too, and that rolling back discards the pending ones.
+func TestSimulatedSendValidation(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	sim := NewSimulatedBackend(core.GenesisAccount{Address: addr, Balance: big.NewInt(100000)})
+
+	if err := sim.SendTransaction(transfer(t, key, 1, common.Address{}, 1)); err == nil {
+		t.Errorf("future nonce accepted")
+	}
+	if err := sim.SendTransaction(transfer(t, key, 0, common.Address{}, 100000)); err == nil {
+		t.Errorf("overspending transaction accepted")
+	}
+	if err := sim.SendTransaction(transfer(t, key, 0, common.Address{}, 1)); err != nil {
+		t.Fatalf("failed to send valid transaction: %v", err)
+	}
+	if err := sim.SendTransaction(transfer(t, key, 0, common.Address{}, 1)); err == nil {
+		t.Errorf("replayed nonce accepted")
+	}
+	if nonce, _ := sim.PendingAccountNonce(addr); nonce != 1 {
+		t.Errorf("pending nonce mismatch: have %d, want %d", nonce, 1)
+	}
+	sim.Rollback()
+	if nonce, _ := sim.PendingAccountNonce(addr); nonce != 0 {
+		t.Errorf("pending nonce mismatch after rollback: have %d, want %d", nonce, 0)
+	}
+}
+
+// Tests that the pending block's timestamp can be pushed into the future and that
+// the shift is retained by the committed block.
+func TestSimulatedAdjustTime(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	sim := NewSimulatedBackend(core.GenesisAccount{Address: addr, Balance: big.NewInt(10000000000)})
+	if err := sim.SendTransaction(transfer(t, key, 0, common.Address{}, 1)); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	prev := sim.pendingBlock.Time().Int64()
+
+	if err := sim.AdjustTime(-time.Second); err == nil {
+		t.Errorf("negative time adjustment accepted")
+	}
+	if err := sim.AdjustTime(time.Hour); err != nil {
+		t.Fatalf("failed to adjust time: %v", err)
+	}
+	if have, want := sim.pendingBlock.Time().Int64(), prev+3600; have != want {
+		t.Errorf("pending time mismatch: have %d, want %d", have, want)
+	}
+	if len(sim.pendingBlock.Transactions()) != 1 {
+		t.Errorf("pending transactions dropped by time adjustment")
+	}
+	sim.Commit()
+
+	if have, want := sim.blockchain.CurrentBlock().Time().Int64(), prev+3600; have != want {
+		t.Errorf("committed time mismatch: have %d, want %d", have, want)
+	}
+	if have, want := sim.pendingBlock.Time().Int64(), prev+3610; have != want {
+		t.Errorf("next pending time mismatch: have %d, want %d", have, want)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/ethereumproject/go-ethereum/accounts/abi"
 	"github.com/ethereumproject/go-ethereum/common"
 	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/core/vm"
 	"github.com/ethereumproject/go-ethereum/crypto"
 )
 
@@ -49,6 +50,19 @@ type TransactOpts struct {
 	GasLimit *big.Int // Gas limit to set for the transaction execution (nil = estimate + 10%)
 }
 
+// FilterOpts is the collection of options to fine tune filtering for events
+// within a bound contract.
+type FilterOpts struct {
+	Start uint64  // Start of the queried range
+	End   *uint64 // End of the range (nil = latest)
+}
+
+// WatchOpts is the collection of options to fine tune subscribing for events
+// within a bound contract.
+type WatchOpts struct {
+	Start *uint64 // Start of the queried range to deliver before live events (nil = latest)
+}
+
 // BoundContract is the base wrapper object that reflects a contract on the
 // Ethereum network. It contains a collection of methods that are used by the
 // higher level contract bindings to operate.
@@ -57,19 +71,21 @@ type BoundContract struct {
 	abi        abi.ABI            // Reflect based ABI to access the correct Ethereum methods
 	caller     ContractCaller     // Read interface to interact with the blockchain
 	transactor ContractTransactor // Write interface to interact with the blockchain
+	filterer   ContractFilterer   // Event filtering to interact with the blockchain
 
 	latestHasCode  uint32 // Cached verification that the latest state contains code for this contract
 	pendingHasCode uint32 // Cached verification that the pending state contains code for this contract
 }
 
-// NewBoundContract creates a low level contract interface through which calls
-// and transactions may be made through.
-func NewBoundContract(address common.Address, abi abi.ABI, caller ContractCaller, transactor ContractTransactor) *BoundContract {
+// NewBoundContract creates a low level contract interface through which calls,
+// transactions and event filters may be made through.
+func NewBoundContract(address common.Address, abi abi.ABI, caller ContractCaller, transactor ContractTransactor, filterer ContractFilterer) *BoundContract {
 	return &BoundContract{
 		address:    address,
 		abi:        abi,
 		caller:     caller,
 		transactor: transactor,
+		filterer:   filterer,
 	}
 }
 
@@ -77,7 +93,7 @@ func NewBoundContract(address common.Address, abi abi.ABI, caller ContractCaller
 // deployment address with a Go wrapper.
 func DeployContract(opts *TransactOpts, abi abi.ABI, bytecode []byte, backend ContractBackend, params ...interface{}) (common.Address, *types.Transaction, *BoundContract, error) {
 	// Otherwise try to deploy the contract
-	c := NewBoundContract(common.Address{}, abi, backend, backend)
+	c := NewBoundContract(common.Address{}, abi, backend, backend, backend)
 
 	input, err := c.abi.Pack("", params...)
 	if err != nil {
@@ -204,3 +220,113 @@ func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, i
 	}
 	return signedTx, nil
 }
+
+// FilterLogs retrieves the past logs of the named event raised by the contract
+// within the requested block range. The query lists the accepted values of the
+// indexed event arguments in order, an empty list accepting any value.
+func (c *BoundContract) FilterLogs(opts *FilterOpts, name string, query ...[]interface{}) (vm.Logs, error) {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(FilterOpts)
+	}
+	filter, err := c.filterQuery(name, query)
+	if err != nil {
+		return nil, err
+	}
+	filter.FromBlock = new(big.Int).SetUint64(opts.Start)
+	if opts.End != nil {
+		filter.ToBlock = new(big.Int).SetUint64(*opts.End)
+	}
+	return c.filterer.FilterLogs(filter)
+}
+
+// WatchLogs subscribes to the future logs of the named event raised by the
+// contract, optionally preceded by the past ones starting at a requested block.
+// The query lists the accepted values of the indexed event arguments in order,
+// an empty list accepting any value.
+func (c *BoundContract) WatchLogs(opts *WatchOpts, name string, query ...[]interface{}) (chan *vm.Log, Subscription, error) {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(WatchOpts)
+	}
+	filter, err := c.filterQuery(name, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Subscribe before retrieving any past logs to avoid missing the ones in between
+	live := make(chan *vm.Log, 128)
+	liveSub, err := c.filterer.SubscribeFilterLogs(filter, live)
+	if err != nil {
+		return nil, nil, err
+	}
+	logs := make(chan *vm.Log, 128)
+	sub := NewSubscription(func(quit <-chan struct{}) error {
+		defer liveSub.Unsubscribe()
+
+		// Deliver any requested past logs, remembering how far they reached
+		var (
+			backfilled bool
+			last       uint64
+		)
+		if opts.Start != nil {
+			filter.FromBlock = new(big.Int).SetUint64(*opts.Start)
+			past, err := c.filterer.FilterLogs(filter)
+			if err != nil {
+				return err
+			}
+			for _, log := range past {
+				select {
+				case logs <- log:
+					backfilled, last = true, log.BlockNumber
+				case <-quit:
+					return nil
+				}
+			}
+		}
+		// Forward the live logs, skipping blocks already delivered from the past
+		for {
+			select {
+			case log := <-live:
+				if backfilled && log.BlockNumber <= last {
+					continue
+				}
+				select {
+				case logs <- log:
+				case <-quit:
+					return nil
+				}
+			case err := <-liveSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+	return logs, sub, nil
+}
+
+// UnpackLog unpacks a retrieved log into the provided output structure.
+func (c *BoundContract) UnpackLog(out interface{}, event string, log *vm.Log) error {
+	return c.abi.UnpackLog(out, event, log.Topics, log.Data)
+}
+
+// filterQuery assembles the log filter matching the named event raised by the
+// contract, restricting its indexed arguments to the queried values.
+func (c *BoundContract) filterQuery(name string, query [][]interface{}) (FilterQuery, error) {
+	event, ok := c.abi.Events[name]
+	if !ok {
+		return FilterQuery{}, fmt.Errorf("event '%s' not found", name)
+	}
+	// Anonymous events don't log their signature, the first topic is an argument
+	if !event.Anonymous {
+		query = append([][]interface{}{{event.Id()}}, query...)
+	}
+	topics, err := makeTopics(query...)
+	if err != nil {
+		return FilterQuery{}, err
+	}
+	return FilterQuery{
+		Addresses: []common.Address{c.address},
+		Topics:    topics,
+	}, nil
+}
@@ -22,23 +22,42 @@ package bind
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
-	"regexp"
+	"sort"
 	"strings"
 	"text/template"
-	"unicode"
 
 	"github.com/ethereumproject/go-ethereum/accounts/abi"
 	"golang.org/x/tools/imports"
 )
 
-// Bind generates a Go wrapper around a contract ABI. This wrapper isn't meant
-// to be used as is in client code, but rather as an intermediate struct which
-// enforces compile time type safety and naming convention opposed to having to
-// manually maintain hard coded strings that break on runtime.
-func Bind(types []string, abis []string, bytecodes []string, pkg string) (string, error) {
+// Lang is a target programming language selector to generate bindings for.
+type Lang int
+
+const (
+	LangGo Lang = iota
+	LangJava
+	LangTypeScript
+)
+
+// Bind generates a Go, Java or TypeScript wrapper around a contract ABI. This
+// wrapper isn't meant to be used as is in client code, but rather as an
+// intermediate struct which enforces compile time type safety and naming
+// convention opposed to having to manually maintain hard coded strings that
+// break on runtime.
+//
+// Java bindings are built on top of the web3j library and TypeScript ones on
+// top of ethers.js (v5).
+func Bind(types []string, abis []string, bytecodes []string, pkg string, lang Lang) (string, error) {
+	if _, ok := tmplSource[lang]; !ok {
+		return "", fmt.Errorf("unsupported binding language: %d", lang)
+	}
 	// Process each individual contract requested binding
-	contracts := make(map[string]*tmplContract)
+	var (
+		contracts = make(map[string]*tmplContract)
+		structs   = make(map[string]*tmplStruct)
+	)
 
 	for i := 0; i < len(types); i++ {
 		// Parse the actual ABI to generate the binding for
@@ -46,20 +65,21 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string) (string
 		if err != nil {
 			return "", err
 		}
-		// Strip any whitespace from the JSON ABI
-		strippedABI := strings.Map(func(r rune) rune {
-			if unicode.IsSpace(r) {
-				return -1
-			}
-			return r
-		}, abis[i])
+		// Strip any insignificant whitespace from the JSON ABI
+		stripped := new(bytes.Buffer)
+		if err := json.Compact(stripped, []byte(abis[i])); err != nil {
+			return "", err
+		}
 
 		// Extract the call and transact methods, and sort them alphabetically
 		var (
 			calls     = make(map[string]*tmplMethod)
 			transacts = make(map[string]*tmplMethod)
+			used      = make(map[string]*tmplStruct)
 		)
-		for _, original := range evmABI.Methods {
+		for _, name := range sortedMethods(evmABI.Methods) {
+			original := evmABI.Methods[name]
+
 			// Normalize the method for capital cases and non-anonymous inputs/outputs
 			normalized := original
 			normalized.Name = capitalise(original.Name)
@@ -70,6 +90,7 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string) (string
 				if input.Name == "" {
 					normalized.Inputs[j].Name = fmt.Sprintf("arg%d", j)
 				}
+				bindStructType(input.Type, structs, used)
 			}
 			normalized.Outputs = make([]abi.Argument, len(original.Outputs))
 			copy(normalized.Outputs, original.Outputs)
@@ -77,6 +98,7 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string) (string
 				if output.Name != "" {
 					normalized.Outputs[j].Name = capitalise(output.Name)
 				}
+				bindStructType(output.Type, structs, used)
 			}
 			// Append the methos to the call or transact lists
 			if original.Const {
@@ -85,30 +107,72 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string) (string
 				transacts[original.Name] = &tmplMethod{Original: original, Normalized: normalized, Structured: structured(original)}
 			}
 		}
+		// Extract the events, normalizing their arguments into exported field names
+		events := make(map[string]*tmplEvent)
+		for _, name := range sortedEvents(evmABI.Events) {
+			original := evmABI.Events[name]
+
+			normalized := original
+			normalized.Name = capitalise(original.Name)
+
+			normalized.Inputs = make([]abi.Argument, len(original.Inputs))
+			copy(normalized.Inputs, original.Inputs)
+			for j, input := range normalized.Inputs {
+				if input.Name == "" {
+					normalized.Inputs[j].Name = fmt.Sprintf("arg%d", j)
+				}
+				normalized.Inputs[j].Name = capitalise(normalized.Inputs[j].Name)
+				bindStructType(input.Type, structs, used)
+			}
+			events[original.Name] = &tmplEvent{Original: original, Normalized: normalized}
+		}
+		for _, input := range evmABI.Constructor.Inputs {
+			bindStructType(input.Type, structs, used)
+		}
 		contracts[types[i]] = &tmplContract{
 			Type:        capitalise(types[i]),
-			InputABI:    strippedABI,
+			InputABI:    stripped.String(),
 			InputBin:    strings.TrimSpace(bytecodes[i]),
 			Constructor: evmABI.Constructor,
 			Calls:       calls,
 			Transacts:   transacts,
+			Events:      events,
+			Structs:     used,
 		}
 	}
 	// Generate the contract template data content and render it
 	data := &tmplData{
 		Package:   pkg,
 		Contracts: contracts,
+		Structs:   structs,
 	}
 	buffer := new(bytes.Buffer)
 
 	funcs := map[string]interface{}{
-		"bindtype": bindType,
+		"bindtype": func(kind abi.Type) string {
+			return bindType[lang](kind, structs)
+		},
+		"bindargtype": func(kind abi.Type) string {
+			return bindArgType[lang](kind, structs)
+		},
+		"bindtopictype": func(kind abi.Type) string {
+			return bindTopicType[lang](kind, structs)
+		},
+		"topichashed":  topicHashed,
+		"argindex":     argIndex,
+		"indexed":      indexed,
+		"decapitalise": decapitalise,
+		"upper":        strings.ToUpper,
+		"quote":        quoteJava,
 	}
-	tmpl := template.Must(template.New("").Funcs(funcs).Parse(tmplSource))
+	tmpl := template.Must(template.New("").Funcs(funcs).Parse(tmplSource[lang]))
 	if err := tmpl.Execute(buffer, data); err != nil {
 		return "", err
 	}
-	// Pass the code through goimports to clean it up and double check
+	// For Go bindings pass the code through goimports to clean it up and double check
+	if lang != LangGo {
+		return buffer.String(), nil
+	}
 	code, err := imports.Process("", buffer.Bytes(), nil)
 	if err != nil {
 		return "", fmt.Errorf("%v\n%s", err, buffer)
@@ -116,55 +180,296 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string) (string
 	return string(code), nil
 }
 
-// bindType converts a Solidity type to a Go one. Since there is no clear mapping
+// bindType is a set of type binders that convert Solidity types to some supported
+// programming language types.
+var bindType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
+	LangGo:         bindTypeGo,
+	LangJava:       bindTypeJava,
+	LangTypeScript: bindTypeTypeScript,
+}
+
+// bindArgType is a set of type binders that convert Solidity types to the types
+// accepted as arguments by some supported programming language bindings. Apart
+// from TypeScript, these are the same as the types values are returned as.
+var bindArgType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
+	LangGo:         bindTypeGo,
+	LangJava:       bindTypeJava,
+	LangTypeScript: bindArgTypeTypeScript,
+}
+
+// bindTopicType is a set of type binders that convert Solidity types of indexed
+// event arguments to some supported programming language types. Dynamic types,
+// arrays and tuples are only logged as the hash of their content, so they are
+// bound to a hash instead of their own type.
+var bindTopicType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
+	LangGo: func(kind abi.Type, structs map[string]*tmplStruct) string {
+		if topicHashed(kind) {
+			return "common.Hash"
+		}
+		return bindTypeGo(kind, structs)
+	},
+	LangJava: func(kind abi.Type, structs map[string]*tmplStruct) string {
+		if topicHashed(kind) {
+			return "Bytes32"
+		}
+		return bindTypeJava(kind, structs)
+	},
+	LangTypeScript: func(kind abi.Type, structs map[string]*tmplStruct) string {
+		if topicHashed(kind) {
+			return "string"
+		}
+		return bindTypeTypeScript(kind, structs)
+	},
+}
+
+// bindTypeGo converts a Solidity type to a Go one. Since there is no clear mapping
 // from all Solidity types to Go ones (e.g. uint17), those that cannot be exactly
-// mapped will use an upscaled type (e.g. *big.Int).
-func bindType(kind abi.Type) string {
-	stringKind := kind.String()
+// mapped will use an upscaled type (e.g. *big.Int). Tuples are bound to the Go
+// structs previously registered for them by bindStructType.
+func bindTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		return structs[structKey(kind)].Name
+	case abi.ArrayTy:
+		return fmt.Sprintf("[%d]", kind.SliceSize) + bindTypeGo(*kind.Elem, structs)
+	case abi.SliceTy:
+		return "[]" + bindTypeGo(*kind.Elem, structs)
+	case abi.AddressTy:
+		return "common.Address"
+	case abi.FixedBytesTy:
+		return fmt.Sprintf("[%d]byte", kind.SliceSize)
+	case abi.BytesTy:
+		return "[]byte"
+	case abi.IntTy, abi.UintTy:
+		switch kind.Size {
+		case 8, 16, 32, 64:
+			if kind.T == abi.UintTy {
+				return fmt.Sprintf("uint%d", kind.Size)
+			}
+			return fmt.Sprintf("int%d", kind.Size)
+		}
+		return "*big.Int"
+	case abi.BoolTy:
+		return "bool"
+	case abi.StringTy:
+		return "string"
+	default:
+		return kind.String()
+	}
+}
 
-	switch {
-	case strings.HasPrefix(stringKind, "address"):
-		parts := regexp.MustCompile("address(\\[[0-9]*\\])?").FindStringSubmatch(stringKind)
-		if len(parts) != 2 {
-			return stringKind
+// bindTypeJava converts a Solidity type to the web3j type representing it. Since
+// web3j only provides static array types up to 32 elements, longer ones are bound
+// to the generic static array.
+func bindTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		return structs[structKey(kind)].Name
+	case abi.ArrayTy:
+		if kind.SliceSize > 32 {
+			return fmt.Sprintf("StaticArray<%s>", bindTypeJava(*kind.Elem, structs))
 		}
-		return fmt.Sprintf("%scommon.Address", parts[1])
+		return fmt.Sprintf("StaticArray%d<%s>", kind.SliceSize, bindTypeJava(*kind.Elem, structs))
+	case abi.SliceTy:
+		return fmt.Sprintf("DynamicArray<%s>", bindTypeJava(*kind.Elem, structs))
+	case abi.AddressTy:
+		return "Address"
+	case abi.FixedBytesTy:
+		return fmt.Sprintf("Bytes%d", kind.SliceSize)
+	case abi.BytesTy:
+		return "DynamicBytes"
+	case abi.IntTy:
+		return fmt.Sprintf("Int%d", kind.Size)
+	case abi.UintTy:
+		return fmt.Sprintf("Uint%d", kind.Size)
+	case abi.BoolTy:
+		return "Bool"
+	case abi.StringTy:
+		return "Utf8String"
+	default:
+		return kind.String()
+	}
+}
 
-	case strings.HasPrefix(stringKind, "bytes"):
-		parts := regexp.MustCompile("bytes([0-9]*)(\\[[0-9]*\\])?").FindStringSubmatch(stringKind)
-		if len(parts) != 3 {
-			return stringKind
+// bindTypeTypeScript converts a Solidity type to the TypeScript type ethers.js
+// returns values of it as. Integers up to 48 bits fit into a JavaScript number,
+// wider ones are returned as a BigNumber.
+func bindTypeTypeScript(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		return structs[structKey(kind)].Name
+	case abi.ArrayTy, abi.SliceTy:
+		return bindTypeTypeScript(*kind.Elem, structs) + "[]"
+	case abi.AddressTy, abi.FixedBytesTy, abi.BytesTy, abi.StringTy:
+		return "string"
+	case abi.IntTy, abi.UintTy:
+		if kind.Size <= 48 {
+			return "number"
 		}
-		return fmt.Sprintf("%s[%s]byte", parts[2], parts[1])
+		return "BigNumber"
+	case abi.BoolTy:
+		return "boolean"
+	default:
+		return kind.String()
+	}
+}
+
+// bindArgTypeTypeScript converts a Solidity type to the TypeScript type ethers.js
+// accepts as an argument for it, being more lenient than the returned one.
+func bindArgTypeTypeScript(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.ArrayTy, abi.SliceTy:
+		return bindArgTypeTypeScript(*kind.Elem, structs) + "[]"
+	case abi.FixedBytesTy, abi.BytesTy:
+		return "BytesLike"
+	case abi.IntTy, abi.UintTy:
+		return "BigNumberish"
+	default:
+		return bindTypeTypeScript(kind, structs)
+	}
+}
+
+// topicHashed returns whether an indexed event argument of the given type is
+// logged as the hash of its content instead of the value itself.
+func topicHashed(kind abi.Type) bool {
+	switch kind.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return true
+	}
+	return false
+}
 
-	case strings.HasPrefix(stringKind, "int") || strings.HasPrefix(stringKind, "uint"):
-		parts := regexp.MustCompile("(u)?int([0-9]*)(\\[[0-9]*\\])?").FindStringSubmatch(stringKind)
-		if len(parts) != 4 {
-			return stringKind
+// dynamicType returns whether a value of the given type is encoded out of place.
+func dynamicType(kind abi.Type) bool {
+	switch kind.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy:
+		return true
+	case abi.ArrayTy:
+		return dynamicType(*kind.Elem)
+	case abi.TupleTy:
+		for _, elem := range kind.TupleElems {
+			if dynamicType(*elem) {
+				return true
+			}
 		}
-		switch parts[2] {
-		case "8", "16", "32", "64":
-			return fmt.Sprintf("%s%sint%s", parts[3], parts[1], parts[2])
+	}
+	return false
+}
+
+// argIndex returns the position of the i-th event argument among the arguments
+// logged the same way, i.e. among the indexed or among the non-indexed ones.
+func argIndex(args []abi.Argument, i int) int {
+	index := 0
+	for _, arg := range args[:i] {
+		if arg.Indexed == args[i].Indexed {
+			index++
 		}
-		return fmt.Sprintf("%s*big.Int", parts[3])
+	}
+	return index
+}
 
-	case strings.HasPrefix(stringKind, "bool") || strings.HasPrefix(stringKind, "string"):
-		parts := regexp.MustCompile("([a-z]+)(\\[[0-9]*\\])?").FindStringSubmatch(stringKind)
-		if len(parts) != 3 {
-			return stringKind
+// indexed returns the indexed arguments of an event.
+func indexed(args []abi.Argument) []abi.Argument {
+	var indexed []abi.Argument
+	for _, arg := range args {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
 		}
-		return fmt.Sprintf("%s%s", parts[2], parts[1])
+	}
+	return indexed
+}
 
-	default:
-		return stringKind
+// quoteJava escapes a string to be embedded into a Java string literal.
+func quoteJava(input string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(input)
+}
+
+// bindStructType registers a struct for every tuple found within the given
+// Solidity type, nested ones first, also marking them as used by the contract
+// being bound. Structs are named after the struct declared in Solidity if known,
+// or numbered in the order they are encountered otherwise.
+func bindStructType(kind abi.Type, structs map[string]*tmplStruct, used map[string]*tmplStruct) {
+	switch kind.T {
+	case abi.ArrayTy, abi.SliceTy:
+		bindStructType(*kind.Elem, structs, used)
+
+	case abi.TupleTy:
+		key := structKey(kind)
+		if s, exist := structs[key]; exist {
+			used[key] = s
+			return
+		}
+		fields := make([]*tmplField, len(kind.TupleElems))
+		for i, elem := range kind.TupleElems {
+			bindStructType(*elem, structs, used)
+			fields[i] = &tmplField{Name: abi.ToCamelCase(kind.TupleRawNames[i]), RawName: kind.TupleRawNames[i], Type: *elem}
+		}
+		name := fmt.Sprintf("Struct%d", len(structs))
+		if kind.TupleRawName != "" {
+			name = capitalise(kind.TupleRawName)
+		}
+		// Different tuples may be declared with the same name, disambiguate them
+		names := make(map[string]bool)
+		for _, s := range structs {
+			names[s.Name] = true
+		}
+		for base, i := name, 0; names[name]; i++ {
+			name = fmt.Sprintf("%s%d", base, i)
+		}
+		structs[key] = &tmplStruct{Name: name, Fields: fields, Dynamic: dynamicType(kind)}
+		used[key] = structs[key]
 	}
 }
 
+// structKey returns the key identifying a tuple type among the structs bound
+// for it, being its struct name along with the names and types of all of its
+// components.
+func structKey(kind abi.Type) string {
+	switch kind.T {
+	case abi.ArrayTy:
+		return fmt.Sprintf("%s[%d]", structKey(*kind.Elem), kind.SliceSize)
+	case abi.SliceTy:
+		return structKey(*kind.Elem) + "[]"
+	case abi.TupleTy:
+		fields := make([]string, len(kind.TupleElems))
+		for i, elem := range kind.TupleElems {
+			fields[i] = kind.TupleRawNames[i] + " " + structKey(*elem)
+		}
+		return kind.TupleRawName + "{" + strings.Join(fields, ";") + "}"
+	}
+	return kind.String()
+}
+
+// sortedMethods returns the names of the given methods in alphabetical order.
+func sortedMethods(methods map[string]abi.Method) []string {
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedEvents returns the names of the given events in alphabetical order.
+func sortedEvents(events map[string]abi.Event) []string {
+	names := make([]string, 0, len(events))
+	for name := range events {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // capitalise makes the first character of a string upper case.
 func capitalise(input string) string {
 	return strings.ToUpper(input[:1]) + input[1:]
 }
 
+// decapitalise makes the first character of a string lower case.
+func decapitalise(input string) string {
+	return strings.ToLower(input[:1]) + input[1:]
+}
+
 // structured checks whether a method has enough information to return a proper
 // Go struct ot if flat returns are needed.
 func structured(method abi.Method) bool {
@@ -134,6 +134,32 @@ var bindTests = []struct {
 			 fmt.Println(str1, str2, res.Str1, res.Str2, err)
 		 }`,
 	},
+	// Test that tuples are bound to named Go structs
+	{
+		`TupleChecker`, ``, ``,
+		`
+			[
+				{"type":"function","name":"pair","constant":true,"inputs":[{"name":"p","type":"tuple","internalType":"struct TupleChecker.Pair","components":[{"name":"key","type":"bytes32"},{"name":"values","type":"uint8[2][]"}]}],"outputs":[{"name":"","type":"tuple[]","internalType":"struct TupleChecker.Pair[]","components":[{"name":"key","type":"bytes32"},{"name":"values","type":"uint8[2][]"}]}]},
+				{"type":"function","name":"nested","constant":false,"inputs":[{"name":"n","type":"tuple","components":[{"name":"owner","type":"address"},{"name":"inner","type":"tuple[2]","components":[{"name":"amount_wei","type":"uint256"},{"name":"note","type":"string"}]}]}],"outputs":[]},
+				{"type":"event","name":"Paired","inputs":[{"indexed":true,"name":"p","type":"tuple","internalType":"struct TupleChecker.Pair","components":[{"name":"key","type":"bytes32"},{"name":"values","type":"uint8[2][]"}]},{"indexed":false,"name":"q","type":"tuple","internalType":"struct TupleChecker.Pair","components":[{"name":"key","type":"bytes32"},{"name":"values","type":"uint8[2][]"}]}]}
+			]
+		`,
+		`if b, err := NewTupleChecker(common.Address{}, backends.NewNilBackend()); b == nil || err != nil {
+			 t.Fatalf("binding (%v) nil or error (%v) not nil", b, nil)
+		 } else if false { // Don't run, just compile and test types
+			 var pairs []Pair
+			 var err error
+
+			 pairs, err = b.Pair(nil, Pair{Key: [32]byte{}, Values: [][2]uint8{{1, 2}}})
+			 _, err     = b.Nested(nil, Struct1{Owner: common.Address{}, Inner: [2]Struct0{{AmountWei: big.NewInt(1), Note: ""}}})
+
+			 var event TupleCheckerPaired
+			 var hash common.Hash = event.P
+			 var pair Pair = event.Q
+
+			 fmt.Println(pairs, hash, pair, err)
+		 }`,
+	},
 	// Test that contract interactions (deploy, transact and call) generate working code
 	{
 		`Interactor`,
@@ -353,6 +379,87 @@ var bindTests = []struct {
 			}
 		`,
 	},
+	// Tests that events can be filtered and watched through the generated bindings
+	{
+		`Eventer`,
+		`
+			contract Eventer {
+				event Raised(address indexed sender, uint value);
+
+				function raise() {
+					Raised(msg.sender, 42);
+				}
+			}
+		`,
+		`602d600c600039602d6000f3602a600052337f19b70886e9e49b62d56c7144fe0aa82d93221a9ccdc642b0f2497225d10c7ad060206000a200`,
+		`[{"constant":false,"inputs":[],"name":"raise","outputs":[],"type":"function"},{"anonymous":false,"inputs":[{"indexed":true,"name":"sender","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Raised","type":"event"}]`,
+		`
+			// Generate a new random account and a funded simulator
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+			sim := backends.NewSimulatedBackend(core.GenesisAccount{Address: auth.From, Balance: big.NewInt(10000000000)})
+
+			// Deploy an event raiser contract and start watching for its events
+			_, _, eventer, err := DeployEventer(auth, sim)
+			if err != nil {
+				t.Fatalf("Failed to deploy eventer contract: %v", err)
+			}
+			sim.Commit()
+
+			raised := make(chan *EventerRaised, 2)
+			sub, err := eventer.WatchRaised(nil, raised, []common.Address{auth.From})
+			if err != nil {
+				t.Fatalf("Failed to subscribe to raised events: %v", err)
+			}
+			defer sub.Unsubscribe()
+
+			// Raise a few events and ensure they can be filtered and unpacked
+			for i := 0; i < 2; i++ {
+				if _, err := eventer.Raise(auth); err != nil {
+					t.Fatalf("Failed to raise event %d: %v", i, err)
+				}
+			}
+			sim.Commit()
+
+			it, err := eventer.FilterRaised(nil, []common.Address{auth.From})
+			if err != nil {
+				t.Fatalf("Failed to filter raised events: %v", err)
+			}
+			count := 0
+			for it.Next() {
+				if it.Event.Sender != auth.From || it.Event.Value.Cmp(big.NewInt(42)) != 0 {
+					t.Fatalf("Event %d mismatch: have %x/%v, want %x/%v", count, it.Event.Sender, it.Event.Value, auth.From, 42)
+				}
+				count++
+			}
+			if err := it.Error(); err != nil {
+				t.Fatalf("Failed to iterate raised events: %v", err)
+			}
+			if count != 2 {
+				t.Fatalf("Filtered event count mismatch: have %d, want %d", count, 2)
+			}
+			// Ensure the indexed arguments restrict the filtered events
+			if it, err = eventer.FilterRaised(nil, []common.Address{common.HexToAddress("0x01")}); err != nil {
+				t.Fatalf("Failed to filter foreign raised events: %v", err)
+			}
+			if it.Next() {
+				t.Fatalf("Foreign raised event found: %v", it.Event)
+			}
+			// Ensure the live events were delivered to the watcher too
+			for i := 0; i < 2; i++ {
+				select {
+				case event := <-raised:
+					if event.Sender != auth.From || event.Value.Cmp(big.NewInt(42)) != 0 {
+						t.Fatalf("Watched event %d mismatch: have %x/%v, want %x/%v", i, event.Sender, event.Value, auth.From, 42)
+					}
+				case err := <-sub.Err():
+					t.Fatalf("Raised event subscription failed: %v", err)
+				case <-time.After(3 * time.Second):
+					t.Fatalf("Watched event %d timeout", i)
+				}
+			}
+		`,
+	},
 }
 
 // Tests that packages generated by the binder can be successfully compiled and
@@ -386,7 +493,7 @@ func TestBindings(t *testing.T) {
 	// Generate the test suite for all the contracts
 	for i, tt := range bindTests {
 		// Generate the binding and create a Go source file in the workspace
-		bind, err := Bind([]string{tt.name}, []string{tt.abi}, []string{tt.bytecode}, "bindtest")
+		bind, err := Bind([]string{tt.name}, []string{tt.abi}, []string{tt.bytecode}, "bindtest", LangGo)
 		if err != nil {
 			t.Fatalf("test %d: failed to generate binding: %v", i, err)
 		}
@@ -410,3 +517,50 @@ func TestBindings(t *testing.T) {
 		t.Fatalf("failed to run binding test: %v\n%s", err, out)
 	}
 }
+
+// Tests that the bindings generated for each supported language match the golden
+// files stored in testdata.
+var goldenBindTests = []struct {
+	name     string
+	bytecode string
+	abi      string
+	golden   map[Lang]string
+}{
+	{
+		`Token`,
+		`6060604052`,
+		`
+			[
+				{"type":"function","name":"balanceOf","constant":true,"inputs":[{"name":"","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+				{"type":"function","name":"info","constant":true,"inputs":[],"outputs":[{"name":"name","type":"string"},{"name":"decimals","type":"uint8"}]},
+				{"type":"function","name":"transfer","constant":false,"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[{"name":"ok","type":"bool"}]},
+				{"type":"function","name":"store","constant":false,"inputs":[{"name":"p","type":"tuple","internalType":"struct Token.Pair","components":[{"name":"key","type":"bytes32"},{"name":"values","type":"uint8[2][]"}]}],"outputs":[]},
+				{"type":"constructor","inputs":[{"name":"supply","type":"uint256"}]},
+				{"type":"event","name":"Transfer","anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"memo","type":"string"},{"indexed":false,"name":"value","type":"uint256"}]}
+			]
+		`,
+		map[Lang]string{
+			LangGo:         "token.go.golden",
+			LangJava:       "token.java.golden",
+			LangTypeScript: "token.ts.golden",
+		},
+	},
+}
+
+func TestGoldenBindings(t *testing.T) {
+	for i, tt := range goldenBindTests {
+		for lang, file := range tt.golden {
+			code, err := Bind([]string{tt.name}, []string{tt.abi}, []string{tt.bytecode}, "bindtest", lang)
+			if err != nil {
+				t.Fatalf("test %d, %s: failed to generate binding: %v", i, file, err)
+			}
+			golden, err := ioutil.ReadFile(filepath.Join("testdata", file))
+			if err != nil {
+				t.Fatalf("test %d, %s: failed to read golden binding: %v", i, file, err)
+			}
+			if code != string(golden) {
+				t.Errorf("test %d, %s: binding mismatch:\nhave:\n%s\nwant:\n%s", i, file, code, golden)
+			}
+		}
+	}
+}
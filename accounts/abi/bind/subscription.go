@@ -0,0 +1,75 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import "sync"
+
+// NewSubscription runs a producer function as a subscription in a new goroutine.
+// The channel given to the producer is closed when Unsubscribe is called. If the
+// producer returns an error, it is delivered on the subscription's error channel.
+func NewSubscription(producer func(<-chan struct{}) error) Subscription {
+	sub := &funcSub{
+		quit: make(chan struct{}),
+		err:  make(chan error, 1),
+	}
+	go func() {
+		defer close(sub.err)
+		err := producer(sub.quit)
+
+		sub.lock.Lock()
+		defer sub.lock.Unlock()
+
+		if !sub.unsubscribed {
+			if err != nil {
+				sub.err <- err
+			}
+			sub.unsubscribed = true
+		}
+	}()
+	return sub
+}
+
+// funcSub is a subscription backed by a producer function.
+type funcSub struct {
+	quit chan struct{} // Quit channel to signal the producer to stop
+	err  chan error    // Error channel closed when the producer returns
+
+	unsubscribed bool       // Whether the subscription was already terminated
+	lock         sync.Mutex // Mutex protecting the termination flag
+}
+
+// Unsubscribe implements Subscription, signalling the producer to stop and
+// waiting until it returns.
+func (s *funcSub) Unsubscribe() {
+	s.lock.Lock()
+	if s.unsubscribed {
+		s.lock.Unlock()
+		return
+	}
+	s.unsubscribed = true
+	close(s.quit)
+	s.lock.Unlock()
+
+	// Wait for the producer to shut down
+	<-s.err
+}
+
+// Err implements Subscription, returning the channel carrying the producer's
+// failure, if any.
+func (s *funcSub) Err() <-chan error {
+	return s.err
+}
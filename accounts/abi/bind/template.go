@@ -22,6 +22,7 @@ import "github.com/ethereumproject/go-ethereum/accounts/abi"
 type tmplData struct {
 	Package   string                   // Name of the package to place the generated file in
 	Contracts map[string]*tmplContract // List of contracts to generate into this file
+	Structs   map[string]*tmplStruct   // Tuple types shared by all contracts in this file
 }
 
 // tmplContract contains the data needed to generate an individual contract binding.
@@ -32,6 +33,8 @@ type tmplContract struct {
 	Constructor abi.Method             // Contract constructor for deploy parametrization
 	Calls       map[string]*tmplMethod // Contract calls that only read state data
 	Transacts   map[string]*tmplMethod // Contract calls that write state data
+	Events      map[string]*tmplEvent  // Contract events accessors
+	Structs     map[string]*tmplStruct // Tuple types used by the contract
 }
 
 // tmplMethod is a wrapper around an abi.Method that contains a few preprocessed
@@ -42,14 +45,62 @@ type tmplMethod struct {
 	Structured bool       // Whether the returns should be accumulated into a contract
 }
 
-// tmplSource is the Go source template use to generate the contract binding
+// tmplEvent is a wrapper around an abi.Event that contains a few preprocessed
+// and cached data fields.
+type tmplEvent struct {
+	Original   abi.Event // Original event as parsed by the abi package
+	Normalized abi.Event // Normalized version of the parsed event (capitalized names, non-anonymous args)
+}
+
+// tmplStruct is a Go struct generated for a tuple type of the contract ABIs.
+type tmplStruct struct {
+	Name    string       // Type name of the generated struct
+	Fields  []*tmplField // Struct fields, one for each tuple component
+	Dynamic bool         // Whether the tuple is encoded out of place
+}
+
+// tmplField is a single field of a generated struct.
+type tmplField struct {
+	Name    string   // Field name, derived from the tuple component name
+	RawName string   // Tuple component name as listed in the ABI
+	Type    abi.Type // Solidity type of the field
+}
+
+// tmplSource is language to template mapping containing all the supported
+// programming languages the package can generate to.
+var tmplSource = map[Lang]string{
+	LangGo:         tmplSourceGo,
+	LangJava:       tmplSourceJava,
+	LangTypeScript: tmplSourceTypeScript,
+}
+
+// tmplSourceGo is the Go source template use to generate the contract binding
 // based on.
-const tmplSource = `
+const tmplSourceGo = `
 // This file is an automatically generated Go binding. Do not modify as any
 // change will likely be lost upon the next re-generation!
 
 package {{.Package}}
 
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereumproject/go-ethereum/accounts/abi"
+	"github.com/ethereumproject/go-ethereum/accounts/abi/bind"
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/core/vm"
+)
+
+{{range .Structs}}
+	// {{.Name}} is an auto generated low-level Go binding around a user-defined struct.
+	type {{.Name}} struct {
+	{{range .Fields}}
+	  {{.Name}} {{bindtype .Type}}{{end}}
+	}
+{{end}}
+
 {{range $contract := .Contracts}}
 	// {{.Type}}ABI is the input ABI used to generate the binding from.
 	const {{.Type}}ABI = ` + "`" + `{{.InputABI}}` + "`" + `
@@ -68,7 +119,7 @@ package {{.Package}}
 		  if err != nil {
 		    return common.Address{}, nil, nil, err
 		  }
-		  return address, tx, &{{.Type}}{ {{.Type}}Caller: {{.Type}}Caller{contract: contract}, {{.Type}}Transactor: {{.Type}}Transactor{contract: contract} }, nil
+		  return address, tx, &{{.Type}}{ {{.Type}}Caller: {{.Type}}Caller{contract: contract}, {{.Type}}Transactor: {{.Type}}Transactor{contract: contract}, {{.Type}}Filterer: {{.Type}}Filterer{contract: contract} }, nil
 		}
 	{{end}}
 
@@ -76,6 +127,7 @@ package {{.Package}}
 	type {{.Type}} struct {
 	  {{.Type}}Caller     // Read-only binding to the contract
 	  {{.Type}}Transactor // Write-only binding to the contract
+	  {{.Type}}Filterer   // Log filterer for contract events
 	}
 
 	// {{.Type}}Caller is an auto generated read-only Go binding around an Ethereum contract.
@@ -88,6 +140,11 @@ package {{.Package}}
 	  contract *bind.BoundContract // Generic contract wrapper for the low level calls
 	}
 
+	// {{.Type}}Filterer is an auto generated log filtering Go binding around an Ethereum contract events.
+	type {{.Type}}Filterer struct {
+	  contract *bind.BoundContract // Generic contract wrapper for the low level calls
+	}
+
 	// {{.Type}}Session is an auto generated Go binding around an Ethereum contract,
 	// with pre-set call and transact options.
 	type {{.Type}}Session struct {
@@ -127,16 +184,16 @@ package {{.Package}}
 
 	// New{{.Type}} creates a new instance of {{.Type}}, bound to a specific deployed contract.
 	func New{{.Type}}(address common.Address, backend bind.ContractBackend) (*{{.Type}}, error) {
-	  contract, err := bind{{.Type}}(address, backend.(bind.ContractCaller), backend.(bind.ContractTransactor))
+	  contract, err := bind{{.Type}}(address, backend.(bind.ContractCaller), backend.(bind.ContractTransactor), backend.(bind.ContractFilterer))
 	  if err != nil {
 	    return nil, err
 	  }
-	  return &{{.Type}}{ {{.Type}}Caller: {{.Type}}Caller{contract: contract}, {{.Type}}Transactor: {{.Type}}Transactor{contract: contract} }, nil
+	  return &{{.Type}}{ {{.Type}}Caller: {{.Type}}Caller{contract: contract}, {{.Type}}Transactor: {{.Type}}Transactor{contract: contract}, {{.Type}}Filterer: {{.Type}}Filterer{contract: contract} }, nil
 	}
 
 	// New{{.Type}}Caller creates a new read-only instance of {{.Type}}, bound to a specific deployed contract.
 	func New{{.Type}}Caller(address common.Address, caller bind.ContractCaller) (*{{.Type}}Caller, error) {
-	  contract, err := bind{{.Type}}(address, caller, nil)
+	  contract, err := bind{{.Type}}(address, caller, nil, nil)
 	  if err != nil {
 	    return nil, err
 	  }
@@ -145,20 +202,29 @@ package {{.Package}}
 
 	// New{{.Type}}Transactor creates a new write-only instance of {{.Type}}, bound to a specific deployed contract.
 	func New{{.Type}}Transactor(address common.Address, transactor bind.ContractTransactor) (*{{.Type}}Transactor, error) {
-	  contract, err := bind{{.Type}}(address, nil, transactor)
+	  contract, err := bind{{.Type}}(address, nil, transactor, nil)
 	  if err != nil {
 	    return nil, err
 	  }
 	  return &{{.Type}}Transactor{contract: contract}, nil
 	}
 
+	// New{{.Type}}Filterer creates a new log filterer instance of {{.Type}}, bound to a specific deployed contract.
+	func New{{.Type}}Filterer(address common.Address, filterer bind.ContractFilterer) (*{{.Type}}Filterer, error) {
+	  contract, err := bind{{.Type}}(address, nil, nil, filterer)
+	  if err != nil {
+	    return nil, err
+	  }
+	  return &{{.Type}}Filterer{contract: contract}, nil
+	}
+
 	// bind{{.Type}} binds a generic wrapper to an already deployed contract.
-	func bind{{.Type}}(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor) (*bind.BoundContract, error) {
+	func bind{{.Type}}(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
 	  parsed, err := abi.JSON(strings.NewReader({{.Type}}ABI))
 	  if err != nil {
 	    return nil, err
 	  }
-	  return bind.NewBoundContract(address, parsed, caller, transactor), nil
+	  return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
 	}
 
 	// Call invokes the (constant) contract method with params as input values and
@@ -256,5 +322,316 @@ package {{.Package}}
 		  return _{{$contract.Type}}.Contract.{{.Normalized.Name}}(&_{{$contract.Type}}.TransactOpts {{range $i, $_ := .Normalized.Inputs}}, {{.Name}}{{end}})
 		}
 	{{end}}
+
+	{{range .Events}}
+		// {{$contract.Type}}{{.Normalized.Name}}Iterator is returned from Filter{{.Normalized.Name}} and is used to iterate over the raw logs and unpacked data for {{.Normalized.Name}} events raised by the {{$contract.Type}} contract.
+		type {{$contract.Type}}{{.Normalized.Name}}Iterator struct {
+			Event *{{$contract.Type}}{{.Normalized.Name}} // Event containing the contract specifics and raw log
+
+			contract *bind.BoundContract // Generic contract to use for unpacking event data
+			event    string              // Event name to use for unpacking event data
+
+			logs vm.Logs // Logs remaining to be iterated over
+			fail error   // Occurred error to stop iteration
+		}
+
+		// Next advances the iterator to the subsequent event, returning whether there
+		// are any more events found. In case of an unpacking error, false is returned
+		// and Error() can be queried for the exact failure.
+		func (it *{{$contract.Type}}{{.Normalized.Name}}Iterator) Next() bool {
+			if it.fail != nil || len(it.logs) == 0 {
+				return false
+			}
+			it.Event = new({{$contract.Type}}{{.Normalized.Name}})
+			if err := it.contract.UnpackLog(it.Event, it.event, it.logs[0]); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = *it.logs[0]
+			it.logs = it.logs[1:]
+			return true
+		}
+
+		// Error returns any retrieval or parsing error occurred during filtering.
+		func (it *{{$contract.Type}}{{.Normalized.Name}}Iterator) Error() error {
+			return it.fail
+		}
+
+		// Close terminates the iteration process, releasing any pending logs.
+		func (it *{{$contract.Type}}{{.Normalized.Name}}Iterator) Close() error {
+			it.logs = nil
+			return nil
+		}
+
+		// {{$contract.Type}}{{.Normalized.Name}} represents a {{.Normalized.Name}} event raised by the {{$contract.Type}} contract.
+		type {{$contract.Type}}{{.Normalized.Name}} struct { {{range .Normalized.Inputs}}
+			{{.Name}} {{if .Indexed}}{{bindtopictype .Type}}{{else}}{{bindtype .Type}}{{end}}; {{end}}
+			Raw vm.Log // Blockchain specific contextual infos
+		}
+
+		// Filter{{.Normalized.Name}} is a free log retrieval operation binding the contract event 0x{{printf "%x" .Original.Id}}.
+		func (_{{$contract.Type}} *{{$contract.Type}}Filterer) Filter{{.Normalized.Name}}(opts *bind.FilterOpts{{range .Normalized.Inputs}}{{if .Indexed}}, {{decapitalise .Name}} []{{bindtype .Type}}{{end}}{{end}}) (*{{$contract.Type}}{{.Normalized.Name}}Iterator, error) {
+			{{range .Normalized.Inputs}}
+			{{if .Indexed}}var {{decapitalise .Name}}Rule []interface{}
+			for _, {{decapitalise .Name}}Item := range {{decapitalise .Name}} {
+				{{decapitalise .Name}}Rule = append({{decapitalise .Name}}Rule, {{decapitalise .Name}}Item)
+			}{{end}}{{end}}
+
+			logs, err := _{{$contract.Type}}.contract.FilterLogs(opts, "{{.Original.Name}}"{{range .Normalized.Inputs}}{{if .Indexed}}, {{decapitalise .Name}}Rule{{end}}{{end}})
+			if err != nil {
+				return nil, err
+			}
+			return &{{$contract.Type}}{{.Normalized.Name}}Iterator{contract: _{{$contract.Type}}.contract, event: "{{.Original.Name}}", logs: logs}, nil
+		}
+
+		// Watch{{.Normalized.Name}} is a free log subscription operation binding the contract event 0x{{printf "%x" .Original.Id}}.
+		func (_{{$contract.Type}} *{{$contract.Type}}Filterer) Watch{{.Normalized.Name}}(opts *bind.WatchOpts, sink chan<- *{{$contract.Type}}{{.Normalized.Name}}{{range .Normalized.Inputs}}{{if .Indexed}}, {{decapitalise .Name}} []{{bindtype .Type}}{{end}}{{end}}) (bind.Subscription, error) {
+			{{range .Normalized.Inputs}}
+			{{if .Indexed}}var {{decapitalise .Name}}Rule []interface{}
+			for _, {{decapitalise .Name}}Item := range {{decapitalise .Name}} {
+				{{decapitalise .Name}}Rule = append({{decapitalise .Name}}Rule, {{decapitalise .Name}}Item)
+			}{{end}}{{end}}
+
+			logs, sub, err := _{{$contract.Type}}.contract.WatchLogs(opts, "{{.Original.Name}}"{{range .Normalized.Inputs}}{{if .Indexed}}, {{decapitalise .Name}}Rule{{end}}{{end}})
+			if err != nil {
+				return nil, err
+			}
+			return bind.NewSubscription(func(quit <-chan struct{}) error {
+				defer sub.Unsubscribe()
+				for {
+					select {
+					case log := <-logs:
+						// New log arrived, parse the event and forward to the user
+						event := new({{$contract.Type}}{{.Normalized.Name}})
+						if err := _{{$contract.Type}}.contract.UnpackLog(event, "{{.Original.Name}}", log); err != nil {
+							return err
+						}
+						event.Raw = *log
+
+						select {
+						case sink <- event:
+						case err := <-sub.Err():
+							return err
+						case <-quit:
+							return nil
+						}
+					case err := <-sub.Err():
+						return err
+					case <-quit:
+						return nil
+					}
+				}
+			}), nil
+		}
+	{{end}}
 {{end}}
 `
+
+// tmplSourceJava is the Java source template use to generate the contract binding
+// based on. The bindings are built on top of the web3j library.
+const tmplSourceJava = `// This file is an automatically generated Java binding. Do not modify as any
+// change will likely be lost upon the next re-generation!
+
+package {{.Package}};
+
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.Collections;
+import java.util.List;
+
+import org.web3j.abi.FunctionEncoder;
+import org.web3j.abi.TypeReference;
+import org.web3j.abi.datatypes.*;
+import org.web3j.abi.datatypes.generated.*;
+import org.web3j.protocol.Web3j;
+import org.web3j.protocol.core.RemoteCall;
+import org.web3j.protocol.core.methods.response.Log;
+import org.web3j.protocol.core.methods.response.TransactionReceipt;
+import org.web3j.tx.Contract;
+import org.web3j.tx.TransactionManager;
+import org.web3j.tx.gas.ContractGasProvider;
+{{range $contract := .Contracts}}
+// {{.Type}} is an auto generated Java binding around an Ethereum contract.
+public class {{.Type}} extends Contract {
+	// ABI is the input ABI used to generate the binding from.
+	public static final String ABI = "{{quote .InputABI}}";
+
+	// BYTECODE is the compiled bytecode used for deploying new contracts.
+	public static final String BYTECODE = "{{if .InputBin}}0x{{.InputBin}}{{end}}";
+{{range .Structs}}
+	// {{.Name}} is an auto generated Java binding around a user-defined struct.
+	public static class {{.Name}} extends {{if .Dynamic}}DynamicStruct{{else}}StaticStruct{{end}} {
+{{- range .Fields}}
+		public final {{bindtype .Type}} {{decapitalise .Name}};
+{{- end}}
+
+		public {{.Name}}({{range $i, $_ := .Fields}}{{if $i}}, {{end}}{{bindtype .Type}} {{decapitalise .Name}}{{end}}) {
+			super({{range $i, $_ := .Fields}}{{if $i}}, {{end}}{{decapitalise .Name}}{{end}});
+{{- range .Fields}}
+			this.{{decapitalise .Name}} = {{decapitalise .Name}};
+{{- end}}
+		}
+	}
+{{end}}
+	protected {{.Type}}(String address, Web3j web3j, TransactionManager transactionManager, ContractGasProvider gasProvider) {
+		super(BYTECODE, address, web3j, transactionManager, gasProvider);
+	}
+
+	// load binds an instance of {{.Type}} to an already deployed contract.
+	public static {{.Type}} load(String address, Web3j web3j, TransactionManager transactionManager, ContractGasProvider gasProvider) {
+		return new {{.Type}}(address, web3j, transactionManager, gasProvider);
+	}
+{{if .InputBin}}
+	// deploy deploys a new Ethereum contract, binding an instance of {{.Type}} to it.
+	public static RemoteCall<{{.Type}}> deploy(Web3j web3j, TransactionManager transactionManager, ContractGasProvider gasProvider{{range .Constructor.Inputs}}, {{bindtype .Type}} {{.Name}}{{end}}) {
+		String encodedConstructor = FunctionEncoder.encodeConstructor(Arrays.<Type>asList({{range $i, $_ := .Constructor.Inputs}}{{if $i}}, {{end}}{{.Name}}{{end}}));
+		return deployRemoteCall({{.Type}}.class, web3j, transactionManager, gasProvider, BYTECODE, encodedConstructor);
+	}
+{{end}}
+{{- range .Calls}}
+	// {{.Original.Name}} is a free data retrieval call binding the contract method 0x{{printf "%x" .Original.Id}}.
+	//
+	// Solidity: {{.Original.String}}
+	public RemoteCall<{{if eq (len .Normalized.Outputs) 1}}{{bindtype (index .Normalized.Outputs 0).Type}}{{else}}List<Type>{{end}}> {{.Original.Name}}({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{bindtype .Type}} {{.Name}}{{end}}) {
+		final Function function = new Function("{{.Original.Name}}",
+			Arrays.<Type>asList({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{.Name}}{{end}}),
+			Arrays.<TypeReference<?>>asList({{range $i, $_ := .Normalized.Outputs}}{{if $i}}, {{end}}new TypeReference<{{bindtype .Type}}>() {}{{end}}));
+		return {{if eq (len .Normalized.Outputs) 1}}executeRemoteCallSingleValueReturn{{else}}executeRemoteCallMultipleValueReturn{{end}}(function);
+	}
+{{end}}
+{{- range .Transacts}}
+	// {{.Original.Name}} is a paid mutator transaction binding the contract method 0x{{printf "%x" .Original.Id}}.
+	//
+	// Solidity: {{.Original.String}}
+	public RemoteCall<TransactionReceipt> {{.Original.Name}}({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{bindtype .Type}} {{.Name}}{{end}}) {
+		final Function function = new Function("{{.Original.Name}}",
+			Arrays.<Type>asList({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{.Name}}{{end}}),
+			Collections.<TypeReference<?>>emptyList());
+		return executeRemoteCallTransaction(function);
+	}
+{{end}}
+{{- range $event := .Events}}
+	// {{upper .Original.Name}}_EVENT describes the contract event 0x{{printf "%x" .Original.Id}}.
+	public static final Event {{upper .Original.Name}}_EVENT = new Event("{{.Original.Name}}",
+		Arrays.<TypeReference<?>>asList({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{if .Indexed}}new TypeReference<{{bindtopictype .Type}}>(true) {}{{else}}new TypeReference<{{bindtype .Type}}>() {}{{end}}{{end}}));
+
+	// {{.Normalized.Name}}EventResponse represents a {{.Original.Name}} event raised by the {{$contract.Type}} contract.
+	public static class {{.Normalized.Name}}EventResponse {
+{{- range .Normalized.Inputs}}
+		public {{if .Indexed}}{{bindtopictype .Type}}{{else}}{{bindtype .Type}}{{end}} {{decapitalise .Name}};
+{{- end}}
+		public Log log; // Blockchain specific contextual infos
+	}
+
+	// get{{.Normalized.Name}}Events extracts the {{.Original.Name}} events raised by the {{$contract.Type}} contract within a transaction.
+	public List<{{.Normalized.Name}}EventResponse> get{{.Normalized.Name}}Events(TransactionReceipt receipt) {
+		List<Contract.EventValuesWithLog> valueList = extractEventParametersWithLog({{upper .Original.Name}}_EVENT, receipt);
+		List<{{.Normalized.Name}}EventResponse> responses = new ArrayList<>(valueList.size());
+		for (Contract.EventValuesWithLog eventValues : valueList) {
+			{{.Normalized.Name}}EventResponse response = new {{.Normalized.Name}}EventResponse();
+{{- range $i, $_ := .Normalized.Inputs}}
+			response.{{decapitalise .Name}} = ({{if .Indexed}}{{bindtopictype .Type}}) eventValues.getIndexedValues(){{else}}{{bindtype .Type}}) eventValues.getNonIndexedValues(){{end}}.get({{argindex $event.Normalized.Inputs $i}});
+{{- end}}
+			response.log = eventValues.getLog();
+			responses.add(response);
+		}
+		return responses;
+	}
+{{end -}}
+}
+{{end}}`
+
+// tmplSourceTypeScript is the TypeScript source template use to generate the
+// contract binding based on. The bindings are built on top of ethers.js (v5).
+const tmplSourceTypeScript = `// This file is an automatically generated TypeScript binding. Do not modify as any
+// change will likely be lost upon the next re-generation!
+
+import { BigNumber, BigNumberish, BytesLike, CallOverrides, Contract, ContractFactory, ContractTransaction, Event, PayableOverrides, Signer, providers } from "ethers";
+{{range .Structs}}
+// {{.Name}} is an auto generated TypeScript binding around a user-defined struct.
+export interface {{.Name}} {
+{{- range .Fields}}
+	{{.RawName}}: {{bindtype .Type}};
+{{- end}}
+}
+{{end}}
+{{- range $contract := .Contracts}}
+// {{.Type}}ABI is the input ABI used to generate the binding from.
+export const {{.Type}}ABI = {{.InputABI}};
+{{if .InputBin}}
+// {{.Type}}Bin is the compiled bytecode used for deploying new contracts.
+export const {{.Type}}Bin = "0x{{.InputBin}}";
+{{end}}
+{{- range .Events}}
+// {{$contract.Type}}{{.Normalized.Name}} represents a {{.Original.Name}} event raised by the {{$contract.Type}} contract.
+export interface {{$contract.Type}}{{.Normalized.Name}} {
+{{- range .Normalized.Inputs}}
+	{{decapitalise .Name}}: {{if .Indexed}}{{bindtopictype .Type}}{{else}}{{bindtype .Type}}{{end}};
+{{- end}}
+	raw: Event; // Blockchain specific contextual infos
+}
+{{end}}
+// {{.Type}} is an auto generated TypeScript binding around an Ethereum contract.
+export class {{.Type}} {
+	readonly contract: Contract; // Generic contract wrapper for the low level calls
+
+	constructor(address: string, signerOrProvider: Signer | providers.Provider) {
+		this.contract = new Contract(address, {{.Type}}ABI, signerOrProvider);
+	}
+{{if .InputBin}}
+	// deploy deploys a new Ethereum contract, binding an instance of {{.Type}} to it.
+	static async deploy(signer: Signer, {{range .Constructor.Inputs}}{{.Name}}: {{bindargtype .Type}}, {{end}}overrides: PayableOverrides = {}): Promise<{{.Type}}> {
+		const factory = new ContractFactory({{.Type}}ABI, {{.Type}}Bin, signer);
+		const contract = await factory.deploy({{range .Constructor.Inputs}}{{.Name}}, {{end}}overrides);
+		await contract.deployed();
+		return new {{.Type}}(contract.address, signer);
+	}
+{{end}}
+{{- range .Calls}}
+	// {{.Original.Name}} is a free data retrieval call binding the contract method 0x{{printf "%x" .Original.Id}}.
+	//
+	// Solidity: {{.Original.String}}
+	async {{.Original.Name}}({{range .Normalized.Inputs}}{{.Name}}: {{bindargtype .Type}}, {{end}}overrides: CallOverrides = {}): Promise<{{if eq (len .Normalized.Outputs) 0}}void{{else if eq (len .Normalized.Outputs) 1}}{{bindtype (index .Normalized.Outputs 0).Type}}{{else}}[{{range $i, $_ := .Normalized.Outputs}}{{if $i}}, {{end}}{{bindtype .Type}}{{end}}]{{end}}> {
+		return this.contract["{{.Original.Sig}}"]({{range .Normalized.Inputs}}{{.Name}}, {{end}}overrides);
+	}
+{{end}}
+{{- range .Transacts}}
+	// {{.Original.Name}} is a paid mutator transaction binding the contract method 0x{{printf "%x" .Original.Id}}.
+	//
+	// Solidity: {{.Original.String}}
+	async {{.Original.Name}}({{range .Normalized.Inputs}}{{.Name}}: {{bindargtype .Type}}, {{end}}overrides: PayableOverrides = {}): Promise<ContractTransaction> {
+		return this.contract["{{.Original.Sig}}"]({{range .Normalized.Inputs}}{{.Name}}, {{end}}overrides);
+	}
+{{end}}
+{{- range .Events}}
+	// filter{{.Normalized.Name}} is a free log retrieval operation binding the contract event 0x{{printf "%x" .Original.Id}}.
+	async filter{{.Normalized.Name}}({{range .Normalized.Inputs}}{{if .Indexed}}{{decapitalise .Name}}?: {{bindargtype .Type}} | {{bindargtype .Type}}[] | null, {{end}}{{end}}fromBlock?: providers.BlockTag, toBlock?: providers.BlockTag): Promise<{{$contract.Type}}{{.Normalized.Name}}[]> {
+		const filter = this.contract.filters["{{.Original.Sig}}"]({{range $i, $_ := indexed .Normalized.Inputs}}{{if $i}}, {{end}}{{decapitalise .Name}}{{end}});
+		const events = await this.contract.queryFilter(filter, fromBlock, toBlock);
+		return events.map(event => this.parse{{.Normalized.Name}}(event));
+	}
+
+	// watch{{.Normalized.Name}} is a free log subscription operation binding the contract event 0x{{printf "%x" .Original.Id}}.
+	// The returned function cancels the subscription.
+	watch{{.Normalized.Name}}(listener: (event: {{$contract.Type}}{{.Normalized.Name}}) => void{{range .Normalized.Inputs}}{{if .Indexed}}, {{decapitalise .Name}}?: {{bindargtype .Type}} | {{bindargtype .Type}}[] | null{{end}}{{end}}): () => void {
+		const filter = this.contract.filters["{{.Original.Sig}}"]({{range $i, $_ := indexed .Normalized.Inputs}}{{if $i}}, {{end}}{{decapitalise .Name}}{{end}});
+		const handler = (...args: any[]) => listener(this.parse{{.Normalized.Name}}(args[args.length - 1]));
+		this.contract.on(filter, handler);
+		return () => {
+			this.contract.off(filter, handler);
+		};
+	}
+
+	// parse{{.Normalized.Name}} unpacks a raw {{.Original.Name}} event into its typed representation.
+	private parse{{.Normalized.Name}}(event: Event): {{$contract.Type}}{{.Normalized.Name}} {
+		const args = event.args!;
+		return {
+{{- range $i, $_ := .Normalized.Inputs}}
+			{{decapitalise .Name}}: args[{{$i}}]{{if and .Indexed (topichashed .Type)}}.hash{{end}},
+{{- end}}
+			raw: event,
+		};
+	}
+{{end -}}
+}
+{{end}}`
@@ -0,0 +1,95 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereumproject/go-ethereum/accounts/abi"
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/crypto"
+)
+
+// makeTopics converts the accepted values of each indexed event argument into
+// the topic filters matching them. An empty list of values is converted into a
+// wildcard, accepting any topic in its position.
+func makeTopics(query ...[]interface{}) ([][]common.Hash, error) {
+	topics := make([][]common.Hash, len(query))
+	for i, filter := range query {
+		if len(filter) == 0 {
+			topics[i] = []common.Hash{{}}
+			continue
+		}
+		for _, rule := range filter {
+			topic, err := makeTopic(rule)
+			if err != nil {
+				return nil, err
+			}
+			topics[i] = append(topics[i], topic)
+		}
+	}
+	return topics, nil
+}
+
+// makeTopic converts a single indexed event argument value into the topic it is
+// logged as. Static values are padded to 32 bytes, dynamic ones are hashed.
+func makeTopic(rule interface{}) (common.Hash, error) {
+	var topic common.Hash
+
+	switch rule := rule.(type) {
+	case common.Hash:
+		copy(topic[:], rule[:])
+	case common.Address:
+		copy(topic[common.HashLength-common.AddressLength:], rule[:])
+	case *big.Int:
+		copy(topic[:], abi.U256(rule))
+	case bool:
+		if rule {
+			topic[common.HashLength-1] = 1
+		}
+	case int8:
+		copy(topic[:], abi.U256(big.NewInt(int64(rule))))
+	case int16:
+		copy(topic[:], abi.U256(big.NewInt(int64(rule))))
+	case int32:
+		copy(topic[:], abi.U256(big.NewInt(int64(rule))))
+	case int64:
+		copy(topic[:], abi.U256(big.NewInt(rule)))
+	case uint8:
+		topic[common.HashLength-1] = rule
+	case uint16:
+		copy(topic[:], abi.U256(new(big.Int).SetUint64(uint64(rule))))
+	case uint32:
+		copy(topic[:], abi.U256(new(big.Int).SetUint64(uint64(rule))))
+	case uint64:
+		copy(topic[:], abi.U256(new(big.Int).SetUint64(rule)))
+	case string:
+		topic = crypto.Keccak256Hash([]byte(rule))
+	case []byte:
+		topic = crypto.Keccak256Hash(rule)
+	default:
+		// Fixed byte arrays are left aligned within the topic
+		val := reflect.ValueOf(rule)
+		if val.Kind() != reflect.Array || val.Type().Elem().Kind() != reflect.Uint8 || val.Len() > common.HashLength {
+			return common.Hash{}, fmt.Errorf("unsupported indexed type: %T", rule)
+		}
+		reflect.Copy(reflect.ValueOf(topic[:]), val)
+	}
+	return topic, nil
+}
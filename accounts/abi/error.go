@@ -40,11 +40,7 @@ func sliceTypeCheck(t Type, val reflect.Value) error {
 		return typeErr(formatSliceString(t.Elem.Kind, t.SliceSize), formatSliceString(val.Type().Elem().Kind(), val.Len()))
 	}
 
-	if t.Elem.IsSlice {
-		if val.Len() > 0 {
-			return sliceTypeCheck(*t.Elem, val.Index(0))
-		}
-	} else if t.Elem.IsArray {
+	if (t.Elem.IsSlice || t.Elem.IsArray) && val.Len() > 0 {
 		return sliceTypeCheck(*t.Elem, val.Index(0))
 	}
 
@@ -17,6 +17,7 @@
 package abi
 
 import (
+	"math/big"
 	"strings"
 	"testing"
 
@@ -54,3 +55,48 @@ func TestEventId(t *testing.T) {
 		}
 	}
 }
+
+// Tests that logs are unpacked from both their topics and data.
+func TestEventUnpackLog(t *testing.T) {
+	definition := `[
+	{ "type" : "event", "name" : "transfer", "inputs": [{ "name" : "from", "type": "address", "indexed": true }, { "name" : "memo", "type": "string", "indexed": true }, { "name" : "amount", "type": "uint256" }, { "name" : "", "type": "uint8" }] },
+	{ "type" : "event", "name" : "ping", "anonymous": true, "inputs": [{ "name" : "id", "type": "uint64", "indexed": true }] }
+	]`
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	memo := crypto.Keccak256Hash([]byte("hello"))
+
+	var transfer struct {
+		From   common.Address
+		Memo   common.Hash
+		Amount *big.Int
+		Arg3   uint8
+	}
+	topics := []common.Hash{abi.Events["transfer"].Id(), common.BytesToHash(from[:]), memo}
+	data := append(U256(big.NewInt(1000)), U256(big.NewInt(7))...)
+
+	if err := abi.UnpackLog(&transfer, "transfer", topics, data); err != nil {
+		t.Fatalf("failed to unpack transfer: %v", err)
+	}
+	if transfer.From != from || transfer.Memo != memo || transfer.Amount.Cmp(big.NewInt(1000)) != 0 || transfer.Arg3 != 7 {
+		t.Errorf("transfer mismatch: have %x/%x/%v/%d, want %x/%x/%v/%d", transfer.From, transfer.Memo, transfer.Amount, transfer.Arg3, from, memo, 1000, 7)
+	}
+	// Logs of other events and truncated topics must be rejected
+	if err := abi.UnpackLog(&transfer, "transfer", topics[1:], data); err == nil {
+		t.Errorf("unpacked log without event signature")
+	}
+	if err := abi.UnpackLog(&transfer, "transfer", topics[:2], data); err == nil {
+		t.Errorf("unpacked log with missing topics")
+	}
+	// Anonymous events have no signature topic
+	var ping struct{ Id uint64 }
+	if err := abi.UnpackLog(&ping, "ping", []common.Hash{common.BigToHash(big.NewInt(3))}, nil); err != nil {
+		t.Fatalf("failed to unpack ping: %v", err)
+	}
+	if ping.Id != 3 {
+		t.Errorf("ping id mismatch: have %d, want %d", ping.Id, 3)
+	}
+}
@@ -90,6 +90,35 @@ func set(dst, src reflect.Value, output Argument) error {
 		dst.Set(src)
 	case dstType.Kind() == reflect.Ptr:
 		return set(dst.Elem(), src, output)
+	case dstType.Kind() == reflect.Struct && srcType.Kind() == reflect.Struct && output.Type.T == TupleTy:
+		// tuple components are matched to the struct fields by name
+		for i := 0; i < srcType.NumField(); i++ {
+			name := srcType.Field(i).Name
+			field := dst.FieldByName(name)
+			if !field.IsValid() {
+				return fmt.Errorf("abi: field %s can't be found in the given value", name)
+			}
+			if err := set(field, src.Field(i), Argument{Type: *output.Type.TupleElems[i]}); err != nil {
+				return err
+			}
+		}
+	case dstType.Kind() == reflect.Slice && srcType.Kind() == reflect.Slice && output.Type.T == SliceTy:
+		slice := reflect.MakeSlice(dstType, src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := set(slice.Index(i), src.Index(i), Argument{Type: *output.Type.Elem}); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+	case dstType.Kind() == reflect.Array && srcType.Kind() == reflect.Array && output.Type.T == ArrayTy:
+		if dst.Len() != src.Len() {
+			return fmt.Errorf("abi: cannot unmarshal src (len=%d) in to dst (len=%d)", src.Len(), dst.Len())
+		}
+		for i := 0; i < src.Len(); i++ {
+			if err := set(dst.Index(i), src.Index(i), Argument{Type: *output.Type.Elem}); err != nil {
+				return err
+			}
+		}
 	default:
 		return fmt.Errorf("abi: cannot unmarshal %v in to %v", src.Type(), dst.Type())
 	}
@@ -21,6 +21,9 @@ import (
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
+
+	"github.com/ethereumproject/go-ethereum/common"
 )
 
 const (
@@ -34,6 +37,8 @@ const (
 	BytesTy
 	HashTy
 	RealTy
+	ArrayTy
+	TupleTy
 )
 
 // Type is the reflection of the supported argument type
@@ -48,53 +53,114 @@ type Type struct {
 	Size int
 	T    byte // Our own type checking
 
+	TupleElems    []*Type  // Component types of a tuple
+	TupleRawNames []string // Raw component names of a tuple, as listed in the ABI
+	TupleRawName  string   // Struct name of a tuple as declared in Solidity, if known
+
 	stringKind string // holds the unparsed string for deriving signatures
 }
 
 var (
-	// fullTypeRegex parses the abi types
+	// typeRegex parses the abi sub types
 	//
 	// Types can be in the format of:
 	//
-	// 	Input  = Type [ "[" [ Number ] "]" ] Name .
-	// 	Type   = [ "u" ] "int" [ Number ] .
+	// 	Type = ( [ "u" ] "int" | "bytes" ) [ Number ] | "address" | "bool" | "string" .
 	//
 	// Examples:
 	//
-	//      string     int       uint       real
-	//      string32   int8      uint8      uint[]
-	//      address    int256    uint256    real[2]
-	fullTypeRegex = regexp.MustCompile("([a-zA-Z0-9]+)(\\[([0-9]*)?\\])?")
-	// typeRegex parses the abi sub types
-	typeRegex = regexp.MustCompile("([a-zA-Z]+)([0-9]*)?")
+	//      string     int       uint       address
+	//      bytes      int8      uint8      bytes32
+	typeRegex = regexp.MustCompile("^([a-zA-Z]+)([0-9]*)$")
 )
 
-// NewType creates a new reflection type of abi type given in t.
+// NewType creates a new reflection type of abi type given in t. Arrays of any
+// dimension are supported, e.g. uint8[2][] for a dynamic array of uint8 pairs.
 func NewType(t string) (typ Type, err error) {
-	res := fullTypeRegex.FindAllStringSubmatch(t, -1)[0]
-	// check if type is slice and parse type.
-	switch {
-	case res[3] != "":
-		// err is ignored. Already checked for number through the regexp
-		typ.SliceSize, _ = strconv.Atoi(res[3])
-		typ.IsArray = true
-	case res[2] != "":
-		typ.IsSlice, typ.SliceSize = true, -1
-	case res[0] == "":
+	return newType(t, "", nil)
+}
+
+// newType creates a new reflection type of abi type given in t, using the given
+// components for tuples. The internal type is the type as declared in Solidity,
+// used only to name the structs of tuples.
+func newType(t string, internalType string, components []argumentMarshaling) (typ Type, err error) {
+	// check that array brackets are balanced if they exist
+	if strings.Count(t, "[") != strings.Count(t, "]") {
 		return Type{}, fmt.Errorf("abi: type parse error: %s", t)
 	}
-	if typ.IsArray || typ.IsSlice {
-		sliceType, err := NewType(res[1])
+	// The outermost dimension of an array is the last one, parse the element type
+	// recursively and wrap it in the array or slice
+	if strings.HasSuffix(t, "]") {
+		i := strings.LastIndex(t, "[")
+
+		elemInternalType := internalType
+		if j := strings.LastIndex(internalType, "["); j >= 0 && strings.HasSuffix(internalType, "]") {
+			elemInternalType = internalType[:j]
+		}
+		elem, err := newType(t[:i], elemInternalType, components)
 		if err != nil {
 			return Type{}, err
 		}
-		typ.Elem = &sliceType
-		typ.stringKind = sliceType.stringKind + t[len(res[1]):]
+		if size := t[i+1 : len(t)-1]; size == "" {
+			typ.IsSlice, typ.SliceSize = true, -1
+			typ.Kind, typ.T = reflect.Slice, SliceTy
+		} else {
+			if typ.SliceSize, err = strconv.Atoi(size); err != nil {
+				return Type{}, fmt.Errorf("abi: error parsing array size: %v", err)
+			}
+			if typ.SliceSize <= 0 {
+				return Type{}, fmt.Errorf("abi: invalid array size %d", typ.SliceSize)
+			}
+			typ.IsArray = true
+			typ.Kind, typ.T = reflect.Array, ArrayTy
+		}
+		typ.Elem = &elem
+		typ.stringKind = elem.stringKind + t[i:]
 		return typ, nil
 	}
+	// Tuples are assembled from their components
+	if t == "tuple" {
+		if len(components) == 0 {
+			return Type{}, fmt.Errorf("abi: tuple without components")
+		}
+		var (
+			names = make(map[string]bool)
+			sigs  = make([]string, len(components))
+		)
+		for i, c := range components {
+			elem, err := newType(c.Type, c.InternalType, c.Components)
+			if err != nil {
+				return Type{}, err
+			}
+			name := ToCamelCase(c.Name)
+			if name == "" {
+				return Type{}, fmt.Errorf("abi: anonymous or underscored tuple component is not supported")
+			}
+			if names[name] {
+				return Type{}, fmt.Errorf("abi: duplicated tuple component name %s", name)
+			}
+			names[name] = true
 
+			typ.TupleElems = append(typ.TupleElems, &elem)
+			typ.TupleRawNames = append(typ.TupleRawNames, c.Name)
+			sigs[i] = elem.stringKind
+		}
+		// Solidity declares structs as "struct Contract.Name", keep only the name
+		if strings.HasPrefix(internalType, "struct ") {
+			name := strings.TrimPrefix(internalType, "struct ")
+			typ.TupleRawName = name[strings.LastIndex(name, ".")+1:]
+		}
+		typ.Kind, typ.T = reflect.Struct, TupleTy
+		typ.stringKind = "(" + strings.Join(sigs, ",") + ")"
+		return typ, nil
+	}
 	// parse the type and size of the abi-type.
-	parsedType := typeRegex.FindAllStringSubmatch(res[1], -1)[0]
+	parsed := typeRegex.FindAllStringSubmatch(t, -1)
+	if len(parsed) == 0 {
+		return Type{}, fmt.Errorf("abi: type parse error: %s", t)
+	}
+	parsedType := parsed[0]
+
 	// varSize is the size of the variable
 	var varSize int
 	if len(parsedType[2]) > 0 {
@@ -141,10 +207,12 @@ func NewType(t string) (typ Type, err error) {
 		typ.Elem = &sliceType
 		if varSize == 0 {
 			typ.IsSlice = true
+			typ.Kind = reflect.Slice
 			typ.T = BytesTy
 			typ.SliceSize = -1
 		} else {
 			typ.IsArray = true
+			typ.Kind = reflect.Array
 			typ.T = FixedBytesTy
 			typ.SliceSize = varSize
 		}
@@ -168,23 +236,137 @@ func (t Type) pack(v reflect.Value) ([]byte, error) {
 		return nil, err
 	}
 
-	if (t.IsSlice || t.IsArray) && t.T != BytesTy && t.T != FixedBytesTy {
-		var packed []byte
+	switch t.T {
+	case SliceTy, ArrayTy:
+		types := make([]Type, v.Len())
+		values := make([]reflect.Value, v.Len())
 		for i := 0; i < v.Len(); i++ {
-			val, err := t.Elem.pack(v.Index(i))
-			if err != nil {
-				return nil, err
+			types[i], values[i] = *t.Elem, v.Index(i)
+		}
+		packed, err := packSequence(types, values)
+		if err != nil {
+			return nil, err
+		}
+		// Dynamic arrays are prefixed by their element count
+		if t.T == SliceTy {
+			return append(packNum(reflect.ValueOf(v.Len())), packed...), nil
+		}
+		return packed, nil
+
+	case TupleTy:
+		types := make([]Type, len(t.TupleElems))
+		values := make([]reflect.Value, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			field := v.FieldByName(ToCamelCase(t.TupleRawNames[i]))
+			if !field.IsValid() {
+				return nil, fmt.Errorf("abi: field %s for tuple not found in the given struct", ToCamelCase(t.TupleRawNames[i]))
 			}
-			packed = append(packed, val...)
+			types[i], values[i] = *elem, field
 		}
-		return packBytesSlice(packed, v.Len()), nil
+		return packSequence(types, values)
 	}
-
 	return packElement(t, v), nil
 }
 
-// requireLengthPrefix returns whether the type requires any sort of length
-// prefixing.
-func (t Type) requiresLengthPrefix() bool {
-	return t.T != FixedBytesTy && (t.T == StringTy || t.T == BytesTy || t.IsSlice || t.IsArray)
+// isDynamic returns whether the type is encoded out of place, referenced by an
+// offset from within the static part of the encoding it is contained in.
+func (t Type) isDynamic() bool {
+	switch t.T {
+	case StringTy, BytesTy, SliceTy:
+		return true
+	case ArrayTy:
+		return t.Elem.isDynamic()
+	case TupleTy:
+		for _, elem := range t.TupleElems {
+			if elem.isDynamic() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// headSize returns the number of bytes the type takes up in the static part of
+// the encoding it is contained in. Static arrays and tuples are encoded in place,
+// everything else takes up a single word.
+func (t Type) headSize() int {
+	if t.isDynamic() {
+		return 32
+	}
+	switch t.T {
+	case ArrayTy:
+		return t.SliceSize * t.Elem.headSize()
+	case TupleTy:
+		size := 0
+		for _, elem := range t.TupleElems {
+			size += elem.headSize()
+		}
+		return size
+	}
+	return 32
+}
+
+// reflectType returns the Go type a value of the abi type is unpacked into.
+func (t Type) reflectType() reflect.Type {
+	switch t.T {
+	case IntTy, UintTy:
+		switch t.Kind {
+		case reflect.Uint8:
+			return reflect.TypeOf(uint8(0))
+		case reflect.Uint16:
+			return reflect.TypeOf(uint16(0))
+		case reflect.Uint32:
+			return reflect.TypeOf(uint32(0))
+		case reflect.Uint64:
+			return reflect.TypeOf(uint64(0))
+		case reflect.Int8:
+			return int8_t
+		case reflect.Int16:
+			return int16_t
+		case reflect.Int32:
+			return int32_t
+		case reflect.Int64:
+			return int64_t
+		}
+		return reflect.PtrTo(big_t)
+	case BoolTy:
+		return reflect.TypeOf(false)
+	case StringTy:
+		return reflect.TypeOf("")
+	case AddressTy:
+		return address_t
+	case HashTy:
+		return reflect.TypeOf(common.Hash{})
+	case BytesTy:
+		return reflect.TypeOf([]byte(nil))
+	case FixedBytesTy:
+		return reflect.ArrayOf(t.SliceSize, r_byte)
+	case SliceTy:
+		return reflect.SliceOf(t.Elem.reflectType())
+	case ArrayTy:
+		return reflect.ArrayOf(t.SliceSize, t.Elem.reflectType())
+	case TupleTy:
+		fields := make([]reflect.StructField, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			fields[i] = reflect.StructField{
+				Name: ToCamelCase(t.TupleRawNames[i]),
+				Type: elem.reflectType(),
+				Tag:  reflect.StructTag(fmt.Sprintf(`json:"%s"`, t.TupleRawNames[i])),
+			}
+		}
+		return reflect.StructOf(fields)
+	}
+	panic("abi: fatal error")
+}
+
+// ToCamelCase converts an under-score separated abi name into the capitalised
+// Go identifier it is bound to, e.g. "_from_account" into "FromAccount".
+func ToCamelCase(input string) string {
+	parts := strings.Split(input, "_")
+	for i, part := range parts {
+		if len(part) > 0 {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "")
 }
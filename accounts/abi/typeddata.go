@@ -0,0 +1,408 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/crypto"
+)
+
+// domainType is the name of the struct type of the domain separator.
+const domainType = "EIP712Domain"
+
+// domainFields are the fields a domain may have, in their canonical order.
+var domainFields = []TypedDataField{
+	{Name: "name", Type: "string"},
+	{Name: "version", Type: "string"},
+	{Name: "chainId", Type: "uint256"},
+	{Name: "verifyingContract", Type: "address"},
+	{Name: "salt", Type: "bytes32"},
+}
+
+// TypedDataField is a member of a struct type of typed data.
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData is structured data to be hashed and signed as specified by EIP-712:
+// a message of the primary type, bound to the application by its domain.
+//
+// Struct members are atomic ABI types, string, bytes, struct types defined in
+// Types, or arrays of any of them. If Types does not define the EIP712Domain
+// type, it is derived from the fields present in the domain.
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// UnmarshalJSON decodes typed data, keeping numbers in their textual form so
+// that 256 bit integers do not lose precision.
+func (td *TypedData) UnmarshalJSON(input []byte) error {
+	type typedData TypedData
+
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+
+	var data typedData
+	if err := dec.Decode(&data); err != nil {
+		return err
+	}
+	*td = TypedData(data)
+	return nil
+}
+
+// SigningHash returns the hash to sign for the typed data, computed as
+//
+//	keccak256("\x19\x01" ‖ hashStruct(domain) ‖ hashStruct(message))
+func (td *TypedData) SigningHash() (common.Hash, error) {
+	domain, err := td.HashStruct(domainType, td.Domain)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("domain: %v", err)
+	}
+	message, err := td.HashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("message: %v", err)
+	}
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domain.Bytes(), message.Bytes()), nil
+}
+
+// BindChainId makes sure the domain separator includes the chain id, filling
+// it in if missing. It fails if the domain is for another chain or its type
+// does not have a chain id.
+func (td *TypedData) BindChainId(chainId *big.Int) error {
+	fields, err := td.fields(domainType)
+	if err != nil {
+		return err
+	}
+	if _, declared := td.Types[domainType]; declared {
+		found := false
+		for _, field := range fields {
+			found = found || field.Name == "chainId"
+		}
+		if !found {
+			return fmt.Errorf("domain does not include a chain id")
+		}
+	}
+	if td.Domain == nil {
+		td.Domain = make(map[string]interface{})
+	}
+	value, ok := td.Domain["chainId"]
+	if !ok {
+		td.Domain["chainId"] = chainId.String()
+		return nil
+	}
+	have, err := parseInteger(value)
+	if err != nil {
+		return fmt.Errorf("domain chain id: %v", err)
+	}
+	if have.Cmp(chainId) != 0 {
+		return fmt.Errorf("domain chain id mismatch: have %v, want %v", have, chainId)
+	}
+	return nil
+}
+
+// HashStruct returns the hash of a struct of the given type:
+//
+//	keccak256(typeHash ‖ encodeData(struct))
+func (td *TypedData) HashStruct(typ string, data map[string]interface{}) (common.Hash, error) {
+	enc, err := td.encodeData(typ, data, 1)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(enc), nil
+}
+
+// TypeHash returns the hash of the encoding of the given struct type.
+func (td *TypedData) TypeHash(typ string) (common.Hash, error) {
+	enc, err := td.EncodeType(typ)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash([]byte(enc)), nil
+}
+
+// EncodeType returns the signature of the given struct type, followed by the
+// ones of all the struct types it references in alphabetical order, e.g.
+//
+//	Mail(Person from,Person to,string contents)Person(string name,address wallet)
+func (td *TypedData) EncodeType(typ string) (string, error) {
+	deps := make(map[string]bool)
+	if err := td.dependencies(typ, deps); err != nil {
+		return "", err
+	}
+	delete(deps, typ)
+
+	names := make([]string, 0, len(deps))
+	for dep := range deps {
+		names = append(names, dep)
+	}
+	sort.Strings(names)
+
+	var enc bytes.Buffer
+	for _, name := range append([]string{typ}, names...) {
+		fields, err := td.fields(name)
+		if err != nil {
+			return "", err
+		}
+		members := make([]string, len(fields))
+		for i, field := range fields {
+			members[i] = field.Type + " " + field.Name
+		}
+		enc.WriteString(name + "(" + strings.Join(members, ",") + ")")
+	}
+	return enc.String(), nil
+}
+
+// fields returns the members of a struct type, deriving the domain type from
+// the domain itself if it is not declared.
+func (td *TypedData) fields(typ string) ([]TypedDataField, error) {
+	if fields, ok := td.Types[typ]; ok {
+		return fields, nil
+	}
+	if typ != domainType {
+		return nil, fmt.Errorf("unknown type %q", typ)
+	}
+	var fields []TypedDataField
+	for _, field := range domainFields {
+		if _, ok := td.Domain[field.Name]; ok {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// isStruct reports whether the type is a struct type of the typed data.
+func (td *TypedData) isStruct(typ string) bool {
+	if _, ok := td.Types[typ]; ok {
+		return true
+	}
+	return typ == domainType
+}
+
+// dependencies collects the struct types referenced by the given one,
+// including itself.
+func (td *TypedData) dependencies(typ string, deps map[string]bool) error {
+	if deps[typ] {
+		return nil
+	}
+	fields, err := td.fields(typ)
+	if err != nil {
+		return err
+	}
+	deps[typ] = true
+	for _, field := range fields {
+		if elem := baseType(field.Type); td.isStruct(elem) {
+			if err := td.dependencies(elem, deps); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// maxDepth bounds the nesting of structs and arrays in typed data, guarding
+// against recursive types.
+const maxDepth = 16
+
+// encodeData encodes a struct as its type hash followed by its members, each
+// encoded into a single word.
+func (td *TypedData) encodeData(typ string, data map[string]interface{}, depth int) ([]byte, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("typed data nested too deep")
+	}
+	fields, err := td.fields(typ)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > len(fields) {
+		return nil, fmt.Errorf("%s: unexpected members", typ)
+	}
+	hash, err := td.TypeHash(typ)
+	if err != nil {
+		return nil, err
+	}
+	enc := hash.Bytes()
+	for _, field := range fields {
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s: missing member %q", typ, field.Name)
+		}
+		word, err := td.encodeValue(field.Type, value, depth)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", typ, field.Name, err)
+		}
+		enc = append(enc, word...)
+	}
+	return enc, nil
+}
+
+// encodeValue encodes a member of a struct into a single word. Structs, arrays
+// and dynamic values are replaced by their hash, atomic values are encoded as
+// in the ABI.
+func (td *TypedData) encodeValue(typ string, value interface{}, depth int) ([]byte, error) {
+	// Arrays are hashed from the concatenated encodings of their elements
+	if strings.HasSuffix(typ, "]") {
+		i := strings.LastIndex(typ, "[")
+		elems, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid array %v", value)
+		}
+		if size := typ[i+1 : len(typ)-1]; size != "" {
+			if n, err := strconv.Atoi(size); err != nil || n != len(elems) {
+				return nil, fmt.Errorf("array length mismatch: have %d, want %s", len(elems), size)
+			}
+		}
+		var enc []byte
+		for _, elem := range elems {
+			word, err := td.encodeValue(typ[:i], elem, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			enc = append(enc, word...)
+		}
+		return crypto.Keccak256(enc), nil
+	}
+	if td.isStruct(typ) {
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid struct %v", value)
+		}
+		enc, err := td.encodeData(typ, data, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(enc), nil
+	}
+	t, err := NewType(typ)
+	if err != nil {
+		return nil, err
+	}
+	switch t.T {
+	case StringTy:
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid string %v", value)
+		}
+		return crypto.Keccak256([]byte(str)), nil
+
+	case BytesTy:
+		blob, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(blob), nil
+
+	case FixedBytesTy:
+		blob, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(blob) != t.SliceSize {
+			return nil, fmt.Errorf("bytes length mismatch: have %d, want %d", len(blob), t.SliceSize)
+		}
+		return common.RightPadBytes(blob, 32), nil
+
+	case AddressTy:
+		str, ok := value.(string)
+		if !ok || !common.IsHexAddress(str) {
+			return nil, fmt.Errorf("invalid address %v", value)
+		}
+		return common.LeftPadBytes(common.HexToAddress(str).Bytes(), 32), nil
+
+	case BoolTy:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid bool %v", value)
+		}
+		if b {
+			return U256(big.NewInt(1)), nil
+		}
+		return U256(new(big.Int)), nil
+
+	case IntTy, UintTy:
+		n, err := parseInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		// Make sure the number fits the type before encoding it
+		min, max := new(big.Int), new(big.Int).Lsh(common.Big1, uint(t.Size))
+		if t.T == IntTy {
+			max.Rsh(max, 1)
+			min.Neg(max)
+		}
+		if n.Cmp(min) < 0 || n.Cmp(max) >= 0 {
+			return nil, fmt.Errorf("%v out of range for %s", n, typ)
+		}
+		return U256(n), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", typ)
+}
+
+// baseType strips any array dimensions from a type.
+func baseType(typ string) string {
+	if i := strings.Index(typ, "["); i >= 0 {
+		return typ[:i]
+	}
+	return typ
+}
+
+// parseBytes decodes a hex encoded byte string.
+func parseBytes(value interface{}) ([]byte, error) {
+	str, ok := value.(string)
+	if !ok || !strings.HasPrefix(str, "0x") || len(str)%2 != 0 {
+		return nil, fmt.Errorf("invalid bytes %v", value)
+	}
+	return common.FromHex(str), nil
+}
+
+// parseInteger decodes an integer given as a JSON number or a decimal or hex
+// encoded string.
+func parseInteger(value interface{}) (*big.Int, error) {
+	var str string
+	switch v := value.(type) {
+	case json.Number:
+		str = string(v)
+	case string:
+		str = v
+	case float64:
+		// Numbers decoded without UseNumber, only exact if small enough
+		if v != float64(int64(v)) || v > 1<<53 || v < -(1<<53) {
+			return nil, fmt.Errorf("inexact integer %v", v)
+		}
+		return big.NewInt(int64(v)), nil
+	case *big.Int:
+		return new(big.Int).Set(v), nil
+	default:
+		return nil, fmt.Errorf("invalid integer %v", value)
+	}
+	n, ok := new(big.Int).SetString(str, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", str)
+	}
+	return n, nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/crypto"
+)
+
+// mailTypedData is the example of the EIP-712 specification.
+const mailTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func parseTypedData(t *testing.T, blob string) *TypedData {
+	td := new(TypedData)
+	if err := json.Unmarshal([]byte(blob), td); err != nil {
+		t.Fatalf("failed to decode typed data: %v", err)
+	}
+	return td
+}
+
+// Tests the hashes of the EIP-712 specification example, and that its signature
+// recovers to the sender.
+func TestTypedDataMail(t *testing.T) {
+	td := parseTypedData(t, mailTypedData)
+
+	enc, err := td.EncodeType("Mail")
+	if err != nil {
+		t.Fatalf("failed to encode type: %v", err)
+	}
+	if want := "Mail(Person from,Person to,string contents)Person(string name,address wallet)"; enc != want {
+		t.Errorf("type encoding mismatch: have %s, want %s", enc, want)
+	}
+	for _, tt := range []struct {
+		name string
+		hash func() (common.Hash, error)
+		want string
+	}{
+		{"type hash", func() (common.Hash, error) { return td.TypeHash("Mail") }, "0xa0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2"},
+		{"message hash", func() (common.Hash, error) { return td.HashStruct("Mail", td.Message) }, "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e"},
+		{"domain separator", func() (common.Hash, error) { return td.HashStruct("EIP712Domain", td.Domain) }, "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"},
+		{"signing hash", td.SigningHash, "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"},
+	} {
+		hash, err := tt.hash()
+		if err != nil {
+			t.Errorf("%s: failed to hash: %v", tt.name, err)
+			continue
+		}
+		if hash.Hex() != tt.want {
+			t.Errorf("%s mismatch: have %s, want %s", tt.name, hash.Hex(), tt.want)
+		}
+	}
+	key := crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	hash, _ := td.SigningHash()
+	sig, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	pub, err := crypto.SigToPub(hash.Bytes(), sig)
+	if err != nil {
+		t.Fatalf("failed to recover signer: %v", err)
+	}
+	if have, want := crypto.PubkeyToAddress(*pub), common.HexToAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"); have != want {
+		t.Errorf("signer mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that the chain id is filled into the domain if missing, and that a
+// domain of another chain is refused.
+func TestTypedDataBindChainId(t *testing.T) {
+	td := parseTypedData(t, mailTypedData)
+	if err := td.BindChainId(big.NewInt(1)); err != nil {
+		t.Errorf("matching chain id refused: %v", err)
+	}
+	if err := td.BindChainId(big.NewInt(61)); err == nil {
+		t.Errorf("foreign chain id accepted")
+	}
+	delete(td.Domain, "chainId")
+	if err := td.BindChainId(big.NewInt(61)); err != nil {
+		t.Fatalf("failed to bind chain id: %v", err)
+	}
+	if _, err := td.SigningHash(); err != nil {
+		t.Errorf("failed to hash bound typed data: %v", err)
+	}
+	// A declared domain type without a chain id cannot be bound
+	td.Types["EIP712Domain"] = td.Types["EIP712Domain"][:2]
+	delete(td.Domain, "chainId")
+	if err := td.BindChainId(big.NewInt(61)); err == nil {
+		t.Errorf("domain type without chain id accepted")
+	}
+	// An undeclared domain type is derived from the domain
+	delete(td.Types, "EIP712Domain")
+	if err := td.BindChainId(big.NewInt(61)); err != nil {
+		t.Fatalf("failed to bind chain id to derived domain: %v", err)
+	}
+	enc, err := td.EncodeType("EIP712Domain")
+	if err != nil {
+		t.Fatalf("failed to encode derived domain type: %v", err)
+	}
+	if want := "EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"; enc != want {
+		t.Errorf("derived domain type mismatch: have %s, want %s", enc, want)
+	}
+}
+
+// Tests that malformed messages are refused.
+func TestTypedDataInvalid(t *testing.T) {
+	for i, message := range []string{
+		`{"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"}, "contents": "Hi"}`,                                          // missing member
+		`{"from": {"name": "Cow", "wallet": "0x01"}, "to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"}, "contents": "Hi"}`, // bad address
+		`{"from": "Cow", "to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"}, "contents": "Hi"}`,                             // not a struct
+		`{"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"}, "to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"}, "contents": 1}`,
+	} {
+		td := parseTypedData(t, mailTypedData)
+		td.Message = nil
+		if err := json.Unmarshal([]byte(message), &td.Message); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := td.SigningHash(); err == nil {
+			t.Errorf("message %d: invalid message accepted", i)
+		}
+	}
+	// Integers must fit their type, arrays their length
+	td := &TypedData{
+		Types: map[string][]TypedDataField{
+			"Values": {{Name: "small", Type: "uint8"}, {Name: "signed", Type: "int8"}, {Name: "pair", Type: "bytes1[2]"}},
+		},
+		PrimaryType: "Values",
+		Domain:      map[string]interface{}{"name": "test"},
+	}
+	for i, tt := range []struct {
+		message map[string]interface{}
+		valid   bool
+	}{
+		{map[string]interface{}{"small": "255", "signed": "-128", "pair": []interface{}{"0x01", "0x02"}}, true},
+		{map[string]interface{}{"small": "0x100", "signed": "0", "pair": []interface{}{"0x01", "0x02"}}, false},
+		{map[string]interface{}{"small": "0", "signed": "128", "pair": []interface{}{"0x01", "0x02"}}, false},
+		{map[string]interface{}{"small": "0", "signed": "0", "pair": []interface{}{"0x01"}}, false},
+		{map[string]interface{}{"small": "0", "signed": "0", "pair": []interface{}{"0x01", "0x0203"}}, false},
+	} {
+		td.Message = tt.message
+		if _, err := td.SigningHash(); (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch: have error %v, want valid %v", i, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/crypto/randentropy"
+)
+
+// ErrInvalidMnemonic is returned when a mnemonic fails its BIP39 checksum.
+var ErrInvalidMnemonic = errors.New("invalid mnemonic checksum")
+
+// bip39Index maps the words of the BIP39 English wordlist to their values.
+var bip39Index = make(map[string]int, len(bip39English))
+
+func init() {
+	for i, word := range bip39English {
+		bip39Index[word] = i
+	}
+}
+
+// NewMnemonic generates a random BIP39 mnemonic carrying the given number of
+// entropy bits, which must be a multiple of 32 between 128 and 256.
+func NewMnemonic(bits int) (string, error) {
+	if bits%32 != 0 || bits < 128 || bits > 256 {
+		return "", fmt.Errorf("invalid mnemonic entropy size: %d bits", bits)
+	}
+	return entropyToMnemonic(randentropy.GetEntropyCSPRNG(bits / 8)), nil
+}
+
+// entropyToMnemonic encodes the entropy along with its checksum as a sequence
+// of words, each carrying 11 bits.
+func entropyToMnemonic(entropy []byte) string {
+	// The checksum is the first bits of the entropy's SHA256 hash, one for every
+	// 32 bits of entropy, appended to the entropy itself
+	checksumBits := uint(len(entropy) / 4)
+	hash := sha256.Sum256(entropy)
+
+	value := new(big.Int).SetBytes(entropy)
+	value.Lsh(value, checksumBits)
+	value.Or(value, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	words := make([]string, (len(entropy)*8+int(checksumBits))/11)
+	mask := big.NewInt(2047)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = bip39English[new(big.Int).And(value, mask).Int64()]
+		value.Rsh(value, 11)
+	}
+	return strings.Join(words, " ")
+}
+
+// mnemonicToEntropy decodes a mnemonic back into its entropy, verifying the
+// embedded checksum.
+func mnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(normalizeMnemonic(mnemonic))
+	if len(words)%3 != 0 || len(words) < 12 || len(words) > 24 {
+		return nil, fmt.Errorf("invalid mnemonic length: %d words", len(words))
+	}
+	value := new(big.Int)
+	for _, word := range words {
+		index, ok := bip39Index[word]
+		if !ok {
+			return nil, fmt.Errorf("invalid mnemonic word: %q", word)
+		}
+		value.Lsh(value, 11)
+		value.Or(value, big.NewInt(int64(index)))
+	}
+	checksumBits := uint(len(words) / 3)
+	checksum := new(big.Int).And(value, big.NewInt(1<<checksumBits-1)).Int64()
+	value.Rsh(value, checksumBits)
+
+	entropy := common.LeftPadBytes(value.Bytes(), (len(words)*11-int(checksumBits))/8)
+	if hash := sha256.Sum256(entropy); int64(hash[0]>>(8-checksumBits)) != checksum {
+		return nil, ErrInvalidMnemonic
+	}
+	return entropy, nil
+}
+
+// mnemonicSeed derives the 64 byte BIP39 seed of a mnemonic, protected by an
+// optional password. The password is used as is, without Unicode normalisation.
+func mnemonicSeed(mnemonic, password string) []byte {
+	return pbkdf2.Key([]byte(normalizeMnemonic(mnemonic)), []byte("mnemonic"+password), 2048, 64, sha512.New)
+}
+
+// normalizeMnemonic lower-cases a mnemonic and separates its words by single spaces.
+func normalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+}
@@ -0,0 +1,106 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// Tests the mnemonic encoding and seed derivation against the BIP39 reference
+// vectors, which protect all seeds with the password "TREZOR".
+func TestMnemonicVectors(t *testing.T) {
+	tests := []struct {
+		entropy  string
+		mnemonic string
+		seed     string
+	}{
+		{
+			entropy:  "00000000000000000000000000000000",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+		},
+		{
+			entropy:  "80808080808080808080808080808080",
+			mnemonic: "letter advice cage absurd amount doctor acoustic avoid letter advice cage above",
+		},
+		{
+			entropy:  "ffffffffffffffffffffffffffffffff",
+			mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		},
+		{
+			entropy:  "0000000000000000000000000000000000000000000000000000000000000000",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+		},
+	}
+	for i, tt := range tests {
+		entropy, _ := hex.DecodeString(tt.entropy)
+		if mnemonic := entropyToMnemonic(entropy); mnemonic != tt.mnemonic {
+			t.Errorf("test %d: mnemonic mismatch: have %q, want %q", i, mnemonic, tt.mnemonic)
+		}
+		decoded, err := mnemonicToEntropy(tt.mnemonic)
+		if err != nil {
+			t.Errorf("test %d: failed to decode mnemonic: %v", i, err)
+		} else if hex.EncodeToString(decoded) != tt.entropy {
+			t.Errorf("test %d: entropy mismatch: have %x, want %s", i, decoded, tt.entropy)
+		}
+		if tt.seed != "" {
+			if seed := hex.EncodeToString(mnemonicSeed(tt.mnemonic, "TREZOR")); seed != tt.seed {
+				t.Errorf("test %d: seed mismatch: have %s, want %s", i, seed, tt.seed)
+			}
+		}
+	}
+}
+
+// Tests that malformed mnemonics are rejected.
+func TestMnemonicInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon etherium",
+	}
+	for i, mnemonic := range tests {
+		if _, err := mnemonicToEntropy(mnemonic); err == nil {
+			t.Errorf("test %d: invalid mnemonic %q accepted", i, mnemonic)
+		}
+	}
+}
+
+// Tests that generated mnemonics have the requested size and a valid checksum.
+func TestNewMnemonic(t *testing.T) {
+	for _, bits := range []int{128, 160, 192, 224, 256} {
+		mnemonic, err := NewMnemonic(bits)
+		if err != nil {
+			t.Fatalf("%d bits: failed to generate mnemonic: %v", bits, err)
+		}
+		if words := len(strings.Fields(mnemonic)); words != (bits+bits/32)/11 {
+			t.Errorf("%d bits: word count mismatch: have %d, want %d", bits, words, (bits+bits/32)/11)
+		}
+		if _, err := mnemonicToEntropy(mnemonic); err != nil {
+			t.Errorf("%d bits: generated mnemonic invalid: %v", bits, err)
+		}
+	}
+	if _, err := NewMnemonic(100); err == nil {
+		t.Errorf("invalid entropy size accepted")
+	}
+}
@@ -219,6 +219,7 @@ func (ac *addrCache) scan() ([]Account, error) {
 		addrs   []Account
 		keyJSON struct {
 			Address common.Address `json:"address"`
+			Meta    KeyMeta        `json:"meta"`
 		}
 	)
 	for _, fi := range files {
@@ -234,7 +235,7 @@ func (ac *addrCache) scan() ([]Account, error) {
 		}
 		buf.Reset(fd)
 		// Parse the address.
-		keyJSON.Address = common.Address{}
+		keyJSON.Address, keyJSON.Meta = common.Address{}, KeyMeta{}
 		err = json.NewDecoder(buf).Decode(&keyJSON)
 		switch {
 		case err != nil:
@@ -242,7 +243,7 @@ func (ac *addrCache) scan() ([]Account, error) {
 		case (keyJSON.Address == common.Address{}):
 			glog.V(logger.Debug).Infof("can't decode key %s: missing or zero address", path)
 		default:
-			addrs = append(addrs, Account{Address: keyJSON.Address, File: path})
+			addrs = append(addrs, Account{Address: keyJSON.Address, File: path, Meta: keyJSON.Meta})
 		}
 		fd.Close()
 	}
@@ -472,6 +472,7 @@ func processKeyFile(wg *sync.WaitGroup, path string, fi os.FileInfo, i int, numF
 
 		keyJSON := struct {
 			Address common.Address `json:"address"`
+			Meta    KeyMeta        `json:"meta"`
 		}{}
 
 		buf := new(bufio.Reader)
@@ -501,7 +502,7 @@ func processKeyFile(wg *sync.WaitGroup, path string, fi os.FileInfo, i int, numF
 			glog.V(logger.Debug).Infof("(%v/%v) can't decode key %s: missing or zero address", i, numFiles, path)
 			errs <- fmt.Errorf("(%v/%v) can't decode key %s: missing or zero address", i, numFiles, path)
 		default:
-			aChan <- Account{Address: keyJSON.Address, File: fi.Name(), EncryptedKey: string(web3JSON)}
+			aChan <- Account{Address: keyJSON.Address, File: fi.Name(), EncryptedKey: string(web3JSON), Meta: keyJSON.Meta}
 		}
 	}
 }
@@ -512,11 +513,18 @@ func bytesToAccount(bs []byte) Account {
 		panic(e)
 		//return Account{}
 	}
-	return Account{
+	account := Account{
 		Address:      common.HexToAddress(aux.Address),
 		EncryptedKey: aux.EncryptedKey,
 		File:         aux.File,
 	}
+	account.Meta.Label, account.Meta.HDPath = aux.Label, aux.HDPath
+	if aux.Created != "" {
+		if t, e := time.Parse(time.RFC3339, aux.Created); e == nil {
+			account.Meta.Created = t.UTC()
+		}
+	}
+	return account
 }
 
 func accountToBytes(account Account) []byte {
@@ -524,6 +532,11 @@ func accountToBytes(account Account) []byte {
 		Address:      account.Address.Hex(),
 		EncryptedKey: account.EncryptedKey,
 		File:         account.File,
+		Label:        account.Meta.Label,
+		HDPath:       account.Meta.HDPath,
+	}
+	if !account.Meta.Created.IsZero() {
+		aux.Created = account.Meta.Created.UTC().Format(time.RFC3339)
 	}
 	b, e := easyjson.Marshal(aux)
 	if e != nil {
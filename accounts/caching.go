@@ -81,7 +81,7 @@ func skipKeyFile(fi os.FileInfo) bool {
 	if strings.HasSuffix(fi.Name(), "~") || strings.HasPrefix(fi.Name(), ".") {
 		return true
 	}
-	if strings.HasSuffix(fi.Name(), "accounts.db") {
+	if strings.HasSuffix(fi.Name(), "accounts.db") || fi.Name() == hdWalletFile {
 		return true
 	}
 	// Skip misc special files, directories (yes, symlinks too).
@@ -0,0 +1,257 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package external implements an account backend delegating to a standalone
+// signer process reached over RPC, so that no keys live inside the node.
+package external
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereumproject/go-ethereum/accounts"
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/event"
+	"github.com/ethereumproject/go-ethereum/rlp"
+	"github.com/ethereumproject/go-ethereum/rpc"
+)
+
+// These nil assignments ensure compile time that Backend implements
+// accounts.Backend and its wallet accounts.Wallet.
+var (
+	_ accounts.Backend = (*Backend)(nil)
+	_ accounts.Wallet  = (*wallet)(nil)
+)
+
+// Backend is an accounts.Backend offering the single wallet of an external
+// signer.
+type Backend struct {
+	wallet *wallet
+	mux    event.TypeMux
+}
+
+// NewBackend connects to the signer at the endpoint, given in the format of
+// rpc.NewClient, e.g. ipc:/path/to/signer.ipc or http://localhost:8550, and
+// retrieves the accounts it discloses.
+func NewBackend(endpoint string) (*Backend, error) {
+	client, err := rpc.NewClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	w := &wallet{endpoint: endpoint, client: client}
+	if err := w.Open(""); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &Backend{wallet: w}, nil
+}
+
+// Wallets returns the wallet of the external signer.
+func (b *Backend) Wallets() []accounts.Wallet {
+	return []accounts.Wallet{b.wallet}
+}
+
+// Subscribe creates a subscription for wallet events. The wallet of a signer
+// never comes or goes, so no events are posted.
+func (b *Backend) Subscribe() event.Subscription {
+	return b.mux.Subscribe(accounts.WalletEvent{})
+}
+
+// Close disconnects from the signer.
+func (b *Backend) Close() error {
+	b.mux.Stop()
+	return b.wallet.Close()
+}
+
+// request is a JSON RPC request sent to the signer.
+type request struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// response is a JSON RPC response sent back by the signer.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int             `json:"id"`
+	Error   *failure        `json:"error"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// failure is a JSON RPC error sent back by the signer.
+type failure struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// wallet is the accounts.Wallet of an external signer, caching the accounts
+// disclosed on opening.
+type wallet struct {
+	endpoint string
+	client   rpc.Client
+	autoid   uint32
+
+	accounts []accounts.Account
+	lock     sync.Mutex // Protects the accounts and singleton access to the client
+}
+
+func (w *wallet) URL() string { return "extapi://" + w.endpoint }
+
+// Status reports the number of accounts disclosed by the signer.
+func (w *wallet) Status() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return fmt.Sprintf("Signer with %d accounts", len(w.accounts))
+}
+
+// Open retrieves the accounts disclosed by the signer, which may need the
+// approval of its user. The passphrase is not used.
+func (w *wallet) Open(passphrase string) error {
+	var addrs []common.Address
+	if err := w.call(&addrs, "account_list"); err != nil {
+		return err
+	}
+	accs := make([]accounts.Account, len(addrs))
+	for i, addr := range addrs {
+		accs[i] = accounts.Account{Address: addr}
+	}
+	w.lock.Lock()
+	w.accounts = accs
+	w.lock.Unlock()
+
+	return nil
+}
+
+// Close disconnects from the signer.
+func (w *wallet) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.client.Close()
+	return nil
+}
+
+// Accounts returns the accounts disclosed by the signer.
+func (w *wallet) Accounts() []accounts.Account {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return append([]accounts.Account{}, w.accounts...)
+}
+
+// Contains reports whether the signer disclosed the account.
+func (w *wallet) Contains(account accounts.Account) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for _, acc := range w.accounts {
+		if acc.Address == account.Address {
+			return true
+		}
+	}
+	return false
+}
+
+// Derive is not supported, accounts are managed by the signer itself.
+func (w *wallet) Derive(path accounts.DerivationPath) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// signTxArgs mirrors the transaction arguments of account_signTransaction.
+type signTxArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      *rpc.HexNumber  `json:"gas"`
+	GasPrice *rpc.HexNumber  `json:"gasPrice"`
+	Value    *rpc.HexNumber  `json:"value"`
+	Data     string          `json:"data"`
+	Nonce    *rpc.HexNumber  `json:"nonce"`
+	ChainId  *rpc.HexNumber  `json:"chainId,omitempty"`
+}
+
+// SignTx has the signer sign the transaction, subject to the approval of its
+// user. The signer uses its own configured chain, which must match the one of
+// a replay protected signer.
+func (w *wallet) SignTx(account accounts.Account, tx *types.Transaction, signer types.Signer) (*types.Transaction, error) {
+	if !w.Contains(account) {
+		return nil, accounts.ErrUnknownWallet
+	}
+	args := &signTxArgs{
+		From:     account.Address,
+		To:       tx.To(),
+		Gas:      rpc.NewHexNumber(tx.Gas()),
+		GasPrice: rpc.NewHexNumber(tx.GasPrice()),
+		Value:    rpc.NewHexNumber(tx.Value()),
+		Data:     common.ToHex(tx.Data()),
+		Nonce:    rpc.NewHexNumber(tx.Nonce()),
+	}
+	if s, ok := signer.(types.ChainIdSigner); ok {
+		args.ChainId = rpc.NewHexNumber(s.ChainId())
+	}
+	var raw string
+	if err := w.call(&raw, "account_signTransaction", args); err != nil {
+		return nil, err
+	}
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(raw), signed); err != nil {
+		return nil, err
+	}
+	// Make sure the signer signed what was asked for, with the right account
+	if (types.BasicSigner{}).Hash(signed) != (types.BasicSigner{}).Hash(tx) {
+		return nil, fmt.Errorf("signer altered the transaction")
+	}
+	if from, err := signed.From(); err != nil || from != account.Address {
+		return nil, fmt.Errorf("signer used the wrong account: have %x, want %x", from, account.Address)
+	}
+	return signed, nil
+}
+
+// SignTxWithPassphrase is not supported, the passphrases are kept by the signer.
+func (w *wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, signer types.Signer) (*types.Transaction, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// call invokes the method of the signer and decodes its result.
+func (w *wallet) call(result interface{}, method string, params ...interface{}) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if params == nil {
+		params = []interface{}{}
+	}
+	req := &request{
+		JSONRPC: "2.0",
+		ID:      int(atomic.AddUint32(&w.autoid, 1)),
+		Method:  method,
+		Params:  params,
+	}
+	if err := w.client.Send(req); err != nil {
+		return err
+	}
+	res := new(response)
+	if err := w.client.Recv(res); err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return fmt.Errorf("signer error: %s", res.Error.Message)
+	}
+	return json.Unmarshal(res.Result, result)
+}
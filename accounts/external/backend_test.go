@@ -0,0 +1,113 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package external
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereumproject/go-ethereum/accounts"
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/rpc"
+	"github.com/ethereumproject/go-ethereum/signer"
+)
+
+// Tests that a node side account manager lists and signs with the accounts of
+// a signer reached over IPC, without holding any keys itself.
+func TestExternalSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-signer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Start a signer approving everything with the known passphrase
+	keys, err := accounts.NewManager(filepath.Join(dir, "signer"), accounts.LightScryptN, accounts.LightScryptP, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := keys.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := `function ApproveListing(req) { return "Approve"; }
+function ApproveTx(req) { return "Approve"; }`
+	ui, err := signer.NewRuleUI(signer.NewCommandlineUI(strings.NewReader(""), ioutil.Discard), rules, map[common.Address]string{account.Address: "foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName(signer.Namespace, signer.NewSignerAPI(big.NewInt(61), keys, ui, nil)); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	endpoint := filepath.Join(dir, "signer.ipc")
+	listener, err := rpc.CreateIPCListener(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.ServeCodec(rpc.NewJSONCodec(conn), rpc.OptionMethodInvocation)
+		}
+	}()
+	// Attach the signer to an empty node side account manager
+	am, err := accounts.NewManager(filepath.Join(dir, "node"), accounts.LightScryptN, accounts.LightScryptP, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend, err := NewBackend("ipc:" + endpoint)
+	if err != nil {
+		t.Fatalf("failed to connect to signer: %v", err)
+	}
+	defer backend.Close()
+	am.AddBackend(backend)
+
+	if accs := am.Accounts(); len(accs) != 1 || accs[0].Address != account.Address {
+		t.Fatalf("account list mismatch: have %v, want [%x]", accs, account.Address)
+	}
+	chainSigner := types.NewChainIdSigner(big.NewInt(61))
+	tx := types.NewTransaction(1, common.Address{0x01}, big.NewInt(100), big.NewInt(21000), big.NewInt(1), []byte{0xca, 0xfe})
+
+	signed, err := am.SignTx(accounts.Account{Address: account.Address}, tx, chainSigner)
+	if err != nil {
+		t.Fatalf("failed to sign through signer: %v", err)
+	}
+	if from, err := types.Sender(chainSigner, signed); err != nil || from != account.Address {
+		t.Errorf("sender mismatch: have %x/%v, want %x", from, err, account.Address)
+	}
+	if signed.Nonce() != 1 || signed.Value().Cmp(big.NewInt(100)) != 0 || common.ToHex(signed.Data()) != "0xcafe" {
+		t.Errorf("signed transaction mismatch: %v", signed)
+	}
+	if _, err := am.SignTx(accounts.Account{Address: account.Address}, tx, types.NewChainIdSigner(big.NewInt(62))); err == nil {
+		t.Errorf("signed transaction for foreign chain")
+	}
+	if _, err := am.SignTxWithPassphrase(accounts.Account{Address: account.Address}, "foo", tx, chainSigner); err != accounts.ErrNotSupported {
+		t.Errorf("passphrase signing error mismatch: have %v, want %v", err, accounts.ErrNotSupported)
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/crypto"
+	"github.com/ethereumproject/go-ethereum/crypto/secp256k1"
+)
+
+// hardenedOffset is added to the index of a BIP32 path component to request
+// hardened derivation, which requires the parent's private key.
+const hardenedOffset = 0x80000000
+
+// DefaultRootDerivationPath is the BIP44 path under which Ethereum Classic
+// accounts are derived: m/44'/61'/0'/0. The first account is at m/44'/61'/0'/0/0,
+// the second at m/44'/61'/0'/0/1, etc.
+var DefaultRootDerivationPath = DerivationPath{hardenedOffset + 44, hardenedOffset + 61, hardenedOffset + 0, 0}
+
+// DefaultBaseDerivationPath is the path of the first account derived from a
+// mnemonic: m/44'/61'/0'/0/0.
+var DefaultBaseDerivationPath = DerivationPath{hardenedOffset + 44, hardenedOffset + 61, hardenedOffset + 0, 0, 0}
+
+var (
+	errHardenedPublic = errors.New("cannot derive a hardened key from a public key")
+	errInvalidChild   = errors.New("derived key is invalid, use the next index")
+)
+
+// DerivationPath represents the computer friendly version of a hierarchical
+// deterministic wallet account derivation path, as specified by BIP32. Hardened
+// components have hardenedOffset added to their index.
+type DerivationPath []uint32
+
+// ParseDerivationPath converts a user specified derivation path string to the
+// internal binary representation. Absolute paths start with "m/", relative ones
+// are appended to DefaultRootDerivationPath. Hardened components are marked
+// with a trailing apostrophe, e.g. m/44'/61'/0'/0/1.
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	var result DerivationPath
+
+	components := strings.Split(path, "/")
+	for i := range components {
+		components[i] = strings.TrimSpace(components[i])
+	}
+	switch {
+	case components[0] == "":
+		return nil, errors.New("empty derivation path")
+	case components[0] == "m":
+		components = components[1:]
+	default:
+		result = append(result, DefaultRootDerivationPath...)
+	}
+	if len(components) == 0 {
+		return nil, errors.New("empty derivation path")
+	}
+	for _, component := range components {
+		var offset uint64
+		if strings.HasSuffix(component, "'") {
+			offset = hardenedOffset
+			component = strings.TrimSpace(strings.TrimSuffix(component, "'"))
+		}
+		index, err := strconv.ParseUint(component, 10, 32)
+		if err != nil || index >= hardenedOffset {
+			return nil, fmt.Errorf("invalid derivation path component: %q", component)
+		}
+		result = append(result, uint32(index+offset))
+	}
+	return result, nil
+}
+
+// String implements fmt.Stringer, converting a binary derivation path to its
+// canonical textual representation.
+func (path DerivationPath) String() string {
+	result := "m"
+	for _, component := range path {
+		if component >= hardenedOffset {
+			result += fmt.Sprintf("/%d'", component-hardenedOffset)
+		} else {
+			result += fmt.Sprintf("/%d", component)
+		}
+	}
+	return result
+}
+
+// DeriveMnemonicKey derives the private key at the given path from a BIP39
+// mnemonic, without any password protecting the seed.
+func DeriveMnemonicKey(mnemonic string, path DerivationPath) (*ecdsa.PrivateKey, error) {
+	if _, err := mnemonicToEntropy(mnemonic); err != nil {
+		return nil, err
+	}
+	master, err := newMasterKey(mnemonicSeed(mnemonic, ""))
+	if err != nil {
+		return nil, err
+	}
+	defer master.zero()
+
+	child, err := master.derive(path)
+	if err != nil {
+		return nil, err
+	}
+	defer child.zero()
+
+	return child.privateKey(), nil
+}
+
+// extendedKey is a BIP32 key along with the chain code needed to derive its
+// children. Public-only keys (lacking a private part) can only derive non
+// hardened children.
+type extendedKey struct {
+	private   []byte   // 32 byte private key, nil for public-only keys
+	x, y      *big.Int // Public key point on the secp256k1 curve
+	chainCode []byte   // 32 byte chain code mixed into the derivation of children
+}
+
+// newMasterKey derives the BIP32 master key from a seed.
+func newMasterKey(seed []byte) (*extendedKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	if k := new(big.Int).SetBytes(sum[:32]); k.Sign() == 0 || k.Cmp(secp256k1.S256().N) >= 0 {
+		return nil, errors.New("invalid master key, use another seed")
+	}
+	return newPrivateExtendedKey(sum[:32], sum[32:]), nil
+}
+
+// newPrivateExtendedKey assembles an extended key from a private key and chain code.
+func newPrivateExtendedKey(private, chainCode []byte) *extendedKey {
+	x, y := secp256k1.S256().ScalarBaseMult(private)
+	return &extendedKey{private: private, x: x, y: y, chainCode: chainCode}
+}
+
+// compressedPublicKey serializes the public key in the 33 byte compressed form.
+func (k *extendedKey) compressedPublicKey() []byte {
+	prefix := byte(0x02)
+	if k.y.Bit(0) == 1 {
+		prefix = 0x03
+	}
+	return append([]byte{prefix}, common.LeftPadBytes(k.x.Bytes(), 32)...)
+}
+
+// child derives the child key at the given index.
+func (k *extendedKey) child(index uint32) (*extendedKey, error) {
+	var data []byte
+	if index >= hardenedOffset {
+		if k.private == nil {
+			return nil, errHardenedPublic
+		}
+		data = append([]byte{0x00}, k.private...)
+	} else {
+		data = k.compressedPublicKey()
+	}
+	data = append(data, make([]byte, 4)...)
+	binary.BigEndian.PutUint32(data[len(data)-4:], index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	curve := secp256k1.S256()
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(curve.N) >= 0 {
+		return nil, errInvalidChild
+	}
+	if k.private != nil {
+		// Private derivation: child = tweak + parent (mod n)
+		child := tweak.Add(tweak, new(big.Int).SetBytes(k.private))
+		child.Mod(child, curve.N)
+		if child.Sign() == 0 {
+			return nil, errInvalidChild
+		}
+		return newPrivateExtendedKey(common.LeftPadBytes(child.Bytes(), 32), sum[32:]), nil
+	}
+	// Public derivation: child = tweak*G + parent
+	if tweak.Sign() == 0 {
+		return nil, errInvalidChild
+	}
+	tx, ty := curve.ScalarBaseMult(sum[:32])
+	x, y := curve.Add(tx, ty, k.x, k.y)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, errInvalidChild
+	}
+	return &extendedKey{x: x, y: y, chainCode: sum[32:]}, nil
+}
+
+// derive walks the derivation path down from the key.
+func (k *extendedKey) derive(path DerivationPath) (*extendedKey, error) {
+	var err error
+	for _, index := range path {
+		if k, err = k.child(index); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// public returns a public-only copy of the key, unable to derive hardened
+// children or sign anything.
+func (k *extendedKey) public() *extendedKey {
+	return &extendedKey{x: k.x, y: k.y, chainCode: k.chainCode}
+}
+
+// address returns the Ethereum address belonging to the key.
+func (k *extendedKey) address() common.Address {
+	return crypto.PubkeyToAddress(*k.publicKey())
+}
+
+// publicKey returns the public key as an ECDSA key.
+func (k *extendedKey) publicKey() *ecdsa.PublicKey {
+	return &ecdsa.PublicKey{Curve: secp256k1.S256(), X: k.x, Y: k.y}
+}
+
+// privateKey returns the private key as an ECDSA key, or nil for public-only keys.
+func (k *extendedKey) privateKey() *ecdsa.PrivateKey {
+	if k.private == nil {
+		return nil
+	}
+	return crypto.ToECDSA(common.CopyBytes(k.private))
+}
+
+// zero wipes the private part of the key from memory.
+func (k *extendedKey) zero() {
+	for i := range k.private {
+		k.private[i] = 0
+	}
+}
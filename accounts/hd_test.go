@@ -0,0 +1,151 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/ethereumproject/go-ethereum/common"
+)
+
+// Tests that derivation paths are converted to their binary representation
+// and back.
+func TestDerivationPaths(t *testing.T) {
+	tests := []struct {
+		input  string
+		output DerivationPath
+		text   string
+	}{
+		{"m/44'/61'/0'/0/0", DefaultBaseDerivationPath, "m/44'/61'/0'/0/0"},
+		{"m/44'/60'/0'/0/1", DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000, 0, 1}, "m/44'/60'/0'/0/1"},
+		{"m/2147483647'", DerivationPath{0xffffffff}, "m/2147483647'"},
+		{" m / 0 / 1 ' ", DerivationPath{0, 0x80000001}, "m/0/1'"},
+		{"5", append(append(DerivationPath{}, DefaultRootDerivationPath...), 5), "m/44'/61'/0'/0/5"},
+		{"", nil, ""},
+		{"m", nil, ""},
+		{"m/2147483648", nil, ""},
+		{"m/-1", nil, ""},
+		{"m/0''", nil, ""},
+		{"n/0", nil, ""},
+	}
+	for i, tt := range tests {
+		path, err := ParseDerivationPath(tt.input)
+		if tt.output == nil {
+			if err == nil {
+				t.Errorf("test %d: invalid path %q accepted as %v", i, tt.input, path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: failed to parse %q: %v", i, tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(path, tt.output) {
+			t.Errorf("test %d: path mismatch: have %v, want %v", i, []uint32(path), []uint32(tt.output))
+		}
+		if path.String() != tt.text {
+			t.Errorf("test %d: text mismatch: have %s, want %s", i, path, tt.text)
+		}
+	}
+}
+
+// Tests the private key derivation against BIP32 test vector 1.
+func TestExtendedKeyVectors(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := newMasterKey(seed)
+	if err != nil {
+		t.Fatalf("failed to create master key: %v", err)
+	}
+	if have := hex.EncodeToString(master.chainCode); have != "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508" {
+		t.Errorf("master chain code mismatch: have %s", have)
+	}
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{"m/0'/1/2'/2", "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+	for i, tt := range tests {
+		// The master key itself is reached by an empty path
+		var path DerivationPath
+		if tt.path != "m" {
+			if path, err = ParseDerivationPath(tt.path); err != nil {
+				t.Fatalf("test %d: failed to parse path: %v", i, err)
+			}
+		}
+		key, err := master.derive(path)
+		if err != nil {
+			t.Fatalf("test %d: failed to derive key: %v", i, err)
+		}
+		if have := hex.EncodeToString(key.private); have != tt.key {
+			t.Errorf("test %d: key mismatch for %s: have %s, want %s", i, tt.path, have, tt.key)
+		}
+	}
+}
+
+// Tests that public derivation of non hardened children yields the same keys
+// as private derivation, and that hardened public derivation is refused.
+func TestExtendedKeyPublicDerivation(t *testing.T) {
+	master, err := newMasterKey(mnemonicSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", ""))
+	if err != nil {
+		t.Fatalf("failed to create master key: %v", err)
+	}
+	root, err := master.derive(DefaultRootDerivationPath)
+	if err != nil {
+		t.Fatalf("failed to derive root key: %v", err)
+	}
+	for i := uint32(0); i < 5; i++ {
+		private, err := root.child(i)
+		if err != nil {
+			t.Fatalf("child %d: private derivation failed: %v", i, err)
+		}
+		public, err := root.public().child(i)
+		if err != nil {
+			t.Fatalf("child %d: public derivation failed: %v", i, err)
+		}
+		if private.address() != public.address() {
+			t.Errorf("child %d: address mismatch: private %x, public %x", i, private.address(), public.address())
+		}
+	}
+	if _, err := root.public().child(hardenedOffset); err != errHardenedPublic {
+		t.Errorf("hardened public derivation error mismatch: have %v, want %v", err, errHardenedPublic)
+	}
+}
+
+// Tests the derivation of a well known mnemonic along the Ethereum path, which
+// is shared by most wallet software.
+func TestExtendedKeyMnemonicAddress(t *testing.T) {
+	master, err := newMasterKey(mnemonicSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", ""))
+	if err != nil {
+		t.Fatalf("failed to create master key: %v", err)
+	}
+	path, _ := ParseDerivationPath("m/44'/60'/0'/0/0")
+	key, err := master.derive(path)
+	if err != nil {
+		t.Fatalf("failed to derive key: %v", err)
+	}
+	if want := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"); key.address() != want {
+		t.Errorf("address mismatch: have %x, want %x", key.address(), want)
+	}
+}
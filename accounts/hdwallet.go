@@ -0,0 +1,333 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/crypto"
+)
+
+// hdWalletFile is the name of the file within the key directory holding the
+// imported HD wallet. It is skipped when scanning for key files.
+const hdWalletFile = "hdwallet.json"
+
+var (
+	ErrNoHDWallet     = errors.New("no HD wallet imported")
+	ErrHDWalletExists = errors.New("HD wallet already imported")
+
+	errHDDelete  = errors.New("accounts derived from the HD wallet cannot be deleted")
+	errHDMigrate = errors.New("accounts derived from the HD wallet have no key file to migrate")
+)
+
+// ChainStateReader provides access to the account state of the chain, used to
+// discover which accounts of an HD wallet have already been used.
+type ChainStateReader interface {
+	BalanceAt(account common.Address) (*big.Int, error)
+	NonceAt(account common.Address) (uint64, error)
+}
+
+// hdWalletJSON is the on-disk representation of an HD wallet. The extended public
+// key of the root path is kept in plain text so that accounts can be listed and
+// derived without the passphrase, while the mnemonic itself is only stored in
+// plain text if it was imported without a passphrase.
+type hdWalletJSON struct {
+	Path      string      `json:"path"`
+	PublicKey string      `json:"publickey"`
+	ChainCode string      `json:"chaincode"`
+	Derived   int         `json:"derived"`
+	Mnemonic  string      `json:"mnemonic,omitempty"`
+	Crypto    *cryptoJSON `json:"crypto,omitempty"`
+	Version   int         `json:"version"`
+}
+
+// hdWallet is a hierarchical deterministic wallet deriving its accounts from a
+// BIP39 mnemonic along the BIP44 path of Ethereum Classic.
+type hdWallet struct {
+	file     string
+	root     DerivationPath // Path the accounts are derived under, by index
+	rootKey  *extendedKey   // Public-only key at the root path
+	accounts []Account      // Accounts derived so far, in derivation order
+
+	mnemonic string      // Plain text mnemonic, empty if stored encrypted
+	crypto   *cryptoJSON // Encrypted mnemonic, nil if stored in plain text
+
+	mu sync.RWMutex
+}
+
+// newHDWallet creates an HD wallet from a mnemonic, encrypting it with the given
+// passphrase unless that is empty. The wallet has no accounts derived yet and
+// is not stored until one is.
+func newHDWallet(file, mnemonic, passphrase string, kdf KDF) (*hdWallet, error) {
+	if _, err := mnemonicToEntropy(mnemonic); err != nil {
+		return nil, err
+	}
+	mnemonic = normalizeMnemonic(mnemonic)
+
+	master, err := newMasterKey(mnemonicSeed(mnemonic, ""))
+	if err != nil {
+		return nil, err
+	}
+	defer master.zero()
+
+	rootKey, err := master.derive(DefaultRootDerivationPath)
+	if err != nil {
+		return nil, err
+	}
+	defer rootKey.zero()
+
+	w := &hdWallet{
+		file:    file,
+		root:    DefaultRootDerivationPath,
+		rootKey: rootKey.public(),
+	}
+	if passphrase == "" {
+		w.mnemonic = mnemonic
+	} else {
+		encrypted, err := encryptData([]byte(mnemonic), passphrase, kdf)
+		if err != nil {
+			return nil, err
+		}
+		w.crypto = &encrypted
+	}
+	return w, nil
+}
+
+// loadHDWallet reads the HD wallet stored in the given file, re-deriving all its
+// accounts. It returns nil without error if there is no such file.
+func loadHDWallet(file string) (*hdWallet, error) {
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored hdWalletJSON
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	if stored.Version != 1 {
+		return nil, fmt.Errorf("unsupported HD wallet version: %d", stored.Version)
+	}
+	root, err := ParseDerivationPath(stored.Path)
+	if err != nil {
+		return nil, err
+	}
+	pub := crypto.ToECDSAPub(common.FromHex(stored.PublicKey))
+	if pub == nil || pub.X == nil {
+		return nil, fmt.Errorf("invalid HD wallet public key: %s", stored.PublicKey)
+	}
+	chainCode, err := hex.DecodeString(stored.ChainCode)
+	if err != nil {
+		return nil, err
+	}
+	w := &hdWallet{
+		file:     file,
+		root:     root,
+		rootKey:  &extendedKey{x: pub.X, y: pub.Y, chainCode: chainCode},
+		mnemonic: stored.Mnemonic,
+		crypto:   stored.Crypto,
+	}
+	for i := 0; i < stored.Derived; i++ {
+		account, err := w.account(i)
+		if err != nil {
+			return nil, err
+		}
+		w.accounts = append(w.accounts, account)
+	}
+	return w, nil
+}
+
+// store writes the wallet into its file.
+func (w *hdWallet) store() error {
+	data, err := json.Marshal(hdWalletJSON{
+		Path:      w.root.String(),
+		PublicKey: hex.EncodeToString(crypto.FromECDSAPub(w.rootKey.publicKey())),
+		ChainCode: hex.EncodeToString(w.rootKey.chainCode),
+		Derived:   len(w.accounts),
+		Mnemonic:  w.mnemonic,
+		Crypto:    w.crypto,
+		Version:   1,
+	})
+	if err != nil {
+		return err
+	}
+	return writeKeyFile(w.file, data)
+}
+
+// account derives the account at the given index below the root path.
+func (w *hdWallet) account(index int) (Account, error) {
+	key, err := w.rootKey.child(uint32(index))
+	if err != nil {
+		return Account{}, err
+	}
+	path := append(append(DerivationPath{}, w.root...), uint32(index))
+	return Account{Address: key.address(), File: w.file, HDPath: path.String()}, nil
+}
+
+// list returns the accounts derived so far.
+func (w *hdWallet) list() []Account {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	cpy := make([]Account, len(w.accounts))
+	copy(cpy, w.accounts)
+	return cpy
+}
+
+// find returns the derived account matching the address, and the file or path
+// if those are set.
+func (w *hdWallet) find(a Account) (Account, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if a.File != "" && a.File != w.file && a.File != filepath.Base(w.file) {
+		return Account{}, false
+	}
+	for _, account := range w.accounts {
+		if account.Address == a.Address && (a.HDPath == "" || a.HDPath == account.HDPath) {
+			return account, true
+		}
+	}
+	return Account{}, false
+}
+
+// derive derives the next account of the wallet and stores the wallet.
+func (w *hdWallet) derive() (Account, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	account, err := w.account(len(w.accounts))
+	if err != nil {
+		return Account{}, err
+	}
+	w.accounts = append(w.accounts, account)
+	if err := w.store(); err != nil {
+		w.accounts = w.accounts[:len(w.accounts)-1]
+		return Account{}, err
+	}
+	return account, nil
+}
+
+// selfDerive derives accounts for as long as the next one was already used on
+// chain, i.e. it has a nonce or a balance, storing the wallet if any was found.
+func (w *hdWallet) selfDerive(chain ChainStateReader) ([]Account, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var derived []Account
+	for {
+		account, err := w.account(len(w.accounts) + len(derived))
+		if err != nil {
+			return nil, err
+		}
+		nonce, err := chain.NonceAt(account.Address)
+		if err != nil {
+			return nil, err
+		}
+		balance, err := chain.BalanceAt(account.Address)
+		if err != nil {
+			return nil, err
+		}
+		if nonce == 0 && balance.Sign() == 0 {
+			break
+		}
+		derived = append(derived, account)
+	}
+	if len(derived) == 0 {
+		return nil, nil
+	}
+	w.accounts = append(w.accounts, derived...)
+	if err := w.store(); err != nil {
+		w.accounts = w.accounts[:len(w.accounts)-len(derived)]
+		return nil, err
+	}
+	return derived, nil
+}
+
+// unlock returns the mnemonic of the wallet, decrypting it with the passphrase
+// if it is stored encrypted.
+func (w *hdWallet) unlock(passphrase string) (string, error) {
+	if w.crypto == nil {
+		if passphrase != "" {
+			return "", ErrDecrypt
+		}
+		return w.mnemonic, nil
+	}
+	mnemonic, err := decryptData(*w.crypto, passphrase)
+	if err != nil {
+		return "", err
+	}
+	return string(mnemonic), nil
+}
+
+// key derives the private key of a derived account, unlocking the wallet with
+// the passphrase.
+func (w *hdWallet) key(a Account, passphrase string) (*key, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	mnemonic, err := w.unlock(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	path, err := ParseDerivationPath(a.HDPath)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := DeriveMnemonicKey(mnemonic, path)
+	if err != nil {
+		return nil, err
+	}
+	if crypto.PubkeyToAddress(privateKey.PublicKey) != a.Address {
+		zeroKey(privateKey)
+		return nil, errAddrMismatch
+	}
+	return newKeyFromECDSA(privateKey)
+}
+
+// update re-encrypts the mnemonic of the wallet with a new passphrase, storing
+// it in plain text if that is empty.
+func (w *hdWallet) update(passphrase, newPassphrase string, kdf KDF) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	mnemonic, err := w.unlock(passphrase)
+	if err != nil {
+		return err
+	}
+	if newPassphrase == "" {
+		w.mnemonic, w.crypto = mnemonic, nil
+	} else {
+		encrypted, err := encryptData([]byte(mnemonic), newPassphrase, kdf)
+		if err != nil {
+			return err
+		}
+		w.mnemonic, w.crypto = "", &encrypted
+	}
+	return w.store()
+}
@@ -0,0 +1,182 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/crypto"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// testChainState is a ChainStateReader reporting the nonces and balances of a
+// fixed set of accounts.
+type testChainState struct {
+	nonces   map[common.Address]uint64
+	balances map[common.Address]*big.Int
+}
+
+func (s *testChainState) NonceAt(account common.Address) (uint64, error) {
+	return s.nonces[account], nil
+}
+
+func (s *testChainState) BalanceAt(account common.Address) (*big.Int, error) {
+	if balance, ok := s.balances[account]; ok {
+		return balance, nil
+	}
+	return new(big.Int), nil
+}
+
+// Tests that an imported mnemonic derives its accounts along the Ethereum Classic
+// path, lists them and signs with them once unlocked with the passphrase.
+func TestHDWalletImport(t *testing.T) {
+	dir, am := tmpManager(t)
+	defer os.RemoveAll(dir)
+
+	if _, err := am.DeriveHDAccount(); err != ErrNoHDWallet {
+		t.Fatalf("derivation without wallet error mismatch: have %v, want %v", err, ErrNoHDWallet)
+	}
+	first, err := am.ImportMnemonic(testMnemonic, "foo")
+	if err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	if first.HDPath != "m/44'/61'/0'/0/0" {
+		t.Errorf("first account path mismatch: have %s, want %s", first.HDPath, "m/44'/61'/0'/0/0")
+	}
+	if _, err := am.ImportMnemonic(testMnemonic, "foo"); err != ErrHDWalletExists {
+		t.Errorf("second import error mismatch: have %v, want %v", err, ErrHDWalletExists)
+	}
+	second, err := am.DeriveHDAccount()
+	if err != nil {
+		t.Fatalf("failed to derive account: %v", err)
+	}
+	if second.HDPath != "m/44'/61'/0'/0/1" {
+		t.Errorf("second account path mismatch: have %s, want %s", second.HDPath, "m/44'/61'/0'/0/1")
+	}
+	// Only the HD accounts should be listed, the wallet file is not a key file
+	if accounts := am.Accounts(); len(accounts) != 2 || accounts[0] != first || accounts[1] != second {
+		t.Fatalf("accounts mismatch: have %v, want %v", accounts, []Account{first, second})
+	}
+	if !am.HasAddress(second.Address) {
+		t.Errorf("derived account not reported")
+	}
+	// Signing must only work with the right passphrase
+	if _, err := am.SignWithPassphrase(second.Address, "bar", testSigData); err != ErrDecrypt {
+		t.Errorf("wrong passphrase error mismatch: have %v, want %v", err, ErrDecrypt)
+	}
+	if err := am.Unlock(Account{Address: second.Address}, "foo"); err != nil {
+		t.Fatalf("failed to unlock derived account: %v", err)
+	}
+	sig, err := am.Sign(second.Address, testSigData)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	pub, err := crypto.SigToPub(testSigData, sig)
+	if err != nil {
+		t.Fatalf("failed to recover signer: %v", err)
+	}
+	if signer := crypto.PubkeyToAddress(*pub); signer != second.Address {
+		t.Errorf("signer mismatch: have %x, want %x", signer, second.Address)
+	}
+	if err := am.DeleteAccount(first, "foo"); err != errHDDelete {
+		t.Errorf("deletion error mismatch: have %v, want %v", err, errHDDelete)
+	}
+}
+
+// Tests that the HD wallet is reloaded from the key directory with all of its
+// derived accounts, and that its passphrase can be changed or removed.
+func TestHDWalletReload(t *testing.T) {
+	dir, am := tmpManager(t)
+	defer os.RemoveAll(dir)
+
+	first, err := am.ImportMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	second, err := am.DeriveHDAccount()
+	if err != nil {
+		t.Fatalf("failed to derive account: %v", err)
+	}
+	if _, err := am.SignWithPassphrase(first.Address, "", testSigData); err != nil {
+		t.Errorf("failed to sign with unencrypted wallet: %v", err)
+	}
+	if err := am.Update(first, "", "foo"); err != nil {
+		t.Fatalf("failed to update passphrase: %v", err)
+	}
+	reloaded, err := NewManager(dir, veryLightScryptN, veryLightScryptP, false)
+	if err != nil {
+		t.Fatalf("failed to reload manager: %v", err)
+	}
+	if accounts := reloaded.Accounts(); len(accounts) != 2 || accounts[0] != first || accounts[1] != second {
+		t.Fatalf("reloaded accounts mismatch: have %v, want %v", accounts, []Account{first, second})
+	}
+	if _, err := reloaded.SignWithPassphrase(second.Address, "", testSigData); err != ErrDecrypt {
+		t.Errorf("old passphrase error mismatch: have %v, want %v", err, ErrDecrypt)
+	}
+	if _, err := reloaded.SignWithPassphrase(second.Address, "foo", testSigData); err != nil {
+		t.Errorf("failed to sign with new passphrase: %v", err)
+	}
+	// Key files imported alongside must still be listed
+	if _, err := reloaded.NewAccount("bar"); err != nil {
+		t.Fatalf("failed to create key file account: %v", err)
+	}
+	if accounts := reloaded.Accounts(); len(accounts) != 3 || accounts[0].HDPath != "" || accounts[0].File == filepath.Join(dir, hdWalletFile) {
+		t.Errorf("mixed accounts mismatch: have %v", accounts)
+	}
+}
+
+// Tests that self-derivation picks up the accounts already used on chain and
+// stops at the first unused one.
+func TestHDWalletSelfDerive(t *testing.T) {
+	dir, am := tmpManager(t)
+	defer os.RemoveAll(dir)
+
+	if _, err := am.ImportMnemonic(testMnemonic, "foo"); err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	// Figure out the addresses of the next accounts without storing them
+	var addrs []common.Address
+	for i := 1; i <= 4; i++ {
+		account, err := am.hdWallet().account(i)
+		if err != nil {
+			t.Fatalf("failed to derive account %d: %v", i, err)
+		}
+		addrs = append(addrs, account.Address)
+	}
+	chain := &testChainState{
+		nonces:   map[common.Address]uint64{addrs[0]: 1},
+		balances: map[common.Address]*big.Int{addrs[1]: big.NewInt(1), addrs[3]: big.NewInt(1)},
+	}
+	derived, err := am.SelfDeriveHDAccounts(chain)
+	if err != nil {
+		t.Fatalf("failed to self-derive: %v", err)
+	}
+	if len(derived) != 2 || derived[0].Address != addrs[0] || derived[1].Address != addrs[1] {
+		t.Fatalf("self-derived accounts mismatch: have %v, want %x", derived, addrs[:2])
+	}
+	if accounts := am.Accounts(); len(accounts) != 3 {
+		t.Errorf("account count mismatch: have %d, want %d", len(accounts), 3)
+	}
+	if derived, err := am.SelfDeriveHDAccounts(chain); err != nil || len(derived) != 0 {
+		t.Errorf("repeated self-derivation mismatch: have %v/%v, want none", derived, err)
+	}
+}
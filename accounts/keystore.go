@@ -26,10 +26,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/pbkdf2"
 	"golang.org/x/crypto/scrypt"
 
@@ -46,6 +49,15 @@ type key struct {
 	// we only store privkey as pubkey/address can be derived from it
 	// privkey in this struct is always in plaintext
 	PrivateKey *ecdsa.PrivateKey
+	// metadata stored along with the key in the key file
+	Meta KeyMeta
+}
+
+// KeyMeta is the metadata section of key files. It is not encrypted.
+type KeyMeta struct {
+	Label   string    `json:"label,omitempty"`  // user given name of the account
+	Created time.Time `json:"created"`          // creation time of the key
+	HDPath  string    `json:"hdpath,omitempty"` // derivation path the key was exported from, if any
 }
 
 type plainKeyJSON struct {
@@ -112,6 +124,7 @@ func newKeyFromECDSA(privateKeyECDSA *ecdsa.PrivateKey) (*key, error) {
 		UUID:       id,
 		Address:    crypto.PubkeyToAddress(privateKeyECDSA.PublicKey),
 		PrivateKey: privateKeyECDSA,
+		Meta:       KeyMeta{Created: time.Now().UTC().Truncate(time.Second)},
 	}, nil
 }
 
@@ -130,16 +143,86 @@ func storeNewKey(store *keyStore, secret string) (*key, Account, error) {
 		return nil, Account{}, err
 	}
 
-	return key, Account{Address: key.Address, File: file}, err
+	return key, Account{Address: key.Address, File: file, Meta: key.Meta}, err
+}
+
+// Key derivation functions available for protecting key files.
+const (
+	KDFScrypt   = "scrypt"
+	KDFPBKDF2   = "pbkdf2"
+	KDFArgon2id = "argon2id"
+)
+
+// KDF selects and configures the key derivation function stretching the
+// passphrases of new and updated key files.
+type KDF struct {
+	Name string // KDFScrypt, KDFPBKDF2 or KDFArgon2id
+
+	ScryptN, ScryptP int    // scrypt CPU/memory cost and parallelization
+	PBKDF2C          int    // PBKDF2 iteration count
+	Argon2T          uint32 // argon2id number of passes
+	Argon2M          uint32 // argon2id memory in KiB
+	Argon2P          uint8  // argon2id number of threads
+}
+
+// NewKDF returns the standard parameters of the named key derivation function,
+// or the light ones trading strength for less RAM and CPU usage.
+func NewKDF(name string, light bool) (KDF, error) {
+	switch name {
+	case KDFScrypt:
+		if light {
+			return KDF{Name: name, ScryptN: LightScryptN, ScryptP: LightScryptP}, nil
+		}
+		return KDF{Name: name, ScryptN: StandardScryptN, ScryptP: StandardScryptP}, nil
+	case KDFPBKDF2:
+		if light {
+			return KDF{Name: name, PBKDF2C: LightPBKDF2C}, nil
+		}
+		return KDF{Name: name, PBKDF2C: StandardPBKDF2C}, nil
+	case KDFArgon2id:
+		if light {
+			return KDF{Name: name, Argon2T: LightArgon2T, Argon2M: LightArgon2M, Argon2P: argon2P}, nil
+		}
+		return KDF{Name: name, Argon2T: StandardArgon2T, Argon2M: StandardArgon2M, Argon2P: argon2P}, nil
+	}
+	return KDF{}, fmt.Errorf("unsupported KDF: %q", name)
+}
+
+// deriveKey stretches the secret with a fresh salt, returning the derived key
+// along with the parameters needed to derive it again.
+func (kdf KDF) deriveKey(secret string) ([]byte, map[string]interface{}, error) {
+	salt := randentropy.GetEntropyCSPRNG(32)
+	params := map[string]interface{}{
+		"dklen": scryptDKLen,
+		"salt":  hex.EncodeToString(salt),
+	}
+	switch kdf.Name {
+	case KDFScrypt:
+		params["n"], params["r"], params["p"] = kdf.ScryptN, scryptR, kdf.ScryptP
+		derivedKey, err := scrypt.Key([]byte(secret), salt, kdf.ScryptN, scryptR, kdf.ScryptP, scryptDKLen)
+		return derivedKey, params, err
+	case KDFPBKDF2:
+		if kdf.PBKDF2C <= 0 {
+			return nil, nil, fmt.Errorf("invalid PBKDF2 iteration count: %d", kdf.PBKDF2C)
+		}
+		params["c"], params["prf"] = kdf.PBKDF2C, "hmac-sha256"
+		return pbkdf2.Key([]byte(secret), salt, kdf.PBKDF2C, scryptDKLen, sha256.New), params, nil
+	case KDFArgon2id:
+		if kdf.Argon2T == 0 || kdf.Argon2M == 0 || kdf.Argon2P == 0 {
+			return nil, nil, fmt.Errorf("invalid argon2id parameters: t=%d m=%d p=%d", kdf.Argon2T, kdf.Argon2M, kdf.Argon2P)
+		}
+		params["t"], params["m"], params["p"] = kdf.Argon2T, kdf.Argon2M, kdf.Argon2P
+		return argon2.IDKey([]byte(secret), salt, kdf.Argon2T, kdf.Argon2M, kdf.Argon2P, scryptDKLen), params, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported KDF: %q", kdf.Name)
 }
 
 type keyStore struct {
 	baseDir string // absolute filepath to default/flagged value for eg datadir/mainnet/keystore
-	scryptN int
-	scryptP int
+	kdf     KDF    // protects the key files written
 }
 
-func newKeyStore(dir string, scryptN, scryptP int) (*keyStore, error) {
+func newKeyStore(dir string, kdf KDF) (*keyStore, error) {
 	if !filepath.IsAbs(dir) {
 		var err error
 		dir, err = filepath.Abs(dir)
@@ -153,8 +236,7 @@ func newKeyStore(dir string, scryptN, scryptP int) (*keyStore, error) {
 
 	return &keyStore{
 		baseDir: dir,
-		scryptN: scryptN,
-		scryptP: scryptP,
+		kdf:     kdf,
 	}, nil
 }
 
@@ -185,7 +267,10 @@ func (store *keyStore) Lookup(file string, secret string) (*key, error) {
 }
 
 func (store *keyStore) Insert(key *key, secret string) (file string, err error) {
-	data, err := encryptKey(key, secret, store.scryptN, store.scryptP)
+	if key.Meta.Created.IsZero() {
+		key.Meta.Created = time.Now().UTC().Truncate(time.Second)
+	}
+	data, err := encryptKey(key, secret, store.kdf)
 	if err != nil {
 		return "", err
 	}
@@ -201,15 +286,74 @@ func (store *keyStore) Insert(key *key, secret string) (file string, err error)
 }
 
 func (store keyStore) Update(file string, key *key, secret string) error {
-	data, err := encryptKey(key, secret, store.scryptN, store.scryptP)
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(store.baseDir, file)
+	}
+	if key.Meta.Created.IsZero() {
+		key.Meta.Created = keyFileCreated(file)
+	}
+	data, err := encryptKey(key, secret, store.kdf)
 	if err != nil {
 		return err
 	}
+	return writeKeyFile(file, data)
+}
 
+// Migrate re-encrypts the key file with the KDF of the store. The
+// original file is copied into the backup directory of the store first, and the
+// new record must decrypt to the same key before it replaces the file. It
+// returns the path of the backup.
+func (store keyStore) Migrate(file string, key *key, secret string) (string, error) {
 	if !filepath.IsAbs(file) {
 		file = filepath.Join(store.baseDir, file)
 	}
-	return writeKeyFile(file, data)
+	if key.Meta.Created.IsZero() {
+		key.Meta.Created = keyFileCreated(file)
+	}
+	data, err := encryptKey(key, secret, store.kdf)
+	if err != nil {
+		return "", err
+	}
+	check, err := decryptKey(data, secret)
+	if err != nil {
+		return "", fmt.Errorf("verify migrated key: %v", err)
+	}
+	defer zeroKey(check.PrivateKey)
+	if check.Address != key.Address || !bytes.Equal(crypto.FromECDSA(check.PrivateKey), crypto.FromECDSA(key.PrivateKey)) {
+		return "", errMigrateMismatch
+	}
+
+	orig, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(store.baseDir, backupDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	timestamp := time.Now().UTC().Format("2006-01-02T15-04-05.999999999")
+	backup := filepath.Join(dir, fmt.Sprintf("%s.%sZ.bak", filepath.Base(file), timestamp))
+	if err := writeKeyFile(backup, orig); err != nil {
+		return "", err
+	}
+	return backup, writeKeyFile(file, data)
+}
+
+// keyFileCreated returns the creation time encoded in the name of key files
+// written by the key store, or the modification time of the file otherwise.
+func keyFileCreated(file string) time.Time {
+	name := filepath.Base(file)
+	if strings.HasPrefix(name, "UTC--") {
+		if i := strings.Index(name[5:], "Z--"); i > 0 {
+			if t, err := time.Parse("2006-01-02T15-04-05.999999999", name[5:5+i]); err == nil {
+				return t.UTC().Truncate(time.Second)
+			}
+		}
+	}
+	if fi, err := os.Stat(file); err == nil {
+		return fi.ModTime().UTC().Truncate(time.Second)
+	}
+	return time.Time{}
 }
 
 // https://github.com/ethereum/wiki/wiki/Web3-Secret-Storage-Definition
@@ -222,7 +366,19 @@ type web3v3 struct {
 	Version int        `json:"version"`
 }
 
-// web3v3 is a version 1 encrypted key store record.
+// web3v4 is a version 4 encrypted key store record. It extends version 3 with
+// a choice of KDF and an unencrypted metadata section. Keys protected by a
+// version 3 KDF are written in this layout but as version 3, whose readers
+// ignore the metadata section.
+type web3v4 struct {
+	ID      string     `json:"id"`
+	Address string     `json:"address"`
+	Crypto  cryptoJSON `json:"crypto"`
+	Meta    KeyMeta    `json:"meta"`
+	Version int        `json:"version"`
+}
+
+// web3v1 is a version 1 encrypted key store record.
 type web3v1 struct {
 	ID      string     `json:"id"`
 	Address string     `json:"address"`
@@ -243,44 +399,54 @@ type cipherparamsJSON struct {
 	IV string `json:"iv"`
 }
 
-// encryptKey encrypts key as version 3.
-func encryptKey(key *key, secret string, scryptN, scryptP int) ([]byte, error) {
-	salt := randentropy.GetEntropyCSPRNG(32)
-	derivedKey, err := scrypt.Key([]byte(secret), salt, scryptN, scryptR, scryptP, scryptDKLen)
+// encryptKey encrypts key along with its metadata, as version 4 if the KDF is
+// argon2id and as version 3 otherwise, so that the file stays readable by
+// version 3 implementations whenever possible.
+func encryptKey(key *key, secret string, kdf KDF) ([]byte, error) {
+	keyBytes := crypto.FromECDSA(key.PrivateKey)
+	cryptoStruct, err := encryptData(keyBytes, secret, kdf)
 	if err != nil {
 		return nil, err
 	}
+	version := 3
+	if kdf.Name == KDFArgon2id {
+		version = 4
+	}
+	return json.Marshal(web3v4{
+		ID:      key.UUID,
+		Address: hex.EncodeToString(key.Address[:]),
+		Crypto:  cryptoStruct,
+		Meta:    key.Meta,
+		Version: version,
+	})
+}
+
+// encryptData encrypts data with the secret using the version 3 cipher and
+// the given key derivation function.
+func encryptData(data []byte, secret string, kdf KDF) (cryptoJSON, error) {
+	derivedKey, kdfParams, err := kdf.deriveKey(secret)
+	if err != nil {
+		return cryptoJSON{}, err
+	}
 	encryptKey := derivedKey[:16]
-	keyBytes := crypto.FromECDSA(key.PrivateKey)
 
 	iv := randentropy.GetEntropyCSPRNG(aes.BlockSize) // 16
-	cipherText, err := aesCTRXOR(encryptKey, keyBytes, iv)
+	cipherText, err := aesCTRXOR(encryptKey, data, iv)
 	if err != nil {
-		return nil, err
+		return cryptoJSON{}, err
 	}
 	mac := crypto.Keccak256(derivedKey[16:32], cipherText)
 
-	return json.Marshal(web3v3{
-		ID:      key.UUID,
-		Address: hex.EncodeToString(key.Address[:]),
-		Crypto: cryptoJSON{
-			Cipher:     "aes-128-ctr",
-			CipherText: hex.EncodeToString(cipherText),
-			CipherParams: cipherparamsJSON{
-				IV: hex.EncodeToString(iv),
-			},
-			KDF: "scrypt",
-			KDFParams: map[string]interface{}{
-				"n":     scryptN,
-				"r":     scryptR,
-				"p":     scryptP,
-				"dklen": scryptDKLen,
-				"salt":  hex.EncodeToString(salt),
-			},
-			MAC: hex.EncodeToString(mac),
+	return cryptoJSON{
+		Cipher:     "aes-128-ctr",
+		CipherText: hex.EncodeToString(cipherText),
+		CipherParams: cipherparamsJSON{
+			IV: hex.EncodeToString(iv),
 		},
-		Version: 3,
-	})
+		KDF:       kdf.Name,
+		KDFParams: kdfParams,
+		MAC:       hex.EncodeToString(mac),
+	}, nil
 }
 
 // Web3PrivateKey decrypts the record with secret and returns the private key.
@@ -304,6 +470,7 @@ func decryptKey(web3JSON []byte, secret string) (*key, error) {
 	var (
 		keyBytes []byte
 		keyUUID  string
+		keyMeta  KeyMeta
 	)
 	if version, ok := m["version"].(string); ok && version == "1" {
 		w := new(web3v1)
@@ -319,18 +486,18 @@ func decryptKey(web3JSON []byte, secret string) (*key, error) {
 			return nil, err
 		}
 	} else {
-		w := new(web3v3)
+		w := new(web3v4)
 		if err := json.Unmarshal(web3JSON, w); err != nil {
 			return nil, err
 		}
-		if w.Version != 3 {
+		if w.Version != 3 && w.Version != 4 {
 			return nil, fmt.Errorf("unsupported Web3 version: %v", version)
 		}
 
-		keyUUID = w.ID
+		keyUUID, keyMeta = w.ID, w.Meta
 
 		var err error
-		keyBytes, err = decryptKeyV3(w, secret)
+		keyBytes, err = decryptData(w.Crypto, secret)
 		if err != nil {
 			return nil, err
 		}
@@ -341,30 +508,36 @@ func decryptKey(web3JSON []byte, secret string) (*key, error) {
 		UUID:       keyUUID,
 		Address:    crypto.PubkeyToAddress(k.PublicKey),
 		PrivateKey: k,
+		Meta:       keyMeta,
 	}, nil
 }
 
 func decryptKeyV3(keyProtected *web3v3, secret string) (keyBytes []byte, err error) {
-	if keyProtected.Crypto.Cipher != "aes-128-ctr" {
-		return nil, fmt.Errorf("Cipher not supported: %v", keyProtected.Crypto.Cipher)
+	return decryptData(keyProtected.Crypto, secret)
+}
+
+// decryptData decrypts data protected by the version 3 cipher with the secret.
+func decryptData(cryptoStruct cryptoJSON, secret string) ([]byte, error) {
+	if cryptoStruct.Cipher != "aes-128-ctr" {
+		return nil, fmt.Errorf("Cipher not supported: %v", cryptoStruct.Cipher)
 	}
 
-	mac, err := hex.DecodeString(keyProtected.Crypto.MAC)
+	mac, err := hex.DecodeString(cryptoStruct.MAC)
 	if err != nil {
 		return nil, err
 	}
 
-	iv, err := hex.DecodeString(keyProtected.Crypto.CipherParams.IV)
+	iv, err := hex.DecodeString(cryptoStruct.CipherParams.IV)
 	if err != nil {
 		return nil, err
 	}
 
-	cipherText, err := hex.DecodeString(keyProtected.Crypto.CipherText)
+	cipherText, err := hex.DecodeString(cryptoStruct.CipherText)
 	if err != nil {
 		return nil, err
 	}
 
-	derivedKey, err := getKDFKey(keyProtected.Crypto, secret)
+	derivedKey, err := getKDFKey(cryptoStruct, secret)
 	if err != nil {
 		return nil, err
 	}
@@ -435,6 +608,24 @@ func getKDFKey(cryptoJSON cryptoJSON, secret string) ([]byte, error) {
 		}
 		key := pbkdf2.Key([]byte(secret), salt, c, dkLen, sha256.New)
 		return key, nil
+
+	} else if cryptoJSON.KDF == "argon2id" {
+		t, err := kdfParamInt(cryptoJSON.KDFParams, "t")
+		if err != nil {
+			return nil, err
+		}
+		m, err := kdfParamInt(cryptoJSON.KDFParams, "m")
+		if err != nil {
+			return nil, err
+		}
+		p, err := kdfParamInt(cryptoJSON.KDFParams, "p")
+		if err != nil {
+			return nil, err
+		}
+		if t < 1 || t > maxArgon2T || m < 1 || m > maxArgon2M || p < 1 || p > maxArgon2P || dkLen < 32 {
+			return nil, fmt.Errorf("Invalid argon2id parameters: t=%d m=%d p=%d dklen=%d", t, m, p, dkLen)
+		}
+		return argon2.IDKey([]byte(secret), salt, uint32(t), uint32(m), uint8(p), uint32(dkLen)), nil
 	}
 
 	return nil, fmt.Errorf("Unsupported KDF: %s", cryptoJSON.KDF)
@@ -451,6 +642,20 @@ func ensureInt(x interface{}) int {
 	return res
 }
 
+// kdfParamInt retrieves the KDF parameter name of a key file, which has to be
+// present and a whole number, unlike with ensureInt.
+func kdfParamInt(params map[string]interface{}, name string) (int, error) {
+	switch x := params[name].(type) {
+	case int:
+		return x, nil
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) <= math.MaxInt32 {
+			return int(x), nil
+		}
+	}
+	return 0, fmt.Errorf("Invalid KDF parameter %s: %v", name, params[name])
+}
+
 func writeKeyFile(file string, content []byte) error {
 	dir, basename := filepath.Split(file)
 
@@ -40,7 +40,7 @@ func tmpKeyStore(t *testing.T) (dir string, ks *keyStore) {
 		t.Fatal(err)
 	}
 
-	store, err := newKeyStore(dir, veryLightScryptN, veryLightScryptP)
+	store, err := newKeyStore(dir, veryLightKDF)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -163,7 +163,7 @@ func TestV1_1(t *testing.T) {
 
 func TestV1_2(t *testing.T) {
 	t.Parallel()
-	store, err := newKeyStore("testdata/v1", LightScryptN, LightScryptP)
+	store, err := newKeyStore("testdata/v1", KDF{Name: KDFScrypt, ScryptN: LightScryptN, ScryptP: LightScryptP})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -267,6 +267,8 @@ const (
 	veryLightScryptP = 1
 )
 
+var veryLightKDF = KDF{Name: KDFScrypt, ScryptN: veryLightScryptN, ScryptP: veryLightScryptP}
+
 // Tests that a JSON key file can be decrypted and encrypted in multiple rounds.
 func TestKeyEncryptDecrypt(t *testing.T) {
 	keyjson, err := ioutil.ReadFile("testdata/very-light-scrypt.json")
@@ -292,8 +294,88 @@ func TestKeyEncryptDecrypt(t *testing.T) {
 		}
 		// recrypt with a new password and start over
 		password += "new data appended"
-		if keyjson, err = encryptKey(key, password, veryLightScryptN, veryLightScryptP); err != nil {
+		if keyjson, err = encryptKey(key, password, veryLightKDF); err != nil {
 			t.Errorf("test %d: failed to recrypt key %v", i, err)
 		}
 	}
 }
+
+// Tests that keys protected by each KDF decrypt to the same key, carrying their
+// metadata along, as version 4 for argon2id only.
+func TestKeyEncryptKDFs(t *testing.T) {
+	key, err := newKeyFromECDSA(crypto.ToECDSA(crypto.Keccak256([]byte("kdf"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.Meta.Label, key.Meta.HDPath = "savings", "m/44'/61'/0'/0/1"
+
+	versions := map[string]int{KDFScrypt: 3, KDFPBKDF2: 3, KDFArgon2id: 4}
+	for _, name := range []string{KDFScrypt, KDFPBKDF2, KDFArgon2id} {
+		kdf, err := NewKDF(name, true)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		keyjson, err := encryptKey(key, "foo", kdf)
+		if err != nil {
+			t.Fatalf("%s: failed to encrypt: %v", name, err)
+		}
+		var w web3v4
+		if err := json.Unmarshal(keyjson, &w); err != nil {
+			t.Fatalf("%s: failed to decode: %v", name, err)
+		}
+		if w.Version != versions[name] || w.Crypto.KDF != name {
+			t.Errorf("%s: record mismatch: have version %d kdf %s, want version %d", name, w.Version, w.Crypto.KDF, versions[name])
+		}
+		if _, err := decryptKey(keyjson, "bar"); err != ErrDecrypt {
+			t.Errorf("%s: wrong passphrase error mismatch: have %v, want %v", name, err, ErrDecrypt)
+		}
+		dec, err := decryptKey(keyjson, "foo")
+		if err != nil {
+			t.Fatalf("%s: failed to decrypt: %v", name, err)
+		}
+		if dec.Address != key.Address || dec.UUID != key.UUID {
+			t.Errorf("%s: key mismatch: have %x/%s, want %x/%s", name, dec.Address, dec.UUID, key.Address, key.UUID)
+		}
+		if dec.Meta != key.Meta {
+			t.Errorf("%s: metadata mismatch: have %+v, want %+v", name, dec.Meta, key.Meta)
+		}
+	}
+	if _, err := NewKDF("bcrypt", false); err == nil {
+		t.Errorf("unknown KDF accepted")
+	}
+}
+
+// Tests that argon2id parameters beyond the bounds are rejected before deriving
+// the key.
+func TestArgon2Bounds(t *testing.T) {
+	tests := []struct {
+		t, m, p int
+		ok      bool
+	}{
+		{1, 8, 1, true},
+		{maxArgon2T + 1, 8, 1, false},
+		{1, maxArgon2M + 1, 1, false},
+		{1, 8, maxArgon2P + 1, false},
+		{0, 8, 1, false},
+	}
+	for i, tt := range tests {
+		params := cryptoJSON{KDF: KDFArgon2id, KDFParams: map[string]interface{}{
+			"salt": "00", "dklen": float64(32), "t": float64(tt.t), "m": float64(tt.m), "p": float64(tt.p),
+		}}
+		if _, err := getKDFKey(params, "foo"); (err == nil) != tt.ok {
+			t.Errorf("test %d: t=%d m=%d p=%d: error mismatch: have %v, want ok %v", i, tt.t, tt.m, tt.p, err, tt.ok)
+		}
+	}
+	// Missing or malformed parameters are rejected rather than panicking
+	for i, p := range []interface{}{nil, "1", 1.5, []interface{}{1}} {
+		params := cryptoJSON{KDF: KDFArgon2id, KDFParams: map[string]interface{}{
+			"salt": "00", "dklen": float64(32), "t": float64(1), "m": float64(8),
+		}}
+		if p != nil {
+			params.KDFParams["p"] = p
+		}
+		if _, err := getKDFKey(params, "foo"); err == nil {
+			t.Errorf("malformed test %d: p=%v: no error", i, p)
+		}
+	}
+}
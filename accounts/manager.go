@@ -24,6 +24,7 @@ import (
 	"crypto/ecdsa"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"runtime"
 	"sync"
@@ -31,7 +32,9 @@ import (
 
 	"encoding/json"
 	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/types"
 	"github.com/ethereumproject/go-ethereum/crypto"
+	"github.com/ethereumproject/go-ethereum/event"
 	"path/filepath"
 )
 
@@ -40,7 +43,9 @@ var (
 	ErrNoMatch = errors.New("no key for given address or file")
 	ErrDecrypt = errors.New("could not decrypt key with given passphrase")
 
-	errAddrMismatch = errors.New("security violation: address of file didn't match request")
+	errAddrMismatch    = errors.New("security violation: address of file didn't match request")
+	errMigrateMismatch = errors.New("migrated key file does not decrypt to the original key")
+	errNoMeta          = errors.New("key file has no metadata section, migrate it first")
 )
 
 // Account represents a stored key.
@@ -53,7 +58,15 @@ type Account struct {
 	// When Acccount is used as an argument to select a key, File can be left blank to
 	// select just by address or set to the basename or absolute path of a file in the key
 	// directory. Accounts returned by Manager will always contain an absolute path.
+	// Accounts derived from the HD wallet refer to the wallet file.
 	File string
+
+	// HDPath is the BIP32 derivation path of accounts derived from the HD wallet,
+	// e.g. m/44'/61'/0'/0/1, and empty for accounts backed by key files.
+	HDPath string
+
+	// Meta is the metadata section of the key file, zero for files without one.
+	Meta KeyMeta
 }
 
 // AccountJSON is an auxiliary between Account and EasyMarshal'd structs.
@@ -62,6 +75,9 @@ type AccountJSON struct {
 	Address      string `json:"address"`
 	EncryptedKey string `json:"key"`
 	File         string `json:"file"`
+	Label        string `json:"label"`
+	Created      string `json:"created"`
+	HDPath       string `json:"hdpath"`
 }
 
 func (acc *Account) MarshalJSON() ([]byte, error) {
@@ -78,6 +94,13 @@ type Manager struct {
 	keyStore keyStore
 	mu       sync.RWMutex
 	unlocked map[common.Address]*unlocked
+
+	hd   *hdWallet // Imported HD wallet, nil if none
+	hdMu sync.RWMutex
+
+	backends  []Backend     // Additional wallet sources, e.g. hardware wallets
+	backendMu sync.RWMutex  // Protects backends
+	walletMux event.TypeMux // Forwards the wallet events of the backends
 }
 
 type unlocked struct {
@@ -96,12 +119,45 @@ const (
 
 	scryptR     = 8
 	scryptDKLen = 32
+
+	// 2^18 iterations of HMAC-SHA256 take approx 100ms CPU time on a modern CPU.
+	StandardPBKDF2C = 1 << 18
+	LightPBKDF2C    = 1 << 14
+
+	// t,m,p = 3, 256MB, 4 and 3, 4MB, 4 mirror the memory usage of scrypt.
+	StandardArgon2T = 3
+	StandardArgon2M = 1 << 18
+	LightArgon2T    = 3
+	LightArgon2M    = 1 << 12
+	argon2P         = 4
+
+	// Upper bounds on the argon2id parameters of key files, so that a crafted
+	// file can't make its decryption exhaust memory (512MB) or CPU.
+	maxArgon2T = 16
+	maxArgon2M = 1 << 19
+	maxArgon2P = 16
+
+	// backupDir is the directory within the key directory receiving the
+	// original key files replaced by Migrate.
+	backupDir = "backup"
 )
 
-// NewManager creates a manager for the given directory.
+// NewManager creates a manager for the given directory, protecting new key
+// files with scrypt.
 // keydir is by default /Users/ia/Library/EthereumClassic/mainnet/keystore
 func NewManager(keydir string, scryptN, scryptP int, wantCacheDB bool) (*Manager, error) {
-	store, err := newKeyStore(keydir, scryptN, scryptP)
+	return NewManagerKDF(keydir, KDF{Name: KDFScrypt, ScryptN: scryptN, ScryptP: scryptP}, wantCacheDB)
+}
+
+// NewManagerKDF creates a manager for the given directory, protecting new and
+// updated key files with the given key derivation function.
+func NewManagerKDF(keydir string, kdf KDF, wantCacheDB bool) (*Manager, error) {
+	store, err := newKeyStore(keydir, kdf)
+	if err != nil {
+		return nil, err
+	}
+
+	hd, err := loadHDWallet(filepath.Join(store.baseDir, hdWalletFile))
 	if err != nil {
 		return nil, err
 	}
@@ -109,6 +165,7 @@ func NewManager(keydir string, scryptN, scryptP int, wantCacheDB bool) (*Manager
 	am := &Manager{
 		keyStore: *store,
 		unlocked: make(map[common.Address]*unlocked),
+		hd:       hd,
 	}
 	if wantCacheDB {
 		am.ac = newCacheDB(keydir)
@@ -139,14 +196,38 @@ func (am *Manager) BuildIndexDB() []error {
 
 // HasAddress reports whether a key with the given address is present.
 func (am *Manager) HasAddress(addr common.Address) bool {
+	if hd := am.hdWallet(); hd != nil {
+		if _, ok := hd.find(Account{Address: addr}); ok {
+			return true
+		}
+	}
 	return am.ac.hasAddress(addr)
 }
 
-// Accounts returns all key files present in the directory.
+// Accounts returns all key files present in the directory, followed by the
+// accounts derived from the HD wallet and those of the backend wallets.
 func (am *Manager) Accounts() []Account {
+	accounts := am.keystoreAccounts()
+	for _, wallet := range am.backendWallets() {
+		accounts = append(accounts, wallet.Accounts()...)
+	}
+	return accounts
+}
+
+// KeyFileAccounts returns the accounts backed by key files in the directory.
+func (am *Manager) KeyFileAccounts() []Account {
 	return am.ac.accounts()
 }
 
+// keystoreAccounts returns the accounts the manager holds the keys of.
+func (am *Manager) keystoreAccounts() []Account {
+	accounts := am.ac.accounts()
+	if hd := am.hdWallet(); hd != nil {
+		accounts = append(accounts, hd.list()...)
+	}
+	return accounts
+}
+
 // DeleteAccount deletes the key matched by account if the passphrase is correct.
 // If a contains no filename, the address must match a unique key.
 func (am *Manager) DeleteAccount(a Account, passphrase string) error {
@@ -160,6 +241,9 @@ func (am *Manager) DeleteAccount(a Account, passphrase string) error {
 	if err != nil {
 		return err
 	}
+	if a.HDPath != "" {
+		return errHDDelete
+	}
 
 	if !filepath.IsAbs(a.File) {
 		p := filepath.Join(am.ac.getKeydir(), a.File)
@@ -200,6 +284,16 @@ func (am *Manager) SignWithPassphrase(addr common.Address, passphrase string, ha
 	return crypto.Sign(hash, key.PrivateKey)
 }
 
+// TextHash returns the hash signed for an arbitrary message, computed as
+//
+//	keccak256("\x19Ethereum Signed Message:\n" + len(message) + message)
+//
+// The prefix makes sure a signed message can never be a valid transaction.
+func TextHash(data []byte) []byte {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(data))
+	return crypto.Keccak256([]byte(prefix), data)
+}
+
 // Unlock unlocks the given account indefinitely.
 func (am *Manager) Unlock(a Account, passphrase string) error {
 	return am.TimedUnlock(a, passphrase, 0)
@@ -255,6 +349,15 @@ func (am *Manager) TimedUnlock(a Account, passphrase string, timeout time.Durati
 }
 
 func (am *Manager) getDecryptedKey(a Account, auth string) (Account, *key, error) {
+	if hd := am.hdWallet(); hd != nil {
+		if a, ok := hd.find(a); ok {
+			key, err := hd.key(a, auth)
+			if err != nil {
+				return Account{}, nil, err
+			}
+			return a, key, nil
+		}
+	}
 	am.ac.maybeReload()
 	am.ac.muLock()
 	a, err := am.ac.find(a)
@@ -324,11 +427,14 @@ func (am *Manager) AccountByIndex(i int) (Account, error) {
 
 // Export exports as a JSON key, encrypted with newPassphrase.
 func (am *Manager) Export(a Account, passphrase, newPassphrase string) (keyJSON []byte, err error) {
-	_, key, err := am.getDecryptedKey(a, passphrase)
+	a, key, err := am.getDecryptedKey(a, passphrase)
 	if err != nil {
 		return nil, err
 	}
-	return encryptKey(key, newPassphrase, am.keyStore.scryptN, am.keyStore.scryptP)
+	if a.HDPath != "" {
+		key.Meta = KeyMeta{Created: time.Now().UTC().Truncate(time.Second), HDPath: a.HDPath}
+	}
+	return encryptKey(key, newPassphrase, am.keyStore.kdf)
 }
 
 // Import stores the given encrypted JSON key into the key directory.
@@ -363,7 +469,7 @@ func (am *Manager) importKey(key *key, passphrase string) (Account, error) {
 		return Account{}, err
 	}
 
-	a := Account{File: file, Address: key.Address}
+	a := Account{File: file, Address: key.Address, Meta: key.Meta}
 	am.ac.add(a)
 	return a, nil
 }
@@ -374,7 +480,90 @@ func (am *Manager) Update(a Account, passphrase, newPassphrase string) error {
 	if err != nil {
 		return err
 	}
-	return am.keyStore.Update(a.File, key, newPassphrase)
+	if a.HDPath != "" {
+		zeroKey(key.PrivateKey)
+		return am.hdWallet().update(passphrase, newPassphrase, am.keyStore.kdf)
+	}
+	defer zeroKey(key.PrivateKey)
+	if err := am.keyStore.Update(a.File, key, newPassphrase); err != nil {
+		return err
+	}
+	am.refresh(a, key.Meta)
+	return nil
+}
+
+// Migrate re-encrypts the key file of the account in place with the key
+// derivation function of the manager and the same passphrase, adding the
+// metadata section (see encryptKey for the version written). The
+// original file is kept in the backup directory of the key directory, whose
+// path is returned, and only replaced once the new one is verified.
+func (am *Manager) Migrate(a Account, passphrase string) (Account, string, error) {
+	a, key, err := am.getDecryptedKey(a, passphrase)
+	if err != nil {
+		return Account{}, "", err
+	}
+	defer zeroKey(key.PrivateKey)
+	if a.HDPath != "" {
+		return Account{}, "", errHDMigrate
+	}
+	backup, err := am.keyStore.Migrate(a.File, key, passphrase)
+	if err != nil {
+		return Account{}, "", err
+	}
+	return am.refresh(a, key.Meta), backup, nil
+}
+
+// SetLabel sets the label in the metadata section of the key file of the
+// account, which must have one. The key stays encrypted.
+func (am *Manager) SetLabel(a Account, label string) (Account, error) {
+	am.ac.maybeReload()
+	am.ac.muLock()
+	a, err := am.ac.find(a)
+	am.ac.muUnlock()
+	if err != nil {
+		return Account{}, err
+	}
+	file := a.File
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(am.keyStore.baseDir, file)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return Account{}, err
+	}
+	var w web3v4
+	if err := json.Unmarshal(data, &w); err != nil {
+		return Account{}, err
+	}
+	if w.Version != 4 && w.Meta.Created.IsZero() {
+		return Account{}, errNoMeta
+	}
+	w.Meta.Label = label
+	if data, err = json.Marshal(w); err != nil {
+		return Account{}, err
+	}
+	if err := writeKeyFile(file, data); err != nil {
+		return Account{}, err
+	}
+	return am.refresh(a, w.Meta), nil
+}
+
+// refresh replaces the cached account after its key file was rewritten with
+// the given metadata, returning the new account.
+func (am *Manager) refresh(a Account, meta KeyMeta) Account {
+	am.ac.delete(a)
+	a.Meta = meta
+	if a.EncryptedKey != "" {
+		file := a.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(am.keyStore.baseDir, file)
+		}
+		if data, err := ioutil.ReadFile(file); err == nil {
+			a.EncryptedKey = string(data)
+		}
+	}
+	am.ac.add(a)
+	return a
 }
 
 // ImportPreSaleKey decrypts the given Ethereum presale wallet and stores
@@ -388,6 +577,141 @@ func (am *Manager) ImportPreSaleKey(keyJSON []byte, passphrase string) (Account,
 	return a, nil
 }
 
+// ImportMnemonic imports a BIP39 mnemonic as the HD wallet of the manager and
+// derives its first account at m/44'/61'/0'/0/0. The mnemonic is stored in the
+// key directory, encrypted with the passphrase unless that is empty. Accounts
+// derived from the wallet are unlocked with the same passphrase.
+func (am *Manager) ImportMnemonic(mnemonic, passphrase string) (Account, error) {
+	am.hdMu.Lock()
+	defer am.hdMu.Unlock()
+
+	if am.hd != nil {
+		return Account{}, ErrHDWalletExists
+	}
+	hd, err := newHDWallet(filepath.Join(am.keyStore.baseDir, hdWalletFile), mnemonic, passphrase, am.keyStore.kdf)
+	if err != nil {
+		return Account{}, err
+	}
+	a, err := hd.derive()
+	if err != nil {
+		return Account{}, err
+	}
+	am.hd = hd
+	return a, nil
+}
+
+// DeriveHDAccount derives the next account of the HD wallet. No passphrase is
+// needed since only the public part of the derivation is performed.
+func (am *Manager) DeriveHDAccount() (Account, error) {
+	hd := am.hdWallet()
+	if hd == nil {
+		return Account{}, ErrNoHDWallet
+	}
+	return hd.derive()
+}
+
+// SelfDeriveHDAccounts derives the accounts of the HD wallet which were already
+// used on chain, stopping at the first one without any nonce or balance. It
+// returns the newly derived accounts.
+func (am *Manager) SelfDeriveHDAccounts(chain ChainStateReader) ([]Account, error) {
+	hd := am.hdWallet()
+	if hd == nil {
+		return nil, ErrNoHDWallet
+	}
+	return hd.selfDerive(chain)
+}
+
+// AddBackend registers a source of wallets, e.g. a hub of USB hardware wallets.
+// Their accounts are listed and can sign transactions alongside the ones in the
+// key directory, and their wallet events are forwarded to Subscribe.
+func (am *Manager) AddBackend(backend Backend) {
+	am.backendMu.Lock()
+	am.backends = append(am.backends, backend)
+	am.backendMu.Unlock()
+
+	sub := backend.Subscribe()
+	go func() {
+		for ev := range sub.Chan() {
+			am.walletMux.Post(ev.Data)
+		}
+	}()
+}
+
+// Subscribe creates a subscription delivering the WalletEvents of all the
+// registered backends.
+func (am *Manager) Subscribe() event.Subscription {
+	return am.walletMux.Subscribe(WalletEvent{})
+}
+
+// Wallets returns a wallet for every account of the key directory and the HD
+// wallet, followed by the wallets of the registered backends.
+func (am *Manager) Wallets() []Wallet {
+	var wallets []Wallet
+	for _, account := range am.keystoreAccounts() {
+		wallets = append(wallets, &keystoreWallet{account: account, am: am})
+	}
+	return append(wallets, am.backendWallets()...)
+}
+
+// backendWallets returns the wallets of the registered backends.
+func (am *Manager) backendWallets() []Wallet {
+	am.backendMu.RLock()
+	defer am.backendMu.RUnlock()
+
+	var wallets []Wallet
+	for _, backend := range am.backends {
+		wallets = append(wallets, backend.Wallets()...)
+	}
+	return wallets
+}
+
+// Wallet returns the wallet with the given URL.
+func (am *Manager) Wallet(url string) (Wallet, error) {
+	for _, wallet := range am.Wallets() {
+		if wallet.URL() == url {
+			return wallet, nil
+		}
+	}
+	return nil, ErrUnknownWallet
+}
+
+// Find returns the wallet holding the given account.
+func (am *Manager) Find(a Account) (Wallet, error) {
+	for _, wallet := range am.Wallets() {
+		if wallet.Contains(a) {
+			return wallet, nil
+		}
+	}
+	return nil, ErrUnknownWallet
+}
+
+// SignTx signs the transaction with the given account, which must be unlocked
+// if it is backed by a key file, through whichever wallet holds it.
+func (am *Manager) SignTx(a Account, tx *types.Transaction, signer types.Signer) (*types.Transaction, error) {
+	wallet, err := am.Find(a)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.SignTx(a, tx, signer)
+}
+
+// SignTxWithPassphrase signs the transaction with the given account through
+// whichever wallet holds it, unlocking it with the passphrase if needed.
+func (am *Manager) SignTxWithPassphrase(a Account, passphrase string, tx *types.Transaction, signer types.Signer) (*types.Transaction, error) {
+	wallet, err := am.Find(a)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.SignTxWithPassphrase(a, passphrase, tx, signer)
+}
+
+// hdWallet returns the imported HD wallet, or nil if there is none.
+func (am *Manager) hdWallet() *hdWallet {
+	am.hdMu.RLock()
+	defer am.hdMu.RUnlock()
+	return am.hd
+}
+
 // zeroKey zeroes a private key in memory.
 func zeroKey(k *ecdsa.PrivateKey) {
 	b := k.D.Bits()
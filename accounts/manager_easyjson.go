@@ -43,6 +43,12 @@ func easyjsonEd74d837DecodeGithubComEthereumprojectGoEthereumAccounts(in *jlexer
 			out.EncryptedKey = string(in.String())
 		case "file":
 			out.File = string(in.String())
+		case "label":
+			out.Label = string(in.String())
+		case "created":
+			out.Created = string(in.String())
+		case "hdpath":
+			out.HDPath = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -75,6 +81,24 @@ func easyjsonEd74d837EncodeGithubComEthereumprojectGoEthereumAccounts(out *jwrit
 	first = false
 	out.RawString("\"file\":")
 	out.String(string(in.File))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"label\":")
+	out.String(string(in.Label))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"created\":")
+	out.String(string(in.Created))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"hdpath\":")
+	out.String(string(in.HDPath))
 	out.RawByte('}')
 }
 
@@ -17,11 +17,15 @@
 package accounts
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/ethereumproject/go-ethereum/common"
 	"io/ioutil"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"strings"
@@ -261,3 +265,91 @@ func TestSignRace_Mem(t *testing.T) {
 	}
 	t.Error("Account did not lock within the timeout")
 }
+
+// Tests that version 3 key files are migrated in place to version 4 with the
+// configured KDF, keeping a backup, and that the account indexes pick up the
+// metadata of the new files.
+func TestMigrate(t *testing.T) {
+	for _, cachedb := range []bool{false, true} {
+		testMigrate(t, cachedb)
+	}
+}
+
+func testMigrate(t *testing.T, cachedb bool) {
+	dir, err := ioutil.TempDir("", "eth-manager-migrate-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	orig, err := ioutil.ReadFile("testdata/very-light-scrypt.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "UTC--2016-03-22T12-57-55.920751759Z--45dea0fb0bba44f4fcf290bba71fd57d7117cbb8")
+	if err := ioutil.WriteFile(file, orig, 0600); err != nil {
+		t.Fatal(err)
+	}
+	kdf, err := NewKDF(KDFArgon2id, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	am, err := NewManagerKDF(dir, kdf, cachedb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cachedb {
+		if errs := am.BuildIndexDB(); len(errs) != 0 {
+			t.Fatalf("failed to build index: %v", errs)
+		}
+	}
+	account := Account{Address: common.HexToAddress("45dea0fb0bba44f4fcf290bba71fd57d7117cbb8")}
+
+	if _, err := am.SetLabel(account, "savings"); err != errNoMeta {
+		t.Errorf("cachedb %v: version 3 label error mismatch: have %v, want %v", cachedb, err, errNoMeta)
+	}
+	if _, _, err := am.Migrate(account, "bad"); err != ErrDecrypt {
+		t.Errorf("cachedb %v: wrong passphrase error mismatch: have %v, want %v", cachedb, err, ErrDecrypt)
+	}
+	migrated, backup, err := am.Migrate(account, "")
+	if err != nil {
+		t.Fatalf("cachedb %v: failed to migrate: %v", cachedb, err)
+	}
+	if blob, err := ioutil.ReadFile(backup); err != nil || !bytes.Equal(blob, orig) {
+		t.Errorf("cachedb %v: backup mismatch: %v", cachedb, err)
+	}
+	blob, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var w web3v4
+	if err := json.Unmarshal(blob, &w); err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2016, 3, 22, 12, 57, 55, 0, time.UTC)
+	if w.Version != 4 || w.Crypto.KDF != KDFArgon2id || !w.Meta.Created.Equal(created) {
+		t.Errorf("cachedb %v: migrated record mismatch: version %d kdf %s created %v", cachedb, w.Version, w.Crypto.KDF, w.Meta.Created)
+	}
+	if !migrated.Meta.Created.Equal(created) {
+		t.Errorf("cachedb %v: migrated account creation time mismatch: have %v, want %v", cachedb, migrated.Meta.Created, created)
+	}
+	if err := am.Unlock(account, ""); err != nil {
+		t.Errorf("cachedb %v: failed to unlock migrated account: %v", cachedb, err)
+	}
+	if _, err := am.SetLabel(account, "savings"); err != nil {
+		t.Fatalf("cachedb %v: failed to set label: %v", cachedb, err)
+	}
+	accs := am.Accounts()
+	if len(accs) != 1 || accs[0].Meta.Label != "savings" || !accs[0].Meta.Created.Equal(created) {
+		t.Errorf("cachedb %v: indexed metadata mismatch: have %+v", cachedb, accs)
+	}
+	// Rebuilding the index from the files should yield the same metadata
+	if cachedb {
+		if errs := am.BuildIndexDB(); len(errs) != 0 {
+			t.Fatalf("failed to rebuild index: %v", errs)
+		}
+		if reindexed := am.Accounts(); len(reindexed) != 1 || reindexed[0].Meta != accs[0].Meta {
+			t.Errorf("reindexed metadata mismatch: have %+v, want %+v", reindexed, accs[0].Meta)
+		}
+	}
+}
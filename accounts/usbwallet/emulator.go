@@ -0,0 +1,254 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package usbwallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/ethereumproject/go-ethereum/accounts"
+	"github.com/ethereumproject/go-ethereum/crypto"
+	"github.com/ethereumproject/go-ethereum/rlp"
+)
+
+// emulatorPath is the device path the Emulator is enumerated under.
+const emulatorPath = "emulator"
+
+// emulatorVersion is the Ethereum application version the Emulator reports.
+var emulatorVersion = [3]byte{1, 0, 0}
+
+// Emulator is a software Ledger device running the Ethereum application, holding
+// the accounts of a BIP39 mnemonic. It is reached through the same Enumerator
+// and Transport interfaces as real devices, allowing wallets to be tested
+// without hardware. Transactions are signed without any user confirmation.
+type Emulator struct {
+	mnemonic string
+	plugged  bool
+	lock     sync.Mutex
+}
+
+// NewEmulator creates an unplugged emulated device deriving its accounts from
+// the given mnemonic.
+func NewEmulator(mnemonic string) (*Emulator, error) {
+	if _, err := accounts.DeriveMnemonicKey(mnemonic, accounts.DefaultBaseDerivationPath); err != nil {
+		return nil, err
+	}
+	return &Emulator{mnemonic: mnemonic}, nil
+}
+
+// Plug attaches the emulated device, making it enumerable.
+func (e *Emulator) Plug() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.plugged = true
+}
+
+// Unplug detaches the emulated device, failing all open transports.
+func (e *Emulator) Unplug() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.plugged = false
+}
+
+// attached reports whether the emulated device is plugged in.
+func (e *Emulator) attached() bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return e.plugged
+}
+
+// Enumerate implements Enumerator, listing the emulated device if plugged in.
+func (e *Emulator) Enumerate() ([]DeviceInfo, error) {
+	if !e.attached() {
+		return nil, nil
+	}
+	return []DeviceInfo{{Path: emulatorPath, VendorID: ledgerVendorID, ProductID: 0x0001}}, nil
+}
+
+// Open implements Enumerator, connecting to the emulated device.
+func (e *Emulator) Open(info DeviceInfo) (Transport, error) {
+	if info.Path != emulatorPath || !e.attached() {
+		return nil, errDeviceGone
+	}
+	return &emulatorTransport{emulator: e}, nil
+}
+
+// emulatorTransport is a connection to the Emulator, processing every APDU as
+// soon as all of its reports are written.
+type emulatorTransport struct {
+	emulator *Emulator
+	request  []byte   // Reports of the APDU being received
+	replies  [][]byte // Reports of the replies not read yet
+
+	txPath accounts.DerivationPath // Path of the account signing a transaction
+	txData []byte                  // Transaction received so far, nil if none
+}
+
+// Write implements Transport, receiving a report of an APDU.
+func (t *emulatorTransport) Write(report []byte) (int, error) {
+	if !t.emulator.attached() {
+		return 0, errDeviceGone
+	}
+	if len(report) != reportSize {
+		return 0, errLedgerFraming
+	}
+	t.request = append(t.request, report...)
+
+	apdu, err := ledgerReadMessage(bytes.NewReader(t.request))
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return len(report), nil // More reports needed
+	case err != nil:
+		t.request = nil
+		return 0, err
+	}
+	t.request = nil
+	t.replies = append(t.replies, ledgerFrames(t.process(apdu))...)
+	return len(report), nil
+}
+
+// Read implements Transport, returning the next report of a reply. Unlike a
+// device it does not block, failing if there is no reply pending.
+func (t *emulatorTransport) Read(report []byte) (int, error) {
+	if !t.emulator.attached() {
+		return 0, errDeviceGone
+	}
+	if len(t.replies) == 0 {
+		return 0, errors.New("usbwallet: no reply pending")
+	}
+	n := copy(report, t.replies[0])
+	t.replies = t.replies[1:]
+	return n, nil
+}
+
+// Close implements Transport.
+func (t *emulatorTransport) Close() error {
+	return nil
+}
+
+// process executes an APDU, returning the reply data followed by the status word.
+func (t *emulatorTransport) process(apdu []byte) []byte {
+	if len(apdu) < 5 || len(apdu) != 5+int(apdu[4]) {
+		return ledgerStatus(nil, ledgerSWInvalidData)
+	}
+	if apdu[0] != ledgerCLA {
+		return ledgerStatus(nil, ledgerSWClaUnsupported)
+	}
+	ins, p1, data := apdu[1], apdu[2], apdu[5:]
+
+	switch ins {
+	case ledgerInsGetConfig:
+		return ledgerStatus(append([]byte{0x00}, emulatorVersion[:]...), ledgerSWSuccess)
+
+	case ledgerInsGetAddress:
+		path, _, ok := parseLedgerPath(data)
+		if !ok {
+			return ledgerStatus(nil, ledgerSWInvalidData)
+		}
+		key, err := accounts.DeriveMnemonicKey(t.emulator.mnemonic, path)
+		if err != nil {
+			return ledgerStatus(nil, ledgerSWInvalidData)
+		}
+		pub := crypto.FromECDSAPub(&key.PublicKey)
+		address := hex.EncodeToString(crypto.PubkeyToAddress(key.PublicKey).Bytes())
+
+		reply := append([]byte{byte(len(pub))}, pub...)
+		reply = append(reply, byte(len(address)))
+		return ledgerStatus(append(reply, address...), ledgerSWSuccess)
+
+	case ledgerInsSignTx:
+		switch {
+		case p1 == ledgerP1FirstTxData:
+			path, rest, ok := parseLedgerPath(data)
+			if !ok {
+				return ledgerStatus(nil, ledgerSWInvalidData)
+			}
+			t.txPath, t.txData = path, append([]byte{}, rest...)
+		case p1 == ledgerP1MoreTxData && t.txData != nil:
+			t.txData = append(t.txData, data...)
+		default:
+			return ledgerStatus(nil, ledgerSWInvalidData)
+		}
+		// Keep collecting chunks until the transaction is complete
+		if _, _, rest, err := rlp.Split(t.txData); err != nil || len(rest) > 0 {
+			if err == nil {
+				t.txData = nil
+				return ledgerStatus(nil, ledgerSWInvalidData)
+			}
+			return ledgerStatus(nil, ledgerSWSuccess)
+		}
+		payload := t.txData
+		t.txData = nil
+		return t.sign(payload)
+
+	default:
+		return ledgerStatus(nil, ledgerSWInsUnsupported)
+	}
+}
+
+// sign signs a complete transaction payload, reporting V as it would appear
+// in the signed transaction: 27 or 28, or derived from the chain id for EIP155.
+func (t *emulatorTransport) sign(payload []byte) []byte {
+	var fields []rlp.RawValue
+	if err := rlp.DecodeBytes(payload, &fields); err != nil || (len(fields) != 6 && len(fields) != 9) {
+		return ledgerStatus(nil, ledgerSWInvalidData)
+	}
+	key, err := accounts.DeriveMnemonicKey(t.emulator.mnemonic, t.txPath)
+	if err != nil {
+		return ledgerStatus(nil, ledgerSWInvalidData)
+	}
+	signature, err := crypto.Sign(crypto.Keccak256(payload), key)
+	if err != nil {
+		return ledgerStatus(nil, ledgerSWInvalidData)
+	}
+	v := new(big.Int).SetInt64(int64(signature[64]) + 27)
+	if len(fields) == 9 {
+		chainId := new(big.Int)
+		if err := rlp.DecodeBytes(fields[6], chainId); err != nil {
+			return ledgerStatus(nil, ledgerSWInvalidData)
+		}
+		v.Mul(chainId, big.NewInt(2))
+		v.Add(v, big.NewInt(int64(signature[64])+35))
+	}
+	reply := append([]byte{byte(v.Uint64())}, signature[:64]...)
+	return ledgerStatus(reply, ledgerSWSuccess)
+}
+
+// parseLedgerPath splits a serialized derivation path off the given data.
+func parseLedgerPath(data []byte) (accounts.DerivationPath, []byte, bool) {
+	if len(data) < 1 || len(data) < 1+4*int(data[0]) {
+		return nil, nil, false
+	}
+	path := make(accounts.DerivationPath, data[0])
+	for i := range path {
+		path[i] = binary.BigEndian.Uint32(data[1+4*i:])
+	}
+	return path, data[1+4*len(path):], true
+}
+
+// ledgerStatus appends a status word to the reply data.
+func ledgerStatus(data []byte, sw uint16) []byte {
+	return append(data, byte(sw>>8), byte(sw))
+}
@@ -0,0 +1,94 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package usbwallet
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hidrawEnumerator finds HID devices through the Linux hidraw driver, which
+// exposes every device as /dev/hidrawN along with its identifiers in sysfs.
+type hidrawEnumerator struct{}
+
+// NewHIDEnumerator creates an enumerator for the HID devices of the system.
+func NewHIDEnumerator() Enumerator {
+	return hidrawEnumerator{}
+}
+
+// Enumerate lists the hidraw devices, reading their vendor and product ids
+// from the HID_ID entry of the device's uevent file.
+func (hidrawEnumerator) Enumerate() ([]DeviceInfo, error) {
+	nodes, err := filepath.Glob("/sys/class/hidraw/hidraw*")
+	if err != nil {
+		return nil, err
+	}
+	var infos []DeviceInfo
+	for _, node := range nodes {
+		uevent, err := ioutil.ReadFile(filepath.Join(node, "device", "uevent"))
+		if err != nil {
+			continue // Device vanished in the meantime
+		}
+		for _, line := range strings.Split(string(uevent), "\n") {
+			if !strings.HasPrefix(line, "HID_ID=") {
+				continue
+			}
+			// HID_ID=<bus>:<vendor>:<product>, all in hexadecimal
+			var bus, vendor, product uint32
+			if _, err := fmt.Sscanf(strings.TrimPrefix(line, "HID_ID="), "%x:%x:%x", &bus, &vendor, &product); err != nil {
+				break
+			}
+			infos = append(infos, DeviceInfo{
+				Path:      filepath.Join("/dev", filepath.Base(node)),
+				VendorID:  uint16(vendor),
+				ProductID: uint16(product),
+			})
+			break
+		}
+	}
+	return infos, nil
+}
+
+// Open opens the hidraw device node for reading and writing reports.
+func (hidrawEnumerator) Open(info DeviceInfo) (Transport, error) {
+	file, err := os.OpenFile(info.Path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &hidrawTransport{file}, nil
+}
+
+// hidrawTransport exchanges reports through a hidraw device node.
+type hidrawTransport struct {
+	file *os.File
+}
+
+// Write sends a report, prefixed by the zero report number hidraw expects for
+// devices without numbered reports.
+func (t *hidrawTransport) Write(report []byte) (int, error) {
+	n, err := t.file.Write(append([]byte{0x00}, report...))
+	if n > 0 {
+		n--
+	}
+	return n, err
+}
+
+func (t *hidrawTransport) Read(report []byte) (int, error) { return t.file.Read(report) }
+func (t *hidrawTransport) Close() error                    { return t.file.Close() }
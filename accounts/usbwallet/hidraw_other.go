@@ -0,0 +1,37 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build !linux
+
+package usbwallet
+
+import "github.com/ethereumproject/go-ethereum/accounts"
+
+// unsupportedEnumerator is used on platforms without a HID driver, reporting
+// no devices at all.
+type unsupportedEnumerator struct{}
+
+// NewHIDEnumerator creates an enumerator for the HID devices of the system,
+// which is not supported on this platform.
+func NewHIDEnumerator() Enumerator {
+	return unsupportedEnumerator{}
+}
+
+func (unsupportedEnumerator) Enumerate() ([]DeviceInfo, error) { return nil, nil }
+
+func (unsupportedEnumerator) Open(DeviceInfo) (Transport, error) {
+	return nil, accounts.ErrNotSupported
+}
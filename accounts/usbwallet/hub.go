@@ -0,0 +1,161 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package usbwallet
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereumproject/go-ethereum/accounts"
+	"github.com/ethereumproject/go-ethereum/event"
+	"github.com/ethereumproject/go-ethereum/logger"
+	"github.com/ethereumproject/go-ethereum/logger/glog"
+)
+
+// refreshCycle is the interval between checks for attached or removed devices.
+const refreshCycle = time.Second
+
+// This nil assignment ensures compile time that Hub implements accounts.Backend.
+var _ accounts.Backend = (*Hub)(nil)
+
+// Hub is an accounts.Backend tracking the Ledger wallets reachable through an
+// enumerator. A wallet is created for every arriving device and dropped once
+// the device is removed, posting a WalletEvent for both.
+type Hub struct {
+	enumerator Enumerator
+	wallets    []*wallet
+	mux        event.TypeMux
+
+	lock sync.Mutex
+	quit chan struct{}
+}
+
+// NewLedgerHub creates a hub for the Ledger wallets attached to the system.
+func NewLedgerHub() *Hub {
+	return NewHub(NewHIDEnumerator())
+}
+
+// NewHub creates a hub for the Ledger wallets reachable through the given
+// enumerator, e.g. an Emulator, checking for changes in the background.
+func NewHub(enumerator Enumerator) *Hub {
+	hub := &Hub{
+		enumerator: enumerator,
+		quit:       make(chan struct{}),
+	}
+	hub.refresh()
+	go hub.loop()
+	return hub
+}
+
+// Wallets implements accounts.Backend, returning the wallets of the attached devices.
+func (hub *Hub) Wallets() []accounts.Wallet {
+	hub.refresh()
+
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+
+	wallets := make([]accounts.Wallet, len(hub.wallets))
+	for i, wallet := range hub.wallets {
+		wallets[i] = wallet
+	}
+	return wallets
+}
+
+// Subscribe implements accounts.Backend, delivering a WalletEvent whenever a
+// wallet arrives, gets opened or leaves.
+func (hub *Hub) Subscribe() event.Subscription {
+	return hub.mux.Subscribe(accounts.WalletEvent{})
+}
+
+// Close stops tracking devices, closing all wallets and subscriptions.
+func (hub *Hub) Close() {
+	close(hub.quit)
+
+	hub.lock.Lock()
+	for _, wallet := range hub.wallets {
+		wallet.Close()
+	}
+	hub.wallets = nil
+	hub.lock.Unlock()
+
+	hub.mux.Stop()
+}
+
+// loop periodically refreshes the tracked devices until the hub is closed.
+func (hub *Hub) loop() {
+	ticker := time.NewTicker(refreshCycle)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			hub.refresh()
+		case <-hub.quit:
+			return
+		}
+	}
+}
+
+// refresh enumerates the attached devices, creating wallets for new ones and
+// dropping those of removed ones.
+func (hub *Hub) refresh() {
+	infos, err := hub.enumerator.Enumerate()
+	if err != nil {
+		glog.V(logger.Debug).Infof("failed to enumerate USB devices: %v", err)
+		return
+	}
+	hub.lock.Lock()
+	select {
+	case <-hub.quit:
+		hub.lock.Unlock()
+		return
+	default:
+	}
+	existing := make(map[string]*wallet, len(hub.wallets))
+	for _, wallet := range hub.wallets {
+		existing[wallet.info.Path] = wallet
+	}
+	var (
+		wallets []*wallet
+		events  []accounts.WalletEvent
+	)
+	for _, info := range infos {
+		if info.VendorID != ledgerVendorID {
+			continue
+		}
+		if wallet, ok := existing[info.Path]; ok {
+			wallets = append(wallets, wallet)
+			delete(existing, info.Path)
+			continue
+		}
+		wallet := &wallet{hub: hub, info: info, url: "ledger://" + info.Path}
+		wallets = append(wallets, wallet)
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletArrived})
+	}
+	for _, wallet := range hub.wallets {
+		if _, dropped := existing[wallet.info.Path]; dropped {
+			wallet.Close()
+			events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletDropped})
+		}
+	}
+	hub.wallets = wallets
+	hub.lock.Unlock()
+
+	for _, ev := range events {
+		hub.mux.Post(ev)
+	}
+}
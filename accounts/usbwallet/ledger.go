@@ -0,0 +1,209 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package usbwallet
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/ethereumproject/go-ethereum/accounts"
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/rlp"
+)
+
+// Ledger USB identifiers and the APDU instructions of its Ethereum application.
+const (
+	ledgerVendorID = 0x2c97 // USB vendor identifier of Ledger devices
+	ledgerChannel  = 0x0101 // HID channel of the APDU transport
+	ledgerTag      = 0x05   // HID command tag of APDU frames
+
+	ledgerCLA           = 0xe0 // Instruction class of the Ethereum application
+	ledgerInsGetAddress = 0x02 // Returns the public key and address of a path
+	ledgerInsSignTx     = 0x04 // Signs a transaction after user confirmation
+	ledgerInsGetConfig  = 0x06 // Returns the application version
+
+	ledgerP1FirstTxData = 0x00 // First chunk of the transaction to sign
+	ledgerP1MoreTxData  = 0x80 // Subsequent chunk of the transaction to sign
+
+	ledgerMaxChunk = 255 // Maximum size of the data of a single APDU
+)
+
+// Status words returned by the Ledger Ethereum application.
+const (
+	ledgerSWSuccess        = 0x9000
+	ledgerSWDenied         = 0x6985
+	ledgerSWInvalidData    = 0x6a80
+	ledgerSWInsUnsupported = 0x6d00
+	ledgerSWClaUnsupported = 0x6e00
+)
+
+var errLedgerFraming = errors.New("usbwallet: malformed ledger frame")
+
+// ledgerFrames splits an APDU into HID reports. Every report carries the channel,
+// the tag and a sequence number, the first one also the length of the APDU.
+func ledgerFrames(apdu []byte) [][]byte {
+	payload := make([]byte, 2, 2+len(apdu))
+	binary.BigEndian.PutUint16(payload, uint16(len(apdu)))
+	payload = append(payload, apdu...)
+
+	var frames [][]byte
+	for seq := 0; len(payload) > 0; seq++ {
+		frame := make([]byte, reportSize)
+		binary.BigEndian.PutUint16(frame, ledgerChannel)
+		frame[2] = ledgerTag
+		binary.BigEndian.PutUint16(frame[3:], uint16(seq))
+
+		n := copy(frame[5:], payload)
+		payload = payload[n:]
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// ledgerReadMessage reads HID reports until a complete APDU is reassembled.
+func ledgerReadMessage(r io.Reader) ([]byte, error) {
+	var (
+		message []byte
+		total   = -1
+		frame   = make([]byte, reportSize)
+	)
+	for seq := 0; total < 0 || len(message) < total; seq++ {
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return nil, err
+		}
+		if binary.BigEndian.Uint16(frame) != ledgerChannel || frame[2] != ledgerTag || int(binary.BigEndian.Uint16(frame[3:])) != seq {
+			return nil, errLedgerFraming
+		}
+		chunk := frame[5:]
+		if seq == 0 {
+			total, chunk = int(binary.BigEndian.Uint16(chunk)), chunk[2:]
+		}
+		message = append(message, chunk...)
+	}
+	return message[:total], nil
+}
+
+// ledgerExchange sends an APDU to the device and returns the data of its reply,
+// failing unless the reply carries the success status word.
+func ledgerExchange(t Transport, ins, p1, p2 byte, data []byte) ([]byte, error) {
+	apdu := append([]byte{ledgerCLA, ins, p1, p2, byte(len(data))}, data...)
+	for _, frame := range ledgerFrames(apdu) {
+		if _, err := t.Write(frame); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := ledgerReadMessage(t)
+	if err != nil {
+		return nil, err
+	}
+	if len(reply) < 2 {
+		return nil, errLedgerFraming
+	}
+	switch sw := binary.BigEndian.Uint16(reply[len(reply)-2:]); sw {
+	case ledgerSWSuccess:
+		return reply[:len(reply)-2], nil
+	case ledgerSWDenied:
+		return nil, errors.New("usbwallet: request denied on the device")
+	default:
+		return nil, fmt.Errorf("usbwallet: ledger status %#04x", sw)
+	}
+}
+
+// ledgerPath serializes a derivation path as its length followed by the
+// big endian components.
+func ledgerPath(path accounts.DerivationPath) []byte {
+	data := make([]byte, 1+4*len(path))
+	data[0] = byte(len(path))
+	for i, component := range path {
+		binary.BigEndian.PutUint32(data[1+4*i:], component)
+	}
+	return data
+}
+
+// ledgerVersion retrieves the version of the Ethereum application, failing if
+// the application is not running on the device.
+func ledgerVersion(t Transport) ([3]byte, error) {
+	var version [3]byte
+
+	reply, err := ledgerExchange(t, ledgerInsGetConfig, 0, 0, nil)
+	if err != nil {
+		return version, err
+	}
+	if len(reply) != 4 {
+		return version, errLedgerFraming
+	}
+	copy(version[:], reply[1:])
+	return version, nil
+}
+
+// ledgerDerive retrieves the address of the account at the given path. The
+// reply carries the public key and the hex address, each prefixed by its length.
+func ledgerDerive(t Transport, path accounts.DerivationPath) (common.Address, error) {
+	reply, err := ledgerExchange(t, ledgerInsGetAddress, 0, 0, ledgerPath(path))
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(reply) < 1 || len(reply) < 1+int(reply[0])+1 {
+		return common.Address{}, errLedgerFraming
+	}
+	reply = reply[1+int(reply[0]):]
+	if len(reply) < 1+int(reply[0]) || reply[0] != 2*common.AddressLength {
+		return common.Address{}, errLedgerFraming
+	}
+	address, err := hex.DecodeString(string(reply[1 : 1+reply[0]]))
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(address), nil
+}
+
+// ledgerSign sends the transaction to be signed by the account at the given
+// path, in chunks the device confirms one by one. The signed payload is the one
+// hashed by the signer, so EIP155 transactions carry the chain id. It returns
+// the signature as reported by the device: V, R and S.
+func ledgerSign(t Transport, path accounts.DerivationPath, tx *types.Transaction, chainId *big.Int) ([]byte, error) {
+	fields := []interface{}{tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data()}
+	if chainId != nil {
+		fields = append(fields, chainId, uint(0), uint(0))
+	}
+	payload, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return nil, err
+	}
+	payload = append(ledgerPath(path), payload...)
+
+	var reply []byte
+	for p1 := byte(ledgerP1FirstTxData); len(payload) > 0; p1 = ledgerP1MoreTxData {
+		chunk := payload
+		if len(chunk) > ledgerMaxChunk {
+			chunk = chunk[:ledgerMaxChunk]
+		}
+		if reply, err = ledgerExchange(t, ledgerInsSignTx, p1, 0, chunk); err != nil {
+			return nil, err
+		}
+		payload = payload[len(chunk):]
+	}
+	if len(reply) != 65 {
+		return nil, errLedgerFraming
+	}
+	return reply, nil
+}
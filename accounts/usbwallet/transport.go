@@ -0,0 +1,54 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package usbwallet implements accounts.Backend for USB hardware wallets such as
+// the Ledger Nano, speaking the device APDU protocol over raw HID reports.
+package usbwallet
+
+import (
+	"errors"
+	"io"
+)
+
+// reportSize is the size of the HID reports exchanged with hardware wallets.
+const reportSize = 64
+
+var errDeviceGone = errors.New("usbwallet: device disconnected")
+
+// DeviceInfo describes a HID device found during enumeration.
+type DeviceInfo struct {
+	Path      string // Platform specific path to open the device by
+	VendorID  uint16 // USB vendor identifier
+	ProductID uint16 // USB product identifier
+}
+
+// Transport is a raw channel to a HID device. Every Write sends and every Read
+// receives a single report of reportSize bytes, reads blocking until one is
+// available.
+type Transport interface {
+	io.ReadWriteCloser
+}
+
+// Enumerator lists the HID devices attached to the system and opens transports
+// to them. Real devices are reached through the platform HID driver, while the
+// Emulator provides a software device for testing.
+type Enumerator interface {
+	// Enumerate lists the currently attached HID devices.
+	Enumerate() ([]DeviceInfo, error)
+
+	// Open establishes a transport to a previously enumerated device.
+	Open(info DeviceInfo) (Transport, error)
+}
@@ -0,0 +1,223 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package usbwallet
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereumproject/go-ethereum/accounts"
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/crypto"
+	"github.com/ethereumproject/go-ethereum/event"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// waitWalletEvent waits for the next wallet event on the subscription.
+func waitWalletEvent(t *testing.T, sub event.Subscription) accounts.WalletEvent {
+	select {
+	case ev := <-sub.Chan():
+		return ev.Data.(accounts.WalletEvent)
+	case <-time.After(time.Second):
+		t.Fatalf("wallet event timeout")
+	}
+	return accounts.WalletEvent{}
+}
+
+// Tests that APDUs survive being split into HID reports and reassembled.
+func TestLedgerFraming(t *testing.T) {
+	for _, size := range []int{0, 1, 57, 58, 59, 200, 260} {
+		apdu := bytes.Repeat([]byte{0xaa}, size)
+		var stream []byte
+		for _, frame := range ledgerFrames(apdu) {
+			if len(frame) != reportSize {
+				t.Fatalf("size %d: frame length mismatch: have %d, want %d", size, len(frame), reportSize)
+			}
+			stream = append(stream, frame...)
+		}
+		message, err := ledgerReadMessage(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatalf("size %d: failed to reassemble: %v", size, err)
+		}
+		if !bytes.Equal(message, apdu) {
+			t.Errorf("size %d: message mismatch: have %x, want %x", size, message, apdu)
+		}
+	}
+}
+
+// Tests that wallets arrive and leave along with the device, and that they need
+// to be opened before deriving accounts.
+func TestHubWalletEvents(t *testing.T) {
+	emulator, err := NewEmulator(testMnemonic)
+	if err != nil {
+		t.Fatalf("failed to create emulator: %v", err)
+	}
+	hub := NewHub(emulator)
+	defer hub.Close()
+
+	sub := hub.Subscribe()
+	defer sub.Unsubscribe()
+
+	if wallets := hub.Wallets(); len(wallets) != 0 {
+		t.Fatalf("wallets found without device: %v", wallets)
+	}
+	emulator.Plug()
+	go hub.refresh()
+	ev := waitWalletEvent(t, sub)
+	if ev.Kind != accounts.WalletArrived || ev.Wallet.URL() != "ledger://emulator" {
+		t.Fatalf("arrival event mismatch: have %v %s", ev.Kind, ev.Wallet.URL())
+	}
+	wallet := ev.Wallet
+	if _, err := wallet.Derive(accounts.DefaultBaseDerivationPath); err != accounts.ErrWalletClosed {
+		t.Errorf("derivation on closed wallet error mismatch: have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+	go wallet.Open("")
+	if ev := waitWalletEvent(t, sub); ev.Kind != accounts.WalletOpened || ev.Wallet != wallet {
+		t.Fatalf("open event mismatch: have %v", ev.Kind)
+	}
+	if status := wallet.Status(); status != "Ethereum app v1.0.0 online" {
+		t.Errorf("status mismatch: have %q", status)
+	}
+	emulator.Unplug()
+	go hub.refresh()
+	if ev := waitWalletEvent(t, sub); ev.Kind != accounts.WalletDropped || ev.Wallet != wallet {
+		t.Fatalf("drop event mismatch: have %v", ev.Kind)
+	}
+	if status := wallet.Status(); status != "Closed" {
+		t.Errorf("dropped wallet status mismatch: have %q", status)
+	}
+}
+
+// Tests that accounts derived through the device match the software derivation
+// and that transactions signed by the device recover to them, with and without
+// replay protection and regardless of the payload spanning several chunks.
+func TestWalletSignTx(t *testing.T) {
+	emulator, err := NewEmulator(testMnemonic)
+	if err != nil {
+		t.Fatalf("failed to create emulator: %v", err)
+	}
+	emulator.Plug()
+
+	hub := NewHub(emulator)
+	defer hub.Close()
+
+	wallet := hub.Wallets()[0]
+	if err := wallet.Open(""); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	path, _ := accounts.ParseDerivationPath("m/44'/61'/0'/0/3")
+	account, err := wallet.Derive(path)
+	if err != nil {
+		t.Fatalf("failed to derive account: %v", err)
+	}
+	key, _ := accounts.DeriveMnemonicKey(testMnemonic, path)
+	if want := (accounts.Account{Address: crypto.PubkeyToAddress(key.PublicKey), File: "ledger://emulator", HDPath: "m/44'/61'/0'/0/3"}); account != want {
+		t.Errorf("account mismatch: have %+v, want %+v", account, want)
+	}
+	if !wallet.Contains(accounts.Account{Address: account.Address}) {
+		t.Errorf("derived account not contained in wallet")
+	}
+	tests := []struct {
+		signer types.Signer
+		data   []byte
+	}{
+		{types.BasicSigner{}, nil},
+		{types.NewChainIdSigner(big.NewInt(61)), nil},
+		{types.NewChainIdSigner(big.NewInt(62)), bytes.Repeat([]byte{0x01}, 600)},
+	}
+	for i, tt := range tests {
+		tx := types.NewTransaction(1, common.Address{0x01}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), tt.data)
+		signed, err := wallet.SignTx(account, tx, tt.signer)
+		if err != nil {
+			t.Fatalf("test %d: failed to sign transaction: %v", i, err)
+		}
+		from, err := types.Sender(tt.signer, signed)
+		if err != nil {
+			t.Fatalf("test %d: failed to recover sender: %v", i, err)
+		}
+		if from != account.Address {
+			t.Errorf("test %d: sender mismatch: have %x, want %x", i, from, account.Address)
+		}
+	}
+	if _, err := wallet.SignTx(accounts.Account{Address: common.Address{0x02}}, types.NewTransaction(0, common.Address{}, new(big.Int), big.NewInt(21000), new(big.Int), nil), types.BasicSigner{}); err != accounts.ErrUnknownWallet {
+		t.Errorf("unknown account error mismatch: have %v, want %v", err, accounts.ErrUnknownWallet)
+	}
+}
+
+// Tests that the accounts of a hub's wallets are listed by the manager, which
+// forwards their events and routes transactions to them for signing.
+func TestManagerBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "usbwallet-manager-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	am, err := accounts.NewManager(dir, accounts.LightScryptN, accounts.LightScryptP, false)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	emulator, err := NewEmulator(testMnemonic)
+	if err != nil {
+		t.Fatalf("failed to create emulator: %v", err)
+	}
+	hub := NewHub(emulator)
+	defer hub.Close()
+
+	am.AddBackend(hub)
+	sub := am.Subscribe()
+	defer sub.Unsubscribe()
+
+	emulator.Plug()
+	go hub.refresh()
+	url := waitWalletEvent(t, sub).Wallet.URL()
+
+	wallet, err := am.Wallet(url)
+	if err != nil {
+		t.Fatalf("failed to find wallet %q: %v", url, err)
+	}
+	go wallet.Open("")
+	waitWalletEvent(t, sub)
+
+	account, err := wallet.Derive(accounts.DefaultBaseDerivationPath)
+	if err != nil {
+		t.Fatalf("failed to derive account: %v", err)
+	}
+	if _, err := am.Wallet("ledger://unknown"); err != accounts.ErrUnknownWallet {
+		t.Errorf("unknown wallet error mismatch: have %v, want %v", err, accounts.ErrUnknownWallet)
+	}
+	if accs := am.Accounts(); len(accs) != 1 || accs[0] != account {
+		t.Fatalf("manager accounts mismatch: have %v, want %v", accs, []accounts.Account{account})
+	}
+	if accs := am.KeyFileAccounts(); len(accs) != 0 {
+		t.Errorf("key file accounts include wallet accounts: %v", accs)
+	}
+	signer := types.NewChainIdSigner(big.NewInt(61))
+	signed, err := am.SignTx(accounts.Account{Address: account.Address}, types.NewTransaction(0, common.Address{}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil), signer)
+	if err != nil {
+		t.Fatalf("failed to sign through manager: %v", err)
+	}
+	if from, err := types.Sender(signer, signed); err != nil || from != account.Address {
+		t.Errorf("sender mismatch: have %x/%v, want %x", from, err, account.Address)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package usbwallet
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereumproject/go-ethereum/accounts"
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/crypto"
+)
+
+// This nil assignment ensures compile time that wallet implements accounts.Wallet.
+var _ accounts.Wallet = (*wallet)(nil)
+
+// wallet is an accounts.Wallet backed by a Ledger device running the Ethereum
+// application. Accounts are tracked once explicitly derived.
+type wallet struct {
+	hub  *Hub
+	info DeviceInfo
+	url  string
+
+	transport Transport // Connection to the device, nil while closed
+	version   [3]byte   // Version of the Ethereum application on the device
+	accounts  []accounts.Account
+	paths     map[common.Address]accounts.DerivationPath
+
+	lock sync.Mutex // Serializes the communication with the device
+}
+
+// URL implements accounts.Wallet, identifying the wallet by its device path.
+func (w *wallet) URL() string {
+	return w.url
+}
+
+// Status implements accounts.Wallet, reporting whether the wallet is open and
+// the version of the Ethereum application if so.
+func (w *wallet) Status() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.transport == nil {
+		return "Closed"
+	}
+	return fmt.Sprintf("Ethereum app v%d.%d.%d online", w.version[0], w.version[1], w.version[2])
+}
+
+// Open implements accounts.Wallet, connecting to the device and checking that
+// the Ethereum application is running. The passphrase is ignored, the device
+// is unlocked by the user directly.
+func (w *wallet) Open(passphrase string) error {
+	w.lock.Lock()
+	if w.transport != nil {
+		w.lock.Unlock()
+		return errors.New("usbwallet: wallet already open")
+	}
+	transport, err := w.hub.enumerator.Open(w.info)
+	if err != nil {
+		w.lock.Unlock()
+		return err
+	}
+	version, err := ledgerVersion(transport)
+	if err != nil {
+		transport.Close()
+		w.lock.Unlock()
+		return fmt.Errorf("usbwallet: Ethereum application not running: %v", err)
+	}
+	w.transport, w.version = transport, version
+	w.paths = make(map[common.Address]accounts.DerivationPath)
+	w.lock.Unlock()
+
+	w.hub.mux.Post(accounts.WalletEvent{Wallet: w, Kind: accounts.WalletOpened})
+	return nil
+}
+
+// Close implements accounts.Wallet, releasing the connection to the device and
+// forgetting the derived accounts.
+func (w *wallet) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.transport == nil {
+		return nil
+	}
+	err := w.transport.Close()
+	w.transport, w.accounts, w.paths = nil, nil, nil
+	return err
+}
+
+// Accounts implements accounts.Wallet, returning the accounts derived so far.
+func (w *wallet) Accounts() []accounts.Account {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	cpy := make([]accounts.Account, len(w.accounts))
+	copy(cpy, w.accounts)
+	return cpy
+}
+
+// Contains implements accounts.Wallet, reporting whether the account was derived
+// from this wallet.
+func (w *wallet) Contains(account accounts.Account) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	_, ok := w.paths[account.Address]
+	return ok && (account.File == "" || account.File == w.url)
+}
+
+// Derive implements accounts.Wallet, requesting the address of the account at
+// the given path from the device and tracking it.
+func (w *wallet) Derive(path accounts.DerivationPath) (accounts.Account, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.transport == nil {
+		return accounts.Account{}, accounts.ErrWalletClosed
+	}
+	address, err := ledgerDerive(w.transport, path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	account := accounts.Account{Address: address, File: w.url, HDPath: path.String()}
+	if _, ok := w.paths[address]; !ok {
+		w.accounts = append(w.accounts, account)
+		w.paths[address] = append(accounts.DerivationPath{}, path...)
+	}
+	return account, nil
+}
+
+// SignTx implements accounts.Wallet, sending the transaction to the device for
+// the user to confirm and sign.
+func (w *wallet) SignTx(account accounts.Account, tx *types.Transaction, signer types.Signer) (*types.Transaction, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.transport == nil {
+		return nil, accounts.ErrWalletClosed
+	}
+	path, ok := w.paths[account.Address]
+	if !ok {
+		return nil, accounts.ErrUnknownWallet
+	}
+	var chainId *big.Int
+	if s, ok := signer.(types.ChainIdSigner); ok {
+		chainId = s.ChainId()
+	}
+	reply, err := ledgerSign(w.transport, path, tx, chainId)
+	if err != nil {
+		return nil, err
+	}
+	// The device reports V in its transaction encoding, recover the plain
+	// recovery id by checking which one yields the account
+	hash := signer.Hash(tx)
+	signature := append(append([]byte{}, reply[1:]...), 0)
+	for recovery := byte(0); recovery < 2; recovery++ {
+		signature[64] = recovery
+		pub, err := crypto.SigToPub(hash[:], signature)
+		if err == nil && crypto.PubkeyToAddress(*pub) == account.Address {
+			return tx.WithSigner(signer).WithSignature(signature)
+		}
+	}
+	return nil, errors.New("usbwallet: device signature does not match the account")
+}
+
+// SignTxWithPassphrase implements accounts.Wallet. Hardware wallets are unlocked
+// on the device itself, so this is the same as SignTx.
+func (w *wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, signer types.Signer) (*types.Transaction, error) {
+	return w.SignTx(account, tx, signer)
+}
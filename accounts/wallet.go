@@ -0,0 +1,160 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"errors"
+
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/event"
+)
+
+var (
+	// ErrNotSupported is returned for operations a wallet is not able to perform,
+	// e.g. deriving accounts from a plain key file.
+	ErrNotSupported = errors.New("not supported")
+
+	// ErrUnknownWallet is returned if no wallet holds the requested account.
+	ErrUnknownWallet = errors.New("unknown wallet")
+
+	// ErrWalletClosed is returned if an operation needs an open wallet.
+	ErrWalletClosed = errors.New("wallet closed")
+)
+
+// Wallet represents a software or hardware wallet holding one or more accounts.
+type Wallet interface {
+	// URL uniquely identifies the wallet, e.g. keystore:///path/to/keyfile or
+	// ledger:///dev/hidraw0.
+	URL() string
+
+	// Status returns a textual status of the wallet, e.g. whether it is open.
+	Status() string
+
+	// Open initializes access to the wallet, establishing the connection to a
+	// hardware device. The passphrase is only used by wallets needing one.
+	Open(passphrase string) error
+
+	// Close releases any resources held by an open wallet.
+	Close() error
+
+	// Accounts returns the accounts known to the wallet. For hierarchical
+	// deterministic wallets these are the ones explicitly derived.
+	Accounts() []Account
+
+	// Contains reports whether the account is held by this wallet.
+	Contains(account Account) bool
+
+	// Derive derives the account at the given path and starts tracking it.
+	Derive(path DerivationPath) (Account, error)
+
+	// SignTx signs the transaction with the given account, requesting any
+	// confirmation needed from the user.
+	SignTx(account Account, tx *types.Transaction, signer types.Signer) (*types.Transaction, error)
+
+	// SignTxWithPassphrase signs the transaction with the given account, using
+	// the passphrase to unlock it if needed.
+	SignTxWithPassphrase(account Account, passphrase string, tx *types.Transaction, signer types.Signer) (*types.Transaction, error)
+}
+
+// Backend is a source of wallets, e.g. the USB hardware wallets plugged in.
+type Backend interface {
+	// Wallets returns the wallets currently available from the backend.
+	Wallets() []Wallet
+
+	// Subscribe creates a subscription delivering a WalletEvent whenever a
+	// wallet of the backend arrives, gets opened or leaves.
+	Subscribe() event.Subscription
+}
+
+// WalletEventType is the kind of change a WalletEvent reports.
+type WalletEventType int
+
+const (
+	// WalletArrived is posted when a new wallet is detected.
+	WalletArrived WalletEventType = iota
+
+	// WalletOpened is posted when a wallet was successfully opened.
+	WalletOpened
+
+	// WalletDropped is posted when a wallet is removed.
+	WalletDropped
+)
+
+// WalletEvent is posted by a Backend when a wallet arrives, is opened or leaves.
+type WalletEvent struct {
+	Wallet Wallet
+	Kind   WalletEventType
+}
+
+// keystoreWallet is a wallet holding a single account of the key directory or
+// the HD wallet of a Manager, signing through the manager's decrypted keys.
+type keystoreWallet struct {
+	account Account
+	am      *Manager
+}
+
+func (w *keystoreWallet) URL() string                  { return "keystore://" + w.account.File }
+func (w *keystoreWallet) Open(passphrase string) error { return nil }
+func (w *keystoreWallet) Close() error                 { return nil }
+func (w *keystoreWallet) Accounts() []Account          { return []Account{w.account} }
+
+// Status reports whether the account of the wallet is unlocked.
+func (w *keystoreWallet) Status() string {
+	w.am.mu.RLock()
+	defer w.am.mu.RUnlock()
+
+	if _, ok := w.am.unlocked[w.account.Address]; ok {
+		return "Unlocked"
+	}
+	return "Locked"
+}
+
+// Contains reports whether the account matches the one of the wallet.
+func (w *keystoreWallet) Contains(account Account) bool {
+	return account.Address == w.account.Address && (account.File == "" || account.File == w.account.File)
+}
+
+// Derive is not supported by single key wallets, HD accounts are derived by the
+// Manager itself.
+func (w *keystoreWallet) Derive(path DerivationPath) (Account, error) {
+	return Account{}, ErrNotSupported
+}
+
+// SignTx signs the transaction with the unlocked key of the account.
+func (w *keystoreWallet) SignTx(account Account, tx *types.Transaction, signer types.Signer) (*types.Transaction, error) {
+	if !w.Contains(account) {
+		return nil, ErrUnknownWallet
+	}
+	signature, err := w.am.Sign(account.Address, signer.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSigner(signer).WithSignature(signature)
+}
+
+// SignTxWithPassphrase signs the transaction with the key of the account,
+// decrypting it with the passphrase.
+func (w *keystoreWallet) SignTxWithPassphrase(account Account, passphrase string, tx *types.Transaction, signer types.Signer) (*types.Transaction, error) {
+	if !w.Contains(account) {
+		return nil, ErrUnknownWallet
+	}
+	signature, err := w.am.SignWithPassphrase(account.Address, passphrase, signer.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSigner(signer).WithSignature(signature)
+}
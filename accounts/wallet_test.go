@@ -0,0 +1,82 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/types"
+)
+
+// Tests that every key file and HD account is exposed as a wallet, and that
+// transactions are signed through them by the manager.
+func TestManagerWallets(t *testing.T) {
+	dir, am := tmpManager(t)
+	defer os.RemoveAll(dir)
+
+	keyed, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	derived, err := am.ImportMnemonic(testMnemonic, "bar")
+	if err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	wallets := am.Wallets()
+	if len(wallets) != 2 {
+		t.Fatalf("wallet count mismatch: have %d, want %d", len(wallets), 2)
+	}
+	for i, account := range []Account{keyed, derived} {
+		if !wallets[i].Contains(Account{Address: account.Address}) {
+			t.Errorf("wallet %d: account %x missing", i, account.Address)
+		}
+		if wallets[i].URL() != "keystore://"+account.File {
+			t.Errorf("wallet %d: URL mismatch: have %s", i, wallets[i].URL())
+		}
+	}
+	signer := types.NewChainIdSigner(big.NewInt(61))
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+
+	// Locked key files can only sign with their passphrase
+	if _, err := am.SignTx(Account{Address: keyed.Address}, tx, signer); err != ErrLocked {
+		t.Errorf("locked signing error mismatch: have %v, want %v", err, ErrLocked)
+	}
+	for _, tt := range []struct {
+		account    Account
+		passphrase string
+	}{{keyed, "foo"}, {derived, "bar"}} {
+		signed, err := am.SignTxWithPassphrase(Account{Address: tt.account.Address}, tt.passphrase, tx, signer)
+		if err != nil {
+			t.Fatalf("failed to sign with %x: %v", tt.account.Address, err)
+		}
+		if from, err := types.Sender(signer, signed); err != nil || from != tt.account.Address {
+			t.Errorf("sender mismatch: have %x/%v, want %x", from, err, tt.account.Address)
+		}
+	}
+	if err := am.Unlock(keyed, "foo"); err != nil {
+		t.Fatalf("failed to unlock: %v", err)
+	}
+	if _, err := am.SignTx(Account{Address: keyed.Address}, tx, signer); err != nil {
+		t.Errorf("failed to sign with unlocked account: %v", err)
+	}
+	if _, err := am.SignTx(Account{Address: common.Address{0x01}}, tx, signer); err != ErrUnknownWallet {
+		t.Errorf("unknown account error mismatch: have %v, want %v", err, ErrUnknownWallet)
+	}
+}
@@ -35,14 +35,15 @@ var Version = "unknown"
 var (
 	abiFlag = flag.String("abi", "", "Path to the Ethereum contract ABI json to bind")
 	binFlag = flag.String("bin", "", "Path to the Ethereum contract bytecode (generate deploy method)")
-	typFlag = flag.String("type", "", "Go struct name for the binding (default = package name)")
+	typFlag = flag.String("type", "", "Struct or class name for the binding (default = package name)")
 
 	solFlag  = flag.String("sol", "", "Path to the Ethereum contract Solidity source to build and bind")
 	solcFlag = flag.String("solc", "solc", "Solidity compiler to use if source builds are requested")
 	excFlag  = flag.String("exc", "", "Comma separated types to exclude from binding")
 
-	pkgFlag     = flag.String("pkg", "", "Go package name to generate the binding into")
+	pkgFlag     = flag.String("pkg", "", "Package name to generate the binding into (Go and Java only)")
 	outFlag     = flag.String("out", "", "Output file for the generated binding (default = stdout)")
+	langFlag    = flag.String("lang", "go", "Destination language for the bindings (go, java, ts)")
 	versionFlag = flag.Bool("version", false, "Prints the revision identifier and exit immediatily.")
 )
 
@@ -62,8 +63,20 @@ func main() {
 		fmt.Printf("Contract ABI (--abi), bytecode (--bin) and type (--type) flags are mutually exclusive with the Solidity source (--sol) flag\n")
 		os.Exit(-1)
 	}
-	if *pkgFlag == "" {
-		fmt.Printf("No destination Go package specified (--pkg)\n")
+	var lang bind.Lang
+	switch *langFlag {
+	case "go":
+		lang = bind.LangGo
+	case "java":
+		lang = bind.LangJava
+	case "ts", "typescript":
+		lang = bind.LangTypeScript
+	default:
+		fmt.Printf("Unsupported destination language \"%s\" (--lang)\n", *langFlag)
+		os.Exit(-1)
+	}
+	if *pkgFlag == "" && lang != bind.LangTypeScript {
+		fmt.Printf("No destination package specified (--pkg)\n")
 		os.Exit(-1)
 	}
 	// If the entire solidity code was specified, build and bind based on that
@@ -126,10 +139,14 @@ func main() {
 		if kind == "" {
 			kind = *pkgFlag
 		}
+		if kind == "" {
+			fmt.Printf("No binding type specified (--type)\n")
+			os.Exit(-1)
+		}
 		types = append(types, kind)
 	}
 	// Generate the contract binding
-	code, err := bind.Bind(types, abis, bins, *pkgFlag)
+	code, err := bind.Bind(types, abis, bins, *pkgFlag, lang)
 	if err != nil {
 		fmt.Printf("Failed to generate ABI binding: %v\n", err)
 		os.Exit(-1)
@@ -185,6 +185,40 @@ with that option.
 It indexes all key files from keystore/* (non-recursively).
 					`,
 			},
+			{
+				Action: accountMigrate,
+				Name:   "migrate",
+				Usage:  "Re-encrypt key files with the chosen KDF",
+				Description: `
+
+    geth --kdf argon2id account migrate [<address> ...]
+
+Re-encrypts the key files of the given accounts, or of all key files if no
+account is given, in place with the key-derivation function chosen with --kdf.
+The passphrases stay the same, you are prompted for each of them.
+
+Every new key file is verified to decrypt to the original key before it replaces
+the old one, which is kept in keystore/backup. The new key files carry a
+metadata section with the label and creation time of the account. They are
+written as version 4 with argon2id and as version 3 with the other KDFs.
+
+For non-interactive use the passphrases can be specified with the --password flag,
+one per line in the order of the accounts.
+					`,
+			},
+			{
+				Action: accountLabel,
+				Name:   "label",
+				Usage:  "Set the label of an account",
+				Description: `
+
+    geth account label <address> <label>
+
+Sets the label in the metadata section of the key file of the account, which
+must have been migrated first. No passphrase is needed, as the
+metadata is not encrypted.
+					`,
+			},
 		},
 	}
 )
@@ -209,7 +243,10 @@ func accountIndex(ctx *cli.Context) error {
 func accountList(ctx *cli.Context) error {
 	accman := MakeAccountManager(ctx)
 	for i, acct := range accman.Accounts() {
-
+		if acct.Meta.Label != "" {
+			fmt.Printf("Account #%d: {%x} %s (%s)\n", i, acct.Address, acct.File, acct.Meta.Label)
+			continue
+		}
 		fmt.Printf("Account #%d: {%x} %s\n", i, acct.Address, acct.File)
 	}
 	return nil
@@ -366,3 +403,50 @@ func accountImport(ctx *cli.Context) error {
 	fmt.Printf("Address: {%x}\n", acct.Address)
 	return nil
 }
+
+// accountMigrate re-encrypts the key files of the given accounts, or all key
+// files, with the configured KDF.
+func accountMigrate(ctx *cli.Context) error {
+	accman := MakeAccountManager(ctx)
+
+	var accs []accounts.Account
+	if len(ctx.Args()) == 0 {
+		accs = accman.KeyFileAccounts()
+	} else {
+		for _, address := range ctx.Args() {
+			acct, err := MakeAddress(accman, address)
+			if err != nil {
+				log.Fatal(err)
+			}
+			accs = append(accs, acct)
+		}
+	}
+	passwords := MakePasswordList(ctx)
+	for i, acct := range accs {
+		prompt := fmt.Sprintf("Migrating account {%x} %s", acct.Address, acct.File)
+		password := getPassPhrase(prompt, false, i, passwords)
+
+		migrated, backup, err := accman.Migrate(acct, password)
+		if err != nil {
+			log.Fatalf("Could not migrate account {%x}: %v", acct.Address, err)
+		}
+		fmt.Printf("Migrated {%x} %s, backup at %s\n", migrated.Address, migrated.File, backup)
+	}
+	return nil
+}
+
+// accountLabel sets the label of an account in its key file.
+func accountLabel(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		log.Fatal("Use: $ geth account label <address> <label>")
+	}
+	accman := MakeAccountManager(ctx)
+	acct, err := MakeAddress(accman, ctx.Args().First())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := accman.SetLabel(acct, ctx.Args().Get(1)); err != nil {
+		log.Fatalf("Could not label account {%x}: %v", acct.Address, err)
+	}
+	return nil
+}
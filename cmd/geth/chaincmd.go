@@ -94,6 +94,17 @@ Use "ethereum dump 0" to dump the genesis block.
 		Syncing will require downloading contemporary block information from the index onwards.
 		`,
 	}
+	buildAddrTxIndexCommand = cli.Command{
+		Action: buildAddrTxIndex,
+		Name:   "atxi-build",
+		Usage:  "Index the transactions of existing blocks by address",
+		Description: `
+		Builds the address transaction index used by eth_getTransactionsByAddress for the
+		blocks already in the database, which the --atxi flag keeps up to date afterwards.
+		Optional first and second arguments set the first and last block to index, by default
+		the index is built from where an earlier run stopped up to the current head.
+		`,
+	}
 	statusCommand = cli.Command{
 		Action: status,
 		Name:   "status",
@@ -150,6 +161,37 @@ func exportChain(ctx *cli.Context) error {
 	return nil
 }
 
+func buildAddrTxIndex(ctx *cli.Context) error {
+	chain, chainDb := MakeChain(ctx)
+	defer chainDb.Close()
+
+	first, last := core.GetAddrTxIndexBookmark(chainDb), chain.CurrentBlock().NumberU64()
+	if len(ctx.Args()) > 0 {
+		n, err := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+		if err != nil {
+			log.Fatal("atxi-build parameter: ", err)
+		}
+		first = n
+	}
+	if len(ctx.Args()) > 1 {
+		n, err := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+		if err != nil {
+			log.Fatal("atxi-build parameter: ", err)
+		}
+		last = n
+	}
+	if first > last {
+		fmt.Printf("Address transaction index already built up to block #%d\n", first-1)
+		return nil
+	}
+	start := time.Now()
+	if err := core.BuildAddrTxIndex(chainDb, first, last); err != nil {
+		log.Fatal("Index error: ", err)
+	}
+	fmt.Printf("Indexed blocks #%d-#%d in %v\n", first, last, time.Since(start))
+	return nil
+}
+
 func removeDB(ctx *cli.Context) error {
 	confirm, err := console.Stdin.PromptConfirm("Remove local database?")
 	if err != nil {
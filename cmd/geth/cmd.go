@@ -414,6 +414,9 @@ func formatStackConfigPretty(stackConfig *node.Config) (s []string) {
 	ss = append(ss, printable{0, "Max peers", stackConfig.MaxPeers})
 	// MaxPendingPeers
 	ss = append(ss, printable{0, "Max pending peers", stackConfig.MaxPendingPeers})
+	// Ingress limits
+	ss = append(ss, printable{0, "Max peer ingress (B/s)", stackConfig.MaxPeerIngress})
+	ss = append(ss, printable{0, "Max ingress (B/s)", stackConfig.MaxIngress})
 	// HTTP
 	ss = append(ss, printable{0, "HTTP", nil})
 	// HTTPHost
@@ -32,6 +32,8 @@ import (
 
 	"github.com/ethereumproject/ethash"
 	"github.com/ethereumproject/go-ethereum/accounts"
+	"github.com/ethereumproject/go-ethereum/accounts/external"
+	"github.com/ethereumproject/go-ethereum/accounts/usbwallet"
 	"github.com/ethereumproject/go-ethereum/common"
 	"github.com/ethereumproject/go-ethereum/core"
 	"github.com/ethereumproject/go-ethereum/core/state"
@@ -40,6 +42,7 @@ import (
 	"github.com/ethereumproject/go-ethereum/eth"
 	"github.com/ethereumproject/go-ethereum/ethdb"
 	"github.com/ethereumproject/go-ethereum/event"
+	"github.com/ethereumproject/go-ethereum/graphql"
 	"github.com/ethereumproject/go-ethereum/logger"
 	"github.com/ethereumproject/go-ethereum/logger/glog"
 	"github.com/ethereumproject/go-ethereum/miner"
@@ -47,6 +50,7 @@ import (
 	"github.com/ethereumproject/go-ethereum/p2p/discover"
 	"github.com/ethereumproject/go-ethereum/p2p/nat"
 	"github.com/ethereumproject/go-ethereum/pow"
+	"github.com/ethereumproject/go-ethereum/rpc"
 	"github.com/ethereumproject/go-ethereum/whisper"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -301,6 +305,44 @@ func MakeNAT(ctx *cli.Context) nat.Interface {
 	return natif
 }
 
+// MakeIngressLimit converts a KB/s rate limit flag into bytes per second.
+func MakeIngressLimit(ctx *cli.Context, flag cli.IntFlag) uint64 {
+	limit := ctx.GlobalInt(flag.Name)
+	if limit < 0 {
+		glog.Fatalf("%v: invalid rate limit: %d", flag.Name, limit)
+	}
+	return uint64(limit) * 1024
+}
+
+// MakeRPCLimits creates the resource limits of the HTTP and websocket RPC
+// interfaces from the set command line flags.
+func MakeRPCLimits(ctx *cli.Context) rpc.Limits {
+	limits := rpc.Limits{
+		MaxBatchLength:   ctx.GlobalInt(aliasableName(RPCBatchLimitFlag.Name, ctx)),
+		MaxResponseSize:  ctx.GlobalInt(aliasableName(RPCResponseLimitFlag.Name, ctx)),
+		MaxConcurrency:   ctx.GlobalInt(aliasableName(RPCConcurrencyFlag.Name, ctx)),
+		ExecutionTimeout: ctx.GlobalDuration(aliasableName(RPCTimeoutFlag.Name, ctx)),
+	}
+	if limits.MaxBatchLength < 0 || limits.MaxResponseSize < 0 || limits.MaxConcurrency < 0 || limits.ExecutionTimeout < 0 {
+		glog.Fatalf("RPC limits must not be negative")
+	}
+	timeouts, err := rpc.ParseMethodTimeouts(ctx.GlobalString(aliasableName(RPCMethodTimeoutsFlag.Name, ctx)))
+	if err != nil {
+		glog.Fatalf("%v: %v", RPCMethodTimeoutsFlag.Name, err)
+	}
+	limits.MethodTimeouts = timeouts
+	return limits
+}
+
+// MakeLogRangeLimit returns the maximum number of blocks a log query may span.
+func MakeLogRangeLimit(ctx *cli.Context) uint64 {
+	limit := ctx.GlobalInt(aliasableName(RPCLogRangeFlag.Name, ctx))
+	if limit < 0 {
+		glog.Fatalf("%v: invalid log range limit: %d", RPCLogRangeFlag.Name, limit)
+	}
+	return uint64(limit)
+}
+
 // MakeRPCModules splits input separated by a comma and trims excessive white
 // space from the substrings.
 func MakeRPCModules(input string) []string {
@@ -348,11 +390,9 @@ func MakeDatabaseHandles() int {
 // MakeAccountManager creates an account manager from set command line flags.
 func MakeAccountManager(ctx *cli.Context) *accounts.Manager {
 	// Create the keystore crypto primitive, light if requested
-	scryptN := accounts.StandardScryptN
-	scryptP := accounts.StandardScryptP
-	if ctx.GlobalBool(aliasableName(LightKDFFlag.Name, ctx)) {
-		scryptN = accounts.LightScryptN
-		scryptP = accounts.LightScryptP
+	kdf, err := accounts.NewKDF(ctx.GlobalString(aliasableName(KDFFlag.Name, ctx)), ctx.GlobalBool(aliasableName(LightKDFFlag.Name, ctx)))
+	if err != nil {
+		glog.Fatalf("%v: %v", ErrInvalidFlag, err)
 	}
 
 	datadir := MustMakeChainDataDir(ctx)
@@ -367,10 +407,20 @@ func MakeAccountManager(ctx *cli.Context) *accounts.Manager {
 		}
 	}
 
-	m, err := accounts.NewManager(keydir, scryptN, scryptP, ctx.GlobalBool(aliasableName(AccountsIndexFlag.Name, ctx)))
+	m, err := accounts.NewManagerKDF(keydir, kdf, ctx.GlobalBool(aliasableName(AccountsIndexFlag.Name, ctx)))
 	if err != nil {
 		glog.Fatalf("init account manager at %q: %s", keydir, err)
 	}
+	if endpoint := ctx.GlobalString(aliasableName(ExternalSignerFlag.Name, ctx)); endpoint != "" {
+		backend, err := external.NewBackend(endpoint)
+		if err != nil {
+			glog.Fatalf("connect to external signer at %q: %s", endpoint, err)
+		}
+		m.AddBackend(backend)
+	}
+	if ctx.GlobalBool(aliasableName(USBFlag.Name, ctx)) {
+		m.AddBackend(usbwallet.NewLedgerHub())
+	}
 	return m
 }
 
@@ -495,6 +545,17 @@ func MakeSystemNode(version string, ctx *cli.Context) *node.Node {
 			glog.Fatalf("%v: failed to register the Whisper service: ", ErrStackFail, err)
 		}
 	}
+	if ctx.GlobalBool(aliasableName(GraphQLEnabledFlag.Name, ctx)) {
+		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+			var ethereum *eth.Ethereum
+			if err := ctx.Service(&ethereum); err != nil {
+				return nil, err
+			}
+			return graphql.New(ethereum), nil
+		}); err != nil {
+			glog.Fatalf("%v: failed to register the GraphQL service: ", ErrStackFail, err)
+		}
+	}
 
 	if ctx.GlobalBool(Unused1.Name) {
 		glog.V(logger.Info).Infoln(fmt.Sprintf("Geth started with --%s flag, which is unused by Geth Classic and can be omitted", Unused1.Name))
@@ -526,15 +587,21 @@ func mustMakeStackConf(ctx *cli.Context, name string, config *core.SufficientCha
 		NAT:             MakeNAT(ctx),
 		MaxPeers:        ctx.GlobalInt(aliasableName(MaxPeersFlag.Name, ctx)),
 		MaxPendingPeers: ctx.GlobalInt(aliasableName(MaxPendingPeersFlag.Name, ctx)),
+		MaxPeerIngress:  MakeIngressLimit(ctx, MaxPeerIngressFlag),
+		MaxIngress:      MakeIngressLimit(ctx, MaxIngressFlag),
 		IPCPath:         MakeIPCPath(ctx),
 		HTTPHost:        MakeHTTPRpcHost(ctx),
 		HTTPPort:        ctx.GlobalInt(aliasableName(RPCPortFlag.Name, ctx)),
 		HTTPCors:        ctx.GlobalString(aliasableName(RPCCORSDomainFlag.Name, ctx)),
 		HTTPModules:     MakeRPCModules(ctx.GlobalString(aliasableName(RPCApiFlag.Name, ctx))),
+		HTTPVhosts:      MakeRPCModules(ctx.GlobalString(aliasableName(RPCVirtualHostsFlag.Name, ctx))),
+		HTTPMaxHeadAge:  ctx.GlobalDuration(aliasableName(RPCReadyHeadAgeFlag.Name, ctx)),
 		WSHost:          MakeWSRpcHost(ctx),
 		WSPort:          ctx.GlobalInt(aliasableName(WSPortFlag.Name, ctx)),
 		WSOrigins:       ctx.GlobalString(aliasableName(WSAllowedOriginsFlag.Name, ctx)),
 		WSModules:       MakeRPCModules(ctx.GlobalString(aliasableName(WSApiFlag.Name, ctx))),
+		RPCAuthFile:     ctx.GlobalString(aliasableName(RPCAuthFileFlag.Name, ctx)),
+		RPCLimits:       MakeRPCLimits(ctx),
 	}
 
 	// Configure the Whisper service
@@ -576,6 +643,8 @@ func mustMakeEthConf(ctx *cli.Context, sconf *core.SufficientChainConfig) *eth.C
 		ChainConfig:             sconf.ChainConfig,
 		Genesis:                 sconf.Genesis,
 		FastSync:                ctx.GlobalBool(aliasableName(FastSyncFlag.Name, ctx)),
+		AddrTxIndex:             ctx.GlobalBool(aliasableName(AddrTxIndexFlag.Name, ctx)),
+		MaxLogBlockRange:        MakeLogRangeLimit(ctx),
 		BlockChainVersion:       ctx.GlobalInt(aliasableName(BlockchainVersionFlag.Name, ctx)),
 		DatabaseCache:           ctx.GlobalInt(aliasableName(CacheFlag.Name, ctx)),
 		DatabaseHandles:         MakeDatabaseHandles(),
@@ -781,6 +850,7 @@ func MakeChain(ctx *cli.Context) (chain *core.BlockChain, chainDb ethdb.Database
 	if err != nil {
 		glog.Fatal("Could not start chainmanager: ", err)
 	}
+	chain.SetAddrTxIndex(ctx.GlobalBool(aliasableName(AddrTxIndexFlag.Name, ctx)))
 	return chain, chainDb
 }
 
@@ -9,6 +9,7 @@ import (
 	"github.com/ethereumproject/go-ethereum/common"
 	"github.com/ethereumproject/go-ethereum/core"
 	"github.com/ethereumproject/go-ethereum/eth"
+	"github.com/ethereumproject/go-ethereum/graphql"
 	"github.com/ethereumproject/go-ethereum/logger/glog"
 	"github.com/ethereumproject/go-ethereum/rpc"
 	"gopkg.in/urfave/cli.v1"
@@ -78,10 +79,19 @@ var (
 		Name:  "fast",
 		Usage: "Enable fast syncing through state downloads",
 	}
+	AddrTxIndexFlag = cli.BoolFlag{
+		Name:  "atxi,add-tx-index",
+		Usage: "Index the transactions sent from and to each address (use 'atxi-build' to index existing blocks)",
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "light-kdf,lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
 	}
+	KDFFlag = cli.StringFlag{
+		Name:  "kdf",
+		Usage: "Key-derivation function protecting new and updated key files (scrypt, pbkdf2, argon2id)",
+		Value: "scrypt",
+	}
 	// Network Split settings
 	ETFChain = cli.BoolFlag{
 		Name:  "etf",
@@ -136,6 +146,15 @@ var (
 		Usage: "Password file to use for non-inteactive password input",
 		Value: "",
 	}
+	ExternalSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "External signer holding the account keys (ipc:/path/to/signer.ipc or http://host:port)",
+		Value: "",
+	}
+	USBFlag = cli.BoolFlag{
+		Name:  "usb",
+		Usage: "Enables monitoring for and managing USB hardware wallets",
+	}
 
 	// logging and debug settings
 	VerbosityFlag = cli.GenericFlag{
@@ -197,6 +216,49 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: rpc.DefaultHTTPApis,
 	}
+	RPCVirtualHostsFlag = cli.StringFlag{
+		Name:  "rpc-vhosts,rpcvhosts",
+		Usage: "Comma separated list of virtual hostnames from which to accept HTTP-RPC requests (server enforced, accepts '*')",
+		Value: "localhost",
+	}
+	RPCReadyHeadAgeFlag = cli.DurationFlag{
+		Name:  "rpc-ready-head-age",
+		Usage: "Maximum head block age for the HTTP-RPC /ready endpoint to report the node ready (0 = unchecked)",
+	}
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable the GraphQL endpoint on the HTTP-RPC server (" + graphql.Path + ")",
+	}
+	RPCAuthFileFlag = cli.StringFlag{
+		Name:  "rpc-auth,rpcauth",
+		Usage: "JSON file of bearer token credentials restricting the HTTP-RPC and WS-RPC methods callable",
+		Value: "",
+	}
+	RPCTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc-timeout",
+		Usage: "Maximum execution time of a HTTP-RPC or WS-RPC method call (0 = unlimited)",
+	}
+	RPCMethodTimeoutsFlag = cli.StringFlag{
+		Name:  "rpc-method-timeouts",
+		Usage: "Comma separated execution time limits overriding --rpc-timeout per method or namespace (e.g. eth_getLogs=30s,debug=2m)",
+		Value: "",
+	}
+	RPCBatchLimitFlag = cli.IntFlag{
+		Name:  "rpc-batch-limit",
+		Usage: "Maximum number of requests in a HTTP-RPC or WS-RPC batch (0 = unlimited)",
+	}
+	RPCResponseLimitFlag = cli.IntFlag{
+		Name:  "rpc-response-limit",
+		Usage: "Maximum size in bytes of a HTTP-RPC or WS-RPC response (0 = unlimited)",
+	}
+	RPCConcurrencyFlag = cli.IntFlag{
+		Name:  "rpc-concurrency",
+		Usage: "Maximum number of requests executed in parallel per WS-RPC connection (0 = unlimited)",
+	}
+	RPCLogRangeFlag = cli.IntFlag{
+		Name:  "rpc-log-range",
+		Usage: "Maximum number of blocks a log query may span (0 = unlimited)",
+	}
 	IPCDisabledFlag = cli.BoolFlag{
 		Name:  "ipc-disable,ipcdisable",
 		Usage: "Disable the IPC-RPC server",
@@ -255,6 +317,16 @@ var (
 		Usage: "Maximum number of pending connection attempts (defaults used if set to 0)",
 		Value: 0,
 	}
+	MaxPeerIngressFlag = cli.IntFlag{
+		Name:  "max-peer-ingress",
+		Usage: "Maximum inbound message rate per peer in KB/s (unlimited if set to 0)",
+		Value: 0,
+	}
+	MaxIngressFlag = cli.IntFlag{
+		Name:  "max-ingress",
+		Usage: "Maximum inbound message rate across all peers in KB/s (unlimited if set to 0)",
+		Value: 0,
+	}
 	ListenPortFlag = cli.IntFlag{
 		Name:  "port",
 		Usage: "Network listening port",
@@ -53,6 +53,7 @@ func makeCLIApp() (app *cli.App) {
 		removedbCommand,
 		dumpCommand,
 		rollbackCommand,
+		buildAddrTxIndexCommand,
 		monitorCommand,
 		accountCommand,
 		walletCommand,
@@ -104,6 +105,8 @@ The output of this command is supposed to be machine-readable.
 		NodeNameFlag,
 		UnlockedAccountFlag,
 		PasswordFileFlag,
+		ExternalSignerFlag,
+		USBFlag,
 		AccountsIndexFlag,
 		BootnodesFlag,
 		DataDirFlag,
@@ -112,12 +115,16 @@ The output of this command is supposed to be machine-readable.
 		ChainIdentityFlag,
 		BlockchainVersionFlag,
 		FastSyncFlag,
+		AddrTxIndexFlag,
 		CacheFlag,
 		LightKDFFlag,
+		KDFFlag,
 		JSpathFlag,
 		ListenPortFlag,
 		MaxPeersFlag,
 		MaxPendingPeersFlag,
+		MaxPeerIngressFlag,
+		MaxIngressFlag,
 		EtherbaseFlag,
 		GasPriceFlag,
 		MinerThreadsFlag,
@@ -149,6 +156,16 @@ The output of this command is supposed to be machine-readable.
 		TestNetFlag,
 		NetworkIdFlag,
 		RPCCORSDomainFlag,
+		RPCVirtualHostsFlag,
+		RPCReadyHeadAgeFlag,
+		GraphQLEnabledFlag,
+		RPCAuthFileFlag,
+		RPCTimeoutFlag,
+		RPCMethodTimeoutsFlag,
+		RPCBatchLimitFlag,
+		RPCResponseLimitFlag,
+		RPCConcurrencyFlag,
+		RPCLogRangeFlag,
 		VerbosityFlag,
 		VModuleFlag,
 		LogDirFlag,
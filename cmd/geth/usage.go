@@ -62,7 +62,9 @@ var AppHelpFlagGroups = []flagGroup{
 			DevModeFlag,
 			NodeNameFlag,
 			FastSyncFlag,
+			AddrTxIndexFlag,
 			LightKDFFlag,
+			KDFFlag,
 			CacheFlag,
 			BlockchainVersionFlag,
 		},
@@ -72,6 +74,8 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			UnlockedAccountFlag,
 			PasswordFileFlag,
+			ExternalSignerFlag,
+			USBFlag,
 			AccountsIndexFlag,
 		},
 	},
@@ -91,6 +95,16 @@ var AppHelpFlagGroups = []flagGroup{
 			IPCApiFlag,
 			IPCPathFlag,
 			RPCCORSDomainFlag,
+			RPCVirtualHostsFlag,
+			RPCReadyHeadAgeFlag,
+			GraphQLEnabledFlag,
+			RPCAuthFileFlag,
+			RPCTimeoutFlag,
+			RPCMethodTimeoutsFlag,
+			RPCBatchLimitFlag,
+			RPCResponseLimitFlag,
+			RPCConcurrencyFlag,
+			RPCLogRangeFlag,
 			JSpathFlag,
 			ExecFlag,
 			PreloadJSFlag,
@@ -103,6 +117,8 @@ var AppHelpFlagGroups = []flagGroup{
 			ListenPortFlag,
 			MaxPeersFlag,
 			MaxPendingPeersFlag,
+			MaxPeerIngressFlag,
+			MaxIngressFlag,
 			NATFlag,
 			NoDiscoverFlag,
 			NodeKeyFileFlag,
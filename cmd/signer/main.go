@@ -0,0 +1,169 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// signer is a standalone process holding the keys of a keystore, signing the
+// transactions and data requested over its account API once approved, either
+// by the user at the terminal or by JavaScript rules. Geth can use it as an
+// account backend with --signer, keeping the keys out of the node.
+//
+// Rules are a JavaScript file defining any of ApproveTx, ApproveSignData and
+// ApproveListing, returning "Approve" or "Reject" for the request given, e.g.
+//
+//	function ApproveTx(req) {
+//		if (req.transaction.to == "0x...") return "Approve";
+//	}
+//
+// Anything else is put to the user. Approved signing requests are unlocked with
+// the passphrases in the credentials file, a JSON object mapping addresses to
+// passphrases.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"os/signal"
+
+	"github.com/ethereumproject/go-ethereum/accounts"
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/rpc"
+	"github.com/ethereumproject/go-ethereum/signer"
+)
+
+// Version is the application revision identifier. It can be set with the linker
+// as in: go build -ldflags "-X main.Version="`git describe --tags`
+var Version = "unknown"
+
+var (
+	keystoreFlag = flag.String("keystore", "", "Directory of the keystore holding the keys")
+	lightKDFFlag = flag.Bool("lightkdf", false, "Keystore uses the light key derivation parameters")
+	chainIdFlag  = flag.Int64("chainid", 61, "Chain id to sign transactions for (61=mainnet, 62=morden)")
+
+	ipcFlag  = flag.String("ipc", "signer.ipc", "Path of the IPC endpoint (empty = disabled)")
+	httpFlag = flag.String("http", "", "Listening address of the HTTP endpoint, e.g. localhost:8550 (empty = disabled)")
+
+	rulesFlag       = flag.String("rules", "", "JavaScript file with the rules approving or rejecting requests")
+	credentialsFlag = flag.String("credentials", "", "JSON file with the passphrases for rule approved requests")
+	auditFlag       = flag.String("audit", "audit.log", "File to append the audit log of all requests to")
+
+	versionFlag = flag.Bool("version", false, "Prints the revision identifier and exit immediatily.")
+)
+
+func main() {
+	flag.Parse()
+
+	if *versionFlag {
+		fmt.Println("signer version", Version)
+		os.Exit(0)
+	}
+	if *keystoreFlag == "" {
+		fatalf("No keystore specified (--keystore)")
+	}
+	if *ipcFlag == "" && *httpFlag == "" {
+		fatalf("No endpoint enabled (--ipc, --http)")
+	}
+	scryptN, scryptP := accounts.StandardScryptN, accounts.StandardScryptP
+	if *lightKDFFlag {
+		scryptN, scryptP = accounts.LightScryptN, accounts.LightScryptP
+	}
+	am, err := accounts.NewManager(*keystoreFlag, scryptN, scryptP, false)
+	if err != nil {
+		fatalf("Failed to open keystore: %v", err)
+	}
+	// Put requests to the user, unless decided by the rules
+	var ui signer.UIHandler = signer.NewCommandlineUI(os.Stdin, os.Stdout)
+	if *rulesFlag != "" {
+		rules, err := ioutil.ReadFile(*rulesFlag)
+		if err != nil {
+			fatalf("Failed to read rules: %v", err)
+		}
+		credentials := make(map[common.Address]string)
+		if *credentialsFlag != "" {
+			blob, err := ioutil.ReadFile(*credentialsFlag)
+			if err != nil {
+				fatalf("Failed to read credentials: %v", err)
+			}
+			var passwords map[string]string
+			if err := json.Unmarshal(blob, &passwords); err != nil {
+				fatalf("Failed to parse credentials: %v", err)
+			}
+			for addr, password := range passwords {
+				if !common.IsHexAddress(addr) {
+					fatalf("Invalid address in credentials: %q", addr)
+				}
+				credentials[common.HexToAddress(addr)] = password
+			}
+		}
+		if ui, err = signer.NewRuleUI(ui, string(rules), credentials); err != nil {
+			fatalf("Failed to load rules: %v", err)
+		}
+	}
+	audit, err := os.OpenFile(*auditFlag, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		fatalf("Failed to open audit log: %v", err)
+	}
+	defer audit.Close()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName(signer.Namespace, signer.NewSignerAPI(big.NewInt(*chainIdFlag), am, ui, signer.NewAuditLog(audit))); err != nil {
+		fatalf("Failed to register API: %v", err)
+	}
+	if *ipcFlag != "" {
+		listener, err := rpc.CreateIPCListener(*ipcFlag)
+		if err != nil {
+			fatalf("Failed to open IPC endpoint: %v", err)
+		}
+		defer listener.Close()
+		go serveIPC(listener, server)
+		fmt.Printf("IPC endpoint opened: %s\n", *ipcFlag)
+	}
+	if *httpFlag != "" {
+		listener, err := net.Listen("tcp", *httpFlag)
+		if err != nil {
+			fatalf("Failed to open HTTP endpoint: %v", err)
+		}
+		defer listener.Close()
+		go rpc.NewHTTPServer("", []string{"localhost"}, nil, nil, nil, server).Serve(listener)
+		fmt.Printf("HTTP endpoint opened: http://%s\n", *httpFlag)
+	}
+	// Serve until interrupted
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt)
+	<-sigc
+	server.Stop()
+}
+
+// serveIPC serves the API on every connection accepted by the listener, until
+// it is closed.
+func serveIPC(listener net.Listener, server *rpc.Server) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.ServeCodec(rpc.NewJSONCodec(conn), rpc.OptionMethodInvocation)
+	}
+}
+
+// fatalf prints the error and exits.
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
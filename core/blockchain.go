@@ -98,6 +98,13 @@ type BlockChain struct {
 	// procInterrupt must be atomically called
 	procInterrupt int32          // interrupt signaler for block processing
 	wg            sync.WaitGroup // chain processing wait group for shutting down
+	// addrTxIndex must be atomically called
+	addrTxIndex int32 // whether canonical transactions are indexed by address
+
+	eventsMu   sync.Mutex    // protects the queued chain events
+	events     []interface{} // chain events waiting to be posted, in order
+	eventsWake chan struct{} // wakes the event poster on newly queued events
+	eventsOnce sync.Once     // starts the event poster on first use
 
 	pow       pow.PoW
 	processor Processor // block processor interface
@@ -159,6 +166,22 @@ func (self *BlockChain) getProcInterrupt() bool {
 	return atomic.LoadInt32(&self.procInterrupt) == 1
 }
 
+// SetAddrTxIndex enables or disables indexing the transactions of the canonical
+// chain by the addresses sending and receiving them. Blocks imported while the
+// index is disabled can be indexed later with BuildAddrTxIndex.
+func (self *BlockChain) SetAddrTxIndex(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&self.addrTxIndex, 1)
+	} else {
+		atomic.StoreInt32(&self.addrTxIndex, 0)
+	}
+}
+
+// AddrTxIndex returns whether the canonical transactions are indexed by address.
+func (self *BlockChain) AddrTxIndex() bool {
+	return atomic.LoadInt32(&self.addrTxIndex) == 1
+}
+
 // loadLastState loads the last known chain state from the database. This method
 // assumes that the chain manager mutex is held.
 func (self *BlockChain) loadLastState() error {
@@ -219,6 +242,13 @@ func (bc *BlockChain) SetHead(head uint64) {
 	defer bc.mu.Unlock()
 
 	delFn := func(hash common.Hash) {
+		if bc.AddrTxIndex() {
+			if block := GetBlock(bc.chainDb, hash); block != nil {
+				if err := DeleteAddrTxIndex(bc.chainDb, block); err != nil {
+					glog.V(logger.Error).Infof("failed to unindex block #%d transactions by address: %v", block.NumberU64(), err)
+				}
+			}
+		}
 		DeleteBody(bc.chainDb, hash)
 	}
 	bc.hc.SetHead(head, delFn)
@@ -694,6 +724,14 @@ func (self *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain
 				glog.Fatal(errs[index])
 				return
 			}
+			if self.AddrTxIndex() {
+				if err := WriteAddrTxIndex(self.chainDb, block); err != nil {
+					errs[index] = fmt.Errorf("failed to index transactions by address: %v", err)
+					atomic.AddInt32(&failed, 1)
+					glog.Fatal(errs[index])
+					return
+				}
+			}
 			if err := WriteReceipts(self.chainDb, receipts); err != nil {
 				errs[index] = fmt.Errorf("failed to write individual receipts: %v", err)
 				atomic.AddInt32(&failed, 1)
@@ -812,17 +850,21 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (chainIndex int, err err
 	self.chainmu.Lock()
 	defer self.chainmu.Unlock()
 
-	// A queued approach to delivering events. This is generally
-	// faster than direct delivery and requires much less mutex
-	// acquiring.
 	var (
-		stats         struct{ queued, processed, ignored int }
-		events        = make([]interface{}, 0, len(chain))
-		coalescedLogs vm.Logs
-		tstart        = time.Now()
+		stats     struct{ queued, processed, ignored int }
+		lastCanon *types.Block
+		tstart    = time.Now()
 
 		nonceChecked = make([]bool, len(chain))
 	)
+	// Announce the new head once the batch is done, even if it's aborted half
+	// way. The events of the individual blocks are queued as they're written,
+	// keeping them in order with the events of any reorg they cause.
+	defer func() {
+		if lastCanon != nil && self.LastBlockHash() == lastCanon.Hash() {
+			self.PostChainEvents(ChainHeadEvent{lastCanon})
+		}
+	}()
 
 	// Start the parallel nonce verifier.
 	nonceAbort, nonceResults := verifyNoncesFromBlocks(self.pow, chain)
@@ -909,9 +951,6 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (chainIndex int, err err
 			return i, err
 		}
 
-		// coalesce logs for later processing
-		coalescedLogs = append(coalescedLogs, logs...)
-
 		if err := WriteBlockReceipts(self.chainDb, block.Hash(), receipts); err != nil {
 			return i, err
 		}
@@ -928,12 +967,15 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (chainIndex int, err err
 			if glog.V(logger.Debug) {
 				glog.Infof("[%v] inserted block #%d (%d TXs %v G %d UNCs) (%x...). Took %v\n", time.Now().UnixNano(), block.Number(), len(block.Transactions()), block.GasUsed(), len(block.Uncles()), block.Hash().Bytes()[0:4], time.Since(bstart))
 			}
-			events = append(events, ChainEvent{block, block.Hash(), logs})
-
 			// This puts transactions in a extra db for rpc
 			if err := WriteTransactions(self.chainDb, block); err != nil {
 				return i, err
 			}
+			if self.AddrTxIndex() {
+				if err := WriteAddrTxIndex(self.chainDb, block); err != nil {
+					return i, err
+				}
+			}
 			// store the receipts
 			if err := WriteReceipts(self.chainDb, receipts); err != nil {
 				return i, err
@@ -942,14 +984,17 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (chainIndex int, err err
 			if err := WriteMipmapBloom(self.chainDb, block.NumberU64(), receipts); err != nil {
 				return i, err
 			}
+			self.PostChainEvents(logs, ChainEvent{block, block.Hash(), logs})
+			lastCanon = block
+
 		case SideStatTy:
 			if glog.V(logger.Detail) {
 				glog.Infof("inserted forked block #%d (TD=%v) (%d TXs %d UNCs) (%x...). Took %v\n", block.Number(), block.Difficulty(), len(block.Transactions()), len(block.Uncles()), block.Hash().Bytes()[0:4], time.Since(bstart))
 			}
-			events = append(events, ChainSideEvent{block, logs})
+			self.PostChainEvents(ChainSideEvent{block, logs})
 
 		case SplitStatTy:
-			events = append(events, ChainSplitEvent{block, logs})
+			self.PostChainEvents(ChainSplitEvent{block, logs})
 		}
 		stats.processed++
 	}
@@ -959,7 +1004,6 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (chainIndex int, err err
 		start, end := chain[0], chain[len(chain)-1]
 		glog.Infof("imported %d block(s) (%d queued %d ignored) including %d txs in %v. #%v [%x / %x]\n", stats.processed, stats.queued, stats.ignored, txcount, tend, end.Number(), start.Hash().Bytes()[:4], end.Hash().Bytes()[:4])
 	}
-	go self.postChainEvents(events, coalescedLogs)
 
 	return 0, nil
 }
@@ -979,14 +1023,18 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		deletedLogsByHash = make(map[common.Hash]vm.Logs)
 		// collectLogs collects the logs that were generated during the
 		// processing of the block that corresponds with the given hash.
-		// These logs are later announced as deleted.
+		// These logs are later announced as deleted. As the old chain is
+		// walked from its head, collecting each block's logs backwards
+		// yields them in the exact reverse of their original delivery.
 		collectLogs = func(h common.Hash) {
-			// Coalesce logs
 			receipts := GetBlockReceipts(self.chainDb, h)
+			for i := len(receipts) - 1; i >= 0; i-- {
+				for j := len(receipts[i].Logs) - 1; j >= 0; j-- {
+					deletedLogs = append(deletedLogs, receipts[i].Logs[j])
+				}
+			}
 			for _, receipt := range receipts {
-				deletedLogs = append(deletedLogs, receipt.Logs...)
-
-				deletedLogsByHash[h] = receipt.Logs
+				deletedLogsByHash[h] = append(deletedLogsByHash[h], receipt.Logs...)
 			}
 		}
 	)
@@ -1039,6 +1087,15 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		glog.Infof("Chain split detected @ %x. Reorganising chain from #%v %x to %x", commonHash[:4], numSplit, oldStart.Hash().Bytes()[:4], newStart.Hash().Bytes()[:4])
 	}
 
+	// Drop the old chain from the address index, the block numbers and indexes of
+	// its transactions change even if they are included in the new chain too
+	if self.AddrTxIndex() {
+		for _, block := range oldChain {
+			if err := DeleteAddrTxIndex(self.chainDb, block); err != nil {
+				return err
+			}
+		}
+	}
 	var addedTxs types.Transactions
 	// insert blocks. Order does not matter. Last block will be written in ImportChain itself which creates the new head properly
 	for _, block := range newChain {
@@ -1048,6 +1105,11 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		if err := WriteTransactions(self.chainDb, block); err != nil {
 			return err
 		}
+		if self.AddrTxIndex() {
+			if err := WriteAddrTxIndex(self.chainDb, block); err != nil {
+				return err
+			}
+		}
 		receipts := GetBlockReceipts(self.chainDb, block.Hash())
 		// write receipts
 		if err := WriteReceipts(self.chainDb, receipts); err != nil {
@@ -1068,41 +1130,82 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		DeleteReceipt(self.chainDb, tx.Hash())
 		DeleteTransaction(self.chainDb, tx.Hash())
 	}
-	// Must be posted in a goroutine because of the transaction pool trying
-	// to acquire the chain manager lock
+	// Announce the reorg: first what was dropped, newest first, then the
+	// blocks of the new chain, oldest first. The new head itself is announced
+	// by the caller writing it. Queueing keeps the events in order with the
+	// ones of the surrounding import, and out of the chain lock the
+	// transaction pool needs to handle them.
+	var events []interface{}
 	if len(diff) > 0 {
-		go self.eventMux.Post(RemovedTransactionEvent{diff})
+		events = append(events, RemovedTransactionEvent{diff})
 	}
 	if len(deletedLogs) > 0 {
-		go self.eventMux.Post(RemovedLogsEvent{deletedLogs})
+		events = append(events, RemovedLogsEvent{deletedLogs})
 	}
-
 	if len(oldChain) > 0 {
-		go func() {
-			for _, block := range oldChain {
-				self.eventMux.Post(ChainSideEvent{Block: block, Logs: deletedLogsByHash[block.Hash()]})
-			}
-		}()
+		events = append(events, RemovedBlocksEvent{oldChain})
+		for _, block := range oldChain {
+			events = append(events, ChainSideEvent{Block: block, Logs: deletedLogsByHash[block.Hash()]})
+		}
 	}
+	for i := len(newChain) - 1; i > 0; i-- {
+		block := newChain[i]
+
+		var logs vm.Logs
+		for _, receipt := range GetBlockReceipts(self.chainDb, block.Hash()) {
+			logs = append(logs, receipt.Logs...)
+		}
+		events = append(events, logs, ChainEvent{block, block.Hash(), logs})
+	}
+	self.PostChainEvents(events...)
 
 	return nil
 }
 
-// postChainEvents iterates over the events generated by a chain insertion and
-// posts them into the event mux.
-func (self *BlockChain) postChainEvents(events []interface{}, logs vm.Logs) {
-	// post event logs for further processing
-	self.eventMux.Post(logs)
-	for _, event := range events {
-		if event, ok := event.(ChainEvent); ok {
-			// We need some control over the mining operation. Acquiring locks and waiting for the miner to create new block takes too long
-			// and in most cases isn't even necessary.
-			if self.LastBlockHash() == event.Hash {
-				self.eventMux.Post(ChainHeadEvent{event.Block})
+// PostChainEvents queues events for posting on the event mux. Events are posted
+// asynchronously, one at a time, in the order they were queued in, so that
+// subscribers see e.g. the logs removed by a reorg before the logs of the new
+// chain. Components writing blocks to the chain themselves (i.e. the miner)
+// should announce them through here too.
+func (self *BlockChain) PostChainEvents(events ...interface{}) {
+	if len(events) == 0 {
+		return
+	}
+	self.eventsOnce.Do(func() {
+		self.eventsWake = make(chan struct{}, 1)
+		go self.postEvents()
+	})
+	self.eventsMu.Lock()
+	self.events = append(self.events, events...)
+	self.eventsMu.Unlock()
+
+	select {
+	case self.eventsWake <- struct{}{}:
+	default:
+	}
+}
+
+// postEvents posts the queued chain events until the chain is stopped.
+func (self *BlockChain) postEvents() {
+	for {
+		select {
+		case <-self.eventsWake:
+		case <-self.quit:
+			return
+		}
+		for {
+			self.eventsMu.Lock()
+			events := self.events
+			self.events = nil
+			self.eventsMu.Unlock()
+
+			if len(events) == 0 {
+				break
+			}
+			for _, event := range events {
+				self.eventMux.Post(event)
 			}
 		}
-		// Fire the insertion events individually too
-		self.eventMux.Post(event)
 	}
 }
 
@@ -18,6 +18,7 @@ package core
 
 import (
 	"fmt"
+	"math"
 	"math/big"
 	"math/rand"
 	"os"
@@ -1113,6 +1114,81 @@ func TestChainTxReorgs(t *testing.T) {
 	}
 }
 
+// Tests that the address transaction index follows the canonical chain through
+// reorganisations and rewinds.
+func TestAddrTxIndexReorgs(t *testing.T) {
+	MinGasLimit = big.NewInt(125000)
+
+	key1, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	db, _ := ethdb.NewMemDatabase()
+
+	var (
+		addr1  = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2  = common.Address{0x02}
+		addr3  = common.Address{0x03}
+		signer = types.NewChainIdSigner(big.NewInt(63))
+	)
+	genesis := WriteGenesisBlockForTesting(db, GenesisAccount{addr1, big.NewInt(1000000)})
+
+	postponed, _ := types.NewTransaction(0, addr2, big.NewInt(1000), TxGas, nil, nil).WithSigner(signer).SignECDSA(key1)
+	dropped, _ := types.NewTransaction(1, addr2, big.NewInt(1000), TxGas, nil, nil).WithSigner(signer).SignECDSA(key1)
+	added, _ := types.NewTransaction(1, addr3, big.NewInt(1000), TxGas, nil, nil).WithSigner(signer).SignECDSA(key1)
+
+	chainConfig := MakeDiehardChainConfig()
+	chain, _ := GenerateChain(chainConfig, genesis, db, 3, func(i int, gen *BlockGen) {
+		switch i {
+		case 0:
+			gen.AddTx(postponed)
+		case 2:
+			gen.AddTx(dropped)
+			gen.OffsetTime(9) // Lower the block difficulty to simulate a weaker chain
+		}
+	})
+	blockchain, err := NewBlockChain(db, chainConfig, FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockchain.SetAddrTxIndex(true)
+
+	if i, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert original chain[%d]: %v", i, err)
+	}
+	check := func(addr common.Address, want ...AddrTx) {
+		have := GetAddrTxs(db, addr, 0, math.MaxUint64, 0, false, 0, 100)
+		if len(have) != len(want) {
+			t.Fatalf("%x: entry count mismatch: have %d, want %d", addr[:4], len(have), len(want))
+		}
+		for i := range want {
+			if have[i] != want[i] {
+				t.Errorf("%x: entry %d mismatch: have %+v, want %+v", addr[:4], i, have[i], want[i])
+			}
+		}
+	}
+	check(addr2, AddrTx{postponed.Hash(), 1, 0, AddrTxTo}, AddrTx{dropped.Hash(), 3, 0, AddrTxTo})
+
+	// Overwrite the original chain with a heavier one, moving and replacing transactions
+	chain, _ = GenerateChain(chainConfig, genesis, db, 5, func(i int, gen *BlockGen) {
+		switch i {
+		case 1:
+			gen.AddTx(postponed)
+		case 3:
+			gen.AddTx(added)
+		}
+	})
+	if i, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert forked chain[%d]: %v", i, err)
+	}
+	check(addr1, AddrTx{postponed.Hash(), 2, 0, AddrTxFrom}, AddrTx{added.Hash(), 4, 0, AddrTxFrom})
+	check(addr2, AddrTx{postponed.Hash(), 2, 0, AddrTxTo})
+	check(addr3, AddrTx{added.Hash(), 4, 0, AddrTxTo})
+
+	// Rewind the chain and make sure the transactions of the dropped blocks are removed
+	blockchain.SetHead(3)
+
+	check(addr1, AddrTx{postponed.Hash(), 2, 0, AddrTxFrom})
+	check(addr3)
+}
+
 func TestLogReorgs(t *testing.T) {
 	MinGasLimit = big.NewInt(125000)
 
@@ -1251,6 +1327,113 @@ done:
 
 }
 
+// Tests that the events of a reorg are posted in order: the removed logs and
+// blocks of the old chain, newest first, then the logs and blocks of the new
+// chain, oldest first, and finally the new head.
+func TestReorgEventOrder(t *testing.T) {
+	MinGasLimit = big.NewInt(125000)
+
+	key1, err := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr1 := crypto.PubkeyToAddress(key1.PublicKey)
+	// this code generates a log
+	code := common.Hex2Bytes("60606040525b7f24ec1d3ff24c2f6ff210738839dbc339cd45a5294d85c79361016243157aae7b60405180905060405180910390a15b600a8060416000396000f360606040526008565b00")
+	signer := types.NewChainIdSigner(big.NewInt(63))
+	db, err := ethdb.NewMemDatabase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := WriteGenesisBlockForTesting(db, GenesisAccount{addr1, big.NewInt(10000000000000)})
+	chainConfig := MakeDiehardChainConfig()
+
+	evmux := &event.TypeMux{}
+	blockchain, err := NewBlockChain(db, chainConfig, FakePow{}, evmux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blockchain.Stop()
+
+	subs := evmux.Subscribe(RemovedLogsEvent{}, RemovedBlocksEvent{}, ChainEvent{}, ChainHeadEvent{}, vm.Logs(nil))
+	defer subs.Unsubscribe()
+
+	// next returns the next event, failing the test if none arrives in time
+	next := func() interface{} {
+		select {
+		case ev := <-subs.Chan():
+			return ev.Data
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout waiting for event")
+		}
+		return nil
+	}
+	// makeChain generates a chain with a log in each block
+	makeChain := func(n int, coinbase common.Address) types.Blocks {
+		chain, _ := GenerateChain(chainConfig, genesis, db, n, func(i int, gen *BlockGen) {
+			gen.SetCoinbase(coinbase)
+			tx, err := types.NewContractCreation(gen.TxNonce(addr1), new(big.Int), big.NewInt(1000000), new(big.Int), code).WithSigner(signer).SignECDSA(key1)
+			if err != nil {
+				t.Fatalf("failed to create tx: %v", err)
+			}
+			gen.AddTx(tx)
+		})
+		return chain
+	}
+	oldChain := makeChain(3, common.Address{1})
+	if _, err := blockchain.InsertChain(oldChain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	for {
+		if ev, ok := next().(ChainHeadEvent); ok && ev.Block.Hash() == oldChain[2].Hash() {
+			break
+		}
+	}
+
+	newChain := makeChain(5, common.Address{2})
+	if _, err := blockchain.InsertChain(newChain); err != nil {
+		t.Fatalf("failed to insert forked chain: %v", err)
+	}
+
+	removed, ok := next().(RemovedLogsEvent)
+	if !ok {
+		t.Fatalf("expected removed logs first")
+	}
+	if len(removed.Logs) != len(oldChain) {
+		t.Fatalf("removed logs mismatch: have %d, want %d", len(removed.Logs), len(oldChain))
+	}
+	for i, log := range removed.Logs {
+		if want := oldChain[len(oldChain)-1-i].Hash(); log.BlockHash != want {
+			t.Errorf("removed log %d: block hash mismatch: have %x, want %x", i, log.BlockHash, want)
+		}
+	}
+	removedBlocks, ok := next().(RemovedBlocksEvent)
+	if !ok {
+		t.Fatalf("expected removed blocks after removed logs")
+	}
+	if len(removedBlocks.Blocks) != len(oldChain) {
+		t.Fatalf("removed blocks mismatch: have %d, want %d", len(removedBlocks.Blocks), len(oldChain))
+	}
+	for i, block := range removedBlocks.Blocks {
+		if want := oldChain[len(oldChain)-1-i].Hash(); block.Hash() != want {
+			t.Errorf("removed block %d: hash mismatch: have %x, want %x", i, block.Hash(), want)
+		}
+	}
+	for i, block := range newChain {
+		logs, ok := next().(vm.Logs)
+		if !ok || len(logs) != 1 || logs[0].BlockHash != block.Hash() {
+			t.Fatalf("block %d: expected its logs, have %v", i, logs)
+		}
+		ev, ok := next().(ChainEvent)
+		if !ok || ev.Hash != block.Hash() {
+			t.Fatalf("block %d: expected its chain event", i)
+		}
+	}
+	if ev, ok := next().(ChainHeadEvent); !ok || ev.Block.Hash() != newChain[4].Hash() {
+		t.Fatalf("expected new head last")
+	}
+}
+
 // Tests if the canonical block can be fetched from the database during chain insertion.
 func TestCanonicalBlockRetrieval(t *testing.T) {
 	t.Skip("Skipped: needs updating")
@@ -0,0 +1,86 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereumproject/go-ethereum/core/types"
+)
+
+// Tests that batched bloom bits are correctly rotated from the input bloom
+// filters.
+func TestGenerator(t *testing.T) {
+	// Generate the input and the rotated output
+	var input, output [types.BloomBitLength][types.BloomByteLength]byte
+
+	for i := 0; i < types.BloomBitLength; i++ {
+		for j := 0; j < types.BloomBitLength; j++ {
+			bit := byte(rand.Int() % 2)
+
+			input[i][j/8] |= bit << byte(7-j%8)
+			output[types.BloomBitLength-1-j][i/8] |= bit << byte(7-i%8)
+		}
+	}
+	// Crunch the input through the generator and verify the result
+	gen, err := NewGenerator(types.BloomBitLength)
+	if err != nil {
+		t.Fatalf("failed to create bloombit generator: %v", err)
+	}
+	for i, bloom := range input {
+		if err := gen.AddBloom(uint(i), bloom); err != nil {
+			t.Fatalf("bloom %d: failed to add: %v", i, err)
+		}
+	}
+	for i, want := range output {
+		have, err := gen.Bitset(uint(i))
+		if err != nil {
+			t.Fatalf("output %d: failed to retrieve bits: %v", i, err)
+		}
+		if !bytes.Equal(have, want[:]) {
+			t.Errorf("output %d: bit vector mismatch have %x, want %x", i, have, want)
+		}
+	}
+}
+
+// Tests that the generator rejects blooms out of order or beyond the section.
+func TestGeneratorBounds(t *testing.T) {
+	gen, err := NewGenerator(8)
+	if err != nil {
+		t.Fatalf("failed to create bloombit generator: %v", err)
+	}
+	if err := gen.AddBloom(1, types.Bloom{}); err != errUnexpectedIndex {
+		t.Errorf("out of order bloom error mismatch: have %v, want %v", err, errUnexpectedIndex)
+	}
+	if _, err := gen.Bitset(0); err == nil {
+		t.Errorf("retrieved bit vector of incomplete section")
+	}
+	for i := 0; i < 8; i++ {
+		if err := gen.AddBloom(uint(i), types.BytesToBloom(big.NewInt(int64(i)).Bytes())); err != nil {
+			t.Fatalf("bloom %d: failed to add: %v", i, err)
+		}
+	}
+	if err := gen.AddBloom(8, types.Bloom{}); err != errSectionOutOfBounds {
+		t.Errorf("overflowing bloom error mismatch: have %v, want %v", err, errSectionOutOfBounds)
+	}
+	if _, err := gen.Bitset(types.BloomBitLength); err != errBloomBitOutOfBounds {
+		t.Errorf("overflowing bit error mismatch: have %v, want %v", err, errBloomBitOutOfBounds)
+	}
+}
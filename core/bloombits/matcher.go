@@ -0,0 +1,228 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereumproject/go-ethereum/common/bitutil"
+	"github.com/ethereumproject/go-ethereum/crypto"
+)
+
+const (
+	matcherBatch   = 16 // Number of sections filtered together
+	matcherWorkers = 16 // Number of bit vector batches retrieved concurrently
+)
+
+// errInvalidBitset is returned if a retriever delivers a bit vector with a size
+// not matching the section size.
+var errInvalidBitset = errors.New("invalid bit vector length")
+
+// Retriever fetches the bit vectors of a single bloom bit for a batch of sections.
+// Implementations may read them from a local index or request them from remote
+// peers, so a light client can filter by proxy of a full node's index.
+type Retriever interface {
+	// GetBloomBits returns the uncompressed bit vectors of the given bloom bit in
+	// the same order as the requested sections. A nil vector signals the section
+	// is not indexed and all its blocks need to be checked.
+	GetBloomBits(bit uint, sections []uint64) ([][]byte, error)
+}
+
+// bloomIndexes represents the bit indexes inside the bloom filter that belong
+// to some key.
+type bloomIndexes [3]uint
+
+// calcBloomIndexes returns the bloom filter bit indexes belonging to the given key.
+func calcBloomIndexes(b []byte) bloomIndexes {
+	b = crypto.Keccak256(b)
+
+	var idxs bloomIndexes
+	for i := 0; i < len(idxs); i++ {
+		idxs[i] = (uint(b[2*i])<<8)&2047 + uint(b[2*i+1])
+	}
+	return idxs
+}
+
+// Matcher evaluates a filter over whole sections of blocks at once by combining
+// the retrieved bit vectors of the filtered keys with binary AND/OR operations.
+//
+// The filter is a list of groups: a block matches if it matches every group, and
+// it matches a group if it matches any of the keys within it. Empty groups are
+// wildcards and match every block.
+type Matcher struct {
+	sectionSize uint64           // Number of blocks in a single section
+	filters     [][]bloomIndexes // Filter groups the matcher is evaluating
+}
+
+// NewMatcher creates a new matcher for the given section size and filter groups.
+func NewMatcher(sectionSize uint64, filters [][][]byte) *Matcher {
+	m := &Matcher{sectionSize: sectionSize}
+	for _, filter := range filters {
+		if len(filter) == 0 {
+			continue
+		}
+		group := make([]bloomIndexes, len(filter))
+		for i, key := range filter {
+			group[i] = calcBloomIndexes(key)
+		}
+		m.filters = append(m.filters, group)
+	}
+	return m
+}
+
+// Match returns the numbers of the blocks in the range [begin, end] that possibly
+// match the filter. All the bloom bits needed by a filter group are retrieved in
+// parallel, and sections already ruled out are not retrieved for later groups.
+func (m *Matcher) Match(begin, end uint64, retriever Retriever) ([]uint64, error) {
+	var matches []uint64
+	for first := begin / m.sectionSize; first <= end/m.sectionSize; first += matcherBatch {
+		last := first + matcherBatch - 1
+		if last > end/m.sectionSize {
+			last = end / m.sectionSize
+		}
+		sections := make([]uint64, 0, last-first+1)
+		for section := first; section <= last; section++ {
+			sections = append(sections, section)
+		}
+		vectors, err := m.matchSections(sections, retriever)
+		if err != nil {
+			return nil, err
+		}
+		for i, vector := range vectors {
+			for j := uint64(0); j < m.sectionSize; j++ {
+				if vector[j/8]&(1<<byte(7-j%8)) == 0 {
+					continue
+				}
+				if number := sections[i]*m.sectionSize + j; number >= begin && number <= end {
+					matches = append(matches, number)
+				}
+			}
+		}
+	}
+	return matches, nil
+}
+
+// matchSections evaluates the filter groups for a batch of sections, returning
+// the result vector of each.
+func (m *Matcher) matchSections(sections []uint64, retriever Retriever) ([][]byte, error) {
+	vectors := make([][]byte, len(sections))
+	for i := range vectors {
+		vectors[i] = make([]byte, m.sectionSize/8)
+		for j := range vectors[i] {
+			vectors[i][j] = 0xff
+		}
+	}
+	for _, group := range m.filters {
+		// Gather the sections still in the running
+		var (
+			alive []uint64
+			index []int
+		)
+		for i, vector := range vectors {
+			if bitutil.TestBytes(vector) {
+				alive = append(alive, sections[i])
+				index = append(index, i)
+			}
+		}
+		if len(alive) == 0 {
+			break
+		}
+		bits, err := m.retrieve(group, alive, retriever)
+		if err != nil {
+			return nil, err
+		}
+		// OR the keys of the group together and AND the group into the results
+		for k, i := range index {
+			result := make([]byte, m.sectionSize/8)
+			for _, idxs := range group {
+				match := make([]byte, len(result))
+				copy(match, bits[idxs[0]][k])
+				for _, bit := range idxs[1:] {
+					bitutil.ANDBytes(match, match, bits[bit][k])
+				}
+				bitutil.ORBytes(result, result, match)
+			}
+			bitutil.ANDBytes(vectors[i], vectors[i], result)
+		}
+	}
+	return vectors, nil
+}
+
+// retrieve fetches the bit vectors of all bloom bits used by a filter group for
+// the given sections concurrently. Vectors of unindexed sections are returned
+// with all bits set.
+func (m *Matcher) retrieve(group []bloomIndexes, sections []uint64, retriever Retriever) (map[uint][][]byte, error) {
+	var fetch []uint
+	bits := make(map[uint][][]byte)
+	for _, idxs := range group {
+		for _, bit := range idxs {
+			if _, ok := bits[bit]; !ok {
+				bits[bit], fetch = nil, append(fetch, bit)
+			}
+		}
+	}
+	var (
+		lock    sync.Mutex
+		wg      sync.WaitGroup
+		failure error
+		workers = make(chan struct{}, matcherWorkers)
+	)
+	for _, bit := range fetch {
+		wg.Add(1)
+		workers <- struct{}{}
+
+		go func(bit uint) {
+			defer func() { <-workers; wg.Done() }()
+
+			vectors, err := retriever.GetBloomBits(bit, sections)
+			if err == nil && len(vectors) != len(sections) {
+				err = errInvalidBitset
+			}
+			for i, vector := range vectors {
+				if err != nil {
+					break
+				}
+				switch {
+				case vector == nil:
+					vectors[i] = make([]byte, m.sectionSize/8)
+					for j := range vectors[i] {
+						vectors[i][j] = 0xff
+					}
+				case uint64(len(vector)) != m.sectionSize/8:
+					err = errInvalidBitset
+				}
+			}
+			lock.Lock()
+			defer lock.Unlock()
+
+			if err != nil {
+				if failure == nil {
+					failure = err
+				}
+				return
+			}
+			bits[bit] = vectors
+		}(bit)
+	}
+	wg.Wait()
+
+	if failure != nil {
+		return nil, failure
+	}
+	return bits, nil
+}
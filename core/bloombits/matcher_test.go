@@ -0,0 +1,168 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/ethereumproject/go-ethereum/core/types"
+)
+
+const testSectionSize = 64
+
+// testRetriever serves bit vectors generated from a set of in-memory blooms,
+// counting the retrievals done.
+type testRetriever struct {
+	blooms   []types.Bloom
+	indexed  map[uint64]bool // Sections available, nil for all of them
+	fail     bool
+	lock     sync.Mutex
+	requests int
+}
+
+func (r *testRetriever) GetBloomBits(bit uint, sections []uint64) ([][]byte, error) {
+	r.lock.Lock()
+	r.requests += len(sections)
+	r.lock.Unlock()
+
+	if r.fail {
+		return nil, errors.New("retrieval failed")
+	}
+	vectors := make([][]byte, len(sections))
+	for i, section := range sections {
+		if r.indexed != nil && !r.indexed[section] {
+			continue
+		}
+		gen, _ := NewGenerator(testSectionSize)
+		for j := uint64(0); j < testSectionSize; j++ {
+			var bloom types.Bloom
+			if number := section*testSectionSize + j; number < uint64(len(r.blooms)) {
+				bloom = r.blooms[number]
+			}
+			gen.AddBloom(uint(j), bloom)
+		}
+		vectors[i], _ = gen.Bitset(bit)
+	}
+	return vectors, nil
+}
+
+// makeBlooms creates a chain of blooms where block n contains the key n%mod for
+// every given modulus.
+func makeBlooms(blocks int, mods ...int) []types.Bloom {
+	blooms := make([]types.Bloom, blocks)
+	for i := range blooms {
+		for _, mod := range mods {
+			blooms[i].Add(new(big.Int).SetBytes(testKey(mod, i%mod)))
+		}
+	}
+	return blooms
+}
+
+func testKey(mod, n int) []byte {
+	return []byte{byte(mod), byte(n), 0xde, 0xad}
+}
+
+// bruteMatch returns the blocks matching the filter by checking each bloom.
+func bruteMatch(blooms []types.Bloom, begin, end uint64, filters [][][]byte) []uint64 {
+	var matches []uint64
+	for number := begin; number <= end && number < uint64(len(blooms)); number++ {
+		match := true
+		for _, group := range filters {
+			if len(group) == 0 {
+				continue
+			}
+			any := false
+			for _, key := range group {
+				if types.BloomLookup(blooms[number], key) {
+					any = true
+				}
+			}
+			match = match && any
+		}
+		if match {
+			matches = append(matches, number)
+		}
+	}
+	return matches
+}
+
+// Tests that the matcher finds the same blocks as checking the blooms one by one.
+func TestMatcher(t *testing.T) {
+	blooms := makeBlooms(40*testSectionSize, 7, 13)
+
+	tests := []struct {
+		begin, end uint64
+		filters    [][][]byte
+	}{
+		{0, uint64(len(blooms) - 1), [][][]byte{{testKey(7, 3)}}},
+		{0, uint64(len(blooms) - 1), [][][]byte{{testKey(7, 3)}, {testKey(13, 5)}}},
+		{0, uint64(len(blooms) - 1), [][][]byte{{testKey(7, 3), testKey(7, 4)}, nil, {testKey(13, 5), testKey(13, 6)}}},
+		{100, 2000, [][][]byte{{testKey(7, 3)}, {testKey(13, 5)}}},
+		{130, 130, [][][]byte{{testKey(7, 4)}}},
+		{0, uint64(len(blooms) - 1), [][][]byte{{[]byte("missing")}}},
+	}
+	for i, tt := range tests {
+		retriever := &testRetriever{blooms: blooms}
+		have, err := NewMatcher(testSectionSize, tt.filters).Match(tt.begin, tt.end, retriever)
+		if err != nil {
+			t.Fatalf("test %d: failed to match: %v", i, err)
+		}
+		if want := bruteMatch(blooms, tt.begin, tt.end, tt.filters); !reflect.DeepEqual(have, want) {
+			t.Errorf("test %d: match mismatch: have %v, want %v", i, have, want)
+		}
+	}
+}
+
+// Tests that sections ruled out by a filter group are not retrieved for later
+// groups.
+func TestMatcherShortCircuit(t *testing.T) {
+	blooms := makeBlooms(32*testSectionSize, 7, 13)
+	filters := [][][]byte{{[]byte("missing")}, {testKey(13, 5)}}
+
+	retriever := &testRetriever{blooms: blooms}
+	if _, err := NewMatcher(testSectionSize, filters).Match(0, uint64(len(blooms)-1), retriever); err != nil {
+		t.Fatalf("failed to match: %v", err)
+	}
+	// Only the 3 bits of the first group should have been retrieved, for each section
+	if retriever.requests > 3*32 {
+		t.Errorf("retrieval count mismatch: have %d, want at most %d", retriever.requests, 3*32)
+	}
+}
+
+// Tests that unindexed sections are reported as full matches and retrieval
+// failures are propagated.
+func TestMatcherUnindexed(t *testing.T) {
+	blooms := makeBlooms(4*testSectionSize, 7)
+	filters := [][][]byte{{[]byte("missing")}}
+
+	retriever := &testRetriever{blooms: blooms, indexed: map[uint64]bool{0: true, 1: true, 3: true}}
+	have, err := NewMatcher(testSectionSize, filters).Match(0, uint64(len(blooms)-1), retriever)
+	if err != nil {
+		t.Fatalf("failed to match: %v", err)
+	}
+	if len(have) != testSectionSize || have[0] != 2*testSectionSize {
+		t.Errorf("unindexed section not matched: have %v", have)
+	}
+	retriever = &testRetriever{blooms: blooms, fail: true}
+	if _, err := NewMatcher(testSectionSize, filters).Match(0, uint64(len(blooms)-1), retriever); err == nil {
+		t.Errorf("retrieval failure not reported")
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/event"
+	"github.com/ethereumproject/go-ethereum/logger"
+	"github.com/ethereumproject/go-ethereum/logger/glog"
+)
+
+// ChainIndexerBackend defines the methods needed to process chain sections in
+// the background and write the section results into the database. These can be
+// used to create filter blooms, CHTs or other derived indexes.
+type ChainIndexerBackend interface {
+	// Reset initiates the processing of a new chain section, discarding any
+	// partially completed one (e.g. after a failure or a reorg).
+	Reset(section uint64, prevHead common.Hash) error
+
+	// Process crunches through the next header of the section. The indexer
+	// ensures the headers are fed in sequential, parent linked order.
+	Process(header *types.Header) error
+
+	// Commit adds the results of the finished section to the batch, which is
+	// written to the database atomically with the indexer's own metadata.
+	Commit(batch ethdb.Batch) error
+}
+
+// ChainIndexer does a post-processing job for equally sized sections of the
+// canonical chain (like bloom bits and CHT structures). It follows the chain
+// head events posted on the event mux, processes a section once its last block
+// has enough confirmations and rolls back the sections reorged out of the chain.
+//
+// Child indexers can be added to build on the output of the parent. They are
+// notified of a new head only after the parent finished a whole section, or
+// after a rollback of the parent's already finished sections.
+type ChainIndexer struct {
+	db       ethdb.Database      // Chain database to index the data from
+	prefix   []byte              // Key prefix of the index metadata in the database
+	backend  ChainIndexerBackend // Background processor generating the index content
+	children []*ChainIndexer     // Child indexers to cascade processed heads to
+
+	sectionSize uint64        // Number of blocks in a single chain section
+	confirmsReq uint64        // Number of confirmations before processing a completed section
+	throttling  time.Duration // Pause between sections to prevent hogging the database
+	kind        string        // Name of the index, used for logging
+
+	storedSections uint64 // Number of sections successfully indexed into the database
+	knownSections  uint64 // Number of sections known to be complete and confirmed
+
+	update chan struct{} // Notification channel of chain changes to process
+	quit   chan struct{}
+	wg     sync.WaitGroup
+	lock   sync.Mutex
+}
+
+// NewChainIndexer creates a new chain indexer processing sections of sectionSize
+// blocks with the given backend, once they have confirmsReq blocks on top. The
+// indexer keeps its progress in db under the given key prefix.
+func NewChainIndexer(db ethdb.Database, prefix []byte, backend ChainIndexerBackend, sectionSize, confirmsReq uint64, throttling time.Duration, kind string) *ChainIndexer {
+	return &ChainIndexer{
+		db:             db,
+		prefix:         prefix,
+		backend:        backend,
+		sectionSize:    sectionSize,
+		confirmsReq:    confirmsReq,
+		throttling:     throttling,
+		kind:           kind,
+		storedSections: GetIndexSections(db, prefix),
+		update:         make(chan struct{}, 1),
+		quit:           make(chan struct{}),
+	}
+}
+
+// Start begins processing the sections of the current canonical chain, and
+// keeps following the chain head and reorg events posted on mux. Stored
+// sections not matching the canonical chain any more are rolled back first.
+func (c *ChainIndexer) Start(mux *event.TypeMux) {
+	sub := mux.Subscribe(ChainHeadEvent{}, ChainSideEvent{})
+
+	c.start()
+	if header := GetHeader(c.db, GetHeadBlockHash(c.db)); header != nil {
+		c.setChainLength(header.Number.Uint64() + 1)
+	}
+	c.wg.Add(1)
+	go c.eventLoop(sub)
+}
+
+// start launches the processing loop, which verifies the stored sections before
+// processing any new ones.
+func (c *ChainIndexer) start() {
+	c.wg.Add(1)
+	go c.updateLoop()
+	c.signal()
+}
+
+// Stop terminates the indexer and all its children, waiting for the sections
+// being processed to be aborted.
+func (c *ChainIndexer) Stop() {
+	close(c.quit)
+	c.wg.Wait()
+
+	c.lock.Lock()
+	children := c.children
+	c.lock.Unlock()
+
+	for _, child := range children {
+		child.Stop()
+	}
+}
+
+// AddChildIndexer adds a child indexer that is only notified of the sections
+// already processed by this one, and starts it.
+func (c *ChainIndexer) AddChildIndexer(child *ChainIndexer) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.children = append(c.children, child)
+	child.start()
+	child.setChainLength(c.storedSections * c.sectionSize)
+}
+
+// Sections returns the number of consecutive sections processed from the
+// genesis block.
+func (c *ChainIndexer) Sections() uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.storedSections
+}
+
+// SectionHead retrieves the hash of the last block of a processed section, or
+// the zero hash if it's not processed.
+func (c *ChainIndexer) SectionHead(section uint64) common.Hash {
+	return GetIndexSectionHead(c.db, c.prefix, section)
+}
+
+// eventLoop follows the chain events, notifying the processing loop of every
+// change. It's separate from the processing as posting events must not block.
+func (c *ChainIndexer) eventLoop(sub event.Subscription) {
+	defer c.wg.Done()
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev, ok := <-sub.Chan():
+			if !ok {
+				return
+			}
+			switch ev := ev.Data.(type) {
+			case ChainHeadEvent:
+				c.setChainLength(ev.Block.NumberU64() + 1)
+			case ChainSideEvent:
+				// Blocks of a reorged out chain are announced as side blocks,
+				// have the stored sections verified against the new chain
+				c.signal()
+			}
+
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// setChainLength updates the number of sections confirmed by a chain of the
+// given number of blocks and notifies the processing loop.
+func (c *ChainIndexer) setChainLength(blocks uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.knownSections = 0
+	if blocks >= c.confirmsReq {
+		c.knownSections = (blocks - c.confirmsReq) / c.sectionSize
+	}
+	c.signal()
+}
+
+// signal wakes up the processing loop, unless it's already notified.
+func (c *ChainIndexer) signal() {
+	select {
+	case c.update <- struct{}{}:
+	default:
+	}
+}
+
+// updateLoop rolls back the stored sections invalidated by reorgs and processes
+// the confirmed ones one by one whenever the chain changes.
+func (c *ChainIndexer) updateLoop() {
+	defer c.wg.Done()
+
+	for {
+		select {
+		case <-c.update:
+		case <-c.quit:
+			return
+		}
+		c.lock.Lock()
+		c.verifySections()
+		if c.knownSections <= c.storedSections {
+			c.lock.Unlock()
+			continue
+		}
+		section := c.storedSections
+		var prevHead common.Hash
+		if section > 0 {
+			prevHead = c.SectionHead(section - 1)
+		}
+		c.lock.Unlock()
+
+		// Process the section without holding the lock, reorgs may happen meanwhile
+		start := time.Now()
+		batch, head, err := c.processSection(section, prevHead)
+
+		c.lock.Lock()
+		switch {
+		case err != nil:
+			glog.V(logger.Debug).Infof("%s section %d not processed: %v", c.kind, section, err)
+
+		case c.storedSections != section:
+			glog.V(logger.Debug).Infof("%s section %d rolled back during processing", c.kind, section)
+
+		default:
+			WriteIndexSectionHead(batch, c.prefix, section, head)
+			WriteIndexSections(batch, c.prefix, section+1)
+			if err = batch.Write(); err != nil {
+				glog.V(logger.Error).Infof("Failed to store %s section %d: %v", c.kind, section, err)
+				break
+			}
+			c.storedSections = section + 1
+			c.cascade()
+
+			glog.V(logger.Debug).Infof("Processed %s section %d [%x…] in %v", c.kind, section, head[:4], time.Since(start))
+		}
+		more := err == nil && c.knownSections > c.storedSections
+		c.lock.Unlock()
+
+		// Continue with the next section, leaving some room for the database
+		if more {
+			if c.throttling > 0 {
+				select {
+				case <-time.After(c.throttling):
+				case <-c.quit:
+					return
+				}
+			}
+			c.signal()
+		}
+	}
+}
+
+// processSection feeds the headers of a canonical chain section to the backend,
+// returning the batch of results to store and the hash of the section's last block.
+func (c *ChainIndexer) processSection(section uint64, prevHead common.Hash) (ethdb.Batch, common.Hash, error) {
+	if err := c.backend.Reset(section, prevHead); err != nil {
+		return nil, common.Hash{}, err
+	}
+	head := prevHead
+	for number := section * c.sectionSize; number < (section+1)*c.sectionSize; number++ {
+		select {
+		case <-c.quit:
+			return nil, common.Hash{}, fmt.Errorf("indexer terminated")
+		default:
+		}
+		hash := GetCanonicalHash(c.db, number)
+		if (hash == common.Hash{}) {
+			return nil, common.Hash{}, fmt.Errorf("canonical block #%d unknown", number)
+		}
+		header := GetHeader(c.db, hash)
+		if header == nil {
+			return nil, common.Hash{}, fmt.Errorf("block #%d [%x…] not found", number, hash[:4])
+		}
+		// Make sure a reorg didn't happen in the mean time
+		if number > 0 && header.ParentHash != head {
+			return nil, common.Hash{}, fmt.Errorf("block #%d [%x…] not on top of #%d [%x…]", number, hash[:4], number-1, head[:4])
+		}
+		if err := c.backend.Process(header); err != nil {
+			return nil, common.Hash{}, err
+		}
+		head = hash
+	}
+	batch := c.db.NewBatch()
+	if err := c.backend.Commit(batch); err != nil {
+		return nil, common.Hash{}, err
+	}
+	return batch, head, nil
+}
+
+// verifySections rolls back the stored sections whose last block isn't part of
+// the canonical chain any more. A reorg within a section changes the canonical
+// hash at its end too, so it's enough to walk back from the newest section.
+//
+// The caller must hold the lock.
+func (c *ChainIndexer) verifySections() {
+	valid := c.storedSections
+	for valid > 0 && c.SectionHead(valid-1) != GetCanonicalHash(c.db, valid*c.sectionSize-1) {
+		valid--
+	}
+	if valid == c.storedSections {
+		return
+	}
+	glog.V(logger.Warn).Infof("%s index rolled back from %d to %d sections", c.kind, c.storedSections, valid)
+
+	batch := c.db.NewBatch()
+	WriteIndexSections(batch, c.prefix, valid)
+	if err := batch.Write(); err != nil {
+		glog.V(logger.Error).Infof("Failed to roll back %s index: %v", c.kind, err)
+		return
+	}
+	for section := valid; section < c.storedSections; section++ {
+		DeleteIndexSectionHead(c.db, c.prefix, section)
+	}
+	c.storedSections = valid
+	c.cascade()
+}
+
+// cascade notifies the child indexers of the blocks processed by this one.
+//
+// The caller must hold the lock.
+func (c *ChainIndexer) cascade() {
+	for _, child := range c.children {
+		child.setChainLength(c.storedSections * c.sectionSize)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/event"
+)
+
+// testIndexerBackend is a chain indexer backend storing the number of headers
+// processed in each section, checking they are fed in order.
+type testIndexerBackend struct {
+	prefix  string
+	section uint64
+	next    uint64
+	size    uint64
+}
+
+func (b *testIndexerBackend) Reset(section uint64, prevHead common.Hash) error {
+	b.section, b.next = section, section*b.size
+	return nil
+}
+
+func (b *testIndexerBackend) Process(header *types.Header) error {
+	if number := header.Number.Uint64(); number != b.next {
+		return fmt.Errorf("header out of order: have #%d, want #%d", number, b.next)
+	}
+	b.next++
+	return nil
+}
+
+func (b *testIndexerBackend) Commit(batch ethdb.Batch) error {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, b.next-b.section*b.size)
+	return batch.Put([]byte(fmt.Sprintf("%s%d", b.prefix, b.section)), data)
+}
+
+// newTestIndexer creates a chain indexer with a test backend on top of db.
+func newTestIndexer(db ethdb.Database, name string, size, confirms uint64) *ChainIndexer {
+	backend := &testIndexerBackend{prefix: name + "-data-", size: size}
+	return NewChainIndexer(db, []byte(name+"-"), backend, size, confirms, 0, name)
+}
+
+// writeIndexerChain writes a canonical header chain of the given length on top
+// of the parent, marking headers of different forks with their extra data.
+func writeIndexerChain(db ethdb.Database, parent *types.Header, n int, fork byte) []*types.Header {
+	var headers []*types.Header
+	for i := 0; i < n; i++ {
+		header := &types.Header{
+			Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+			ParentHash: parent.Hash(),
+			Difficulty: big.NewInt(1),
+			Extra:      []byte{fork},
+		}
+		WriteHeader(db, header)
+		WriteCanonicalHash(db, header.Hash(), header.Number.Uint64())
+		WriteHeadBlockHash(db, header.Hash())
+
+		headers = append(headers, header)
+		parent = header
+	}
+	return headers
+}
+
+// checkIndexer checks that the indexer has the given section count, and all the
+// stored sections match the canonical chain.
+func checkIndexer(db ethdb.Database, indexer *ChainIndexer, size, sections uint64) error {
+	if have := indexer.Sections(); have != sections {
+		return fmt.Errorf("section count mismatch: have %d, want %d", have, sections)
+	}
+	if have := GetIndexSections(db, indexer.prefix); have != sections {
+		return fmt.Errorf("stored section count mismatch: have %d, want %d", have, sections)
+	}
+	for section := uint64(0); section < sections; section++ {
+		if head, want := indexer.SectionHead(section), GetCanonicalHash(db, (section+1)*size-1); head != want {
+			return fmt.Errorf("section %d head mismatch: have %x, want %x", section, head, want)
+		}
+		data, _ := db.Get([]byte(fmt.Sprintf("%s-data-%d", indexer.kind, section)))
+		if len(data) != 8 || binary.BigEndian.Uint64(data) != size {
+			return fmt.Errorf("section %d data mismatch: have %x", section, data)
+		}
+	}
+	return nil
+}
+
+// waitIndexer waits until the indexer reaches the given section count, with all
+// the sections matching the canonical chain.
+func waitIndexer(t *testing.T, db ethdb.Database, indexer *ChainIndexer, size, sections uint64) {
+	err := checkIndexer(db, indexer, size, sections)
+	for i := 0; i < 100 && err != nil; i++ {
+		time.Sleep(20 * time.Millisecond)
+		err = checkIndexer(db, indexer, size, sections)
+	}
+	if err != nil {
+		t.Fatalf("%s: %v", indexer.kind, err)
+	}
+}
+
+// Tests that the chain indexer processes confirmed sections as the chain grows,
+// rolls back the sections invalidated by reorgs and cascades to child indexers.
+func TestChainIndexer(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	mux := new(event.TypeMux)
+
+	genesis := &types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)}
+	WriteHeader(db, genesis)
+	WriteCanonicalHash(db, genesis.Hash(), 0)
+
+	// Start the indexers on a chain of two complete sections, one confirmed
+	headers := append([]*types.Header{genesis}, writeIndexerChain(db, genesis, 18, 0)...)
+
+	parent := newTestIndexer(db, "parent", 8, 4)
+	child := newTestIndexer(db, "child", 16, 0)
+	parent.Start(mux)
+	parent.AddChildIndexer(child)
+	defer parent.Stop()
+
+	waitIndexer(t, db, parent, 8, 1)
+	waitIndexer(t, db, child, 16, 0)
+
+	// Extend the chain and make sure new sections get processed and cascaded
+	headers = append(headers, writeIndexerChain(db, headers[len(headers)-1], 20, 0)...)
+	mux.Post(ChainHeadEvent{Block: types.NewBlockWithHeader(headers[len(headers)-1])})
+
+	waitIndexer(t, db, parent, 8, 4)
+	waitIndexer(t, db, child, 16, 2)
+
+	// Reorg the chain back into the second section and ensure the announcement of
+	// the dropped blocks gets the sections reindexed
+	fork := writeIndexerChain(db, headers[12], 26, 1)
+	mux.Post(ChainSideEvent{Block: types.NewBlockWithHeader(headers[13])})
+
+	waitIndexer(t, db, parent, 8, 4)
+	waitIndexer(t, db, child, 16, 2)
+
+	// Extend the fork and make sure it keeps being indexed
+	fork = append(fork, writeIndexerChain(db, fork[len(fork)-1], 30, 1)...)
+	mux.Post(ChainHeadEvent{Block: types.NewBlockWithHeader(fork[len(fork)-1])})
+
+	waitIndexer(t, db, parent, 8, 8)
+	waitIndexer(t, db, child, 16, 4)
+}
+
+// Tests that a restarted chain indexer resumes from its stored sections, rolling
+// back the ones reorged out while it was not running.
+func TestChainIndexerRestart(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	genesis := &types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)}
+	WriteHeader(db, genesis)
+	WriteCanonicalHash(db, genesis.Hash(), 0)
+
+	headers := append([]*types.Header{genesis}, writeIndexerChain(db, genesis, 40, 0)...)
+
+	indexer := newTestIndexer(db, "test", 8, 0)
+	indexer.Start(new(event.TypeMux))
+	waitIndexer(t, db, indexer, 8, 5)
+	indexer.Stop()
+
+	// Reorg the chain while the indexer is down, and restart it
+	writeIndexerChain(db, headers[20], 5, 1)
+	for number := uint64(26); number <= 40; number++ {
+		DeleteCanonicalHash(db, number)
+	}
+	indexer = newTestIndexer(db, "test", 8, 0)
+	if sections := indexer.Sections(); sections != 5 {
+		t.Fatalf("resumed section count mismatch: have %d, want %d", sections, 5)
+	}
+	indexer.Start(new(event.TypeMux))
+	defer indexer.Stop()
+
+	waitIndexer(t, db, indexer, 8, 3)
+	if head := indexer.SectionHead(3); (head != common.Hash{}) {
+		t.Fatalf("rolled back section head not deleted: %x", head)
+	}
+}
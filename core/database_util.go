@@ -20,10 +20,14 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"math/big"
+	"time"
 
 	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/common/bitutil"
 	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/crypto"
 	"github.com/ethereumproject/go-ethereum/ethdb"
 	"github.com/ethereumproject/go-ethereum/logger"
 	"github.com/ethereumproject/go-ethereum/logger/glog"
@@ -34,6 +38,7 @@ var (
 	headHeaderKey = []byte("LastHeader")
 	headBlockKey  = []byte("LastBlock")
 	headFastKey   = []byte("LastFast")
+	fastSyncKey   = []byte("FastSyncProgress")
 
 	blockPrefix    = []byte("block-")
 	blockNumPrefix = []byte("block-num-")
@@ -49,9 +54,35 @@ var (
 	mipmapPre    = []byte("mipmap-log-bloom-")
 	MIPMapLevels = []uint64{1000000, 500000, 100000, 50000, 1000}
 
+	indexSectionsSuffix = []byte("sections") // index prefix + indexSectionsSuffix -> number of sections indexed
+	indexHeadSuffix     = []byte("head-")    // index prefix + indexHeadSuffix + section (uint64 big endian) -> section head hash
+
+	addrTxPrefix      = []byte("atx-")                // addrTxPrefix + address + block number (uint64 big endian) + tx index (uint32 big endian) + direction -> tx hash
+	addrTxBookmarkKey = []byte("AddrTxIndexBookmark") // number of the next block to backfill into the address transaction index
+
+	BloomBitsIndexPrefix = []byte("bloom-bits-") // BloomBitsIndexPrefix + bit (uint16 big endian) + section (uint64 big endian) + head hash -> compressed bit vector
+
 	blockHashPrefix = []byte("block-hash-") // [deprecated by the header/block split, remove eventually]
 )
 
+// FastSyncProgress is the progress of an interrupted fast sync, persisted to let
+// a restarted node resume the state retrieval of the same pivot block, without
+// downloading again the blocks it already retrieved but didn't import yet.
+type FastSyncProgress struct {
+	Pivot   *types.Header     // Pivot block whose state is being retrieved
+	Nodes   [][]byte          // State nodes retrieved but not yet committed to the database
+	Results []*FastSyncResult // Blocks retrieved but not yet committed to the database
+}
+
+// FastSyncResult is a block of an interrupted fast sync whose contents were all
+// retrieved, but which wasn't imported yet.
+type FastSyncResult struct {
+	Header       *types.Header
+	Uncles       []*types.Header
+	Transactions types.Transactions
+	Receipts     types.Receipts
+}
+
 // GetCanonicalHash retrieves a hash assigned to a canonical block number.
 func GetCanonicalHash(db ethdb.Database, number uint64) common.Hash {
 	data, _ := db.Get(append(blockNumPrefix, big.NewInt(int64(number)).Bytes()...))
@@ -95,6 +126,21 @@ func GetHeadFastBlockHash(db ethdb.Database) common.Hash {
 	return common.BytesToHash(data)
 }
 
+// GetFastSyncProgress retrieves the persisted progress of an interrupted fast
+// sync, or nil if there is none.
+func GetFastSyncProgress(db ethdb.Database) *FastSyncProgress {
+	data, _ := db.Get(fastSyncKey)
+	if len(data) == 0 {
+		return nil
+	}
+	progress := new(FastSyncProgress)
+	if err := rlp.DecodeBytes(data, progress); err != nil {
+		glog.V(logger.Error).Infof("invalid fast sync progress RLP: %v", err)
+		return nil
+	}
+	return progress
+}
+
 // GetHeaderRLP retrieves a block header in its raw RLP database encoding, or nil
 // if the header's not found.
 func GetHeaderRLP(db ethdb.Database, hash common.Hash) rlp.RawValue {
@@ -271,6 +317,15 @@ func WriteHeadFastBlockHash(db ethdb.Database, hash common.Hash) error {
 	return nil
 }
 
+// WriteFastSyncProgress stores the progress of the running fast sync.
+func WriteFastSyncProgress(db ethdb.Database, progress *FastSyncProgress) error {
+	data, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		return err
+	}
+	return db.Put(fastSyncKey, data)
+}
+
 // WriteHeader serializes a block header into the database.
 func WriteHeader(db ethdb.Database, header *types.Header) error {
 	data, err := rlp.EncodeToBytes(header)
@@ -422,6 +477,11 @@ func DeleteCanonicalHash(db ethdb.Database, number uint64) {
 	db.Delete(append(blockNumPrefix, big.NewInt(int64(number)).Bytes()...))
 }
 
+// DeleteFastSyncProgress removes the progress of a finished or abandoned fast sync.
+func DeleteFastSyncProgress(db ethdb.Database) {
+	db.Delete(fastSyncKey)
+}
+
 // DeleteHeader removes all block header data associated with a hash.
 func DeleteHeader(db ethdb.Database, hash common.Hash) {
 	db.Delete(append(append(blockPrefix, hash.Bytes()...), headerSuffix...))
@@ -518,6 +578,299 @@ func GetMipmapBloom(db ethdb.Database, number, level uint64) types.Bloom {
 	return types.BytesToBloom(bloomDat)
 }
 
+// Directions of a transaction relative to an address of the address transaction
+// index.
+const (
+	AddrTxFrom   byte = 'f' // Transaction sent by the address
+	AddrTxTo     byte = 't' // Transaction sent to the address
+	AddrTxCreate byte = 'c' // Transaction creating the contract at the address
+)
+
+// addrTxBookmarkInterval is the number of blocks after which the progress of an
+// address transaction index backfill is saved.
+const addrTxBookmarkInterval = 10000
+
+// AddrTx is an entry of the address transaction index.
+type AddrTx struct {
+	Hash        common.Hash // Hash of the transaction
+	BlockNumber uint64      // Number of the canonical block including the transaction
+	Index       uint        // Index of the transaction in the block
+	Direction   byte        // Direction of the transaction relative to the address
+}
+
+// addrTxKey returns the address transaction index key of a transaction, ordering
+// the entries of an address by block number and transaction index.
+func addrTxKey(address common.Address, number uint64, index uint, direction byte) []byte {
+	key := make([]byte, len(addrTxPrefix)+common.AddressLength+13)
+
+	copy(key, addrTxPrefix)
+	copy(key[len(addrTxPrefix):], address[:])
+	binary.BigEndian.PutUint64(key[len(addrTxPrefix)+common.AddressLength:], number)
+	binary.BigEndian.PutUint32(key[len(addrTxPrefix)+common.AddressLength+8:], uint32(index))
+	key[len(key)-1] = direction
+
+	return key
+}
+
+// addrTxKeys returns the address transaction index keys of all the transactions
+// in a block along with the hashes they map to: one for the sender, and one for
+// either the recipient or the created contract.
+func addrTxKeys(block *types.Block) ([][]byte, []common.Hash, error) {
+	var (
+		keys   [][]byte
+		hashes []common.Hash
+	)
+	for i, tx := range block.Transactions() {
+		from, err := tx.From()
+		if err != nil {
+			return nil, nil, fmt.Errorf("tx %x: %v", tx.Hash(), err)
+		}
+		keys = append(keys, addrTxKey(from, block.NumberU64(), uint(i), AddrTxFrom))
+		if to := tx.To(); to != nil {
+			keys = append(keys, addrTxKey(*to, block.NumberU64(), uint(i), AddrTxTo))
+		} else {
+			keys = append(keys, addrTxKey(crypto.CreateAddress(from, tx.Nonce()), block.NumberU64(), uint(i), AddrTxCreate))
+		}
+		hashes = append(hashes, tx.Hash(), tx.Hash())
+	}
+	return keys, hashes, nil
+}
+
+// WriteAddrTxIndex stores the address transaction index entries of all the
+// transactions in a canonical block.
+func WriteAddrTxIndex(db ethdb.Database, block *types.Block) error {
+	keys, hashes, err := addrTxKeys(block)
+	if err != nil {
+		return err
+	}
+	batch := db.NewBatch()
+	for i, key := range keys {
+		if err := batch.Put(key, hashes[i].Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return fmt.Errorf("address tx index write fail for: %d: %v", block.NumberU64(), err)
+	}
+	return nil
+}
+
+// DeleteAddrTxIndex removes the address transaction index entries of all the
+// transactions in a block dropped from the canonical chain.
+func DeleteAddrTxIndex(db ethdb.Database, block *types.Block) error {
+	keys, _, err := addrTxKeys(block)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		db.Delete(key)
+	}
+	return nil
+}
+
+// GetAddrTxs retrieves the address transaction index entries of an address in
+// the block range [from, to], ordered by block number and transaction index (or
+// in reverse). A zero direction matches all entries. The first skip matching
+// entries are left out, and at most limit are returned. Nil is returned if the
+// database doesn't support iteration.
+func GetAddrTxs(db ethdb.Database, address common.Address, from, to uint64, direction byte, reverse bool, skip, limit int) []AddrTx {
+	iteratee, iterable := db.(ethdb.Iteratee)
+	if !iterable {
+		return nil
+	}
+	prefix := append(append([]byte{}, addrTxPrefix...), address[:]...)
+
+	it := iteratee.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+
+	var (
+		ok   bool
+		next = it.Next
+	)
+	if !reverse {
+		ok = it.Seek(addrTxKey(address, from, 0, 0))
+	} else {
+		// Position on the last entry before the first key past the range
+		next = it.Prev
+		if to == math.MaxUint64 {
+			ok = it.Last()
+		} else if ok = it.Seek(addrTxKey(address, to+1, 0, 0)); ok {
+			ok = it.Prev()
+		} else {
+			ok = it.Last()
+		}
+	}
+	var txs []AddrTx
+	for ; ok && len(txs) < limit; ok = next() {
+		key := it.Key()
+		if len(key) != len(prefix)+13 {
+			continue
+		}
+		entry := AddrTx{
+			Hash:        common.BytesToHash(it.Value()),
+			BlockNumber: binary.BigEndian.Uint64(key[len(prefix):]),
+			Index:       uint(binary.BigEndian.Uint32(key[len(prefix)+8:])),
+			Direction:   key[len(key)-1],
+		}
+		if entry.BlockNumber < from || entry.BlockNumber > to {
+			break
+		}
+		if direction != 0 && entry.Direction != direction {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		txs = append(txs, entry)
+	}
+	return txs
+}
+
+// GetAddrTxIndexBookmark retrieves the number of the next block to backfill into
+// the address transaction index.
+func GetAddrTxIndexBookmark(db ethdb.Database) uint64 {
+	data, _ := db.Get(addrTxBookmarkKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteAddrTxIndexBookmark stores the number of the next block to backfill into
+// the address transaction index.
+func WriteAddrTxIndexBookmark(db ethdb.Database, number uint64) error {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, number)
+	return db.Put(addrTxBookmarkKey, data)
+}
+
+// BuildAddrTxIndex backfills the address transaction index with the canonical
+// blocks in the range [from, to], saving its progress as a bookmark to resume
+// an interrupted run from.
+func BuildAddrTxIndex(db ethdb.Database, from, to uint64) error {
+	start := time.Now()
+	for number := from; number <= to; number++ {
+		block := GetBlock(db, GetCanonicalHash(db, number))
+		if block == nil {
+			return fmt.Errorf("canonical block #%d not found", number)
+		}
+		if err := WriteAddrTxIndex(db, block); err != nil {
+			return err
+		}
+		if number%addrTxBookmarkInterval == 0 || number == to {
+			if err := WriteAddrTxIndexBookmark(db, number+1); err != nil {
+				return err
+			}
+			glog.V(logger.Info).Infof("Address transaction index built up to block #%d in %v", number, time.Since(start))
+		}
+	}
+	return nil
+}
+
+// GetIndexSections retrieves the number of consecutive sections processed from
+// the genesis block by the chain indexer storing its metadata under prefix.
+func GetIndexSections(db ethdb.Database, prefix []byte) uint64 {
+	data, _ := db.Get(append(append([]byte{}, prefix...), indexSectionsSuffix...))
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteIndexSections stores the number of consecutive sections processed from
+// the genesis block by the chain indexer storing its metadata under prefix.
+func WriteIndexSections(batch ethdb.Batch, prefix []byte, sections uint64) error {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, sections)
+	return batch.Put(append(append([]byte{}, prefix...), indexSectionsSuffix...), data)
+}
+
+// indexSectionHeadKey returns the database key of the head of a section
+// processed by the chain indexer storing its metadata under prefix.
+func indexSectionHeadKey(prefix []byte, section uint64) []byte {
+	key := make([]byte, len(prefix)+len(indexHeadSuffix)+8)
+
+	copy(key, prefix)
+	copy(key[len(prefix):], indexHeadSuffix)
+	binary.BigEndian.PutUint64(key[len(prefix)+len(indexHeadSuffix):], section)
+
+	return key
+}
+
+// GetIndexSectionHead retrieves the hash of the last block of a section processed
+// by a chain indexer, or the zero hash if it's not processed.
+func GetIndexSectionHead(db ethdb.Database, prefix []byte, section uint64) common.Hash {
+	data, _ := db.Get(indexSectionHeadKey(prefix, section))
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteIndexSectionHead stores the hash of the last block of a section processed
+// by a chain indexer.
+func WriteIndexSectionHead(batch ethdb.Batch, prefix []byte, section uint64, head common.Hash) error {
+	return batch.Put(indexSectionHeadKey(prefix, section), head.Bytes())
+}
+
+// DeleteIndexSectionHead removes the head hash of a section rolled back by a
+// chain indexer.
+func DeleteIndexSectionHead(db ethdb.Database, prefix []byte, section uint64) {
+	db.Delete(indexSectionHeadKey(prefix, section))
+}
+
+// BloomBitsBlocks is the number of blocks a single bloom bit section vector
+// contains.
+const BloomBitsBlocks uint64 = 4096
+
+// bloomBitsKey returns the database key of a bloom bit vector of a section,
+// distinguishing sections with different heads to survive reorgs.
+func bloomBitsKey(bit uint, section uint64, head common.Hash) []byte {
+	key := make([]byte, len(BloomBitsIndexPrefix)+10+common.HashLength)
+
+	copy(key, BloomBitsIndexPrefix)
+	binary.BigEndian.PutUint16(key[len(BloomBitsIndexPrefix):], uint16(bit))
+	binary.BigEndian.PutUint64(key[len(BloomBitsIndexPrefix)+2:], section)
+	copy(key[len(BloomBitsIndexPrefix)+10:], head[:])
+
+	return key
+}
+
+// GetBloomBits retrieves the bit vector of the given bloom bit for the section
+// with the given head, or nil if it's not found. Note, an all zero vector is
+// stored compressed as an empty value.
+func GetBloomBits(db ethdb.Database, bit uint, section uint64, head common.Hash) []byte {
+	data, err := db.Get(bloomBitsKey(bit, section, head))
+	if err != nil {
+		return nil
+	}
+	bits, err := bitutil.DecompressBytes(data, int(BloomBitsBlocks/8))
+	if err != nil {
+		glog.V(logger.Error).Infof("invalid bloom bits of section %d, bit %d: %v", section, bit, err)
+		return nil
+	}
+	return bits
+}
+
+// WriteBloomBits stores the compressed bit vector of the given bloom bit for the
+// section with the given head.
+func WriteBloomBits(batch ethdb.Batch, bit uint, section uint64, head common.Hash, bits []byte) error {
+	return batch.Put(bloomBitsKey(bit, section, head), bitutil.CompressBytes(bits))
+}
+
+// GetBloomBitsSectionHead retrieves the hash of the last block of an indexed
+// bloom bits section, or the zero hash if it's not indexed.
+func GetBloomBitsSectionHead(db ethdb.Database, section uint64) common.Hash {
+	return GetIndexSectionHead(db, BloomBitsIndexPrefix, section)
+}
+
+// GetBloomBitsSections retrieves the number of consecutive bloom bits sections
+// indexed from the genesis block.
+func GetBloomBitsSections(db ethdb.Database) uint64 {
+	return GetIndexSections(db, BloomBitsIndexPrefix)
+}
+
 // GetBlockChainVersion reads the version number from db.
 func GetBlockChainVersion(db ethdb.Database) int {
 	var vsn uint
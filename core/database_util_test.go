@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"math/big"
 	"os"
 	"strconv"
@@ -363,6 +364,64 @@ func TestHeadStorage(t *testing.T) {
 	}
 }
 
+// Tests that the progress of an interrupted fast sync can be stored, retrieved
+// and deleted.
+func TestFastSyncProgressStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	if entry := GetFastSyncProgress(db); entry != nil {
+		t.Fatalf("Non fast sync progress returned: %v", entry)
+	}
+	progress := &FastSyncProgress{
+		Pivot: &types.Header{Number: big.NewInt(314), Extra: []byte("test pivot header")},
+		Nodes: [][]byte{[]byte("node one"), []byte("node two")},
+		Results: []*FastSyncResult{{
+			Header:       &types.Header{Number: big.NewInt(313), Extra: []byte("test result header")},
+			Uncles:       []*types.Header{{Number: big.NewInt(312), Extra: []byte("test uncle header")}},
+			Transactions: types.Transactions{types.NewTransaction(1, common.BytesToAddress([]byte{0x11}), big.NewInt(111), big.NewInt(1111), big.NewInt(11111), []byte{0x11, 0x11, 0x11})},
+			Receipts:     types.Receipts{types.NewReceipt([]byte{0x01}, big.NewInt(21000))},
+		}},
+	}
+	if err := WriteFastSyncProgress(db, progress); err != nil {
+		t.Fatalf("Failed to write fast sync progress: %v", err)
+	}
+	entry := GetFastSyncProgress(db)
+	if entry == nil {
+		t.Fatalf("Stored fast sync progress not found")
+	}
+	if entry.Pivot.Hash() != progress.Pivot.Hash() {
+		t.Fatalf("Pivot mismatch: have %x, want %x", entry.Pivot.Hash(), progress.Pivot.Hash())
+	}
+	if len(entry.Nodes) != len(progress.Nodes) {
+		t.Fatalf("Node count mismatch: have %d, want %d", len(entry.Nodes), len(progress.Nodes))
+	}
+	for i, node := range entry.Nodes {
+		if !bytes.Equal(node, progress.Nodes[i]) {
+			t.Fatalf("Node %d mismatch: have %x, want %x", i, node, progress.Nodes[i])
+		}
+	}
+	if len(entry.Results) != len(progress.Results) {
+		t.Fatalf("Result count mismatch: have %d, want %d", len(entry.Results), len(progress.Results))
+	}
+	have, want := entry.Results[0], progress.Results[0]
+	if have.Header.Hash() != want.Header.Hash() {
+		t.Fatalf("Result header mismatch: have %x, want %x", have.Header.Hash(), want.Header.Hash())
+	}
+	if types.CalcUncleHash(have.Uncles) != types.CalcUncleHash(want.Uncles) {
+		t.Fatalf("Result uncles mismatch: have %v, want %v", have.Uncles, want.Uncles)
+	}
+	if types.DeriveSha(have.Transactions) != types.DeriveSha(want.Transactions) {
+		t.Fatalf("Result transactions mismatch: have %v, want %v", have.Transactions, want.Transactions)
+	}
+	if types.DeriveSha(have.Receipts) != types.DeriveSha(want.Receipts) {
+		t.Fatalf("Result receipts mismatch: have %v, want %v", have.Receipts, want.Receipts)
+	}
+	DeleteFastSyncProgress(db)
+	if entry := GetFastSyncProgress(db); entry != nil {
+		t.Fatalf("Deleted fast sync progress returned: %v", entry)
+	}
+}
+
 // Tests that transactions and associated metadata can be stored and retrieved.
 func TestTransactionStorage(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
@@ -628,3 +687,114 @@ func TestMipmapChain(t *testing.T) {
 		t.Error("address was included in bloom and should not have")
 	}
 }
+
+// Tests bloom bits section storage and retrieval operations.
+func TestBloomBitsStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	head, other := common.HexToHash("0x01"), common.HexToHash("0x02")
+	bits := make([]byte, BloomBitsBlocks/8)
+	bits[3], bits[100] = 0x80, 0x01
+
+	if stored := GetBloomBits(db, 7, 1, head); stored != nil {
+		t.Fatalf("non existent bloom bits returned: %x", stored)
+	}
+	batch := db.NewBatch()
+	if err := WriteBloomBits(batch, 7, 1, head, bits); err != nil {
+		t.Fatalf("failed to write bloom bits: %v", err)
+	}
+	if err := WriteIndexSectionHead(batch, BloomBitsIndexPrefix, 1, head); err != nil {
+		t.Fatalf("failed to write section head: %v", err)
+	}
+	if err := WriteIndexSections(batch, BloomBitsIndexPrefix, 2); err != nil {
+		t.Fatalf("failed to write section count: %v", err)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to flush batch: %v", err)
+	}
+	if stored := GetBloomBits(db, 7, 1, head); !bytes.Equal(stored, bits) {
+		t.Fatalf("bloom bits mismatch: have %x, want %x", stored, bits)
+	}
+	if stored := GetBloomBits(db, 7, 1, other); stored != nil {
+		t.Fatalf("bloom bits of different section head returned: %x", stored)
+	}
+	// All zero vectors compress to nothing but must still be found
+	batch = db.NewBatch()
+	WriteBloomBits(batch, 8, 1, head, make([]byte, BloomBitsBlocks/8))
+	batch.Write()
+	if stored := GetBloomBits(db, 8, 1, head); stored == nil || !bytes.Equal(stored, make([]byte, BloomBitsBlocks/8)) {
+		t.Fatalf("zero bloom bits mismatch: have %x", stored)
+	}
+	if stored := GetBloomBitsSectionHead(db, 1); stored != head {
+		t.Fatalf("section head mismatch: have %x, want %x", stored, head)
+	}
+	if stored := GetBloomBitsSections(db); stored != 2 {
+		t.Fatalf("section count mismatch: have %d, want %d", stored, 2)
+	}
+}
+
+// Tests address transaction index storage, querying and removal.
+func TestAddrTxIndexStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		key2, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = crypto.PubkeyToAddress(key2.PublicKey)
+		signer  = types.NewChainIdSigner(big.NewInt(63))
+	)
+	// Create a few blocks with transfers in both directions and a contract creation
+	var blocks []*types.Block
+	for i := 0; i < 4; i++ {
+		tx1, _ := types.NewTransaction(uint64(i), addr2, big.NewInt(1), big.NewInt(21000), nil, nil).WithSigner(signer).SignECDSA(key1)
+		tx2, _ := types.NewTransaction(uint64(i), addr1, big.NewInt(1), big.NewInt(21000), nil, nil).WithSigner(signer).SignECDSA(key2)
+		txs := []*types.Transaction{tx1, tx2}
+		if i == 2 {
+			create, _ := types.NewContractCreation(uint64(i+1), big.NewInt(0), big.NewInt(100000), big.NewInt(1), nil).WithSigner(signer).SignECDSA(key1)
+			txs = append(txs, create)
+		}
+		block := types.NewBlock(&types.Header{Number: big.NewInt(int64(i + 1))}, txs, nil, nil)
+		if err := WriteAddrTxIndex(db, block); err != nil {
+			t.Fatalf("block %d: failed to index transactions: %v", i+1, err)
+		}
+		blocks = append(blocks, block)
+	}
+	// Check the full listing and the filtered ones of an address
+	all := GetAddrTxs(db, addr1, 0, math.MaxUint64, 0, false, 0, 100)
+	if len(all) != 9 {
+		t.Fatalf("address tx count mismatch: have %d, want %d", len(all), 9)
+	}
+	for i := 1; i < len(all); i++ {
+		if prev, cur := all[i-1], all[i]; prev.BlockNumber > cur.BlockNumber || (prev.BlockNumber == cur.BlockNumber && prev.Index > cur.Index) {
+			t.Fatalf("entry %d out of order: %+v after %+v", i, cur, prev)
+		}
+	}
+	if sent := GetAddrTxs(db, addr1, 0, math.MaxUint64, AddrTxFrom, false, 0, 100); len(sent) != 5 {
+		t.Fatalf("sent tx count mismatch: have %d, want %d", len(sent), 5)
+	}
+	if recv := GetAddrTxs(db, addr1, 0, math.MaxUint64, AddrTxTo, false, 0, 100); len(recv) != 4 {
+		t.Fatalf("received tx count mismatch: have %d, want %d", len(recv), 4)
+	}
+	contract := crypto.CreateAddress(addr1, 3)
+	if created := GetAddrTxs(db, contract, 0, math.MaxUint64, 0, false, 0, 100); len(created) != 1 || created[0].Direction != AddrTxCreate || created[0].Hash != blocks[2].Transactions()[2].Hash() {
+		t.Fatalf("contract creation mismatch: have %+v", created)
+	}
+	// Check block ranges, pagination and reverse ordering
+	if ranged := GetAddrTxs(db, addr1, 2, 3, 0, false, 0, 100); len(ranged) != 5 || ranged[0].BlockNumber != 2 || ranged[4].BlockNumber != 3 {
+		t.Fatalf("ranged query mismatch: have %+v", ranged)
+	}
+	if page := GetAddrTxs(db, addr1, 0, math.MaxUint64, 0, false, 3, 2); len(page) != 2 || page[0] != all[3] || page[1] != all[4] {
+		t.Fatalf("page mismatch: have %+v, want %+v", page, all[3:5])
+	}
+	if page := GetAddrTxs(db, addr1, 0, 3, 0, true, 1, 2); len(page) != 2 || page[0] != all[5] || page[1] != all[4] {
+		t.Fatalf("reverse page mismatch: have %+v, want %+v", page, []AddrTx{all[5], all[4]})
+	}
+	// Remove a block and make sure its entries are gone
+	if err := DeleteAddrTxIndex(db, blocks[3]); err != nil {
+		t.Fatalf("failed to unindex transactions: %v", err)
+	}
+	if remaining := GetAddrTxs(db, addr2, 0, math.MaxUint64, 0, false, 0, 100); len(remaining) != 6 {
+		t.Fatalf("remaining tx count mismatch: have %d, want %d", len(remaining), 6)
+	}
+}
@@ -47,9 +47,14 @@ type NewMinedBlockEvent struct{ Block *types.Block }
 // RemovedTransactionEvent is posted when a reorg happens
 type RemovedTransactionEvent struct{ Txs types.Transactions }
 
-// RemovedLogEvent is posted when a reorg happens
+// RemovedLogEvent is posted when a reorg happens, listing the logs of the
+// dropped blocks in the reverse order they were originally posted in.
 type RemovedLogsEvent struct{ Logs vm.Logs }
 
+// RemovedBlocksEvent is posted when a reorg happens, listing the blocks dropped
+// from the canonical chain, newest first.
+type RemovedBlocksEvent struct{ Blocks types.Blocks }
+
 // ChainSplit is posted when a new head is detected
 type ChainSplitEvent struct {
 	Block *types.Block
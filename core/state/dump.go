@@ -18,6 +18,7 @@ package state
 
 import (
 	"bytes"
+	"context"
 	"sync"
 	"sort"
 	"bufio"
@@ -55,6 +56,13 @@ func lookupAddress(addr common.Address, addresses []common.Address) bool {
 }
 
 func (self *StateDB) RawDump(addresses []common.Address) Dump {
+	dump, _ := self.RawDumpContext(context.Background(), addresses)
+	return dump
+}
+
+// RawDumpContext is like RawDump, but aborts with the error of the context if
+// it is cancelled before all the accounts are dumped.
+func (self *StateDB) RawDumpContext(ctx context.Context, addresses []common.Address) (Dump, error) {
 
 	dump := Dump{
 		Root:     common.Bytes2Hex(self.trie.Root()),
@@ -63,6 +71,9 @@ func (self *StateDB) RawDump(addresses []common.Address) Dump {
 
 	it := self.trie.Iterator()
 	for it.Next() {
+		if err := ctx.Err(); err != nil {
+			return Dump{}, err
+		}
 		addr := self.trie.GetKey(it.Key)
 		addrA := common.BytesToAddress(addr)
 
@@ -94,7 +105,7 @@ func (self *StateDB) RawDump(addresses []common.Address) Dump {
 		}
 		dump.Accounts[common.Bytes2Hex(addr)] = account
 	}
-	return dump
+	return dump, nil
 }
 
 const ZipperBlockLength = 1*1024*1024
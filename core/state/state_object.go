@@ -186,6 +186,22 @@ func (self *StateObject) setState(key, value common.Hash) {
 	}
 }
 
+// setStorage replaces the storage of the object, starting over from an empty
+// storage trie.
+func (self *StateObject) setStorage(db trie.Database, storage map[common.Hash]common.Hash) {
+	self.trie, _ = trie.NewSecure(common.Hash{}, db, 0)
+	self.cachedStorage = make(Storage)
+	self.dirtyStorage = make(Storage)
+	for key, value := range storage {
+		self.cachedStorage[key] = value
+		self.dirtyStorage[key] = value
+	}
+	if self.onDirty != nil {
+		self.onDirty(self.Address())
+		self.onDirty = nil
+	}
+}
+
 // updateTrie writes cached storage modifications into the object's storage trie.
 func (self *StateObject) updateTrie(db trie.Database) {
 	tr := self.getTrie(db)
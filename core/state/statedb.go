@@ -281,6 +281,34 @@ func (self *StateDB) GetState(a common.Address, b common.Hash) common.Hash {
 	return common.Hash{}
 }
 
+// GetStorageRoot returns the root hash of the storage trie of an account, or
+// the zero hash if the account doesn't exist.
+func (self *StateDB) GetStorageRoot(addr common.Address) common.Hash {
+	stateObject := self.GetStateObject(addr)
+	if stateObject == nil {
+		return common.Hash{}
+	}
+	return stateObject.data.Root
+}
+
+// GetProof returns the Merkle proof of an account in the state trie, or nil if
+// the trie nodes on its path are missing. Proofs are only valid for a state
+// without uncommitted changes.
+func (self *StateDB) GetProof(addr common.Address) []rlp.RawValue {
+	return self.trie.Prove(addr[:])
+}
+
+// GetStorageProof returns the Merkle proof of a slot in the storage trie of an
+// account, or nil if the account doesn't exist or the trie nodes on the path
+// are missing.
+func (self *StateDB) GetStorageProof(addr common.Address, key common.Hash) []rlp.RawValue {
+	stateObject := self.GetStateObject(addr)
+	if stateObject == nil {
+		return nil
+	}
+	return stateObject.getTrie(self.db).Prove(key[:])
+}
+
 func (self *StateDB) HasSuicided(addr common.Address) bool {
 	stateObject := self.GetStateObject(addr)
 	if stateObject != nil {
@@ -328,6 +356,16 @@ func (self *StateDB) SetState(addr common.Address, key common.Hash, value common
 	}
 }
 
+// SetStorage replaces the entire storage of an account with the given slots,
+// dropping all others. It's meant for overriding state ahead of simulating
+// calls and, unlike the other setters, can't be reverted to a snapshot.
+func (self *StateDB) SetStorage(addr common.Address, storage map[common.Hash]common.Hash) {
+	stateObject := self.GetOrNewStateObject(addr)
+	if stateObject != nil {
+		stateObject.setStorage(self.db, storage)
+	}
+}
+
 // Suicide marks the given account as suicided.
 // This clears the account balance.
 //
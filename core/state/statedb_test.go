@@ -30,7 +30,10 @@ import (
 
 	"github.com/ethereumproject/go-ethereum/common"
 	"github.com/ethereumproject/go-ethereum/core/vm"
+	"github.com/ethereumproject/go-ethereum/crypto"
 	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/rlp"
+	"github.com/ethereumproject/go-ethereum/trie"
 )
 
 // Tests that updating a state trie does not leak any database writes prior to
@@ -60,6 +63,86 @@ func TestUpdateLeaks(t *testing.T) {
 	}
 }
 
+// Tests that account and storage proofs of a committed state verify against
+// its root and the account's storage root.
+func TestProofs(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, db)
+	for i := byte(0); i < 255; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		state.AddBalance(addr, big.NewInt(int64(11*i)+1))
+		state.SetState(addr, common.BytesToHash([]byte{i}), common.BytesToHash([]byte{i, i}))
+	}
+	root, err := state.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	state, _ = New(root, db)
+
+	addr, key := common.BytesToAddress([]byte{42}), common.BytesToHash([]byte{42})
+	enc, err := trie.VerifyProof(root, crypto.Keccak256(addr[:]), state.GetProof(addr))
+	if err != nil {
+		t.Fatalf("account proof failed to verify: %v", err)
+	}
+	var account Account
+	if err := rlp.DecodeBytes(enc, &account); err != nil {
+		t.Fatalf("failed to decode proven account: %v", err)
+	}
+	if account.Balance.Cmp(state.GetBalance(addr)) != 0 || account.Root != state.GetStorageRoot(addr) {
+		t.Errorf("proven account mismatch: have %+v", account)
+	}
+	enc, err = trie.VerifyProof(account.Root, crypto.Keccak256(key[:]), state.GetStorageProof(addr, key))
+	if err != nil {
+		t.Fatalf("storage proof failed to verify: %v", err)
+	}
+	_, content, _, err := rlp.Split(enc)
+	if err != nil {
+		t.Fatalf("failed to decode proven slot: %v", err)
+	}
+	if have, want := common.BytesToHash(content), state.GetState(addr, key); have != want {
+		t.Errorf("proven slot mismatch: have %x, want %x", have, want)
+	}
+	// Absent accounts have proofs of their absence, but no storage proofs
+	missing := common.BytesToAddress([]byte{1, 0, 0})
+	if enc, err := trie.VerifyProof(root, crypto.Keccak256(missing[:]), state.GetProof(missing)); err != nil || enc != nil {
+		t.Errorf("absent account proof: have %x, %v", enc, err)
+	}
+	if proof := state.GetStorageProof(missing, key); proof != nil {
+		t.Errorf("absent account has storage proof: %x", proof)
+	}
+}
+
+// Tests that replacing the storage of an account drops all its previous slots.
+func TestSetStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, db)
+
+	addr := common.BytesToAddress([]byte{1})
+	a, b, c := common.BytesToHash([]byte{1}), common.BytesToHash([]byte{2}), common.BytesToHash([]byte{3})
+	state.SetState(addr, a, a)
+	state.SetState(addr, b, b)
+	root, _ := state.Commit()
+
+	state, _ = New(root, db)
+	state.SetStorage(addr, map[common.Hash]common.Hash{b: c, c: c})
+	check := func(state *StateDB) {
+		for key, want := range map[common.Hash]common.Hash{a: {}, b: c, c: c} {
+			if have := state.GetState(addr, key); have != want {
+				t.Errorf("slot %x: have %x, want %x", key, have, want)
+			}
+		}
+	}
+	check(state)
+
+	// Make sure the replaced storage is what gets committed
+	root, err := state.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	state, _ = New(root, db)
+	check(state)
+}
+
 // Tests that no intermediate state of an object is stored into the database,
 // only the one right before the commit.
 func TestIntermediateLeaks(t *testing.T) {
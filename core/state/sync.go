@@ -68,3 +68,13 @@ func (s *StateSync) Process(list []trie.SyncResult) (int, error) {
 func (s *StateSync) Pending() int {
 	return (*trie.TrieSync)(s).Pending()
 }
+
+// Uncommitted retrieves the state nodes retrieved but not yet stored.
+func (s *StateSync) Uncommitted() [][]byte {
+	return (*trie.TrieSync)(s).Uncommitted()
+}
+
+// Restore injects the uncommitted nodes of an interrupted sync of the same state.
+func (s *StateSync) Restore(nodes [][]byte) (int, error) {
+	return (*trie.TrieSync)(s).Restore(nodes)
+}
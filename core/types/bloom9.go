@@ -25,9 +25,15 @@ import (
 	"github.com/ethereumproject/go-ethereum/crypto"
 )
 
-const bloomLength = 256
+const (
+	// BloomByteLength represents the number of bytes used in a header log bloom.
+	BloomByteLength = 256
 
-type Bloom [bloomLength]byte
+	// BloomBitLength represents the number of bits used in a header log bloom.
+	BloomBitLength = 8 * BloomByteLength
+)
+
+type Bloom [BloomByteLength]byte
 
 func BytesToBloom(b []byte) Bloom {
 	var bloom Bloom
@@ -40,7 +46,7 @@ func (b *Bloom) SetBytes(d []byte) {
 		panic(fmt.Sprintf("bloom bytes too big %d %d", len(b), len(d)))
 	}
 
-	copy(b[bloomLength-len(d):], d)
+	copy(b[BloomByteLength-len(d):], d)
 }
 
 func (b *Bloom) Add(d *big.Int) {
@@ -132,6 +132,11 @@ func NewChainIdSigner(chainId *big.Int) ChainIdSigner {
 	}
 }
 
+// ChainId returns the chain identifier the signer protects transactions with.
+func (s ChainIdSigner) ChainId() *big.Int {
+	return new(big.Int).Set(s.chainId)
+}
+
 func (s ChainIdSigner) Equal(s2 Signer) bool {
 	other, ok := s2.(ChainIdSigner)
 	if !ok {
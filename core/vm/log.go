@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
 
 	"github.com/ethereumproject/go-ethereum/common"
 	"github.com/ethereumproject/go-ethereum/rlp"
@@ -37,6 +38,10 @@ type Log struct {
 	TxIndex     uint
 	BlockHash   common.Hash
 	Index       uint
+
+	// Removed is set if the log was reverted by a chain reorganisation, when
+	// delivered by a log filter. It is not stored.
+	Removed bool
 }
 
 func NewLog(address common.Address, topics []common.Hash, data []byte, number uint64) *Log {
@@ -74,14 +79,116 @@ func (r *Log) MarshalJSON() ([]byte, error) {
 		"transactionHash":  r.TxHash,
 		"transactionIndex": fmt.Sprintf("%#x", r.TxIndex),
 		"topics":           r.Topics,
+		"removed":          r.Removed,
 	}
 
 	return json.Marshal(fields)
 }
 
+// UnmarshalJSON parses a log in the format produced by MarshalJSON, as served
+// by the RPC APIs.
+func (r *Log) UnmarshalJSON(input []byte) error {
+	var dec struct {
+		Address     common.Address `json:"address"`
+		Topics      []common.Hash  `json:"topics"`
+		Data        string         `json:"data"`
+		BlockNumber string         `json:"blockNumber"`
+		TxHash      common.Hash    `json:"transactionHash"`
+		TxIndex     string         `json:"transactionIndex"`
+		BlockHash   common.Hash    `json:"blockHash"`
+		Index       string         `json:"logIndex"`
+		Removed     bool           `json:"removed"`
+	}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	number, err := parseHexUint(dec.BlockNumber)
+	if err != nil {
+		return fmt.Errorf("invalid block number: %v", err)
+	}
+	txIndex, err := parseHexUint(dec.TxIndex)
+	if err != nil {
+		return fmt.Errorf("invalid transaction index: %v", err)
+	}
+	index, err := parseHexUint(dec.Index)
+	if err != nil {
+		return fmt.Errorf("invalid log index: %v", err)
+	}
+	*r = Log{
+		Address:     dec.Address,
+		Topics:      dec.Topics,
+		Data:        common.FromHex(dec.Data),
+		BlockNumber: number,
+		TxHash:      dec.TxHash,
+		TxIndex:     uint(txIndex),
+		BlockHash:   dec.BlockHash,
+		Index:       uint(index),
+		Removed:     dec.Removed,
+	}
+	return nil
+}
+
+// parseHexUint parses a 0x prefixed hex quantity, treating a missing one (e.g.
+// of a pending log) as zero.
+func parseHexUint(input string) (uint64, error) {
+	if input == "" {
+		return 0, nil
+	}
+	if len(input) < 2 || input[0] != '0' || (input[1] != 'x' && input[1] != 'X') {
+		return 0, fmt.Errorf("hex string without 0x prefix: %q", input)
+	}
+	return strconv.ParseUint(input[2:], 16, 64)
+}
+
 type Logs []*Log
 
 // LogForStorage is a wrapper around a Log that flattens and parses the entire
 // content of a log, as opposed to only the consensus fields originally (by hiding
 // the rlp interface methods).
 type LogForStorage Log
+
+// storedLog is the storage encoding of a log, leaving out the fields which only
+// make sense while the log is delivered.
+type storedLog struct {
+	Address     common.Address
+	Topics      []common.Hash
+	Data        []byte
+	BlockNumber uint64
+	TxHash      common.Hash
+	TxIndex     uint
+	BlockHash   common.Hash
+	Index       uint
+}
+
+// EncodeRLP implements rlp.Encoder.
+func (l *LogForStorage) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, &storedLog{
+		Address:     l.Address,
+		Topics:      l.Topics,
+		Data:        l.Data,
+		BlockNumber: l.BlockNumber,
+		TxHash:      l.TxHash,
+		TxIndex:     l.TxIndex,
+		BlockHash:   l.BlockHash,
+		Index:       l.Index,
+	})
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (l *LogForStorage) DecodeRLP(s *rlp.Stream) error {
+	var dec storedLog
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	*l = LogForStorage{
+		Address:     dec.Address,
+		Topics:      dec.Topics,
+		Data:        dec.Data,
+		BlockNumber: dec.BlockNumber,
+		TxHash:      dec.TxHash,
+		TxIndex:     dec.TxIndex,
+		BlockHash:   dec.BlockHash,
+		Index:       dec.Index,
+	}
+	return nil
+}
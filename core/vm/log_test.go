@@ -0,0 +1,81 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/rlp"
+)
+
+// Tests that logs survive a round trip through their RPC JSON representation.
+func TestLogJSONRoundTrip(t *testing.T) {
+	log := &Log{
+		Address:     common.HexToAddress("0x1000000000000000000000000000000000000001"),
+		Topics:      []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")},
+		Data:        []byte{0xde, 0xad, 0xbe, 0xef},
+		BlockNumber: 1234,
+		TxHash:      common.HexToHash("0x03"),
+		TxIndex:     5,
+		BlockHash:   common.HexToHash("0x04"),
+		Index:       6,
+		Removed:     true,
+	}
+	blob, err := json.Marshal(log)
+	if err != nil {
+		t.Fatalf("failed to marshal log: %v", err)
+	}
+	decoded := new(Log)
+	if err := json.Unmarshal(blob, decoded); err != nil {
+		t.Fatalf("failed to unmarshal log: %v", err)
+	}
+	if !reflect.DeepEqual(log, decoded) {
+		t.Errorf("log mismatch: have %v, want %v", decoded, log)
+	}
+}
+
+// Tests that the storage encoding of logs keeps the derived fields, but not the
+// delivery flags.
+func TestLogStorageRLP(t *testing.T) {
+	log := &Log{
+		Address:     common.HexToAddress("0x1000000000000000000000000000000000000001"),
+		Topics:      []common.Hash{common.HexToHash("0x01")},
+		Data:        []byte{0xca, 0xfe},
+		BlockNumber: 1234,
+		TxHash:      common.HexToHash("0x03"),
+		TxIndex:     5,
+		BlockHash:   common.HexToHash("0x04"),
+		Index:       6,
+		Removed:     true,
+	}
+	blob, err := rlp.EncodeToBytes((*LogForStorage)(log))
+	if err != nil {
+		t.Fatalf("failed to encode log: %v", err)
+	}
+	decoded := new(LogForStorage)
+	if err := rlp.DecodeBytes(blob, decoded); err != nil {
+		t.Fatalf("failed to decode log: %v", err)
+	}
+	want := *log
+	want.Removed = false
+	if !reflect.DeepEqual((*Log)(decoded), &want) {
+		t.Errorf("log mismatch: have %v, want %v", (*Log)(decoded), &want)
+	}
+}
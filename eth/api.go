@@ -32,6 +32,7 @@ import (
 
 	"github.com/ethereumproject/ethash"
 	"github.com/ethereumproject/go-ethereum/accounts"
+	"github.com/ethereumproject/go-ethereum/accounts/abi"
 	"github.com/ethereumproject/go-ethereum/common"
 	"github.com/ethereumproject/go-ethereum/common/compiler"
 	"github.com/ethereumproject/go-ethereum/core"
@@ -415,21 +416,23 @@ func (s *PublicAccountAPI) Accounts() []accounts.Account {
 // It offers methods to create, (un)lock en list accounts. Some methods accept
 // passwords and are therefore considered private by default.
 type PrivateAccountAPI struct {
-	bc     *core.BlockChain
-	am     *accounts.Manager
-	txPool *core.TxPool
-	txMu   *sync.Mutex
-	gpo    *GasPriceOracle
+	bc      *core.BlockChain
+	chainDb ethdb.Database
+	am      *accounts.Manager
+	txPool  *core.TxPool
+	txMu    *sync.Mutex
+	gpo     *GasPriceOracle
 }
 
 // NewPrivateAccountAPI create a new PrivateAccountAPI.
 func NewPrivateAccountAPI(e *Ethereum) *PrivateAccountAPI {
 	return &PrivateAccountAPI{
-		bc:     e.blockchain,
-		am:     e.accountManager,
-		txPool: e.txPool,
-		txMu:   &e.txMu,
-		gpo:    e.gpo,
+		bc:      e.blockchain,
+		chainDb: e.chainDb,
+		am:      e.accountManager,
+		txPool:  e.txPool,
+		txMu:    &e.txMu,
+		gpo:     e.gpo,
 	}
 }
 
@@ -464,6 +467,102 @@ func (s *PrivateAccountAPI) ImportRawKey(privkey string, password string) (commo
 	return acc.Address, err
 }
 
+// ImportMnemonic imports the given BIP39 mnemonic as the HD wallet of the node,
+// encrypting it with the password, and returns the address of its first account.
+func (s *PrivateAccountAPI) ImportMnemonic(mnemonic string, password string) (common.Address, error) {
+	acc, err := s.am.ImportMnemonic(mnemonic, password)
+	return acc.Address, err
+}
+
+// DeriveAccount derives the next account of the HD wallet and returns its address.
+func (s *PrivateAccountAPI) DeriveAccount() (common.Address, error) {
+	acc, err := s.am.DeriveHDAccount()
+	return acc.Address, err
+}
+
+// SelfDeriveAccounts derives the accounts of the HD wallet already used on the
+// local chain and returns their addresses.
+func (s *PrivateAccountAPI) SelfDeriveAccounts() ([]common.Address, error) {
+	statedb, err := s.bc.State()
+	if err != nil {
+		return nil, err
+	}
+	derived, err := s.am.SelfDeriveHDAccounts(stateReader{statedb})
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]common.Address, len(derived))
+	for i, acc := range derived {
+		addresses[i] = acc.Address
+	}
+	return addresses, nil
+}
+
+// WalletStatus describes a wallet managed by this node.
+type WalletStatus struct {
+	URL      string           `json:"url"`
+	Status   string           `json:"status"`
+	Accounts []common.Address `json:"accounts"`
+}
+
+// ListWallets returns the wallets this node manages, including those of
+// hardware devices, with the accounts derived from them so far.
+func (s *PrivateAccountAPI) ListWallets() []WalletStatus {
+	wallets := s.am.Wallets()
+	statuses := make([]WalletStatus, len(wallets))
+	for i, wallet := range wallets {
+		accs := wallet.Accounts()
+		addresses := make([]common.Address, len(accs))
+		for j, acc := range accs {
+			addresses[j] = acc.Address
+		}
+		statuses[i] = WalletStatus{URL: wallet.URL(), Status: wallet.Status(), Accounts: addresses}
+	}
+	return statuses
+}
+
+// OpenWallet opens the wallet with the given URL, e.g. connecting to a hardware
+// device. The passphrase is only used by wallets requiring one.
+func (s *PrivateAccountAPI) OpenWallet(url string, passphrase *string) error {
+	wallet, err := s.am.Wallet(url)
+	if err != nil {
+		return err
+	}
+	var pass string
+	if passphrase != nil {
+		pass = *passphrase
+	}
+	return wallet.Open(pass)
+}
+
+// DeriveWalletAccount derives the account at the given path, e.g. m/44'/61'/0'/0/0,
+// from the opened wallet with the given URL and returns its address.
+func (s *PrivateAccountAPI) DeriveWalletAccount(url string, path string) (common.Address, error) {
+	wallet, err := s.am.Wallet(url)
+	if err != nil {
+		return common.Address{}, err
+	}
+	derivPath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return common.Address{}, err
+	}
+	acc, err := wallet.Derive(derivPath)
+	return acc.Address, err
+}
+
+// stateReader implements accounts.ChainStateReader on top of a state database.
+type stateReader struct {
+	statedb *state.StateDB
+}
+
+func (r stateReader) BalanceAt(account common.Address) (*big.Int, error) {
+	return r.statedb.GetBalance(account), nil
+}
+
+func (r stateReader) NonceAt(account common.Address) (uint64, error) {
+	return r.statedb.GetNonce(account), nil
+}
+
 // UnlockAccount will unlock the account associated with the given address with
 // the given password for duration seconds. If duration is nil it will use a
 // default of 300 seconds. It returns an indication if the account was unlocked.
@@ -504,14 +603,46 @@ func (s *PrivateAccountAPI) SignAndSendTransaction(args SendTxArgs, passwd strin
 		tx = types.NewTransaction(args.Nonce.Uint64(), *args.To, args.Value.BigInt(), args.Gas.BigInt(), args.GasPrice.BigInt(), common.FromHex(args.Data))
 	}
 
-	tx.SetSigner(s.bc.Config().GetSigner(s.bc.CurrentBlock().Number()))
+	signer := s.bc.Config().GetSigner(s.bc.CurrentBlock().Number())
 
-	signature, err := s.am.SignWithPassphrase(args.From, passwd, tx.SigHash().Bytes())
+	signedTx, err := s.am.SignTxWithPassphrase(accounts.Account{Address: args.From}, passwd, tx, signer)
 	if err != nil {
 		return common.Hash{}, err
 	}
 
-	return submitTransaction(s.bc, s.txPool, tx, signature)
+	return submitTransaction(s.txPool, signedTx)
+}
+
+// Sign calculates an Ethereum specific signature of the hex encoded data with the
+// key of addr, decrypted with passwd. The data is prefixed as in accounts.TextHash,
+// so the signature can't be abused as the signature of a transaction. The V of the
+// returned signature is 27 or 28.
+func (s *PrivateAccountAPI) Sign(data string, addr common.Address, passwd string) (string, error) {
+	signature, err := s.am.SignWithPassphrase(addr, passwd, accounts.TextHash(common.FromHex(data)))
+	if err != nil {
+		return "", err
+	}
+	signature[64] += 27
+	return common.ToHex(signature), nil
+}
+
+// EcRecover returns the address of the account that created the signature of the
+// hex encoded data with personal_sign or eth_sign.
+func (s *PrivateAccountAPI) EcRecover(data string, sig string) (common.Address, error) {
+	signature := common.FromHex(sig)
+	if len(signature) != 65 {
+		return common.Address{}, fmt.Errorf("signature must be 65 bytes long")
+	}
+	if signature[64] != 27 && signature[64] != 28 {
+		return common.Address{}, fmt.Errorf("invalid Ethereum signature (V is not 27 or 28)")
+	}
+	signature[64] -= 27
+
+	pub, err := crypto.SigToPub(accounts.TextHash(common.FromHex(data)), signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
 }
 
 // PublicBlockChainAPI provides an API to access the Ethereum blockchain.
@@ -521,8 +652,8 @@ type PublicBlockChainAPI struct {
 	bc                      *core.BlockChain
 	chainDb                 ethdb.Database
 	eventMux                *event.TypeMux
-	muNewBlockSubscriptions sync.Mutex                             // protects newBlocksSubscriptions
-	newBlockSubscriptions   map[string]func(core.ChainEvent) error // callbacks for new block subscriptions
+	muNewBlockSubscriptions sync.Mutex                                // protects newBlocksSubscriptions
+	newBlockSubscriptions   map[string]func(*types.Block, bool) error // callbacks for new and removed blocks
 	am                      *accounts.Manager
 	miner                   *miner.Miner
 	gpo                     *GasPriceOracle
@@ -537,7 +668,7 @@ func NewPublicBlockChainAPI(config *core.ChainConfig, bc *core.BlockChain, m *mi
 		chainDb:  chainDb,
 		eventMux: eventMux,
 		am:       am,
-		newBlockSubscriptions: make(map[string]func(core.ChainEvent) error),
+		newBlockSubscriptions: make(map[string]func(*types.Block, bool) error),
 		gpo: gpo,
 	}
 
@@ -547,17 +678,30 @@ func NewPublicBlockChainAPI(config *core.ChainConfig, bc *core.BlockChain, m *mi
 }
 
 // subscriptionLoop reads events from the global event mux and creates notifications for the matched subscriptions.
+// Both event types are read from a single subscription, so blocks dropped by a reorg are always reported before the
+// blocks replacing them.
 func (s *PublicBlockChainAPI) subscriptionLoop() {
-	sub := s.eventMux.Subscribe(core.ChainEvent{})
+	sub := s.eventMux.Subscribe(core.ChainEvent{}, core.RemovedBlocksEvent{})
 	for event := range sub.Chan() {
-		if chainEvent, ok := event.Data.(core.ChainEvent); ok {
-			s.muNewBlockSubscriptions.Lock()
-			for id, notifyOf := range s.newBlockSubscriptions {
-				if notifyOf(chainEvent) == rpc.ErrNotificationNotFound {
-					delete(s.newBlockSubscriptions, id)
-				}
+		switch ev := event.Data.(type) {
+		case core.ChainEvent:
+			s.notifyNewBlocks(ev.Block, false)
+		case core.RemovedBlocksEvent:
+			for _, block := range ev.Blocks {
+				s.notifyNewBlocks(block, true)
 			}
-			s.muNewBlockSubscriptions.Unlock()
+		}
+	}
+}
+
+// notifyNewBlocks passes a new or removed block to all new block subscriptions.
+func (s *PublicBlockChainAPI) notifyNewBlocks(block *types.Block, removed bool) {
+	s.muNewBlockSubscriptions.Lock()
+	defer s.muNewBlockSubscriptions.Unlock()
+
+	for id, notifyOf := range s.newBlockSubscriptions {
+		if notifyOf(block, removed) == rpc.ErrNotificationNotFound {
+			delete(s.newBlockSubscriptions, id)
 		}
 	}
 }
@@ -657,6 +801,9 @@ type NewBlocksArgs struct {
 
 // NewBlocks triggers a new block event each time a block is appended to the chain. It accepts an argument which allows
 // the caller to specify whether the output should contain transactions and in what format.
+//
+// Blocks are delivered in chain order. When a reorg replaces blocks that were already delivered, they are delivered
+// again with the "removed" field set, newest first, followed by the blocks of the new chain, oldest first.
 func (s *PublicBlockChainAPI) NewBlocks(ctx context.Context, args NewBlocksArgs) (rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
@@ -676,9 +823,10 @@ func (s *PublicBlockChainAPI) NewBlocks(ctx context.Context, args NewBlocksArgs)
 
 	// add a callback that is called on chain events which will format the block and notify the client
 	s.muNewBlockSubscriptions.Lock()
-	s.newBlockSubscriptions[subscription.ID()] = func(e core.ChainEvent) error {
-		notification, err := s.rpcOutputBlock(e.Block, args.IncludeTransactions, args.TransactionDetails)
+	s.newBlockSubscriptions[subscription.ID()] = func(block *types.Block, removed bool) error {
+		notification, err := s.rpcOutputBlock(block, args.IncludeTransactions, args.TransactionDetails)
 		if err == nil {
+			notification["removed"] = removed
 			return subscription.Notify(notification)
 		}
 		glog.V(logger.Warn).Info("unable to format block %v\n", err)
@@ -712,6 +860,83 @@ func (s *PublicBlockChainAPI) GetStorageAt(address common.Address, key string, b
 	return state.GetState(address, common.HexToHash(key)).Hex(), nil
 }
 
+// AccountResult is the proof of an account and some of its storage slots in the
+// state of a block, as returned by GetProof.
+type AccountResult struct {
+	Address      common.Address   `json:"address"`
+	AccountProof []string         `json:"accountProof"`
+	Balance      *rpc.HexNumber   `json:"balance"`
+	CodeHash     common.Hash      `json:"codeHash"`
+	Nonce        *rpc.HexNumber   `json:"nonce"`
+	StorageHash  common.Hash      `json:"storageHash"`
+	StorageProof []*StorageResult `json:"storageProof"`
+}
+
+// StorageResult is the proof of a storage slot in the storage trie of an account.
+type StorageResult struct {
+	Key   string         `json:"key"`
+	Value *rpc.HexNumber `json:"value"`
+	Proof []string       `json:"proof"`
+}
+
+// GetProof returns the Merkle proof of an account in the state trie of the given block, along with proofs of the
+// requested slots in its storage trie. The proofs let clients verify the account and storage values against the
+// state root of the block header, without trusting this node. Accounts that don't exist are proven absent; their
+// storage proofs are empty.
+func (s *PublicBlockChainAPI) GetProof(address common.Address, storageKeys []string, blockNr rpc.BlockNumber) (*AccountResult, error) {
+	if blockNr == rpc.PendingBlockNumber {
+		return nil, errors.New("proofs are not available for the pending block")
+	}
+	state, _, err := stateAndBlockByNumber(s.miner, s.bc, blockNr, s.chainDb)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	accountProof := state.GetProof(address)
+	if accountProof == nil {
+		return nil, fmt.Errorf("state of block %v is not available", blockNr)
+	}
+	result := &AccountResult{
+		Address:      address,
+		AccountProof: encodeProof(accountProof),
+		Balance:      rpc.NewHexNumber(state.GetBalance(address)),
+		CodeHash:     crypto.Keccak256Hash(nil),
+		Nonce:        rpc.NewHexNumber(state.GetNonce(address)),
+		StorageHash:  types.EmptyRootHash,
+		StorageProof: make([]*StorageResult, len(storageKeys)),
+	}
+	exists := state.Exist(address)
+	if exists {
+		result.CodeHash = state.GetCodeHash(address)
+		result.StorageHash = state.GetStorageRoot(address)
+	}
+	for i, key := range storageKeys {
+		slot := common.HexToHash(key)
+		proof := []string{}
+		if exists {
+			storageProof := state.GetStorageProof(address, slot)
+			if storageProof == nil {
+				return nil, fmt.Errorf("storage of account %x in block %v is not available", address, blockNr)
+			}
+			proof = encodeProof(storageProof)
+		}
+		result.StorageProof[i] = &StorageResult{
+			Key:   key,
+			Value: rpc.NewHexNumber(state.GetState(address, slot).Big()),
+			Proof: proof,
+		}
+	}
+	return result, nil
+}
+
+// encodeProof hex encodes the nodes of a Merkle proof.
+func encodeProof(proof []rlp.RawValue) []string {
+	nodes := make([]string, len(proof))
+	for i, node := range proof {
+		nodes[i] = common.ToHex(node)
+	}
+	return nodes
+}
+
 // callmsg is the message type used for call transactions.
 type callmsg struct {
 	from          *state.StateObject
@@ -741,7 +966,98 @@ type CallArgs struct {
 	Data     string          `json:"data"`
 }
 
-func (s *PublicBlockChainAPI) doCall(args CallArgs, blockNr rpc.BlockNumber) (string, *big.Int, error) {
+// OverrideAccount replaces parts of the state of an account ahead of executing a
+// call. Storage is either replaced as a whole by State, or slot by slot by
+// StateDiff.
+type OverrideAccount struct {
+	Nonce     *rpc.HexNumber    `json:"nonce"`
+	Code      *string           `json:"code"`
+	Balance   *rpc.HexNumber    `json:"balance"`
+	State     map[string]string `json:"state"`
+	StateDiff map[string]string `json:"stateDiff"`
+}
+
+// StateOverride is the set of accounts overridden for a call, keyed by address.
+type StateOverride map[string]OverrideAccount
+
+// apply overrides the accounts in the given state.
+func (diff StateOverride) apply(stateDb *state.StateDB) error {
+	for hexAddr, account := range diff {
+		if !common.IsHexAddress(hexAddr) {
+			return fmt.Errorf("invalid override address %q", hexAddr)
+		}
+		addr := common.HexToAddress(hexAddr)
+
+		if account.Nonce != nil {
+			stateDb.SetNonce(addr, account.Nonce.Uint64())
+		}
+		if account.Code != nil {
+			stateDb.SetCode(addr, common.FromHex(*account.Code))
+		}
+		if account.Balance != nil {
+			stateDb.SetBalance(addr, account.Balance.BigInt())
+		}
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", hexAddr)
+		}
+		if account.State != nil {
+			storage, err := parseStorage(account.State)
+			if err != nil {
+				return fmt.Errorf("account %s: %v", hexAddr, err)
+			}
+			stateDb.SetStorage(addr, storage)
+		}
+		if account.StateDiff != nil {
+			storage, err := parseStorage(account.StateDiff)
+			if err != nil {
+				return fmt.Errorf("account %s: %v", hexAddr, err)
+			}
+			for key, value := range storage {
+				stateDb.SetState(addr, key, value)
+			}
+		}
+	}
+	return nil
+}
+
+// parseStorage converts hex encoded storage slots and values into hashes.
+func parseStorage(slots map[string]string) (map[common.Hash]common.Hash, error) {
+	storage := make(map[common.Hash]common.Hash, len(slots))
+	for key, value := range slots {
+		if len(common.FromHex(key)) > common.HashLength {
+			return nil, fmt.Errorf("invalid storage slot %q", key)
+		}
+		if len(common.FromHex(value)) > common.HashLength {
+			return nil, fmt.Errorf("invalid value %q of storage slot %s", value, key)
+		}
+		storage[common.HexToHash(key)] = common.HexToHash(value)
+	}
+	return storage, nil
+}
+
+// BlockOverrides replaces fields of the block context a call is executed in.
+type BlockOverrides struct {
+	Number   *rpc.HexNumber  `json:"number"`
+	Time     *rpc.HexNumber  `json:"time"`
+	Coinbase *common.Address `json:"coinbase"`
+}
+
+// apply returns a copy of the header with the fields overridden.
+func (o *BlockOverrides) apply(header *types.Header) *types.Header {
+	header = types.CopyHeader(header)
+	if o.Number != nil {
+		header.Number = o.Number.BigInt()
+	}
+	if o.Time != nil {
+		header.Time = o.Time.BigInt()
+	}
+	if o.Coinbase != nil {
+		header.Coinbase = *o.Coinbase
+	}
+	return header
+}
+
+func (s *PublicBlockChainAPI) doCall(args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride, blockOverrides *BlockOverrides) (string, *big.Int, error) {
 	// Fetch the state associated with the block number
 	stateDb, block, err := stateAndBlockByNumber(s.miner, s.bc, blockNr, s.chainDb)
 	if stateDb == nil || err != nil {
@@ -763,6 +1079,17 @@ func (s *PublicBlockChainAPI) doCall(args CallArgs, blockNr rpc.BlockNumber) (st
 	}
 	from.SetBalance(common.MaxBig)
 
+	// Override the state after funding the sender, so it may be overridden too
+	if overrides != nil {
+		if err := overrides.apply(stateDb); err != nil {
+			return "0x", nil, err
+		}
+	}
+	header := block.Header()
+	if blockOverrides != nil {
+		header = blockOverrides.apply(header)
+	}
+
 	// Assemble the CALL invocation
 	msg := callmsg{
 		from:     from,
@@ -780,7 +1107,7 @@ func (s *PublicBlockChainAPI) doCall(args CallArgs, blockNr rpc.BlockNumber) (st
 	}
 
 	// Execute the call and return
-	vmenv := core.NewEnv(stateDb, s.config, s.bc, msg, block.Header())
+	vmenv := core.NewEnv(stateDb, s.config, s.bc, msg, header)
 	gp := new(core.GasPool).AddGas(common.MaxBig)
 
 	res, requiredGas, _, err := core.NewStateTransition(vmenv, msg, gp).TransitionDb()
@@ -792,14 +1119,18 @@ func (s *PublicBlockChainAPI) doCall(args CallArgs, blockNr rpc.BlockNumber) (st
 
 // Call executes the given transaction on the state for the given block number.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
-func (s *PublicBlockChainAPI) Call(args CallArgs, blockNr rpc.BlockNumber) (string, error) {
-	result, _, err := s.doCall(args, blockNr)
+//
+// The optional overrides replace the balance, nonce, code or storage of arbitrary accounts, and the number, time or
+// coinbase of the block, before the call is executed.
+func (s *PublicBlockChainAPI) Call(args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride, blockOverrides *BlockOverrides) (string, error) {
+	result, _, err := s.doCall(args, blockNr, overrides, blockOverrides)
 	return result, err
 }
 
-// EstimateGas returns an estimate of the amount of gas needed to execute the given transaction.
-func (s *PublicBlockChainAPI) EstimateGas(args CallArgs) (*rpc.HexNumber, error) {
-	_, gas, err := s.doCall(args, rpc.PendingBlockNumber)
+// EstimateGas returns an estimate of the amount of gas needed to execute the given transaction. The optional overrides
+// are applied as with Call.
+func (s *PublicBlockChainAPI) EstimateGas(args CallArgs, overrides *StateOverride, blockOverrides *BlockOverrides) (*rpc.HexNumber, error) {
+	_, gas, err := s.doCall(args, rpc.PendingBlockNumber, overrides, blockOverrides)
 	return rpc.NewHexNumber(gas), err
 }
 
@@ -1163,15 +1494,111 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 	return fields, nil
 }
 
+const (
+	defaultAddrTxLimit = 100  // Number of address transactions returned if no limit is requested
+	maxAddrTxLimit     = 1000 // Maximum number of address transactions returned by a single query
+)
+
+var (
+	// errAddrTxIndexDisabled is returned when querying the transactions of an address
+	// on a node not maintaining the address transaction index.
+	errAddrTxIndexDisabled = errors.New("address transaction index not enabled")
+
+	// errAddrTxIndexUnsupported is returned when querying the transactions of an
+	// address on a node whose database can't iterate over the index.
+	errAddrTxIndexUnsupported = errors.New("address transaction index not supported by the database")
+)
+
+// addrTxDirections maps the RPC names of the address transaction directions to
+// their index representation.
+var addrTxDirections = map[string]byte{
+	"":       0,
+	"from":   core.AddrTxFrom,
+	"to":     core.AddrTxTo,
+	"create": core.AddrTxCreate,
+}
+
+// AddrTxArgs represents the arguments of an address transaction query. All the
+// fields are optional, by default the first page of all the transactions in the
+// canonical chain is returned.
+type AddrTxArgs struct {
+	FromBlock *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock   *rpc.BlockNumber `json:"toBlock"`
+	Direction string           `json:"direction"` // "from", "to", "create" or empty for all
+	Offset    *rpc.HexNumber   `json:"offset"`
+	Limit     *rpc.HexNumber   `json:"limit"`
+	Reverse   bool             `json:"reverse"` // Return the newest transactions first
+}
+
+// RPCAddrTx represents a transaction of an address returned by an address
+// transaction query.
+type RPCAddrTx struct {
+	Hash             common.Hash    `json:"hash"`
+	BlockNumber      *rpc.HexNumber `json:"blockNumber"`
+	TransactionIndex *rpc.HexNumber `json:"transactionIndex"`
+	Direction        string         `json:"direction"`
+}
+
+// GetTransactionsByAddress returns a page of the canonical transactions sent from,
+// sent to or creating the given address, ordered by block number and index.
+func (s *PublicTransactionPoolAPI) GetTransactionsByAddress(address common.Address, args *AddrTxArgs) ([]*RPCAddrTx, error) {
+	if !s.bc.AddrTxIndex() {
+		return nil, errAddrTxIndexDisabled
+	}
+	if _, ok := s.chainDb.(ethdb.Iteratee); !ok {
+		return nil, errAddrTxIndexUnsupported
+	}
+	if args == nil {
+		args = new(AddrTxArgs)
+	}
+	head := s.bc.CurrentBlock().NumberU64()
+
+	from, to := uint64(0), head
+	if args.FromBlock != nil && *args.FromBlock >= 0 {
+		from = uint64(*args.FromBlock)
+	}
+	if args.ToBlock != nil && *args.ToBlock >= 0 && uint64(*args.ToBlock) < head {
+		to = uint64(*args.ToBlock)
+	}
+	direction, ok := addrTxDirections[args.Direction]
+	if !ok {
+		return nil, fmt.Errorf("invalid direction %q", args.Direction)
+	}
+	offset, limit := 0, defaultAddrTxLimit
+	if args.Offset != nil {
+		offset = args.Offset.Int()
+	}
+	if args.Limit != nil {
+		limit = args.Limit.Int()
+	}
+	if offset < 0 || limit < 0 || limit > maxAddrTxLimit {
+		return nil, fmt.Errorf("invalid page: offset %d, limit %d (max %d)", offset, limit, maxAddrTxLimit)
+	}
+	txs := []*RPCAddrTx{}
+	if from > to {
+		return txs, nil
+	}
+	for _, tx := range core.GetAddrTxs(s.chainDb, address, from, to, direction, args.Reverse, offset, limit) {
+		entry := &RPCAddrTx{
+			Hash:             tx.Hash,
+			BlockNumber:      rpc.NewHexNumber(tx.BlockNumber),
+			TransactionIndex: rpc.NewHexNumber(tx.Index),
+		}
+		for name, dir := range addrTxDirections {
+			if dir == tx.Direction && name != "" {
+				entry.Direction = name
+			}
+		}
+		txs = append(txs, entry)
+	}
+	return txs, nil
+}
+
 // sign is a helper function that signs a transaction with the private key of the given address.
 func (s *PublicTransactionPoolAPI) sign(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
 	signer := s.bc.Config().GetSigner(s.bc.CurrentBlock().Number())
 
-	signature, err := s.am.Sign(addr, signer.Hash(tx).Bytes())
-	if err != nil {
-		return nil, err
-	}
-	return tx.WithSigner(signer).WithSignature(signature)
+	return s.am.SignTx(accounts.Account{Address: addr}, tx, signer)
 }
 
 // SendTxArgs represents the arguments to sumbit a new transaction into the transaction pool.
@@ -1200,14 +1627,7 @@ func prepareSendTxArgs(args SendTxArgs, gpo *GasPriceOracle) SendTxArgs {
 }
 
 // submitTransaction is a helper function that submits tx to txPool and creates a log entry.
-func submitTransaction(bc *core.BlockChain, txPool *core.TxPool, tx *types.Transaction, signature []byte) (common.Hash, error) {
-	signer := bc.Config().GetSigner(bc.CurrentBlock().Number())
-
-	signedTx, err := tx.WithSigner(signer).WithSignature(signature)
-	if err != nil {
-		return common.Hash{}, err
-	}
-
+func submitTransaction(txPool *core.TxPool, signedTx *types.Transaction) (common.Hash, error) {
 	txPool.SetLocal(signedTx)
 	if err := txPool.Add(signedTx); err != nil {
 		return common.Hash{}, err
@@ -1218,7 +1638,7 @@ func submitTransaction(bc *core.BlockChain, txPool *core.TxPool, tx *types.Trans
 		addr := crypto.CreateAddress(from, signedTx.Nonce())
 		glog.V(logger.Info).Infof("Tx(%s) created: %s\n", signedTx.Hash().Hex(), addr.Hex())
 	} else {
-		glog.V(logger.Info).Infof("Tx(%s) to: %s\n", signedTx.Hash().Hex(), tx.To().Hex())
+		glog.V(logger.Info).Infof("Tx(%s) to: %s\n", signedTx.Hash().Hex(), signedTx.To().Hex())
 	}
 
 	return signedTx.Hash(), nil
@@ -1244,14 +1664,13 @@ func (s *PublicTransactionPoolAPI) SendTransaction(args SendTxArgs) (common.Hash
 	}
 
 	signer := s.bc.Config().GetSigner(s.bc.CurrentBlock().Number())
-	tx.SetSigner(signer)
 
-	signature, err := s.am.Sign(args.From, signer.Hash(tx).Bytes())
+	signedTx, err := s.am.SignTx(accounts.Account{Address: args.From}, tx, signer)
 	if err != nil {
 		return common.Hash{}, err
 	}
 
-	return submitTransaction(s.bc, s.txPool, tx, signature)
+	return submitTransaction(s.txPool, signedTx)
 }
 
 // SendRawTransaction will add the signed transaction to the transaction pool.
@@ -1281,11 +1700,38 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(encodedTx string) (string,
 	return tx.Hash().Hex(), nil
 }
 
-// Sign signs the given hash using the key that matches the address. The key must be
-// unlocked in order to sign the hash.
-func (s *PublicTransactionPoolAPI) Sign(addr common.Address, hash common.Hash) (string, error) {
-	signature, error := s.am.Sign(addr, hash[:])
-	return common.ToHex(signature), error
+// Sign signs the given hex encoded data with the key of addr, which must be
+// unlocked. Like personal_sign, the data is hashed with the Ethereum message
+// prefix (see accounts.TextHash), so the signature can never be that of a
+// transaction. The V of the returned signature is 27 or 28.
+func (s *PublicTransactionPoolAPI) Sign(addr common.Address, data string) (string, error) {
+	signature, err := s.am.Sign(addr, accounts.TextHash(common.FromHex(data)))
+	if err != nil {
+		return "", err
+	}
+	signature[64] += 27
+	return common.ToHex(signature), nil
+}
+
+// SignTypedData signs the EIP-712 typed data with the key of addr, which must be
+// unlocked. The chain id of the node is bound into the domain separator, so the
+// signature is only valid for this chain. The V of the returned signature is 27
+// or 28.
+func (s *PublicTransactionPoolAPI) SignTypedData(addr common.Address, data abi.TypedData) (string, error) {
+	signer := types.NewChainIdSigner(s.bc.Config().GetChainID())
+	if err := data.BindChainId(signer.ChainId()); err != nil {
+		return "", err
+	}
+	hash, err := data.SigningHash()
+	if err != nil {
+		return "", err
+	}
+	signature, err := s.am.Sign(addr, hash.Bytes())
+	if err != nil {
+		return "", err
+	}
+	signature[64] += 27
+	return common.ToHex(signature), nil
 }
 
 // SignTransactionArgs represents the arguments to sign a transaction.
@@ -1620,7 +2066,7 @@ func NewPublicDebugAPI(eth *Ethereum) *PublicDebugAPI {
 
 // DumpBlock retrieves the entire state of the database at a given block.
 // TODO: update to be able to dump for specific addresses?
-func (api *PublicDebugAPI) DumpBlock(number uint64) (state.Dump, error) {
+func (api *PublicDebugAPI) DumpBlock(ctx context.Context, number uint64) (state.Dump, error) {
 	block := api.eth.BlockChain().GetBlockByNumber(number)
 	if block == nil {
 		return state.Dump{}, fmt.Errorf("block #%d not found", number)
@@ -1629,7 +2075,7 @@ func (api *PublicDebugAPI) DumpBlock(number uint64) (state.Dump, error) {
 	if err != nil {
 		return state.Dump{}, err
 	}
-	return stateDb.RawDump([]common.Address{}), nil
+	return stateDb.RawDumpContext(ctx, []common.Address{})
 }
 
 // AccountExist checks whether an address is considered exists at a given block.
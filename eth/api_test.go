@@ -0,0 +1,264 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereumproject/go-ethereum/accounts"
+	"github.com/ethereumproject/go-ethereum/accounts/usbwallet"
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/state"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/crypto"
+	"github.com/ethereumproject/go-ethereum/ethdb"
+)
+
+// Tests that state overrides as sent over RPC replace the requested parts of
+// the accounts.
+func TestStateOverride(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, db)
+
+	replaced, patched := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+	one, two := common.BytesToHash([]byte{1}), common.BytesToHash([]byte{2})
+	for _, addr := range []common.Address{replaced, patched} {
+		statedb.SetBalance(addr, big.NewInt(1))
+		statedb.SetState(addr, one, one)
+		statedb.SetState(addr, two, one)
+	}
+	root, _ := statedb.Commit()
+	statedb, _ = state.New(root, db)
+
+	var overrides StateOverride
+	err := json.Unmarshal([]byte(`{
+		"0x0000000000000000000000000000000000000001": {
+			"balance": "0x100", "nonce": "0x7", "code": "0x6001",
+			"state": {"0x02": "0x02"}
+		},
+		"0x0000000000000000000000000000000000000002": {
+			"stateDiff": {"0x02": "0x02"}
+		}
+	}`), &overrides)
+	if err != nil {
+		t.Fatalf("failed to decode overrides: %v", err)
+	}
+	if err := overrides.apply(statedb); err != nil {
+		t.Fatalf("failed to apply overrides: %v", err)
+	}
+	if balance := statedb.GetBalance(replaced); balance.Cmp(big.NewInt(0x100)) != 0 {
+		t.Errorf("balance mismatch: have %v, want %v", balance, 0x100)
+	}
+	if nonce := statedb.GetNonce(replaced); nonce != 7 {
+		t.Errorf("nonce mismatch: have %d, want %d", nonce, 7)
+	}
+	if code := statedb.GetCode(replaced); common.ToHex(code) != "0x6001" {
+		t.Errorf("code mismatch: have %x, want 0x6001", code)
+	}
+	tests := []struct {
+		addr      common.Address
+		key, want common.Hash
+	}{
+		{replaced, one, common.Hash{}},
+		{replaced, two, two},
+		{patched, one, one},
+		{patched, two, two},
+	}
+	for i, tt := range tests {
+		if have := statedb.GetState(tt.addr, tt.key); have != tt.want {
+			t.Errorf("test %d: slot %x of %x: have %x, want %x", i, tt.key, tt.addr, have, tt.want)
+		}
+	}
+	if balance := statedb.GetBalance(patched); balance.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("untouched balance changed: have %v", balance)
+	}
+}
+
+// Tests that invalid state overrides are rejected.
+func TestStateOverrideInvalid(t *testing.T) {
+	tests := []string{
+		`{"0x01": {}}`,
+		`{"0x0000000000000000000000000000000000000001": {"state": {}, "stateDiff": {}}}`,
+		`{"0x0000000000000000000000000000000000000001": {"stateDiff": {"0x0000000000000000000000000000000000000000000000000000000000000000ff": "0x01"}}}`,
+	}
+	for i, blob := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, db)
+
+		var overrides StateOverride
+		if err := json.Unmarshal([]byte(blob), &overrides); err != nil {
+			t.Fatalf("test %d: failed to decode overrides: %v", i, err)
+		}
+		if err := overrides.apply(statedb); err == nil {
+			t.Errorf("test %d: expected error", i)
+		}
+	}
+}
+
+// Tests that block overrides replace fields of a copy of the header.
+func TestBlockOverrides(t *testing.T) {
+	var overrides BlockOverrides
+	if err := json.Unmarshal([]byte(`{"number": "0x10", "coinbase": "0x0000000000000000000000000000000000000003"}`), &overrides); err != nil {
+		t.Fatalf("failed to decode overrides: %v", err)
+	}
+	header := &types.Header{Number: big.NewInt(1), Time: big.NewInt(2)}
+	have := overrides.apply(header)
+
+	if have.Number.Cmp(big.NewInt(0x10)) != 0 || have.Time.Cmp(big.NewInt(2)) != 0 || have.Coinbase != common.HexToAddress("0x03") {
+		t.Errorf("overridden header mismatch: number %v, time %v, coinbase %x", have.Number, have.Time, have.Coinbase)
+	}
+	if header.Number.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("original header modified")
+	}
+}
+
+// Tests that personal_sign signatures recover to the signing account, and only
+// for the data signed.
+func TestPersonalSignRecover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eth-personal-sign-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	am, err := accounts.NewManager(dir, accounts.LightScryptN, accounts.LightScryptP, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := &PrivateAccountAPI{am: am}
+
+	if _, err := api.Sign("0xdeadbeef", account.Address, "bar"); err != accounts.ErrDecrypt {
+		t.Errorf("wrong passphrase error mismatch: have %v, want %v", err, accounts.ErrDecrypt)
+	}
+	sig, err := api.Sign("0xdeadbeef", account.Address, "foo")
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if v := common.FromHex(sig)[64]; v != 27 && v != 28 {
+		t.Errorf("signature V mismatch: have %d, want 27 or 28", v)
+	}
+	if addr, err := api.EcRecover("0xdeadbeef", sig); err != nil || addr != account.Address {
+		t.Errorf("recovered address mismatch: have %x/%v, want %x", addr, err, account.Address)
+	}
+	if addr, err := api.EcRecover("0xdeadbeee", sig); err == nil && addr == account.Address {
+		t.Errorf("signature recovered to the account for other data")
+	}
+	if _, err := api.EcRecover("0xdeadbeef", sig[:len(sig)-2]); err == nil {
+		t.Errorf("short signature accepted")
+	}
+}
+
+// Tests that eth_sign prefixes the data like personal_sign, so its signatures
+// recover with personal_ecRecover and never sign a bare hash.
+func TestEthSignPrefixed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eth-sign-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	am, err := accounts.NewManager(dir, accounts.LightScryptN, accounts.LightScryptP, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := &PublicTransactionPoolAPI{am: am}
+
+	if _, err := api.Sign(account.Address, "0xdeadbeef"); err == nil {
+		t.Errorf("signed with a locked account")
+	}
+	if err := am.Unlock(account, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	sig, err := api.Sign(account.Address, "0xdeadbeef")
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	personal := &PrivateAccountAPI{am: am}
+	if addr, err := personal.EcRecover("0xdeadbeef", sig); err != nil || addr != account.Address {
+		t.Errorf("recovered address mismatch: have %x/%v, want %x", addr, err, account.Address)
+	}
+	raw := common.FromHex(sig)
+	raw[64] -= 27
+	if pub, err := crypto.Ecrecover(crypto.Keccak256(common.FromHex("0xdeadbeef")), raw); err == nil && common.BytesToAddress(crypto.Keccak256(pub[1:])[12:]) == account.Address {
+		t.Errorf("signature recovered to the account for the bare hash")
+	}
+}
+
+// Tests that hardware wallets registered with the account manager can be listed,
+// opened and derived from through the personal API.
+func TestPersonalWallets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eth-personal-wallets-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	am, err := accounts.NewManager(dir, accounts.LightScryptN, accounts.LightScryptP, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	emulator, err := usbwallet.NewEmulator("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about")
+	if err != nil {
+		t.Fatal(err)
+	}
+	emulator.Plug()
+	hub := usbwallet.NewHub(emulator)
+	defer hub.Close()
+	am.AddBackend(hub)
+
+	api := &PrivateAccountAPI{am: am}
+	wallets := api.ListWallets()
+	if len(wallets) != 1 || wallets[0].Status != "Closed" || len(wallets[0].Accounts) != 0 {
+		t.Fatalf("wallets mismatch: %+v", wallets)
+	}
+	url := wallets[0].URL
+
+	if _, err := api.DeriveWalletAccount(url, "m/44'/61'/0'/0/0"); err != accounts.ErrWalletClosed {
+		t.Errorf("closed wallet error mismatch: have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+	if err := api.OpenWallet("ledger://unknown", nil); err != accounts.ErrUnknownWallet {
+		t.Errorf("unknown wallet error mismatch: have %v, want %v", err, accounts.ErrUnknownWallet)
+	}
+	if err := api.OpenWallet(url, nil); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	if _, err := api.DeriveWalletAccount(url, "m/44'/61'/x"); err == nil {
+		t.Errorf("invalid derivation path accepted")
+	}
+	addr, err := api.DeriveWalletAccount(url, "m/44'/61'/0'/0/0")
+	if err != nil {
+		t.Fatalf("failed to derive account: %v", err)
+	}
+	if accs := api.ListAccounts(); len(accs) != 1 || accs[0] != addr {
+		t.Errorf("accounts mismatch: have %x, want [%x]", accs, addr)
+	}
+	if wallets := api.ListWallets(); len(wallets) != 1 || len(wallets[0].Accounts) != 1 || wallets[0].Accounts[0] != addr {
+		t.Errorf("wallets mismatch after derivation: %+v", wallets)
+	}
+}
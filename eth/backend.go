@@ -63,6 +63,10 @@ type Config struct {
 	Genesis   *core.GenesisDump
 	FastSync  bool // Enables the state download based fast synchronisation algorithm
 
+	AddrTxIndex bool // Enables indexing the canonical transactions by sender and recipient address
+
+	MaxLogBlockRange uint64 // Maximum number of blocks a log query may span (0 = unlimited)
+
 	BlockChainVersion  int
 	SkipBcVersionCheck bool // e.g. blockchain export
 	DatabaseCache      int
@@ -107,6 +111,8 @@ type Ethereum struct {
 	accountManager  *accounts.Manager
 	pow             *ethash.Ethash
 	protocolManager *ProtocolManager
+	bloomIndexer    *core.ChainIndexer
+	maxLogRange     uint64
 	SolcPath        string
 	solc            *compiler.Solidity
 	gpo             *GasPriceOracle
@@ -202,6 +208,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		GpobaseStepUp:           config.GpobaseStepUp,
 		GpobaseCorrectionFactor: config.GpobaseCorrectionFactor,
 		httpclient:              httpclient.New(config.DocRoot),
+		maxLogRange:             config.MaxLogBlockRange,
 	}
 	switch {
 	case config.PowTest:
@@ -251,7 +258,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		}
 		return nil, err
 	}
+	eth.blockchain.SetAddrTxIndex(config.AddrTxIndex)
 	eth.gpo = NewGasPriceOracle(eth)
+	eth.bloomIndexer = newBloomIndexer(chainDb, bloomConfirms)
 
 	newPool := core.NewTxPool(eth.chainConfig, eth.EventMux(), eth.blockchain.State, eth.blockchain.GasLimit)
 	eth.txPool = newPool
@@ -319,7 +328,7 @@ func (s *Ethereum) APIs() []rpc.API {
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(s.chainDb, s.eventMux),
+			Service:   filters.NewPublicFilterAPI(s.chainDb, s.eventMux, s.maxLogRange),
 			Public:    true,
 		}, {
 			Namespace: "admin",
@@ -380,6 +389,22 @@ func (s *Ethereum) EthVersion() int                    { return int(s.protocolMa
 func (s *Ethereum) NetVersion() int                    { return s.netVersionId }
 func (s *Ethereum) Downloader() *downloader.Downloader { return s.protocolManager.downloader }
 
+// SyncStatus implements node.SyncReporter, returning the chain sync progress
+// for the node's health endpoints.
+func (s *Ethereum) SyncStatus() node.SyncStatus {
+	_, current, highest, _, _ := s.Downloader().Progress()
+	head := s.blockchain.CurrentBlock()
+	if highest < head.NumberU64() {
+		highest = head.NumberU64()
+	}
+	return node.SyncStatus{
+		Syncing:      current < highest,
+		CurrentBlock: head.NumberU64(),
+		HighestBlock: highest,
+		HeadTime:     time.Unix(head.Time().Int64(), 0),
+	}
+}
+
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
@@ -393,6 +418,7 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 		s.StartAutoDAG()
 	}
 	s.protocolManager.Start()
+	s.bloomIndexer.Start(s.eventMux)
 	s.netRPCService = NewPublicNetAPI(srvr, s.NetVersion())
 	return nil
 }
@@ -400,6 +426,7 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 // Stop implements node.Service, terminating all internal goroutines used by the
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
+	s.bloomIndexer.Stop()
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	s.txPool.Stop()
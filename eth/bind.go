@@ -17,10 +17,17 @@
 package eth
 
 import (
+	"context"
 	"math/big"
 
+	"github.com/ethereumproject/go-ethereum/accounts/abi/bind"
 	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core"
 	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/core/vm"
+	"github.com/ethereumproject/go-ethereum/eth/filters"
+	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/event"
 	"github.com/ethereumproject/go-ethereum/rlp"
 	"github.com/ethereumproject/go-ethereum/rpc"
 )
@@ -36,6 +43,9 @@ type ContractBackend struct {
 	eapi  *PublicEthereumAPI        // Wrapper around the Ethereum object to access metadata
 	bcapi *PublicBlockChainAPI      // Wrapper around the blockchain to access chain data
 	txapi *PublicTransactionPoolAPI // Wrapper around the transaction pool to access transaction data
+
+	chainDb  ethdb.Database // Block chain database to filter past logs from
+	eventMux *event.TypeMux // Event multiplexer to stream new logs from
 }
 
 // NewContractBackend creates a new native contract backend using an existing
@@ -45,6 +55,9 @@ func NewContractBackend(eth *Ethereum) *ContractBackend {
 		eapi:  NewPublicEthereumAPI(eth),
 		bcapi: NewPublicBlockChainAPI(eth.chainConfig, eth.blockchain, eth.miner, eth.chainDb, eth.gpo, eth.eventMux, eth.accountManager),
 		txapi: NewPublicTransactionPoolAPI(eth),
+
+		chainDb:  eth.chainDb,
+		eventMux: eth.eventMux,
 	}
 }
 
@@ -73,7 +86,7 @@ func (b *ContractBackend) ContractCall(contract common.Address, data []byte, pen
 		block = rpc.PendingBlockNumber
 	}
 	// Execute the call and convert the output back to Go types
-	out, err := b.bcapi.Call(args, block)
+	out, err := b.bcapi.Call(args, block, nil, nil)
 	return common.FromHex(out), err
 }
 
@@ -101,7 +114,7 @@ func (b *ContractBackend) EstimateGasLimit(sender common.Address, contract *comm
 		To:    contract,
 		Value: *rpc.NewHexNumber(value),
 		Data:  common.ToHex(data),
-	})
+	}, nil, nil)
 	return out.BigInt(), err
 }
 
@@ -112,3 +125,58 @@ func (b *ContractBackend) SendTransaction(tx *types.Transaction) error {
 	_, err := b.txapi.SendRawTransaction(common.ToHex(raw))
 	return err
 }
+
+// TransactionReceipt implements bind.DeployBackend retrieving the receipt of a
+// transaction already included in the local chain.
+func (b *ContractBackend) TransactionReceipt(txHash common.Hash) (*types.Receipt, error) {
+	return core.GetReceipt(b.chainDb, txHash), nil
+}
+
+// FilterLogs implements bind.ContractFilterer executing a log filter operation
+// against the local chain, returning all the results in one batch.
+func (b *ContractBackend) FilterLogs(query bind.FilterQuery) (vm.Logs, error) {
+	filter := filters.New(b.chainDb)
+	filter.SetBeginBlock(-1)
+	if query.FromBlock != nil {
+		filter.SetBeginBlock(query.FromBlock.Int64())
+	}
+	filter.SetEndBlock(-1)
+	if query.ToBlock != nil {
+		filter.SetEndBlock(query.ToBlock.Int64())
+	}
+	filter.SetAddresses(query.Addresses)
+	filter.SetTopics(query.Topics)
+
+	return filter.Find(context.Background())
+}
+
+// SubscribeFilterLogs implements bind.ContractFilterer streaming the matching
+// logs of the blocks imported after the subscription was created.
+func (b *ContractBackend) SubscribeFilterLogs(query bind.FilterQuery, sink chan<- *vm.Log) (bind.Subscription, error) {
+	filter := filters.New(b.chainDb)
+	filter.SetAddresses(query.Addresses)
+	filter.SetTopics(query.Topics)
+
+	// Removed logs cannot be told apart from new ones by the sink, skip them
+	done := make(chan struct{})
+	filter.LogCallback = func(log *vm.Log, removed bool) {
+		if removed {
+			return
+		}
+		select {
+		case sink <- log:
+		case <-done:
+		}
+	}
+	system := filters.NewFilterSystem(b.eventMux)
+	system.Lock()
+	system.Add(filter, filters.LogFilter)
+	system.Unlock()
+
+	return bind.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		close(done)
+		system.Stop()
+		return nil
+	}), nil
+}
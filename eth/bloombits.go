@@ -0,0 +1,188 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"time"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/common/bitutil"
+	"github.com/ethereumproject/go-ethereum/core"
+	"github.com/ethereumproject/go-ethereum/core/bloombits"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/p2p"
+)
+
+const (
+	// bloomConfirms is the number of confirmation blocks before a bloom section is
+	// considered probably final and its rotated bits are calculated.
+	bloomConfirms = 256
+
+	// bloomThrottling is the time to wait between processing two consecutive index
+	// sections. It's useful during chain upgrades to prevent disk overload.
+	bloomThrottling = 100 * time.Millisecond
+)
+
+// bloomIndexer implements core.ChainIndexerBackend, transposing the header blooms
+// of the canonical chain into bloom bits sections, letting the log filters match
+// long ranges of blocks section by section instead of block by block.
+type bloomIndexer struct {
+	gen     *bloombits.Generator // Generator to rotate the bloom bits of the section
+	section uint64               // Section being processed currently
+	head    common.Hash          // Hash of the last header processed
+}
+
+// newBloomIndexer returns a chain indexer that generates the bloom bits index of
+// the canonical chain stored in db.
+func newBloomIndexer(db ethdb.Database, confirms uint64) *core.ChainIndexer {
+	return core.NewChainIndexer(db, core.BloomBitsIndexPrefix, new(bloomIndexer), core.BloomBitsBlocks, confirms, bloomThrottling, "bloombits")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new bloom bits section.
+func (b *bloomIndexer) Reset(section uint64, prevHead common.Hash) error {
+	gen, err := bloombits.NewGenerator(uint(core.BloomBitsBlocks))
+	b.gen, b.section, b.head = gen, section, common.Hash{}
+	return err
+}
+
+// Process implements core.ChainIndexerBackend, adding a new header's bloom into
+// the section.
+func (b *bloomIndexer) Process(header *types.Header) error {
+	b.head = header.Hash()
+	return b.gen.AddBloom(uint(header.Number.Uint64()-b.section*core.BloomBitsBlocks), header.Bloom)
+}
+
+// Commit implements core.ChainIndexerBackend, adding the rotated bit vectors of
+// the finished section to the batch.
+func (b *bloomIndexer) Commit(batch ethdb.Batch) error {
+	for i := uint(0); i < types.BloomBitLength; i++ {
+		bits, err := b.gen.Bitset(i)
+		if err != nil {
+			return err
+		}
+		if err := core.WriteBloomBits(batch, i, b.section, b.head, bits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The bloom bits protocol serves the bit vectors of the index to remote peers, so
+// that light clients can filter logs by proxy of a full node's index. It runs as
+// a sub-protocol of its own alongside eth, so only peers knowing it query it.
+const (
+	bloomProtocolName    = "bloom"
+	bloomProtocolVersion = 1
+	bloomProtocolLength  = 2
+
+	// maxBloomBitsFetch is the maximum number of sections served per request.
+	maxBloomBitsFetch = 64
+)
+
+// bloom bits protocol message codes
+const (
+	GetBloomBitsMsg = 0x00
+	BloomBitsMsg    = 0x01
+)
+
+// getBloomBitsData represents a query of the bit vectors of a bloom bit.
+type getBloomBitsData struct {
+	ID       uint64   // Request ID to match up responses with
+	Bit      uint64   // Bloom bit to retrieve the vectors of
+	Sections []uint64 // Sections to retrieve the vectors for
+}
+
+// bloomBitsData is the response to a bloom bits query, listing the requested
+// sections in order. Sections not indexed have a zero head and no vector.
+type bloomBitsData struct {
+	ID      uint64        // Request ID of the query answered
+	Heads   []common.Hash // Hash of the last block of each section, to check the chain against
+	Vectors [][]byte      // Compressed bit vector of each section
+}
+
+// makeBloomProtocol creates the sub-protocol serving the bloom bits index in db.
+func makeBloomProtocol(db ethdb.Database) p2p.Protocol {
+	return p2p.Protocol{
+		Name:    bloomProtocolName,
+		Version: bloomProtocolVersion,
+		Length:  bloomProtocolLength,
+		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+			return handleBloomBits(db, rw)
+		},
+	}
+}
+
+// handleBloomBits serves the bloom bits queries of a remote peer until the
+// connection is torn down or the peer misbehaves.
+func handleBloomBits(db ethdb.Database, rw p2p.MsgReadWriter) error {
+	for {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if msg.Size > ProtocolMaxMsgSize {
+			return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+		}
+		switch msg.Code {
+		case GetBloomBitsMsg:
+			var req getBloomBitsData
+			err := msg.Decode(&req)
+			msg.Discard()
+			if err != nil {
+				return errResp(ErrDecode, "%v: %v", msg, err)
+			}
+			if err := p2p.Send(rw, BloomBitsMsg, serveBloomBits(db, &req)); err != nil {
+				return err
+			}
+		default:
+			msg.Discard()
+			return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+		}
+	}
+}
+
+// serveBloomBits retrieves the compressed bit vectors of a query from the index,
+// answering at most maxBloomBitsFetch sections.
+func serveBloomBits(db ethdb.Database, req *getBloomBitsData) *bloomBitsData {
+	res := &bloomBitsData{ID: req.ID, Heads: []common.Hash{}, Vectors: [][]byte{}}
+	if req.Bit >= types.BloomBitLength {
+		return res
+	}
+	sections := req.Sections
+	if len(sections) > maxBloomBitsFetch {
+		sections = sections[:maxBloomBitsFetch]
+	}
+	indexed := core.GetBloomBitsSections(db)
+	for _, section := range sections {
+		var (
+			head   common.Hash
+			vector []byte
+		)
+		if section < indexed {
+			head = core.GetBloomBitsSectionHead(db, section)
+			if bits := core.GetBloomBits(db, uint(req.Bit), section, head); bits != nil {
+				vector = bitutil.CompressBytes(bits)
+			} else {
+				head = common.Hash{}
+			}
+		}
+		res.Heads = append(res.Heads, head)
+		res.Vectors = append(res.Vectors, vector)
+	}
+	return res
+}
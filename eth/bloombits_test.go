@@ -0,0 +1,172 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/common/bitutil"
+	"github.com/ethereumproject/go-ethereum/core"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/event"
+	"github.com/ethereumproject/go-ethereum/p2p"
+)
+
+// writeBloomChain writes a canonical header chain of the given length on top of
+// the parent, each header's bloom containing its own number.
+func writeBloomChain(db ethdb.Database, parent *types.Header, n int, fork byte) []*types.Header {
+	var headers []*types.Header
+	for i := 0; i < n; i++ {
+		header := &types.Header{
+			Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+			ParentHash: parent.Hash(),
+			Difficulty: big.NewInt(1),
+			Extra:      []byte{fork},
+		}
+		header.Bloom.Add(header.Number)
+		core.WriteHeader(db, header)
+		core.WriteCanonicalHash(db, header.Hash(), header.Number.Uint64())
+		core.WriteHeadBlockHash(db, header.Hash())
+
+		headers = append(headers, header)
+		parent = header
+	}
+	return headers
+}
+
+// waitSections waits until the bloom bits index reaches the given section count.
+func waitSections(t *testing.T, db ethdb.Database, sections uint64) {
+	for i := 0; i < 100; i++ {
+		if core.GetBloomBitsSections(db) == sections {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("indexed section count mismatch: have %d, want %d", core.GetBloomBitsSections(db), sections)
+}
+
+// Tests that the bloom indexer processes confirmed sections in the background,
+// and reindexes the ones reorged out of the canonical chain.
+func TestBloomIndexer(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	mux := new(event.TypeMux)
+
+	genesis := &types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)}
+	core.WriteHeader(db, genesis)
+	core.WriteCanonicalHash(db, genesis.Hash(), 0)
+
+	// Create a chain with a single confirmed section and start indexing
+	headers := append([]*types.Header{genesis}, writeBloomChain(db, genesis, int(core.BloomBitsBlocks)+10, 0)...)
+
+	indexer := newBloomIndexer(db, 8)
+	indexer.Start(mux)
+	defer indexer.Stop()
+
+	waitSections(t, db, 1)
+	if head := core.GetBloomBitsSectionHead(db, 0); head != headers[core.BloomBitsBlocks-1].Hash() {
+		t.Fatalf("section head mismatch: have %x, want %x", head, headers[core.BloomBitsBlocks-1].Hash())
+	}
+	bloom := headers[100].Bloom
+	for i := uint(0); i < types.BloomBitLength; i++ {
+		bits := core.GetBloomBits(db, i, 0, headers[core.BloomBitsBlocks-1].Hash())
+		if bits == nil {
+			t.Fatalf("bit %d: missing vector", i)
+		}
+		set := bits[100/8]&(1<<(7-100%8)) != 0
+		if want := bloom[types.BloomByteLength-1-i/8]&(1<<(i%8)) != 0; set != want {
+			t.Fatalf("bit %d: block 100 mismatch: have %v, want %v", i, set, want)
+		}
+	}
+	// Reorg the end of the section out and make sure it's reindexed
+	fork := writeBloomChain(db, headers[core.BloomBitsBlocks-20], 30+8, 1)
+	mux.Post(core.ChainHeadEvent{Block: types.NewBlockWithHeader(fork[len(fork)-1])})
+
+	for i := 0; i < 100; i++ {
+		if core.GetBloomBitsSectionHead(db, 0) == fork[18].Hash() {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if head := core.GetBloomBitsSectionHead(db, 0); head != fork[18].Hash() {
+		t.Fatalf("reorged section head mismatch: have %x, want %x", head, fork[18].Hash())
+	}
+	waitSections(t, db, 1)
+}
+
+// Tests that the bit vectors of indexed sections are served to remote peers over
+// the bloom bits protocol, and unindexed ones are reported as such.
+func TestBloomBitsServing(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := &types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)}
+	core.WriteHeader(db, genesis)
+	core.WriteCanonicalHash(db, genesis.Hash(), 0)
+	headers := append([]*types.Header{genesis}, writeBloomChain(db, genesis, int(core.BloomBitsBlocks)+10, 0)...)
+
+	indexer := newBloomIndexer(db, 8)
+	indexer.Start(new(event.TypeMux))
+	defer indexer.Stop()
+	waitSections(t, db, 1)
+
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+	go handleBloomBits(db, net)
+
+	bit := uint64(42)
+	if err := p2p.Send(app, GetBloomBitsMsg, &getBloomBitsData{ID: 7, Bit: bit, Sections: []uint64{0, 1}}); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	msg, err := app.ReadMsg()
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if msg.Code != BloomBitsMsg {
+		t.Fatalf("message code mismatch: have %d, want %d", msg.Code, BloomBitsMsg)
+	}
+	var res bloomBitsData
+	if err := msg.Decode(&res); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if res.ID != 7 || len(res.Heads) != 2 || len(res.Vectors) != 2 {
+		t.Fatalf("response mismatch: id %d, %d heads, %d vectors", res.ID, len(res.Heads), len(res.Vectors))
+	}
+	head := headers[core.BloomBitsBlocks-1].Hash()
+	if res.Heads[0] != head {
+		t.Errorf("section head mismatch: have %x, want %x", res.Heads[0], head)
+	}
+	bits, err := bitutil.DecompressBytes(res.Vectors[0], int(core.BloomBitsBlocks/8))
+	if err != nil {
+		t.Fatalf("failed to decompress vector: %v", err)
+	}
+	if want := core.GetBloomBits(db, uint(bit), 0, head); !bytes.Equal(bits, want) {
+		t.Errorf("vector mismatch: have %x, want %x", bits, want)
+	}
+	if res.Heads[1] != (common.Hash{}) || len(res.Vectors[1]) != 0 {
+		t.Errorf("unindexed section served: head %x, vector %x", res.Heads[1], res.Vectors[1])
+	}
+	// Requests for bits outside the bloom are answered empty
+	if err := p2p.Send(app, GetBloomBitsMsg, &getBloomBitsData{ID: 8, Bit: types.BloomBitLength, Sections: []uint64{0}}); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	if err := p2p.ExpectMsg(app, BloomBitsMsg, &bloomBitsData{ID: 8, Heads: []common.Hash{}, Vectors: [][]byte{}}); err != nil {
+		t.Errorf("invalid bit response mismatch: %v", err)
+	}
+}
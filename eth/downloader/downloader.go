@@ -30,12 +30,14 @@ import (
 	"time"
 
 	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core"
 	"github.com/ethereumproject/go-ethereum/core/types"
 	"github.com/ethereumproject/go-ethereum/ethdb"
 	"github.com/ethereumproject/go-ethereum/event"
 	"github.com/ethereumproject/go-ethereum/logger"
 	"github.com/ethereumproject/go-ethereum/logger/glog"
 	"github.com/ethereumproject/go-ethereum/metrics"
+	"github.com/ethereumproject/go-ethereum/p2p"
 	"github.com/ethereumproject/go-ethereum/trie"
 )
 
@@ -73,6 +75,9 @@ var (
 	fsPivotInterval        = 512  // Number of headers out of which to randomize the pivot point
 	fsMinFullBlocks        = 1024 // Number of blocks to retrieve fully even in fast sync
 	fsCriticalTrials       = 10   // Number of times to retry in the cricical section before bailing
+	fsPivotMaxAge          = 4096 // Number of blocks a resumed pivot may fall behind the chain head before being moved
+
+	fsProgressInterval = time.Minute // Interval of persisting the state retrieval progress for resumption
 )
 
 var (
@@ -111,8 +116,9 @@ const (
 )
 
 type Downloader struct {
-	mode SyncMode       // Synchronisation mode defining the strategy used (per sync cycle)
-	mux  *event.TypeMux // Event multiplexer to announce sync operation events
+	mode    SyncMode       // Synchronisation mode defining the strategy used (per sync cycle)
+	mux     *event.TypeMux // Event multiplexer to announce sync operation events
+	stateDb ethdb.Database // Database to populate the state into and persist the fast sync progress in
 
 	queue *queue   // Scheduler for selecting the hashes to download
 	peers *peerSet // Set of active peers from which download can proceed
@@ -144,6 +150,8 @@ type Downloader struct {
 	insertReceipts   receiptChainInsertFn     // Injects a batch of blocks and their receipts into the chain
 	rollback         chainRollbackFn          // Removes a batch of recently added chain links
 	dropPeer         peerDropFn               // Drops a peer for misbehaving
+	reportPeer       peerReportFn             // Reports peer behaviour to the reputation tracker
+	snapSync         stateSyncFn              // Retrieves state in bulk before node data healing (nil if disabled)
 
 	// Status
 	synchroniseMock func(id string, hash common.Hash) error // Replacement for synchronise during testing
@@ -180,11 +188,13 @@ type Downloader struct {
 func New(stateDb ethdb.Database, mux *event.TypeMux, hasHeader headerCheckFn, hasBlockAndState blockAndStateCheckFn,
 	getHeader headerRetrievalFn, getBlock blockRetrievalFn, headHeader headHeaderRetrievalFn, headBlock headBlockRetrievalFn,
 	headFastBlock headFastBlockRetrievalFn, commitHeadBlock headBlockCommitterFn, getTd tdRetrievalFn, insertHeaders headerChainInsertFn,
-	insertBlocks blockChainInsertFn, insertReceipts receiptChainInsertFn, rollback chainRollbackFn, dropPeer peerDropFn) *Downloader {
+	insertBlocks blockChainInsertFn, insertReceipts receiptChainInsertFn, rollback chainRollbackFn, dropPeer peerDropFn,
+	reportPeer peerReportFn, snapSync stateSyncFn) *Downloader {
 
 	dl := &Downloader{
 		mode:             FullSync,
 		mux:              mux,
+		stateDb:          stateDb,
 		queue:            newQueue(stateDb),
 		peers:            newPeerSet(),
 		rttEstimate:      uint64(rttMaxEstimate),
@@ -203,6 +213,8 @@ func New(stateDb ethdb.Database, mux *event.TypeMux, hasHeader headerCheckFn, ha
 		insertReceipts:   insertReceipts,
 		rollback:         rollback,
 		dropPeer:         dropPeer,
+		reportPeer:       reportPeer,
+		snapSync:         snapSync,
 		newPeerCh:        make(chan *peer, 1),
 		headerCh:         make(chan dataPack, 1),
 		bodyCh:           make(chan dataPack, 1),
@@ -305,6 +317,7 @@ func (d *Downloader) Synchronise(id string, head common.Hash, td *big.Int, mode
 	switch err {
 	case nil:
 		log.Printf("peer %q sync complete", id)
+		d.reportPeer(id, p2p.PeerGoodDelivery)
 		return true
 
 	case errBusy:
@@ -314,6 +327,7 @@ func (d *Downloader) Synchronise(id string, head common.Hash, td *big.Int, mode
 		errEmptyHeaderSet, errPeersUnavailable, errTooOld,
 		errInvalidAncestor, errInvalidChain:
 		log.Printf("peer %q drop: %s", id, err)
+		d.reportPeer(id, peerEventForError(err))
 		d.dropPeer(id)
 
 	case errCancelBlockFetch, errCancelHeaderFetch, errCancelBodyFetch, errCancelReceiptFetch, errCancelStateFetch, errCancelHeaderProcessing, errCancelContentProcessing:
@@ -325,6 +339,21 @@ func (d *Downloader) Synchronise(id string, head common.Hash, td *big.Int, mode
 	return false
 }
 
+// peerEventForError maps a synchronisation failure to the behaviour reported
+// for the peer responsible.
+func peerEventForError(err error) p2p.PeerEvent {
+	switch err {
+	case errTimeout, errStallingPeer:
+		return p2p.PeerTimeout
+	case errInvalidAncestor, errInvalidChain:
+		return p2p.PeerInvalidBlock
+	case errBadPeer:
+		return p2p.PeerProtocolBreach
+	default:
+		return p2p.PeerUselessResponse
+	}
+}
+
 // synchronise will select the peer and use it for synchronising. If an empty string is given
 // it will use the best peer possible and synchronise if it's TD is higher than our own. If any of the
 // checks fail an error will be returned. This method is synchronous
@@ -344,7 +373,7 @@ func (d *Downloader) synchronise(id string, hash common.Hash, td *big.Int, mode
 		glog.V(logger.Info).Infoln("Block synchronisation started")
 	}
 	// Reset the queue, peer set and wake channels to clean any internal leftover state
-	d.queue = newQueue(d.queue.stateDatabase)
+	d.queue = newQueue(d.stateDb)
 	d.peers.Reset()
 
 	for _, ch := range []chan bool{d.bodyWakeCh, d.receiptWakeCh, d.stateWakeCh} {
@@ -437,8 +466,13 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 	case LightSync:
 		pivot = height
 	case FastSync:
-		// Calculate the new fast/slow sync pivot point
-		if d.fsPivotLock == nil {
+		// Calculate the new fast/slow sync pivot point, resuming any interrupted one
+		if d.fsPivotLock != nil {
+			// Pivot point locked in, use this and do not pick a new one!
+			pivot = d.fsPivotLock.Number.Uint64()
+		} else if progress := d.resumeSyncProgress(height); progress != nil {
+			pivot = progress.Pivot.Number.Uint64()
+		} else {
 			pivotOffset, err := rand.Int(rand.Reader, big.NewInt(int64(fsPivotInterval)))
 			if err != nil {
 				panic(fmt.Sprintf("Failed to access crypto random source: %v", err))
@@ -446,9 +480,6 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 			if height > uint64(fsMinFullBlocks)+pivotOffset.Uint64() {
 				pivot = height - uint64(fsMinFullBlocks) - pivotOffset.Uint64()
 			}
-		} else {
-			// Pivot point locked in, use this and do not pick a new one!
-			pivot = d.fsPivotLock.Number.Uint64()
 		}
 		// If the point is below the origin, move origin back to ensure state download
 		if pivot < origin {
@@ -464,13 +495,16 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 	if d.syncInitHook != nil {
 		d.syncInitHook(origin, height)
 	}
-	return d.spawnSync(origin+1,
+	err = d.spawnSync(origin+1,
 		func() error { return d.fetchHeaders(p, origin+1) },    // Headers are always retrieved
 		func() error { return d.processHeaders(origin+1, td) }, // Headers are always retrieved
 		func() error { return d.fetchBodies(origin + 1) },      // Bodies are retrieved during normal and fast sync
 		func() error { return d.fetchReceipts(origin + 1) },    // Receipts are retrieved during fast sync
 		func() error { return d.fetchNodeData() },              // Node state data is retrieved during fast sync
 	)
+	// Persist any unfinished state retrieval to resume it on the next run
+	d.saveSyncProgress()
+	return err
 }
 
 // spawnSync runs d.process and all given fetcher functions to completion in
@@ -953,8 +987,30 @@ func (d *Downloader) fetchReceipts(from uint64) error {
 // available peers, reserving a chunk of nodes for each, waiting for delivery and
 // also periodically checking for timeouts.
 func (d *Downloader) fetchNodeData() error {
+	if d.snapSync != nil {
+		if err := d.fetchSnapState(); err != nil {
+			return err
+		}
+	}
 	glog.V(logger.Debug).Infof("Downloading node state data")
 
+	// Periodically persist the progress to survive a crash
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(fsProgressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				d.saveSyncProgress()
+			case <-done:
+				return
+			}
+		}
+	}()
+	defer close(done)
+
 	var (
 		deliver = func(packet dataPack) (int, error) {
 			start := time.Now()
@@ -1005,6 +1061,83 @@ func (d *Downloader) fetchNodeData() error {
 	return err
 }
 
+// resumeSyncProgress retrieves the persisted progress of an interrupted fast sync
+// and schedules it for resumption, returning nil if there is nothing to resume.
+// Progress whose pivot fell too far behind the chain head is discarded, moving
+// the pivot: state already committed is reused by any new pivot anyway.
+func (d *Downloader) resumeSyncProgress(height uint64) *core.FastSyncProgress {
+	progress := core.GetFastSyncProgress(d.stateDb)
+	if progress == nil || progress.Pivot == nil {
+		return nil
+	}
+	number := progress.Pivot.Number.Uint64()
+	if number > height {
+		// Remote chain shorter than our pivot, keep the progress for a better peer
+		return nil
+	}
+	if number+uint64(fsPivotMaxAge) < height {
+		glog.V(logger.Info).Infof("Moving stale fast sync pivot #%d (head #%d), dropping %d uncommitted state entries", number, height, len(progress.Nodes))
+		core.DeleteFastSyncProgress(d.stateDb)
+		return nil
+	}
+	glog.V(logger.Info).Infof("Resuming fast sync at pivot #%d [%x…] with %d uncommitted state entries and %d blocks", number, progress.Pivot.Hash().Bytes()[:4], len(progress.Nodes), len(progress.Results))
+	d.queue.ResumeProgress(progress)
+	return progress
+}
+
+// saveSyncProgress persists the progress of the pivot state retrieval and the
+// blocks retrieved but not yet imported, allowing a restarted node to resume it
+// instead of starting over.
+func (d *Downloader) saveSyncProgress() {
+	progress := d.queue.SyncProgress()
+	if progress == nil {
+		return
+	}
+	if err := core.WriteFastSyncProgress(d.stateDb, progress); err != nil {
+		glog.V(logger.Warn).Infof("Failed to persist fast sync progress: %v", err)
+		return
+	}
+	glog.V(logger.Debug).Infof("Persisted fast sync progress at pivot #%d: %d uncommitted state entries, %d blocks", progress.Pivot.Number, len(progress.Nodes), len(progress.Results))
+}
+
+// fetchSnapState waits for the state root of the sync to be scheduled and then
+// retrieves the bulk of the state in ranges, leaving only the range boundaries
+// to be fetched node by node. Failures fall back to plain node data retrieval.
+func (d *Downloader) fetchSnapState() error {
+	for {
+		if root := d.queue.StateRoot(); root != (common.Hash{}) {
+			start := time.Now()
+			if err := d.snapSync(root, d.cancelCh); err != nil {
+				select {
+				case <-d.cancelCh:
+					return errCancelStateFetch
+				default:
+				}
+				glog.V(logger.Info).Infof("Snapshot sync of state %x failed, falling back to node data: %v", root[:4], err)
+				return nil
+			}
+			glog.V(logger.Info).Infof("Snapshot synced state %x in %v, healing boundaries", root[:4], time.Since(start))
+			return nil
+		}
+		select {
+		case <-d.cancelCh:
+			return errCancelStateFetch
+
+		case cont := <-d.stateWakeCh:
+			if !cont {
+				// Header processing finished, hand the signal over to the node fetcher
+				select {
+				case d.stateWakeCh <- false:
+				default:
+				}
+				if d.queue.StateRoot() == (common.Hash{}) {
+					return nil
+				}
+			}
+		}
+	}
+}
+
 // fetchParts iteratively downloads scheduled block parts, taking any available
 // peers, reserving a chunk of fetch requests for each, waiting for delivery and
 // also periodically checking for timeouts.
@@ -1116,6 +1249,7 @@ func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliv
 						setIdle(peer, 0)
 					} else {
 						glog.V(logger.Debug).Infof("%s: stalling %s delivery, dropping", peer, strings.ToLower(kind))
+						d.reportPeer(pid, p2p.PeerTimeout)
 						d.dropPeer(pid)
 					}
 				}
@@ -1404,6 +1538,9 @@ func (d *Downloader) processContent() error {
 				if err == nil && blocks[len(blocks)-1].NumberU64() == pivot {
 					glog.V(logger.Debug).Infof("Committing block #%d [%x…] as the new head", blocks[len(blocks)-1].Number(), blocks[len(blocks)-1].Hash().Bytes()[:4])
 					index, err = len(blocks)-1, d.commitHeadBlock(blocks[len(blocks)-1].Hash())
+					if err == nil {
+						core.DeleteFastSyncProgress(d.stateDb)
+					}
 				}
 			default:
 				index, err = d.insertBlocks(blocks)
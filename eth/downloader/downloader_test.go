@@ -33,6 +33,7 @@ import (
 	"github.com/ethereumproject/go-ethereum/crypto"
 	"github.com/ethereumproject/go-ethereum/ethdb"
 	"github.com/ethereumproject/go-ethereum/event"
+	"github.com/ethereumproject/go-ethereum/p2p"
 	"github.com/ethereumproject/go-ethereum/trie"
 )
 
@@ -177,6 +178,7 @@ type downloadTester struct {
 	peerChainTds map[string]map[common.Hash]*big.Int       // Total difficulties of the blocks in the peer chains
 
 	peerMissingStates map[string]map[common.Hash]bool // State entries that fast sync should not return
+	peerReports       map[string][]p2p.PeerEvent      // Behaviour reported by the downloader per peer
 
 	lock sync.RWMutex
 }
@@ -195,13 +197,14 @@ func newTester() *downloadTester {
 		peerReceipts:      make(map[string]map[common.Hash]types.Receipts),
 		peerChainTds:      make(map[string]map[common.Hash]*big.Int),
 		peerMissingStates: make(map[string]map[common.Hash]bool),
+		peerReports:       make(map[string][]p2p.PeerEvent),
 	}
 	tester.stateDb, _ = ethdb.NewMemDatabase()
 	tester.stateDb.Put(genesis.Root().Bytes(), []byte{0x00})
 
 	tester.downloader = New(tester.stateDb, new(event.TypeMux), tester.hasHeader, tester.hasBlock, tester.getHeader,
 		tester.getBlock, tester.headHeader, tester.headBlock, tester.headFastBlock, tester.commitHeadBlock, tester.getTd,
-		tester.insertHeaders, tester.insertBlocks, tester.insertReceipts, tester.rollback, tester.dropPeer)
+		tester.insertHeaders, tester.insertBlocks, tester.insertReceipts, tester.rollback, tester.dropPeer, tester.reportPeer, nil)
 
 	return tester
 }
@@ -490,6 +493,15 @@ func (dl *downloadTester) dropPeer(id string) {
 	dl.downloader.UnregisterPeer(id)
 }
 
+// reportPeer simulates the reputation tracker, accumulating the behaviour
+// reported about a peer.
+func (dl *downloadTester) reportPeer(id string, event p2p.PeerEvent) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.peerReports[id] = append(dl.peerReports[id], event)
+}
+
 // peerCurrentHeadFn constructs a function to retrieve a peer's current head hash
 // and total difficulty.
 func (dl *downloadTester) peerCurrentHeadFn(id string) func() (common.Hash, *big.Int) {
@@ -1374,6 +1386,16 @@ func testBlockHeaderAttackerDropping(t *testing.T, protocol int) {
 		if _, ok := tester.peerHashes[id]; !ok != tt.drop {
 			t.Errorf("test %d: peer drop mismatch for %v: have %v, want %v", i, tt.result, !ok, tt.drop)
 		}
+		// Dropped peers must have their misbehaviour reported, successful ones their delivery
+		reports := tester.peerReports[id]
+		switch {
+		case tt.drop && (len(reports) != 1 || reports[0] != peerEventForError(tt.result)):
+			t.Errorf("test %d: peer report mismatch for %v: have %v, want [%v]", i, tt.result, reports, peerEventForError(tt.result))
+		case tt.result == nil && (len(reports) != 1 || reports[0] != p2p.PeerGoodDelivery):
+			t.Errorf("test %d: peer report mismatch for successful sync: have %v, want [%v]", i, reports, p2p.PeerGoodDelivery)
+		case !tt.drop && tt.result != nil && len(reports) != 0:
+			t.Errorf("test %d: unexpected peer reports for %v: %v", i, tt.result, reports)
+		}
 	}
 }
 
@@ -1789,3 +1811,134 @@ func testFastCriticalRestarts(t *testing.T, protocol int) {
 	}
 	assertOwnChain(t, tester, targetBlocks+1)
 }
+
+// Tests that an interrupted fast sync resumes at its persisted pivot, restoring
+// the uncommitted state entries instead of retrieving them again.
+func TestFastSyncResume63(t *testing.T) { testFastSyncResume(t, 63) }
+func TestFastSyncResume64(t *testing.T) { testFastSyncResume(t, 64) }
+
+func testFastSyncResume(t *testing.T, protocol int) {
+	t.Parallel()
+
+	targetBlocks := blockCacheLimit - 15
+	hashes, headers, blocks, receipts := makeChain(targetBlocks, 0, genesis, nil, false)
+
+	tester := newTester()
+	defer tester.terminate()
+
+	// Persist the progress of a sync which retrieved the pivot's state root, and
+	// make sure the peer cannot serve it again
+	pivot := headers[hashes[len(hashes)-1-targetBlocks/2]]
+	root, _ := testdb.Get(pivot.Root.Bytes())
+	core.WriteFastSyncProgress(tester.stateDb, &core.FastSyncProgress{Pivot: pivot, Nodes: [][]byte{root}})
+
+	tester.newPeer("peer", protocol, hashes, headers, blocks, receipts)
+	tester.peerMissingStates["peer"][pivot.Root] = true
+
+	if err := tester.sync("peer", nil, FastSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	if have, want := tester.downloader.queue.FastSyncPivot(), pivot.Number.Uint64(); have != want {
+		t.Fatalf("pivot mismatch: have %d, want %d", have, want)
+	}
+	if hs := len(tester.ownHeaders); hs != targetBlocks+1 {
+		t.Errorf("synchronised headers mismatch: have %v, want %v", hs, targetBlocks+1)
+	}
+	if bs := len(tester.ownBlocks); bs != targetBlocks+1 {
+		t.Errorf("synchronised blocks mismatch: have %v, want %v", bs, targetBlocks+1)
+	}
+	if rs := len(tester.ownReceipts); rs != int(pivot.Number.Uint64())+1 {
+		t.Errorf("synchronised receipts mismatch: have %v, want %v", rs, pivot.Number.Uint64()+1)
+	}
+	if statedb, err := state.New(pivot.Root, tester.stateDb); statedb == nil || err != nil {
+		t.Errorf("pivot state reconstruction failed: %v", err)
+	}
+	if progress := core.GetFastSyncProgress(tester.stateDb); progress != nil {
+		t.Errorf("progress not removed after pivot commit")
+	}
+}
+
+// Tests that the persisted progress of a pivot too far behind the chain head is
+// dropped and a new pivot picked.
+func TestFastSyncStaleResume63(t *testing.T) { testFastSyncStaleResume(t, 63) }
+func TestFastSyncStaleResume64(t *testing.T) { testFastSyncStaleResume(t, 64) }
+
+func testFastSyncStaleResume(t *testing.T, protocol int) {
+	t.Parallel()
+
+	targetBlocks := fsPivotMaxAge + fsMinFullBlocks
+	hashes, headers, blocks, receipts := makeChain(targetBlocks, 0, genesis, nil, false)
+
+	tester := newTester()
+	defer tester.terminate()
+
+	pivot := headers[hashes[len(hashes)-2]]
+	core.WriteFastSyncProgress(tester.stateDb, &core.FastSyncProgress{Pivot: pivot})
+
+	tester.newPeer("peer", protocol, hashes, headers, blocks, receipts)
+	if err := tester.sync("peer", nil, FastSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, targetBlocks+1)
+
+	if have := tester.downloader.queue.FastSyncPivot(); have <= pivot.Number.Uint64()+uint64(fsPivotMaxAge/2) {
+		t.Errorf("stale pivot not moved: have #%d, stale #%d", have, pivot.Number)
+	}
+	if progress := core.GetFastSyncProgress(tester.stateDb); progress != nil {
+		t.Errorf("stale progress not removed")
+	}
+}
+
+// Tests that an interrupted fast sync reuses the blocks it retrieved but didn't
+// import before the interruption, instead of retrieving them again.
+func TestFastSyncResumeBlocks63(t *testing.T) { testFastSyncResumeBlocks(t, 63) }
+func TestFastSyncResumeBlocks64(t *testing.T) { testFastSyncResumeBlocks(t, 64) }
+
+func testFastSyncResumeBlocks(t *testing.T, protocol int) {
+	t.Parallel()
+
+	targetBlocks := blockCacheLimit - 15
+	hashes, headers, blocks, receipts := makeChain(targetBlocks, 0, genesis, nil, false)
+
+	tester := newTester()
+	defer tester.terminate()
+
+	// Persist the progress of a sync which retrieved a few blocks with contents
+	// below the pivot, and make sure the peer cannot serve them again
+	pivot := headers[hashes[len(hashes)-1-targetBlocks/2]]
+	tester.newPeer("peer", protocol, hashes, headers, blocks, receipts)
+
+	progress := &core.FastSyncProgress{Pivot: pivot}
+	for _, number := range []int{1, 4, 7, 10} {
+		hash := hashes[len(hashes)-1-number]
+		block := blocks[hash]
+		progress.Results = append(progress.Results, &core.FastSyncResult{
+			Header:       block.Header(),
+			Uncles:       block.Uncles(),
+			Transactions: block.Transactions(),
+			Receipts:     receipts[hash],
+		})
+		delete(tester.peerBlocks["peer"], hash)
+		delete(tester.peerReceipts["peer"], hash)
+	}
+	core.WriteFastSyncProgress(tester.stateDb, progress)
+
+	if err := tester.sync("peer", nil, FastSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	if bs := len(tester.ownBlocks); bs != targetBlocks+1 {
+		t.Errorf("synchronised blocks mismatch: have %v, want %v", bs, targetBlocks+1)
+	}
+	if rs := len(tester.ownReceipts); rs != int(pivot.Number.Uint64())+1 {
+		t.Errorf("synchronised receipts mismatch: have %v, want %v", rs, pivot.Number.Uint64()+1)
+	}
+	for _, result := range progress.Results {
+		hash := result.Header.Hash()
+		if block := tester.ownBlocks[hash]; block == nil || len(block.Transactions()) != len(result.Transactions) {
+			t.Errorf("block #%d not imported with its transactions", result.Header.Number)
+		}
+		if len(tester.ownReceipts[hash]) != len(result.Receipts) {
+			t.Errorf("block #%d receipts mismatch: have %d, want %d", result.Header.Number, len(tester.ownReceipts[hash]), len(result.Receipts))
+		}
+	}
+}
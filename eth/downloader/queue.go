@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core"
 	"github.com/ethereumproject/go-ethereum/core/state"
 	"github.com/ethereumproject/go-ethereum/core/types"
 	"github.com/ethereumproject/go-ethereum/crypto"
@@ -104,13 +105,17 @@ type queue struct {
 	stateTaskQueue *prque.Prque             // [eth/63] Priority queue of the hashes to fetch the node data for
 	statePendPool  map[string]*fetchRequest // [eth/63] Currently pending node data retrieval operations
 
-	stateDatabase   ethdb.Database   // [eth/63] Trie database to populate during state reassembly
-	stateScheduler  *state.StateSync // [eth/63] State trie synchronisation scheduler and integrator
-	stateProcessors int32            // [eth/63] Number of currently running state processors
-	stateSchedLock  sync.RWMutex     // [eth/63] Lock serialising access to the state scheduler
+	stateDatabase   ethdb.Database         // [eth/63] Trie database to populate during state reassembly
+	stateScheduler  *state.StateSync       // [eth/63] State trie synchronisation scheduler and integrator
+	stateRoot       common.Hash            // [eth/63] Root of the state trie being synchronised
+	statePivot      *types.Header          // [eth/63] Pivot block whose state is being synchronised
+	stateResume     *core.FastSyncProgress // [eth/63] Progress of an interrupted sync to resume at the pivot
+	stateProcessors int32                  // [eth/63] Number of currently running state processors
+	stateSchedLock  sync.RWMutex           // [eth/63] Lock serialising access to the state scheduler
 
-	resultCache  []*fetchResult // Downloaded but not yet delivered fetch results
-	resultOffset uint64         // Offset of the first cached fetch result in the block chain
+	resultCache  []*fetchResult                       // Downloaded but not yet delivered fetch results
+	resultOffset uint64                               // Offset of the first cached fetch result in the block chain
+	resultResume map[common.Hash]*core.FastSyncResult // [eth/63] Results of an interrupted sync to reuse instead of fetching
 
 	active *sync.Cond
 	done   chan struct{}
@@ -175,6 +180,70 @@ func (q *queue) PendingNodeData() int {
 	return 0
 }
 
+// StateRoot retrieves the root of the state trie scheduled for retrieval, or the
+// zero hash if none is scheduled yet.
+func (q *queue) StateRoot() common.Hash {
+	q.stateSchedLock.RLock()
+	defer q.stateSchedLock.RUnlock()
+
+	return q.stateRoot
+}
+
+// ResumeProgress schedules the progress of an interrupted sync to be restored.
+// Its state entries are restored once its pivot header is reached, a different
+// pivot header discarding them. Its retrieved blocks are used in place of any
+// body and receipt fetches of the same headers.
+func (q *queue) ResumeProgress(progress *core.FastSyncProgress) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.resultResume = make(map[common.Hash]*core.FastSyncResult, len(progress.Results))
+	for _, result := range progress.Results {
+		q.resultResume[result.Header.Hash()] = result
+	}
+	q.stateSchedLock.Lock()
+	q.stateResume = progress
+	q.stateSchedLock.Unlock()
+}
+
+// SyncProgress retrieves the progress of the pivot state retrieval and of the
+// blocks up to the pivot retrieved but not yet delivered, needed to resume them
+// later, or nil if there is nothing to resume.
+func (q *queue) SyncProgress() *core.FastSyncProgress {
+	q.Lock()
+	defer q.Unlock()
+
+	q.stateSchedLock.RLock()
+	defer q.stateSchedLock.RUnlock()
+
+	if q.statePivot == nil || q.stateScheduler == nil {
+		return nil
+	}
+	// Gather the completed results and the resumed ones not yet reused
+	var results []*core.FastSyncResult
+	for _, result := range q.resultCache {
+		if result == nil || result.Pending > 0 || result.Header.Number.Uint64() > q.fastSyncPivot {
+			continue
+		}
+		if _, ok := q.resultResume[result.Header.Hash()]; ok {
+			continue
+		}
+		results = append(results, &core.FastSyncResult{
+			Header:       result.Header,
+			Uncles:       result.Uncles,
+			Transactions: result.Transactions,
+			Receipts:     result.Receipts,
+		})
+	}
+	for _, result := range q.resultResume {
+		results = append(results, result)
+	}
+	if q.stateScheduler.Pending() == 0 && len(results) == 0 {
+		return nil
+	}
+	return &core.FastSyncProgress{Pivot: q.statePivot, Nodes: q.stateScheduler.Uncommitted(), Results: results}
+}
+
 // InFlightHeaders retrieves whether there are header fetch requests currently
 // in flight.
 func (q *queue) InFlightHeaders() bool {
@@ -348,6 +417,18 @@ func (q *queue) Schedule(headers []*types.Header, from uint64) []*types.Header {
 			// Pivoting point of the fast sync, retrieve the state tries
 			q.stateSchedLock.Lock()
 			q.stateScheduler = state.NewStateSync(header.Root, q.stateDatabase)
+			q.stateRoot, q.statePivot = header.Root, header
+
+			if resume := q.stateResume; resume != nil && resume.Pivot.Hash() == hash {
+				restored, err := q.stateScheduler.Restore(resume.Nodes)
+				if err != nil {
+					glog.V(logger.Warn).Infof("Failed to restore state progress of pivot #%d: %v", header.Number, err)
+					q.stateScheduler = state.NewStateSync(header.Root, q.stateDatabase)
+				} else {
+					glog.V(logger.Info).Infof("Restored %d uncommitted state entries of pivot #%d", restored, header.Number)
+				}
+			}
+			q.stateResume = nil
 			q.stateSchedLock.Unlock()
 		}
 		inserts = append(inserts, header)
@@ -389,6 +470,7 @@ func (q *queue) WaitResults() []*fetchResult {
 			hash := result.Header.Hash()
 			delete(q.blockDonePool, hash)
 			delete(q.receiptDonePool, hash)
+			delete(q.resultResume, hash)
 		}
 		// Delete the results from the cache and clear the tail.
 		copy(q.resultCache, q.resultCache[nproc:])
@@ -561,8 +643,18 @@ func (q *queue) reserveHashes(p *peer, count int, taskQueue *prque.Prque, taskGe
 // previously failed downloads. Beside the next batch of needed fetches, it also
 // returns a flag whether empty blocks were queued requiring processing.
 func (q *queue) ReserveBodies(p *peer, count int) (*fetchRequest, bool, error) {
-	isNoop := func(header *types.Header) bool {
-		return header.TxHash == types.EmptyRootHash && header.UncleHash == types.EmptyUncleHash
+	isNoop := func(header *types.Header, result *fetchResult) bool {
+		if header.TxHash == types.EmptyRootHash && header.UncleHash == types.EmptyUncleHash {
+			return true
+		}
+		// Reuse the body retrieved by an interrupted sync, if any
+		if resumed := q.resultResume[header.Hash()]; resumed != nil {
+			if types.DeriveSha(resumed.Transactions) == header.TxHash && types.CalcUncleHash(resumed.Uncles) == header.UncleHash {
+				result.Transactions, result.Uncles = resumed.Transactions, resumed.Uncles
+				return true
+			}
+		}
+		return false
 	}
 	q.Lock()
 	defer q.Unlock()
@@ -574,8 +666,16 @@ func (q *queue) ReserveBodies(p *peer, count int) (*fetchRequest, bool, error) {
 // any previously failed downloads. Beside the next batch of needed fetches, it
 // also returns a flag whether empty receipts were queued requiring importing.
 func (q *queue) ReserveReceipts(p *peer, count int) (*fetchRequest, bool, error) {
-	isNoop := func(header *types.Header) bool {
-		return header.ReceiptHash == types.EmptyRootHash
+	isNoop := func(header *types.Header, result *fetchResult) bool {
+		if header.ReceiptHash == types.EmptyRootHash {
+			return true
+		}
+		// Reuse the receipts retrieved by an interrupted sync, if any
+		if resumed := q.resultResume[header.Hash()]; resumed != nil && types.DeriveSha(resumed.Receipts) == header.ReceiptHash {
+			result.Receipts = resumed.Receipts
+			return true
+		}
+		return false
 	}
 	q.Lock()
 	defer q.Unlock()
@@ -585,13 +685,14 @@ func (q *queue) ReserveReceipts(p *peer, count int) (*fetchRequest, bool, error)
 
 // reserveHeaders reserves a set of data download operations for a given peer,
 // skipping any previously failed ones. This method is a generic version used
-// by the individual special reservation functions.
+// by the individual special reservation functions. Fetches reported as noops
+// by isNoop are completed without any retrieval, isNoop filling in the result.
 //
 // Note, this method expects the queue lock to be already held for writing. The
 // reason the lock is not obtained in here is because the parameters already need
 // to access the queue, so they already need a lock anyway.
 func (q *queue) reserveHeaders(p *peer, count int, taskPool map[common.Hash]*types.Header, taskQueue *prque.Prque,
-	pendPool map[string]*fetchRequest, donePool map[common.Hash]struct{}, isNoop func(*types.Header) bool) (*fetchRequest, bool, error) {
+	pendPool map[string]*fetchRequest, donePool map[common.Hash]struct{}, isNoop func(*types.Header, *fetchResult) bool) (*fetchRequest, bool, error) {
 	// Short circuit if the pool has been depleted, or if the peer's already
 	// downloading something (sanity check not to corrupt state)
 	if taskQueue.Empty() {
@@ -634,7 +735,7 @@ func (q *queue) reserveHeaders(p *peer, count int, taskPool map[common.Hash]*typ
 		}
 
 		// If this fetch task is a noop, skip this fetch operation
-		if isNoop(header) {
+		if isNoop(header, q.resultCache[index]) {
 			donePool[header.Hash()] = struct{}{}
 			delete(taskPool, header.Hash())
 
@@ -1074,5 +1175,6 @@ func (q *queue) Prepare(offset uint64, mode SyncMode, pivot uint64, head *types.
 	// If long running fast sync, also start up a head stateretrieval immediately
 	if mode == FastSync && pivot > 0 {
 		q.stateScheduler = state.NewStateSync(head.Root, q.stateDatabase)
+		q.stateRoot = head.Root
 	}
 }
@@ -22,6 +22,7 @@ import (
 
 	"github.com/ethereumproject/go-ethereum/common"
 	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/p2p"
 )
 
 // headerCheckFn is a callback type for verifying a header's presence in the local chain.
@@ -66,6 +67,14 @@ type chainRollbackFn func([]common.Hash)
 // peerDropFn is a callback type for dropping a peer detected as malicious.
 type peerDropFn func(id string)
 
+// peerReportFn is a callback type for feeding observed peer behaviour into the
+// reputation tracker.
+type peerReportFn func(id string, event p2p.PeerEvent)
+
+// stateSyncFn is a callback type for retrieving the bulk of a state trie out of
+// band, before the remaining nodes are fetched individually.
+type stateSyncFn func(root common.Hash, cancel <-chan struct{}) error
+
 // dataPack is a data message returned by a peer for some query.
 type dataPack interface {
 	PeerId() string
@@ -29,6 +29,7 @@ import (
 	"github.com/ethereumproject/go-ethereum/logger"
 	"github.com/ethereumproject/go-ethereum/logger/glog"
 	"github.com/ethereumproject/go-ethereum/metrics"
+	"github.com/ethereumproject/go-ethereum/p2p"
 	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
 )
 
@@ -70,6 +71,10 @@ type chainInsertFn func(types.Blocks) (int, error)
 // peerDropFn is a callback type for dropping a peer detected as malicious.
 type peerDropFn func(id string)
 
+// peerReportFn is a callback type for feeding observed peer behaviour into the
+// reputation tracker.
+type peerReportFn func(id string, event p2p.PeerEvent)
+
 // announce is the hash notification of the availability of a new block in the
 // network.
 type announce struct {
@@ -137,6 +142,7 @@ type Fetcher struct {
 	chainHeight    chainHeightFn      // Retrieves the current chain's height
 	insertChain    chainInsertFn      // Injects a batch of blocks into the chain
 	dropPeer       peerDropFn         // Drops a peer for misbehaving
+	reportPeer     peerReportFn       // Reports peer behaviour to the reputation tracker
 
 	// Testing hooks
 	announceChangeHook func(common.Hash, bool) // Method to call upon adding or deleting a hash from the announce list
@@ -147,7 +153,7 @@ type Fetcher struct {
 }
 
 // New creates a block fetcher to retrieve blocks based on hash announcements.
-func New(getBlock blockRetrievalFn, validateBlock blockValidatorFn, broadcastBlock blockBroadcasterFn, chainHeight chainHeightFn, insertChain chainInsertFn, dropPeer peerDropFn) *Fetcher {
+func New(getBlock blockRetrievalFn, validateBlock blockValidatorFn, broadcastBlock blockBroadcasterFn, chainHeight chainHeightFn, insertChain chainInsertFn, dropPeer peerDropFn, reportPeer peerReportFn) *Fetcher {
 	return &Fetcher{
 		notify:         make(chan *announce),
 		inject:         make(chan *inject),
@@ -170,6 +176,7 @@ func New(getBlock blockRetrievalFn, validateBlock blockValidatorFn, broadcastBlo
 		chainHeight:    chainHeight,
 		insertChain:    insertChain,
 		dropPeer:       dropPeer,
+		reportPeer:     reportPeer,
 	}
 }
 
@@ -467,6 +474,7 @@ func (f *Fetcher) loop() {
 					// If the delivered header does not match the promised number, drop the announcer
 					if header.Number.Uint64() != announce.number {
 						glog.V(logger.Detail).Infof("[eth/62] Peer %s: invalid block number for [%x…]: announced %d, provided %d", announce.origin, header.Hash().Bytes()[:4], announce.number, header.Number.Uint64())
+						f.reportPeer(announce.origin, p2p.PeerProtocolBreach)
 						f.dropPeer(announce.origin)
 						f.forgetHash(hash)
 						continue
@@ -681,14 +689,20 @@ func (f *Fetcher) insert(peer string, block *types.Block) {
 		default:
 			// Something went very wrong, drop the peer
 			glog.V(logger.Debug).Infof("Peer %s: block #%d [%x…] verification failed: %v", peer, block.NumberU64(), hash[:4], err)
+			f.reportPeer(peer, p2p.PeerInvalidBlock)
 			f.dropPeer(peer)
 			return
 		}
 		// Run the actual import and log any issues
 		if _, err := f.insertChain(types.Blocks{block}); err != nil {
 			glog.V(logger.Warn).Infof("Peer %s: block #%d [%x…] import failed: %v", peer, block.NumberU64(), hash[:4], err)
+			if core.IsValidateError(err) {
+				f.reportPeer(peer, p2p.PeerInvalidBlock)
+			}
 			return
 		}
+		f.reportPeer(peer, p2p.PeerGoodDelivery)
+
 		// If import succeeded, broadcast the block
 		metrics.FetchAnnounceTimer.UpdateSince(block.ReceivedAt)
 		go f.broadcastBlock(block, false)
@@ -32,6 +32,7 @@ import (
 	"github.com/ethereumproject/go-ethereum/core/types"
 	"github.com/ethereumproject/go-ethereum/crypto"
 	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/p2p"
 )
 
 var (
@@ -113,6 +114,8 @@ type fetcherTester struct {
 	blocks map[common.Hash]*types.Block // Blocks belonging to the tester
 	drops  map[string]bool              // Map of peers dropped by the fetcher
 
+	reports map[string][]p2p.PeerEvent // Behaviour reported by the fetcher per peer
+
 	lock sync.RWMutex
 }
 
@@ -122,8 +125,10 @@ func newTester() *fetcherTester {
 		hashes: []common.Hash{genesis.Hash()},
 		blocks: map[common.Hash]*types.Block{genesis.Hash(): genesis},
 		drops:  make(map[string]bool),
+
+		reports: make(map[string][]p2p.PeerEvent),
 	}
-	tester.fetcher = New(tester.getBlock, tester.verifyBlock, tester.broadcastBlock, tester.chainHeight, tester.insertChain, tester.dropPeer)
+	tester.fetcher = New(tester.getBlock, tester.verifyBlock, tester.broadcastBlock, tester.chainHeight, tester.insertChain, tester.dropPeer, tester.reportPeer)
 	tester.fetcher.Start()
 
 	return tester
@@ -184,6 +189,15 @@ func (f *fetcherTester) dropPeer(peer string) {
 	f.drops[peer] = true
 }
 
+// reportPeer is an emulator for the reputation tracker, simply accumulating the
+// behaviour reported by the fetcher.
+func (f *fetcherTester) reportPeer(peer string, event p2p.PeerEvent) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.reports[peer] = append(f.reports[peer], event)
+}
+
 // makeHeaderFetcher retrieves a block header fetcher associated with a simulated peer.
 func (f *fetcherTester) makeHeaderFetcher(blocks map[common.Hash]*types.Block, drift time.Duration) headerRequesterFn {
 	closure := make(map[common.Hash]*types.Block)
@@ -645,22 +659,30 @@ func testInvalidNumberAnnouncement(t *testing.T, protocol int) {
 
 	tester.lock.RLock()
 	dropped := tester.drops["bad"]
+	reports := tester.reports["bad"]
 	tester.lock.RUnlock()
 
 	if !dropped {
 		t.Fatalf("peer with invalid numbered announcement not dropped")
 	}
+	if len(reports) != 1 || reports[0] != p2p.PeerProtocolBreach {
+		t.Fatalf("peer with invalid numbered announcement reports mismatch: have %v, want [%v]", reports, p2p.PeerProtocolBreach)
+	}
 	// Make sure a good announcement passes without a drop
 	tester.fetcher.Notify("good", hashes[0], 1, time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
 	verifyImportEvent(t, imported, true)
 
 	tester.lock.RLock()
 	dropped = tester.drops["good"]
+	reports = tester.reports["good"]
 	tester.lock.RUnlock()
 
 	if dropped {
 		t.Fatalf("peer with valid numbered announcement dropped")
 	}
+	if len(reports) != 1 || reports[0] != p2p.PeerGoodDelivery {
+		t.Fatalf("peer with valid numbered announcement reports mismatch: have %v, want [%v]", reports, p2p.PeerGoodDelivery)
+	}
 	verifyImportDone(t, imported)
 }
 
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core"
 	"github.com/ethereumproject/go-ethereum/core/types"
 	"github.com/ethereumproject/go-ethereum/core/vm"
 	"github.com/ethereumproject/go-ethereum/ethdb"
@@ -51,8 +52,9 @@ const (
 type PublicFilterAPI struct {
 	mux *event.TypeMux
 
-	quit    chan struct{}
-	chainDb ethdb.Database
+	quit        chan struct{}
+	chainDb     ethdb.Database
+	maxLogRange uint64 // Maximum number of blocks a log query may span (0 = unlimited)
 
 	filterManager *FilterSystem
 
@@ -69,11 +71,13 @@ type PublicFilterAPI struct {
 	transactionQueue map[int]*hashQueue
 }
 
-// NewPublicFilterAPI returns a new PublicFilterAPI instance.
-func NewPublicFilterAPI(chainDb ethdb.Database, mux *event.TypeMux) *PublicFilterAPI {
+// NewPublicFilterAPI returns a new PublicFilterAPI instance. Log queries spanning
+// more than maxLogRange blocks are rejected, unless it's zero.
+func NewPublicFilterAPI(chainDb ethdb.Database, mux *event.TypeMux, maxLogRange uint64) *PublicFilterAPI {
 	svc := &PublicFilterAPI{
 		mux:              mux,
 		chainDb:          chainDb,
+		maxLogRange:      maxLogRange,
 		filterManager:    NewFilterSystem(mux),
 		filterMapping:    make(map[string]int),
 		logQueue:         make(map[int]*logQueue),
@@ -242,6 +246,10 @@ func (s *PublicFilterAPI) newLogFilter(earliest, latest int64, addresses []commo
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
+//
+// Logs are delivered in chain order. When a reorg drops blocks whose logs were already delivered, those logs are
+// delivered again with the "removed" field set, in the reverse of their original order, before any log of the new
+// chain. Replaying the notifications in order therefore always reflects the logs of the canonical chain.
 func (s *PublicFilterAPI) Logs(ctx context.Context, args NewFilterArgs) (rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
@@ -416,6 +424,9 @@ func (args *NewFilterArgs) UnmarshalJSON(data []byte) error {
 
 // NewFilter creates a new filter and returns the filter id. It can be uses to retrieve logs.
 func (s *PublicFilterAPI) NewFilter(args NewFilterArgs) (string, error) {
+	if err := s.checkLogRange(args.FromBlock.Int64(), args.ToBlock.Int64()); err != nil {
+		return "", err
+	}
 	externalId, err := newFilterId()
 	if err != nil {
 		return "", err
@@ -439,14 +450,57 @@ func (s *PublicFilterAPI) NewFilter(args NewFilterArgs) (string, error) {
 }
 
 // GetLogs returns the logs matching the given argument.
-func (s *PublicFilterAPI) GetLogs(args NewFilterArgs) []vmlog {
+func (s *PublicFilterAPI) GetLogs(ctx context.Context, args NewFilterArgs) ([]vmlog, error) {
+	if err := s.checkLogRange(args.FromBlock.Int64(), args.ToBlock.Int64()); err != nil {
+		return nil, err
+	}
 	filter := New(s.chainDb)
 	filter.SetBeginBlock(args.FromBlock.Int64())
 	filter.SetEndBlock(args.ToBlock.Int64())
 	filter.SetAddresses(args.Addresses)
 	filter.SetTopics(args.Topics)
 
-	return toRPCLogs(filter.Find(), false)
+	logs, err := filter.Find(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toRPCLogs(logs, false), nil
+}
+
+// logRangeError is returned for log queries spanning more blocks than allowed.
+type logRangeError struct {
+	blocks, limit uint64
+}
+
+func (e *logRangeError) Code() int {
+	return -32005
+}
+
+func (e *logRangeError) Error() string {
+	return fmt.Sprintf("log query spans too many blocks (%d, limit %d)", e.blocks, e.limit)
+}
+
+// checkLogRange verifies that a log query from begin till end, with -1 meaning
+// the latest block, doesn't span more blocks than configured.
+func (s *PublicFilterAPI) checkLogRange(begin, end int64) error {
+	if s.maxLogRange == 0 {
+		return nil
+	}
+	var head uint64
+	if header := core.GetHeader(s.chainDb, core.GetHeadBlockHash(s.chainDb)); header != nil {
+		head = header.Number.Uint64()
+	}
+	from, to := uint64(begin), uint64(end)
+	if begin < 0 {
+		from = head
+	}
+	if end < 0 {
+		to = head
+	}
+	if to >= from && to-from+1 > s.maxLogRange {
+		return &logRangeError{to - from + 1, s.maxLogRange}
+	}
+	return nil
 }
 
 // UninstallFilter removes the filter with the given filter id.
@@ -540,19 +594,27 @@ func (s *PublicFilterAPI) logFilterChanged(id int) []vmlog {
 }
 
 // GetFilterLogs returns the logs for the filter with the given id.
-func (s *PublicFilterAPI) GetFilterLogs(filterId string) []vmlog {
+func (s *PublicFilterAPI) GetFilterLogs(ctx context.Context, filterId string) ([]vmlog, error) {
 	s.filterMapMu.RLock()
 	id, ok := s.filterMapping[filterId]
 	s.filterMapMu.RUnlock()
 	if !ok {
-		return toRPCLogs(nil, false)
+		return toRPCLogs(nil, false), nil
 	}
 
 	if filter := s.filterManager.Get(id); filter != nil {
-		return toRPCLogs(filter.Find(), false)
+		// filters up to the latest block grow with the chain, check them again
+		if err := s.checkLogRange(filter.begin, filter.end); err != nil {
+			return nil, err
+		}
+		logs, err := filter.Find(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return toRPCLogs(logs, false), nil
 	}
 
-	return toRPCLogs(nil, false)
+	return toRPCLogs(nil, false), nil
 }
 
 // GetFilterChanges returns the logs for the filter with the given id since last time is was called.
@@ -583,6 +645,14 @@ type vmlog struct {
 	Removed bool `json:"removed"`
 }
 
+// MarshalJSON implements json.Marshaler, flagging the encoded log as removed
+// (the embedded log would otherwise be encoded on its own).
+func (l vmlog) MarshalJSON() ([]byte, error) {
+	log := *l.Log
+	log.Removed = l.Removed
+	return json.Marshal(&log)
+}
+
 type logQueue struct {
 	mu sync.Mutex
 
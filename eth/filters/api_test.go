@@ -17,12 +17,20 @@
 package filters_test
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/crypto"
 	"github.com/ethereumproject/go-ethereum/eth/filters"
+	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/event"
 	"github.com/ethereumproject/go-ethereum/rpc"
 )
 
@@ -196,3 +204,121 @@ func TestUnmarshalJSONNewFilterArgs(t *testing.T) {
 		)
 	}
 }
+
+func TestLogBlockRangeLimit(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	head := &types.Header{Number: big.NewInt(100), Difficulty: big.NewInt(1)}
+	core.WriteHeader(db, head)
+	core.WriteHeadBlockHash(db, head.Hash())
+
+	api := filters.NewPublicFilterAPI(db, new(event.TypeMux), 10)
+
+	tests := []struct {
+		from, to rpc.BlockNumber
+		fail     bool
+	}{
+		{0, 9, false},
+		{0, 10, true},
+		{91, rpc.LatestBlockNumber, false},
+		{90, rpc.LatestBlockNumber, true},
+		{rpc.LatestBlockNumber, rpc.LatestBlockNumber, false},
+		{20, 10, false},
+	}
+	for i, tt := range tests {
+		args := filters.NewFilterArgs{FromBlock: tt.from, ToBlock: tt.to}
+		_, err := api.GetLogs(context.Background(), args)
+		if (err != nil) != tt.fail {
+			t.Errorf("test %d: GetLogs error mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+		if rpcErr, ok := err.(rpc.RPCError); err != nil && (!ok || rpcErr.Code() != -32005) {
+			t.Errorf("test %d: invalid error %v", i, err)
+		}
+		if _, err := api.NewFilter(args); (err != nil) != tt.fail {
+			t.Errorf("test %d: NewFilter error mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+	}
+}
+
+// Tests that the logs of the blocks dropped by a reorg are delivered again with
+// the removed flag set, newest first, before the logs of the new chain.
+func TestLogFilterReorg(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		signer  = types.NewChainIdSigner(big.NewInt(63))
+		code    = common.FromHex("0x60006000a0") // LOG0 of no data
+		db, _   = ethdb.NewMemDatabase()
+		genesis = core.WriteGenesisBlockForTesting(db, core.GenesisAccount{Address: addr, Balance: big.NewInt(10000000000000)})
+		config  = core.MakeDiehardChainConfig()
+		mux     = new(event.TypeMux)
+	)
+	blockchain, err := core.NewBlockChain(db, config, core.FakePow{}, mux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blockchain.Stop()
+
+	api := filters.NewPublicFilterAPI(db, mux, 0)
+	id, err := api.NewFilter(filters.NewFilterArgs{FromBlock: rpc.LatestBlockNumber, ToBlock: rpc.LatestBlockNumber})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// logAt adds a transaction logging once to the blocks with the given indexes
+	logAt := func(blocks ...int) func(int, *core.BlockGen) {
+		return func(i int, gen *core.BlockGen) {
+			for _, n := range blocks {
+				if i == n {
+					tx, err := types.NewContractCreation(gen.TxNonce(addr), new(big.Int), big.NewInt(100000), new(big.Int), code).WithSigner(signer).SignECDSA(key)
+					if err != nil {
+						t.Fatal(err)
+					}
+					gen.AddTx(tx)
+				}
+			}
+		}
+	}
+	oldChain, _ := core.GenerateChain(config, genesis, db, 2, logAt(0, 1))
+	if _, err := blockchain.InsertChain(oldChain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	newChain, _ := core.GenerateChain(config, genesis, db, 3, logAt(1))
+	if _, err := blockchain.InsertChain(newChain); err != nil {
+		t.Fatalf("failed to insert forked chain: %v", err)
+	}
+	want := []struct {
+		block   common.Hash
+		removed bool
+	}{
+		{oldChain[0].Hash(), false},
+		{oldChain[1].Hash(), false},
+		{oldChain[1].Hash(), true},
+		{oldChain[0].Hash(), true},
+		{newChain[1].Hash(), false},
+	}
+	var logs []struct {
+		BlockHash common.Hash `json:"blockHash"`
+		Removed   bool        `json:"removed"`
+	}
+	for timeout := time.Now().Add(5 * time.Second); len(logs) < len(want) && time.Now().Before(timeout); time.Sleep(10 * time.Millisecond) {
+		blob, err := json.Marshal(api.GetFilterChanges(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var changes []struct {
+			BlockHash common.Hash `json:"blockHash"`
+			Removed   bool        `json:"removed"`
+		}
+		if err := json.Unmarshal(blob, &changes); err != nil {
+			t.Fatalf("failed to decode %s: %v", blob, err)
+		}
+		logs = append(logs, changes...)
+	}
+	if len(logs) != len(want) {
+		t.Fatalf("log count mismatch: have %d, want %d", len(logs), len(want))
+	}
+	for i := range want {
+		if logs[i].BlockHash != want[i].block || logs[i].Removed != want[i].removed {
+			t.Errorf("log %d: have block %x removed %v, want block %x removed %v", i, logs[i].BlockHash, logs[i].Removed, want[i].block, want[i].removed)
+		}
+	}
+}
@@ -17,14 +17,18 @@
 package filters
 
 import (
+	"context"
 	"math"
 	"time"
 
 	"github.com/ethereumproject/go-ethereum/common"
 	"github.com/ethereumproject/go-ethereum/core"
+	"github.com/ethereumproject/go-ethereum/core/bloombits"
 	"github.com/ethereumproject/go-ethereum/core/types"
 	"github.com/ethereumproject/go-ethereum/core/vm"
 	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/logger"
+	"github.com/ethereumproject/go-ethereum/logger/glog"
 )
 
 type AccountChange struct {
@@ -70,11 +74,12 @@ func (self *Filter) SetTopics(topics [][]common.Hash) {
 	self.topics = topics
 }
 
-// Run filters logs with the current parameters set
-func (self *Filter) Find() vm.Logs {
+// Find filters logs with the current parameters set, aborting with the error of
+// the context if it is cancelled before the whole range is searched.
+func (self *Filter) Find(ctx context.Context) (vm.Logs, error) {
 	latestBlock := core.GetBlock(self.db, core.GetHeadBlockHash(self.db))
 	if latestBlock == nil {
-		return vm.Logs{}
+		return vm.Logs{}, nil
 	}
 	var beginBlockNo uint64 = uint64(self.begin)
 	if self.begin == -1 {
@@ -85,20 +90,107 @@ func (self *Filter) Find() vm.Logs {
 		endBlockNo = latestBlock.NumberU64()
 	}
 
+	// Match the sections covered by the bloom bits index bit-wise, and search
+	// the rest of the range block by block
+	indexed := core.GetBloomBitsSections(self.db) * core.BloomBitsBlocks
+	if beginBlockNo >= indexed || !self.indexable() {
+		return self.unindexedLogs(ctx, beginBlockNo, endBlockNo)
+	}
+	if endBlockNo < indexed {
+		return self.indexedLogs(ctx, beginBlockNo, endBlockNo)
+	}
+	logs, err := self.indexedLogs(ctx, beginBlockNo, indexed-1)
+	if err != nil {
+		return logs, err
+	}
+	rest, err := self.unindexedLogs(ctx, indexed, endBlockNo)
+	return append(logs, rest...), err
+}
+
+// indexable returns whether the filter restricts the logs by any address or
+// topic, only in which case the bloom bits index can narrow down the search.
+func (self *Filter) indexable() bool {
+	if len(self.addresses) > 0 {
+		return true
+	}
+	for _, sub := range self.topics {
+		if !wildcard(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcard returns whether a list of alternative topics matches any topic.
+func wildcard(topics []common.Hash) bool {
+	if len(topics) == 0 {
+		return true
+	}
+	for _, topic := range topics {
+		if topic == (common.Hash{}) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexedLogs retrieves the logs within a range of blocks covered by the bloom
+// bits index, only checking the blocks the index matches.
+func (self *Filter) indexedLogs(ctx context.Context, start, end uint64) (logs vm.Logs, err error) {
+	filters := make([][][]byte, 0, len(self.topics)+1)
+
+	addresses := make([][]byte, len(self.addresses))
+	for i, addr := range self.addresses {
+		addresses[i] = addr.Bytes()
+	}
+	filters = append(filters, addresses)
+	for _, sub := range self.topics {
+		var topics [][]byte
+		if !wildcard(sub) {
+			for _, topic := range sub {
+				topics = append(topics, topic.Bytes())
+			}
+		}
+		filters = append(filters, topics)
+	}
+	matches, err := bloombits.NewMatcher(core.BloomBitsBlocks, filters).Match(start, end, &dbRetriever{db: self.db})
+	if err != nil {
+		glog.V(logger.Warn).Infof("Bloom bits matching failed, searching blocks #%d-#%d one by one: %v", start, end, err)
+		return self.unindexedLogs(ctx, start, end)
+	}
+	for _, number := range matches {
+		if err := ctx.Err(); err != nil {
+			return logs, err
+		}
+		block := core.GetBlock(self.db, core.GetCanonicalHash(self.db, number))
+		if block == nil { // block not found/written
+			return logs, nil
+		}
+		logs = append(logs, self.blockLogs(block)...)
+	}
+	return logs, nil
+}
+
+// unindexedLogs retrieves the logs within a range of blocks not covered by the
+// bloom bits index.
+func (self *Filter) unindexedLogs(ctx context.Context, start, end uint64) (vm.Logs, error) {
 	// if no addresses are present we can't make use of fast search which
 	// uses the mipmap bloom filters to check for fast inclusion and uses
 	// higher range probability in order to ensure at least a false positive
 	if len(self.addresses) == 0 {
-		return self.getLogs(beginBlockNo, endBlockNo)
+		return self.getLogs(ctx, start, end)
 	}
-	return self.mipFind(beginBlockNo, endBlockNo, 0)
+	return self.mipFind(ctx, start, end, 0)
 }
 
-func (self *Filter) mipFind(start, end uint64, depth int) (logs vm.Logs) {
+func (self *Filter) mipFind(ctx context.Context, start, end uint64, depth int) (logs vm.Logs, err error) {
 	level := core.MIPMapLevels[depth]
 	// normalise numerator so we can work in level specific batches and
 	// work with the proper range checks
 	for num := start / level * level; num <= end; num += level {
+		if err := ctx.Err(); err != nil {
+			return logs, err
+		}
 		// find addresses in bloom filters
 		bloom := core.GetMipmapBloom(self.db, num, level)
 		for _, addr := range self.addresses {
@@ -108,10 +200,15 @@ func (self *Filter) mipFind(start, end uint64, depth int) (logs vm.Logs) {
 				// normalised values.
 				start := uint64(math.Max(float64(num), float64(start)))
 				end := uint64(math.Min(float64(num+level-1), float64(end)))
+				var found vm.Logs
 				if depth+1 == len(core.MIPMapLevels) {
-					logs = append(logs, self.getLogs(start, end)...)
+					found, err = self.getLogs(ctx, start, end)
 				} else {
-					logs = append(logs, self.mipFind(start, end, depth+1)...)
+					found, err = self.mipFind(ctx, start, end, depth+1)
+				}
+				logs = append(logs, found...)
+				if err != nil {
+					return logs, err
 				}
 				// break so we don't check the same range for each
 				// possible address. Checks on multiple addresses
@@ -121,36 +218,62 @@ func (self *Filter) mipFind(start, end uint64, depth int) (logs vm.Logs) {
 		}
 	}
 
-	return logs
+	return logs, nil
 }
 
-func (self *Filter) getLogs(start, end uint64) (logs vm.Logs) {
+func (self *Filter) getLogs(ctx context.Context, start, end uint64) (logs vm.Logs, err error) {
 	for i := start; i <= end; i++ {
+		if err := ctx.Err(); err != nil {
+			return logs, err
+		}
 		var block *types.Block
 		hash := core.GetCanonicalHash(self.db, i)
 		if hash != (common.Hash{}) {
 			block = core.GetBlock(self.db, hash)
 		}
 		if block == nil { // block not found/written
-			return logs
-		}
-
-		// Use bloom filtering to see if this block is interesting given the
-		// current parameters
-		if self.bloomFilter(block) {
-			// Get the logs of the block
-			var (
-				receipts   = core.GetBlockReceipts(self.db, block.Hash())
-				unfiltered vm.Logs
-			)
-			for _, receipt := range receipts {
-				unfiltered = append(unfiltered, receipt.Logs...)
-			}
-			logs = append(logs, self.FilterLogs(unfiltered)...)
+			return logs, nil
 		}
+
+		logs = append(logs, self.blockLogs(block)...)
+	}
+
+	return logs, nil
+}
+
+// blockLogs returns the logs of a block matching the filter.
+func (self *Filter) blockLogs(block *types.Block) vm.Logs {
+	// Use bloom filtering to see if this block is interesting given the
+	// current parameters
+	if !self.bloomFilter(block) {
+		return nil
+	}
+	// Get the logs of the block
+	var (
+		receipts   = core.GetBlockReceipts(self.db, block.Hash())
+		unfiltered vm.Logs
+	)
+	for _, receipt := range receipts {
+		unfiltered = append(unfiltered, receipt.Logs...)
 	}
+	return self.FilterLogs(unfiltered)
+}
 
-	return logs
+// dbRetriever serves the bit vectors of the bloom bits index in the database
+// for the sections of the current canonical chain.
+type dbRetriever struct {
+	db ethdb.Database
+}
+
+// GetBloomBits implements bloombits.Retriever, retrieving the bit vectors of a
+// bloom bit for each section from the database.
+func (r *dbRetriever) GetBloomBits(bit uint, sections []uint64) ([][]byte, error) {
+	vectors := make([][]byte, len(sections))
+	for i, section := range sections {
+		head := core.GetCanonicalHash(r.db, (section+1)*core.BloomBitsBlocks-1)
+		vectors[i] = core.GetBloomBits(r.db, bit, section, head)
+	}
+	return vectors, nil
 }
 
 func includes(addresses []common.Address, a common.Address) bool {
@@ -17,6 +17,7 @@
 package filters
 
 import (
+	"context"
 	"io/ioutil"
 	"math/big"
 	"os"
@@ -24,6 +25,7 @@ import (
 
 	"github.com/ethereumproject/go-ethereum/common"
 	"github.com/ethereumproject/go-ethereum/core"
+	"github.com/ethereumproject/go-ethereum/core/bloombits"
 	"github.com/ethereumproject/go-ethereum/core/types"
 	"github.com/ethereumproject/go-ethereum/core/vm"
 	"github.com/ethereumproject/go-ethereum/crypto"
@@ -106,7 +108,7 @@ func BenchmarkMipmaps(b *testing.B) {
 	filter.SetEndBlock(-1)
 
 	for i := 0; i < b.N; i++ {
-		logs := filter.Find()
+		logs, _ := filter.Find(context.Background())
 		if len(logs) != 4 {
 			b.Fatal("expected 4 log, got", len(logs))
 		}
@@ -207,17 +209,23 @@ func TestFilters(t *testing.T) {
 	filter.SetBeginBlock(0)
 	filter.SetEndBlock(-1)
 
-	logs := filter.Find()
+	logs, _ := filter.Find(context.Background())
 	if len(logs) != 4 {
 		t.Error("expected 4 log, got", len(logs))
 	}
+	// A cancelled search is aborted with the error of the context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := filter.Find(ctx); err != context.Canceled {
+		t.Errorf("cancelled search error mismatch: have %v, want %v", err, context.Canceled)
+	}
 
 	filter = New(db)
 	filter.SetAddresses([]common.Address{addr})
 	filter.SetTopics([][]common.Hash{{hash3}})
 	filter.SetBeginBlock(900)
 	filter.SetEndBlock(999)
-	logs = filter.Find()
+	logs, _ = filter.Find(context.Background())
 	if len(logs) != 1 {
 		t.Error("expected 1 log, got", len(logs))
 	}
@@ -230,7 +238,7 @@ func TestFilters(t *testing.T) {
 	filter.SetTopics([][]common.Hash{{hash3}})
 	filter.SetBeginBlock(990)
 	filter.SetEndBlock(-1)
-	logs = filter.Find()
+	logs, _ = filter.Find(context.Background())
 	if len(logs) != 1 {
 		t.Error("expected 1 log, got", len(logs))
 	}
@@ -243,7 +251,7 @@ func TestFilters(t *testing.T) {
 	filter.SetBeginBlock(1)
 	filter.SetEndBlock(10)
 
-	logs = filter.Find()
+	logs, _ = filter.Find(context.Background())
 	if len(logs) != 2 {
 		t.Error("expected 2 log, got", len(logs))
 	}
@@ -254,7 +262,7 @@ func TestFilters(t *testing.T) {
 	filter.SetBeginBlock(0)
 	filter.SetEndBlock(-1)
 
-	logs = filter.Find()
+	logs, _ = filter.Find(context.Background())
 	if len(logs) != 0 {
 		t.Error("expected 0 log, got", len(logs))
 	}
@@ -265,7 +273,7 @@ func TestFilters(t *testing.T) {
 	filter.SetBeginBlock(0)
 	filter.SetEndBlock(-1)
 
-	logs = filter.Find()
+	logs, _ = filter.Find(context.Background())
 	if len(logs) != 0 {
 		t.Error("expected 0 log, got", len(logs))
 	}
@@ -275,8 +283,125 @@ func TestFilters(t *testing.T) {
 	filter.SetBeginBlock(0)
 	filter.SetEndBlock(-1)
 
-	logs = filter.Find()
+	logs, _ = filter.Find(context.Background())
 	if len(logs) != 0 {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+// writeBloomBits indexes the header blooms of the complete sections of a chain.
+func writeBloomBits(t *testing.T, db ethdb.Database, chain []*types.Block) {
+	headers := []*types.Header{core.GetHeader(db, core.GetCanonicalHash(db, 0))}
+	for _, block := range chain {
+		headers = append(headers, block.Header())
+	}
+	batch := db.NewBatch()
+	for section := uint64(0); (section+1)*core.BloomBitsBlocks <= uint64(len(headers)); section++ {
+		gen, err := bloombits.NewGenerator(uint(core.BloomBitsBlocks))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := uint64(0); i < core.BloomBitsBlocks; i++ {
+			if err := gen.AddBloom(uint(i), headers[section*core.BloomBitsBlocks+i].Bloom); err != nil {
+				t.Fatal(err)
+			}
+		}
+		head := headers[(section+1)*core.BloomBitsBlocks-1].Hash()
+		for i := uint(0); i < types.BloomBitLength; i++ {
+			bits, _ := gen.Bitset(i)
+			core.WriteBloomBits(batch, i, section, head, bits)
+		}
+		core.WriteIndexSectionHead(batch, core.BloomBitsIndexPrefix, section, head)
+		core.WriteIndexSections(batch, core.BloomBitsIndexPrefix, section+1)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndexedFilters(t *testing.T) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key1.PublicKey)
+		other   = common.BytesToAddress([]byte("other"))
+
+		hash1 = common.BytesToHash([]byte("topic1"))
+		hash2 = common.BytesToHash([]byte("topic2"))
+	)
+	// Place logs in the first section, on its boundary and in the unindexed tail
+	// (block numbers are one above the generator indexes)
+	blocks := int(core.BloomBitsBlocks) + 100
+	logged := map[int]*vm.Log{
+		10:                             {Address: addr, Topics: []common.Hash{hash1}},
+		2000:                           {Address: other, Topics: []common.Hash{hash1}},
+		int(core.BloomBitsBlocks) - 2:  {Address: addr, Topics: []common.Hash{hash2}},
+		int(core.BloomBitsBlocks) + 50: {Address: addr, Topics: []common.Hash{hash1}},
+	}
+	genesis := core.WriteGenesisBlockForTesting(db, core.GenesisAccount{Address: addr, Balance: big.NewInt(1000000)})
+	chain, receipts := core.GenerateChain(core.TestConfig, genesis, db, blocks, func(i int, gen *core.BlockGen) {
+		if log, ok := logged[i]; ok {
+			receipt := types.NewReceipt(nil, new(big.Int))
+			receipt.Logs = vm.Logs{log}
+			gen.AddUncheckedReceipt(receipt)
+			core.WriteMipmapBloom(db, uint64(i+1), types.Receipts{receipt})
+		}
+	})
+	for i, block := range chain {
+		core.WriteBlock(db, block)
+		core.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		core.WriteHeadBlockHash(db, block.Hash())
+		core.WriteBlockReceipts(db, block.Hash(), receipts[i])
+	}
+	tests := []struct {
+		addresses  []common.Address
+		topics     [][]common.Hash
+		begin, end int64
+		want       int // Logs matching the filter
+		tail       int // Logs matching the filter in the unindexed tail
+	}{
+		{[]common.Address{addr}, nil, 0, -1, 3, 1},
+		{[]common.Address{addr, other}, nil, 0, -1, 4, 1},
+		{nil, [][]common.Hash{{hash1}}, 0, -1, 3, 1},
+		{nil, [][]common.Hash{{hash2}}, 0, 4000, 0, 0},
+		{[]common.Address{addr}, [][]common.Hash{{hash2}}, 0, -1, 1, 0},
+		{[]common.Address{addr}, [][]common.Hash{{common.Hash{}}}, 12, -1, 2, 1},
+		{[]common.Address{other}, [][]common.Hash{{hash2}}, 0, -1, 0, 0},
+	}
+	check := func(stage string, blank bool) {
+		for i, tt := range tests {
+			filter := New(db)
+			filter.SetAddresses(tt.addresses)
+			filter.SetTopics(tt.topics)
+			filter.SetBeginBlock(tt.begin)
+			filter.SetEndBlock(tt.end)
+
+			want := tt.want
+			if blank {
+				want = tt.tail
+			}
+			if logs, _ := filter.Find(context.Background()); len(logs) != want {
+				t.Errorf("%s: test %d: log count mismatch: have %d, want %d", stage, i, len(logs), want)
+			}
+		}
+	}
+	// Sections indexed for a different chain must be searched block by block
+	batch := db.NewBatch()
+	for i := uint(0); i < types.BloomBitLength; i++ {
+		core.WriteBloomBits(batch, i, 0, common.Hash{0x01}, make([]byte, core.BloomBitsBlocks/8))
+	}
+	core.WriteIndexSections(batch, core.BloomBitsIndexPrefix, 1)
+	batch.Write()
+	check("stale index", false)
+
+	// Sections indexed for the canonical chain are matched bit-wise
+	writeBloomBits(t, db, chain)
+	check("valid index", false)
+
+	batch = db.NewBatch()
+	for i := uint(0); i < types.BloomBitLength; i++ {
+		core.WriteBloomBits(batch, i, 0, chain[core.BloomBitsBlocks-2].Hash(), make([]byte, core.BloomBitsBlocks/8))
+	}
+	batch.Write()
+	check("blank index", true)
+}
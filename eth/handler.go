@@ -30,6 +30,7 @@ import (
 	"github.com/ethereumproject/go-ethereum/core/types"
 	"github.com/ethereumproject/go-ethereum/eth/downloader"
 	"github.com/ethereumproject/go-ethereum/eth/fetcher"
+	"github.com/ethereumproject/go-ethereum/eth/snap"
 	"github.com/ethereumproject/go-ethereum/ethdb"
 	"github.com/ethereumproject/go-ethereum/event"
 	"github.com/ethereumproject/go-ethereum/logger"
@@ -49,8 +50,18 @@ const (
 // not compatible (low protocol version restrictions and high requirements).
 var errIncompatibleConfig = errors.New("incompatible configuration")
 
+// respError is a protocol violation committed by a remote peer.
+type respError struct {
+	code errCode
+	msg  string
+}
+
+func (e *respError) Error() string {
+	return fmt.Sprintf("%v - %v", e.code, e.msg)
+}
+
 func errResp(code errCode, format string, v ...interface{}) error {
-	return fmt.Errorf("%v - %v", code, fmt.Sprintf(format, v...))
+	return &respError{code: code, msg: fmt.Sprintf(format, v...)}
 }
 
 type ProtocolManager struct {
@@ -66,6 +77,7 @@ type ProtocolManager struct {
 
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
+	snapSyncer *snap.Syncer
 	peers      *peerSet
 
 	SubProtocols []p2p.Protocol
@@ -150,11 +162,18 @@ func NewProtocolManager(config *core.ChainConfig, fastSync bool, networkId int,
 	if len(manager.SubProtocols) == 0 {
 		return nil, errIncompatibleConfig
 	}
+	// Serve and retrieve state ranges over the snap protocol alongside eth
+	manager.snapSyncer = snap.NewSyncer(chaindb, manager.reportPeer)
+	manager.SubProtocols = append(manager.SubProtocols, snap.MakeProtocols(chaindb, manager.snapSyncer)...)
+
+	// Serve the bloom bits index to peers filtering logs by proxy
+	manager.SubProtocols = append(manager.SubProtocols, makeBloomProtocol(chaindb))
+
 	// Construct the different synchronisation mechanisms
 	manager.downloader = downloader.New(chaindb, manager.eventMux, blockchain.HasHeader, blockchain.HasBlockAndState, blockchain.GetHeader,
 		blockchain.GetBlock, blockchain.CurrentHeader, blockchain.CurrentBlock, blockchain.CurrentFastBlock, blockchain.FastSyncCommitHead,
 		blockchain.GetTd, blockchain.InsertHeaderChain, manager.insertChain, blockchain.InsertReceiptChain, blockchain.Rollback,
-		manager.removePeer)
+		manager.removePeer, manager.reportPeer, manager.snapSyncer.Sync)
 
 	validator := func(block *types.Block, parent *types.Block) error {
 		return core.ValidateHeader(config, pow, block.Header(), parent.Header(), true, false)
@@ -166,7 +185,7 @@ func NewProtocolManager(config *core.ChainConfig, fastSync bool, networkId int,
 		atomic.StoreUint32(&manager.synced, 1) // Mark initial sync done on any fetcher import
 		return manager.insertChain(blocks)
 	}
-	manager.fetcher = fetcher.New(blockchain.GetBlock, validator, manager.BroadcastBlock, heighter, inserter, manager.removePeer)
+	manager.fetcher = fetcher.New(blockchain.GetBlock, validator, manager.BroadcastBlock, heighter, inserter, manager.removePeer, manager.reportPeer)
 
 	if blockchain.Genesis().Hash().Hex() == defaultGenesisHash && networkId == 1 {
 		manager.badBlockReportingEnabled = false
@@ -202,6 +221,14 @@ func (pm *ProtocolManager) removePeer(id string) {
 	}
 }
 
+// reportPeer feeds an observation about the behaviour of a peer into the
+// reputation tracker of the p2p server.
+func (pm *ProtocolManager) reportPeer(id string, event p2p.PeerEvent) {
+	if peer := pm.peers.Peer(id); peer != nil {
+		peer.Peer.Report(event)
+	}
+}
+
 func (pm *ProtocolManager) Start() {
 	// broadcast transactions
 	pm.txSub = pm.eventMux.Subscribe(core.TxPreEvent{})
@@ -293,6 +320,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 			// Start a timer to disconnect if the peer doesn't reply in time
 			p.timeout = time.AfterFunc((5 * time.Second), func() {
 				glog.V(logger.Debug).Infof("%v: timed out fork-check, dropping", p)
+				p.Peer.Report(p2p.PeerTimeout)
 				pm.removePeer(p.id)
 			})
 			// Make sure it's cleaned up if the peer dies off
@@ -308,6 +336,9 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	for {
 		if err := pm.handleMsg(p); err != nil {
 			glog.V(logger.Debug).Infof("%v: message handling failed: %v", p, err)
+			if _, ok := err.(*respError); ok {
+				p.Peer.Report(p2p.PeerProtocolBreach)
+			}
 			return err
 		}
 	}
@@ -423,6 +454,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 				p.timeout = nil
 			}
 			if err := pm.chainConfig.HeaderCheck(headers[0]); err != nil {
+				p.Peer.Report(p2p.PeerInvalidBlock)
 				pm.removePeer(p.id)
 				return err
 			}
@@ -0,0 +1,345 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereumproject/go-ethereum/accounts/abi"
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core"
+	"github.com/ethereumproject/go-ethereum/core/vm"
+	"github.com/ethereumproject/go-ethereum/eth/filters"
+	"github.com/ethereumproject/go-ethereum/rpc"
+)
+
+// MultisigWalletABI is the interface of the widely deployed multisig wallet
+// contract (MultiSigWallet.sol), whose owners propose transactions which are
+// executed once confirmed by the required number of them.
+const MultisigWalletABI = `[
+	{"type": "function", "name": "submitTransaction", "constant": false, "inputs": [{"name": "destination", "type": "address"}, {"name": "value", "type": "uint256"}, {"name": "data", "type": "bytes"}], "outputs": [{"name": "transactionId", "type": "uint256"}]},
+	{"type": "function", "name": "confirmTransaction", "constant": false, "inputs": [{"name": "transactionId", "type": "uint256"}], "outputs": []},
+	{"type": "function", "name": "revokeConfirmation", "constant": false, "inputs": [{"name": "transactionId", "type": "uint256"}], "outputs": []},
+	{"type": "function", "name": "executeTransaction", "constant": false, "inputs": [{"name": "transactionId", "type": "uint256"}], "outputs": []},
+	{"type": "function", "name": "transactions", "constant": true, "inputs": [{"name": "", "type": "uint256"}], "outputs": [{"name": "destination", "type": "address"}, {"name": "value", "type": "uint256"}, {"name": "data", "type": "bytes"}, {"name": "executed", "type": "bool"}]},
+	{"type": "function", "name": "required", "constant": true, "inputs": [], "outputs": [{"name": "", "type": "uint256"}]},
+	{"type": "function", "name": "isOwner", "constant": true, "inputs": [{"name": "", "type": "address"}], "outputs": [{"name": "", "type": "bool"}]},
+	{"type": "event", "name": "Confirmation", "anonymous": false, "inputs": [{"indexed": true, "name": "sender", "type": "address"}, {"indexed": true, "name": "transactionId", "type": "uint256"}]},
+	{"type": "event", "name": "Revocation", "anonymous": false, "inputs": [{"indexed": true, "name": "sender", "type": "address"}, {"indexed": true, "name": "transactionId", "type": "uint256"}]},
+	{"type": "event", "name": "Submission", "anonymous": false, "inputs": [{"indexed": true, "name": "transactionId", "type": "uint256"}]},
+	{"type": "event", "name": "Execution", "anonymous": false, "inputs": [{"indexed": true, "name": "transactionId", "type": "uint256"}]},
+	{"type": "event", "name": "ExecutionFailure", "anonymous": false, "inputs": [{"indexed": true, "name": "transactionId", "type": "uint256"}]}
+]`
+
+// multisigGas is the default gas allowance of calls to a multisig wallet. The
+// confirmation completing the required number also executes the transaction.
+const multisigGas = uint64(500000)
+
+// multisigScanBlocks is the number of blocks whose wallet events are searched
+// for at once by PendingMultisigTransactions, a multiple of the bloom bits
+// section size so that every full chunk is served by the index.
+const multisigScanBlocks = 64 * core.BloomBitsBlocks
+
+var multisigABI abi.ABI
+
+func init() {
+	parsed, err := abi.JSON(strings.NewReader(MultisigWalletABI))
+	if err != nil {
+		panic(fmt.Sprintf("invalid multisig wallet ABI: %v", err))
+	}
+	multisigABI = parsed
+}
+
+var errNotMultisigOwner = errors.New("account is not an owner of the multisig wallet")
+
+// MultisigTransaction is a transaction proposed to a multisig wallet and not
+// executed yet, as tracked from the events of the wallet.
+type MultisigTransaction struct {
+	Id            *rpc.HexNumber   `json:"id"`
+	Destination   common.Address   `json:"destination"`
+	Value         *rpc.HexNumber   `json:"value"`
+	Data          string           `json:"data"`
+	Confirmations []common.Address `json:"confirmations"`
+	Required      *rpc.HexNumber   `json:"required"`
+}
+
+// SubmitMultisigTransaction proposes the transaction to args.To described by args
+// to the multisig wallet, sending the proposal from the owner args.From with the
+// key decrypted by passwd. The gas, gas price and nonce of args apply to the call
+// of the wallet. The proposal counts as the first confirmation.
+func (s *PrivateAccountAPI) SubmitMultisigTransaction(wallet common.Address, args SendTxArgs, passwd string) (common.Hash, error) {
+	if args.To == nil {
+		return common.Hash{}, errors.New("multisig transactions can't create contracts")
+	}
+	value := new(big.Int)
+	if args.Value != nil {
+		value = args.Value.BigInt()
+	}
+	data, err := multisigABI.Pack("submitTransaction", *args.To, value, common.FromHex(args.Data))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return s.sendMultisig(wallet, SendTxArgs{From: args.From, Gas: args.Gas, GasPrice: args.GasPrice, Nonce: args.Nonce}, data, passwd)
+}
+
+// ConfirmMultisigTransaction confirms the proposed transaction id of the multisig
+// wallet on behalf of the owner, executing it if the required number of
+// confirmations is reached.
+func (s *PrivateAccountAPI) ConfirmMultisigTransaction(wallet, owner common.Address, id rpc.HexNumber, passwd string) (common.Hash, error) {
+	data, err := multisigABI.Pack("confirmTransaction", id.BigInt())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return s.sendMultisig(wallet, SendTxArgs{From: owner}, data, passwd)
+}
+
+// RevokeMultisigConfirmation withdraws the confirmation of the proposed
+// transaction id of the multisig wallet given earlier by the owner.
+func (s *PrivateAccountAPI) RevokeMultisigConfirmation(wallet, owner common.Address, id rpc.HexNumber, passwd string) (common.Hash, error) {
+	data, err := multisigABI.Pack("revokeConfirmation", id.BigInt())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return s.sendMultisig(wallet, SendTxArgs{From: owner}, data, passwd)
+}
+
+// ExecuteMultisigTransaction executes the confirmed transaction id of the
+// multisig wallet, e.g. after its execution failed for lack of funds.
+func (s *PrivateAccountAPI) ExecuteMultisigTransaction(wallet, owner common.Address, id rpc.HexNumber, passwd string) (common.Hash, error) {
+	data, err := multisigABI.Pack("executeTransaction", id.BigInt())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return s.sendMultisig(wallet, SendTxArgs{From: owner}, data, passwd)
+}
+
+// sendMultisig sends the packed call to the wallet from the owner in args,
+// refusing accounts the wallet doesn't know as an owner.
+func (s *PrivateAccountAPI) sendMultisig(wallet common.Address, args SendTxArgs, data []byte, passwd string) (common.Hash, error) {
+	input, err := multisigABI.Pack("isOwner", args.From)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	output, err := s.callContract(wallet, input)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var owner bool
+	if err := multisigABI.Unpack(&owner, "isOwner", output); err != nil {
+		return common.Hash{}, fmt.Errorf("%x is not a multisig wallet: %v", wallet, err)
+	}
+	if !owner {
+		return common.Hash{}, errNotMultisigOwner
+	}
+	if args.Gas == nil {
+		args.Gas = rpc.NewHexNumber(multisigGas)
+	}
+	args.To, args.Data = &wallet, common.ToHex(data)
+	return s.SignAndSendTransaction(args, passwd)
+}
+
+// PendingMultisigTransactions returns the transactions proposed to the multisig
+// wallet which haven't been executed yet, along with the owners confirming them.
+// The events of the wallet are searched for from fromBlock, by default the
+// genesis block, to the head of the chain, in chunks of multisigScanBlocks.
+// Transactions submitted before fromBlock aren't reported.
+func (s *PrivateAccountAPI) PendingMultisigTransactions(ctx context.Context, wallet common.Address, fromBlock *rpc.BlockNumber) ([]*MultisigTransaction, error) {
+	head := s.bc.CurrentBlock().NumberU64()
+
+	from := uint64(0)
+	if fromBlock != nil && *fromBlock >= 0 {
+		from = uint64(*fromBlock)
+	}
+	if from > head {
+		return nil, fmt.Errorf("invalid block range %d-%d", from, head)
+	}
+	var logs vm.Logs
+	for begin, end := from, uint64(0); begin <= head; begin = end + 1 {
+		end = begin - begin%multisigScanBlocks + multisigScanBlocks - 1
+		if end > head {
+			end = head
+		}
+		filter := filters.New(s.chainDb)
+		filter.SetBeginBlock(int64(begin))
+		filter.SetEndBlock(int64(end))
+		filter.SetAddresses([]common.Address{wallet})
+		filter.SetTopics([][]common.Hash{{
+			multisigABI.Events["Submission"].Id(),
+			multisigABI.Events["Confirmation"].Id(),
+			multisigABI.Events["Revocation"].Id(),
+			multisigABI.Events["Execution"].Id(),
+		}})
+		chunk, err := filter.Find(ctx)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, chunk...)
+	}
+	pending, err := trackMultisig(logs)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return pending, nil
+	}
+	// Complete the transactions with their details and the confirmations required
+	input, err := multisigABI.Pack("required")
+	if err != nil {
+		return nil, err
+	}
+	output, err := s.callContract(wallet, input)
+	if err != nil {
+		return nil, err
+	}
+	required := new(big.Int)
+	if err := multisigABI.Unpack(&required, "required", output); err != nil {
+		return nil, err
+	}
+	txs := pending[:0]
+	for _, tx := range pending {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		input, err := multisigABI.Pack("transactions", tx.Id.BigInt())
+		if err != nil {
+			return nil, err
+		}
+		output, err := s.callContract(wallet, input)
+		if err != nil {
+			return nil, err
+		}
+		var details struct {
+			Destination common.Address
+			Value       *big.Int
+			Data        []byte
+			Executed    bool
+		}
+		if err := multisigABI.Unpack(&details, "transactions", output); err != nil {
+			return nil, err
+		}
+		if details.Executed {
+			continue // executed after the latest block scanned
+		}
+		tx.Destination, tx.Value, tx.Data = details.Destination, rpc.NewHexNumber(details.Value), common.ToHex(details.Data)
+		tx.Required = rpc.NewHexNumber(required)
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// trackMultisig replays the events logged by a multisig wallet, in the order
+// logged, returning the transactions proposed but not executed along with the
+// owners confirming them.
+func trackMultisig(logs vm.Logs) ([]*MultisigTransaction, error) {
+	var (
+		txs     = make(map[string]*MultisigTransaction)
+		ids     []*big.Int
+		names   = make(map[common.Hash]string)
+		payload struct {
+			Sender        common.Address
+			TransactionId *big.Int
+		}
+	)
+	for name, event := range multisigABI.Events {
+		names[event.Id()] = name
+	}
+	for _, log := range logs {
+		if len(log.Topics) == 0 {
+			continue
+		}
+		name, ok := names[log.Topics[0]]
+		if !ok {
+			continue
+		}
+		if err := multisigABI.UnpackLog(&payload, name, log.Topics, log.Data); err != nil {
+			return nil, fmt.Errorf("invalid %s event in block %d: %v", name, log.BlockNumber, err)
+		}
+		id := payload.TransactionId.String()
+
+		switch name {
+		case "Submission":
+			if _, ok := txs[id]; !ok {
+				txs[id] = &MultisigTransaction{Id: rpc.NewHexNumber(payload.TransactionId), Confirmations: []common.Address{}}
+				ids = append(ids, payload.TransactionId)
+			}
+		case "Confirmation":
+			if tx := txs[id]; tx != nil {
+				tx.Confirmations = append(removeAddress(tx.Confirmations, payload.Sender), payload.Sender)
+			}
+		case "Revocation":
+			if tx := txs[id]; tx != nil {
+				tx.Confirmations = removeAddress(tx.Confirmations, payload.Sender)
+			}
+		case "Execution":
+			delete(txs, id)
+		}
+	}
+	sort.Sort(bigInts(ids))
+
+	pending := make([]*MultisigTransaction, 0, len(txs))
+	for _, id := range ids {
+		if tx, ok := txs[id.String()]; ok {
+			pending = append(pending, tx)
+			delete(txs, id.String()) // skip ids submitted twice
+		}
+	}
+	return pending, nil
+}
+
+// removeAddress returns the addresses without addr.
+func removeAddress(addrs []common.Address, addr common.Address) []common.Address {
+	for i, a := range addrs {
+		if a == addr {
+			return append(addrs[:i], addrs[i+1:]...)
+		}
+	}
+	return addrs
+}
+
+type bigInts []*big.Int
+
+func (s bigInts) Len() int           { return len(s) }
+func (s bigInts) Less(i, j int) bool { return s[i].Cmp(s[j]) < 0 }
+func (s bigInts) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// callContract executes a read only call of the contract on the current state,
+// returning its output. The call may use up to the gas limit of the block.
+func (s *PrivateAccountAPI) callContract(contract common.Address, data []byte) ([]byte, error) {
+	block := s.bc.CurrentBlock()
+	statedb, err := s.bc.StateAt(block.Root())
+	if err != nil {
+		return nil, err
+	}
+	msg := callmsg{
+		from:     statedb.GetOrNewStateObject(common.Address{}),
+		to:       &contract,
+		gas:      block.GasLimit(),
+		gasPrice: new(big.Int),
+		value:    new(big.Int),
+		data:     data,
+	}
+	vmenv := core.NewEnv(statedb, s.bc.Config(), s.bc, msg, block.Header())
+	gp := new(core.GasPool).AddGas(common.MaxBig)
+
+	output, _, _, err := core.NewStateTransition(vmenv, msg, gp).TransitionDb()
+	return output, err
+}
@@ -0,0 +1,159 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereumproject/go-ethereum/accounts/abi"
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/vm"
+	"github.com/ethereumproject/go-ethereum/rpc"
+)
+
+// multisigLog creates a log of the named wallet event with the given topics.
+func multisigLog(name string, topics ...common.Hash) *vm.Log {
+	return &vm.Log{Topics: append([]common.Hash{multisigABI.Events[name].Id()}, topics...)}
+}
+
+// Tests that replaying the events of a wallet tracks the confirmations of the
+// transactions not executed yet.
+func TestTrackMultisig(t *testing.T) {
+	var (
+		alice = common.HexToAddress("0xa1")
+		bob   = common.HexToAddress("0xb0b")
+		carol = common.HexToAddress("0xca201")
+		id    = func(n int64) common.Hash { return common.BigToHash(big.NewInt(n)) }
+		owner = func(addr common.Address) common.Hash { return common.BytesToHash(addr[:]) }
+	)
+	logs := vm.Logs{
+		multisigLog("Submission", id(1)),
+		multisigLog("Confirmation", owner(alice), id(1)),
+		multisigLog("Submission", id(0)),
+		multisigLog("Confirmation", owner(bob), id(0)),
+		multisigLog("Confirmation", owner(carol), id(1)),
+		multisigLog("Revocation", owner(alice), id(1)),
+		multisigLog("ExecutionFailure", id(1)),
+		multisigLog("Submission", id(2)),
+		multisigLog("Confirmation", owner(alice), id(2)),
+		multisigLog("Confirmation", owner(bob), id(2)),
+		multisigLog("Execution", id(2)),
+		{Topics: []common.Hash{common.HexToHash("0x01")}}, // foreign event
+	}
+	pending, err := trackMultisig(logs)
+	if err != nil {
+		t.Fatalf("failed to track events: %v", err)
+	}
+	want := []struct {
+		id            int64
+		confirmations []common.Address
+	}{
+		{0, []common.Address{bob}},
+		{1, []common.Address{carol}},
+	}
+	if len(pending) != len(want) {
+		t.Fatalf("pending transactions mismatch: have %d, want %d", len(pending), len(want))
+	}
+	for i, tx := range pending {
+		if tx.Id.Int64() != want[i].id {
+			t.Errorf("transaction %d: id mismatch: have %d, want %d", i, tx.Id.Int64(), want[i].id)
+		}
+		if !reflect.DeepEqual(tx.Confirmations, want[i].confirmations) {
+			t.Errorf("transaction %d: confirmations mismatch: have %x, want %x", i, tx.Confirmations, want[i].confirmations)
+		}
+	}
+	// A truncated event must be reported rather than skipped
+	if _, err := trackMultisig(vm.Logs{multisigLog("Confirmation", owner(alice))}); err == nil {
+		t.Errorf("truncated event accepted")
+	}
+}
+
+// Tests the encoding of the wallet calls and the decoding of the transaction
+// details returned by the wallet.
+func TestMultisigABI(t *testing.T) {
+	destination := common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+	input, err := multisigABI.Pack("submitTransaction", destination, big.NewInt(1000), []byte{0xca, 0xfe})
+	if err != nil {
+		t.Fatalf("failed to pack submission: %v", err)
+	}
+	// selector, destination, value, data offset, data length, data
+	if len(input) != 4+5*32 || !bytes.Equal(input[:4], common.FromHex("0xc6427474")) {
+		t.Errorf("submission encoding mismatch: %x", input)
+	}
+	input, err = multisigABI.Pack("confirmTransaction", big.NewInt(7))
+	if err != nil {
+		t.Fatalf("failed to pack confirmation: %v", err)
+	}
+	if want := "0xc01a8c84" + common.Bytes2Hex(common.BigToHash(big.NewInt(7)).Bytes()); common.ToHex(input) != want {
+		t.Errorf("confirmation encoding mismatch: have %x, want %s", input, want)
+	}
+	// Encode the output of transactions(uint256) as the inputs of a like method
+	encoder, err := abi.JSON(strings.NewReader(`[{"type": "function", "name": "encode", "inputs": [{"name": "destination", "type": "address"}, {"name": "value", "type": "uint256"}, {"name": "data", "type": "bytes"}, {"name": "executed", "type": "bool"}]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := encoder.Pack("encode", destination, big.NewInt(1000), []byte{0xca, 0xfe}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var details struct {
+		Destination common.Address
+		Value       *big.Int
+		Data        []byte
+		Executed    bool
+	}
+	if err := multisigABI.Unpack(&details, "transactions", output[4:]); err != nil {
+		t.Fatalf("failed to unpack transaction details: %v", err)
+	}
+	if details.Destination != destination || details.Value.Int64() != 1000 || !bytes.Equal(details.Data, []byte{0xca, 0xfe}) || !details.Executed {
+		t.Errorf("transaction details mismatch: %+v", details)
+	}
+}
+
+// Tests that the pending transactions of a wallet are only searched for in the
+// requested range of blocks, which must lie within the chain.
+func TestPendingMultisigRange(t *testing.T) {
+	pm := newTestProtocolManagerMust(t, false, 4, nil, nil)
+	defer pm.Stop()
+
+	api := &PrivateAccountAPI{bc: pm.blockchain, chainDb: pm.chaindb}
+	wallet := common.HexToAddress("0x01")
+
+	for _, from := range []*rpc.BlockNumber{nil, blockNumber(2), blockNumber(4)} {
+		if txs, err := api.PendingMultisigTransactions(context.Background(), wallet, from); err != nil || len(txs) != 0 {
+			t.Errorf("from %v: have %v/%v, want no transactions", from, txs, err)
+		}
+	}
+	if _, err := api.PendingMultisigTransactions(context.Background(), wallet, blockNumber(5)); err == nil {
+		t.Errorf("range beyond the head accepted")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := api.PendingMultisigTransactions(ctx, wallet, nil); err != context.Canceled {
+		t.Errorf("cancelled search error mismatch: have %v, want %v", err, context.Canceled)
+	}
+}
+
+func blockNumber(n int64) *rpc.BlockNumber {
+	bn := rpc.BlockNumber(n)
+	return &bn
+}
@@ -0,0 +1,250 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/logger"
+	"github.com/ethereumproject/go-ethereum/logger/glog"
+	"github.com/ethereumproject/go-ethereum/p2p"
+	"github.com/ethereumproject/go-ethereum/trie"
+)
+
+const (
+	softResponseLimit = 2 * 1024 * 1024 // Target maximum size of returned state data
+	maxCodeLookups    = 1024            // Maximum number of contract codes to serve per request
+	maxTrieLookups    = 1024            // Maximum number of storage tries to serve per request
+)
+
+// MakeProtocols constructs the snap sub-protocols, serving state ranges out of
+// the given database and handing any connected peers to the syncer.
+func MakeProtocols(db ethdb.Database, syncer *Syncer) []p2p.Protocol {
+	protocols := make([]p2p.Protocol, len(ProtocolVersions))
+	for i, version := range ProtocolVersions {
+		version := version // Closure for the run
+		protocols[i] = p2p.Protocol{
+			Name:    ProtocolName,
+			Version: version,
+			Length:  ProtocolLengths[i],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				id := p.ID()
+				peer := NewPeer(version, fmt.Sprintf("%x", id[:8]), rw)
+
+				if err := syncer.Register(peer); err != nil {
+					return err
+				}
+				defer syncer.Unregister(peer.id)
+
+				return Handle(db, syncer, peer)
+			},
+		}
+	}
+	return protocols
+}
+
+// Handle serves the state requests of a remote peer and forwards its responses
+// to the syncer, until the connection is torn down.
+func Handle(db ethdb.Database, syncer *Syncer, p *Peer) error {
+	glog.V(logger.Debug).Infof("%v: snap peer connected", p)
+	for {
+		if err := handleMessage(db, syncer, p); err != nil {
+			glog.V(logger.Debug).Infof("%v: snap message handling failed: %v", p, err)
+			return err
+		}
+	}
+}
+
+// handleMessage is invoked whenever an inbound message is received from a remote
+// peer. The remote connection is torn down upon returning any error.
+func handleMessage(db ethdb.Database, syncer *Syncer, p *Peer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return fmt.Errorf("message too large: %v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	switch msg.Code {
+	case GetAccountRangeMsg:
+		var req getAccountRangeData
+		if err := msg.Decode(&req); err != nil {
+			return fmt.Errorf("%v: %v", msg, err)
+		}
+		return p2p.Send(p.rw, AccountRangeMsg, serveAccountRange(db, &req))
+
+	case AccountRangeMsg:
+		res := new(accountRangeData)
+		if err := msg.Decode(res); err != nil {
+			return fmt.Errorf("%v: %v", msg, err)
+		}
+		syncer.deliver(p.id, res.ID, res)
+
+	case GetStorageRangesMsg:
+		var req getStorageRangesData
+		if err := msg.Decode(&req); err != nil {
+			return fmt.Errorf("%v: %v", msg, err)
+		}
+		return p2p.Send(p.rw, StorageRangesMsg, serveStorageRanges(db, &req))
+
+	case StorageRangesMsg:
+		res := new(storageRangesData)
+		if err := msg.Decode(res); err != nil {
+			return fmt.Errorf("%v: %v", msg, err)
+		}
+		syncer.deliver(p.id, res.ID, res)
+
+	case GetByteCodesMsg:
+		var req getByteCodesData
+		if err := msg.Decode(&req); err != nil {
+			return fmt.Errorf("%v: %v", msg, err)
+		}
+		return p2p.Send(p.rw, ByteCodesMsg, serveByteCodes(db, &req))
+
+	case ByteCodesMsg:
+		res := new(byteCodesData)
+		if err := msg.Decode(res); err != nil {
+			return fmt.Errorf("%v: %v", msg, err)
+		}
+		syncer.deliver(p.id, res.ID, res)
+
+	default:
+		return fmt.Errorf("invalid message code: %v", msg.Code)
+	}
+	return nil
+}
+
+// responseLimit caps the requested response size to the serving limit.
+func responseLimit(bytes uint64) uint64 {
+	if bytes == 0 || bytes > softResponseLimit {
+		return softResponseLimit
+	}
+	return bytes
+}
+
+// serveAccountRange collects the accounts of a state trie starting at the
+// requested origin, until the size limit or the requested limit is reached. An
+// empty response without proofs signals that the state is not available.
+func serveAccountRange(db ethdb.Database, req *getAccountRangeData) *accountRangeData {
+	res := &accountRangeData{ID: req.ID}
+
+	tr, err := trie.New(req.Root, db)
+	if err != nil {
+		return res
+	}
+	var (
+		limit = responseLimit(req.Bytes)
+		size  uint64
+		last  []byte
+	)
+	err = tr.IterateFrom(req.Origin[:], func(key, value []byte) bool {
+		res.Accounts = append(res.Accounts, &accountData{Hash: common.BytesToHash(key), Body: common.CopyBytes(value)})
+		size += uint64(len(key) + len(value))
+		last = key
+
+		return size < limit && bytes.Compare(key, req.Limit[:]) < 0
+	})
+	if err != nil {
+		glog.V(logger.Debug).Infof("failed to serve accounts of %x: %v", req.Root[:4], err)
+		return &accountRangeData{ID: req.ID}
+	}
+	res.Proof = tr.Prove(req.Origin[:])
+	if last != nil {
+		res.Proof = append(res.Proof, tr.Prove(last)...)
+	}
+	return res
+}
+
+// serveStorageRanges collects the slots of consecutive storage tries until the
+// size limit is reached. Only the last trie may be served partially, in which
+// case its boundary proofs are attached.
+func serveStorageRanges(db ethdb.Database, req *getStorageRangesData) *storageRangesData {
+	res := &storageRangesData{ID: req.ID}
+
+	var (
+		limit = responseLimit(req.Bytes)
+		size  uint64
+	)
+	for i, root := range req.Roots {
+		if size >= limit || i >= maxTrieLookups {
+			break
+		}
+		tr, err := trie.New(root, db)
+		if err != nil {
+			break
+		}
+		var origin common.Hash
+		if i == 0 {
+			origin = req.Origin
+		}
+		var (
+			slots   []*storageData
+			last    []byte
+			partial = origin != (common.Hash{})
+		)
+		err = tr.IterateFrom(origin[:], func(key, value []byte) bool {
+			slots = append(slots, &storageData{Hash: common.BytesToHash(key), Body: common.CopyBytes(value)})
+			size += uint64(len(key) + len(value))
+			last = key
+
+			if size >= limit {
+				partial = true
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			glog.V(logger.Debug).Infof("failed to serve storage of %x: %v", root[:4], err)
+			break
+		}
+		res.Slots = append(res.Slots, slots)
+		if partial {
+			res.Proof = tr.Prove(origin[:])
+			if last != nil {
+				res.Proof = append(res.Proof, tr.Prove(last)...)
+			}
+			break
+		}
+	}
+	return res
+}
+
+// serveByteCodes collects the requested contract codes until the size limit is
+// reached, skipping any unknown ones.
+func serveByteCodes(db ethdb.Database, req *getByteCodesData) *byteCodesData {
+	res := &byteCodesData{ID: req.ID}
+
+	var (
+		limit = responseLimit(req.Bytes)
+		size  uint64
+	)
+	for i, hash := range req.Hashes {
+		if size >= limit || i >= maxCodeLookups {
+			break
+		}
+		if code, _ := db.Get(hash[:]); len(code) > 0 {
+			res.Codes = append(res.Codes, code)
+			size += uint64(len(code))
+		}
+	}
+	return res
+}
@@ -0,0 +1,88 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"fmt"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/logger"
+	"github.com/ethereumproject/go-ethereum/logger/glog"
+	"github.com/ethereumproject/go-ethereum/p2p"
+)
+
+// Peer is a remote node speaking the snap protocol.
+type Peer struct {
+	id      string
+	version uint
+	rw      p2p.MsgReadWriter
+}
+
+// NewPeer wraps a message stream of a remote peer into a snap peer. The id is
+// expected to match the one the eth protocol uses for the same peer.
+func NewPeer(version uint, id string, rw p2p.MsgReadWriter) *Peer {
+	return &Peer{
+		id:      id,
+		version: version,
+		rw:      rw,
+	}
+}
+
+// ID retrieves the peer's unique identifier.
+func (p *Peer) ID() string {
+	return p.id
+}
+
+// String implements fmt.Stringer.
+func (p *Peer) String() string {
+	return fmt.Sprintf("Peer %s [%s]", p.id, fmt.Sprintf("%s/%2d", ProtocolName, p.version))
+}
+
+// RequestAccountRange fetches a batch of consecutive accounts of a state trie,
+// starting at origin.
+func (p *Peer) RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error {
+	glog.V(logger.Debug).Infof("%v fetching accounts of %x from %x", p, root[:4], origin[:4])
+	return p2p.Send(p.rw, GetAccountRangeMsg, &getAccountRangeData{
+		ID:     id,
+		Root:   root,
+		Origin: origin,
+		Limit:  limit,
+		Bytes:  bytes,
+	})
+}
+
+// RequestStorageRanges fetches the slots of a batch of storage tries. The origin
+// applies to the first trie only, all others are retrieved from the start.
+func (p *Peer) RequestStorageRanges(id uint64, roots []common.Hash, origin common.Hash, bytes uint64) error {
+	glog.V(logger.Debug).Infof("%v fetching %d storage tries, first %x from %x", p, len(roots), roots[0][:4], origin[:4])
+	return p2p.Send(p.rw, GetStorageRangesMsg, &getStorageRangesData{
+		ID:     id,
+		Roots:  roots,
+		Origin: origin,
+		Bytes:  bytes,
+	})
+}
+
+// RequestByteCodes fetches a batch of contract codes by hash.
+func (p *Peer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	glog.V(logger.Debug).Infof("%v fetching %d codes", p, len(hashes))
+	return p2p.Send(p.rw, GetByteCodesMsg, &getByteCodesData{
+		ID:     id,
+		Hashes: hashes,
+		Bytes:  bytes,
+	})
+}
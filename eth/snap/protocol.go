@@ -0,0 +1,110 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package snap implements the snapshot state synchronisation protocol, where
+// peers serve contiguous ranges of accounts and storage slots together with the
+// Merkle proofs of the range boundaries, instead of individual trie nodes.
+package snap
+
+import (
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/rlp"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	snap1 = 1
+)
+
+// Official short name of the protocol used during capability negotiation.
+var ProtocolName = "snap"
+
+// Supported versions of the snap protocol (first is primary).
+var ProtocolVersions = []uint{snap1}
+
+// Number of implemented message corresponding to different protocol versions.
+var ProtocolLengths = []uint64{6}
+
+// ProtocolMaxMsgSize is the maximum cap on the size of a protocol message.
+const ProtocolMaxMsgSize = 10 * 1024 * 1024
+
+// snap protocol message codes
+const (
+	GetAccountRangeMsg  = 0x00
+	AccountRangeMsg     = 0x01
+	GetStorageRangesMsg = 0x02
+	StorageRangesMsg    = 0x03
+	GetByteCodesMsg     = 0x04
+	ByteCodesMsg        = 0x05
+)
+
+// getAccountRangeData represents an account range query.
+type getAccountRangeData struct {
+	ID     uint64      // Request ID to match up responses with
+	Root   common.Hash // State root of the account trie to serve
+	Origin common.Hash // Hash of the first account to retrieve
+	Limit  common.Hash // Hash of the last account the requester is interested in
+	Bytes  uint64      // Soft limit at which to stop returning data
+}
+
+// accountData is a single account of an account range, keyed by its hash.
+type accountData struct {
+	Hash common.Hash  // Hash of the account address (trie path)
+	Body rlp.RawValue // Consensus encoding of the account
+}
+
+// accountRangeData is the network packet for account range distribution.
+type accountRangeData struct {
+	ID       uint64         // ID of the request this is a response for
+	Accounts []*accountData // Consecutive accounts of the range, starting at the origin
+	Proof    []rlp.RawValue // Merkle proofs of the origin and the last returned account
+}
+
+// getStorageRangesData represents a storage slot query for one or more tries.
+type getStorageRangesData struct {
+	ID     uint64        // Request ID to match up responses with
+	Roots  []common.Hash // Roots of the storage tries to serve
+	Origin common.Hash   // Hash of the first slot to retrieve from the first trie
+	Bytes  uint64        // Soft limit at which to stop returning data
+}
+
+// storageData is a single storage slot, keyed by its hash.
+type storageData struct {
+	Hash common.Hash // Hash of the storage slot key (trie path)
+	Body []byte      // Data content of the slot
+}
+
+// storageRangesData is the network packet for storage slot distribution. All
+// tries but the last are returned entirely. The last one may be cut short, in
+// which case its boundary proofs are attached.
+type storageRangesData struct {
+	ID    uint64           // ID of the request this is a response for
+	Slots [][]*storageData // Storage slots of the consecutive requested tries
+	Proof []rlp.RawValue   // Merkle proofs of the last trie's range, if partial
+}
+
+// getByteCodesData represents a contract code query.
+type getByteCodesData struct {
+	ID     uint64        // Request ID to match up responses with
+	Hashes []common.Hash // Code hashes to retrieve the code for
+	Bytes  uint64        // Soft limit at which to stop returning data
+}
+
+// byteCodesData is the network packet for contract code distribution.
+type byteCodesData struct {
+	ID    uint64   // ID of the request this is a response for
+	Codes [][]byte // Requested contract codes, in request order (unknown ones skipped)
+}
@@ -0,0 +1,643 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/state"
+	"github.com/ethereumproject/go-ethereum/crypto"
+	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/logger"
+	"github.com/ethereumproject/go-ethereum/logger/glog"
+	"github.com/ethereumproject/go-ethereum/p2p"
+	"github.com/ethereumproject/go-ethereum/rlp"
+	"github.com/ethereumproject/go-ethereum/trie"
+)
+
+var (
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256Hash(nil)
+)
+
+var requestBytes = uint64(512 * 1024) // Soft size limit to request for each response (variable for testing)
+
+var (
+	errSyncActive  = errors.New("state sync already in progress")
+	errNoSnapPeers = errors.New("no peers able to serve the state")
+	errCancelled   = errors.New("state sync cancelled")
+	errUnsolicited = errors.New("unsolicited response")
+	errUnavailable = errors.New("state unavailable")
+	errAlreadyReg  = errors.New("peer is already registered")
+	errNotReg      = errors.New("peer is not registered")
+)
+
+const (
+	accountConcurrency = 16               // Number of chunks to split the account trie into for parallel retrieval
+	requestTimeout     = 10 * time.Second // Time allowance for a peer to answer a request
+	maxCodeRequest     = 128              // Maximum number of codes to request in one go
+	maxStorageRequest  = 128              // Maximum number of whole storage tries to request in one go
+	maxPendingStorage  = 4096             // Number of queued storage tries above which account retrieval pauses
+	maxPendingCodes    = 4096             // Number of queued codes above which account retrieval pauses
+)
+
+// accountTask is a chunk of the account trie to retrieve.
+type accountTask struct {
+	next common.Hash // Hash of the next account to retrieve
+	last common.Hash // Hash of the last account belonging to the chunk
+	busy bool        // Whether the chunk has a request in flight
+	done bool        // Whether the chunk has been fully retrieved
+}
+
+// storageTask is a storage trie to retrieve. Tries are retrieved whole unless
+// they don't fit into a single response, after which they are continued one
+// range at a time.
+type storageTask struct {
+	root common.Hash // Root of the storage trie
+	next common.Hash // Hash of the next slot to retrieve (zero for the whole trie)
+}
+
+// accountRange is a verified account range held back until the storage tries
+// and codes of its accounts are retrieved. Healing does not descend into stored
+// account trie nodes, so they must only be stored once everything below them is.
+type accountRange struct {
+	origin common.Hash    // Hash the range was requested from
+	last   common.Hash    // Hash of the last account belonging to the chunk
+	keys   [][]byte       // Account hashes of the range
+	values [][]byte       // Account bodies of the range
+	proof  []rlp.RawValue // Boundary proofs of the range
+
+	pending int // Number of storage tries and codes still being retrieved
+}
+
+// request is a retrieval in flight to a remote peer.
+type request struct {
+	id   uint64
+	peer string
+	sent time.Time
+
+	account *accountTask   // Account chunk being retrieved
+	storage []*storageTask // Storage tries being retrieved
+	codes   []common.Hash  // Contract codes being retrieved
+}
+
+// delivery is a response from a remote peer.
+type delivery struct {
+	peer   string
+	id     uint64
+	packet interface{}
+}
+
+// syncRun is the progress of a single state retrieval.
+type syncRun struct {
+	root common.Hash
+
+	accounts []*accountTask
+	storage  []*storageTask
+	codes    []common.Hash
+
+	waiting   map[common.Hash][]*accountRange // Storage roots and codes in retrieval, with the ranges waiting on them
+	chunked   map[common.Hash]struct{}        // Storage roots retrieved in multiple ranges, left for healing
+	requests  map[uint64]*request             // Requests in flight, by ID
+	busy      map[string]struct{}             // Peers with a request in flight
+	stateless map[string]struct{}             // Peers unable to serve the state root
+
+	accountsDone, slotsDone, codesDone uint64
+}
+
+// Syncer retrieves state tries in bulk from snap peers. It reconstructs every
+// part of the tries fully covered by the retrieved ranges, leaving the nodes on
+// the boundaries of the ranges for node by node healing.
+type Syncer struct {
+	db     ethdb.Database                       // Database to store the retrieved state into
+	report func(id string, event p2p.PeerEvent) // Callback to report peer behaviour
+
+	peers    map[string]*Peer // Currently connected snap peers
+	peerJoin chan struct{}    // Notification channel for new peers
+	reqID    uint64           // Last request ID handed out (sync goroutine only)
+
+	deliveries chan *delivery // Inbound responses of the running sync
+	cancel     chan struct{}  // Channel closed when the running sync terminates (nil if idle)
+
+	lock sync.RWMutex
+}
+
+// NewSyncer creates a state syncer storing into the given database. The report
+// callback (may be nil) is notified of peers misbehaving during retrieval.
+func NewSyncer(db ethdb.Database, report func(id string, event p2p.PeerEvent)) *Syncer {
+	if report == nil {
+		report = func(string, p2p.PeerEvent) {}
+	}
+	return &Syncer{
+		db:         db,
+		report:     report,
+		peers:      make(map[string]*Peer),
+		peerJoin:   make(chan struct{}, 1),
+		deliveries: make(chan *delivery),
+	}
+}
+
+// Register injects a new snap peer into the set of state sources.
+func (s *Syncer) Register(p *Peer) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.peers[p.id]; ok {
+		return errAlreadyReg
+	}
+	s.peers[p.id] = p
+
+	select {
+	case s.peerJoin <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Unregister removes a snap peer from the set of state sources. Any requests
+// in flight to it will be timed out and reassigned.
+func (s *Syncer) Unregister(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.peers[id]; !ok {
+		return errNotReg
+	}
+	delete(s.peers, id)
+	return nil
+}
+
+// deliver injects a response from a remote peer into the running sync.
+func (s *Syncer) deliver(peer string, id uint64, packet interface{}) {
+	s.lock.RLock()
+	cancel := s.cancel
+	s.lock.RUnlock()
+
+	if cancel == nil {
+		return
+	}
+	select {
+	case s.deliveries <- &delivery{peer: peer, id: id, packet: packet}:
+	case <-cancel:
+	}
+}
+
+// Sync retrieves the state trie with the given root, together with all the
+// referenced storage tries and contract codes. Nodes on the boundaries of the
+// retrieved ranges are not stored, the state needs to be healed afterwards.
+func (s *Syncer) Sync(root common.Hash, cancel <-chan struct{}) error {
+	s.lock.Lock()
+	if s.cancel != nil {
+		s.lock.Unlock()
+		return errSyncActive
+	}
+	done := make(chan struct{})
+	s.cancel = done
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		close(done)
+		s.cancel = nil
+		s.lock.Unlock()
+	}()
+	glog.V(logger.Info).Infof("Snapshot syncing state %x…", root[:4])
+
+	run := newSyncRun(root)
+	timeout := time.NewTicker(time.Second)
+	defer timeout.Stop()
+
+	for !run.finished() {
+		if !s.assign(run) && len(run.requests) == 0 {
+			return errNoSnapPeers
+		}
+		select {
+		case <-cancel:
+			return errCancelled
+
+		case <-s.peerJoin:
+			// New peer available, assign tasks in the next iteration
+
+		case d := <-s.deliveries:
+			s.process(run, d)
+
+		case <-timeout.C:
+			s.expire(run)
+		}
+	}
+	glog.V(logger.Info).Infof("Snapshot synced state %x: %d accounts, %d slots, %d codes", root[:4], run.accountsDone, run.slotsDone, run.codesDone)
+	return nil
+}
+
+// newSyncRun creates the retrieval tasks of a state root, splitting the account
+// trie into equally sized chunks.
+func newSyncRun(root common.Hash) *syncRun {
+	run := &syncRun{
+		root:      root,
+		waiting:   make(map[common.Hash][]*accountRange),
+		chunked:   make(map[common.Hash]struct{}),
+		requests:  make(map[uint64]*request),
+		busy:      make(map[string]struct{}),
+		stateless: make(map[string]struct{}),
+	}
+	if root == emptyRoot {
+		return run
+	}
+	step := new(big.Int).Div(new(big.Int).Lsh(common.Big1, 256), big.NewInt(accountConcurrency))
+	for i := 0; i < accountConcurrency; i++ {
+		next := new(big.Int).Mul(step, big.NewInt(int64(i)))
+		last := new(big.Int).Sub(new(big.Int).Add(next, step), common.Big1)
+		run.accounts = append(run.accounts, &accountTask{next: common.BigToHash(next), last: common.BigToHash(last)})
+	}
+	return run
+}
+
+// finished reports whether all data has been retrieved.
+func (r *syncRun) finished() bool {
+	for _, task := range r.accounts {
+		if !task.done {
+			return false
+		}
+	}
+	return len(r.storage) == 0 && len(r.codes) == 0 && len(r.requests) == 0
+}
+
+// assign hands out retrieval tasks to all idle peers, preferring codes and storage
+// over accounts to keep the queues bounded. It returns whether any peer is able
+// to serve the state at all.
+func (s *Syncer) assign(run *syncRun) bool {
+	s.lock.RLock()
+	peers := make([]*Peer, 0, len(s.peers))
+	for _, peer := range s.peers {
+		peers = append(peers, peer)
+	}
+	s.lock.RUnlock()
+
+	usable := false
+	for _, peer := range peers {
+		id := peer.id
+		if _, ok := run.stateless[id]; ok {
+			continue
+		}
+		usable = true
+		if _, ok := run.busy[id]; ok {
+			continue
+		}
+		req := &request{id: s.reqID + 1, peer: id, sent: time.Now()}
+
+		var err error
+		switch {
+		case len(run.codes) > 0:
+			n := len(run.codes)
+			if n > maxCodeRequest {
+				n = maxCodeRequest
+			}
+			req.codes, run.codes = run.codes[:n], run.codes[n:]
+			err = peer.RequestByteCodes(req.id, req.codes, requestBytes)
+
+		case len(run.storage) > 0:
+			// Continue partial tries alone, bundle whole ones
+			n := 1
+			if run.storage[0].next == (common.Hash{}) {
+				for n < len(run.storage) && n < maxStorageRequest && run.storage[n].next == (common.Hash{}) {
+					n++
+				}
+			}
+			req.storage, run.storage = run.storage[:n], run.storage[n:]
+
+			roots := make([]common.Hash, len(req.storage))
+			for i, task := range req.storage {
+				roots[i] = task.root
+			}
+			err = peer.RequestStorageRanges(req.id, roots, req.storage[0].next, requestBytes)
+
+		default:
+			if len(run.storage) >= maxPendingStorage || len(run.codes) >= maxPendingCodes {
+				continue
+			}
+			for _, task := range run.accounts {
+				if !task.busy && !task.done {
+					req.account = task
+					break
+				}
+			}
+			if req.account == nil {
+				continue
+			}
+			req.account.busy = true
+			err = peer.RequestAccountRange(req.id, run.root, req.account.next, req.account.last, requestBytes)
+		}
+		s.reqID = req.id
+		run.requests[req.id] = req
+		run.busy[id] = struct{}{}
+
+		if err != nil {
+			glog.V(logger.Debug).Infof("%v: state request failed: %v", peer, err)
+			s.revert(run, req)
+			run.stateless[id] = struct{}{}
+		}
+	}
+	return usable
+}
+
+// revert returns the tasks of a failed request into the queues.
+func (s *Syncer) revert(run *syncRun, req *request) {
+	delete(run.requests, req.id)
+	delete(run.busy, req.peer)
+
+	if req.account != nil {
+		req.account.busy = false
+	}
+	run.storage = append(req.storage, run.storage...)
+	run.codes = append(req.codes, run.codes...)
+}
+
+// expire reverts all requests that were not answered in time, excluding the
+// peers from the remainder of the sync.
+func (s *Syncer) expire(run *syncRun) {
+	for _, req := range run.requests {
+		if time.Since(req.sent) > requestTimeout {
+			glog.V(logger.Debug).Infof("peer %s: state request %d timed out", req.peer, req.id)
+			s.revert(run, req)
+			run.stateless[req.peer] = struct{}{}
+			s.report(req.peer, p2p.PeerTimeout)
+		}
+	}
+}
+
+// process handles a response from a remote peer, reverting the request and
+// excluding the peer if it cannot serve the state or sent invalid data.
+func (s *Syncer) process(run *syncRun, d *delivery) {
+	req := run.requests[d.id]
+	if req == nil || req.peer != d.peer {
+		glog.V(logger.Detail).Infof("peer %s: %v %d", d.peer, errUnsolicited, d.id)
+		return
+	}
+	delete(run.requests, req.id)
+	delete(run.busy, req.peer)
+
+	var (
+		batch = s.db.NewBatch()
+		done  []common.Hash
+		err   error
+	)
+	switch packet := d.packet.(type) {
+	case *accountRangeData:
+		if req.account == nil {
+			err = fmt.Errorf("%v: accounts for non-account request", errUnsolicited)
+		} else {
+			err = s.processAccounts(run, req.account, packet, batch)
+		}
+	case *storageRangesData:
+		if req.storage == nil {
+			err = fmt.Errorf("%v: storage for non-storage request", errUnsolicited)
+		} else {
+			done, err = s.processStorage(run, req.storage, packet, batch)
+		}
+	case *byteCodesData:
+		if req.codes == nil {
+			err = fmt.Errorf("%v: codes for non-code request", errUnsolicited)
+		} else {
+			done, err = s.processCodes(run, req.codes, packet, batch)
+		}
+	}
+	for i := 0; err == nil && i < len(done); i++ {
+		err = s.resolve(run, done[i], batch)
+	}
+	if err == nil {
+		err = batch.Write()
+	}
+	if err != nil {
+		glog.V(logger.Debug).Infof("peer %s: state delivery rejected: %v", req.peer, err)
+		s.revert(run, req)
+		run.stateless[req.peer] = struct{}{}
+		if err != errUnavailable {
+			s.report(req.peer, p2p.PeerUselessResponse)
+		}
+		return
+	}
+	s.report(req.peer, p2p.PeerGoodDelivery)
+}
+
+// processAccounts verifies an account range, scheduling the storage tries and
+// codes of the retrieved accounts. The range is stored once all of them are.
+func (s *Syncer) processAccounts(run *syncRun, task *accountTask, res *accountRangeData, batch ethdb.Batch) error {
+	if len(res.Accounts) == 0 && len(res.Proof) == 0 {
+		return errUnavailable
+	}
+	keys, values := make([][]byte, len(res.Accounts)), make([][]byte, len(res.Accounts))
+	for i, account := range res.Accounts {
+		keys[i], values[i] = account.Hash[:], account.Body
+	}
+	more, err := trie.VerifyRangeProof(run.root, task.next[:], keys, values, res.Proof, nil, nil)
+	if err != nil {
+		return err
+	}
+	rng := &accountRange{origin: task.next, last: task.last, keys: keys, values: values, proof: res.Proof}
+	for i, account := range res.Accounts {
+		// Accounts past the chunk are scheduled by the chunk they belong to
+		if bytes.Compare(keys[i], task.last[:]) > 0 {
+			break
+		}
+		var data state.Account
+		if err := rlp.DecodeBytes(account.Body, &data); err != nil {
+			return err
+		}
+		if data.Root != emptyRoot {
+			s.await(run, rng, data.Root, true)
+		}
+		if hash := common.BytesToHash(data.CodeHash); hash != emptyCode {
+			s.await(run, rng, hash, false)
+		}
+	}
+	run.accountsDone += uint64(len(res.Accounts))
+
+	task.busy = false
+	if !more || (len(keys) > 0 && bytes.Compare(keys[len(keys)-1], task.last[:]) >= 0) {
+		task.done = true
+	} else if len(keys) > 0 {
+		task.next = incHash(common.BytesToHash(keys[len(keys)-1]))
+	}
+	if rng.pending == 0 {
+		return s.commit(run, rng, batch)
+	}
+	return nil
+}
+
+// processStorage verifies and stores the slots of a batch of storage tries,
+// rescheduling the ones not (fully) delivered. The roots of the tries completed
+// are returned.
+func (s *Syncer) processStorage(run *syncRun, tasks []*storageTask, res *storageRangesData, batch ethdb.Batch) ([]common.Hash, error) {
+	if len(res.Slots) == 0 && len(res.Proof) == 0 {
+		return nil, errUnavailable
+	}
+	if len(res.Slots) > len(tasks) {
+		return nil, fmt.Errorf("too many storage tries: have %d, requested %d", len(res.Slots), len(tasks))
+	}
+	var done []common.Hash
+	for i, slots := range res.Slots {
+		keys, values := make([][]byte, len(slots)), make([][]byte, len(slots))
+		for j, slot := range slots {
+			keys[j], values[j] = slot.Hash[:], slot.Body
+		}
+		task := tasks[i]
+
+		// Only the last trie may be partial, all others must be complete
+		var proof []rlp.RawValue
+		if i == len(res.Slots)-1 {
+			proof = res.Proof
+		}
+		if task.next != (common.Hash{}) && len(proof) == 0 {
+			return nil, fmt.Errorf("missing proof for storage range of %x", task.root)
+		}
+		more, err := trie.VerifyRangeProof(task.root, task.next[:], keys, values, proof, batch, nil)
+		if err != nil {
+			return nil, err
+		}
+		run.slotsDone += uint64(len(slots))
+
+		if !more {
+			done = append(done, task.root)
+			continue
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("empty partial storage range of %x", task.root)
+		}
+		// The root of a chunked trie is never stored, leave it to healing
+		run.chunked[task.root] = struct{}{}
+		run.storage = append([]*storageTask{{root: task.root, next: incHash(common.BytesToHash(keys[len(keys)-1]))}}, run.storage...)
+	}
+	run.storage = append(tasks[len(res.Slots):], run.storage...)
+	return done, nil
+}
+
+// processCodes verifies and stores a batch of contract codes, rescheduling the
+// ones not delivered. The hashes of the codes stored are returned.
+func (s *Syncer) processCodes(run *syncRun, hashes []common.Hash, res *byteCodesData, batch ethdb.Batch) ([]common.Hash, error) {
+	if len(res.Codes) == 0 {
+		return nil, errUnavailable
+	}
+	var done []common.Hash
+	pending := make(map[common.Hash]struct{}, len(hashes))
+	for _, hash := range hashes {
+		pending[hash] = struct{}{}
+	}
+	for _, code := range res.Codes {
+		hash := crypto.Keccak256Hash(code)
+		if _, ok := pending[hash]; !ok {
+			return nil, fmt.Errorf("unrequested code %x", hash)
+		}
+		delete(pending, hash)
+		if err := batch.Put(hash[:], code); err != nil {
+			return nil, err
+		}
+		done = append(done, hash)
+	}
+	run.codesDone += uint64(len(res.Codes))
+
+	for _, hash := range hashes {
+		if _, ok := pending[hash]; ok {
+			run.codes = append(run.codes, hash)
+		}
+	}
+	return done, nil
+}
+
+// await makes an account range wait for a storage trie or contract code,
+// scheduling its retrieval unless already in progress or available locally.
+func (s *Syncer) await(run *syncRun, rng *accountRange, hash common.Hash, storage bool) {
+	if waiting, ok := run.waiting[hash]; ok {
+		run.waiting[hash] = append(waiting, rng)
+		rng.pending++
+		return
+	}
+	if _, ok := run.chunked[hash]; ok {
+		return
+	}
+	if blob, _ := s.db.Get(hash[:]); len(blob) > 0 {
+		return
+	}
+	run.waiting[hash] = []*accountRange{rng}
+	rng.pending++
+
+	if storage {
+		run.storage = append(run.storage, &storageTask{root: hash})
+	} else {
+		run.codes = append(run.codes, hash)
+	}
+}
+
+// resolve marks a storage trie or contract code retrieved, storing the account
+// ranges no longer waiting on anything.
+func (s *Syncer) resolve(run *syncRun, hash common.Hash, batch ethdb.Batch) error {
+	waiting := run.waiting[hash]
+	delete(run.waiting, hash)
+
+	for _, rng := range waiting {
+		if rng.pending--; rng.pending == 0 {
+			if err := s.commit(run, rng, batch); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// commit stores the account trie nodes of a range. Accounts with chunked storage
+// tries and those past the chunk are left out, so healing reaches them.
+func (s *Syncer) commit(run *syncRun, rng *accountRange, batch ethdb.Batch) error {
+	skip := make(map[string]struct{})
+	for i, key := range rng.keys {
+		if bytes.Compare(key, rng.last[:]) > 0 {
+			skip[string(key)] = struct{}{}
+			continue
+		}
+		var data state.Account
+		if err := rlp.DecodeBytes(rng.values[i], &data); err != nil {
+			return err
+		}
+		if _, ok := run.chunked[data.Root]; ok {
+			skip[string(key)] = struct{}{}
+		}
+	}
+	incomplete := func(key []byte) bool {
+		_, ok := skip[string(key)]
+		return ok
+	}
+	_, err := trie.VerifyRangeProof(run.root, rng.origin[:], rng.keys, rng.values, rng.proof, batch, incomplete)
+	return err
+}
+
+// incHash returns the hash following h.
+func incHash(h common.Hash) common.Hash {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i]++
+		if h[i] != 0 {
+			break
+		}
+	}
+	return h
+}
@@ -0,0 +1,212 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/state"
+	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/p2p"
+	"github.com/ethereumproject/go-ethereum/trie"
+)
+
+// makeTestState creates a state with plain accounts, contracts with small
+// storage tries and a few contracts with storage too large for one response.
+func makeTestState(accounts int) (*ethdb.MemDatabase, common.Hash) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, db)
+
+	for i := 0; i < accounts; i++ {
+		addr := common.BytesToAddress([]byte{byte(i >> 8), byte(i)})
+		statedb.AddBalance(addr, big.NewInt(int64(i+1)))
+		statedb.SetNonce(addr, uint64(i))
+
+		if i%5 == 0 {
+			statedb.SetCode(addr, []byte{byte(i), byte(i >> 8), 0x60, 0x00})
+		}
+		slots := 0
+		switch {
+		case i%100 == 0:
+			slots = 2000
+		case i%3 == 0:
+			slots = i % 20
+		}
+		for j := 1; j <= slots; j++ {
+			statedb.SetState(addr, common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(i*j))))
+		}
+	}
+	root, _ := statedb.Commit()
+	return db, root
+}
+
+// connect links a syncer to a remote node serving the given database over an
+// in-memory message pipe.
+func connect(syncer *Syncer, local ethdb.Database, id string, remote ethdb.Database) {
+	app, net := p2p.MsgPipe()
+
+	server := NewSyncer(remote, nil)
+	go Handle(remote, server, NewPeer(snap1, "client", net))
+
+	peer := NewPeer(snap1, id, app)
+	syncer.Register(peer)
+	go func() {
+		Handle(local, syncer, peer)
+		syncer.Unregister(id)
+	}()
+}
+
+// heal completes a snapshot synced state node by node from a source database,
+// returning the number of retrieved nodes.
+func heal(t *testing.T, root common.Hash, dst, src ethdb.Database) int {
+	sched := state.NewStateSync(root, dst)
+
+	healed := 0
+	for queue := sched.Missing(0); len(queue) > 0; queue = sched.Missing(0) {
+		results := make([]trie.SyncResult, len(queue))
+		for i, hash := range queue {
+			data, err := src.Get(hash[:])
+			if err != nil {
+				t.Fatalf("failed to retrieve node data for %x: %v", hash, err)
+			}
+			results[i] = trie.SyncResult{Hash: hash, Data: data}
+		}
+		if _, err := sched.Process(results); err != nil {
+			t.Fatalf("failed to process results: %v", err)
+		}
+		healed += len(queue)
+	}
+	return healed
+}
+
+// checkState verifies that every state entry of the source database has been
+// reconstructed in the destination.
+func checkState(t *testing.T, dst ethdb.Database, src *ethdb.MemDatabase) {
+	for _, key := range src.Keys() {
+		if bytes.HasPrefix(key, []byte("secure-key-")) {
+			continue // preimages are not part of the state
+		}
+		want, _ := src.Get(key)
+		if have, _ := dst.Get(key); !bytes.Equal(have, want) {
+			t.Fatalf("state entry %x mismatch: have %x, want %x", key, have, want)
+		}
+	}
+}
+
+// Tests that the state can be retrieved from a single peer, leaving only a few
+// boundary nodes to be healed.
+func TestSyncSinglePeer(t *testing.T)    { testSync(t, 1) }
+func TestSyncMultiplePeers(t *testing.T) { testSync(t, 4) }
+
+func testSync(t *testing.T, peers int) {
+	defer func(bytes uint64) { requestBytes = bytes }(requestBytes)
+	requestBytes = 16 * 1024
+
+	src, root := makeTestState(1000)
+	dst, _ := ethdb.NewMemDatabase()
+
+	syncer := NewSyncer(dst, nil)
+	for i := 0; i < peers; i++ {
+		connect(syncer, dst, fmt.Sprintf("peer-%d", i), src)
+	}
+	if err := syncer.Sync(root, make(chan struct{})); err != nil {
+		t.Fatalf("failed to sync state: %v", err)
+	}
+	healed := heal(t, root, dst, src)
+	if total := len(src.Keys()); healed*4 > total {
+		t.Errorf("healed too many nodes: %d out of %d", healed, total)
+	}
+	checkState(t, dst, src)
+}
+
+// Tests that peers not having the requested state are skipped, and that the sync
+// fails if no peer can serve it.
+func TestSyncStatelessPeers(t *testing.T) {
+	src, root := makeTestState(100)
+	empty, _ := ethdb.NewMemDatabase()
+	dst, _ := ethdb.NewMemDatabase()
+
+	syncer := NewSyncer(dst, nil)
+	connect(syncer, dst, "stateless", empty)
+	if err := syncer.Sync(root, make(chan struct{})); err != errNoSnapPeers {
+		t.Fatalf("sync error mismatch: have %v, want %v", err, errNoSnapPeers)
+	}
+	connect(syncer, dst, "full", src)
+	if err := syncer.Sync(root, make(chan struct{})); err != nil {
+		t.Fatalf("failed to sync state: %v", err)
+	}
+	heal(t, root, dst, src)
+	checkState(t, dst, src)
+}
+
+// Tests that a peer serving incomplete account ranges is reported and its data
+// discarded, with the sync completing from the remaining honest peers.
+func TestSyncBadPeer(t *testing.T) {
+	src, root := makeTestState(1000)
+	dst, _ := ethdb.NewMemDatabase()
+
+	reports := make(map[string][]p2p.PeerEvent)
+	syncer := NewSyncer(dst, func(id string, event p2p.PeerEvent) {
+		reports[id] = append(reports[id], event)
+	})
+	// Create a peer withholding an account from every range it serves
+	app, net := p2p.MsgPipe()
+	go func() {
+		for {
+			msg, err := net.ReadMsg()
+			if err != nil {
+				return
+			}
+			var req getAccountRangeData
+			if msg.Code != GetAccountRangeMsg || msg.Decode(&req) != nil {
+				return
+			}
+			res := serveAccountRange(src, &req)
+			if len(res.Accounts) > 2 {
+				res.Accounts = append(res.Accounts[:1], res.Accounts[2:]...)
+			}
+			p2p.Send(net, AccountRangeMsg, res)
+		}
+	}()
+	bad := NewPeer(snap1, "bad", app)
+	syncer.Register(bad)
+	go Handle(dst, syncer, bad)
+
+	if err := syncer.Sync(root, make(chan struct{})); err != errNoSnapPeers {
+		t.Fatalf("sync error mismatch: have %v, want %v", err, errNoSnapPeers)
+	}
+	if events := reports["bad"]; len(events) != 1 || events[0] != p2p.PeerUselessResponse {
+		t.Fatalf("bad peer reports mismatch: have %v, want [%v]", events, p2p.PeerUselessResponse)
+	}
+	// Sync from an honest peer and check the withheld data is not missing
+	connect(syncer, dst, "good", src)
+	if err := syncer.Sync(root, make(chan struct{})); err != nil {
+		t.Fatalf("failed to sync state: %v", err)
+	}
+	heal(t, root, dst, src)
+	checkState(t, dst, src)
+
+	for _, event := range reports["good"] {
+		if event != p2p.PeerGoodDelivery {
+			t.Errorf("honest peer reported for %v", event)
+		}
+	}
+}
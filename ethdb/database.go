@@ -26,6 +26,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 var OpenFileLimit = 64
@@ -107,6 +108,12 @@ func (self *LDBDatabase) NewIterator() iterator.Iterator {
 	return self.db.NewIterator(nil, nil)
 }
 
+// NewIteratorWithPrefix returns an iterator over the keys starting with the given
+// prefix, in ascending key order.
+func (self *LDBDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	return self.db.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
 func (self *LDBDatabase) Close() {
 	if err := self.db.Close(); err != nil {
 		glog.Errorf("eth: DB %s: %s", self.file, err)
@@ -16,6 +16,8 @@
 
 package ethdb
 
+import "github.com/syndtr/goleveldb/leveldb/iterator"
+
 type Database interface {
 	Put(key []byte, value []byte) error
 	Get(key []byte) ([]byte, error)
@@ -24,6 +26,13 @@ type Database interface {
 	NewBatch() Batch
 }
 
+// Iteratee is implemented by the databases able to iterate over their keys.
+type Iteratee interface {
+	// NewIteratorWithPrefix returns an iterator over the keys starting with the
+	// given prefix, in ascending key order.
+	NewIteratorWithPrefix(prefix []byte) iterator.Iterator
+}
+
 type Batch interface {
 	Put(key, value []byte) error
 	Write() error
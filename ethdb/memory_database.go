@@ -18,9 +18,14 @@ package ethdb
 
 import (
 	"errors"
+	"strings"
 	"sync"
 
 	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 /*
@@ -89,6 +94,21 @@ func (db *MemDatabase) Delete(key []byte) error {
 	return nil
 }
 
+// NewIteratorWithPrefix returns an iterator over a snapshot of the keys starting
+// with the given prefix, in ascending key order.
+func (db *MemDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	snapshot := memdb.New(comparer.DefaultComparer, 0)
+	for key, value := range db.db {
+		if strings.HasPrefix(key, string(prefix)) {
+			snapshot.Put([]byte(key), value)
+		}
+	}
+	return snapshot.NewIterator(util.BytesPrefix(prefix))
+}
+
 func (db *MemDatabase) Close() {}
 
 func (db *MemDatabase) NewBatch() Batch {
@@ -0,0 +1,393 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxQueryDepth is the maximum nesting of fields in a query, bounding the work a
+// single request may cause through cyclic types (block.parent.parent...).
+const maxQueryDepth = 12
+
+// errUnknownField is returned by resolvers for fields their type doesn't have.
+var errUnknownField = errors.New("unknown field")
+
+// object is an instance of a GraphQL object type, resolving its fields on
+// demand. Resolved values are either scalars encoded as JSON, objects, or lists
+// of either.
+type object interface {
+	typeName() string
+	resolve(ctx context.Context, field string, args arguments) (interface{}, error)
+}
+
+// arguments are the coerced arguments of a field: nil, bool, string,
+// json.Number, []interface{} or map[string]interface{} values.
+type arguments map[string]interface{}
+
+// queryError is an error of a request, located at the path of the field which
+// caused it, if any.
+type queryError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// response is the result of a request.
+type response struct {
+	Data   *orderedMap   `json:"data,omitempty"`
+	Errors []*queryError `json:"errors,omitempty"`
+}
+
+// orderedMap is a JSON object keeping its keys in selection order.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]interface{})}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		blob, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(blob)
+		buf.WriteByte(':')
+		if blob, err = json.Marshal(m.values[key]); err != nil {
+			return nil, err
+		}
+		buf.Write(blob)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// executor runs a single operation of a document against a root object.
+type executor struct {
+	ctx       context.Context
+	doc       *document
+	variables map[string]interface{}
+	errors    []*queryError
+}
+
+// execute parses and runs a GraphQL request. Errors of individual fields are
+// reported alongside the data, nulling the failing fields; errors of the request
+// itself leave no data at all.
+func execute(ctx context.Context, root object, query string, variables map[string]interface{}, operationName string) *response {
+	doc, err := parse(query)
+	if err != nil {
+		return &response{Errors: []*queryError{{Message: fmt.Sprintf("syntax error: %v", err)}}}
+	}
+	op, err := doc.operation(operationName)
+	if err != nil {
+		return &response{Errors: []*queryError{{Message: err.Error()}}}
+	}
+	if op.kind != "query" {
+		return &response{Errors: []*queryError{{Message: fmt.Sprintf("%s operations are not supported", op.kind)}}}
+	}
+	if op.depth > maxQueryDepth {
+		return &response{Errors: []*queryError{{Message: fmt.Sprintf("query depth %d exceeds the maximum depth of %d", op.depth, maxQueryDepth)}}}
+	}
+	exec := &executor{ctx: ctx, doc: doc}
+	if exec.variables, err = coerceVariables(op.variables, variables); err != nil {
+		return &response{Errors: []*queryError{{Message: err.Error()}}}
+	}
+	data := exec.selectFields(root, op.selections, nil)
+	return &response{Data: data, Errors: exec.errors}
+}
+
+// operation selects the operation to execute from the document.
+func (doc *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, errors.New("operation name required for documents with multiple operations")
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+// coerceVariables validates the provided variables against the definitions of
+// the operation, filling in the default values of missing ones.
+func coerceVariables(defs []*variableDefinition, provided map[string]interface{}) (map[string]interface{}, error) {
+	vars := make(map[string]interface{})
+	for _, def := range defs {
+		val, ok := provided[def.name]
+		if !ok && def.defValue != nil {
+			val, _ = literal(def.defValue, nil)
+		}
+		if val == nil && strings.HasSuffix(def.typ, "!") {
+			return nil, fmt.Errorf("variable $%s of required type %s was not provided", def.name, def.typ)
+		}
+		vars[def.name] = val
+	}
+	return vars, nil
+}
+
+// literal converts an input value literal into its runtime representation,
+// substituting variables.
+func literal(val value, vars map[string]interface{}) (interface{}, error) {
+	switch val := val.(type) {
+	case variable:
+		v, ok := vars[string(val)]
+		if !ok {
+			return nil, fmt.Errorf("variable $%s is not defined", val)
+		}
+		return v, nil
+	case enumValue:
+		return string(val), nil
+	case []value:
+		list := make([]interface{}, len(val))
+		for i, item := range val {
+			v, err := literal(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = v
+		}
+		return list, nil
+	case map[string]value:
+		obj := make(map[string]interface{})
+		for key, item := range val {
+			v, err := literal(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = v
+		}
+		return obj, nil
+	}
+	return val, nil
+}
+
+// arguments evaluates the arguments of a field or directive.
+func (e *executor) arguments(args []*argument) (arguments, error) {
+	result := make(arguments)
+	for _, arg := range args {
+		val, err := literal(arg.value, e.variables)
+		if err != nil {
+			return nil, err
+		}
+		result[arg.name] = val
+	}
+	return result, nil
+}
+
+// included evaluates the @skip and @include directives of a selection.
+func (e *executor) included(directives []*directive) (bool, error) {
+	for _, dir := range directives {
+		args, err := e.arguments(dir.arguments)
+		if err != nil {
+			return false, err
+		}
+		cond, ok := args["if"].(bool)
+		if !ok {
+			return false, fmt.Errorf("directive @%s requires a boolean 'if' argument", dir.name)
+		}
+		if (dir.name == "skip") == cond {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// collectFields flattens the fragments of a selection set applying to the given
+// type, grouping the fields by their response key.
+func (e *executor) collectFields(typeName string, selections []selection, keys []string, fields map[string][]*field, visited map[string]bool) ([]string, error) {
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *field:
+			if ok, err := e.included(sel.directives); err != nil || !ok {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			key := sel.key()
+			if _, exists := fields[key]; !exists {
+				keys = append(keys, key)
+			}
+			fields[key] = append(fields[key], sel)
+
+		case *fragmentSpread:
+			if ok, err := e.included(sel.directives); err != nil || !ok {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if visited[sel.name] {
+				continue
+			}
+			visited[sel.name] = true
+
+			frag, ok := e.doc.fragments[sel.name]
+			if !ok {
+				return nil, fmt.Errorf("unknown fragment %q", sel.name)
+			}
+			if frag.on != typeName {
+				continue
+			}
+			var err error
+			if keys, err = e.collectFields(typeName, frag.selections, keys, fields, visited); err != nil {
+				return nil, err
+			}
+
+		case *inlineFragment:
+			if ok, err := e.included(sel.directives); err != nil || !ok {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if sel.on != "" && sel.on != typeName {
+				continue
+			}
+			var err error
+			if keys, err = e.collectFields(typeName, sel.selections, keys, fields, visited); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return keys, nil
+}
+
+// selectFields resolves the selections of an object, nulling and reporting the
+// fields that fail.
+func (e *executor) selectFields(obj object, selections []selection, path []interface{}) *orderedMap {
+	result := newOrderedMap()
+	fields := make(map[string][]*field)
+	keys, err := e.collectFields(obj.typeName(), selections, nil, fields, make(map[string]bool))
+	if err != nil {
+		e.fail(path, err)
+		return nil
+	}
+	for _, key := range keys {
+		var (
+			fieldPath = append(append([]interface{}{}, path...), key)
+			first     = fields[key][0]
+		)
+		// Fields sharing a response key have their sub-selections merged
+		var subselections []selection
+		for _, f := range fields[key] {
+			subselections = append(subselections, f.selections...)
+		}
+		if first.name == "__typename" {
+			result.set(key, obj.typeName())
+			continue
+		}
+		val, err := e.resolve(obj, first)
+		if err != nil {
+			e.fail(fieldPath, err)
+			result.set(key, nil)
+			continue
+		}
+		result.set(key, e.complete(obj, first, val, subselections, fieldPath))
+	}
+	return result
+}
+
+// resolve evaluates the arguments of a field and resolves it on the object.
+func (e *executor) resolve(obj object, f *field) (interface{}, error) {
+	if err := e.ctx.Err(); err != nil {
+		return nil, err
+	}
+	args, err := e.arguments(f.arguments)
+	if err != nil {
+		return nil, err
+	}
+	val, err := obj.resolve(e.ctx, f.name, args)
+	if err == errUnknownField {
+		return nil, fmt.Errorf("cannot query field %q on type %q", f.name, obj.typeName())
+	}
+	return val, err
+}
+
+// complete converts a resolved value into its response representation, running
+// the sub-selections of objects.
+func (e *executor) complete(parent object, f *field, val interface{}, selections []selection, path []interface{}) interface{} {
+	if isNil(val) {
+		return nil
+	}
+	switch val := val.(type) {
+	case object:
+		if len(selections) == 0 {
+			e.fail(path, fmt.Errorf("field %q of type %q must have a selection of subfields", f.name, val.typeName()))
+			return nil
+		}
+		if res := e.selectFields(val, selections, path); res != nil {
+			return res
+		}
+		return nil
+
+	case []object:
+		list := make([]interface{}, len(val))
+		for i, item := range val {
+			list[i] = e.complete(parent, f, item, selections, append(append([]interface{}{}, path...), i))
+		}
+		return list
+	}
+	if len(selections) > 0 {
+		e.fail(path, fmt.Errorf("field %q on type %q is a scalar and can't have a selection", f.name, parent.typeName()))
+		return nil
+	}
+	return val
+}
+
+// fail records a field error.
+func (e *executor) fail(path []interface{}, err error) {
+	e.errors = append(e.errors, &queryError{Message: err.Error(), Path: path})
+}
+
+// isNil reports whether a resolved value is nil, including typed nil pointers.
+func isNil(val interface{}) bool {
+	if val == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(val); v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
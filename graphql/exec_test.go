@@ -0,0 +1,122 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// testObject is an object type resolving its fields from a map. Functions are
+// called with the arguments of the field.
+type testObject struct {
+	name   string
+	fields map[string]interface{}
+}
+
+func (o *testObject) typeName() string { return o.name }
+
+func (o *testObject) resolve(ctx context.Context, field string, args arguments) (interface{}, error) {
+	val, ok := o.fields[field]
+	if !ok {
+		return nil, errUnknownField
+	}
+	if fn, ok := val.(func(arguments) (interface{}, error)); ok {
+		return fn(args)
+	}
+	return val, nil
+}
+
+// newTestRoot creates a small object graph: a Query with a Node, a list of
+// nodes of two types, and fields echoing their arguments or failing.
+func newTestRoot() object {
+	leaf := &testObject{"Leaf", map[string]interface{}{"id": 3, "value": "leaf"}}
+	node := &testObject{"Node", map[string]interface{}{"id": 1, "name": "one", "child": leaf}}
+	other := &testObject{"Other", map[string]interface{}{"id": 2, "label": "two"}}
+	return &testObject{"Query", map[string]interface{}{
+		"node":  node,
+		"nodes": []object{node, other},
+		"none":  (*testObject)(nil),
+		"echo": func(args arguments) (interface{}, error) {
+			blob, err := json.Marshal(args["arg"])
+			return string(blob), err
+		},
+		"fail": func(arguments) (interface{}, error) { return nil, errors.New("failed") },
+	}}
+}
+
+// Tests the execution semantics of the specification (section 6) and the
+// response format (section 7).
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		query string
+		vars  map[string]interface{}
+		op    string
+		want  string
+	}{
+		// Fields are returned in selection order, aliases rename them
+		{`{ node { name id } first: node { id } }`, nil, "", `{"data":{"node":{"name":"one","id":1},"first":{"id":1}}}`},
+		// Fields sharing a response key are merged, including their selections
+		{`{ node { id } node { name child { id } } node { child { value } } }`, nil, "", `{"data":{"node":{"id":1,"name":"one","child":{"id":3,"value":"leaf"}}}}`},
+		// Fragments apply only to their type condition, each is spread once
+		{`{ nodes { __typename ...N ... on Other { label } ... { id } ...N } } fragment N on Node { name }`, nil, "", `{"data":{"nodes":[{"__typename":"Node","name":"one","id":1},{"__typename":"Other","label":"two","id":2}]}}`},
+		// @skip takes precedence over @include
+		{`{ node { id @skip(if: true) @include(if: true) name @include(if: false) child @skip(if: false) { id } } }`, nil, "", `{"data":{"node":{"child":{"id":3}}}}`},
+		{`{ node { ... @skip(if: true) { id } ...N @include(if: false) name } } fragment N on Node { id }`, nil, "", `{"data":{"node":{"name":"one"}}}`},
+		{`{ node { id @skip(if: "yes") } }`, nil, "", `{"data":{"node":null},"errors":[{"message":"directive @skip requires a boolean 'if' argument","path":["node"]}]}`},
+		// Argument literals and variables, with defaults for missing variables
+		{`{ echo(arg: {list: [1, 2.5, "s", true, null, ENUM]}) }`, nil, "", `{"data":{"echo":"{\"list\":[1,2.5,\"s\",true,null,\"ENUM\"]}"}}`},
+		{`query ($v: Int = 7, $w: String) { a: echo(arg: $v) b: echo(arg: $w) }`, nil, "", `{"data":{"a":"7","b":"null"}}`},
+		{`query ($v: Int = 7) { echo(arg: $v) }`, map[string]interface{}{"v": json.Number("8")}, "", `{"data":{"echo":"8"}}`},
+		{`query ($v: Int!) { echo(arg: $v) }`, nil, "", `{"errors":[{"message":"variable $v of required type Int! was not provided"}]}`},
+		{`{ echo(arg: $v) }`, nil, "", `{"data":{"echo":null},"errors":[{"message":"variable $v is not defined","path":["echo"]}]}`},
+		// Field errors null the nearest field and are reported with their path
+		{`{ fail node { id } }`, nil, "", `{"data":{"fail":null,"node":{"id":1}},"errors":[{"message":"failed","path":["fail"]}]}`},
+		{`{ nodes { name } }`, nil, "", `{"data":{"nodes":[{"name":"one"},{"name":null}]},"errors":[{"message":"cannot query field \"name\" on type \"Other\"","path":["nodes",1,"name"]}]}`},
+		{`{ none { id } }`, nil, "", `{"data":{"none":null}}`},
+		// Operation selection
+		{`query A { node { id } } query B { node { name } }`, nil, "B", `{"data":{"node":{"name":"one"}}}`},
+		{`query A { node { id } } query B { node { name } }`, nil, "C", `{"errors":[{"message":"unknown operation \"C\""}]}`},
+		{`subscription { node { id } }`, nil, "", `{"errors":[{"message":"subscription operations are not supported"}]}`},
+	}
+	for i, tt := range tests {
+		res := execute(context.Background(), newTestRoot(), tt.query, tt.vars, tt.op)
+		blob, err := json.Marshal(res)
+		if err != nil {
+			t.Fatalf("test %d: failed to encode response: %v", i, err)
+		}
+		if string(blob) != tt.want {
+			t.Errorf("test %d: response mismatch for %q:\nhave %s\nwant %s", i, tt.query, blob, tt.want)
+		}
+	}
+}
+
+// Tests that a cancelled request stops resolving fields.
+func TestExecuteCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res := execute(ctx, newTestRoot(), `{ node { id } }`, nil, "")
+	if want := fmt.Sprintf(`[{"message":"%v","path":["node"]}]`, context.Canceled); res.Errors == nil {
+		t.Fatalf("cancelled request succeeded")
+	} else if blob, _ := json.Marshal(res.Errors); string(blob) != want {
+		t.Errorf("errors mismatch: have %s, want %s", blob, want)
+	}
+}
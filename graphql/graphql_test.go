@@ -0,0 +1,255 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core"
+	"github.com/ethereumproject/go-ethereum/core/state"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/crypto"
+	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/event"
+)
+
+type testBackend struct {
+	chain *core.BlockChain
+	db    ethdb.Database
+	pool  *core.TxPool
+}
+
+func (b *testBackend) BlockChain() *core.BlockChain { return b.chain }
+func (b *testBackend) ChainDb() ethdb.Database      { return b.db }
+func (b *testBackend) TxPool() *core.TxPool         { return b.pool }
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testSigner  = types.NewChainIdSigner(big.NewInt(63))
+	testTopic   = common.BigToHash(big.NewInt(0xaa))
+	testLogCode = common.Hex2Bytes("60aa60006000a100") // LOG1(0, 0, 0xaa)
+)
+
+// newTestBackend creates a chain of two blocks, the first containing a value
+// transfer and a contract creation emitting a log, and a pool with a pending
+// transfer.
+func newTestBackend(t *testing.T) (*testBackend, []*types.Transaction) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := core.WriteGenesisBlockForTesting(db, core.GenesisAccount{Address: testAddr, Balance: big.NewInt(1000000000)})
+
+	transfer, _ := types.NewTransaction(0, common.Address{0x02}, big.NewInt(1000), core.TxGas, nil, nil).WithSigner(testSigner).SignECDSA(testKey)
+	create, _ := types.NewContractCreation(1, new(big.Int), big.NewInt(100000), new(big.Int), testLogCode).WithSigner(testSigner).SignECDSA(testKey)
+	pending, _ := types.NewTransaction(2, common.Address{0x03}, big.NewInt(1000), core.TxGas, nil, nil).WithSigner(testSigner).SignECDSA(testKey)
+
+	config := core.MakeDiehardChainConfig()
+	blocks, _ := core.GenerateChain(config, genesis, db, 2, func(i int, gen *core.BlockGen) {
+		if i == 0 {
+			gen.AddTx(transfer)
+			gen.AddTx(create)
+		}
+	})
+	mux := new(event.TypeMux)
+	chain, err := core.NewBlockChain(db, config, core.FakePow{}, mux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", i, err)
+	}
+	pool := core.NewTxPool(config, mux, func() (*state.StateDB, error) { return chain.State() }, func() *big.Int { return big.NewInt(1000000) })
+	if err := pool.Add(pending); err != nil {
+		t.Fatalf("failed to add pending transaction: %v", err)
+	}
+	return &testBackend{chain, db, pool}, []*types.Transaction{transfer, create, pending}
+}
+
+// runQuery issues a GraphQL request against the service, returning the decoded
+// response.
+func runQuery(t *testing.T, service *Service, query string, variables map[string]interface{}) map[string]interface{} {
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	req := httptest.NewRequest("POST", Path, strings.NewReader(string(body)))
+	rec := httptest.NewRecorder()
+	service.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status mismatch: have %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var res map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body, err)
+	}
+	return res
+}
+
+// checkResponse compares a response against the expected JSON.
+func checkResponse(t *testing.T, name string, have map[string]interface{}, want string) {
+	var expect map[string]interface{}
+	if err := json.Unmarshal([]byte(want), &expect); err != nil {
+		t.Fatalf("%s: invalid expectation: %v", name, err)
+	}
+	if !reflect.DeepEqual(have, expect) {
+		blob, _ := json.Marshal(have)
+		t.Errorf("%s: response mismatch:\nhave %s\nwant %s", name, blob, want)
+	}
+}
+
+func TestNestedQueries(t *testing.T) {
+	backend, txs := newTestBackend(t)
+	defer backend.pool.Stop()
+	service := New(backend)
+
+	contract := crypto.CreateAddress(testAddr, 1)
+	block := backend.chain.GetBlockByNumber(1)
+
+	// A block with its transactions, receipts and logs in one round trip
+	res := runQuery(t, service, `{
+		block(number: 1) {
+			number
+			hash
+			transactionCount
+			transactions {
+				hash
+				index
+				from { address }
+				to { address balance }
+				gasUsed
+				createdContract { address code }
+				logs { index topics account { address } transaction { hash } }
+			}
+		}
+	}`, nil)
+	checkResponse(t, "block", res, fmt.Sprintf(`{"data": {"block": {
+		"number": 1, "hash": "%s", "transactionCount": 2,
+		"transactions": [
+			{"hash": "%s", "index": 0, "from": {"address": "%s"}, "to": {"address": "%s", "balance": "0x3e8"}, "gasUsed": 21000, "createdContract": null, "logs": []},
+			{"hash": "%s", "index": 1, "from": {"address": "%s"}, "to": null, "gasUsed": %d, "createdContract": {"address": "%s", "code": "0x"},
+			 "logs": [{"index": 0, "topics": ["%s"], "account": {"address": "%s"}, "transaction": {"hash": "%s"}}]}
+		]
+	}}}`, block.Hash().Hex(),
+		txs[0].Hash().Hex(), testAddr.Hex(), common.Address{0x02}.Hex(),
+		txs[1].Hash().Hex(), testAddr.Hex(), core.GetReceipt(backend.db, txs[1].Hash()).GasUsed.Uint64(), contract.Hex(),
+		testTopic.Hex(), contract.Hex(), txs[1].Hash().Hex()))
+
+	// Block ranges, aliases, fragments and directives
+	res = runQuery(t, service, `
+		query Range($skip: Boolean!) {
+			blocks(from: 0) { ...numbers }
+			head: block { number parent { number } }
+			genesis: block(number: 0) { parent { number } hash @skip(if: $skip) }
+		}
+		fragment numbers on Block { number ... on Block { transactionCount } }
+	`, map[string]interface{}{"skip": true})
+	checkResponse(t, "blocks", res, `{"data": {
+		"blocks": [{"number": 0, "transactionCount": 0}, {"number": 1, "transactionCount": 2}, {"number": 2, "transactionCount": 0}],
+		"head": {"number": 2, "parent": {"number": 1}},
+		"genesis": {"parent": null}
+	}}`)
+
+	// Logs filtered over a range, with variables
+	res = runQuery(t, service, `query Logs($topic: Bytes32!) {
+		logs(filter: {fromBlock: 0, topics: [[$topic]]}) { data transaction { index block { number } } }
+	}`, map[string]interface{}{"topic": testTopic.Hex()})
+	checkResponse(t, "logs", res, `{"data": {"logs": [{"data": "0x", "transaction": {"index": 1, "block": {"number": 1}}}]}}`)
+
+	// Pending transactions and state
+	res = runQuery(t, service, fmt.Sprintf(`{
+		pending { transactionCount transactions { hash index block { number } gasUsed } account(address: "%s") { transactionCount } }
+		transaction(hash: "%s") { nonce }
+		account(address: "%s", blockNumber: 0) { transactionCount }
+	}`, testAddr.Hex(), txs[2].Hash().Hex(), testAddr.Hex()), nil)
+	checkResponse(t, "pending", res, fmt.Sprintf(`{"data": {
+		"pending": {"transactionCount": 1, "transactions": [{"hash": "%s", "index": null, "block": null, "gasUsed": null}], "account": {"transactionCount": 3}},
+		"transaction": {"nonce": 2},
+		"account": {"transactionCount": 0}
+	}}`, txs[2].Hash().Hex()))
+}
+
+func TestQueryErrors(t *testing.T) {
+	backend, _ := newTestBackend(t)
+	defer backend.pool.Stop()
+	service := New(backend)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		// Request errors yield no data
+		{`{ block { number }`, `{"errors": [{"message": "syntax error: unexpected end of document"}]}`},
+		{`mutation { block { number } }`, `{"errors": [{"message": "mutation operations are not supported"}]}`},
+		{`query A { block { number } } query B { pending { transactionCount } }`, `{"errors": [{"message": "operation name required for documents with multiple operations"}]}`},
+		{`query ($n: Long!) { block(number: $n) { number } }`, `{"errors": [{"message": "variable $n of required type Long! was not provided"}]}`},
+
+		// Field errors null the field and report its path
+		{`{ block { number foo } }`, `{"data": {"block": {"number": 2, "foo": null}}, "errors": [{"message": "cannot query field \"foo\" on type \"Block\"", "path": ["block", "foo"]}]}`},
+		{`{ block }`, `{"data": {"block": null}, "errors": [{"message": "field \"block\" of type \"Block\" must have a selection of subfields", "path": ["block"]}]}`},
+		{`{ block { number { foo } } }`, `{"data": {"block": {"number": null}}, "errors": [{"message": "field \"number\" on type \"Block\" is a scalar and can't have a selection", "path": ["block", "number"]}]}`},
+		{`{ blocks(from: 0) { transactionAt(index: "x") { hash } } }`, `{"data": {"blocks": [{"transactionAt": null}, {"transactionAt": null}, {"transactionAt": null}]}, "errors": [
+			{"message": "invalid argument 'index': strconv.ParseUint: parsing \"x\": invalid syntax", "path": ["blocks", 0, "transactionAt"]},
+			{"message": "invalid argument 'index': strconv.ParseUint: parsing \"x\": invalid syntax", "path": ["blocks", 1, "transactionAt"]},
+			{"message": "invalid argument 'index': strconv.ParseUint: parsing \"x\": invalid syntax", "path": ["blocks", 2, "transactionAt"]}]}`},
+		{`{ blocks(from: 0, to: 5000) { number } }`, `{"data": {"blocks": [{"number": 0}, {"number": 1}, {"number": 2}]}}`},
+		{`{ logs(filter: {fromBlock: 0, toBlock: 1, addresses: ["0x01"]}) { data } }`, `{"data": {"logs": null}, "errors": [{"message": "0x01 is 1 bytes long, want 20", "path": ["logs"]}]}`},
+		{`{ block { parent { parent { parent { number } } } } }`, `{"data": {"block": {"parent": {"parent": {"parent": null}}}}}`},
+	}
+	for i, tt := range tests {
+		checkResponse(t, fmt.Sprintf("test %d", i), runQuery(t, service, tt.query, nil), tt.want)
+	}
+}
+
+// Tests that log searches are aborted once the request is cancelled.
+func TestLogsCancelled(t *testing.T) {
+	backend, _ := newTestBackend(t)
+	defer backend.pool.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	filter := map[string]interface{}{"fromBlock": json.Number("0"), "topics": []interface{}{[]interface{}{testTopic.Hex()}}}
+	if _, err := (&query{backend}).resolve(ctx, "logs", arguments{"filter": filter}); err != context.Canceled {
+		t.Fatalf("error mismatch: have %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestQueryDepthLimit(t *testing.T) {
+	backend, _ := newTestBackend(t)
+	defer backend.pool.Stop()
+
+	deep := "{ block " + strings.Repeat("{ parent ", maxQueryDepth) + "{ number }" + strings.Repeat(" }", maxQueryDepth) + " }"
+	res := execute(context.Background(), &query{backend}, deep, nil, "")
+	if len(res.Errors) != 1 || !strings.Contains(res.Errors[0].Message, "maximum depth") {
+		t.Fatalf("depth limit not enforced: %+v", res.Errors)
+	}
+}
+
+func TestSchemaRequest(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New(nil).ServeHTTP(rec, httptest.NewRequest("GET", Path, nil))
+
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "type Query {") {
+		t.Errorf("schema not served: %d %q", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,738 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+// The parser implements the subset of the GraphQL executable document syntax
+// (https://facebook.github.io/graphql/October2016/) needed to query the node:
+// query operations with variables, fields with aliases and arguments, named and
+// inline fragments, and the @skip and @include directives on selections. Block
+// strings and directives on operations and fragment definitions are rejected.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSelections is the maximum number of selections of an operation once its
+// fragment spreads are inlined, bounding the work of documents reusing fragments.
+const maxSelections = 10000
+
+// document is a parsed GraphQL request.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+	measures   map[string]*measure // Sizes of the fragments validated, nil while in progress
+}
+
+// operation is a query, mutation or subscription of a document.
+type operation struct {
+	kind       string // "query", "mutation" or "subscription"
+	name       string
+	variables  []*variableDefinition
+	selections []selection
+	depth      int // Nesting of fields with fragments inlined, set by validate
+}
+
+// variableDefinition declares a variable of an operation.
+type variableDefinition struct {
+	name     string
+	typ      string // Textual type, e.g. "[Address!]!"
+	defValue value  // Default value, nil if none
+}
+
+// fragment is a named, reusable set of selections on a type.
+type fragment struct {
+	name       string
+	on         string
+	selections []selection
+}
+
+// selection is either a *field, a *fragmentSpread or an *inlineFragment.
+type selection interface{}
+
+type field struct {
+	alias      string
+	name       string
+	arguments  []*argument
+	directives []*directive
+	selections []selection
+}
+
+// key returns the name of the field in the response.
+func (f *field) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+}
+
+type inlineFragment struct {
+	on         string // Empty if no type condition is given
+	directives []*directive
+	selections []selection
+}
+
+type argument struct {
+	name  string
+	value value
+}
+
+type directive struct {
+	name      string
+	arguments []*argument
+}
+
+// value is an input value literal: nil, bool, string, json.Number, enumValue,
+// variable, []value or map[string]value.
+type value interface{}
+
+type enumValue string
+
+type variable string
+
+// token kinds of the lexer.
+const (
+	tokEOF = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+// lexer splits a GraphQL document into tokens.
+type lexer struct {
+	input string
+	pos   int
+}
+
+// next returns the next significant token, skipping whitespace, commas and
+// comments.
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.input) {
+		switch c := l.input[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.input) && l.input[l.pos] != '\n' && l.input[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return l.scan()
+		}
+	}
+	return token{kind: tokEOF, pos: l.pos}, nil
+}
+
+func (l *lexer) scan() (token, error) {
+	start := l.pos
+	c := l.input[l.pos]
+	switch {
+	case strings.HasPrefix(l.input[l.pos:], "..."):
+		l.pos += 3
+		return token{tokPunct, "...", start}, nil
+
+	case strings.IndexByte("!$():=@[]{}|", c) >= 0:
+		l.pos++
+		return token{tokPunct, string(c), start}, nil
+
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.input) && (l.input[l.pos] == '_' || isLetter(l.input[l.pos]) || isDigit(l.input[l.pos])) {
+			l.pos++
+		}
+		return token{tokName, l.input[start:l.pos], start}, nil
+
+	case c == '-' || isDigit(c):
+		return l.scanNumber()
+
+	case c == '"':
+		return l.scanString()
+	}
+	r, _ := utf8.DecodeRuneInString(l.input[l.pos:])
+	return token{}, fmt.Errorf("unexpected character %q at offset %d", r, start)
+}
+
+func (l *lexer) scanNumber() (token, error) {
+	start, kind := l.pos, tokInt
+	if l.input[l.pos] == '-' {
+		l.pos++
+	}
+	digits := func() int {
+		from := l.pos
+		for l.pos < len(l.input) && isDigit(l.input[l.pos]) {
+			l.pos++
+		}
+		return l.pos - from
+	}
+	if n := digits(); n == 0 || (n > 1 && l.input[l.pos-n] == '0') {
+		return token{}, fmt.Errorf("invalid number at offset %d", start)
+	}
+	if l.pos < len(l.input) && l.input[l.pos] == '.' {
+		kind = tokFloat
+		l.pos++
+		if digits() == 0 {
+			return token{}, fmt.Errorf("invalid number at offset %d", start)
+		}
+	}
+	if l.pos < len(l.input) && (l.input[l.pos] == 'e' || l.input[l.pos] == 'E') {
+		kind = tokFloat
+		l.pos++
+		if l.pos < len(l.input) && (l.input[l.pos] == '+' || l.input[l.pos] == '-') {
+			l.pos++
+		}
+		if digits() == 0 {
+			return token{}, fmt.Errorf("invalid number at offset %d", start)
+		}
+	}
+	// A number may not be directly followed by a name or another fraction
+	if l.pos < len(l.input) && (l.input[l.pos] == '.' || l.input[l.pos] == '_' || isLetter(l.input[l.pos])) {
+		return token{}, fmt.Errorf("invalid number at offset %d", start)
+	}
+	return token{kind, l.input[start:l.pos], start}, nil
+}
+
+func (l *lexer) scanString() (token, error) {
+	start := l.pos
+	if strings.HasPrefix(l.input[l.pos:], `"""`) {
+		return token{}, fmt.Errorf("block strings are not supported (offset %d)", start)
+	}
+	l.pos++
+	for l.pos < len(l.input) {
+		switch l.input[l.pos] {
+		case '"':
+			l.pos++
+			// GraphQL escape sequences are the same as JSON ones
+			var text string
+			if err := json.Unmarshal([]byte(l.input[start:l.pos]), &text); err != nil {
+				return token{}, fmt.Errorf("invalid string at offset %d: %v", start, err)
+			}
+			return token{tokString, text, start}, nil
+		case '\\':
+			l.pos += 2
+		case '\n', '\r':
+			return token{}, fmt.Errorf("unterminated string at offset %d", start)
+		default:
+			l.pos++
+		}
+	}
+	return token{}, fmt.Errorf("unterminated string at offset %d", start)
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+// parser is a recursive descent parser of GraphQL executable documents.
+type parser struct {
+	lexer *lexer
+	tok   token
+}
+
+// parse parses a GraphQL request document.
+func parse(query string) (doc *document, err error) {
+	p := &parser{lexer: &lexer{input: query}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc = &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.peek(tokPunct, "{"):
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: selections})
+
+		case p.peek(tokName, "query") || p.peek(tokName, "mutation") || p.peek(tokName, "subscription"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+
+		case p.peek(tokName, "fragment"):
+			frag, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := doc.fragments[frag.name]; exists {
+				return nil, fmt.Errorf("duplicate fragment %q", frag.name)
+			}
+			doc.fragments[frag.name] = frag
+
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if err := doc.validate(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// validate checks the rules of the specification about operations and fragments
+// which don't depend on the schema.
+func (doc *document) validate() error {
+	if len(doc.operations) == 0 {
+		return fmt.Errorf("no operation in document")
+	}
+	names := make(map[string]bool)
+	for _, op := range doc.operations {
+		if op.name == "" && len(doc.operations) > 1 {
+			return fmt.Errorf("anonymous operation must be the only operation in the document")
+		}
+		if names[op.name] {
+			return fmt.Errorf("duplicate operation %q", op.name)
+		}
+		names[op.name] = true
+	}
+	doc.measures = make(map[string]*measure)
+	for _, op := range doc.operations {
+		m, err := doc.measure(op.selections)
+		if err != nil {
+			return err
+		}
+		op.depth = m.depth
+	}
+	return nil
+}
+
+// measure is the size of a selection set with its fragment spreads inlined.
+type measure struct {
+	depth int // Maximum nesting of fields
+	size  int // Number of selections
+}
+
+// measure ensures the fragments spread within a selection set are defined and
+// don't spread themselves, directly or not, and returns the size of the set
+// with the fragments inlined. Sets larger than maxSelections are rejected.
+func (doc *document) measure(selections []selection) (measure, error) {
+	var total measure
+	for _, sel := range selections {
+		var (
+			m   measure
+			err error
+		)
+		switch sel := sel.(type) {
+		case *field:
+			m, err = doc.measure(sel.selections)
+			m.depth++
+		case *inlineFragment:
+			m, err = doc.measure(sel.selections)
+		case *fragmentSpread:
+			m, err = doc.measureFragment(sel.name)
+		}
+		if err != nil {
+			return measure{}, err
+		}
+		if m.depth > total.depth {
+			total.depth = m.depth
+		}
+		if total.size += 1 + m.size; total.size > maxSelections {
+			return measure{}, fmt.Errorf("too many selections with fragments inlined (limit %d)", maxSelections)
+		}
+	}
+	return total, nil
+}
+
+// measureFragment validates and measures the named fragment. The result is
+// cached, so that fragments spread many times are only walked once.
+func (doc *document) measureFragment(name string) (measure, error) {
+	if m, ok := doc.measures[name]; ok {
+		if m == nil {
+			return measure{}, fmt.Errorf("fragment %q spreads itself", name)
+		}
+		return *m, nil
+	}
+	frag, ok := doc.fragments[name]
+	if !ok {
+		return measure{}, fmt.Errorf("unknown fragment %q", name)
+	}
+	doc.measures[name] = nil
+	m, err := doc.measure(frag.selections)
+	if err != nil {
+		return measure{}, err
+	}
+	doc.measures[name] = &m
+	return m, nil
+}
+
+// advance moves to the next token.
+func (p *parser) advance() (err error) {
+	p.tok, err = p.lexer.next()
+	return err
+}
+
+// peek checks whether the current token is of the given kind and text.
+func (p *parser) peek(kind int, text string) bool {
+	return p.tok.kind == kind && p.tok.text == text
+}
+
+// skip consumes the current token if it's the given punctuator.
+func (p *parser) skip(punct string) (bool, error) {
+	if !p.peek(tokPunct, punct) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+// expect consumes the given punctuator, failing if it's something else.
+func (p *parser) expect(punct string) error {
+	if !p.peek(tokPunct, punct) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+// name consumes a name token, returning its text.
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokName {
+		return "", p.unexpected()
+	}
+	name := p.tok.text
+	return name, p.advance()
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokEOF {
+		return fmt.Errorf("unexpected end of document")
+	}
+	return fmt.Errorf("unexpected %q at offset %d", p.tok.text, p.tok.pos)
+}
+
+func (p *parser) parseOperation() (*operation, error) {
+	op := &operation{kind: p.tok.text}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokName {
+		op.name = p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if ok, err := p.skip("("); err != nil {
+		return nil, err
+	} else if ok {
+		for !p.peek(tokPunct, ")") {
+			def, err := p.parseVariableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = append(op.variables, def)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if op.selections, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (p *parser) parseVariableDefinition() (*variableDefinition, error) {
+	if err := p.expect("$"); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	typ, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	def := &variableDefinition{name: name, typ: typ}
+	if ok, err := p.skip("="); err != nil {
+		return nil, err
+	} else if ok {
+		if def.defValue, err = p.parseValue(true); err != nil {
+			return nil, err
+		}
+	}
+	return def, nil
+}
+
+func (p *parser) parseType() (typ string, err error) {
+	if ok, err := p.skip("["); err != nil {
+		return "", err
+	} else if ok {
+		elem, err := p.parseType()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect("]"); err != nil {
+			return "", err
+		}
+		typ = "[" + elem + "]"
+	} else if typ, err = p.name(); err != nil {
+		return "", err
+	}
+	if ok, err := p.skip("!"); err != nil {
+		return "", err
+	} else if ok {
+		typ += "!"
+	}
+	return typ, nil
+}
+
+func (p *parser) parseFragment() (*fragment, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, fmt.Errorf("invalid fragment name %q", name)
+	}
+	if !p.peek(tokName, "on") {
+		return nil, p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	frag := &fragment{name: name}
+	if frag.on, err = p.name(); err != nil {
+		return nil, err
+	}
+	if frag.selections, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return frag, nil
+}
+
+func (p *parser) parseSelectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []selection
+	for !p.peek(tokPunct, "}") {
+		sel, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("empty selection set at offset %d", p.tok.pos)
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) parseSelection() (selection, error) {
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		return p.parseFragmentSelection()
+	}
+	f := new(field)
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		f.alias = name
+		if name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	f.name = name
+	if f.arguments, err = p.parseArguments(); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if p.peek(tokPunct, "{") {
+		if f.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) parseFragmentSelection() (selection, error) {
+	if p.tok.kind == tokName && p.tok.text != "on" {
+		spread := &fragmentSpread{name: p.tok.text}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		var err error
+		if spread.directives, err = p.parseDirectives(); err != nil {
+			return nil, err
+		}
+		return spread, nil
+	}
+	inline := new(inlineFragment)
+	if p.peek(tokName, "on") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		var err error
+		if inline.on, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if inline.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if inline.selections, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return inline, nil
+}
+
+func (p *parser) parseArguments() ([]*argument, error) {
+	if ok, err := p.skip("("); err != nil || !ok {
+		return nil, err
+	}
+	var args []*argument
+	for !p.peek(tokPunct, ")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		val, err := p.parseValue(false)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, &argument{name: name, value: val})
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty argument list at offset %d", p.tok.pos)
+	}
+	return args, p.advance()
+}
+
+func (p *parser) parseDirectives() ([]*directive, error) {
+	var directives []*directive
+	for p.peek(tokPunct, "@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if name != "skip" && name != "include" {
+			return nil, fmt.Errorf("unknown directive @%s", name)
+		}
+		args, err := p.parseArguments()
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, &directive{name: name, arguments: args})
+	}
+	return directives, nil
+}
+
+// parseValue parses an input value literal. Constant values (defaults of
+// variables) may not reference variables.
+func (p *parser) parseValue(constant bool) (value, error) {
+	tok := p.tok
+	switch {
+	case tok.kind == tokPunct && tok.text == "$" && !constant:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		return variable(name), nil
+
+	case tok.kind == tokPunct && tok.text == "[":
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := []value{}
+		for !p.peek(tokPunct, "]") {
+			item, err := p.parseValue(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, p.advance()
+
+	case tok.kind == tokPunct && tok.text == "{":
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		object := make(map[string]value)
+		for !p.peek(tokPunct, "}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.parseValue(constant); err != nil {
+				return nil, err
+			}
+		}
+		return object, p.advance()
+
+	case tok.kind == tokInt || tok.kind == tokFloat:
+		return json.Number(tok.text), p.advance()
+
+	case tok.kind == tokString:
+		return tok.text, p.advance()
+
+	case tok.kind == tokName:
+		var val value
+		switch tok.text {
+		case "true":
+			val = true
+		case "false":
+			val = false
+		case "null":
+			val = nil
+		default:
+			val = enumValue(tok.text)
+		}
+		return val, p.advance()
+	}
+	return nil, p.unexpected()
+}
@@ -0,0 +1,205 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Tests the lexical grammar of the specification (section 2.1).
+func TestLexer(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []token // nil if lexing must fail
+	}{
+		// Ignored tokens: whitespace, line terminators, commas and comments
+		{"\t a ,,b\r\n# comment, { }\nc", []token{{tokName, "a", 2}, {tokName, "b", 6}, {tokName, "c", 24}}},
+		{"\ufeffa", nil}, // byte order marks are not supported
+		// Punctuators and names
+		{"!$():=@[]{}|...", []token{{tokPunct, "!", 0}, {tokPunct, "$", 1}, {tokPunct, "(", 2}, {tokPunct, ")", 3}, {tokPunct, ":", 4}, {tokPunct, "=", 5}, {tokPunct, "@", 6}, {tokPunct, "[", 7}, {tokPunct, "]", 8}, {tokPunct, "{", 9}, {tokPunct, "}", 10}, {tokPunct, "|", 11}, {tokPunct, "...", 12}}},
+		{"_a1 B_2", []token{{tokName, "_a1", 0}, {tokName, "B_2", 4}}},
+		{"..", nil},
+		{"é", nil},
+		// Int and float values
+		{"0 -0 12 -34", []token{{tokInt, "0", 0}, {tokInt, "-0", 2}, {tokInt, "12", 5}, {tokInt, "-34", 8}}},
+		{"1.5 -0.25 1e10 2E-3 3.0e+2", []token{{tokFloat, "1.5", 0}, {tokFloat, "-0.25", 4}, {tokFloat, "1e10", 10}, {tokFloat, "2E-3", 15}, {tokFloat, "3.0e+2", 20}}},
+		{"01", nil},
+		{"-01", nil},
+		{"1.", nil},
+		{".5", nil},
+		{"1e", nil},
+		{"1.2.3", nil},
+		{"12abc", nil},
+		{"-", nil},
+		// String values with escape sequences
+		{`"" "a b" "\"\\\/\b\f\n\r\t" "\u00e9"`, []token{{tokString, "", 0}, {tokString, "a b", 3}, {tokString, "\"\\/\b\f\n\r\t", 9}, {tokString, "é", 28}}},
+		{`"unterminated`, nil},
+		{"\"line\nbreak\"", nil},
+		{`"\x"`, nil},
+		{`"\u00"`, nil},
+		{`"""block"""`, nil},
+	}
+	for i, tt := range tests {
+		var (
+			lex    = &lexer{input: tt.input}
+			tokens []token
+			err    error
+		)
+		for {
+			var tok token
+			if tok, err = lex.next(); err != nil || tok.kind == tokEOF {
+				break
+			}
+			tokens = append(tokens, tok)
+		}
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("test %d: lexing %q succeeded: %v", i, tt.input, tokens)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: lexing %q failed: %v", i, tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(tokens, tt.want) {
+			t.Errorf("test %d: tokens mismatch for %q:\nhave %v\nwant %v", i, tt.input, tokens, tt.want)
+		}
+	}
+}
+
+// Tests the parsing of the executable definitions of the specification (section
+// 2.2 to 2.12), within the supported subset.
+func TestParse(t *testing.T) {
+	doc, err := parse(`
+		query Q($a: Int = 1, $b: [String!]!) {
+			alias: field(int: 1, float: 1.5, str: "s", yes: true, no: false, nil: null, enum: ASC, list: [1, $a], obj: {k: $b})
+			nested { ...Frag @include(if: $a) ... on T @skip(if: false) { inner } ... { any } }
+		}
+		fragment Frag on T { x }
+	`)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if len(doc.operations) != 1 || len(doc.fragments) != 1 {
+		t.Fatalf("definitions mismatch: %d operations, %d fragments", len(doc.operations), len(doc.fragments))
+	}
+	op := doc.operations[0]
+	if op.kind != "query" || op.name != "Q" {
+		t.Errorf("operation mismatch: %s %s", op.kind, op.name)
+	}
+	vars := []*variableDefinition{{name: "a", typ: "Int", defValue: json.Number("1")}, {name: "b", typ: "[String!]!"}}
+	if !reflect.DeepEqual(op.variables, vars) {
+		t.Errorf("variables mismatch: have %+v, want %+v", op.variables, vars)
+	}
+	f := op.selections[0].(*field)
+	args := []*argument{
+		{"int", json.Number("1")}, {"float", json.Number("1.5")}, {"str", "s"}, {"yes", true}, {"no", false}, {"nil", nil},
+		{"enum", enumValue("ASC")}, {"list", []value{json.Number("1"), variable("a")}}, {"obj", map[string]value{"k": variable("b")}},
+	}
+	if f.key() != "alias" || f.name != "field" || !reflect.DeepEqual(f.arguments, args) {
+		t.Errorf("field mismatch: %+v", f)
+	}
+	nested := op.selections[1].(*field).selections
+	if spread, ok := nested[0].(*fragmentSpread); !ok || spread.name != "Frag" || len(spread.directives) != 1 || spread.directives[0].name != "include" {
+		t.Errorf("fragment spread mismatch: %+v", nested[0])
+	}
+	if inline, ok := nested[1].(*inlineFragment); !ok || inline.on != "T" || len(inline.directives) != 1 || inline.directives[0].name != "skip" {
+		t.Errorf("typed inline fragment mismatch: %+v", nested[1])
+	}
+	if inline, ok := nested[2].(*inlineFragment); !ok || inline.on != "" {
+		t.Errorf("untyped inline fragment mismatch: %+v", nested[2])
+	}
+	if frag := doc.fragments["Frag"]; frag.on != "T" || len(frag.selections) != 1 {
+		t.Errorf("fragment mismatch: %+v", frag)
+	}
+}
+
+// Tests that invalid documents, or ones outside the supported subset, are
+// rejected.
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{``, "no operation in document"},
+		{`fragment F on T { a }`, "no operation in document"},
+		{`{}`, "empty selection set"},
+		{`{ a() }`, "empty argument list"},
+		{`{ a(x: $v) }`, ""}, // variables are resolved at execution
+		{`query ($v: Int = $w) { a }`, `unexpected "$"`},
+		{`query Q @skip(if: true) { a }`, `unexpected "@"`},
+		{`{ a @deprecated }`, "unknown directive @deprecated"},
+		{`{ a } fragment F on T @include(if: true) { b }`, `unexpected "@"`},
+		{`{ a } fragment on on T { b }`, `invalid fragment name "on"`},
+		{`{ a } { b }`, "anonymous operation must be the only operation"},
+		{`{ a } query Q { b }`, "anonymous operation must be the only operation"},
+		{`query Q { a } query Q { b }`, `duplicate operation "Q"`},
+		{`{ ...F } fragment F on T { a } fragment F on T { b }`, `duplicate fragment "F"`},
+		{`{ ...F }`, `unknown fragment "F"`},
+		{`{ a { ...F } } fragment F on T { b { ...G } } fragment G on T { ...F }`, `fragment "F" spreads itself`},
+		{`{ ...F ...F } fragment F on T { a }`, ""}, // repeated spreads are fine
+		{`{ a(x: """b""") }`, "block strings are not supported"},
+		{`{ a } }`, `unexpected "}"`},
+	}
+	for i, tt := range tests {
+		_, err := parse(tt.query)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("test %d: parsing %q failed: %v", i, tt.query, err)
+		case tt.err != "" && err == nil:
+			t.Errorf("test %d: parsing %q succeeded, want error %q", i, tt.query, tt.err)
+		case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+			t.Errorf("test %d: error mismatch for %q: have %q, want %q", i, tt.query, err, tt.err)
+		}
+	}
+}
+
+// Tests that documents spreading fragments many times are validated without
+// expanding them and rejected if their expansion is too large.
+func TestParseFragmentExpansion(t *testing.T) {
+	var query bytes.Buffer
+	query.WriteString(`{ ...F0 }`)
+	for i := 0; i < 25; i++ {
+		fmt.Fprintf(&query, ` fragment F%d on Query { ...F%d ...F%d }`, i, i+1, i+1)
+	}
+	query.WriteString(` fragment F25 on Query { a }`)
+
+	start := time.Now()
+	if _, err := parse(query.String()); err == nil || !strings.Contains(err.Error(), "too many selections") {
+		t.Errorf("error mismatch: have %v, want too many selections", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("validation took %v", elapsed)
+	}
+	// The same fragments fit the budget if only spread once each
+	query.Reset()
+	query.WriteString(`{ ...F0 }`)
+	for i := 0; i < 25; i++ {
+		fmt.Fprintf(&query, ` fragment F%d on Query { ...F%d b%d: a }`, i, i+1, i)
+	}
+	query.WriteString(` fragment F25 on Query { a }`)
+	if _, err := parse(query.String()); err != nil {
+		t.Errorf("failed to parse linear fragment chain: %v", err)
+	}
+}
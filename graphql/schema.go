@@ -0,0 +1,797 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/core/vm"
+	"github.com/ethereumproject/go-ethereum/eth/filters"
+)
+
+// maxBlockRange is the maximum number of blocks a blocks or logs query may span.
+const maxBlockRange = 1024
+
+// schema describes the types served by the endpoint, in the GraphQL schema
+// definition language. It's served to clients asking for it, resolution itself
+// is done by the resolvers below.
+const schema = `# Bytes32 is a 32 byte binary string, represented as 0x-prefixed hexadecimal.
+scalar Bytes32
+# Address is a 20 byte account address, represented as 0x-prefixed hexadecimal.
+scalar Address
+# Bytes is an arbitrary length binary string, represented as 0x-prefixed hexadecimal.
+scalar Bytes
+# BigInt is a large integer, represented as 0x-prefixed hexadecimal.
+scalar BigInt
+# Long is a 64 bit unsigned integer.
+scalar Long
+
+schema {
+    query: Query
+}
+
+# Account is an account at a particular block (or the pending state).
+type Account {
+    address: Address!
+    balance: BigInt!
+    transactionCount: Long!
+    code: Bytes!
+    storage(slot: Bytes32!): Bytes32!
+}
+
+# Log is an Ethereum event log.
+type Log {
+    index: Int!
+    # Account that emitted the log, at the block of the log.
+    account: Account!
+    topics: [Bytes32!]!
+    data: Bytes!
+    transaction: Transaction!
+}
+
+# Transaction is an Ethereum transaction. Receipt fields are null while the
+# transaction is pending.
+type Transaction {
+    hash: Bytes32!
+    nonce: Long!
+    index: Int
+    # Sender and recipient are resolved at the block of the transaction, or the
+    # pending state if not yet mined.
+    from: Account!
+    to: Account
+    value: BigInt!
+    gasPrice: BigInt!
+    gas: Long!
+    inputData: Bytes!
+    block: Block
+    gasUsed: Long
+    cumulativeGasUsed: Long
+    createdContract: Account
+    logs: [Log!]
+    r: BigInt!
+    s: BigInt!
+    v: BigInt!
+}
+
+# BlockFilterCriteria filters the logs of a single block.
+input BlockFilterCriteria {
+    addresses: [Address!]
+    # Topics by position, each an alternative of values; empty matches anything.
+    topics: [[Bytes32!]!]
+}
+
+# Block is an Ethereum block. Accounts reached through a block are resolved
+# against the state after it.
+type Block {
+    number: Long!
+    hash: Bytes32!
+    parent: Block
+    nonce: Bytes!
+    transactionsRoot: Bytes32!
+    stateRoot: Bytes32!
+    receiptsRoot: Bytes32!
+    miner: Account!
+    extraData: Bytes!
+    gasLimit: Long!
+    gasUsed: Long!
+    timestamp: Long!
+    logsBloom: Bytes!
+    mixHash: Bytes32!
+    difficulty: BigInt!
+    totalDifficulty: BigInt!
+    ommerCount: Int!
+    ommers: [Block!]!
+    ommerHash: Bytes32!
+    transactionCount: Int!
+    transactions: [Transaction!]!
+    transactionAt(index: Int!): Transaction
+    logs(filter: BlockFilterCriteria!): [Log!]!
+    account(address: Address!): Account!
+}
+
+# FilterCriteria filters the logs of a range of canonical blocks.
+input FilterCriteria {
+    # Defaults to the current head block.
+    fromBlock: Long
+    # Defaults to the current head block.
+    toBlock: Long
+    addresses: [Address!]
+    topics: [[Bytes32!]!]
+}
+
+# Pending is the state of the transaction pool.
+type Pending {
+    transactionCount: Int!
+    transactions: [Transaction!]!
+    account(address: Address!): Account!
+}
+
+type Query {
+    # Block by number or hash, the current head if neither is given.
+    block(number: Long, hash: Bytes32): Block
+    # Canonical blocks in the inclusive range, up to the head.
+    blocks(from: Long!, to: Long): [Block!]!
+    pending: Pending!
+    transaction(hash: Bytes32!): Transaction
+    logs(filter: FilterCriteria!): [Log!]!
+    # Account at the given block number, the current head if not given.
+    account(address: Address!, blockNumber: Long): Account!
+}
+`
+
+// query is the root object of the schema.
+type query struct {
+	backend Backend
+}
+
+func (q *query) typeName() string { return "Query" }
+
+func (q *query) resolve(ctx context.Context, field string, args arguments) (interface{}, error) {
+	chain := q.backend.BlockChain()
+
+	switch field {
+	case "block":
+		number, byNumber, err := args.long("number")
+		if err != nil {
+			return nil, err
+		}
+		hash, byHash, err := args.hash("hash")
+		if err != nil {
+			return nil, err
+		}
+		var block *types.Block
+		switch {
+		case byNumber && byHash:
+			return nil, errors.New("only one of number or hash may be given")
+		case byNumber:
+			block = chain.GetBlockByNumber(number)
+		case byHash:
+			block = chain.GetBlock(hash)
+		default:
+			block = chain.CurrentBlock()
+		}
+		if block == nil {
+			return nil, nil
+		}
+		return &blockResolver{q.backend, block}, nil
+
+	case "blocks":
+		from, ok, err := args.long("from")
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, errors.New("missing argument 'from'")
+		}
+		head := chain.CurrentBlock().NumberU64()
+		to, ok, err := args.long("to")
+		if err != nil {
+			return nil, err
+		} else if !ok || to > head {
+			to = head
+		}
+		if err := checkRange(from, to); err != nil {
+			return nil, err
+		}
+		blocks := []object{}
+		for number := from; number <= to; number++ {
+			block := chain.GetBlockByNumber(number)
+			if block == nil {
+				break
+			}
+			blocks = append(blocks, &blockResolver{q.backend, block})
+		}
+		return blocks, nil
+
+	case "pending":
+		return &pendingResolver{q.backend}, nil
+
+	case "transaction":
+		hash, ok, err := args.hash("hash")
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, errors.New("missing argument 'hash'")
+		}
+		if tx, blockHash, _, index := core.GetTransaction(q.backend.ChainDb(), hash); tx != nil {
+			if block := chain.GetBlock(blockHash); block != nil {
+				return &transactionResolver{q.backend, tx, block, index}, nil
+			}
+		}
+		if tx := q.backend.TxPool().GetTransaction(hash); tx != nil {
+			return &transactionResolver{backend: q.backend, tx: tx}, nil
+		}
+		return nil, nil
+
+	case "logs":
+		criteria, ok := args["filter"].(map[string]interface{})
+		if !ok {
+			return nil, errors.New("missing argument 'filter'")
+		}
+		head := chain.CurrentBlock().NumberU64()
+		from, to := head, head
+		if number, ok, err := arguments(criteria).long("fromBlock"); err != nil {
+			return nil, err
+		} else if ok {
+			from = number
+		}
+		if number, ok, err := arguments(criteria).long("toBlock"); err != nil {
+			return nil, err
+		} else if ok && number < head {
+			to = number
+		}
+		if err := checkRange(from, to); err != nil {
+			return nil, err
+		}
+		filter := filters.New(q.backend.ChainDb())
+		filter.SetBeginBlock(int64(from))
+		filter.SetEndBlock(int64(to))
+		if err := setFilterCriteria(filter, criteria); err != nil {
+			return nil, err
+		}
+		logs, err := filter.Find(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return q.logs(logs), nil
+
+	case "account":
+		address, ok, err := args.address("address")
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, errors.New("missing argument 'address'")
+		}
+		block := chain.CurrentBlock()
+		if number, ok, err := args.long("blockNumber"); err != nil {
+			return nil, err
+		} else if ok {
+			if block = chain.GetBlockByNumber(number); block == nil {
+				return nil, fmt.Errorf("block #%d not found", number)
+			}
+		}
+		return (&blockResolver{q.backend, block}).account(address), nil
+	}
+	return nil, errUnknownField
+}
+
+// logs wraps logs of canonical blocks into resolvers.
+func (q *query) logs(logs vm.Logs) []object {
+	result := make([]object, 0, len(logs))
+	for _, log := range logs {
+		result = append(result, &logResolver{q.backend, log})
+	}
+	return result
+}
+
+// checkRange validates an inclusive range of blocks to query.
+func checkRange(from, to uint64) error {
+	if to < from {
+		return fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+	if to-from+1 > maxBlockRange {
+		return fmt.Errorf("block range too large (%d blocks, limit %d)", to-from+1, maxBlockRange)
+	}
+	return nil
+}
+
+// setFilterCriteria configures the address and topic criteria of a log filter.
+func setFilterCriteria(filter *filters.Filter, criteria map[string]interface{}) error {
+	if list, ok := criteria["addresses"].([]interface{}); ok {
+		addresses := make([]common.Address, len(list))
+		for i, item := range list {
+			address, err := parseAddress(item)
+			if err != nil {
+				return err
+			}
+			addresses[i] = address
+		}
+		filter.SetAddresses(addresses)
+	}
+	if list, ok := criteria["topics"].([]interface{}); ok {
+		topics := make([][]common.Hash, len(list))
+		for i, item := range list {
+			alternatives, ok := item.([]interface{})
+			if !ok {
+				return errors.New("topics must be a list of lists")
+			}
+			for _, alternative := range alternatives {
+				hash, err := parseHash(alternative)
+				if err != nil {
+					return err
+				}
+				topics[i] = append(topics[i], hash)
+			}
+		}
+		filter.SetTopics(topics)
+	}
+	return nil
+}
+
+// blockResolver resolves the fields of a block.
+type blockResolver struct {
+	backend Backend
+	block   *types.Block
+}
+
+func (b *blockResolver) typeName() string { return "Block" }
+
+func (b *blockResolver) resolve(ctx context.Context, field string, args arguments) (interface{}, error) {
+	block := b.block
+
+	switch field {
+	case "number":
+		return block.NumberU64(), nil
+	case "hash":
+		return block.Hash().Hex(), nil
+	case "parent":
+		if block.NumberU64() == 0 {
+			return nil, nil
+		}
+		if parent := b.backend.BlockChain().GetBlock(block.ParentHash()); parent != nil {
+			return &blockResolver{b.backend, parent}, nil
+		}
+		return nil, nil
+	case "nonce":
+		nonce := block.Header().Nonce
+		return hexBytes(nonce[:]), nil
+	case "transactionsRoot":
+		return block.TxHash().Hex(), nil
+	case "stateRoot":
+		return block.Root().Hex(), nil
+	case "receiptsRoot":
+		return block.ReceiptHash().Hex(), nil
+	case "miner":
+		return b.account(block.Coinbase()), nil
+	case "extraData":
+		return hexBytes(block.Extra()), nil
+	case "gasLimit":
+		return block.GasLimit().Uint64(), nil
+	case "gasUsed":
+		return block.GasUsed().Uint64(), nil
+	case "timestamp":
+		return block.Time().Uint64(), nil
+	case "logsBloom":
+		bloom := block.Bloom()
+		return hexBytes(bloom[:]), nil
+	case "mixHash":
+		return block.MixDigest().Hex(), nil
+	case "difficulty":
+		return hexBig(block.Difficulty()), nil
+	case "totalDifficulty":
+		td := b.backend.BlockChain().GetTd(block.Hash())
+		if td == nil {
+			return nil, fmt.Errorf("total difficulty of block %x not found", block.Hash())
+		}
+		return hexBig(td), nil
+	case "ommerCount":
+		return len(block.Uncles()), nil
+	case "ommers":
+		ommers := make([]object, 0, len(block.Uncles()))
+		for _, header := range block.Uncles() {
+			ommers = append(ommers, &blockResolver{b.backend, types.NewBlockWithHeader(header)})
+		}
+		return ommers, nil
+	case "ommerHash":
+		return block.UncleHash().Hex(), nil
+	case "transactionCount":
+		return len(block.Transactions()), nil
+	case "transactions":
+		txs := make([]object, 0, len(block.Transactions()))
+		for i, tx := range block.Transactions() {
+			txs = append(txs, &transactionResolver{b.backend, tx, block, uint64(i)})
+		}
+		return txs, nil
+	case "transactionAt":
+		index, ok, err := args.long("index")
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, errors.New("missing argument 'index'")
+		}
+		if index >= uint64(len(block.Transactions())) {
+			return nil, nil
+		}
+		return &transactionResolver{b.backend, block.Transactions()[index], block, index}, nil
+	case "logs":
+		criteria, ok := args["filter"].(map[string]interface{})
+		if !ok {
+			return nil, errors.New("missing argument 'filter'")
+		}
+		filter := filters.New(b.backend.ChainDb())
+		if err := setFilterCriteria(filter, criteria); err != nil {
+			return nil, err
+		}
+		var logs vm.Logs
+		for _, receipt := range core.GetBlockReceipts(b.backend.ChainDb(), block.Hash()) {
+			logs = append(logs, receipt.Logs...)
+		}
+		result := []object{}
+		for _, log := range filter.FilterLogs(logs) {
+			result = append(result, &logResolver{b.backend, log})
+		}
+		return result, nil
+	case "account":
+		address, ok, err := args.address("address")
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, errors.New("missing argument 'address'")
+		}
+		return b.account(address), nil
+	}
+	return nil, errUnknownField
+}
+
+// account creates the resolver of an account in the state after the block.
+func (b *blockResolver) account(address common.Address) *accountResolver {
+	root := b.block.Root()
+	return &accountResolver{address: address, state: func() (accountState, error) {
+		return b.backend.BlockChain().StateAt(root)
+	}}
+}
+
+// accountState is the subset of the state database accounts are resolved with,
+// implemented by both the state of a block and the pending state.
+type accountState interface {
+	GetBalance(common.Address) *big.Int
+	GetNonce(common.Address) uint64
+	GetCode(common.Address) []byte
+	GetState(common.Address, common.Hash) common.Hash
+}
+
+// accountResolver resolves the fields of an account. The state is only opened
+// once a field other than the address is queried.
+type accountResolver struct {
+	address common.Address
+	state   func() (accountState, error)
+}
+
+func (a *accountResolver) typeName() string { return "Account" }
+
+func (a *accountResolver) resolve(ctx context.Context, field string, args arguments) (interface{}, error) {
+	switch field {
+	case "address":
+		return a.address.Hex(), nil
+	case "balance", "transactionCount", "code", "storage":
+	default:
+		return nil, errUnknownField
+	}
+	state, err := a.state()
+	if err != nil {
+		return nil, err
+	}
+	switch field {
+	case "balance":
+		return hexBig(state.GetBalance(a.address)), nil
+	case "transactionCount":
+		return state.GetNonce(a.address), nil
+	case "code":
+		return hexBytes(state.GetCode(a.address)), nil
+	default:
+		slot, ok, err := args.hash("slot")
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, errors.New("missing argument 'slot'")
+		}
+		return state.GetState(a.address, slot).Hex(), nil
+	}
+}
+
+// transactionResolver resolves the fields of a transaction. The block is nil
+// for pending transactions.
+type transactionResolver struct {
+	backend Backend
+	tx      *types.Transaction
+	block   *types.Block
+	index   uint64
+}
+
+func (t *transactionResolver) typeName() string { return "Transaction" }
+
+func (t *transactionResolver) resolve(ctx context.Context, field string, args arguments) (interface{}, error) {
+	tx := t.tx
+
+	switch field {
+	case "hash":
+		return tx.Hash().Hex(), nil
+	case "nonce":
+		return tx.Nonce(), nil
+	case "index":
+		if t.block == nil {
+			return nil, nil
+		}
+		return t.index, nil
+	case "from":
+		var signer types.Signer = types.BasicSigner{}
+		if tx.Protected() {
+			signer = types.NewChainIdSigner(tx.ChainId())
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return nil, err
+		}
+		return t.account(from), nil
+	case "to":
+		if tx.To() == nil {
+			return nil, nil
+		}
+		return t.account(*tx.To()), nil
+	case "value":
+		return hexBig(tx.Value()), nil
+	case "gasPrice":
+		return hexBig(tx.GasPrice()), nil
+	case "gas":
+		return tx.Gas().Uint64(), nil
+	case "inputData":
+		return hexBytes(tx.Data()), nil
+	case "block":
+		if t.block == nil {
+			return nil, nil
+		}
+		return &blockResolver{t.backend, t.block}, nil
+	case "r":
+		_, r, _ := tx.RawSignatureValues()
+		return hexBig(r), nil
+	case "s":
+		_, _, s := tx.RawSignatureValues()
+		return hexBig(s), nil
+	case "v":
+		v, _, _ := tx.RawSignatureValues()
+		return hexBig(v), nil
+	case "gasUsed", "cumulativeGasUsed", "createdContract", "logs":
+	default:
+		return nil, errUnknownField
+	}
+	// All the remaining fields are derived from the receipt
+	if t.block == nil {
+		return nil, nil
+	}
+	receipt := core.GetReceipt(t.backend.ChainDb(), tx.Hash())
+	if receipt == nil {
+		return nil, fmt.Errorf("receipt of transaction %x not found", tx.Hash())
+	}
+	switch field {
+	case "gasUsed":
+		return receipt.GasUsed.Uint64(), nil
+	case "cumulativeGasUsed":
+		return receipt.CumulativeGasUsed.Uint64(), nil
+	case "createdContract":
+		if tx.To() != nil {
+			return nil, nil
+		}
+		return t.account(receipt.ContractAddress), nil
+	default:
+		logs := make([]object, 0, len(receipt.Logs))
+		for _, log := range receipt.Logs {
+			logs = append(logs, &logResolver{t.backend, log})
+		}
+		return logs, nil
+	}
+}
+
+// account creates the resolver of an account in the state after the block of
+// the transaction, or the pending state.
+func (t *transactionResolver) account(address common.Address) *accountResolver {
+	if t.block == nil {
+		return (&pendingResolver{t.backend}).account(address)
+	}
+	return (&blockResolver{t.backend, t.block}).account(address)
+}
+
+// logResolver resolves the fields of a log.
+type logResolver struct {
+	backend Backend
+	log     *vm.Log
+}
+
+func (l *logResolver) typeName() string { return "Log" }
+
+func (l *logResolver) resolve(ctx context.Context, field string, args arguments) (interface{}, error) {
+	switch field {
+	case "index":
+		return l.log.Index, nil
+	case "account", "transaction":
+		block := l.backend.BlockChain().GetBlock(l.log.BlockHash)
+		if block == nil {
+			return nil, fmt.Errorf("block %x of log not found", l.log.BlockHash)
+		}
+		if field == "account" {
+			return (&blockResolver{l.backend, block}).account(l.log.Address), nil
+		}
+		if int(l.log.TxIndex) >= len(block.Transactions()) {
+			return nil, fmt.Errorf("transaction %x of log not found", l.log.TxHash)
+		}
+		return &transactionResolver{l.backend, block.Transactions()[l.log.TxIndex], block, uint64(l.log.TxIndex)}, nil
+	case "topics":
+		topics := make([]string, len(l.log.Topics))
+		for i, topic := range l.log.Topics {
+			topics[i] = topic.Hex()
+		}
+		return topics, nil
+	case "data":
+		return hexBytes(l.log.Data), nil
+	}
+	return nil, errUnknownField
+}
+
+// pendingResolver resolves the fields of the transaction pool.
+type pendingResolver struct {
+	backend Backend
+}
+
+func (p *pendingResolver) typeName() string { return "Pending" }
+
+func (p *pendingResolver) resolve(ctx context.Context, field string, args arguments) (interface{}, error) {
+	switch field {
+	case "transactionCount":
+		return len(p.backend.TxPool().GetTransactions()), nil
+	case "transactions":
+		pending := p.backend.TxPool().GetTransactions()
+		txs := make([]object, 0, len(pending))
+		for _, tx := range pending {
+			txs = append(txs, &transactionResolver{backend: p.backend, tx: tx})
+		}
+		return txs, nil
+	case "account":
+		address, ok, err := args.address("address")
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, errors.New("missing argument 'address'")
+		}
+		return p.account(address), nil
+	}
+	return nil, errUnknownField
+}
+
+// account creates the resolver of an account in the pending state.
+func (p *pendingResolver) account(address common.Address) *accountResolver {
+	return &accountResolver{address: address, state: func() (accountState, error) {
+		state := p.backend.TxPool().State()
+		if state == nil {
+			return nil, errors.New("pending state not available")
+		}
+		return state, nil
+	}}
+}
+
+// long retrieves an optional Long argument.
+func (args arguments) long(name string) (uint64, bool, error) {
+	val, ok := args[name]
+	if !ok || val == nil {
+		return 0, false, nil
+	}
+	var (
+		number uint64
+		err    error
+	)
+	switch val := val.(type) {
+	case json.Number:
+		number, err = strconv.ParseUint(string(val), 10, 64)
+	case string:
+		if strings.HasPrefix(val, "0x") {
+			number, err = strconv.ParseUint(val[2:], 16, 64)
+		} else {
+			number, err = strconv.ParseUint(val, 10, 64)
+		}
+	default:
+		err = errors.New("not a number")
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid argument '%s': %v", name, err)
+	}
+	return number, true, nil
+}
+
+// hash retrieves an optional Bytes32 argument.
+func (args arguments) hash(name string) (common.Hash, bool, error) {
+	val, ok := args[name]
+	if !ok || val == nil {
+		return common.Hash{}, false, nil
+	}
+	hash, err := parseHash(val)
+	if err != nil {
+		return common.Hash{}, false, fmt.Errorf("invalid argument '%s': %v", name, err)
+	}
+	return hash, true, nil
+}
+
+// address retrieves an optional Address argument.
+func (args arguments) address(name string) (common.Address, bool, error) {
+	val, ok := args[name]
+	if !ok || val == nil {
+		return common.Address{}, false, nil
+	}
+	address, err := parseAddress(val)
+	if err != nil {
+		return common.Address{}, false, fmt.Errorf("invalid argument '%s': %v", name, err)
+	}
+	return address, true, nil
+}
+
+// parseHex decodes a 0x-prefixed hex string of the given byte length.
+func parseHex(val interface{}, size int) ([]byte, error) {
+	str, ok := val.(string)
+	if !ok || !strings.HasPrefix(str, "0x") {
+		return nil, fmt.Errorf("%v is not a 0x-prefixed hex string", val)
+	}
+	blob, err := hex.DecodeString(str[2:])
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) != size {
+		return nil, fmt.Errorf("%s is %d bytes long, want %d", str, len(blob), size)
+	}
+	return blob, nil
+}
+
+func parseHash(val interface{}) (common.Hash, error) {
+	blob, err := parseHex(val, common.HashLength)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(blob), nil
+}
+
+func parseAddress(val interface{}) (common.Address, error) {
+	blob, err := parseHex(val, common.AddressLength)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(blob), nil
+}
+
+// hexBytes encodes a Bytes value.
+func hexBytes(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+// hexBig encodes a BigInt value.
+func hexBig(n *big.Int) string {
+	return fmt.Sprintf("0x%x", n)
+}
@@ -0,0 +1,162 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package graphql serves the blockchain data of a node over GraphQL, letting
+// clients fetch nested data (e.g. the transactions of a block along with their
+// receipts and logs) in a single round trip.
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/ethereumproject/go-ethereum/core"
+	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/logger"
+	"github.com/ethereumproject/go-ethereum/logger/glog"
+	"github.com/ethereumproject/go-ethereum/p2p"
+	"github.com/ethereumproject/go-ethereum/rpc"
+)
+
+// Path is the URL path the endpoint is served on.
+const Path = "/graphql"
+
+// maxRequestSize is the maximum size of a request body.
+const maxRequestSize = 1024 * 128
+
+// Backend is the chain data provider the queries are resolved against.
+type Backend interface {
+	BlockChain() *core.BlockChain
+	ChainDb() ethdb.Database
+	TxPool() *core.TxPool
+}
+
+// Service is a node service serving GraphQL queries on the node's HTTP
+// endpoint. It has no protocols or APIs of its own.
+type Service struct {
+	backend Backend
+}
+
+// New creates a GraphQL service resolving queries against the given backend.
+func New(backend Backend) *Service {
+	return &Service{backend: backend}
+}
+
+// Protocols implements node.Service, returning no protocols.
+func (s *Service) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service, returning no RPC APIs.
+func (s *Service) APIs() []rpc.API { return nil }
+
+// Start implements node.Service.
+func (s *Service) Start(server *p2p.Server) error {
+	glog.V(logger.Info).Infof("GraphQL endpoint enabled on %s", Path)
+	return nil
+}
+
+// Stop implements node.Service.
+func (s *Service) Stop() error { return nil }
+
+// HTTPHandlers implements node.HTTPHandlerProvider, serving queries on Path.
+func (s *Service) HTTPHandlers() map[string]http.Handler {
+	return map[string]http.Handler{Path: s}
+}
+
+// request is a GraphQL request, as sent in the body of POST requests or the
+// parameters of GET requests.
+type request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// ServeHTTP implements http.Handler. Queries are accepted as JSON encoded POST
+// requests, raw application/graphql POST requests or GET requests with query
+// parameters. A GET request without a query returns the schema.
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := parseRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req == nil {
+		w.Header().Set("content-type", "text/plain; charset=utf-8")
+		io.WriteString(w, schema)
+		return
+	}
+	res := execute(r.Context(), &query{s.backend}, req.Query, req.Variables, req.OperationName)
+
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		glog.V(logger.Debug).Infof("Failed to write GraphQL response: %v", err)
+	}
+}
+
+// parseRequest extracts the GraphQL request from an HTTP request, returning nil
+// if it's a GET request for the schema.
+func parseRequest(r *http.Request) (*request, error) {
+	switch r.Method {
+	case "GET":
+		params := r.URL.Query()
+		if params.Get("query") == "" {
+			return nil, nil
+		}
+		req := &request{Query: params.Get("query"), OperationName: params.Get("operationName")}
+		if vars := params.Get("variables"); vars != "" {
+			if err := decodeVariables([]byte(vars), &req.Variables); err != nil {
+				return nil, err
+			}
+		}
+		return req, nil
+
+	case "POST":
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > maxRequestSize {
+			return nil, fmt.Errorf("request too large (limit %d bytes)", maxRequestSize)
+		}
+		if strings.HasPrefix(r.Header.Get("content-type"), "application/graphql") {
+			return &request{Query: string(body)}, nil
+		}
+		req := new(request)
+		if err := decodeVariables(body, req); err != nil {
+			return nil, err
+		}
+		if req.Query == "" {
+			return nil, fmt.Errorf("missing query")
+		}
+		return req, nil
+	}
+	return nil, fmt.Errorf("method %s not allowed", r.Method)
+}
+
+// decodeVariables decodes JSON keeping numbers in their textual form, so large
+// integers don't lose precision as floats.
+func decodeVariables(blob []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(blob))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request: %v", err)
+	}
+	return nil
+}
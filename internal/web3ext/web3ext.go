@@ -39,6 +39,17 @@ web3._extend({
 			call: 'admin_addPeer',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'addBan',
+			call: 'admin_addBan',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'removeBan',
+			call: 'admin_removeBan',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -141,6 +152,10 @@ web3._extend({
 			name: 'peers',
 			getter: 'admin_peers'
 		}),
+		new web3._extend.Property({
+			name: 'bans',
+			getter: 'admin_bans'
+		}),
 		new web3._extend.Property({
 			name: 'datadir',
 			getter: 'admin_datadir'
@@ -329,6 +344,12 @@ web3._extend({
 			params: 1,
 			inputFormatter: [web3._extend.formatters.inputTransactionFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'signTypedData',
+			call: 'eth_signTypedData',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
 		new web3._extend.Method({
 			name: 'signTransaction',
 			call: 'eth_signTransaction',
@@ -345,6 +366,12 @@ web3._extend({
 			name: 'chainId',
 			call: 'eth_chainId',
 			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getProof',
+			call: 'eth_getProof',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
 		})
 	],
 	properties:
@@ -447,6 +474,77 @@ web3._extend({
 			call: 'personal_signAndSendTransaction',
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputTransactionFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'importMnemonic',
+			call: 'personal_importMnemonic',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'deriveAccount',
+			call: 'personal_deriveAccount',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'selfDeriveAccounts',
+			call: 'personal_selfDeriveAccounts',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'listWallets',
+			call: 'personal_listWallets',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'openWallet',
+			call: 'personal_openWallet',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'deriveWalletAccount',
+			call: 'personal_deriveWalletAccount',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'sign',
+			call: 'personal_sign',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'ecRecover',
+			call: 'personal_ecRecover',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'submitMultisigTransaction',
+			call: 'personal_submitMultisigTransaction',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputTransactionFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'confirmMultisigTransaction',
+			call: 'personal_confirmMultisigTransaction',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputAddressFormatter, web3._extend.utils.fromDecimal, null]
+		}),
+		new web3._extend.Method({
+			name: 'revokeMultisigConfirmation',
+			call: 'personal_revokeMultisigConfirmation',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputAddressFormatter, web3._extend.utils.fromDecimal, null]
+		}),
+		new web3._extend.Method({
+			name: 'executeMultisigTransaction',
+			call: 'personal_executeMultisigTransaction',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputAddressFormatter, web3._extend.utils.fromDecimal, null]
+		}),
+		new web3._extend.Method({
+			name: 'pendingMultisigTransactions',
+			call: 'personal_pendingMultisigTransactions',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter]
 		})
 	]
 });
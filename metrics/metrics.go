@@ -20,6 +20,7 @@ package metrics
 import (
 	"bufio"
 	"encoding/json"
+	"fmt"
 	"os"
 	"runtime"
 	"time"
@@ -117,8 +118,28 @@ var (
 	P2PInBytes  = metrics.NewRegisteredMeter("p2p/in/bytes", reg)
 	P2POut      = metrics.NewRegisteredMeter("p2p/out", reg)
 	P2POutBytes = metrics.NewRegisteredMeter("p2p/out/bytes", reg)
+
+	P2PInThrottle = metrics.NewRegisteredTimer("p2p/in/throttle", reg)
 )
 
+// Meter is the subset of the meter interface needed for metering on demand.
+type Meter interface {
+	Count() int64
+	Mark(int64)
+	Rate1() float64
+}
+
+// P2PMsgMeters retrieves the message count and byte meters of a sub-protocol
+// message code in the given direction, registering them on first use.
+func P2PMsgMeters(proto string, version uint, code uint64, ingress bool) (msgs, size Meter) {
+	dir := "out"
+	if ingress {
+		dir = "in"
+	}
+	name := fmt.Sprintf("p2p/%s/%d/%d/%s", proto, version, code, dir)
+	return metrics.GetOrRegisterMeter(name, reg), metrics.GetOrRegisterMeter(name+"/bytes", reg)
+}
+
 var (
 	MemAllocs = metrics.GetOrRegisterGauge("memory/allocs", reg)
 	MemFrees  = metrics.GetOrRegisterGauge("memory/frees", reg)
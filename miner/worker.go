@@ -306,19 +306,17 @@ func (self *worker) wait() {
 					core.WriteMipmapBloom(self.chainDb, block.NumberU64(), work.receipts)
 				}
 
-				// broadcast before waiting for validation
-				go func(block *types.Block, logs vm.Logs, receipts []*types.Receipt) {
-					self.mux.Post(core.NewMinedBlockEvent{Block: block})
-					self.mux.Post(core.ChainEvent{Block: block, Hash: block.Hash(), Logs: logs})
-
-					if stat == core.CanonStatTy {
-						self.mux.Post(core.ChainHeadEvent{Block: block})
-						self.mux.Post(logs)
-					}
-					if err := core.WriteBlockReceipts(self.chainDb, block.Hash(), receipts); err != nil {
-						glog.V(logger.Warn).Infoln("error writing block receipts:", err)
-					}
-				}(block, work.state.Logs(), work.receipts)
+				if err := core.WriteBlockReceipts(self.chainDb, block.Hash(), work.receipts); err != nil {
+					glog.V(logger.Warn).Infoln("error writing block receipts:", err)
+				}
+				// broadcast before waiting for validation, queued behind the
+				// events of any reorg the block caused
+				logs := work.state.Logs()
+				if stat == core.CanonStatTy {
+					self.chain.PostChainEvents(core.NewMinedBlockEvent{Block: block}, logs, core.ChainEvent{Block: block, Hash: block.Hash(), Logs: logs}, core.ChainHeadEvent{Block: block})
+				} else {
+					self.chain.PostChainEvents(core.NewMinedBlockEvent{Block: block}, core.ChainSideEvent{Block: block, Logs: logs})
+				}
 			}
 
 			// check staleness and display confirmation
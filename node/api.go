@@ -19,6 +19,7 @@ package node
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/ethereumproject/go-ethereum/common"
 	"github.com/ethereumproject/go-ethereum/crypto"
@@ -56,6 +57,65 @@ func (api *PrivateAdminAPI) AddPeer(url string) (bool, error) {
 	return true, nil
 }
 
+// AddBan prevents a remote node from connecting for the given number of seconds,
+// or permanently if no duration is given. The node may be specified either by
+// its enode URL or its hex node id.
+func (api *PrivateAdminAPI) AddBan(node string, seconds *rpc.HexNumber) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	id, err := parseNodeID(node)
+	if err != nil {
+		return false, err
+	}
+	var duration time.Duration
+	if seconds != nil {
+		if seconds.Int64() <= 0 {
+			return false, fmt.Errorf("invalid ban duration: %d", seconds.Int64())
+		}
+		duration = time.Duration(seconds.Int64()) * time.Second
+	}
+	if err := server.BanPeer(id, duration, "admin"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveBan lifts the ban of a remote node, also forgetting its past offences.
+func (api *PrivateAdminAPI) RemoveBan(node string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	id, err := parseNodeID(node)
+	if err != nil {
+		return false, err
+	}
+	if err := server.UnbanPeer(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// parseNodeID retrieves the node id from either an enode URL or a hex id.
+func parseNodeID(node string) (discover.NodeID, error) {
+	if strings.HasPrefix(node, "enode://") {
+		n, err := discover.ParseNode(node)
+		if err != nil {
+			return discover.NodeID{}, fmt.Errorf("invalid enode: %v", err)
+		}
+		return n.ID, nil
+	}
+	id, err := discover.HexID(node)
+	if err != nil {
+		return discover.NodeID{}, fmt.Errorf("invalid node id: %v", err)
+	}
+	return id, nil
+}
+
 // StartRPC starts the HTTP RPC API server.
 func (api *PrivateAdminAPI) StartRPC(host *string, port *rpc.HexNumber, cors *string, apis *string) (bool, error) {
 	api.node.lock.Lock()
@@ -176,6 +236,16 @@ func (api *PublicAdminAPI) Peers() ([]*p2p.PeerInfo, error) {
 	return server.PeersInfo(), nil
 }
 
+// Bans retrieves all the nodes currently banned from connecting, either by an
+// administrator or due to their misbehaviour.
+func (api *PublicAdminAPI) Bans() ([]*p2p.BanInfo, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.Bans()
+}
+
 // NodeInfo retrieves all the information we know about the host node at the
 // protocol granularity.
 func (api *PublicAdminAPI) NodeInfo() (*p2p.NodeInfo, error) {
@@ -26,6 +26,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/ethereumproject/go-ethereum/common"
 	"github.com/ethereumproject/go-ethereum/crypto"
@@ -33,6 +34,7 @@ import (
 	"github.com/ethereumproject/go-ethereum/logger/glog"
 	"github.com/ethereumproject/go-ethereum/p2p/discover"
 	"github.com/ethereumproject/go-ethereum/p2p/nat"
+	"github.com/ethereumproject/go-ethereum/rpc"
 )
 
 var (
@@ -98,6 +100,14 @@ type Config struct {
 	// Zero defaults to preset values.
 	MaxPendingPeers int
 
+	// MaxPeerIngress is the maximum rate in bytes per second at which messages
+	// are accepted from a single peer. Zero means no limit.
+	MaxPeerIngress uint64
+
+	// MaxIngress is the maximum rate in bytes per second at which messages are
+	// accepted from all peers combined. Zero means no limit.
+	MaxIngress uint64
+
 	// HTTPHost is the host interface on which to start the HTTP RPC server. If this
 	// field is empty, no HTTP API endpoint will be started.
 	HTTPHost string
@@ -117,6 +127,15 @@ type Config struct {
 	// exposed.
 	HTTPModules []string
 
+	// HTTPVhosts is the list of host names accepted in the Host header of HTTP
+	// RPC requests, protecting the server from DNS rebinding attacks. Requests by IP
+	// address are always accepted. A nil list accepts any host, "*" does too.
+	HTTPVhosts []string
+
+	// HTTPMaxHeadAge is the maximum age of the head block for the node to report
+	// itself ready on the HTTP /ready endpoint. Zero disables the check.
+	HTTPMaxHeadAge time.Duration
+
 	// WSHost is the host interface on which to start the websocket RPC server. If
 	// this field is empty, no websocket API endpoint will be started.
 	WSHost string
@@ -135,6 +154,16 @@ type Config struct {
 	// If the module list is empty, all RPC API endpoints designated public will be
 	// exposed.
 	WSModules []string
+
+	// RPCAuthFile is the path of the JSON file holding the bearer token credentials
+	// of the HTTP and websocket RPC interfaces. If set, unauthenticated requests may
+	// only call the methods listed public in the file, and authenticated ones the
+	// methods granted to their credential, with the privileged calls audited.
+	RPCAuthFile string
+
+	// RPCLimits bounds the batch sizes, response sizes, parallelism and execution
+	// times of the requests served over the HTTP and websocket RPC interfaces.
+	RPCLimits rpc.Limits
 }
 
 // IPCEndpoint resolves an IPC endpoint based on a configured value, taking into
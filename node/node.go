@@ -19,12 +19,15 @@ package node
 
 import (
 	"errors"
+	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/ethereumproject/go-ethereum/event"
 	"github.com/ethereumproject/go-ethereum/logger"
@@ -61,13 +64,17 @@ type Node struct {
 	ipcListener net.Listener // IPC RPC listener socket to serve API requests
 	ipcHandler  *rpc.Server  // IPC RPC request handler to process the API requests
 
-	httpHost      string       // HTTP hostname
-	httpPort      int          // HTTP post
-	httpEndpoint  string       // HTTP endpoint (interface + port) to listen at (empty = HTTP disabled)
-	httpWhitelist []string     // HTTP RPC modules to allow through this endpoint
-	httpCors      string       // HTTP RPC Cross-Origin Resource Sharing header
-	httpListener  net.Listener // HTTP RPC listener socket to server API requests
-	httpHandler   *rpc.Server  // HTTP RPC request handler to process the API requests
+	httpHost      string        // HTTP hostname
+	httpPort      int           // HTTP post
+	httpEndpoint  string        // HTTP endpoint (interface + port) to listen at (empty = HTTP disabled)
+	httpWhitelist []string      // HTTP RPC modules to allow through this endpoint
+	httpCors      string        // HTTP RPC Cross-Origin Resource Sharing header
+	httpVhosts    []string      // HTTP RPC allowed virtual hosts (nil = any)
+	httpHeadAge   time.Duration // Maximum head block age for the HTTP ready endpoint (0 = unchecked)
+	httpListener  net.Listener  // HTTP RPC listener socket to server API requests
+	httpHandler   *rpc.Server   // HTTP RPC request handler to process the API requests
+
+	httpHandlers map[string]http.Handler // HTTP handlers mounted by the services, keyed by path
 
 	wsHost      string       // Websocket host
 	wsPort      int          // Websocket post
@@ -77,6 +84,9 @@ type Node struct {
 	wsListener  net.Listener // Websocket RPC listener socket to server API requests
 	wsHandler   *rpc.Server  // Websocket RPC request handler to process the API requests
 
+	rpcAuth   *rpc.Authenticator // Bearer token authenticator of the HTTP and websocket endpoints (nil = open)
+	rpcLimits rpc.Limits         // Resource limits of the HTTP and websocket endpoints
+
 	stop chan struct{} // Channel to wait for termination notifications
 	lock sync.RWMutex
 }
@@ -89,6 +99,17 @@ func New(conf *Config) (*Node, error) {
 			return nil, err
 		}
 	}
+	// Load the credentials of the HTTP and websocket endpoints, if restricted
+	var auth *rpc.Authenticator
+	if conf.RPCAuthFile != "" {
+		authConf, err := rpc.LoadAuthConfig(conf.RPCAuthFile)
+		if err != nil {
+			return nil, err
+		}
+		if auth, err = rpc.NewAuthenticator(authConf); err != nil {
+			return nil, err
+		}
+	}
 	// Assemble the networking layer and the node itself
 	nodeDbPath := ""
 	if conf.DataDir != "" {
@@ -110,6 +131,8 @@ func New(conf *Config) (*Node, error) {
 			NoDial:          conf.NoDial,
 			MaxPeers:        conf.MaxPeers,
 			MaxPendingPeers: conf.MaxPendingPeers,
+			MaxPeerIngress:  conf.MaxPeerIngress,
+			MaxIngress:      conf.MaxIngress,
 		},
 		serviceFuncs:  []ServiceConstructor{},
 		ipcEndpoint:   conf.IPCEndpoint(),
@@ -118,11 +141,15 @@ func New(conf *Config) (*Node, error) {
 		httpEndpoint:  conf.HTTPEndpoint(),
 		httpWhitelist: conf.HTTPModules,
 		httpCors:      conf.HTTPCors,
+		httpVhosts:    conf.HTTPVhosts,
+		httpHeadAge:   conf.HTTPMaxHeadAge,
 		wsHost:        conf.WSHost,
 		wsPort:        conf.WSPort,
 		wsEndpoint:    conf.WSEndpoint(),
 		wsWhitelist:   conf.WSModules,
 		wsOrigins:     conf.WSOrigins,
+		rpcAuth:       auth,
+		rpcLimits:     conf.RPCLimits,
 		eventmux:      new(event.TypeMux),
 	}, nil
 }
@@ -223,6 +250,23 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 	for _, service := range services {
 		apis = append(apis, service.APIs()...)
 	}
+	// Gather the custom HTTP handlers, mounted next to the HTTP RPC endpoint
+	handlers := make(map[string]http.Handler)
+	for _, service := range services {
+		provider, ok := service.(HTTPHandlerProvider)
+		if !ok {
+			continue
+		}
+		for path, handler := range provider.HTTPHandlers() {
+			if _, exists := handlers[path]; exists {
+				return fmt.Errorf("duplicate HTTP handler for %s", path)
+			}
+			handlers[path] = handler
+			glog.V(logger.Debug).Infof("HTTP mounted %T on '%s'", handler, path)
+		}
+	}
+	n.httpHandlers = handlers
+
 	// Start the various API endpoints, terminating all in case of errors
 	if err := n.startInProc(apis); err != nil {
 		return err
@@ -345,6 +389,7 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	}
 	// Register all the APIs exposed by the services
 	handler := rpc.NewServer()
+	handler.SetLimits(n.rpcLimits)
 	for _, api := range apis {
 		if whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -361,7 +406,7 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return err
 	}
-	go rpc.NewHTTPServer(cors, handler).Serve(listener)
+	go rpc.NewHTTPServer(cors, n.httpVhosts, n.rpcAuth, n.health, n.httpHandlers, handler).Serve(listener)
 	glog.V(logger.Info).Infof("HTTP endpoint opened: http://%s", endpoint)
 
 	// All listeners booted successfully
@@ -373,6 +418,44 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	return nil
 }
 
+// health gathers the sync status of the node for the HTTP health endpoints. The
+// node is ready if it has peers (unless it's not meant to), is not syncing and
+// its head block is recent enough.
+func (n *Node) health() *rpc.Health {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	health := new(rpc.Health)
+	if n.server == nil {
+		health.Problems = append(health.Problems, "node not running")
+		return health
+	}
+	health.Peers = n.server.PeerCount()
+	if health.Peers == 0 && n.serverConfig.MaxPeers > 0 {
+		health.Problems = append(health.Problems, "no peers")
+	}
+	for _, service := range n.services {
+		reporter, ok := service.(SyncReporter)
+		if !ok {
+			continue
+		}
+		status := reporter.SyncStatus()
+		health.Syncing = status.Syncing
+		health.CurrentBlock, health.HighestBlock = status.CurrentBlock, status.HighestBlock
+		if age := time.Since(status.HeadTime); age > 0 {
+			health.HeadAge = uint64(age / time.Second)
+		}
+		if status.Syncing {
+			health.Problems = append(health.Problems, "syncing")
+		}
+		if n.httpHeadAge > 0 && time.Duration(health.HeadAge)*time.Second > n.httpHeadAge {
+			health.Problems = append(health.Problems, fmt.Sprintf("head block too old (%ds)", health.HeadAge))
+		}
+	}
+	health.Ready = len(health.Problems) == 0
+	return health
+}
+
 // stopHTTP terminates the HTTP RPC endpoint.
 func (n *Node) stopHTTP() {
 	if n.httpListener != nil {
@@ -400,6 +483,7 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 	}
 	// Register all the APIs exposed by the services
 	handler := rpc.NewServer()
+	handler.SetLimits(n.rpcLimits)
 	for _, api := range apis {
 		if whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -416,7 +500,7 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return err
 	}
-	go rpc.NewWSServer(wsOrigins, handler).Serve(listener)
+	go rpc.NewWSServer(wsOrigins, n.rpcAuth, handler).Serve(listener)
 	glog.V(logger.Info).Infof("WebSocket endpoint opened: ws://%s", endpoint)
 
 	// All listeners booted successfully
@@ -457,6 +541,7 @@ func (n *Node) Stop() error {
 	n.stopHTTP()
 	n.stopIPC()
 	n.rpcAPIs = nil
+	n.httpHandlers = nil
 
 	failure := &StopError{
 		Services: make(map[reflect.Type]error),
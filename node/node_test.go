@@ -19,6 +19,7 @@ package node
 import (
 	"errors"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"reflect"
 	"testing"
@@ -591,3 +592,99 @@ func TestAPIGather(t *testing.T) {
 		}
 	}
 }
+
+// syncReporterService is a service reporting a configurable sync status.
+type syncReporterService struct {
+	NoopService
+	status SyncStatus
+}
+
+func (s *syncReporterService) SyncStatus() SyncStatus { return s.status }
+
+// Tests that the node health reflects the sync status of the services.
+func TestNodeHealth(t *testing.T) {
+	config := testNodeConfig()
+	config.HTTPMaxHeadAge = time.Minute
+
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if health := stack.health(); health.Ready {
+		t.Fatalf("stopped node reported ready")
+	}
+	service := new(syncReporterService)
+	if err := stack.Register(func(*ServiceContext) (Service, error) { return service, nil }); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	tests := []struct {
+		status   SyncStatus
+		ready    bool
+		problems int
+	}{
+		{SyncStatus{CurrentBlock: 10, HighestBlock: 10, HeadTime: time.Now()}, true, 0},
+		{SyncStatus{Syncing: true, CurrentBlock: 5, HighestBlock: 10, HeadTime: time.Now()}, false, 1},
+		{SyncStatus{CurrentBlock: 10, HighestBlock: 10, HeadTime: time.Now().Add(-time.Hour)}, false, 1},
+		{SyncStatus{Syncing: true, CurrentBlock: 5, HighestBlock: 10, HeadTime: time.Now().Add(-time.Hour)}, false, 2},
+	}
+	for i, tt := range tests {
+		service.status = tt.status
+		health := stack.health()
+		if health.Ready != tt.ready || len(health.Problems) != tt.problems {
+			t.Errorf("test %d: health mismatch: have ready %v problems %v, want ready %v with %d problems", i, health.Ready, health.Problems, tt.ready, tt.problems)
+		}
+		if health.CurrentBlock != tt.status.CurrentBlock || health.HighestBlock != tt.status.HighestBlock || health.Syncing != tt.status.Syncing {
+			t.Errorf("test %d: sync status mismatch: have %+v, want %+v", i, health, tt.status)
+		}
+	}
+}
+
+// httpHandlerService is a service mounting a handler on the HTTP endpoint.
+type httpHandlerService struct {
+	NoopService
+	path string
+}
+
+func (s *httpHandlerService) HTTPHandlers() map[string]http.Handler {
+	return map[string]http.Handler{s.path: http.NotFoundHandler()}
+}
+
+type httpHandlerServiceB struct{ httpHandlerService }
+
+// Tests that the HTTP handlers of the services are gathered on startup, and that
+// conflicting paths are rejected.
+func TestNodeHTTPHandlers(t *testing.T) {
+	stack, err := New(testNodeConfig())
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Register(func(*ServiceContext) (Service, error) { return &httpHandlerService{path: "/a"}, nil }); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	if _, ok := stack.httpHandlers["/a"]; !ok || len(stack.httpHandlers) != 1 {
+		t.Errorf("handlers mismatch: have %v, want /a", stack.httpHandlers)
+	}
+	stack.Stop()
+
+	// Register a second service on the same path and ensure startup fails
+	stack, err = New(testNodeConfig())
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	stack.Register(func(*ServiceContext) (Service, error) { return &httpHandlerService{path: "/a"}, nil })
+	stack.Register(func(*ServiceContext) (Service, error) {
+		return &httpHandlerServiceB{httpHandlerService{path: "/a"}}, nil
+	})
+	if err := stack.Start(); err == nil {
+		stack.Stop()
+		t.Fatalf("conflicting handlers accepted")
+	}
+}
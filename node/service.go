@@ -17,8 +17,10 @@
 package node
 
 import (
+	"net/http"
 	"path/filepath"
 	"reflect"
+	"time"
 
 	"github.com/ethereumproject/go-ethereum/ethdb"
 	"github.com/ethereumproject/go-ethereum/event"
@@ -82,3 +84,24 @@ type Service interface {
 	// are all terminated.
 	Stop() error
 }
+
+// HTTPHandlerProvider is implemented by services serving custom HTTP endpoints,
+// mounted on the node's HTTP RPC server next to the JSON-RPC handler.
+type HTTPHandlerProvider interface {
+	// HTTPHandlers retrieves the handlers to serve, keyed by URL path.
+	HTTPHandlers() map[string]http.Handler
+}
+
+// SyncReporter is implemented by services following a blockchain, to have their
+// sync status reported on the node's HTTP health endpoints.
+type SyncReporter interface {
+	SyncStatus() SyncStatus
+}
+
+// SyncStatus is the chain synchronisation progress of a service.
+type SyncStatus struct {
+	Syncing      bool      // Whether the service is catching up with the network
+	CurrentBlock uint64    // Number of the current head block
+	HighestBlock uint64    // Number of the highest block announced by the network
+	HeadTime     time.Time // Timestamp of the current head block
+}
@@ -209,6 +209,10 @@ func (s *dialstate) taskDone(t task, now time.Time) {
 }
 
 func (t *dialTask) Do(srv *Server) {
+	if srv.isBanned(t.dest.ID) {
+		glog.V(logger.Debug).Infof("not dialing banned node %x", t.dest.ID[:6])
+		return
+	}
 	if t.dest.Incomplete() {
 		if !t.resolve(srv) {
 			return
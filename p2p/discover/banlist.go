@@ -0,0 +1,136 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"sort"
+	"time"
+)
+
+// Ban describes a node which is not allowed to connect.
+type Ban struct {
+	ID        NodeID    // Identifier of the banned node
+	Expires   time.Time // Time at which a temporary ban is lifted
+	Permanent bool      // Whether the ban is only lifted by removal
+	Count     uint      // Number of temporary bans issued so far
+	Reason    string    // Human readable cause of the (last) ban
+}
+
+// Active reports whether the ban is in effect at the given time.
+func (b *Ban) Active(now time.Time) bool {
+	return b.Permanent || now.Before(b.Expires)
+}
+
+// BanList is the persistent set of banned nodes, stored in the node database
+// alongside the discovery data.
+type BanList struct {
+	db    *nodeDB
+	owned bool // Whether the database was opened by (and is closed with) the list
+}
+
+// OpenBanList opens a stand-alone ban list backed by the node database at the
+// given path. If no path is given, an in-memory, temporary list is constructed.
+// It is meant for servers running without discovery; otherwise the list of the
+// discovery table should be used.
+func OpenBanList(path string, self NodeID) (*BanList, error) {
+	db, err := newNodeDB(path, Version, self)
+	if err != nil {
+		return nil, err
+	}
+	// The list runs without the discovery expirer, drop stale bans up front
+	if err := db.expireBans(); err != nil {
+		db.close()
+		return nil, err
+	}
+	return &BanList{db: db, owned: true}, nil
+}
+
+// BanList returns the ban list stored in the node database of the table.
+func (tab *Table) BanList() *BanList {
+	return &BanList{db: tab.db}
+}
+
+// Get retrieves the ban entry of a node, whether active or lifted. It returns
+// nil if the node was never banned or its entry expired by the given time, in
+// which case the entry is dropped.
+func (bl *BanList) Get(id NodeID, now time.Time) *Ban {
+	entry := bl.db.ban(id)
+	if entry == nil {
+		return nil
+	}
+	if entry.stale(now) {
+		bl.db.deleteBan(id)
+		return nil
+	}
+	return newBan(id, entry)
+}
+
+// Banned reports whether the node is banned at the given time.
+func (bl *BanList) Banned(id NodeID, now time.Time) bool {
+	ban := bl.Get(id, now)
+	return ban != nil && ban.Active(now)
+}
+
+// Add inserts or overwrites the ban of a node.
+func (bl *BanList) Add(ban *Ban) error {
+	return bl.db.updateBan(ban.ID, &banEntry{
+		Expires:   uint64(ban.Expires.Unix()),
+		Permanent: ban.Permanent,
+		Count:     uint64(ban.Count),
+		Reason:    ban.Reason,
+	})
+}
+
+// Remove lifts the ban of a node and forgets about its previous offences.
+func (bl *BanList) Remove(id NodeID) error {
+	return bl.db.deleteBan(id)
+}
+
+// List returns all the active bans at the given time, ordered by node id.
+func (bl *BanList) List(now time.Time) []*Ban {
+	var bans []*Ban
+	for id, entry := range bl.db.bans() {
+		if ban := newBan(id, entry); ban.Active(now) {
+			bans = append(bans, ban)
+		}
+	}
+	sort.Sort(bansByID(bans))
+	return bans
+}
+
+// Close releases the database if it is owned by the list.
+func (bl *BanList) Close() {
+	if bl.owned {
+		bl.db.close()
+	}
+}
+
+func newBan(id NodeID, entry *banEntry) *Ban {
+	return &Ban{
+		ID:        id,
+		Expires:   time.Unix(int64(entry.Expires), 0),
+		Permanent: entry.Permanent,
+		Count:     uint(entry.Count),
+		Reason:    entry.Reason,
+	}
+}
+
+type bansByID []*Ban
+
+func (b bansByID) Len() int           { return len(b) }
+func (b bansByID) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b bansByID) Less(i, j int) bool { return b[i].ID.String() < b[j].ID.String() }
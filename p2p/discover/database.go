@@ -57,6 +57,7 @@ type nodeDB struct {
 var (
 	nodeDBVersionKey = []byte("version") // Version of the database to flush if changes
 	nodeDBItemPrefix = []byte("n:")      // Identifier to prefix node entries with
+	nodeDBBanPrefix  = []byte("b:")      // Identifier to prefix ban entries with
 
 	nodeDBDiscoverRoot      = ":discover"
 	nodeDBDiscoverPing      = nodeDBDiscoverRoot + ":lastping"
@@ -237,6 +238,9 @@ func (db *nodeDB) expirer() {
 			if err := db.expireNodes(); err != nil {
 				glog.Error("Failed to expire nodedb items: ", err)
 			}
+			if err := db.expireBans(); err != nil {
+				glog.Error("Failed to expire nodedb bans: ", err)
+			}
 
 		case <-db.quit:
 			return
@@ -364,6 +368,92 @@ func nextNode(it iterator.Iterator) *Node {
 	return nil
 }
 
+// banEntry is the RLP structure of a stored ban. Bans live outside of the node
+// item prefix so that they survive the expiration of the discovery data.
+type banEntry struct {
+	Expires   uint64 // Unix timestamp at which a temporary ban is lifted
+	Permanent bool   // Whether the ban is lifted only by explicit removal
+	Count     uint64 // Number of temporary bans issued so far
+	Reason    string // Human readable cause of the (last) ban
+}
+
+// makeBanKey generates the leveldb key-blob for the ban entry of a node.
+func makeBanKey(id NodeID) []byte {
+	return append(append([]byte{}, nodeDBBanPrefix...), id[:]...)
+}
+
+// ban retrieves the ban entry stored for a node, or nil if there is none.
+func (db *nodeDB) ban(id NodeID) *banEntry {
+	blob, err := db.lvl.Get(makeBanKey(id), nil)
+	if err != nil {
+		return nil
+	}
+	entry := new(banEntry)
+	if err := rlp.DecodeBytes(blob, entry); err != nil {
+		glog.V(logger.Warn).Infof("failed to decode ban RLP: %v", err)
+		return nil
+	}
+	return entry
+}
+
+// updateBan inserts - potentially overwriting - a ban entry of a node.
+func (db *nodeDB) updateBan(id NodeID, entry *banEntry) error {
+	blob, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		return err
+	}
+	return db.lvl.Put(makeBanKey(id), blob, nil)
+}
+
+// deleteBan removes the ban entry of a node.
+func (db *nodeDB) deleteBan(id NodeID) error {
+	return db.lvl.Delete(makeBanKey(id), nil)
+}
+
+// bans iterates over all the stored ban entries.
+func (db *nodeDB) bans() map[NodeID]*banEntry {
+	it := db.lvl.NewIterator(util.BytesPrefix(nodeDBBanPrefix), nil)
+	defer it.Release()
+
+	bans := make(map[NodeID]*banEntry)
+	for it.Next() {
+		var id NodeID
+		if len(it.Key()) != len(nodeDBBanPrefix)+len(id) {
+			continue
+		}
+		copy(id[:], it.Key()[len(nodeDBBanPrefix):])
+
+		entry := new(banEntry)
+		if err := rlp.DecodeBytes(it.Value(), entry); err != nil {
+			glog.V(logger.Warn).Infof("invalid ban %x: %v", id[:8], err)
+			continue
+		}
+		bans[id] = entry
+	}
+	return bans
+}
+
+// stale reports whether a temporary ban has been lifted for longer than the
+// node expiration period at the given time.
+func (entry *banEntry) stale(now time.Time) bool {
+	return !entry.Permanent && int64(entry.Expires) < now.Add(-nodeDBNodeExpiration).Unix()
+}
+
+// expireBans deletes all temporary bans which have been lifted for longer than
+// the node expiration period. Lifted bans are kept around for a while so that
+// repeated offences can still be escalated.
+func (db *nodeDB) expireBans() error {
+	now := time.Now()
+	for id, entry := range db.bans() {
+		if entry.stale(now) {
+			if err := db.deleteBan(id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // close flushes and closes the database files.
 func (db *nodeDB) close() {
 	close(db.quit)
@@ -378,3 +378,129 @@ func TestNodeDBSelfExpiration(t *testing.T) {
 		t.Errorf("self not evacuated")
 	}
 }
+
+func TestNodeDBBans(t *testing.T) {
+	db, _ := newNodeDB("", Version, NodeID{})
+	defer db.close()
+
+	node := nodeDBExpirationNodes[0].node
+	if err := db.updateNode(node); err != nil {
+		t.Fatalf("failed to insert node: %v", err)
+	}
+	entries := map[NodeID]*banEntry{
+		node.ID:      {Expires: uint64(time.Now().Add(time.Hour).Unix()), Count: 1, Reason: "timeout"},
+		NodeID{0x01}: {Permanent: true, Count: 4, Reason: "invalid block"},
+		NodeID{0x02}: {Expires: uint64(time.Now().Add(-time.Minute).Unix()), Count: 1},
+		NodeID{0x03}: {Expires: uint64(time.Now().Add(-nodeDBNodeExpiration - time.Hour).Unix()), Count: 2},
+	}
+	for id, entry := range entries {
+		if err := db.updateBan(id, entry); err != nil {
+			t.Fatalf("failed to store ban of %x: %v", id[:8], err)
+		}
+	}
+	if bans := db.bans(); !reflect.DeepEqual(bans, entries) {
+		t.Fatalf("ban mismatch:\nhave %v\nwant %v", bans, entries)
+	}
+	// Bans must survive expiring the discovery data of the node
+	if err := db.expireNodes(); err != nil {
+		t.Fatalf("failed to expire nodes: %v", err)
+	}
+	if db.node(node.ID) != nil {
+		t.Fatalf("node not expired")
+	}
+	if ban := db.ban(node.ID); !reflect.DeepEqual(ban, entries[node.ID]) {
+		t.Fatalf("ban mismatch after node expiration: have %v, want %v", ban, entries[node.ID])
+	}
+	// Long lifted bans expire, recently lifted ones are retained
+	if err := db.expireBans(); err != nil {
+		t.Fatalf("failed to expire bans: %v", err)
+	}
+	delete(entries, NodeID{0x03})
+	if bans := db.bans(); !reflect.DeepEqual(bans, entries) {
+		t.Fatalf("ban mismatch after expiration:\nhave %v\nwant %v", bans, entries)
+	}
+}
+
+func TestBanList(t *testing.T) {
+	bans, _ := OpenBanList("", NodeID{})
+	defer bans.Close()
+
+	now := time.Now()
+	var (
+		temp  = &Ban{ID: NodeID{0x01}, Expires: now.Add(time.Hour), Count: 1, Reason: "timeout"}
+		perm  = &Ban{ID: NodeID{0x02}, Permanent: true, Reason: "admin"}
+		stale = &Ban{ID: NodeID{0x03}, Expires: now.Add(-time.Hour), Count: 2}
+	)
+	for _, ban := range []*Ban{temp, perm, stale} {
+		if err := bans.Add(ban); err != nil {
+			t.Fatalf("failed to add ban: %v", err)
+		}
+	}
+	if !bans.Banned(temp.ID, now) || !bans.Banned(perm.ID, now) || bans.Banned(stale.ID, now) {
+		t.Errorf("ban state mismatch")
+	}
+	if bans.Banned(temp.ID, now.Add(2*time.Hour)) {
+		t.Errorf("temporary ban not lifted after expiry")
+	}
+	if list := bans.List(now); len(list) != 2 || list[0].ID != temp.ID || list[1].ID != perm.ID {
+		t.Errorf("active ban list mismatch: %v", list)
+	}
+	// Lifted bans are retained for escalation, removed ones are not
+	if ban := bans.Get(stale.ID, now); ban == nil || ban.Count != 2 {
+		t.Errorf("lifted ban mismatch: %v", ban)
+	}
+	if err := bans.Remove(temp.ID); err != nil {
+		t.Fatalf("failed to remove ban: %v", err)
+	}
+	if bans.Get(temp.ID, now) != nil {
+		t.Errorf("removed ban still present")
+	}
+}
+
+func TestBanListExpiry(t *testing.T) {
+	root, err := ioutil.TempDir("", "banlist-")
+	if err != nil {
+		t.Fatalf("failed to create temporary data folder: %v", err)
+	}
+	defer os.RemoveAll(root)
+	path := filepath.Join(root, "database")
+
+	now := time.Now()
+	var (
+		lifted = &Ban{ID: NodeID{0x01}, Expires: now.Add(-time.Hour), Count: 1}
+		stale  = &Ban{ID: NodeID{0x02}, Expires: now.Add(-nodeDBNodeExpiration - time.Hour), Count: 2}
+	)
+	bans, err := OpenBanList(path, NodeID{})
+	if err != nil {
+		t.Fatalf("failed to open ban list: %v", err)
+	}
+	for _, ban := range []*Ban{lifted, stale} {
+		if err := bans.Add(ban); err != nil {
+			t.Fatalf("failed to add ban: %v", err)
+		}
+	}
+	// Stale bans are dropped on lookup
+	if ban := bans.Get(stale.ID, now); ban != nil {
+		t.Errorf("stale ban returned: %v", ban)
+	}
+	if entry := bans.db.ban(stale.ID); entry != nil {
+		t.Errorf("stale ban not deleted on lookup: %v", entry)
+	}
+	// Stale bans are dropped when the list is loaded from disk
+	if err := bans.Add(stale); err != nil {
+		t.Fatalf("failed to add ban: %v", err)
+	}
+	bans.Close()
+
+	if bans, err = OpenBanList(path, NodeID{}); err != nil {
+		t.Fatalf("failed to reopen ban list: %v", err)
+	}
+	defer bans.Close()
+
+	if entry := bans.db.ban(stale.ID); entry != nil {
+		t.Errorf("stale ban not expired on load: %v", entry)
+	}
+	if ban := bans.Get(lifted.ID, now); ban == nil || ban.Count != 1 {
+		t.Errorf("lifted ban mismatch after reload: %v", ban)
+	}
+}
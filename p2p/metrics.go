@@ -18,6 +18,8 @@ package p2p
 
 import (
 	"net"
+	"sync"
+	"sync/atomic"
 
 	"github.com/ethereumproject/go-ethereum/metrics"
 )
@@ -26,26 +28,100 @@ import (
 type meteredConn struct {
 	net.Conn
 	markBytes func(int64)
+
+	read, written uint64 // Wire level byte counters of this connection (atomic)
 }
 
 func newMeteredConn(conn net.Conn, ingress bool) net.Conn {
 	if ingress {
 		metrics.P2PIn.Mark(1)
-		return &meteredConn{conn, metrics.P2PInBytes.Mark}
+		return &meteredConn{Conn: conn, markBytes: metrics.P2PInBytes.Mark}
 	} else {
 		metrics.P2POut.Mark(1)
-		return &meteredConn{conn, metrics.P2POutBytes.Mark}
+		return &meteredConn{Conn: conn, markBytes: metrics.P2POutBytes.Mark}
 	}
 }
 
 func (c *meteredConn) Read(b []byte) (n int, err error) {
 	n, err = c.Conn.Read(b)
 	c.markBytes(int64(n))
+	atomic.AddUint64(&c.read, uint64(n))
 	return
 }
 
 func (c *meteredConn) Write(b []byte) (n int, err error) {
 	n, err = c.Conn.Write(b)
 	c.markBytes(int64(n))
+	atomic.AddUint64(&c.written, uint64(n))
 	return
 }
+
+// MsgTraffic contains the traffic accounted to a single message code.
+type MsgTraffic struct {
+	InMessages  uint64 `json:"inMessages"`
+	InBytes     uint64 `json:"inBytes"`
+	OutMessages uint64 `json:"outMessages"`
+	OutBytes    uint64 `json:"outBytes"`
+}
+
+// TrafficInfo summarises the traffic exchanged with a single peer.
+type TrafficInfo struct {
+	InBytes   uint64                            `json:"inBytes"`   // Bytes read from the wire, including framing and encryption
+	OutBytes  uint64                            `json:"outBytes"`  // Bytes written to the wire, including framing and encryption
+	Protocols map[string]map[uint64]*MsgTraffic `json:"protocols"` // Decoded payload traffic per sub-protocol message code
+}
+
+// peerMeter accounts the sub-protocol messages exchanged with a peer, feeding
+// both the peer's own statistics and the global per message code meters.
+type peerMeter struct {
+	codes map[string]map[uint64]*MsgTraffic
+	lock  sync.Mutex
+}
+
+func newPeerMeter() *peerMeter {
+	return &peerMeter{codes: make(map[string]map[uint64]*MsgTraffic)}
+}
+
+// mark accounts a single message of the given protocol relative code.
+func (m *peerMeter) mark(proto Protocol, code uint64, size uint32, ingress bool) {
+	msgs, bytes := metrics.P2PMsgMeters(proto.Name, proto.Version, code, ingress)
+	msgs.Mark(1)
+	bytes.Mark(int64(size))
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	codes := m.codes[proto.Name]
+	if codes == nil {
+		codes = make(map[uint64]*MsgTraffic)
+		m.codes[proto.Name] = codes
+	}
+	stats := codes[code]
+	if stats == nil {
+		stats = new(MsgTraffic)
+		codes[code] = stats
+	}
+	if ingress {
+		stats.InMessages++
+		stats.InBytes += uint64(size)
+	} else {
+		stats.OutMessages++
+		stats.OutBytes += uint64(size)
+	}
+}
+
+// info returns a copy of the accumulated per message code statistics.
+func (m *peerMeter) info() map[string]map[uint64]*MsgTraffic {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	info := make(map[string]map[uint64]*MsgTraffic, len(m.codes))
+	for proto, codes := range m.codes {
+		info[proto] = make(map[uint64]*MsgTraffic, len(codes))
+		for code, stats := range codes {
+			copy := *stats
+			info[proto][code] = &copy
+		}
+	}
+	return info
+}
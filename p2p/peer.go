@@ -23,19 +23,25 @@ import (
 	"net"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereumproject/go-ethereum/logger"
 	"github.com/ethereumproject/go-ethereum/logger/glog"
+	"github.com/ethereumproject/go-ethereum/metrics"
 	"github.com/ethereumproject/go-ethereum/p2p/discover"
 	"github.com/ethereumproject/go-ethereum/rlp"
 )
 
 const (
-	baseProtocolVersion    = 4
+	baseProtocolVersion    = 5
 	baseProtocolLength     = uint64(16)
 	baseProtocolMaxMsgSize = 2 * 1024
 
+	// snappyProtocolVersion is the first base protocol version which
+	// compresses message payloads with snappy after the handshake.
+	snappyProtocolVersion = 5
+
 	pingInterval = 15 * time.Second
 )
 
@@ -65,6 +71,10 @@ type protoHandshake struct {
 type Peer struct {
 	rw      *conn
 	running map[string]*protoRW
+	rep     *reputation // nil if the peer is not managed by a server
+	meter   *peerMeter
+
+	limiters []*tokenBucket // Ingress rate limiters, nil entries are unlimited
 
 	wg       sync.WaitGroup
 	protoErr chan error
@@ -116,6 +126,19 @@ func (p *Peer) Disconnect(reason DiscReason) {
 	}
 }
 
+// Report feeds an observation about the peer's behaviour into the reputation
+// tracker of the server. If the score of the peer drops too low, it is banned
+// and disconnected. Trusted peers are never banned.
+func (p *Peer) Report(event PeerEvent) {
+	if p.rep == nil || p.rw.is(trustedConn) {
+		return
+	}
+	glog.V(logger.Detail).Infof("%v: reported %v", p, event)
+	if p.rep.report(p.ID(), event) {
+		p.Disconnect(DiscUselessPeer)
+	}
+}
+
 // String implements fmt.Stringer.
 func (p *Peer) String() string {
 	return fmt.Sprintf("Peer %x %v", p.rw.id[:8], p.RemoteAddr())
@@ -126,10 +149,14 @@ func newPeer(conn *conn, protocols []Protocol) *Peer {
 	p := &Peer{
 		rw:       conn,
 		running:  protomap,
+		meter:    newPeerMeter(),
 		disc:     make(chan DiscReason),
 		protoErr: make(chan error, len(protomap)+1), // protocols + pingLoop
 		closed:   make(chan struct{}),
 	}
+	for _, proto := range protomap {
+		proto.meter = p.meter
+	}
 	return p
 }
 
@@ -221,6 +248,38 @@ func (p *Peer) readLoop(errc chan<- error) {
 			errc <- err
 			return
 		}
+		// Hold off reading the next message while the peer is over its
+		// ingress allowance. The wait happens between messages so that
+		// it doesn't count towards the frame read timeout.
+		if !p.throttle(msg.Size) {
+			return
+		}
+	}
+}
+
+// throttle charges an inbound message against the ingress rate limiters and
+// blocks until the limits are honoured again. It returns false if the peer was
+// closed while waiting.
+func (p *Peer) throttle(size uint32) bool {
+	var wait time.Duration
+	for _, limiter := range p.limiters {
+		if d := limiter.take(size); d > wait {
+			wait = d
+		}
+	}
+	if wait == 0 {
+		return true
+	}
+	metrics.P2PInThrottle.Update(wait)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-p.closed:
+		return false
 	}
 }
 
@@ -332,16 +391,21 @@ type protoRW struct {
 	werr   chan<- error    // for write results
 	offset uint64
 	w      MsgWriter
+	meter  *peerMeter // nil for protocols running outside of a peer
 }
 
 func (rw *protoRW) WriteMsg(msg Msg) (err error) {
 	if msg.Code >= rw.Length {
 		return newPeerError(errInvalidMsgCode, "not handled")
 	}
+	code := msg.Code
 	msg.Code += rw.offset
 	select {
 	case <-rw.wstart:
 		err = rw.w.WriteMsg(msg)
+		if err == nil && rw.meter != nil {
+			rw.meter.mark(rw.Protocol, code, msg.Size, false)
+		}
 		// Report write status back to Peer.run. It will initiate
 		// shutdown if the error is non-nil and unblock the next write
 		// otherwise. The calling protocol code should exit for errors
@@ -357,6 +421,9 @@ func (rw *protoRW) ReadMsg() (Msg, error) {
 	select {
 	case msg := <-rw.in:
 		msg.Code -= rw.offset
+		if rw.meter != nil {
+			rw.meter.mark(rw.Protocol, msg.Code, msg.Size, true)
+		}
 		return msg, nil
 	case <-rw.closed:
 		return Msg{}, io.EOF
@@ -367,16 +434,28 @@ func (rw *protoRW) ReadMsg() (Msg, error) {
 // peer. Sub-protocol independent fields are contained and initialized here, with
 // protocol specifics delegated to all connected sub-protocols.
 type PeerInfo struct {
-	ID      string   `json:"id"`   // Unique node identifier (also the encryption key)
-	Name    string   `json:"name"` // Name of the node, including client type, version, OS, custom data
-	Caps    []string `json:"caps"` // Sum-protocols advertised by this particular peer
+	ID      string   `json:"id"`    // Unique node identifier (also the encryption key)
+	Name    string   `json:"name"`  // Name of the node, including client type, version, OS, custom data
+	Caps    []string `json:"caps"`  // Sum-protocols advertised by this particular peer
+	Score   int      `json:"score"` // Current reputation score of the peer
 	Network struct {
 		LocalAddress  string `json:"localAddress"`  // Local endpoint of the TCP data connection
 		RemoteAddress string `json:"remoteAddress"` // Remote endpoint of the TCP data connection
 	} `json:"network"`
+	Traffic   *TrafficInfo           `json:"traffic"`   // Bandwidth used by the peer
 	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
 }
 
+// Traffic returns the bandwidth statistics of the peer.
+func (p *Peer) Traffic() *TrafficInfo {
+	traffic := &TrafficInfo{Protocols: p.meter.info()}
+	if mc, ok := p.rw.fd.(*meteredConn); ok {
+		traffic.InBytes = atomic.LoadUint64(&mc.read)
+		traffic.OutBytes = atomic.LoadUint64(&mc.written)
+	}
+	return traffic
+}
+
 // Info gathers and returns a collection of metadata known about a peer.
 func (p *Peer) Info() *PeerInfo {
 	// Gather the protocol capabilities
@@ -391,8 +470,12 @@ func (p *Peer) Info() *PeerInfo {
 		Caps:      caps,
 		Protocols: make(map[string]interface{}),
 	}
+	if p.rep != nil {
+		info.Score = p.rep.score(p.ID())
+	}
 	info.Network.LocalAddress = p.LocalAddr().String()
 	info.Network.RemoteAddress = p.RemoteAddr().String()
+	info.Traffic = p.Traffic()
 
 	// Gather all the running protocol infos
 	for _, proto := range p.running {
@@ -118,6 +118,72 @@ func TestPeerProtoEncodeMsg(t *testing.T) {
 	}
 }
 
+func TestPeerTrafficMetering(t *testing.T) {
+	done := make(chan struct{})
+	proto := Protocol{
+		Name:    "a",
+		Version: 1,
+		Length:  5,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			if err := ExpectMsg(rw, 2, []uint{1}); err != nil {
+				t.Error(err)
+			}
+			if err := ExpectMsg(rw, 2, []uint{2}); err != nil {
+				t.Error(err)
+			}
+			if err := SendItems(rw, 3, "foo"); err != nil {
+				t.Error(err)
+			}
+			close(done)
+			return nil
+		},
+	}
+	closer, rw, peer, _ := testPeer([]Protocol{proto})
+	defer closer()
+
+	Send(rw, baseProtocolLength+2, []uint{1})
+	Send(rw, baseProtocolLength+2, []uint{2})
+	if err := ExpectMsg(rw, baseProtocolLength+3, []string{"foo"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("protocol timeout")
+	}
+	traffic := peer.Traffic().Protocols["a"]
+	if in := traffic[2]; in == nil || in.InMessages != 2 || in.InBytes != 4 || in.OutMessages != 0 {
+		t.Errorf("ingress traffic mismatch: have %+v", in)
+	}
+	if out := traffic[3]; out == nil || out.OutMessages != 1 || out.OutBytes != 5 || out.InMessages != 0 {
+		t.Errorf("egress traffic mismatch: have %+v", out)
+	}
+}
+
+func TestPeerIngressThrottle(t *testing.T) {
+	closer, rw, peer, _ := testPeer(nil)
+	defer closer()
+
+	// Exhaust the allowance of the peer and check that reading stalls
+	limiter := newTokenBucket(1)
+	limiter.take(1)
+	peer.limiters = []*tokenBucket{limiter}
+
+	if err := SendItems(rw, pingMsg); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExpectMsg(rw, pongMsg, nil); err != nil {
+		t.Fatal(err)
+	}
+	sent := make(chan error, 1)
+	go func() { sent <- SendItems(rw, pingMsg) }()
+	select {
+	case err := <-sent:
+		t.Fatalf("message accepted while throttled: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 func TestPeerPing(t *testing.T) {
 	closer, rw, _, _ := testPeer(nil)
 	defer closer()
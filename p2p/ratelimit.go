@@ -0,0 +1,73 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket is a byte rate limiter. Tokens accumulate at a fixed rate up to
+// one second worth of traffic; consumers take the tokens they need and wait out
+// the debt if the bucket runs dry. A bucket may be shared between goroutines.
+type tokenBucket struct {
+	rate     float64 // Tokens (bytes) added per second
+	capacity float64 // Maximum number of tokens the bucket can hold
+	tokens   float64 // Currently available tokens, negative if in debt
+	last     time.Time
+
+	now  func() time.Time // Time source, replaceable for testing
+	lock sync.Mutex
+}
+
+// newTokenBucket creates a rate limiter allowing rate bytes per second. It
+// returns nil for a zero rate, which take treats as unlimited.
+func newTokenBucket(rate uint64) *tokenBucket {
+	if rate == 0 {
+		return nil
+	}
+	return &tokenBucket{
+		rate:     float64(rate),
+		capacity: float64(rate),
+		tokens:   float64(rate),
+		last:     time.Now(),
+		now:      time.Now,
+	}
+}
+
+// take consumes n tokens from the bucket and returns the time the caller must
+// wait for the consumption to be within the allowed rate.
+func (b *tokenBucket) take(n uint32) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := b.now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+	b.tokens -= float64(n)
+
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
@@ -0,0 +1,57 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newTokenBucket(1000)
+	b.now = func() time.Time { return now }
+	b.last = now
+
+	// A full bucket permits a burst of its capacity
+	if wait := b.take(1000); wait != 0 {
+		t.Fatalf("burst wait mismatch: have %v, want 0", wait)
+	}
+	// Going into debt requires waiting it out
+	if wait := b.take(500); wait != 500*time.Millisecond {
+		t.Fatalf("debt wait mismatch: have %v, want %v", wait, 500*time.Millisecond)
+	}
+	// Tokens refill with time, but never beyond capacity
+	now = now.Add(time.Second)
+	if wait := b.take(100); wait != 0 {
+		t.Fatalf("refilled wait mismatch: have %v, want 0", wait)
+	}
+	now = now.Add(time.Hour)
+	if wait := b.take(1500); wait != 500*time.Millisecond {
+		t.Fatalf("capped wait mismatch: have %v, want %v", wait, 500*time.Millisecond)
+	}
+}
+
+func TestTokenBucketUnlimited(t *testing.T) {
+	b := newTokenBucket(0)
+	if b != nil {
+		t.Fatalf("unlimited bucket should be nil")
+	}
+	if wait := b.take(1 << 20); wait != 0 {
+		t.Fatalf("unlimited wait mismatch: have %v, want 0", wait)
+	}
+}
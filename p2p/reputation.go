@@ -0,0 +1,174 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ethereumproject/go-ethereum/logger"
+	"github.com/ethereumproject/go-ethereum/logger/glog"
+	"github.com/ethereumproject/go-ethereum/p2p/discover"
+)
+
+const (
+	// Score at or below which a peer gets banned.
+	banThreshold = -100
+
+	// Time it takes for a score to decay to half of its value.
+	scoreHalfLife = 10 * time.Minute
+
+	// Duration of the first temporary ban of a peer. Every repeated
+	// offence doubles the duration until the ban becomes permanent.
+	tempBanDuration = time.Hour
+
+	// Number of temporary bans after which a peer is banned permanently.
+	maxTempBans = 4
+)
+
+// PeerEvent is an observation about the behaviour of a remote peer, reported
+// by the sub-protocols and fed into the reputation tracker.
+type PeerEvent int
+
+const (
+	PeerGoodDelivery    PeerEvent = iota // Peer delivered useful data
+	PeerUselessResponse                  // Peer answered with empty or unrequested data
+	PeerTimeout                          // Peer failed to answer a request in time
+	PeerProtocolBreach                   // Peer sent a malformed or unexpected message
+	PeerInvalidBlock                     // Peer sent a block failing validation
+)
+
+var peerEventToString = [...]string{
+	PeerGoodDelivery:    "good delivery",
+	PeerUselessResponse: "useless response",
+	PeerTimeout:         "timeout",
+	PeerProtocolBreach:  "protocol breach",
+	PeerInvalidBlock:    "invalid block",
+}
+
+// peerEventWeights are the score adjustments for each event.
+var peerEventWeights = [...]float64{
+	PeerGoodDelivery:    1,
+	PeerUselessResponse: -5,
+	PeerTimeout:         -10,
+	PeerProtocolBreach:  -25,
+	PeerInvalidBlock:    -50,
+}
+
+func (e PeerEvent) String() string {
+	if int(e) >= len(peerEventToString) {
+		return fmt.Sprintf("Unknown Event(%d)", e)
+	}
+	return peerEventToString[e]
+}
+
+// peerScore is the decaying reputation of a single node.
+type peerScore struct {
+	value   float64
+	updated time.Time
+}
+
+// decay brings the score closer to zero, based on the time elapsed since the
+// last update.
+func (s *peerScore) decay(now time.Time) {
+	elapsed := now.Sub(s.updated)
+	if elapsed > 0 {
+		s.value *= math.Pow(0.5, float64(elapsed)/float64(scoreHalfLife))
+	}
+	s.updated = now
+}
+
+// reputation tracks the scores of the nodes we've been connected to, and bans
+// those misbehaving repeatedly. Scores are kept in memory only; the resulting
+// bans are persisted in the node database.
+type reputation struct {
+	bans *discover.BanList
+
+	lock   sync.Mutex
+	scores map[discover.NodeID]*peerScore
+	now    func() time.Time // for testing
+}
+
+func newReputation(bans *discover.BanList) *reputation {
+	return &reputation{
+		bans:   bans,
+		scores: make(map[discover.NodeID]*peerScore),
+		now:    time.Now,
+	}
+}
+
+// report adjusts the score of a node according to the event. It returns
+// whether the node got banned as a result.
+func (r *reputation) report(id discover.NodeID, event PeerEvent) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.now()
+	score := r.scores[id]
+	if score == nil {
+		score = &peerScore{updated: now}
+		r.scores[id] = score
+	}
+	score.decay(now)
+	score.value += peerEventWeights[event]
+
+	// Forget nodes that have fully recovered to keep the map small
+	if math.Abs(score.value) < 0.5 {
+		delete(r.scores, id)
+	}
+	if score.value > banThreshold {
+		return false
+	}
+	delete(r.scores, id)
+
+	// Score dropped too low, ban the node (escalating repeated offences)
+	ban := &discover.Ban{ID: id, Reason: event.String()}
+	if prev := r.bans.Get(id, now); prev != nil {
+		ban.Count = prev.Count
+	}
+	ban.Count++
+	if ban.Count >= maxTempBans {
+		ban.Permanent = true
+	} else {
+		ban.Expires = now.Add(tempBanDuration << (ban.Count - 1))
+	}
+	if err := r.bans.Add(ban); err != nil {
+		glog.V(logger.Warn).Infof("failed to store ban of %x: %v", id[:8], err)
+	}
+	glog.V(logger.Info).Infof("banned %x (%v, offence #%d)", id[:8], event, ban.Count)
+	return true
+}
+
+// score returns the current (decayed) score of a node.
+func (r *reputation) score(id discover.NodeID) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	score := r.scores[id]
+	if score == nil {
+		return 0
+	}
+	score.decay(r.now())
+	return int(score.value)
+}
+
+// banned reports whether the node is currently banned.
+func (r *reputation) banned(id discover.NodeID) bool {
+	return r.bans.Banned(id, r.now())
+}
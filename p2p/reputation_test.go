@@ -0,0 +1,97 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereumproject/go-ethereum/p2p/discover"
+)
+
+func newTestReputation(t *testing.T) (*reputation, *time.Time) {
+	bans, err := discover.OpenBanList("", discover.NodeID{})
+	if err != nil {
+		t.Fatalf("failed to open ban list: %v", err)
+	}
+	rep := newReputation(bans)
+	now := time.Unix(1000000, 0)
+	rep.now = func() time.Time { return now }
+	return rep, &now
+}
+
+func TestReputationDecay(t *testing.T) {
+	rep, now := newTestReputation(t)
+	defer rep.bans.Close()
+
+	id := randomID()
+	rep.report(id, PeerInvalidBlock)
+	if score := rep.score(id); score != -50 {
+		t.Fatalf("score mismatch: have %d, want %d", score, -50)
+	}
+	*now = now.Add(scoreHalfLife)
+	if score := rep.score(id); score != -25 {
+		t.Fatalf("decayed score mismatch: have %d, want %d", score, -25)
+	}
+	// A decayed score takes more offences to reach the ban threshold
+	if rep.report(id, PeerInvalidBlock) {
+		t.Fatalf("banned with score above threshold")
+	}
+	if rep.banned(id) {
+		t.Fatalf("ban stored with score above threshold")
+	}
+}
+
+func TestReputationBanEscalation(t *testing.T) {
+	rep, now := newTestReputation(t)
+	defer rep.bans.Close()
+
+	id := randomID()
+	for i := 1; i <= maxTempBans; i++ {
+		if rep.report(id, PeerInvalidBlock) {
+			t.Fatalf("offence %d: banned after a single invalid block", i)
+		}
+		if !rep.report(id, PeerInvalidBlock) {
+			t.Fatalf("offence %d: not banned after reaching threshold", i)
+		}
+		if !rep.banned(id) {
+			t.Fatalf("offence %d: ban not stored", i)
+		}
+		ban := rep.bans.Get(id, *now)
+		if ban.Count != uint(i) {
+			t.Fatalf("offence %d: count mismatch: have %d", i, ban.Count)
+		}
+		if i == maxTempBans {
+			if !ban.Permanent {
+				t.Fatalf("offence %d: ban not permanent", i)
+			}
+			break
+		}
+		if want := now.Add(tempBanDuration << uint(i-1)); !ban.Expires.Equal(want) {
+			t.Fatalf("offence %d: expiry mismatch: have %v, want %v", i, ban.Expires, want)
+		}
+		// Wait out the ban and reoffend
+		*now = ban.Expires
+		if rep.banned(id) {
+			t.Fatalf("offence %d: ban not lifted after expiry", i)
+		}
+	}
+	*now = now.Add(365 * 24 * time.Hour)
+	if !rep.banned(id) {
+		t.Fatalf("permanent ban lifted")
+	}
+}
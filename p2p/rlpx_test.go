@@ -18,6 +18,7 @@ package p2p
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"crypto/rand"
 	"errors"
 	"fmt"
@@ -374,6 +375,123 @@ func TestRLPXFrameRW(t *testing.T) {
 	}
 }
 
+func TestRLPXFrameRWSnappy(t *testing.T) {
+	rw1, rw2 := newTestFramePair()
+	rw1.snappy, rw2.snappy = true, true
+
+	for i := 0; i < 10; i++ {
+		wmsg := []interface{}{"foo", "bar", strings.Repeat("test", i*100)}
+		if err := Send(rw1, uint64(i), wmsg); err != nil {
+			t.Fatalf("WriteMsg error (i=%d): %v", i, err)
+		}
+		msg, err := rw2.ReadMsg()
+		if err != nil {
+			t.Fatalf("ReadMsg error (i=%d): %v", i, err)
+		}
+		if msg.Code != uint64(i) {
+			t.Fatalf("msg code mismatch: got %d, want %d", msg.Code, i)
+		}
+		payload, _ := ioutil.ReadAll(msg.Payload)
+		wantPayload, _ := rlp.EncodeToBytes(wmsg)
+		if !bytes.Equal(payload, wantPayload) {
+			t.Fatalf("msg payload mismatch:\ngot  %x\nwant %x", payload, wantPayload)
+		}
+		if msg.Size != uint32(len(wantPayload)) {
+			t.Fatalf("msg size mismatch: got %d, want %d", msg.Size, len(wantPayload))
+		}
+	}
+}
+
+// This test checks that a compressed frame announcing a decompressed
+// length above the 24 bit limit is rejected before being inflated.
+func TestRLPXFrameSnappyBomb(t *testing.T) {
+	rw1, rw2 := newTestFramePair()
+	rw2.snappy = true
+
+	// Hand-craft a snappy block header claiming 16MB of output, which
+	// the writer side (without snappy) forwards verbatim.
+	bomb := []byte{0x80, 0x80, 0x80, 0x08}
+	if err := rw1.WriteMsg(Msg{Code: 1, Size: uint32(len(bomb)), Payload: bytes.NewReader(bomb)}); err != nil {
+		t.Fatalf("WriteMsg error: %v", err)
+	}
+	if _, err := rw2.ReadMsg(); err != errPlainMessageTooLarge {
+		t.Fatalf("ReadMsg error mismatch: got %v, want %v", err, errPlainMessageTooLarge)
+	}
+}
+
+func TestProtocolHandshakeSnappy(t *testing.T) {
+	tests := []struct {
+		ours, theirs uint64
+		snappy       bool
+	}{
+		{4, 4, false},
+		{4, 5, false},
+		{5, 4, false},
+		{5, 5, true},
+		{6, 5, true},
+	}
+	for i, tt := range tests {
+		prv0, _ := crypto.GenerateKey()
+		prv1, _ := crypto.GenerateKey()
+		node1 := &discover.Node{ID: discover.PubkeyID(&prv1.PublicKey), IP: net.IP{5, 6, 7, 8}, TCP: 44}
+		fd0, fd1 := net.Pipe()
+
+		var (
+			wg     sync.WaitGroup
+			result [2]bool
+		)
+		run := func(idx int, fd net.Conn, prv *ecdsa.PrivateKey, dial *discover.Node, version uint64) {
+			defer wg.Done()
+			rlpx := newRLPX(fd).(*rlpx)
+			if _, err := rlpx.doEncHandshake(prv, dial); err != nil {
+				t.Errorf("test %d: enc handshake failed: %v", i, err)
+				fd.Close()
+				return
+			}
+			hs := &protoHandshake{Version: version, ID: discover.PubkeyID(&prv.PublicKey)}
+			if _, err := rlpx.doProtoHandshake(hs); err != nil {
+				t.Errorf("test %d: proto handshake failed: %v", i, err)
+				fd.Close()
+				return
+			}
+			result[idx] = rlpx.rw.snappy
+		}
+		wg.Add(2)
+		go run(0, fd0, prv0, node1, tt.ours)
+		go run(1, fd1, prv1, nil, tt.theirs)
+		wg.Wait()
+		fd0.Close()
+		fd1.Close()
+
+		if result[0] != tt.snappy || result[1] != tt.snappy {
+			t.Errorf("test %d: snappy mismatch: got %v, want %v", i, result, tt.snappy)
+		}
+	}
+}
+
+func newTestFramePair() (*rlpxFrameRW, *rlpxFrameRW) {
+	var (
+		aesSecret      = make([]byte, 16)
+		macSecret      = make([]byte, 16)
+		egressMACinit  = make([]byte, 32)
+		ingressMACinit = make([]byte, 32)
+	)
+	for _, s := range [][]byte{aesSecret, macSecret, egressMACinit, ingressMACinit} {
+		rand.Read(s)
+	}
+	conn := new(bytes.Buffer)
+
+	s1 := secrets{AES: aesSecret, MAC: macSecret, EgressMAC: sha3.NewKeccak256(), IngressMAC: sha3.NewKeccak256()}
+	s1.EgressMAC.Write(egressMACinit)
+	s1.IngressMAC.Write(ingressMACinit)
+
+	s2 := secrets{AES: aesSecret, MAC: macSecret, EgressMAC: sha3.NewKeccak256(), IngressMAC: sha3.NewKeccak256()}
+	s2.EgressMAC.Write(ingressMACinit)
+	s2.IngressMAC.Write(egressMACinit)
+
+	return newRLPXFrameRW(conn, s1), newRLPXFrameRW(conn, s2)
+}
+
 type handshakeAuthTest struct {
 	input       string
 	isPlain     bool
@@ -66,6 +66,14 @@ type Config struct {
 	// Zero defaults to preset values.
 	MaxPendingPeers int
 
+	// MaxPeerIngress is the maximum rate in bytes per second at which messages
+	// are accepted from a single peer. Zero means no limit.
+	MaxPeerIngress uint64
+
+	// MaxIngress is the maximum rate in bytes per second at which messages are
+	// accepted from all peers combined. Zero means no limit.
+	MaxIngress uint64
+
 	// Discovery specifies whether the peer discovery mechanism should be started
 	// or not. Disabling is usually useful for protocol debugging (manual topology).
 	Discovery bool
@@ -129,6 +137,9 @@ type Server struct {
 	running bool
 
 	ntab         discoverTable
+	bans         *discover.BanList
+	rep          *reputation
+	ingress      *tokenBucket // Global ingress limiter, nil if unlimited
 	listener     net.Listener
 	ourHandshake *protoHandshake
 	lastLookup   time.Time
@@ -296,6 +307,9 @@ func (srv *Server) Stop() {
 	}
 	close(srv.quit)
 	srv.loopWG.Wait()
+	if srv.bans != nil {
+		srv.bans.Close()
+	}
 }
 
 // Start starts running the server.
@@ -337,7 +351,16 @@ func (srv *Server) Start() (err error) {
 			return err
 		}
 		srv.ntab = ntab
+		srv.bans = ntab.BanList()
+	} else {
+		bans, err := discover.OpenBanList(srv.NodeDatabase, discover.PubkeyID(&srv.PrivateKey.PublicKey))
+		if err != nil {
+			return err
+		}
+		srv.bans = bans
 	}
+	srv.rep = newReputation(srv.bans)
+	srv.ingress = newTokenBucket(srv.MaxIngress)
 
 	dynPeers := (srv.MaxPeers + 1) / 2
 	if !srv.Discovery {
@@ -486,6 +509,8 @@ running:
 			} else {
 				// The handshakes are done and it passed all checks.
 				p := newPeer(c, srv.Protocols)
+				p.rep = srv.rep
+				p.limiters = []*tokenBucket{newTokenBucket(srv.MaxPeerIngress), srv.ingress}
 				peers[c.id] = p
 				go srv.runPeer(p)
 			}
@@ -531,6 +556,8 @@ func (srv *Server) protoHandshakeChecks(peers map[discover.NodeID]*Peer, c *conn
 
 func (srv *Server) encHandshakeChecks(peers map[discover.NodeID]*Peer, c *conn) error {
 	switch {
+	case !c.is(trustedConn) && srv.isBanned(c.id):
+		return DiscUselessPeer
 	case !c.is(trustedConn|staticDialedConn) && len(peers) >= srv.MaxPeers:
 		return DiscTooManyPeers
 	case peers[c.id] != nil:
@@ -542,6 +569,11 @@ func (srv *Server) encHandshakeChecks(peers map[discover.NodeID]*Peer, c *conn)
 	}
 }
 
+// isBanned reports whether the node is not allowed to connect.
+func (srv *Server) isBanned(id discover.NodeID) bool {
+	return srv.rep != nil && srv.rep.banned(id)
+}
+
 type tempError interface {
 	Temporary() bool
 }
@@ -753,3 +785,82 @@ func (srv *Server) PeersInfo() []*PeerInfo {
 	}
 	return infos
 }
+
+// BanInfo represents a short summary of the information known about a banned
+// node.
+type BanInfo struct {
+	ID        string     `json:"id"`                // Unique node identifier (also the encryption key)
+	Expires   *time.Time `json:"expires,omitempty"` // Time at which a temporary ban is lifted
+	Permanent bool       `json:"permanent"`         // Whether the ban is only lifted by removal
+	Offences  uint       `json:"offences"`          // Number of temporary bans issued so far
+	Reason    string     `json:"reason"`            // Cause of the (last) ban
+}
+
+// Bans returns an array of metadata objects describing the active bans.
+func (srv *Server) Bans() ([]*BanInfo, error) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if !srv.running {
+		return nil, errServerStopped
+	}
+	infos := make([]*BanInfo, 0)
+	for _, ban := range srv.bans.List(time.Now()) {
+		info := &BanInfo{
+			ID:        ban.ID.String(),
+			Permanent: ban.Permanent,
+			Offences:  ban.Count,
+			Reason:    ban.Reason,
+		}
+		if !ban.Permanent {
+			expires := ban.Expires
+			info.Expires = &expires
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// BanPeer prevents the given node from connecting for the given duration, or
+// permanently if the duration is zero. If the node is currently connected, it
+// is disconnected.
+func (srv *Server) BanPeer(id discover.NodeID, duration time.Duration, reason string) error {
+	srv.lock.Lock()
+	if !srv.running {
+		srv.lock.Unlock()
+		return errServerStopped
+	}
+	now := time.Now()
+	ban := &discover.Ban{ID: id, Reason: reason, Permanent: duration == 0}
+	if prev := srv.bans.Get(id, now); prev != nil {
+		ban.Count = prev.Count
+	}
+	if !ban.Permanent {
+		ban.Count++
+		ban.Expires = now.Add(duration)
+	}
+	err := srv.bans.Add(ban)
+	srv.lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+	for _, p := range srv.Peers() {
+		if p.ID() == id {
+			p.Disconnect(DiscUselessPeer)
+		}
+	}
+	return nil
+}
+
+// UnbanPeer lifts the ban of the given node, also forgetting about any of its
+// previous offences.
+func (srv *Server) UnbanPeer(id discover.NodeID) error {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if !srv.running {
+		return errServerStopped
+	}
+	return srv.bans.Remove(id)
+}
@@ -358,6 +358,58 @@ func TestServerAtCap(t *testing.T) {
 
 }
 
+func TestServerBans(t *testing.T) {
+	trustedID := randomID()
+	srv := &Server{
+		Config: Config{
+			PrivateKey:   newkey(),
+			MaxPeers:     10,
+			NoDial:       true,
+			TrustedNodes: []*discover.Node{{ID: trustedID}},
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	newconn := func(id discover.NodeID) *conn {
+		fd, _ := net.Pipe()
+		tx := newTestTransport(id, fd)
+		return &conn{fd: fd, transport: tx, flags: inboundConn, id: id, cont: make(chan error)}
+	}
+	bannedID := randomID()
+	for _, id := range []discover.NodeID{bannedID, trustedID} {
+		if err := srv.BanPeer(id, time.Hour, "test"); err != nil {
+			t.Fatalf("failed to ban %x: %v", id[:8], err)
+		}
+	}
+	if bans, _ := srv.Bans(); len(bans) != 2 {
+		t.Fatalf("ban count mismatch: have %d, want %d", len(bans), 2)
+	}
+	// Repeated temporary bans are counted as further offences
+	if err := srv.BanPeer(bannedID, time.Hour, "again"); err != nil {
+		t.Fatalf("failed to re-ban %x: %v", bannedID[:8], err)
+	}
+	if ban := srv.bans.Get(bannedID, time.Now()); ban == nil || ban.Count != 2 || ban.Reason != "again" {
+		t.Fatalf("re-ban mismatch: %v", ban)
+	}
+	// Banned connections are rejected, unless trusted.
+	if err := srv.checkpoint(newconn(bannedID), srv.posthandshake); err != DiscUselessPeer {
+		t.Error("wrong error for banned conn:", err)
+	}
+	if err := srv.checkpoint(newconn(trustedID), srv.posthandshake); err != nil {
+		t.Error("unexpected error for banned trusted conn:", err)
+	}
+	// Lifting the ban lets the node connect again.
+	if err := srv.UnbanPeer(bannedID); err != nil {
+		t.Fatalf("failed to unban: %v", err)
+	}
+	if err := srv.checkpoint(newconn(bannedID), srv.posthandshake); err != nil {
+		t.Error("unexpected error for unbanned conn:", err)
+	}
+}
+
 func TestServerSetupConn(t *testing.T) {
 	id := randomID()
 	srvkey := newkey()
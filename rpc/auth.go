@@ -0,0 +1,308 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereumproject/go-ethereum/logger"
+	"github.com/ethereumproject/go-ethereum/logger/glog"
+)
+
+var (
+	errAuthMissingToken = errors.New("missing bearer token")
+	errAuthInvalidToken = errors.New("invalid bearer token")
+	errAuthExpiredToken = errors.New("expired bearer token")
+)
+
+// AuthConfig is the content of an RPC authentication file. Unauthenticated
+// requests may only call the Public methods; requests presenting a valid
+// bearer token may additionally call the methods allowed by its credential.
+//
+// Allow-list entries are either a namespace ("eth"), a fully qualified method
+// ("admin_peers") or the wildcard "*".
+type AuthConfig struct {
+	Public      []string         `json:"public"`      // Methods open to unauthenticated requests
+	JWTSecret   string           `json:"jwtSecret"`   // Hex encoded HS256 key to verify JWT tokens with
+	AuditLog    string           `json:"auditLog"`    // File to append privileged calls to (empty = log only)
+	Credentials []AuthCredential `json:"credentials"` // Known credentials and their permissions
+}
+
+// AuthCredential is a named set of permissions, granted to requests presenting
+// either the static token, or a JWT whose subject is the credential's name.
+type AuthCredential struct {
+	Name  string   `json:"name"`
+	Token string   `json:"token"`
+	Allow []string `json:"allow"`
+}
+
+// LoadAuthConfig reads an RPC authentication file.
+func LoadAuthConfig(path string) (*AuthConfig, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := new(AuthConfig)
+	if err := json.Unmarshal(blob, config); err != nil {
+		return nil, fmt.Errorf("invalid auth file %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// permissions is an allow-list of RPC methods.
+type permissions []string
+
+// allowed checks whether the method is matched by any entry of the allow-list.
+func (p permissions) allowed(service, method string) bool {
+	for _, entry := range p {
+		if entry == "*" || entry == service || entry == service+serviceMethodSeparator+method {
+			return true
+		}
+	}
+	return false
+}
+
+// authInfo is the identity of the client issuing a request, stored in the
+// request context by the transports enforcing authentication.
+type authInfo struct {
+	auth       *Authenticator
+	credential string      // Name of the credential presented, empty for anonymous clients
+	allow      permissions // Methods allowed beyond the public ones
+	remote     string      // Remote address of the client
+}
+
+type authInfoKey struct{}
+
+// Authenticator verifies the bearer tokens of HTTP and websocket clients and
+// checks the methods they call against the permissions of their credential.
+type Authenticator struct {
+	public  permissions
+	secret  []byte
+	tokens  map[string]*AuthCredential
+	byName  map[string]*AuthCredential
+	auditMu sync.Mutex
+	audit   io.WriteCloser
+}
+
+// NewAuthenticator creates an authenticator from the given configuration,
+// opening the audit log if one is configured.
+func NewAuthenticator(config *AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{
+		public: permissions(config.Public),
+		tokens: make(map[string]*AuthCredential),
+		byName: make(map[string]*AuthCredential),
+	}
+	if config.JWTSecret != "" {
+		secret, err := hex.DecodeString(strings.TrimPrefix(config.JWTSecret, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT secret: %v", err)
+		}
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("JWT secret too short: %d bytes, want at least 32", len(secret))
+		}
+		a.secret = secret
+	}
+	for i := range config.Credentials {
+		cred := &config.Credentials[i]
+		if cred.Name == "" {
+			return nil, fmt.Errorf("credential #%d has no name", i)
+		}
+		if _, ok := a.byName[cred.Name]; ok {
+			return nil, fmt.Errorf("duplicate credential %q", cred.Name)
+		}
+		a.byName[cred.Name] = cred
+		if cred.Token != "" {
+			if _, ok := a.tokens[cred.Token]; ok {
+				return nil, fmt.Errorf("credential %q reuses the token of another one", cred.Name)
+			}
+			a.tokens[cred.Token] = cred
+		}
+	}
+	if config.AuditLog != "" {
+		file, err := os.OpenFile(config.AuditLog, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, err
+		}
+		a.audit = file
+	}
+	return a, nil
+}
+
+// Close releases the audit log of the authenticator.
+func (a *Authenticator) Close() error {
+	a.auditMu.Lock()
+	defer a.auditMu.Unlock()
+
+	if a.audit == nil {
+		return nil
+	}
+	err := a.audit.Close()
+	a.audit = nil
+	return err
+}
+
+// authenticate resolves the credential of the bearer token in the request's
+// Authorization header. Requests without one are anonymous, limited to the
+// public methods.
+func (a *Authenticator) authenticate(r *http.Request) (*authInfo, error) {
+	info := &authInfo{auth: a, remote: r.RemoteAddr}
+
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return info, nil
+	}
+	if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+		return nil, errAuthMissingToken
+	}
+	token := strings.TrimSpace(header[7:])
+
+	cred, err := a.credential(token)
+	if err != nil {
+		return nil, err
+	}
+	info.credential, info.allow = cred.Name, permissions(cred.Allow)
+	return info, nil
+}
+
+// credential looks up the credential of a static token or a signed JWT.
+func (a *Authenticator) credential(token string) (*AuthCredential, error) {
+	for known, cred := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(token)) == 1 {
+			return cred, nil
+		}
+	}
+	if a.secret == nil || strings.Count(token, ".") != 2 {
+		return nil, errAuthInvalidToken
+	}
+	subject, err := verifyJWT(a.secret, token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	cred, ok := a.byName[subject]
+	if !ok {
+		return nil, errAuthInvalidToken
+	}
+	return cred, nil
+}
+
+// jwtHeader and jwtClaims are the fields of a JWT checked by the authenticator.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+type jwtClaims struct {
+	Sub string `json:"sub"`
+	Exp int64  `json:"exp"`
+}
+
+// verifyJWT checks the HS256 signature and expiry of a JWT, returning its subject.
+func verifyJWT(secret []byte, token string, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errAuthInvalidToken
+	}
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return "", errAuthInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errAuthInvalidToken
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", errAuthInvalidToken
+	}
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return "", errAuthInvalidToken
+	}
+	if claims.Exp != 0 && now.Unix() >= claims.Exp {
+		return "", errAuthExpiredToken
+	}
+	return claims.Sub, nil
+}
+
+// decodeJWTPart decodes a base64url encoded JSON segment of a JWT.
+func decodeJWTPart(part string, v interface{}) error {
+	blob, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(blob, v)
+}
+
+// authorize checks whether the client may call the given method, recording the
+// calls beyond the public methods in the audit log.
+func (info *authInfo) authorize(service, method string) bool {
+	if info.auth.public.allowed(service, method) {
+		return true
+	}
+	granted := info.allow.allowed(service, method)
+	info.auth.record(info, service+serviceMethodSeparator+method, granted)
+	return granted
+}
+
+// record writes a privileged call attempt into the audit log.
+func (a *Authenticator) record(info *authInfo, method string, granted bool) {
+	credential := info.credential
+	if credential == "" {
+		credential = "<anonymous>"
+	}
+	glog.V(logger.Info).Infof("RPC audit: %s (%s) calling %s, granted: %v", credential, info.remote, method, granted)
+
+	a.auditMu.Lock()
+	defer a.auditMu.Unlock()
+
+	if a.audit == nil {
+		return
+	}
+	entry, _ := json.Marshal(struct {
+		Time       time.Time `json:"time"`
+		Credential string    `json:"credential"`
+		Remote     string    `json:"remote"`
+		Method     string    `json:"method"`
+		Granted    bool      `json:"granted"`
+	}{time.Now().UTC(), info.credential, info.remote, method, granted})
+
+	if _, err := a.audit.Write(append(entry, '\n')); err != nil {
+		glog.V(logger.Error).Infof("Failed to write RPC audit log: %v", err)
+	}
+}
+
+// authInfoFromContext retrieves the client identity of an authenticated
+// transport, if any.
+func authInfoFromContext(ctx context.Context) (*authInfo, bool) {
+	info, ok := ctx.Value(authInfoKey{}).(*authInfo)
+	return info, ok
+}
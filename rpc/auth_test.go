@@ -0,0 +1,176 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testJWTSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// signTestJWT creates an HS256 JWT with the given claims.
+func signTestJWT(secret []byte, claims string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(header + "." + payload))
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// callHTTP issues a JSON-RPC request with the given bearer token, returning the
+// HTTP status and the JSON-RPC error code (zero on success).
+func callHTTP(t *testing.T, url, token, method string) (int, int) {
+	body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"%s","params":[]}`, method)
+	req, _ := http.NewRequest("POST", url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s: request failed: %v", method, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return res.StatusCode, 0
+	}
+	var reply struct {
+		Error *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&reply); err != nil {
+		t.Fatalf("%s: invalid reply: %v", method, err)
+	}
+	if reply.Error != nil {
+		return res.StatusCode, reply.Error.Code
+	}
+	return res.StatusCode, 0
+}
+
+func TestHTTPAuthentication(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpc-auth-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := &AuthConfig{
+		Public:    []string{"pub"},
+		JWTSecret: fmt.Sprintf("%x", testJWTSecret),
+		AuditLog:  filepath.Join(dir, "audit.log"),
+		Credentials: []AuthCredential{
+			{Name: "ops", Token: "static-token", Allow: []string{"admin"}},
+			{Name: "monitor", Allow: []string{"admin_rets"}},
+		},
+	}
+	auth, err := NewAuthenticator(config)
+	if err != nil {
+		t.Fatalf("failed to create authenticator: %v", err)
+	}
+	defer auth.Close()
+
+	server := NewServer()
+	for _, namespace := range []string{"pub", "admin"} {
+		if err := server.RegisterName(namespace, new(Service)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	httpsrv := httptest.NewServer(NewHTTPServer("*", nil, auth, nil, nil, server).Handler)
+	defer httpsrv.Close()
+
+	valid := signTestJWT(testJWTSecret, `{"sub":"monitor"}`)
+	expired := signTestJWT(testJWTSecret, fmt.Sprintf(`{"sub":"monitor","exp":%d}`, time.Now().Add(-time.Minute).Unix()))
+	forged := signTestJWT([]byte("fedcba9876543210fedcba9876543210"), `{"sub":"ops"}`)
+
+	tests := []struct {
+		token  string
+		method string
+		status int
+		code   int
+	}{
+		{"", "pub_rets", http.StatusOK, 0},
+		{"", "admin_rets", http.StatusOK, -32001},
+		{"static-token", "pub_rets", http.StatusOK, 0},
+		{"static-token", "admin_rets", http.StatusOK, 0},
+		{"static-token", "admin_noArgsRets", http.StatusOK, 0},
+		{valid, "admin_rets", http.StatusOK, 0},
+		{valid, "admin_noArgsRets", http.StatusOK, -32001},
+		{expired, "pub_rets", http.StatusUnauthorized, 0},
+		{forged, "pub_rets", http.StatusUnauthorized, 0},
+		{"unknown-token", "pub_rets", http.StatusUnauthorized, 0},
+	}
+	for i, tt := range tests {
+		status, code := callHTTP(t, httpsrv.URL, tt.token, tt.method)
+		if status != tt.status || code != tt.code {
+			t.Errorf("test %d (%s): status/code mismatch: have %d/%d, want %d/%d", i, tt.method, status, code, tt.status, tt.code)
+		}
+	}
+	// Ensure all privileged calls were audited, but none of the public ones
+	file, err := os.Open(config.AuditLog)
+	if err != nil {
+		t.Fatalf("failed to open audit log: %v", err)
+	}
+	defer file.Close()
+
+	want := []string{"/admin_rets/false", "ops/admin_rets/true", "ops/admin_noArgsRets/true", "monitor/admin_rets/true", "monitor/admin_noArgsRets/false"}
+	var have []string
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		var entry struct {
+			Credential string
+			Method     string
+			Granted    bool
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid audit entry %q: %v", scanner.Text(), err)
+		}
+		have = append(have, fmt.Sprintf("%s/%s/%v", entry.Credential, entry.Method, entry.Granted))
+	}
+	if strings.Join(have, ",") != strings.Join(want, ",") {
+		t.Errorf("audit log mismatch:\nhave %v\nwant %v", have, want)
+	}
+}
+
+func TestAuthConfigValidation(t *testing.T) {
+	tests := []*AuthConfig{
+		{JWTSecret: "not hex"},
+		{JWTSecret: "0x0123"},
+		{Credentials: []AuthCredential{{Token: "nameless"}}},
+		{Credentials: []AuthCredential{{Name: "a"}, {Name: "a"}}},
+		{Credentials: []AuthCredential{{Name: "a", Token: "t"}, {Name: "b", Token: "t"}}},
+	}
+	for i, config := range tests {
+		if _, err := NewAuthenticator(config); err == nil {
+			t.Errorf("test %d: invalid config accepted", i)
+		}
+	}
+}
@@ -16,7 +16,10 @@
 
 package rpc
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // request is for an unknown service
 type methodNotFoundError struct {
@@ -95,3 +98,45 @@ func (e *shutdownError) Code() int {
 func (e *shutdownError) Error() string {
 	return "server is shutting down"
 }
+
+// client isn't allowed to call the requested method
+type permissionDeniedError struct {
+	service string
+	method  string
+}
+
+func (e *permissionDeniedError) Code() int {
+	return -32001
+}
+
+func (e *permissionDeniedError) Error() string {
+	return fmt.Sprintf("permission denied for %s%s%s", e.service, serviceMethodSeparator, e.method)
+}
+
+// request exceeded a resource limit of the server
+type limitExceededError struct {
+	message string
+}
+
+func (e *limitExceededError) Code() int {
+	return -32005
+}
+
+func (e *limitExceededError) Error() string {
+	return e.message
+}
+
+// method execution exceeded its time limit
+type timeoutError struct {
+	service string
+	method  string
+	timeout time.Duration
+}
+
+func (e *timeoutError) Code() int {
+	return -32002
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("%s%s%s timed out after %v", e.service, serviceMethodSeparator, e.method, e.timeout)
+}
@@ -0,0 +1,68 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	healthPath = "/health"
+	readyPath  = "/ready"
+)
+
+// Health is the state of a node reported by the HTTP health endpoints.
+type Health struct {
+	Syncing      bool     `json:"syncing"`
+	CurrentBlock uint64   `json:"currentBlock"`
+	HighestBlock uint64   `json:"highestBlock"`
+	Peers        int      `json:"peers"`
+	HeadAge      uint64   `json:"headAge"`            // Seconds since the timestamp of the head block
+	Ready        bool     `json:"ready"`              // Whether the node is fit to serve requests
+	Problems     []string `json:"problems,omitempty"` // Reasons why the node is not ready
+}
+
+// HealthCheck gathers the current health of a node.
+type HealthCheck func() *Health
+
+// newHealthHandler serves the health of a node on the health and ready paths,
+// passing every other request to next.
+//
+// The health endpoint always answers 200 OK while the server is up, and can be
+// used as a liveness probe. The ready endpoint answers 503 Service Unavailable
+// if the node is not ready, so load balancers can route around it.
+func newHealthHandler(check HealthCheck, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != healthPath && r.URL.Path != readyPath {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.Method != "GET" && r.Method != "HEAD" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		health := check()
+
+		w.Header().Set("content-type", "application/json")
+		w.Header().Set("cache-control", "no-cache")
+		if r.URL.Path == readyPath && !health.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(health)
+	})
+}
@@ -18,12 +18,17 @@ package rpc
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/rs/cors"
 )
@@ -91,7 +96,9 @@ func (t *httpReadWriteNopCloser) Close() error {
 
 // newJSONHTTPHandler creates a HTTP handler that will parse incoming JSON requests,
 // send the request to the given API provider and sends the response back to the caller.
-func newJSONHTTPHandler(srv *Server) http.HandlerFunc {
+// If an authenticator is given, the methods callable are limited by the bearer token
+// presented by the caller.
+func newJSONHTTPHandler(srv *Server, auth *Authenticator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.ContentLength > maxHTTPRequestContentLength {
 			http.Error(w,
@@ -99,6 +106,16 @@ func newJSONHTTPHandler(srv *Server) http.HandlerFunc {
 				http.StatusRequestEntityTooLarge)
 			return
 		}
+		ctx := context.Background()
+		if auth != nil {
+			info, err := auth.authenticate(r)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			ctx = context.WithValue(ctx, authInfoKey{}, info)
+		}
 
 		w.Header().Set("content-type", "application/json")
 
@@ -107,12 +124,155 @@ func newJSONHTTPHandler(srv *Server) http.HandlerFunc {
 		// a single request.
 		codec := NewJSONCodec(&httpReadWriteNopCloser{r.Body, w})
 		defer codec.Close()
-		srv.ServeSingleRequest(codec, OptionMethodInvocation)
+		srv.serveRequest(ctx, codec, true, OptionMethodInvocation)
+	}
+}
+
+// virtualHostHandler rejects requests whose Host header isn't allow-listed,
+// protecting the endpoint from DNS rebinding attacks. Requests addressing the
+// server by IP are always accepted, as they can't be the result of rebinding.
+type virtualHostHandler struct {
+	vhosts map[string]struct{}
+	next   http.Handler
+}
+
+// newVirtualHostHandler creates a host filter around next. A "*" entry allows
+// any host.
+func newVirtualHostHandler(vhosts []string, next http.Handler) http.Handler {
+	h := &virtualHostHandler{vhosts: make(map[string]struct{}), next: next}
+	for _, host := range vhosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host == "*" {
+			return next
+		} else if host != "" {
+			h.vhosts[host] = struct{}{}
+		}
 	}
+	return h
+}
+
+// ServeHTTP implements http.Handler, passing the request on if the host is allowed.
+func (h *virtualHostHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// HTTP/1.0 requests may omit the host altogether
+	if r.Host == "" {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host // no port in the header
+	}
+	if net.ParseIP(host) != nil {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	if _, ok := h.vhosts[strings.ToLower(host)]; ok {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	http.Error(w, "invalid host specified", http.StatusForbidden)
+}
+
+// gzipWriterPool recycles the compressors of gzipped responses.
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(ioutil.Discard) },
+}
+
+// gzipResponseWriter compresses the body written to a response.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+}
+
+// WriteHeader drops any content length set, as it doesn't match the compressed body.
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	w.Header().Del("content-length")
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write compresses p into the response body.
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	return w.gz.Write(p)
+}
+
+// newGzipHandler compresses the responses of next for clients accepting gzip.
+func newGzipHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("vary", "Accept-Encoding")
+		if !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("content-encoding", "gzip")
+
+		gz := gzipWriterPool.Get().(*gzip.Writer)
+		defer gzipWriterPool.Put(gz)
+
+		gz.Reset(w)
+		defer gz.Close()
+
+		next.ServeHTTP(&gzipResponseWriter{ResponseWriter: w, gz: gz}, r)
+	})
+}
+
+// acceptsGzip checks whether the Accept-Encoding header of a request allows
+// gzip compressed responses.
+func acceptsGzip(r *http.Request) bool {
+	for _, header := range r.Header["Accept-Encoding"] {
+		for _, coding := range strings.Split(header, ",") {
+			fields := strings.Split(coding, ";")
+			if strings.TrimSpace(fields[0]) != "gzip" {
+				continue
+			}
+			// an explicit zero quality value refuses the encoding
+			for _, param := range fields[1:] {
+				param = strings.Replace(param, " ", "", -1)
+				if strings.HasPrefix(param, "q=") {
+					if q, err := strconv.ParseFloat(param[2:], 64); err == nil && q == 0 {
+						return false
+					}
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// newMountHandler serves the requests of the mounted paths with their own
+// handlers, passing every other request to next. If an authenticator is given,
+// a mounted handler is treated as a namespace named after its path ("/graphql"
+// is "graphql"), which the client must be allowed to call.
+func newMountHandler(handlers map[string]http.Handler, auth *Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := handlers[r.URL.Path]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if auth != nil {
+			info, err := auth.authenticate(r)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			if !info.authorize(strings.Trim(r.URL.Path, "/"), "query") {
+				http.Error(w, "permission denied", http.StatusForbidden)
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
 }
 
 // NewHTTPServer creates a new HTTP RPC server around an API provider.
-func NewHTTPServer(corsString string, srv *Server) *http.Server {
+//
+// Requests are only accepted with a Host header listed in vhosts (nil allows
+// all). A nil authenticator allows every client to call all the methods of the
+// provider. If a health check is given, the node's health is additionally
+// served on the /health and /ready paths. Requests for the paths in handlers
+// are served by the mapped handlers instead of the API provider.
+func NewHTTPServer(corsString string, vhosts []string, auth *Authenticator, health HealthCheck, handlers map[string]http.Handler, srv *Server) *http.Server {
 	var allowedOrigins []string
 	for _, domain := range strings.Split(corsString, ",") {
 		allowedOrigins = append(allowedOrigins, strings.TrimSpace(domain))
@@ -121,10 +281,20 @@ func NewHTTPServer(corsString string, srv *Server) *http.Server {
 	c := cors.New(cors.Options{
 		AllowedOrigins: allowedOrigins,
 		AllowedMethods: []string{"POST", "GET"},
+		AllowedHeaders: []string{"Accept", "Content-Type", "Authorization"},
 	})
 
-	handler := c.Handler(newJSONHTTPHandler(srv))
-
+	var handler http.Handler = newJSONHTTPHandler(srv, auth)
+	if len(handlers) > 0 {
+		handler = newMountHandler(handlers, auth, handler)
+	}
+	if health != nil {
+		handler = newHealthHandler(health, handler)
+	}
+	handler = newGzipHandler(c.Handler(handler))
+	if vhosts != nil {
+		handler = newVirtualHostHandler(vhosts, handler)
+	}
 	return &http.Server{
 		Handler: handler,
 	}
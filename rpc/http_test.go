@@ -0,0 +1,207 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testHTTPRequest = `{"jsonrpc":"2.0","id":1,"method":"test_rets","params":[]}`
+
+func newTestHTTPServer(t *testing.T, vhosts []string, health HealthCheck) *httptest.Server {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(NewHTTPServer("*", vhosts, nil, health, nil, server).Handler)
+}
+
+func TestHTTPVirtualHosts(t *testing.T) {
+	srv := newTestHTTPServer(t, []string{"localhost", "Node.Example.org"}, nil)
+	defer srv.Close()
+
+	tests := []struct {
+		host   string
+		status int
+	}{
+		{"localhost", http.StatusOK},
+		{"localhost:8545", http.StatusOK},
+		{"node.example.org:8545", http.StatusOK},
+		{"127.0.0.1:8545", http.StatusOK},
+		{"[::1]:8545", http.StatusOK},
+		{"evil.example.org", http.StatusForbidden},
+		{"evil.example.org:8545", http.StatusForbidden},
+	}
+	for i, tt := range tests {
+		req, _ := http.NewRequest("POST", srv.URL, strings.NewReader(testHTTPRequest))
+		req.Host = tt.host
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("test %d: request failed: %v", i, err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.status {
+			t.Errorf("test %d (%s): status mismatch: have %d, want %d", i, tt.host, res.StatusCode, tt.status)
+		}
+	}
+}
+
+func TestHTTPGzip(t *testing.T) {
+	srv := newTestHTTPServer(t, nil, nil)
+	defer srv.Close()
+
+	// Use a raw transport, the default one transparently decompresses
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+	tests := []struct {
+		accept string
+		gzip   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, gzip;q=0.8", true},
+		{"gzip;q=0", false},
+		{"identity", false},
+	}
+	for i, tt := range tests {
+		req, _ := http.NewRequest("POST", srv.URL, strings.NewReader(testHTTPRequest))
+		if tt.accept != "" {
+			req.Header.Set("Accept-Encoding", tt.accept)
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("test %d: request failed: %v", i, err)
+		}
+		body := res.Body
+		if compressed := res.Header.Get("Content-Encoding") == "gzip"; compressed != tt.gzip {
+			t.Errorf("test %d: compression mismatch: have %v, want %v", i, compressed, tt.gzip)
+		} else if compressed {
+			if body, err = gzip.NewReader(res.Body); err != nil {
+				t.Fatalf("test %d: invalid gzip stream: %v", i, err)
+			}
+		}
+		blob, err := ioutil.ReadAll(body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("test %d: failed to read body: %v", i, err)
+		}
+		var reply JSONSuccessResponse
+		if err := json.Unmarshal(blob, &reply); err != nil || reply.Id == nil {
+			t.Errorf("test %d: invalid reply %q: %v", i, blob, err)
+		}
+	}
+}
+
+func TestHTTPHealth(t *testing.T) {
+	health := &Health{Peers: 0, Problems: []string{"no peers"}}
+	srv := newTestHTTPServer(t, nil, func() *Health { return health })
+	defer srv.Close()
+
+	check := func(path string, status int, ready bool) {
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", path, err)
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode != status {
+			t.Errorf("%s: status mismatch: have %d, want %d", path, res.StatusCode, status)
+		}
+		var reply Health
+		if err := json.NewDecoder(res.Body).Decode(&reply); err != nil {
+			t.Fatalf("%s: invalid reply: %v", path, err)
+		}
+		if reply.Ready != ready {
+			t.Errorf("%s: readiness mismatch: have %v, want %v", path, reply.Ready, ready)
+		}
+	}
+	check("/health", http.StatusOK, false)
+	check("/ready", http.StatusServiceUnavailable, false)
+
+	health = &Health{Peers: 5, CurrentBlock: 100, HighestBlock: 100, Ready: true}
+	check("/health", http.StatusOK, true)
+	check("/ready", http.StatusOK, true)
+
+	// Other paths are still served as RPC
+	res, err := http.Post(srv.URL+"/", "application/json", strings.NewReader(testHTTPRequest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("RPC status mismatch: have %d, want %d", res.StatusCode, http.StatusOK)
+	}
+}
+
+func TestHTTPMountedHandlers(t *testing.T) {
+	auth, err := NewAuthenticator(&AuthConfig{
+		Public:      []string{"test"},
+		Credentials: []AuthCredential{{Name: "dashboard", Token: "secret", Allow: []string{"graphql"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auth.Close()
+
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	handlers := map[string]http.Handler{
+		"/graphql": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("mounted")) }),
+	}
+	srv := httptest.NewServer(NewHTTPServer("*", nil, auth, nil, handlers, server).Handler)
+	defer srv.Close()
+
+	// Mounted handlers are only reachable with the permission of their namespace
+	tests := []struct {
+		token  string
+		status int
+	}{
+		{"", http.StatusForbidden},
+		{"wrong", http.StatusUnauthorized},
+		{"secret", http.StatusOK},
+	}
+	for i, tt := range tests {
+		req, _ := http.NewRequest("GET", srv.URL+"/graphql", nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("test %d: request failed: %v", i, err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != tt.status {
+			t.Errorf("test %d: status mismatch: have %d, want %d", i, res.StatusCode, tt.status)
+		}
+		if tt.status == http.StatusOK && string(body) != "mounted" {
+			t.Errorf("test %d: body mismatch: have %q, want %q", i, body, "mounted")
+		}
+	}
+	// Other paths are still served as RPC
+	if status, code := callHTTP(t, srv.URL, "", "test_rets"); status != http.StatusOK || code != 0 {
+		t.Errorf("RPC call failed: status %d, code %d", status, code)
+	}
+}
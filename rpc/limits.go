@@ -0,0 +1,88 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Limits bounds the resources a single client may consume on a server. The
+// zero value of every field means unlimited.
+type Limits struct {
+	MaxBatchLength  int // Maximum number of requests in a batch
+	MaxResponseSize int // Maximum size in bytes of a response (or all responses of a batch)
+	MaxConcurrency  int // Maximum number of requests executed in parallel per connection
+
+	ExecutionTimeout time.Duration            // Default time limit of a method call
+	MethodTimeouts   map[string]time.Duration // Time limits per method ("eth_getLogs") or namespace ("debug")
+}
+
+// ParseMethodTimeouts parses a comma separated list of method=duration pairs,
+// e.g. "eth_getLogs=30s,debug=2m".
+func ParseMethodTimeouts(spec string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid method timeout %q, want method=duration", entry)
+		}
+		timeout, err := time.ParseDuration(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout of %s: %v", parts[0], err)
+		}
+		timeouts[strings.TrimSpace(parts[0])] = timeout
+	}
+	return timeouts, nil
+}
+
+// timeout returns the execution time limit of a method, preferring the method
+// specific one over the namespace one over the default.
+func (l *Limits) timeout(service, method string) time.Duration {
+	if timeout, ok := l.MethodTimeouts[service+serviceMethodSeparator+method]; ok {
+		return timeout
+	}
+	if timeout, ok := l.MethodTimeouts[service]; ok {
+		return timeout
+	}
+	return l.ExecutionTimeout
+}
+
+// SetLimits configures the resource limits enforced on the clients. It must be
+// called before the server starts serving requests.
+func (s *Server) SetLimits(limits Limits) {
+	s.limits = limits
+}
+
+// limitResponse encodes a response, replacing it with an error if it's larger
+// than the remaining budget of bytes. It returns the response to send and the
+// bytes of the budget it used.
+func (s *Server) limitResponse(codec ServerCodec, req *serverRequest, response interface{}, budget int) (interface{}, int) {
+	blob, err := json.Marshal(response)
+	if err != nil {
+		return response, 0
+	}
+	if len(blob) > budget {
+		return codec.CreateErrorResponse(&req.id, &limitExceededError{fmt.Sprintf("response too large (%d bytes, limit %d)", len(blob), s.limits.MaxResponseSize)}), 0
+	}
+	return json.RawMessage(blob), len(blob)
+}
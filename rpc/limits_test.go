@@ -0,0 +1,260 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type LimitTestService struct{}
+
+func (s *LimitTestService) Data(size int) string {
+	return strings.Repeat("x", size)
+}
+
+func (s *LimitTestService) Sleep(ms int) bool {
+	time.Sleep(time.Duration(ms) * time.Millisecond)
+	return true
+}
+
+func (s *LimitTestService) SleepWithCtx(ctx context.Context, ms int) (bool, error) {
+	select {
+	case <-time.After(time.Duration(ms) * time.Millisecond):
+		return true, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+type limitTestResponse struct {
+	Id    int
+	Error *JSONError
+}
+
+// newLimitTestConn starts serving a connection on a server with the given
+// limits, returning the client side of it.
+func newLimitTestConn(t *testing.T, limits Limits) (*json.Encoder, *json.Decoder, func()) {
+	server := NewServer()
+	server.SetLimits(limits)
+	if err := server.RegisterName("test", new(LimitTestService)); err != nil {
+		t.Fatal(err)
+	}
+	clientConn, serverConn := net.Pipe()
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation)
+
+	return json.NewEncoder(clientConn), json.NewDecoder(clientConn), func() { clientConn.Close() }
+}
+
+func limitTestRequest(id int, method string, params ...interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params}
+}
+
+// errorCode returns the JSON-RPC error code of a response, zero on success.
+func (r *limitTestResponse) errorCode() int {
+	if r.Error == nil {
+		return 0
+	}
+	return r.Error.Code
+}
+
+func TestBatchLengthLimit(t *testing.T) {
+	out, in, closer := newLimitTestConn(t, Limits{MaxBatchLength: 2})
+	defer closer()
+
+	// A batch within the limit is executed
+	out.Encode([]interface{}{limitTestRequest(1, "test_data", 1), limitTestRequest(2, "test_data", 1)})
+	var batch []limitTestResponse
+	if err := in.Decode(&batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(batch) != 2 || batch[0].errorCode() != 0 || batch[1].errorCode() != 0 {
+		t.Fatalf("valid batch failed: %+v", batch)
+	}
+	// A larger one is rejected as a whole, leaving the connection usable
+	out.Encode([]interface{}{limitTestRequest(3, "test_data", 1), limitTestRequest(4, "test_data", 1), limitTestRequest(5, "test_data", 1)})
+	var res limitTestResponse
+	if err := in.Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.errorCode() != -32005 {
+		t.Fatalf("oversized batch error code mismatch: have %d, want %d", res.errorCode(), -32005)
+	}
+	out.Encode(limitTestRequest(6, "test_data", 1))
+	res = limitTestResponse{}
+	if err := in.Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Id != 6 || res.errorCode() != 0 {
+		t.Fatalf("request after rejected batch failed: %+v", res)
+	}
+}
+
+func TestResponseSizeLimit(t *testing.T) {
+	out, in, closer := newLimitTestConn(t, Limits{MaxResponseSize: 256})
+	defer closer()
+
+	tests := []struct {
+		size int
+		code int
+	}{
+		{100, 0},
+		{300, -32005},
+	}
+	for i, tt := range tests {
+		out.Encode(limitTestRequest(i, "test_data", tt.size))
+		var res limitTestResponse
+		if err := in.Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Id != i || res.errorCode() != tt.code {
+			t.Errorf("test %d: response mismatch: have id %d code %d, want code %d", i, res.Id, res.errorCode(), tt.code)
+		}
+	}
+	// The limit applies to batches as a whole
+	out.Encode([]interface{}{limitTestRequest(1, "test_data", 60), limitTestRequest(2, "test_data", 60), limitTestRequest(3, "test_data", 60)})
+	var batch []limitTestResponse
+	if err := in.Decode(&batch); err != nil {
+		t.Fatal(err)
+	}
+	var codes []int
+	for _, res := range batch {
+		codes = append(codes, res.errorCode())
+	}
+	if want := []int{0, 0, -32005}; !reflect.DeepEqual(codes, want) {
+		t.Errorf("batch error codes mismatch: have %v, want %v", codes, want)
+	}
+}
+
+func TestExecutionTimeout(t *testing.T) {
+	out, in, closer := newLimitTestConn(t, Limits{
+		ExecutionTimeout: 50 * time.Millisecond,
+		MethodTimeouts:   map[string]time.Duration{"test_sleepWithCtx": time.Second},
+	})
+	defer closer()
+
+	tests := []struct {
+		method string
+		ms     int
+		code   int
+	}{
+		{"test_sleep", 10, 0},
+		{"test_sleep", 500, -32002},
+		{"test_sleepWithCtx", 100, 0},
+	}
+	for i, tt := range tests {
+		start := time.Now()
+		out.Encode(limitTestRequest(i, tt.method, tt.ms))
+		var res limitTestResponse
+		if err := in.Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Id != i || res.errorCode() != tt.code {
+			t.Errorf("test %d: response mismatch: have id %d code %d, want code %d", i, res.Id, res.errorCode(), tt.code)
+		}
+		if tt.code != 0 && time.Since(start) > 400*time.Millisecond {
+			t.Errorf("test %d: timed out call not abandoned", i)
+		}
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	out, in, closer := newLimitTestConn(t, Limits{MaxConcurrency: 1})
+	defer closer()
+
+	// Start a slow request and issue another while it's running
+	out.Encode(limitTestRequest(1, "test_sleep", 200))
+	time.Sleep(50 * time.Millisecond)
+	out.Encode(limitTestRequest(2, "test_sleep", 0))
+
+	codes := make(map[int]int)
+	for i := 0; i < 2; i++ {
+		var res limitTestResponse
+		if err := in.Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		codes[res.Id] = res.errorCode()
+	}
+	if want := map[int]int{1: 0, 2: -32005}; !reflect.DeepEqual(codes, want) {
+		t.Fatalf("error codes mismatch: have %v, want %v", codes, want)
+	}
+	// Once the slow request finished, new ones are accepted again (the slot is
+	// freed right after the response is written)
+	time.Sleep(20 * time.Millisecond)
+	out.Encode(limitTestRequest(3, "test_sleep", 0))
+	res := limitTestResponse{}
+	if err := in.Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Id != 3 || res.errorCode() != 0 {
+		t.Fatalf("request after limit freed failed: %+v", res)
+	}
+}
+
+func TestConcurrencyLimitTimeout(t *testing.T) {
+	out, in, closer := newLimitTestConn(t, Limits{MaxConcurrency: 1, ExecutionTimeout: 50 * time.Millisecond})
+	defer closer()
+
+	// Time out a slow request, its callback keeps running in the background
+	out.Encode(limitTestRequest(1, "test_sleep", 300))
+	var res limitTestResponse
+	if err := in.Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Id != 1 || res.errorCode() != -32002 {
+		t.Fatalf("slow request not timed out: %+v", res)
+	}
+	// The slot is held until the abandoned callback returns
+	out.Encode(limitTestRequest(2, "test_sleep", 0))
+	res = limitTestResponse{}
+	if err := in.Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Id != 2 || res.errorCode() != -32005 {
+		t.Fatalf("request accepted while abandoned callback running: %+v", res)
+	}
+	time.Sleep(350 * time.Millisecond)
+	out.Encode(limitTestRequest(3, "test_sleep", 0))
+	res = limitTestResponse{}
+	if err := in.Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Id != 3 || res.errorCode() != 0 {
+		t.Fatalf("request after callback returned failed: %+v", res)
+	}
+}
+
+func TestParseMethodTimeouts(t *testing.T) {
+	timeouts, err := ParseMethodTimeouts("eth_getLogs=30s, debug=2m,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]time.Duration{"eth_getLogs": 30 * time.Second, "debug": 2 * time.Minute}
+	if !reflect.DeepEqual(timeouts, want) {
+		t.Errorf("timeouts mismatch: have %v, want %v", timeouts, want)
+	}
+	for _, spec := range []string{"eth_getLogs", "=1s", "debug=forever"} {
+		if _, err := ParseMethodTimeouts(spec); err == nil {
+			t.Errorf("invalid spec %q accepted", spec)
+		}
+	}
+}
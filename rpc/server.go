@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -137,7 +138,7 @@ func (s *Server) RegisterName(name string, rcvr interface{}) error {
 // If singleShot is true it will process a single request, otherwise it will handle
 // requests until the codec returns an error when reading a request (in most cases
 // an EOF). It executes requests in parallel when singleShot is false.
-func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecOption) error {
+func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot bool, options CodecOption) error {
 	defer func() {
 		if err := recover(); err != nil {
 			const size = 64 << 10
@@ -153,7 +154,7 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 		return
 	}()
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	// if the codec supports notification include a notifier that callbacks can use
@@ -170,6 +171,12 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 	s.codecs.Add(codec)
 	s.codecsMu.Unlock()
 
+	// limit the number of requests executed in parallel on this connection
+	var pending chan struct{}
+	if !singleShot && s.limits.MaxConcurrency > 0 {
+		pending = make(chan struct{}, s.limits.MaxConcurrency)
+	}
+
 	// test if the server is ordered to stop
 	for atomic.LoadInt32(&s.run) == 1 {
 		reqs, batch, err := s.readRequest(codec)
@@ -179,6 +186,16 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 			return nil
 		}
 
+		// reject batches larger than allowed as a whole
+		if batch && s.limits.MaxBatchLength > 0 && len(reqs) > s.limits.MaxBatchLength {
+			err := &limitExceededError{fmt.Sprintf("batch too large (%d>%d requests)", len(reqs), s.limits.MaxBatchLength)}
+			codec.Write(codec.CreateErrorResponse(nil, err))
+			if singleShot {
+				return nil
+			}
+			continue
+		}
+
 		// check if server is ordered to shutdown and return an error
 		// telling the client that his request failed.
 		if atomic.LoadInt32(&s.run) != 1 {
@@ -201,29 +218,63 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 		} else if singleShot && !batch {
 			s.exec(ctx, codec, reqs[0])
 			return nil
-		} else if !singleShot && batch {
-			go s.execBatch(ctx, codec, reqs)
-		} else {
-			go s.exec(ctx, codec, reqs[0])
 		}
+		if pending != nil {
+			select {
+			case pending <- struct{}{}:
+			default:
+				s.rejectConcurrent(codec, reqs, batch)
+				continue
+			}
+		}
+		go func() {
+			// Hold the slot until the callbacks return, even if they were abandoned
+			// on timeout, as they keep consuming resources meanwhile
+			callbacks := new(sync.WaitGroup)
+			ctx := context.WithValue(ctx, callbacksKey{}, callbacks)
+			if batch {
+				s.execBatch(ctx, codec, reqs)
+			} else {
+				s.exec(ctx, codec, reqs[0])
+			}
+			callbacks.Wait()
+			if pending != nil {
+				<-pending
+			}
+		}()
 	}
 
 	return nil
 }
 
+// rejectConcurrent answers requests exceeding the concurrency limit of the
+// connection with an error.
+func (s *Server) rejectConcurrent(codec ServerCodec, reqs []*serverRequest, batch bool) {
+	err := &limitExceededError{fmt.Sprintf("too many concurrent requests (limit %d)", s.limits.MaxConcurrency)}
+	if !batch {
+		codec.Write(codec.CreateErrorResponse(&reqs[0].id, err))
+		return
+	}
+	resps := make([]interface{}, len(reqs))
+	for i, r := range reqs {
+		resps[i] = codec.CreateErrorResponse(&r.id, err)
+	}
+	codec.Write(resps)
+}
+
 // ServeCodec reads incoming requests from codec, calls the appropriate callback and writes the
 // response back using the given codec. It will block until the codec is closed or the server is
 // stopped. In either case the codec is closed.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	defer codec.Close()
-	s.serveRequest(codec, false, options)
+	s.serveRequest(context.Background(), codec, false, options)
 }
 
 // ServeSingleRequest reads and processes a single RPC request from the given codec. It will not
 // close the codec unless a non-recoverable error has occurred. Note, this method will return after
 // a single request has been processed!
 func (s *Server) ServeSingleRequest(codec ServerCodec, options CodecOption) {
-	s.serveRequest(codec, true, options)
+	s.serveRequest(context.Background(), codec, true, options)
 }
 
 // Stop will stop reading new requests, wait for stopPendingRequestTimeout to allow pending requests to finish,
@@ -279,6 +330,17 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, &invalidParamsError{"Expected subscription id as first argument"}), nil
 	}
 
+	// authenticated transports only allow the methods granted to the client
+	if info, ok := authInfoFromContext(ctx); ok {
+		method := formatName(req.callb.method.Name)
+		if req.callb.isSubscribe {
+			method = "subscribe"
+		}
+		if !info.authorize(req.svcname, method) {
+			return codec.CreateErrorResponse(&req.id, &permissionDeniedError{req.svcname, method}), nil
+		}
+	}
+
 	if req.callb.isSubscribe {
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
@@ -302,6 +364,13 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, rpcErr), nil
 	}
 
+	method := formatName(req.callb.method.Name)
+	timeout := s.limits.timeout(req.svcname, method)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 	arguments := []reflect.Value{req.callb.rcvr}
 	if req.callb.hasCtx {
 		arguments = append(arguments, reflect.ValueOf(ctx))
@@ -310,8 +379,11 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		arguments = append(arguments, req.args...)
 	}
 
-	// execute RPC method and return result
-	reply := req.callb.method.Func.Call(arguments)
+	// execute RPC method within its time limit and return result
+	reply, err := s.call(ctx, req, arguments)
+	if err != nil {
+		return codec.CreateErrorResponse(&req.id, err), nil
+	}
 	if len(reply) == 0 {
 		return codec.CreateResponse(req.id, nil), nil
 	}
@@ -319,6 +391,9 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	if req.callb.errPos >= 0 { // test if method returned an error
 		if !reply[req.callb.errPos].IsNil() {
 			e := reply[req.callb.errPos].Interface().(error)
+			if rpcErr, ok := e.(RPCError); ok { // callback chose its own error code
+				return codec.CreateErrorResponse(&req.id, rpcErr), nil
+			}
 			res := codec.CreateErrorResponse(&req.id, &callbackError{e.Error()})
 			return res, nil
 		}
@@ -326,6 +401,40 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	return codec.CreateResponse(req.id, reply[0].Interface()), nil
 }
 
+// callbacksKey is the context key of the wait group tracking the callbacks
+// running on behalf of a request, including the abandoned ones.
+type callbacksKey struct{}
+
+// call invokes the callback of a request. If the context has a deadline, the
+// call is abandoned once it passes, returning a timeout error; callbacks taking
+// a context are expected to abort on their own.
+func (s *Server) call(ctx context.Context, req *serverRequest, arguments []reflect.Value) ([]reflect.Value, RPCError) {
+	if _, ok := ctx.Deadline(); !ok {
+		return req.callb.method.Func.Call(arguments), nil
+	}
+	callbacks, _ := ctx.Value(callbacksKey{}).(*sync.WaitGroup)
+	if callbacks != nil {
+		callbacks.Add(1)
+	}
+	done := make(chan []reflect.Value, 1)
+	go func() {
+		if callbacks != nil {
+			defer callbacks.Done()
+		}
+		done <- req.callb.method.Func.Call(arguments)
+	}()
+	select {
+	case reply := <-done:
+		return reply, nil
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			method := formatName(req.callb.method.Name)
+			return nil, &timeoutError{req.svcname, method, s.limits.timeout(req.svcname, method)}
+		}
+		return nil, &callbackError{ctx.Err().Error()}
+	}
+}
+
 // exec executes the given request and writes the result back using the codec.
 func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest) {
 	var response interface{}
@@ -335,6 +444,9 @@ func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest
 	} else {
 		response, callback = s.handle(ctx, codec, req)
 	}
+	if s.limits.MaxResponseSize > 0 {
+		response, _ = s.limitResponse(codec, req, response, s.limits.MaxResponseSize)
+	}
 
 	if err := codec.Write(response); err != nil {
 		glog.V(logger.Error).Infof("%v\n", err)
@@ -352,6 +464,7 @@ func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest
 func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*serverRequest) {
 	responses := make([]interface{}, len(requests))
 	var callbacks []func()
+	size := 0
 	for i, req := range requests {
 		if req.err != nil {
 			responses[i] = codec.CreateErrorResponse(&req.id, req.err)
@@ -361,6 +474,12 @@ func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*s
 				callbacks = append(callbacks, callback)
 			}
 		}
+		// the size limit applies to all responses of the batch together
+		if s.limits.MaxResponseSize > 0 {
+			var n int
+			responses[i], n = s.limitResponse(codec, req, responses[i], s.limits.MaxResponseSize-size)
+			size += n
+		}
 	}
 
 	if err := codec.Write(responses); err != nil {
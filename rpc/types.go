@@ -72,6 +72,7 @@ type subscriptionRegistry map[string]*callback // collection of subscription cal
 type Server struct {
 	services      serviceRegistry
 	subscriptions subscriptionRegistry
+	limits        Limits
 
 	run      int32
 	codecsMu sync.Mutex
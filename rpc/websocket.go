@@ -17,6 +17,7 @@
 package rpc
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -87,14 +88,45 @@ func wsHandshakeValidator(allowedOrigins []string) func(*websocket.Config, *http
 	return f
 }
 
-// NewWSServer creates a new websocket RPC server around an API provider.
-func NewWSServer(allowedOrigins string, handler *Server) *http.Server {
+// wsAuthValidator wraps a handshake validator, additionally rejecting the
+// upgrade requests with invalid bearer tokens.
+func wsAuthValidator(validate func(*websocket.Config, *http.Request) error, auth *Authenticator) func(*websocket.Config, *http.Request) error {
+	return func(cfg *websocket.Config, req *http.Request) error {
+		if err := validate(cfg, req); err != nil {
+			return err
+		}
+		if _, err := auth.authenticate(req); err != nil {
+			glog.V(logger.Debug).Infof("WS-RPC authentication failed from %s: %v\n", req.RemoteAddr, err)
+			return err
+		}
+		return nil
+	}
+}
+
+// NewWSServer creates a new websocket RPC server around an API provider. A nil
+// authenticator allows every client to call all the methods of the provider.
+func NewWSServer(allowedOrigins string, auth *Authenticator, handler *Server) *http.Server {
+	validator := wsHandshakeValidator(strings.Split(allowedOrigins, ","))
+	if auth != nil {
+		validator = wsAuthValidator(validator, auth)
+	}
 	return &http.Server{
 		Handler: websocket.Server{
-			Handshake: wsHandshakeValidator(strings.Split(allowedOrigins, ",")),
+			Handshake: validator,
 			Handler: func(conn *websocket.Conn) {
-				handler.ServeCodec(NewJSONCodec(&wsReaderWriterCloser{conn}),
-					OptionMethodInvocation|OptionSubscriptions)
+				codec := NewJSONCodec(&wsReaderWriterCloser{conn})
+				defer codec.Close()
+
+				// the connection keeps the permissions granted during the handshake
+				ctx := context.Background()
+				if auth != nil {
+					info, err := auth.authenticate(conn.Request())
+					if err != nil {
+						return
+					}
+					ctx = context.WithValue(ctx, authInfoKey{}, info)
+				}
+				handler.serveRequest(ctx, codec, false, OptionMethodInvocation|OptionSubscriptions)
 			},
 		},
 	}
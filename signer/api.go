@@ -0,0 +1,214 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package signer implements an account API for a standalone signer process,
+// keeping the keys out of the node. Every request is put to a UIHandler for
+// approval, which may be a user at a terminal or a set of JavaScript rules, and
+// is recorded in an audit log.
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereumproject/go-ethereum/accounts"
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/rlp"
+	"github.com/ethereumproject/go-ethereum/rpc"
+)
+
+// Namespace is the RPC namespace the SignerAPI is served under, making its
+// methods account_list, account_signTransaction and account_signData.
+const Namespace = "account"
+
+// ErrRequestDenied is returned if the UI did not approve a request.
+var ErrRequestDenied = errors.New("request denied")
+
+// SendTxArgs represents a transaction to sign. As the signer has no view of the
+// chain, all the fields but To and Data are mandatory. ChainId is optional, but
+// if given it must match the chain the signer is configured for.
+type SendTxArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      *rpc.HexNumber  `json:"gas"`
+	GasPrice *rpc.HexNumber  `json:"gasPrice"`
+	Value    *rpc.HexNumber  `json:"value"`
+	Data     string          `json:"data"`
+	Nonce    *rpc.HexNumber  `json:"nonce"`
+	ChainId  *rpc.HexNumber  `json:"chainId"`
+}
+
+// toTransaction validates the arguments and assembles the transaction to sign.
+func (args *SendTxArgs) toTransaction(chainId *big.Int) (*types.Transaction, error) {
+	switch {
+	case args.Gas == nil:
+		return nil, errors.New("gas not specified")
+	case args.GasPrice == nil:
+		return nil, errors.New("gas price not specified")
+	case args.Value == nil:
+		return nil, errors.New("value not specified")
+	case args.Nonce == nil:
+		return nil, errors.New("nonce not specified")
+	case args.ChainId != nil && args.ChainId.BigInt().Cmp(chainId) != 0:
+		return nil, fmt.Errorf("chain id mismatch: have %v, want %v", args.ChainId.BigInt(), chainId)
+	}
+	data := common.FromHex(args.Data)
+	if args.To == nil {
+		return types.NewContractCreation(args.Nonce.Uint64(), args.Value.BigInt(), args.Gas.BigInt(), args.GasPrice.BigInt(), data), nil
+	}
+	return types.NewTransaction(args.Nonce.Uint64(), *args.To, args.Value.BigInt(), args.Gas.BigInt(), args.GasPrice.BigInt(), data), nil
+}
+
+// ListRequest asks for the accounts of the signer to be disclosed.
+type ListRequest struct {
+	Accounts []common.Address `json:"accounts"`
+}
+
+// ListResponse holds the accounts the UI allows to be disclosed.
+type ListResponse struct {
+	Accounts []common.Address `json:"accounts"`
+}
+
+// SignTxRequest asks for a transaction to be signed.
+type SignTxRequest struct {
+	Transaction SendTxArgs `json:"transaction"`
+}
+
+// SignTxResponse holds the decision of the UI on a transaction, along with the
+// passphrase unlocking the sending account if approved.
+type SignTxResponse struct {
+	Approved bool
+	Password string
+}
+
+// SignDataRequest asks for arbitrary data to be signed, as a message prefixed
+// with "\x19Ethereum Signed Message:\n" and its length.
+type SignDataRequest struct {
+	Address common.Address `json:"address"`
+	Data    string         `json:"data"`
+	Hash    common.Hash    `json:"hash"`
+}
+
+// SignDataResponse holds the decision of the UI on signing data, along with the
+// passphrase unlocking the account if approved.
+type SignDataResponse struct {
+	Approved bool
+	Password string
+}
+
+// UIHandler decides on the requests made to a SignerAPI.
+type UIHandler interface {
+	// ApproveListing returns the accounts which may be disclosed.
+	ApproveListing(req *ListRequest) (ListResponse, error)
+
+	// ApproveTx decides whether to sign a transaction.
+	ApproveTx(req *SignTxRequest) (SignTxResponse, error)
+
+	// ApproveSignData decides whether to sign a piece of data.
+	ApproveSignData(req *SignDataRequest) (SignDataResponse, error)
+}
+
+// SignerAPI is the account API served by the signer process, signing with the
+// keys of an account manager whatever its UI approves.
+type SignerAPI struct {
+	chainId *big.Int
+	am      *accounts.Manager
+	ui      UIHandler
+	audit   *AuditLog
+}
+
+// NewSignerAPI creates an API signing transactions for the given chain with the
+// keys of the account manager. Requests are recorded in the audit log, if any.
+func NewSignerAPI(chainId *big.Int, am *accounts.Manager, ui UIHandler, audit *AuditLog) *SignerAPI {
+	return &SignerAPI{
+		chainId: chainId,
+		am:      am,
+		ui:      ui,
+		audit:   audit,
+	}
+}
+
+// List returns the addresses of the accounts the UI allows to be disclosed.
+func (api *SignerAPI) List() (addrs []common.Address, err error) {
+	defer func() { api.audit.record("account_list", nil, addrs, err) }()
+
+	req := &ListRequest{Accounts: []common.Address{}}
+	for _, account := range api.am.Accounts() {
+		req.Accounts = append(req.Accounts, account.Address)
+	}
+	res, err := api.ui.ApproveListing(req)
+	if err != nil {
+		return nil, err
+	}
+	// Only disclose accounts that were asked about in the first place
+	addrs = []common.Address{}
+	for _, addr := range res.Accounts {
+		if api.am.HasAddress(addr) {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs, nil
+}
+
+// SignTransaction signs the transaction if approved by the UI, returning it RLP
+// and hex encoded, ready to be submitted to the network.
+func (api *SignerAPI) SignTransaction(args SendTxArgs) (raw string, err error) {
+	defer func() { api.audit.record("account_signTransaction", args, raw, err) }()
+
+	tx, err := args.toTransaction(api.chainId)
+	if err != nil {
+		return "", err
+	}
+	res, err := api.ui.ApproveTx(&SignTxRequest{Transaction: args})
+	if err != nil {
+		return "", err
+	}
+	if !res.Approved {
+		return "", ErrRequestDenied
+	}
+	signed, err := api.am.SignTxWithPassphrase(accounts.Account{Address: args.From}, res.Password, tx, types.NewChainIdSigner(api.chainId))
+	if err != nil {
+		return "", err
+	}
+	enc, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return "", err
+	}
+	return common.ToHex(enc), nil
+}
+
+// SignData signs the hex encoded data as a prefixed message if approved by the
+// UI, returning the hex encoded signature with a V of 27 or 28.
+func (api *SignerAPI) SignData(addr common.Address, data string) (signature string, err error) {
+	defer func() { api.audit.record("account_signData", []interface{}{addr, data}, signature, err) }()
+
+	req := &SignDataRequest{Address: addr, Data: data, Hash: common.BytesToHash(accounts.TextHash(common.FromHex(data)))}
+	res, err := api.ui.ApproveSignData(req)
+	if err != nil {
+		return "", err
+	}
+	if !res.Approved {
+		return "", ErrRequestDenied
+	}
+	sig, err := api.am.SignWithPassphrase(addr, res.Password, req.Hash.Bytes())
+	if err != nil {
+		return "", err
+	}
+	sig[64] += 27
+	return common.ToHex(sig), nil
+}
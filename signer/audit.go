@@ -0,0 +1,67 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ethereumproject/go-ethereum/logger"
+	"github.com/ethereumproject/go-ethereum/logger/glog"
+)
+
+// AuditLog records every request made to the signer, one JSON object per line.
+// Passphrases never make it into the log.
+type AuditLog struct {
+	out  io.Writer
+	lock sync.Mutex
+}
+
+// auditEntry is a single line of the audit log.
+type auditEntry struct {
+	Time   time.Time   `json:"time"`
+	Method string      `json:"method"`
+	Params interface{} `json:"params,omitempty"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// NewAuditLog creates an audit log writing to the given output.
+func NewAuditLog(out io.Writer) *AuditLog {
+	return &AuditLog{out: out}
+}
+
+// record appends a request and its outcome to the log. A nil log records nothing.
+func (l *AuditLog) record(method string, params interface{}, result interface{}, err error) {
+	if l == nil {
+		return
+	}
+	entry := auditEntry{Time: time.Now().UTC(), Method: method, Params: params}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Result = result
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if err := json.NewEncoder(l.out).Encode(entry); err != nil {
+		glog.V(logger.Error).Infof("failed to write audit log: %v", err)
+	}
+}
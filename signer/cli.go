@@ -0,0 +1,131 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/ethereumproject/go-ethereum/common"
+)
+
+// CommandlineUI is a UIHandler putting every request to a user at a terminal.
+type CommandlineUI struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	lock sync.Mutex // Serializes the prompts of concurrent requests
+}
+
+// NewCommandlineUI creates a UI reading the answers of the user from in and
+// printing the requests to out.
+func NewCommandlineUI(in io.Reader, out io.Writer) *CommandlineUI {
+	return &CommandlineUI{in: bufio.NewReader(in), out: out}
+}
+
+// ApproveListing asks whether to disclose the accounts of the signer.
+func (ui *CommandlineUI) ApproveListing(req *ListRequest) (ListResponse, error) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	fmt.Fprintln(ui.out, "-------- List accounts request --------")
+	for _, addr := range req.Accounts {
+		fmt.Fprintf(ui.out, "  %s\n", addr.Hex())
+	}
+	ok, err := ui.confirm()
+	if err != nil || !ok {
+		return ListResponse{Accounts: []common.Address{}}, err
+	}
+	return ListResponse{Accounts: req.Accounts}, nil
+}
+
+// ApproveTx asks whether to sign the transaction, and for the passphrase of the
+// sender if so.
+func (ui *CommandlineUI) ApproveTx(req *SignTxRequest) (SignTxResponse, error) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	tx := req.Transaction
+	fmt.Fprintln(ui.out, "-------- Transaction request --------")
+	fmt.Fprintf(ui.out, "from:     %s\n", tx.From.Hex())
+	if tx.To != nil {
+		fmt.Fprintf(ui.out, "to:       %s\n", tx.To.Hex())
+	} else {
+		fmt.Fprintln(ui.out, "to:       <contract creation>")
+	}
+	fmt.Fprintf(ui.out, "value:    %v wei\n", tx.Value.BigInt())
+	fmt.Fprintf(ui.out, "gas:      %v\n", tx.Gas.BigInt())
+	fmt.Fprintf(ui.out, "gasprice: %v wei\n", tx.GasPrice.BigInt())
+	fmt.Fprintf(ui.out, "nonce:    %v\n", tx.Nonce.BigInt())
+	if data := common.FromHex(tx.Data); len(data) > 0 {
+		fmt.Fprintf(ui.out, "data:     %x\n", data)
+	}
+	ok, err := ui.confirm()
+	if err != nil || !ok {
+		return SignTxResponse{Approved: false}, err
+	}
+	password, err := ui.prompt("Passphrase: ")
+	if err != nil {
+		return SignTxResponse{Approved: false}, err
+	}
+	return SignTxResponse{Approved: true, Password: password}, nil
+}
+
+// ApproveSignData asks whether to sign the data, and for the passphrase of the
+// account if so.
+func (ui *CommandlineUI) ApproveSignData(req *SignDataRequest) (SignDataResponse, error) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	fmt.Fprintln(ui.out, "-------- Sign data request --------")
+	fmt.Fprintf(ui.out, "account: %s\n", req.Address.Hex())
+	fmt.Fprintf(ui.out, "data:    %x\n", common.FromHex(req.Data))
+	fmt.Fprintf(ui.out, "hash:    %s\n", req.Hash.Hex())
+
+	ok, err := ui.confirm()
+	if err != nil || !ok {
+		return SignDataResponse{Approved: false}, err
+	}
+	password, err := ui.prompt("Passphrase: ")
+	if err != nil {
+		return SignDataResponse{Approved: false}, err
+	}
+	return SignDataResponse{Approved: true, Password: password}, nil
+}
+
+// confirm asks the user to approve the request printed last.
+func (ui *CommandlineUI) confirm() (bool, error) {
+	answer, err := ui.prompt("Approve? [y/N] ")
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// prompt prints the question and reads a line of answer.
+func (ui *CommandlineUI) prompt(question string) (string, error) {
+	fmt.Fprint(ui.out, question)
+	line, err := ui.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/logger"
+	"github.com/ethereumproject/go-ethereum/logger/glog"
+	"github.com/robertkrimen/otto"
+)
+
+// Verdicts a rule function may return. Any other value, including undefined or
+// a missing function, leaves the decision to the next UI.
+const (
+	ruleApprove = "Approve"
+	ruleReject  = "Reject"
+)
+
+// ruleTimeout is the time the rules get to load and decide on a request, after
+// which they are aborted so that a looping rule can't stall the signer.
+var ruleTimeout = time.Second
+
+// errRuleTimeout aborts rules running for longer than ruleTimeout.
+var errRuleTimeout = errors.New("rule timed out")
+
+// RuleUI is a UIHandler deciding on requests with JavaScript rules, forwarding
+// the requests the rules do not decide on to the next handler.
+//
+// The rules may define the functions ApproveTx, ApproveSignData and
+// ApproveListing, each called with the request object and returning "Approve"
+// or "Reject". Rules are evaluated in a fresh otto VM for every request, so no
+// state carries over between them. Approved signing requests are unlocked with
+// the passphrase stored for the account in the credentials. Rules not returning
+// within ruleTimeout are aborted and treated as undecided.
+type RuleUI struct {
+	next        UIHandler
+	rules       string
+	credentials map[common.Address]string
+}
+
+// NewRuleUI creates a rule based UI from the JavaScript source, falling back to
+// next for undecided requests. The rules are run once to catch syntax errors.
+func NewRuleUI(next UIHandler, rules string, credentials map[common.Address]string) (*RuleUI, error) {
+	var (
+		vm  = otto.New()
+		err error
+	)
+	if runLimited(vm, func() { _, err = vm.Run(rules) }) {
+		err = errRuleTimeout
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules: %v", err)
+	}
+	return &RuleUI{next: next, rules: rules, credentials: credentials}, nil
+}
+
+// ApproveListing discloses all the accounts if the rules approve, none if they
+// reject and defers to the next UI otherwise.
+func (ui *RuleUI) ApproveListing(req *ListRequest) (ListResponse, error) {
+	switch ui.verdict("ApproveListing", req) {
+	case ruleApprove:
+		return ListResponse{Accounts: req.Accounts}, nil
+	case ruleReject:
+		return ListResponse{Accounts: []common.Address{}}, nil
+	}
+	return ui.next.ApproveListing(req)
+}
+
+// ApproveTx signs the transaction if the rules approve and a passphrase is known
+// for the sender, and defers to the next UI unless the rules reject.
+func (ui *RuleUI) ApproveTx(req *SignTxRequest) (SignTxResponse, error) {
+	switch ui.verdict("ApproveTx", req) {
+	case ruleApprove:
+		if password, ok := ui.credentials[req.Transaction.From]; ok {
+			return SignTxResponse{Approved: true, Password: password}, nil
+		}
+		glog.V(logger.Warn).Infof("Rules approved transaction from %x without stored credentials", req.Transaction.From)
+	case ruleReject:
+		return SignTxResponse{Approved: false}, nil
+	}
+	return ui.next.ApproveTx(req)
+}
+
+// ApproveSignData signs the data if the rules approve and a passphrase is known
+// for the account, and defers to the next UI unless the rules reject.
+func (ui *RuleUI) ApproveSignData(req *SignDataRequest) (SignDataResponse, error) {
+	switch ui.verdict("ApproveSignData", req) {
+	case ruleApprove:
+		if password, ok := ui.credentials[req.Address]; ok {
+			return SignDataResponse{Approved: true, Password: password}, nil
+		}
+		glog.V(logger.Warn).Infof("Rules approved signing data with %x without stored credentials", req.Address)
+	case ruleReject:
+		return SignDataResponse{Approved: false}, nil
+	}
+	return ui.next.ApproveSignData(req)
+}
+
+// verdict evaluates the named rule function on the request. Failing and timed
+// out rules are logged and treated as undecided.
+func (ui *RuleUI) verdict(function string, req interface{}) string {
+	blob, err := json.Marshal(req)
+	if err != nil {
+		glog.V(logger.Error).Infof("Failed to encode request for rule %s: %v", function, err)
+		return ""
+	}
+	var (
+		vm      = otto.New()
+		verdict string
+	)
+	if runLimited(vm, func() { verdict = ui.evaluate(vm, function, string(blob)) }) {
+		glog.V(logger.Error).Infof("Rule %s timed out after %v", function, ruleTimeout)
+		return ""
+	}
+	return verdict
+}
+
+// evaluate loads the rules into the VM and calls the named rule function on the
+// JSON encoded request, returning its verdict.
+func (ui *RuleUI) evaluate(vm *otto.Otto, function string, blob string) string {
+	if _, err := vm.Run(ui.rules); err != nil {
+		glog.V(logger.Error).Infof("Failed to load rules: %v", err)
+		return ""
+	}
+	if fn, err := vm.Get(function); err != nil || !fn.IsFunction() {
+		return ""
+	}
+	obj, err := vm.Call("JSON.parse", nil, blob)
+	if err != nil {
+		glog.V(logger.Error).Infof("Failed to decode request for rule %s: %v", function, err)
+		return ""
+	}
+	result, err := vm.Call(function, nil, obj)
+	if err != nil {
+		glog.V(logger.Error).Infof("Rule %s failed: %v", function, err)
+		return ""
+	}
+	if !result.IsString() {
+		return ""
+	}
+	verdict, _ := result.ToString()
+	return strings.TrimSpace(verdict)
+}
+
+// runLimited runs fn, interrupting the JavaScript it runs on the VM once
+// ruleTimeout elapses. It reports whether fn was interrupted.
+func runLimited(vm *otto.Otto, fn func()) (interrupted bool) {
+	vm.Interrupt = make(chan func(), 1)
+	timer := time.AfterFunc(ruleTimeout, func() {
+		vm.Interrupt <- func() { panic(errRuleTimeout) }
+	})
+	defer func() {
+		timer.Stop()
+		if r := recover(); r != nil {
+			if r != errRuleTimeout {
+				panic(r)
+			}
+			interrupted = true
+		}
+	}()
+	fn()
+	return false
+}
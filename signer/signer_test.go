@@ -0,0 +1,272 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereumproject/go-ethereum/accounts"
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/core/types"
+	"github.com/ethereumproject/go-ethereum/crypto"
+	"github.com/ethereumproject/go-ethereum/rlp"
+	"github.com/ethereumproject/go-ethereum/rpc"
+)
+
+// tmpManager creates an account manager in a temporary directory with a single
+// account protected by the passphrase "foo".
+func tmpManager(t *testing.T) (string, *accounts.Manager, accounts.Account) {
+	dir, err := ioutil.TempDir("", "signer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	am, err := accounts.NewManager(dir, accounts.LightScryptN, accounts.LightScryptP, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, am, account
+}
+
+// txArgs returns the arguments of a plain value transfer from the account.
+func txArgs(from common.Address) SendTxArgs {
+	to := common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+	return SendTxArgs{
+		From:     from,
+		To:       &to,
+		Gas:      rpc.NewHexNumber(21000),
+		GasPrice: rpc.NewHexNumber(20000000000),
+		Value:    rpc.NewHexNumber(1000),
+		Nonce:    rpc.NewHexNumber(3),
+	}
+}
+
+// Tests that requests are signed as answered at the command line, and denied
+// on a negative answer or a wrong passphrase.
+func TestSignerCommandline(t *testing.T) {
+	dir, am, account := tmpManager(t)
+	defer os.RemoveAll(dir)
+
+	answers := strings.Join([]string{
+		"y",        // list accounts
+		"y", "foo", // sign transaction
+		"n",        // sign transaction
+		"y", "bar", // sign transaction
+		"y", "foo", // sign data
+	}, "\n")
+	out, log := new(bytes.Buffer), new(bytes.Buffer)
+	api := NewSignerAPI(big.NewInt(61), am, NewCommandlineUI(strings.NewReader(answers), out), NewAuditLog(log))
+
+	addrs, err := api.List()
+	if err != nil {
+		t.Fatalf("failed to list accounts: %v", err)
+	}
+	if len(addrs) != 1 || addrs[0] != account.Address {
+		t.Fatalf("account list mismatch: have %x, want [%x]", addrs, account.Address)
+	}
+	raw, err := api.SignTransaction(txArgs(account.Address))
+	if err != nil {
+		t.Fatalf("failed to sign approved transaction: %v", err)
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(raw), tx); err != nil {
+		t.Fatalf("failed to decode signed transaction: %v", err)
+	}
+	if from, err := types.Sender(types.NewChainIdSigner(big.NewInt(61)), tx); err != nil || from != account.Address {
+		t.Errorf("sender mismatch: have %x/%v, want %x", from, err, account.Address)
+	}
+	if tx.Nonce() != 3 || tx.Value().Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("transaction mismatch: have nonce %d value %v", tx.Nonce(), tx.Value())
+	}
+	if _, err := api.SignTransaction(txArgs(account.Address)); err != ErrRequestDenied {
+		t.Errorf("rejected transaction error mismatch: have %v, want %v", err, ErrRequestDenied)
+	}
+	if _, err := api.SignTransaction(txArgs(account.Address)); err != accounts.ErrDecrypt {
+		t.Errorf("wrong passphrase error mismatch: have %v, want %v", err, accounts.ErrDecrypt)
+	}
+	sig, err := api.SignData(account.Address, "0xdeadbeef")
+	if err != nil {
+		t.Fatalf("failed to sign data: %v", err)
+	}
+	blob := common.FromHex(sig)
+	blob[64] -= 27
+	pub, err := crypto.SigToPub(accounts.TextHash([]byte{0xde, 0xad, 0xbe, 0xef}), blob)
+	if err != nil || crypto.PubkeyToAddress(*pub) != account.Address {
+		t.Errorf("data signer mismatch: have %v, want %x", err, account.Address)
+	}
+	if !strings.Contains(out.String(), "0x0102030405060708090a0b0c0d0e0f1011121314") {
+		t.Errorf("transaction recipient not shown to the user:\n%s", out)
+	}
+	// Every request should be audited, but no passphrases
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("audit log length mismatch: have %d, want %d", len(lines), 5)
+	}
+	for i, want := range []struct{ method, err string }{
+		{"account_list", ""},
+		{"account_signTransaction", ""},
+		{"account_signTransaction", ErrRequestDenied.Error()},
+		{"account_signTransaction", accounts.ErrDecrypt.Error()},
+		{"account_signData", ""},
+	} {
+		var entry auditEntry
+		if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil {
+			t.Fatalf("audit entry %d: failed to decode: %v", i, err)
+		}
+		if entry.Method != want.method || entry.Error != want.err {
+			t.Errorf("audit entry %d: have %s/%q, want %s/%q", i, entry.Method, entry.Error, want.method, want.err)
+		}
+	}
+	if strings.Contains(log.String(), "foo") {
+		t.Errorf("passphrase leaked into the audit log")
+	}
+}
+
+// Tests that incomplete or foreign chain transactions are refused before being
+// put to the user.
+func TestSignerValidation(t *testing.T) {
+	dir, am, account := tmpManager(t)
+	defer os.RemoveAll(dir)
+
+	api := NewSignerAPI(big.NewInt(61), am, NewCommandlineUI(strings.NewReader(""), ioutil.Discard), nil)
+
+	args := txArgs(account.Address)
+	args.Nonce = nil
+	if _, err := api.SignTransaction(args); err == nil || err == ErrRequestDenied {
+		t.Errorf("transaction without nonce: have %v, want validation error", err)
+	}
+	args = txArgs(account.Address)
+	args.ChainId = rpc.NewHexNumber(62)
+	if _, err := api.SignTransaction(args); err == nil || err == ErrRequestDenied {
+		t.Errorf("transaction for foreign chain: have %v, want validation error", err)
+	}
+}
+
+// stubUI is a UIHandler recording the requests reaching it and denying them.
+type stubUI struct {
+	txs, data, lists int
+}
+
+func (ui *stubUI) ApproveListing(req *ListRequest) (ListResponse, error) {
+	ui.lists++
+	return ListResponse{}, nil
+}
+
+func (ui *stubUI) ApproveTx(req *SignTxRequest) (SignTxResponse, error) {
+	ui.txs++
+	return SignTxResponse{Approved: false}, nil
+}
+
+func (ui *stubUI) ApproveSignData(req *SignDataRequest) (SignDataResponse, error) {
+	ui.data++
+	return SignDataResponse{Approved: false}, nil
+}
+
+const testRules = `
+function ApproveListing(req) {
+	return "Approve";
+}
+
+function ApproveTx(req) {
+	var value = parseInt(req.transaction.value, 16);
+	if (value <= 1000) {
+		return "Approve";
+	}
+	if (value > 1000000) {
+		return "Reject";
+	}
+}
+`
+
+// Tests that the rules approve and reject requests on their own, deferring to
+// the next UI on the requests they leave undecided.
+func TestSignerRules(t *testing.T) {
+	dir, am, account := tmpManager(t)
+	defer os.RemoveAll(dir)
+
+	if _, err := NewRuleUI(new(stubUI), "function (", nil); err == nil {
+		t.Errorf("invalid rules accepted")
+	}
+	next := new(stubUI)
+	ui, err := NewRuleUI(next, testRules, map[common.Address]string{account.Address: "foo"})
+	if err != nil {
+		t.Fatalf("failed to load rules: %v", err)
+	}
+	api := NewSignerAPI(big.NewInt(61), am, ui, nil)
+
+	if addrs, err := api.List(); err != nil || len(addrs) != 1 {
+		t.Errorf("rule approved listing mismatch: have %x/%v", addrs, err)
+	}
+	if _, err := api.SignTransaction(txArgs(account.Address)); err != nil {
+		t.Errorf("failed to sign rule approved transaction: %v", err)
+	}
+	args := txArgs(account.Address)
+	args.Value = rpc.NewHexNumber(2000000)
+	if _, err := api.SignTransaction(args); err != ErrRequestDenied {
+		t.Errorf("rule rejected transaction error mismatch: have %v, want %v", err, ErrRequestDenied)
+	}
+	if next.txs != 0 {
+		t.Errorf("rule decided transactions forwarded: %d", next.txs)
+	}
+	args.Value = rpc.NewHexNumber(5000)
+	if _, err := api.SignTransaction(args); err != ErrRequestDenied {
+		t.Errorf("forwarded transaction error mismatch: have %v, want %v", err, ErrRequestDenied)
+	}
+	if _, err := api.SignData(account.Address, "0x01"); err != ErrRequestDenied {
+		t.Errorf("forwarded data error mismatch: have %v, want %v", err, ErrRequestDenied)
+	}
+	if next.txs != 1 || next.data != 1 || next.lists != 0 {
+		t.Errorf("forwarded requests mismatch: have %d txs, %d data, %d lists, want 1, 1, 0", next.txs, next.data, next.lists)
+	}
+}
+
+// Tests that looping rules are aborted, failing to load or leaving the request
+// to the next UI.
+func TestSignerRulesTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { ruleTimeout = timeout }(ruleTimeout)
+	ruleTimeout = 100 * time.Millisecond
+
+	dir, am, account := tmpManager(t)
+	defer os.RemoveAll(dir)
+
+	if _, err := NewRuleUI(new(stubUI), "for (var i = 0; ; i++) {}", nil); err == nil {
+		t.Errorf("looping rules accepted")
+	}
+	next := new(stubUI)
+	ui, err := NewRuleUI(next, "function ApproveTx(req) { while (true) { req.n++; } }", map[common.Address]string{account.Address: "foo"})
+	if err != nil {
+		t.Fatalf("failed to load rules: %v", err)
+	}
+	api := NewSignerAPI(big.NewInt(61), am, ui, nil)
+
+	if _, err := api.SignTransaction(txArgs(account.Address)); err != ErrRequestDenied {
+		t.Errorf("timed out transaction error mismatch: have %v, want %v", err, ErrRequestDenied)
+	}
+	if next.txs != 1 {
+		t.Errorf("timed out transaction not forwarded: %d", next.txs)
+	}
+}
@@ -19,15 +19,11 @@ package trie
 import (
 	"bytes"
 	crand "crypto/rand"
-	"errors"
-	"fmt"
 	mrand "math/rand"
 	"testing"
 	"time"
 
 	"github.com/ethereumproject/go-ethereum/common"
-	"github.com/ethereumproject/go-ethereum/crypto/sha3"
-	"github.com/ethereumproject/go-ethereum/rlp"
 )
 
 func init() {
@@ -42,7 +38,7 @@ func TestProof(t *testing.T) {
 		if proof == nil {
 			t.Fatalf("missing key %x while constructing proof", kv.k)
 		}
-		val, err := verifyProof(root, kv.k, proof)
+		val, err := VerifyProof(root, kv.k, proof)
 		if err != nil {
 			t.Fatalf("VerifyProof error for key %x: %v\nraw proof: %x", kv.k, err, proof)
 		}
@@ -62,7 +58,7 @@ func TestOneElementProof(t *testing.T) {
 	if len(proof) != 1 {
 		t.Error("proof should have one element")
 	}
-	val, err := verifyProof(trie.Hash(), []byte("k"), proof)
+	val, err := VerifyProof(trie.Hash(), []byte("k"), proof)
 	if err != nil {
 		t.Fatalf("VerifyProof error: %v\nraw proof: %x", err, proof)
 	}
@@ -80,7 +76,7 @@ func TestVerifyBadProof(t *testing.T) {
 			t.Fatal("nil proof")
 		}
 		mutateByte(proof[mrand.Intn(len(proof))])
-		if _, err := verifyProof(root, kv.k, proof); err == nil {
+		if _, err := VerifyProof(root, kv.k, proof); err == nil {
 			t.Fatalf("expected proof to fail for key %x", kv.k)
 		}
 	}
@@ -137,66 +133,3 @@ func randBytes(n int) []byte {
 	crand.Read(r)
 	return r
 }
-
-// VerifyProof checks merkle proofs. The given proof must contain the
-// value for key in a trie with the given root hash. VerifyProof
-// returns an error if the proof contains invalid trie nodes or the
-// wrong value.
-func verifyProof(rootHash common.Hash, key []byte, proof []rlp.RawValue) (value []byte, err error) {
-	key = compactHexDecode(key)
-	sha := sha3.NewKeccak256()
-	wantHash := rootHash.Bytes()
-	for i, buf := range proof {
-		sha.Reset()
-		sha.Write(buf)
-		if !bytes.Equal(sha.Sum(nil), wantHash) {
-			return nil, fmt.Errorf("bad proof node %d: hash mismatch", i)
-		}
-		n, err := decodeNode(wantHash, buf)
-		if err != nil {
-			return nil, fmt.Errorf("bad proof node %d: %v", i, err)
-		}
-		keyrest, cld := get(n, key)
-		switch cld := cld.(type) {
-		case nil:
-			if i != len(proof)-1 {
-				return nil, fmt.Errorf("key mismatch at proof node %d", i)
-			} else {
-				// The trie doesn't contain the key.
-				return nil, nil
-			}
-		case hashNode:
-			key = keyrest
-			wantHash = cld
-		case valueNode:
-			if i != len(proof)-1 {
-				return nil, errors.New("additional nodes at end of proof")
-			}
-			return cld, nil
-		}
-	}
-	return nil, errors.New("unexpected end of proof")
-}
-
-func get(tn node, key []byte) ([]byte, node) {
-	for len(key) > 0 {
-		switch n := tn.(type) {
-		case *shortNode:
-			if len(key) < len(n.Key) || !bytes.Equal(n.Key, key[:len(n.Key)]) {
-				return nil, nil
-			}
-			tn = n.Val
-			key = key[len(n.Key):]
-		case *fullNode:
-			tn = n.Children[key[0]]
-			key = key[1:]
-		case hashNode:
-			return key, n
-		case nil:
-			return key, nil
-		default:
-			panic(fmt.Sprintf("%T: invalid node: %v", tn, tn))
-		}
-	}
-	return nil, tn.(valueNode)
-}
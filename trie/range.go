@@ -0,0 +1,380 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/crypto"
+	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/rlp"
+)
+
+var (
+	// ErrRangeOrder is returned by range proof verification if the keys of the
+	// range are not strictly increasing or start before the range origin.
+	ErrRangeOrder = errors.New("range keys out of order")
+
+	// ErrRangeGap is returned by range proof verification if the proof shows
+	// further entries in the trie, but the range contains none of them.
+	ErrRangeGap = errors.New("range misses entries")
+)
+
+// Positions of a subtrie relative to a key range.
+const (
+	rangeOutside  = iota // The subtrie contains no keys from the range
+	rangeInside          // The subtrie contains only keys from the range
+	rangeBoundary        // The subtrie is on the path of one of the range ends
+)
+
+// IterateFrom calls fn for every key/value pair of the trie in ascending key
+// order, starting at the first key not smaller than start. The iteration stops
+// when fn returns false. Subtries before start are skipped without resolving.
+func (t *Trie) IterateFrom(start []byte, fn func(key, value []byte) bool) error {
+	_, err := t.iterateFrom(t.root, nil, keyNibbles(start), fn)
+	return err
+}
+
+func (t *Trie) iterateFrom(n node, path, start []byte, fn func(key, value []byte) bool) (bool, error) {
+	switch n := n.(type) {
+	case nil:
+		return true, nil
+
+	case valueNode:
+		if bytes.Compare(path, start) < 0 {
+			return true, nil
+		}
+		return fn(decodeCompact(path), n), nil
+
+	case *shortNode:
+		full := concat(path, keyPath(n.Key)...)
+		if bytes.Compare(full, truncate(start, len(full))) < 0 {
+			return true, nil
+		}
+		return t.iterateFrom(n.Val, full, start, fn)
+
+	case *fullNode:
+		if bytes.Compare(path, truncate(start, len(path))) < 0 {
+			return true, nil
+		}
+		if cont, err := t.iterateFrom(n.Children[16], path, start, fn); !cont || err != nil {
+			return cont, err
+		}
+		for i := 0; i < 16; i++ {
+			if cont, err := t.iterateFrom(n.Children[i], concat(path, byte(i)), start, fn); !cont || err != nil {
+				return cont, err
+			}
+		}
+		return true, nil
+
+	case hashNode:
+		if bytes.Compare(path, truncate(start, len(path))) < 0 {
+			return true, nil
+		}
+		child, err := t.resolveHash(n, path, nil)
+		if err != nil {
+			return false, err
+		}
+		return t.iterateFrom(child, path, start, fn)
+
+	default:
+		panic(fmt.Sprintf("%T: invalid node: %v", n, n))
+	}
+}
+
+// VerifyRangeProof checks that the given keys and values are the complete and
+// ordered contents of the trie with the given root, between origin and the last
+// key of the range. The proof must contain the Merkle proofs of both origin and
+// the last key (or only origin if the range is empty). An empty proof asserts
+// that the range makes up the entire trie.
+//
+// If db is non-nil, all trie nodes whose subtries are fully covered by the range
+// are written into it. Nodes on the boundary paths are left out, since they also
+// reference data outside of the range. The optional incomplete callback can mark
+// further keys whose paths should be left out, e.g. leaves referencing data not
+// yet available locally.
+//
+// The returned flag reports whether the trie contains further keys after the
+// range.
+func VerifyRangeProof(root common.Hash, origin []byte, keys, values [][]byte, proof []rlp.RawValue, db DatabaseWriter, incomplete func(key []byte) bool) (bool, error) {
+	if len(keys) != len(values) {
+		return false, fmt.Errorf("range key/value count mismatch: %d != %d", len(keys), len(values))
+	}
+	for i, key := range keys {
+		if i > 0 && bytes.Compare(keys[i-1], key) >= 0 {
+			return false, ErrRangeOrder
+		}
+		if len(values[i]) == 0 {
+			return false, fmt.Errorf("empty value for range key %x", key)
+		}
+	}
+	if len(keys) > 0 && bytes.Compare(keys[0], origin) < 0 {
+		return false, ErrRangeOrder
+	}
+	// Without any proofs, the range must be the entire trie
+	if len(proof) == 0 {
+		tr := new(Trie)
+		for i, key := range keys {
+			tr.Update(key, values[i])
+		}
+		if hash := tr.Hash(); hash != root {
+			return false, fmt.Errorf("range root mismatch: have %x, want %x", hash, root)
+		}
+		if db != nil {
+			if err := storeRange(tr.root, db, incomplete); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+	// Otherwise rebuild the boundary paths from the proof, drop everything between
+	// them and check that the range fills the gap up to the original root.
+	proofDb, _ := ethdb.NewMemDatabase()
+	for i, blob := range proof {
+		hash := crypto.Keccak256(blob)
+		if _, err := decodeNode(hash, blob); err != nil {
+			return false, fmt.Errorf("bad proof node %d: %v", i, err)
+		}
+		proofDb.Put(hash, blob)
+	}
+	tr := &Trie{root: hashNode(root.Bytes()), db: proofDb, originalRoot: root}
+
+	left, right := keyNibbles(origin), keyNibbles(origin)
+	if len(keys) > 0 {
+		right = keyNibbles(keys[len(keys)-1])
+	}
+	n, err := tr.unsetRange(tr.root, nil, left, right)
+	if err != nil {
+		return false, err
+	}
+	tr.root = n
+	for i, key := range keys {
+		if err := tr.TryUpdate(key, values[i]); err != nil {
+			return false, err
+		}
+	}
+	if hash := tr.Hash(); hash != root {
+		return false, fmt.Errorf("range root mismatch: have %x, want %x", hash, root)
+	}
+	more, err := tr.hasRightElement(tr.root, nil, right)
+	if err != nil {
+		return false, err
+	}
+	if more && len(keys) == 0 {
+		return false, ErrRangeGap
+	}
+	if db != nil {
+		if err := storeRange(tr.root, db, incomplete); err != nil {
+			return false, err
+		}
+	}
+	return more, nil
+}
+
+// storeRange writes the fully resolved subtries of a hashed range trie into db.
+func storeRange(root node, db DatabaseWriter, incomplete func(key []byte) bool) error {
+	h := newHasher(0, 0)
+	defer returnHasherToPool(h)
+
+	_, err := storeComplete(h, root, nil, db, incomplete, true)
+	return err
+}
+
+// unsetRange removes all values with keys between left and right (inclusive)
+// from a partial trie, resolving the nodes along both boundary paths.
+func (t *Trie) unsetRange(n node, path, left, right []byte) (node, error) {
+	switch n := n.(type) {
+	case nil:
+		return nil, nil
+
+	case valueNode:
+		if bytes.Compare(path, left) >= 0 && bytes.Compare(path, right) <= 0 {
+			return nil, nil
+		}
+		return n, nil
+
+	case *shortNode:
+		full := concat(path, keyPath(n.Key)...)
+		switch rangePosition(full, left, right) {
+		case rangeInside:
+			return nil, nil
+		case rangeOutside:
+			return n, nil
+		}
+		child, err := t.unsetRange(n.Val, full, left, right)
+		if err != nil || child == nil {
+			return nil, err
+		}
+		return &shortNode{n.Key, child, t.newFlag()}, nil
+
+	case *fullNode:
+		switch rangePosition(path, left, right) {
+		case rangeInside:
+			return nil, nil
+		case rangeOutside:
+			return n, nil
+		}
+		nn := n.copy()
+		nn.flags = t.newFlag()
+
+		var err error
+		if nn.Children[16], err = t.unsetRange(n.Children[16], path, left, right); err != nil {
+			return nil, err
+		}
+		for i := 0; i < 16; i++ {
+			if nn.Children[i], err = t.unsetRange(n.Children[i], concat(path, byte(i)), left, right); err != nil {
+				return nil, err
+			}
+		}
+		return nn, nil
+
+	case hashNode:
+		switch rangePosition(path, left, right) {
+		case rangeInside:
+			return nil, nil
+		case rangeOutside:
+			return n, nil
+		}
+		child, err := t.resolveHash(n, path, nil)
+		if err != nil {
+			return nil, err
+		}
+		return t.unsetRange(child, path, left, right)
+
+	default:
+		panic(fmt.Sprintf("%T: invalid node: %v", n, n))
+	}
+}
+
+// hasRightElement reports whether the trie contains any key after right.
+func (t *Trie) hasRightElement(n node, path, right []byte) (bool, error) {
+	switch n := n.(type) {
+	case nil:
+		return false, nil
+
+	case valueNode:
+		return bytes.Compare(path, right) > 0, nil
+
+	case *shortNode:
+		full := concat(path, keyPath(n.Key)...)
+		if cmp := bytes.Compare(full, truncate(right, len(full))); cmp != 0 {
+			return cmp > 0, nil
+		}
+		return t.hasRightElement(n.Val, full, right)
+
+	case *fullNode:
+		if cmp := bytes.Compare(path, truncate(right, len(path))); cmp != 0 {
+			return cmp > 0, nil
+		}
+		for i := 15; i >= 0; i-- {
+			if more, err := t.hasRightElement(n.Children[i], concat(path, byte(i)), right); more || err != nil {
+				return more, err
+			}
+		}
+		return t.hasRightElement(n.Children[16], path, right)
+
+	case hashNode:
+		if cmp := bytes.Compare(path, truncate(right, len(path))); cmp != 0 {
+			return cmp > 0, nil
+		}
+		child, err := t.resolveHash(n, path, nil)
+		if err != nil {
+			return false, err
+		}
+		return t.hasRightElement(child, path, right)
+
+	default:
+		panic(fmt.Sprintf("%T: invalid node: %v", n, n))
+	}
+}
+
+// storeComplete writes every node of a hashed partial trie into db whose subtrie
+// is fully resolved and holds no incomplete keys, returning whether n itself was
+// complete.
+func storeComplete(h *hasher, n node, path []byte, db DatabaseWriter, incomplete func(key []byte) bool, force bool) (bool, error) {
+	complete := true
+	switch n := n.(type) {
+	case nil:
+		return true, nil
+	case valueNode:
+		return incomplete == nil || !incomplete(decodeCompact(path)), nil
+	case hashNode:
+		return false, nil
+	case *shortNode:
+		ok, err := storeComplete(h, n.Val, concat(path, keyPath(n.Key)...), db, incomplete, false)
+		if err != nil {
+			return false, err
+		}
+		complete = ok
+	case *fullNode:
+		for i, child := range n.Children[:16] {
+			ok, err := storeComplete(h, child, concat(path, byte(i)), db, incomplete, false)
+			if err != nil {
+				return false, err
+			}
+			complete = complete && ok
+		}
+	}
+	if !complete {
+		return false, nil
+	}
+	collapsed, _, err := h.hashChildren(n, nil)
+	if err != nil {
+		return false, err
+	}
+	_, err = h.store(collapsed, db, force)
+	return true, err
+}
+
+// rangePosition determines where the subtrie at the given path lies relative
+// to the key range between left and right.
+func rangePosition(path, left, right []byte) int {
+	cl := bytes.Compare(path, truncate(left, len(path)))
+	cr := bytes.Compare(path, truncate(right, len(path)))
+	switch {
+	case cl < 0 || cr > 0:
+		return rangeOutside
+	case cl > 0 && cr < 0:
+		return rangeInside
+	default:
+		return rangeBoundary
+	}
+}
+
+// keyNibbles converts a key into its nibble path, without the terminator.
+func keyNibbles(key []byte) []byte {
+	nibbles := compactHexDecode(key)
+	return nibbles[:len(nibbles)-1]
+}
+
+// keyPath strips the terminator from a short node key.
+func keyPath(key []byte) []byte {
+	if len(key) > 0 && hasTerm(key) {
+		return key[:len(key)-1]
+	}
+	return key
+}
+
+func truncate(b []byte, n int) []byte {
+	if len(b) > n {
+		return b[:n]
+	}
+	return b
+}
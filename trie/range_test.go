@@ -0,0 +1,283 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	mrand "math/rand"
+	"sort"
+	"testing"
+
+	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/ethdb"
+	"github.com/ethereumproject/go-ethereum/rlp"
+)
+
+// sortedRandomTrie creates a random trie with fixed length keys, returning the
+// trie and its contents in key order.
+func sortedRandomTrie(n int) (*Trie, []*kv) {
+	trie, vals := randomTrie(n)
+	entries := make([]*kv, 0, len(vals))
+	for _, kv := range vals {
+		entries = append(entries, kv)
+	}
+	sort.Sort(kvs(entries))
+	return trie, entries
+}
+
+type kvs []*kv
+
+func (s kvs) Len() int           { return len(s) }
+func (s kvs) Less(i, j int) bool { return bytes.Compare(s[i].k, s[j].k) < 0 }
+func (s kvs) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// rangeOf splits a subset of trie entries into keys and values.
+func rangeOf(entries []*kv) ([][]byte, [][]byte) {
+	keys, vals := make([][]byte, len(entries)), make([][]byte, len(entries))
+	for i, kv := range entries {
+		keys[i], vals[i] = kv.k, kv.v
+	}
+	return keys, vals
+}
+
+// rangeProof assembles the boundary proof of a range.
+func rangeProof(trie *Trie, origin, last []byte) []rlp.RawValue {
+	proof := trie.Prove(origin)
+	if last != nil {
+		proof = append(proof, trie.Prove(last)...)
+	}
+	return proof
+}
+
+func TestIterateFrom(t *testing.T) {
+	trie, entries := sortedRandomTrie(500)
+	for i := 0; i < 100; i++ {
+		start := randBytes(32)
+		if i%2 == 0 {
+			start = entries[mrand.Intn(len(entries))].k
+		}
+		want := sort.Search(len(entries), func(i int) bool { return bytes.Compare(entries[i].k, start) >= 0 })
+
+		var keys [][]byte
+		if err := trie.IterateFrom(start, func(key, value []byte) bool {
+			keys = append(keys, common.CopyBytes(key))
+			return len(keys) < 10
+		}); err != nil {
+			t.Fatalf("iteration failed: %v", err)
+		}
+		for j, key := range keys {
+			if !bytes.Equal(key, entries[want+j].k) {
+				t.Fatalf("start %x, key %d: have %x, want %x", start, j, key, entries[want+j].k)
+			}
+		}
+		if exp := len(entries) - want; len(keys) != 10 && len(keys) != exp {
+			t.Fatalf("start %x: iterated %d keys, want %d", start, len(keys), exp)
+		}
+	}
+}
+
+func TestRangeProof(t *testing.T) {
+	trie, entries := sortedRandomTrie(500)
+	root := trie.Hash()
+
+	for i := 0; i < 500; i++ {
+		start := mrand.Intn(len(entries))
+		end := start + 1 + mrand.Intn(len(entries)-start)
+
+		keys, vals := rangeOf(entries[start:end])
+		more, err := VerifyRangeProof(root, keys[0], keys, vals, rangeProof(trie, keys[0], keys[len(keys)-1]), nil, nil)
+		if err != nil {
+			t.Fatalf("range [%d, %d): verification failed: %v", start, end, err)
+		}
+		if more != (end < len(entries)) {
+			t.Fatalf("range [%d, %d): continuation mismatch: have %v", start, end, more)
+		}
+	}
+}
+
+func TestRangeProofNonExistentOrigin(t *testing.T) {
+	trie, entries := sortedRandomTrie(500)
+	root := trie.Hash()
+
+	for i := 0; i < 100; i++ {
+		origin := randBytes(32)
+		start := sort.Search(len(entries), func(i int) bool { return bytes.Compare(entries[i].k, origin) >= 0 })
+		if start == len(entries) {
+			continue
+		}
+		end := start + 1 + mrand.Intn(len(entries)-start)
+
+		keys, vals := rangeOf(entries[start:end])
+		if _, err := VerifyRangeProof(root, origin, keys, vals, rangeProof(trie, origin, keys[len(keys)-1]), nil, nil); err != nil {
+			t.Fatalf("range from %x: verification failed: %v", origin, err)
+		}
+	}
+}
+
+func TestRangeProofEmpty(t *testing.T) {
+	trie, entries := sortedRandomTrie(100)
+	root := trie.Hash()
+
+	// An empty range after the last key is valid
+	origin := common.CopyBytes(entries[len(entries)-1].k)
+	origin[len(origin)-1]++
+	if origin[len(origin)-1] == 0 {
+		t.Skip("last key not incrementable")
+	}
+	if more, err := VerifyRangeProof(root, origin, nil, nil, rangeProof(trie, origin, nil), nil, nil); err != nil || more {
+		t.Fatalf("empty tail range: more %v, err %v", more, err)
+	}
+	// An empty range hiding entries must be rejected
+	origin = entries[len(entries)/2].k
+	if _, err := VerifyRangeProof(root, origin, nil, nil, rangeProof(trie, origin, nil), nil, nil); err == nil {
+		t.Fatalf("empty range hiding entries accepted")
+	}
+}
+
+func TestRangeProofWholeTrie(t *testing.T) {
+	trie, entries := sortedRandomTrie(100)
+	keys, vals := rangeOf(entries)
+
+	if more, err := VerifyRangeProof(trie.Hash(), nil, keys, vals, nil, nil, nil); err != nil || more {
+		t.Fatalf("whole trie: more %v, err %v", more, err)
+	}
+	if _, err := VerifyRangeProof(trie.Hash(), nil, keys[1:], vals[1:], nil, nil, nil); err == nil {
+		t.Fatalf("partial trie accepted without proof")
+	}
+}
+
+func TestBadRangeProof(t *testing.T) {
+	trie, entries := sortedRandomTrie(500)
+	root := trie.Hash()
+
+	for i := 0; i < 100; i++ {
+		start := mrand.Intn(len(entries) - 3)
+		end := start + 3 + mrand.Intn(len(entries)-start-3)
+
+		keys, vals := rangeOf(entries[start:end])
+		proof := rangeProof(trie, keys[0], keys[len(keys)-1])
+
+		switch mrand.Intn(4) {
+		case 0: // Drop an entry from the middle
+			idx := 1 + mrand.Intn(len(keys)-2)
+			keys = append(keys[:idx:idx], keys[idx+1:]...)
+			vals = append(vals[:idx:idx], vals[idx+1:]...)
+		case 1: // Modify a value
+			idx := mrand.Intn(len(keys))
+			vals = append(vals[:idx:idx], append([][]byte{randBytes(20)}, vals[idx+1:]...)...)
+		case 2: // Inject a new entry
+			key := common.CopyBytes(keys[0])
+			key[len(key)-1] ^= 0x01
+			if bytes.Compare(key, keys[0]) < 0 || bytes.Compare(key, keys[1]) >= 0 {
+				continue
+			}
+			keys = append([][]byte{keys[0], key}, keys[1:]...)
+			vals = append([][]byte{vals[0], randBytes(20)}, vals[1:]...)
+		case 3: // Swap two entries
+			keys[0], keys[1] = keys[1], keys[0]
+		}
+		if _, err := VerifyRangeProof(root, keys[0], keys, vals, proof, nil, nil); err == nil {
+			t.Fatalf("range [%d, %d): tampered range accepted", start, end)
+		}
+	}
+}
+
+// Tests that the nodes stored from a sequence of verified ranges form the whole
+// trie, apart from the boundary nodes which are left to be healed.
+func TestRangeProofStore(t *testing.T) {
+	trie, entries := sortedRandomTrie(1000)
+	root := trie.Hash()
+
+	db, _ := ethdb.NewMemDatabase()
+	for start := 0; start < len(entries); start += 100 {
+		end := start + 100
+		if end > len(entries) {
+			end = len(entries)
+		}
+		keys, vals := rangeOf(entries[start:end])
+		if _, err := VerifyRangeProof(root, keys[0], keys, vals, rangeProof(trie, keys[0], keys[len(keys)-1]), db, nil); err != nil {
+			t.Fatalf("range from %d: verification failed: %v", start, err)
+		}
+	}
+	if blob, _ := db.Get(root[:]); blob != nil {
+		t.Fatalf("boundary root node stored")
+	}
+	// Heal the missing boundary nodes from a complete copy of the trie
+	srcDb, _ := ethdb.NewMemDatabase()
+	trie.CommitTo(srcDb)
+
+	sched := NewTrieSync(root, db, nil)
+	healed := 0
+	for queue := sched.Missing(0); len(queue) > 0; queue = sched.Missing(0) {
+		results := make([]SyncResult, len(queue))
+		for i, hash := range queue {
+			data, err := srcDb.Get(hash[:])
+			if err != nil {
+				t.Fatalf("failed to retrieve node data for %x: %v", hash, err)
+			}
+			results[i] = SyncResult{hash, data}
+		}
+		if _, err := sched.Process(results); err != nil {
+			t.Fatalf("failed to process results: %v", err)
+		}
+		healed += len(queue)
+	}
+	if total := len(srcDb.Keys()); healed*4 > total {
+		t.Errorf("healed too many nodes: %d out of %d", healed, total)
+	}
+	healedTrie, err := New(root, db)
+	if err != nil {
+		t.Fatalf("failed to open healed trie: %v", err)
+	}
+	for _, kv := range entries {
+		if val := healedTrie.Get(kv.k); !bytes.Equal(val, kv.v) {
+			t.Fatalf("key %x: value mismatch: have %x, want %x", kv.k, val, kv.v)
+		}
+	}
+}
+
+// Tests that nodes on the paths of keys flagged incomplete are not stored, even
+// if the range covers their subtries entirely.
+func TestRangeProofStoreIncomplete(t *testing.T) {
+	trie, entries := sortedRandomTrie(100)
+	root := trie.Hash()
+
+	keys, vals := rangeOf(entries)
+	skipped := keys[len(keys)/2]
+
+	db, _ := ethdb.NewMemDatabase()
+	incomplete := func(key []byte) bool { return bytes.Equal(key, skipped) }
+	if _, err := VerifyRangeProof(root, nil, keys, vals, nil, db, incomplete); err != nil {
+		t.Fatalf("verification failed: %v", err)
+	}
+	if blob, _ := db.Get(root[:]); blob != nil {
+		t.Fatalf("root node with incomplete key stored")
+	}
+	srcDb, _ := ethdb.NewMemDatabase()
+	trie.CommitTo(srcDb)
+
+	stored := len(db.Keys())
+	if total := len(srcDb.Keys()); stored == 0 || stored >= total {
+		t.Fatalf("stored node count mismatch: have %d, want between 0 and %d", stored, total)
+	}
+	for _, key := range db.Keys() {
+		want, _ := srcDb.Get(key)
+		if have, _ := db.Get(key); !bytes.Equal(have, want) {
+			t.Fatalf("node %x mismatch: have %x, want %x", key, have, want)
+		}
+	}
+}
@@ -143,3 +143,28 @@ func TestSecureTrieConcurrency(t *testing.T) {
 	// Wait for all threads to finish
 	pend.Wait()
 }
+
+// Tests that proofs of a secure trie verify against the hashed keys.
+func TestSecureProof(t *testing.T) {
+	_, trie, content := makeTestSecureTrie()
+	root := trie.Hash()
+	for key, val := range content {
+		proof := trie.Prove([]byte(key))
+		if proof == nil {
+			t.Fatalf("missing key %x while constructing proof", key)
+		}
+		have, err := VerifyProof(root, crypto.Keccak256([]byte(key)), proof)
+		if err != nil {
+			t.Fatalf("proof of key %x failed to verify: %v", key, err)
+		}
+		if !bytes.Equal(have, val) {
+			t.Fatalf("proof of key %x has wrong value: have %x, want %x", key, have, val)
+		}
+	}
+	// Proofs of absent keys verify to no value
+	missing := []byte("missing")
+	have, err := VerifyProof(root, crypto.Keccak256(missing), trie.Prove(missing))
+	if err != nil || have != nil {
+		t.Fatalf("proof of absence: have %x, %v", have, err)
+	}
+}
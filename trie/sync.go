@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/crypto"
 	"github.com/ethereumproject/go-ethereum/ethdb"
 	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
 )
@@ -187,6 +188,54 @@ func (s *TrieSync) Pending() int {
 	return len(s.requests)
 }
 
+// Uncommitted retrieves the data of all the nodes already retrieved but still
+// waiting for their subtries to complete before being stored. Together with the
+// database contents they make up the entire progress of the sync.
+func (s *TrieSync) Uncommitted() [][]byte {
+	nodes := make([][]byte, 0, len(s.requests))
+	for _, req := range s.requests {
+		if req.data != nil {
+			nodes = append(nodes, req.data)
+		}
+	}
+	return nodes
+}
+
+// Restore injects the uncommitted nodes of an earlier sync of the same trie into
+// the scheduler, rebuilding the set of pending requests without retrieving them
+// again. Nodes not belonging to the trie are ignored. The number of restored
+// nodes is returned.
+func (s *TrieSync) Restore(nodes [][]byte) (int, error) {
+	blobs := make(map[common.Hash][]byte, len(nodes))
+	for _, blob := range nodes {
+		blobs[crypto.Keccak256Hash(blob)] = blob
+	}
+	// Feed the nodes top down, each enabling the requests for its children
+	restored := 0
+	for progress := true; progress; {
+		progress = false
+		for hash, blob := range blobs {
+			if req := s.requests[hash]; req == nil || req.data != nil {
+				continue
+			}
+			if _, err := s.Process([]SyncResult{{Hash: hash, Data: blob}}); err != nil {
+				return restored, err
+			}
+			delete(blobs, hash)
+			restored++
+			progress = true
+		}
+	}
+	// Drop the restored nodes from the retrieval queue
+	s.queue.Reset()
+	for hash, req := range s.requests {
+		if req.data == nil {
+			s.queue.Push(hash, float32(req.depth))
+		}
+	}
+	return restored, nil
+}
+
 // schedule inserts a new state retrieval request into the fetch queue. If there
 // is already a pending request for this node, the new request will be discarded
 // and only a parent reference added to the old one.
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/ethereumproject/go-ethereum/common"
+	"github.com/ethereumproject/go-ethereum/crypto"
 	"github.com/ethereumproject/go-ethereum/ethdb"
 )
 
@@ -331,3 +332,63 @@ func TestIncompleteTrieSync(t *testing.T) {
 		dstDb.Put(key, value)
 	}
 }
+
+// Tests that the uncommitted progress of an interrupted sync can be restored
+// into a new scheduler, which completes the trie without retrieving any of the
+// restored nodes again.
+func TestRestoredTrieSync(t *testing.T) {
+	// Create a random trie to copy
+	srcDb, srcTrie, srcData := makeTestTrie()
+	root := common.BytesToHash(srcTrie.Root())
+
+	// Sync a part of the trie, leaving some requests in flight
+	dstDb, _ := ethdb.NewMemDatabase()
+	sched := NewTrieSync(root, dstDb, nil)
+
+	for i := 0; i < 3; i++ {
+		queue := sched.Missing(10)
+		results := make([]SyncResult, len(queue)/2+1)
+		for j, hash := range queue[:len(results)] {
+			data, _ := srcDb.Get(hash.Bytes())
+			results[j] = SyncResult{hash, data}
+		}
+		if index, err := sched.Process(results); err != nil {
+			t.Fatalf("failed to process result #%d: %v", index, err)
+		}
+	}
+	nodes := sched.Uncommitted()
+	if len(nodes) == 0 {
+		t.Fatalf("no uncommitted nodes to restore")
+	}
+	pending := sched.Pending()
+
+	// Restore the progress into a new scheduler and finish the sync
+	sched = NewTrieSync(root, dstDb, nil)
+	if restored, err := sched.Restore(nodes); err != nil || restored != len(nodes) {
+		t.Fatalf("restore mismatch: have %d/%v, want %d/nil", restored, err, len(nodes))
+	}
+	if have := sched.Pending(); have != pending {
+		t.Fatalf("pending requests mismatch: have %d, want %d", have, pending)
+	}
+	restored := make(map[common.Hash]struct{})
+	for _, blob := range nodes {
+		restored[crypto.Keccak256Hash(blob)] = struct{}{}
+	}
+	for queue := sched.Missing(100); len(queue) > 0; queue = sched.Missing(100) {
+		results := make([]SyncResult, len(queue))
+		for i, hash := range queue {
+			if _, ok := restored[hash]; ok {
+				t.Fatalf("restored node %x requested again", hash)
+			}
+			data, err := srcDb.Get(hash.Bytes())
+			if err != nil {
+				t.Fatalf("failed to retrieve node data for %x: %v", hash, err)
+			}
+			results[i] = SyncResult{hash, data}
+		}
+		if index, err := sched.Process(results); err != nil {
+			t.Fatalf("failed to process result #%d: %v", index, err)
+		}
+	}
+	checkTrieContents(t, dstDb, srcTrie.Root(), srcData)
+}